@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// AdminService manages which users are admins. Admins can answer any
+// suggested course edit and are sent the suggestions nobody else answers.
+type AdminService struct {
+	db *gorm.DB
+}
+
+func NewAdminService() *AdminService {
+	return &AdminService{
+		db: GetDB(),
+	}
+}
+
+// IsAdmin reports whether a user is an admin
+func (s *AdminService) IsAdmin(userID uint) (bool, error) {
+	if s.db == nil {
+		return false, fmt.Errorf("database not connected")
+	}
+
+	var count int64
+	if err := s.db.Model(&User{}).Where("id = ? AND is_admin = ?", userID, true).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check admin: %v", err)
+	}
+	return count > 0, nil
+}
+
+// ListAdmins returns every admin, oldest account first
+func (s *AdminService) ListAdmins() ([]User, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var admins []User
+	if err := s.db.Where("is_admin = ?", true).Order("id").Find(&admins).Error; err != nil {
+		return nil, fmt.Errorf("failed to list admins: %v", err)
+	}
+	return admins, nil
+}
+
+// SetAdmin grants or revokes admin for the user with the given ID or email
+// address and returns the user
+func (s *AdminService) SetAdmin(user string, admin bool) (*User, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	query := s.db.Where("email = ?", strings.TrimSpace(user))
+	if id, err := strconv.ParseUint(user, 10, 32); err == nil {
+		query = s.db.Where("id = ?", id)
+	}
+	var found User
+	if err := query.First(&found).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to find user: %v", err)
+	}
+
+	if err := s.db.Model(&found).Update("is_admin", admin).Error; err != nil {
+		return nil, fmt.Errorf("failed to update user: %v", err)
+	}
+	found.IsAdmin = admin
+	log.Printf("[ADMIN] User %d admin set to %t", found.ID, admin)
+	return &found, nil
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	ErrCommentNotFound       = errors.New("comment not found")
+	ErrCommentReviewNotFound = errors.New("review not found")
+	ErrCommentForbidden      = errors.New("not allowed to change this comment")
+)
+
+// CommentHandler handles review comment API endpoints
+type CommentHandler struct {
+	dbService CommentDatabaseServiceInterface
+}
+
+// CommentCreateRequest represents a new comment or reply on a review
+type CommentCreateRequest struct {
+	Body     string `json:"body" validate:"required,max=2000"`
+	ParentID *uint  `json:"parent_id,omitempty"` // Set to reply to a top-level comment
+}
+
+// CommentUpdateRequest represents an edit to an existing comment
+type CommentUpdateRequest struct {
+	Body string `json:"body" validate:"required,max=2000"`
+}
+
+// CommentModerationRequest represents a course owner's moderation decision
+type CommentModerationRequest struct {
+	Status string `json:"status" validate:"required,oneof=visible pending hidden"`
+}
+
+// CommentResponse represents comment data for API responses
+type CommentResponse struct {
+	ID              uint               `json:"id"`
+	ReviewID        uint               `json:"review_id"`
+	ParentID        *uint              `json:"parent_id"`
+	UserID          uint               `json:"user_id"`
+	AuthorName      string             `json:"author_name"`
+	Body            string             `json:"body"`
+	IsOwnerResponse bool               `json:"is_owner_response"`
+	Status          string             `json:"status"` // "visible", "pending", "hidden"
+	ModerationNote  *string            `json:"moderation_note,omitempty"`
+	EditedAt        *int64             `json:"edited_at"`
+	CreatedAt       int64              `json:"created_at"`
+	UpdatedAt       int64              `json:"updated_at"`
+	CanEdit         bool               `json:"can_edit"`
+	CanModerate     bool               `json:"can_moderate"`
+	Replies         []*CommentResponse `json:"replies,omitempty"` // Only set on top-level comments
+}
+
+// NewCommentHandler creates a new comment handler
+func NewCommentHandler(dbService CommentDatabaseServiceInterface) *CommentHandler {
+	return &CommentHandler{
+		dbService: dbService,
+	}
+}
+
+// GetReviewComments returns paginated top-level comments for a review, each with its replies
+func (h *CommentHandler) GetReviewComments(c echo.Context) error {
+	reviewID, err := parseReviewIDParam(c)
+	if err != nil {
+		return BadRequestError(c, "Invalid review ID")
+	}
+
+	pagination := GetPagination(c)
+
+	// Get user ID if authenticated
+	var userID *uint
+	if uid, err := GetUserID(c); err == nil {
+		userID = &uid
+	}
+
+	visible, err := h.dbService.CanViewReview(reviewID, userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to verify review")
+	}
+	if !visible {
+		return NotFoundError(c, "Review")
+	}
+
+	comments, total, err := h.dbService.GetReviewComments(reviewID, userID, pagination.Page, pagination.PerPage)
+	if err != nil {
+		return commentError(c, err, "Failed to retrieve comments")
+	}
+
+	meta := &APIMeta{
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+		Total:      total,
+		TotalPages: (total + pagination.PerPage - 1) / pagination.PerPage,
+	}
+
+	return SuccessResponseWithMeta(c, comments, meta)
+}
+
+// CreateComment adds a comment or a reply to a review
+func (h *CommentHandler) CreateComment(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	reviewID, err := parseReviewIDParam(c)
+	if err != nil {
+		return BadRequestError(c, "Invalid review ID")
+	}
+
+	var req CommentCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+
+	if validationErrors := validateCommentBody(req.Body); len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+
+	visible, err := h.dbService.CanViewReview(reviewID, &userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to verify review")
+	}
+	if !visible {
+		return NotFoundError(c, "Review")
+	}
+
+	// Only one level of threading: replies must target a top-level comment on the same review
+	if req.ParentID != nil {
+		parent, err := h.dbService.GetReviewComment(*req.ParentID)
+		if err != nil {
+			return InternalServerError(c, "Failed to verify parent comment")
+		}
+		if parent == nil || parent.ReviewID != reviewID {
+			return NotFoundError(c, "Parent comment")
+		}
+		if parent.ParentID != nil {
+			return BadRequestError(c, "Replies can only be added to top-level comments")
+		}
+	}
+
+	comment, err := h.dbService.CreateReviewComment(userID, reviewID, &req)
+	if err != nil {
+		return commentError(c, err, "Failed to create comment")
+	}
+
+	return CreatedResponse(c, comment)
+}
+
+// UpdateComment edits a comment owned by the authenticated user
+func (h *CommentHandler) UpdateComment(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	comment, err := h.loadComment(c)
+	if err != nil || comment == nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		return ForbiddenError(c, "You can only edit your own comments")
+	}
+
+	var req CommentUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+
+	if validationErrors := validateCommentBody(req.Body); len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+
+	updated, err := h.dbService.UpdateReviewComment(userID, comment.ID, &req)
+	if err != nil {
+		return commentError(c, err, "Failed to update comment")
+	}
+
+	return SuccessResponse(c, updated)
+}
+
+// DeleteComment deletes a comment and its replies. Allowed for the author and the course owner.
+func (h *CommentHandler) DeleteComment(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	comment, err := h.loadComment(c)
+	if err != nil || comment == nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		canModerate, err := h.dbService.CanModerateReviewComments(userID, comment.ReviewID)
+		if err != nil {
+			return commentError(c, err, "Failed to check permissions")
+		}
+		if !canModerate {
+			return ForbiddenError(c, "You can only delete your own comments")
+		}
+	}
+
+	if err := h.dbService.DeleteReviewComment(userID, comment.ID); err != nil {
+		return commentError(c, err, "Failed to delete comment")
+	}
+
+	return NoContentResponse(c)
+}
+
+// ModerateComment lets the course owner approve, hold or hide a comment
+func (h *CommentHandler) ModerateComment(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	comment, err := h.loadComment(c)
+	if err != nil || comment == nil {
+		return err
+	}
+
+	var req CommentModerationRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+
+	validStatuses := []string{"visible", "pending", "hidden"}
+	if !contains(validStatuses, req.Status) {
+		return ValidationError(c, map[string]string{
+			"status": "Status must be 'visible', 'pending' or 'hidden'",
+		})
+	}
+
+	canModerate, err := h.dbService.CanModerateReviewComments(userID, comment.ReviewID)
+	if err != nil {
+		return commentError(c, err, "Failed to check permissions")
+	}
+	if !canModerate {
+		return ForbiddenError(c, "Only the course owner can moderate comments")
+	}
+
+	updated, err := h.dbService.SetReviewCommentStatus(userID, comment.ID, req.Status)
+	if err != nil {
+		return commentError(c, err, "Failed to moderate comment")
+	}
+
+	return SuccessResponse(c, updated)
+}
+
+// commentError writes the response for an error from the comment service,
+// which may be one of the ErrComment errors
+func commentError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, ErrCommentNotFound):
+		return NotFoundError(c, "Comment")
+	case errors.Is(err, ErrCommentReviewNotFound):
+		return NotFoundError(c, "Review")
+	case errors.Is(err, ErrCommentForbidden):
+		return ForbiddenError(c, "You don't have permission to change this comment")
+	}
+	return InternalServerError(c, message)
+}
+
+// loadComment resolves the :commentId parameter and checks it belongs to the :id review.
+// On failure it writes the error response and returns a nil comment.
+func (h *CommentHandler) loadComment(c echo.Context) (*CommentResponse, error) {
+	reviewID, err := parseReviewIDParam(c)
+	if err != nil {
+		return nil, BadRequestError(c, "Invalid review ID")
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	if err != nil {
+		return nil, BadRequestError(c, "Invalid comment ID")
+	}
+
+	comment, err := h.dbService.GetReviewComment(uint(commentID))
+	if err != nil {
+		return nil, InternalServerError(c, "Failed to retrieve comment")
+	}
+	if comment == nil || comment.ReviewID != reviewID {
+		return nil, NotFoundError(c, "Comment")
+	}
+
+	return comment, nil
+}
+
+// RegisterRoutes registers review comment routes
+func (h *CommentHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Public routes (optionally authenticated so authors see their held comments)
+	g.GET("/reviews/:id/comments", h.GetReviewComments, OptionalJWTMiddleware(jwtService))
+
+	// Protected routes (authentication required)
+	g.POST("/reviews/:id/comments", h.CreateComment, JWTMiddleware(jwtService))
+	g.PUT("/reviews/:id/comments/:commentId", h.UpdateComment, JWTMiddleware(jwtService))
+	g.DELETE("/reviews/:id/comments/:commentId", h.DeleteComment, JWTMiddleware(jwtService))
+	g.POST("/reviews/:id/comments/:commentId/moderate", h.ModerateComment, JWTMiddleware(jwtService))
+}
+
+func parseReviewIDParam(c echo.Context) (uint, error) {
+	reviewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(reviewID), nil
+}
+
+func validateCommentBody(body string) map[string]string {
+	validationErrors := make(map[string]string)
+	trimmed := strings.TrimSpace(body)
+	if trimmed == "" {
+		validationErrors["body"] = "Comment body is required"
+	} else if len(trimmed) > 2000 {
+		validationErrors["body"] = "Comment must be 2000 characters or less"
+	}
+	return validationErrors
+}
+
+// Database interface for review comment operations. Failures for a missing
+// comment or review, or a change the user may not make, are the ErrComment errors.
+type CommentDatabaseServiceInterface interface {
+	CanViewReview(reviewID uint, userID *uint) (bool, error)   // False for missing reviews and other users' private reviews
+	GetReviewComment(commentID uint) (*CommentResponse, error) // Nil when the comment doesn't exist; the author isn't masked
+	GetReviewComments(reviewID uint, userID *uint, page, perPage int) ([]*CommentResponse, int, error)
+	CreateReviewComment(userID, reviewID uint, req *CommentCreateRequest) (*CommentResponse, error)
+	UpdateReviewComment(userID, commentID uint, req *CommentUpdateRequest) (*CommentResponse, error)
+	DeleteReviewComment(userID, commentID uint) error
+	CanModerateReviewComments(userID, reviewID uint) (bool, error)
+	SetReviewCommentStatus(userID, commentID uint, status string) (*CommentResponse, error)
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_ReviewComments_List(t *testing.T) {
+	e, mockDB, _, _ := setupAPITest(t)
+
+	comments := []*CommentResponse{
+		{
+			ID:       1,
+			ReviewID: 7,
+			UserID:   5,
+			Body:     "Agreed, the greens were fast",
+			Status:   "visible",
+			Replies: []*CommentResponse{
+				{ID: 2, ReviewID: 7, ParentID: uintPtr(1), UserID: 9, Body: "Thanks for playing!", IsOwnerResponse: true, Status: "visible"},
+			},
+		},
+	}
+
+	mockDB.On("CanViewReview", uint(7), (*uint)(nil)).Return(true, nil)
+	mockDB.On("GetReviewComments", uint(7), (*uint)(nil), 1, 20).Return(comments, 1, nil)
+
+	rec := serveJSON(e, http.MethodGet, "/api/v1/reviews/7/comments", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response APIResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	require.NotNil(t, response.Meta)
+	assert.Equal(t, 1, response.Meta.Total)
+
+	data := response.Data.([]interface{})
+	require.Len(t, data, 1)
+	replies := data[0].(map[string]interface{})["replies"].([]interface{})
+	assert.Equal(t, true, replies[0].(map[string]interface{})["is_owner_response"])
+}
+
+func TestAPI_ReviewComments_ReviewNotFound(t *testing.T) {
+	e, mockDB, _, _ := setupAPITest(t)
+
+	mockDB.On("CanViewReview", uint(99), (*uint)(nil)).Return(false, nil)
+
+	rec := serveJSON(e, http.MethodGet, "/api/v1/reviews/99/comments", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAPI_ReviewComments_Create(t *testing.T) {
+	t.Run("TopLevelComment", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		commentReq := CommentCreateRequest{Body: "Great write-up"}
+		created := &CommentResponse{ID: 10, ReviewID: 7, UserID: user.ID, Body: commentReq.Body, Status: "visible", CanEdit: true}
+
+		mockDB.On("CanViewReview", uint(7), mock.Anything).Return(true, nil)
+		mockDB.On("CreateReviewComment", user.ID, uint(7), &commentReq).Return(created, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/reviews/7/comments", token, commentReq)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("ReplyToReplyRejected", func(t *testing.T) {
+		e, mockDB, _, token := setupAPITest(t)
+
+		mockDB.On("CanViewReview", uint(7), mock.Anything).Return(true, nil)
+		mockDB.On("GetReviewComment", uint(2)).Return(&CommentResponse{ID: 2, ReviewID: 7, ParentID: uintPtr(1)}, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/reviews/7/comments", token, CommentCreateRequest{Body: "Nested too deep", ParentID: uintPtr(2)})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockDB.AssertNotCalled(t, "CreateReviewComment", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ParentOnDifferentReview", func(t *testing.T) {
+		e, mockDB, _, token := setupAPITest(t)
+
+		mockDB.On("CanViewReview", uint(7), mock.Anything).Return(true, nil)
+		mockDB.On("GetReviewComment", uint(1)).Return(&CommentResponse{ID: 1, ReviewID: 8}, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/reviews/7/comments", token, CommentCreateRequest{Body: "Wrong thread", ParentID: uintPtr(1)})
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("EmptyBody", func(t *testing.T) {
+		e, mockDB, _, token := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/reviews/7/comments", token, CommentCreateRequest{Body: "   "})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "VAL_001")
+		mockDB.AssertNotCalled(t, "CanViewReview", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RequiresAuthentication", func(t *testing.T) {
+		e, _, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/reviews/7/comments", "", CommentCreateRequest{Body: "Anonymous"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("ReviewRemovedMeanwhile", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		req := CommentCreateRequest{Body: "Too late"}
+		mockDB.On("CanViewReview", uint(7), mock.Anything).Return(true, nil)
+		mockDB.On("CreateReviewComment", user.ID, uint(7), &req).Return((*CommentResponse)(nil), ErrCommentReviewNotFound)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/reviews/7/comments", token, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestAPI_ReviewComments_EditAndDelete(t *testing.T) {
+	t.Run("EditOwnComment", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		updateReq := CommentUpdateRequest{Body: "Edited"}
+		mockDB.On("GetReviewComment", uint(3)).Return(&CommentResponse{ID: 3, ReviewID: 7, UserID: user.ID, Body: "Original"}, nil)
+		mockDB.On("UpdateReviewComment", user.ID, uint(3), &updateReq).Return(&CommentResponse{ID: 3, ReviewID: 7, UserID: user.ID, Body: "Edited"}, nil)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/reviews/7/comments/3", token, updateReq)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("EditOtherCommentForbidden", func(t *testing.T) {
+		e, mockDB, _, token := setupAPITest(t)
+
+		mockDB.On("GetReviewComment", uint(4)).Return(&CommentResponse{ID: 4, ReviewID: 7, UserID: 999}, nil)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/reviews/7/comments/4", token, CommentUpdateRequest{Body: "Hijack"})
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("CommentOnDifferentReview", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetReviewComment", uint(3)).Return(&CommentResponse{ID: 3, ReviewID: 7, UserID: user.ID}, nil)
+
+		rec := serveJSON(e, http.MethodDelete, "/api/v1/reviews/8/comments/3", token, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("NonOwnerCannotDelete", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetReviewComment", uint(4)).Return(&CommentResponse{ID: 4, ReviewID: 7, UserID: 999}, nil)
+		mockDB.On("CanModerateReviewComments", user.ID, uint(7)).Return(false, nil)
+
+		rec := serveJSON(e, http.MethodDelete, "/api/v1/reviews/7/comments/4", token, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		mockDB.AssertNotCalled(t, "DeleteReviewComment", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ServiceErrorsKeepTheirStatus", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		updateReq := CommentUpdateRequest{Body: "Edited"}
+		mockDB.On("GetReviewComment", uint(3)).Return(&CommentResponse{ID: 3, ReviewID: 7, UserID: user.ID}, nil)
+		mockDB.On("UpdateReviewComment", user.ID, uint(3), &updateReq).Return((*CommentResponse)(nil), ErrCommentNotFound)
+		rec := serveJSON(e, http.MethodPut, "/api/v1/reviews/7/comments/3", token, updateReq)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		e, mockDB, user, token = setupAPITest(t)
+		mockDB.On("GetReviewComment", uint(3)).Return(&CommentResponse{ID: 3, ReviewID: 7, UserID: user.ID}, nil)
+		mockDB.On("DeleteReviewComment", user.ID, uint(3)).Return(ErrCommentForbidden)
+		rec = serveJSON(e, http.MethodDelete, "/api/v1/reviews/7/comments/3", token, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("CourseOwnerCanDelete", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetReviewComment", uint(4)).Return(&CommentResponse{ID: 4, ReviewID: 7, UserID: 999}, nil)
+		mockDB.On("CanModerateReviewComments", user.ID, uint(7)).Return(true, nil)
+		mockDB.On("DeleteReviewComment", user.ID, uint(4)).Return(nil)
+
+		rec := serveJSON(e, http.MethodDelete, "/api/v1/reviews/7/comments/4", token, nil)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockDB.AssertCalled(t, "DeleteReviewComment", user.ID, uint(4))
+	})
+}
+
+func TestAPI_ReviewComments_Moderate(t *testing.T) {
+	t.Run("CourseOwner", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetReviewComment", uint(4)).Return(&CommentResponse{ID: 4, ReviewID: 7, UserID: 999}, nil)
+		mockDB.On("CanModerateReviewComments", user.ID, uint(7)).Return(true, nil)
+		mockDB.On("SetReviewCommentStatus", user.ID, uint(4), "hidden").Return(&CommentResponse{ID: 4, ReviewID: 7, UserID: 999, Status: "hidden"}, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/reviews/7/comments/4/moderate", token, CommentModerationRequest{Status: "hidden"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("NotCourseOwner", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetReviewComment", uint(4)).Return(&CommentResponse{ID: 4, ReviewID: 7, UserID: 999}, nil)
+		mockDB.On("CanModerateReviewComments", user.ID, uint(7)).Return(false, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/reviews/7/comments/4/moderate", token, CommentModerationRequest{Status: "hidden"})
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		mockDB.AssertNotCalled(t, "SetReviewCommentStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("InvalidStatus", func(t *testing.T) {
+		e, mockDB, _, token := setupAPITest(t)
+
+		mockDB.On("GetReviewComment", uint(4)).Return(&CommentResponse{ID: 4, ReviewID: 7, UserID: 999}, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/reviews/7/comments/4/moderate", token, CommentModerationRequest{Status: "deleted"})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ConditionsHandler handles course conditions report API endpoints
+type ConditionsHandler struct {
+	dbService ConditionsDatabaseServiceInterface
+}
+
+// ConditionsReportRequest represents a new conditions report for a course
+type ConditionsReportRequest struct {
+	Greens        string `json:"greens" validate:"omitempty,oneof=excellent good fair poor"`
+	Fairways      string `json:"fairways" validate:"omitempty,oneof=excellent good fair poor"`
+	Bunkers       string `json:"bunkers" validate:"omitempty,oneof=excellent good fair poor"`
+	PaceOfPlay    string `json:"pace_of_play" validate:"omitempty,oneof=fast average slow"`
+	CartPathOnly  bool   `json:"cart_path_only"`
+	GreensAerated bool   `json:"greens_aerated"`
+	FrostDelay    bool   `json:"frost_delay"`
+	Note          string `json:"note" validate:"max=500"`
+	ValidHours    int    `json:"valid_hours" validate:"omitempty,min=1,max=168"` // Defaults to 72
+}
+
+// ConditionsReportResponse represents a single conditions report
+type ConditionsReportResponse struct {
+	ID            uint    `json:"id"`
+	CourseID      uint    `json:"course_id"`
+	UserID        uint    `json:"user_id"`
+	AuthorName    string  `json:"author_name"`
+	Greens        *string `json:"greens"`
+	Fairways      *string `json:"fairways"`
+	Bunkers       *string `json:"bunkers"`
+	PaceOfPlay    *string `json:"pace_of_play"`
+	CartPathOnly  bool    `json:"cart_path_only"`
+	GreensAerated bool    `json:"greens_aerated"`
+	FrostDelay    bool    `json:"frost_delay"`
+	Note          *string `json:"note"`
+	ExpiresAt     int64   `json:"expires_at"`
+	CreatedAt     int64   `json:"created_at"`
+	CanDelete     bool    `json:"can_delete"`
+}
+
+// CurrentConditionsResponse is the recency-weighted summary of a course's active reports
+type CurrentConditionsResponse struct {
+	CourseID      uint                        `json:"course_id"`
+	ReportCount   int                         `json:"report_count"`
+	LatestReport  *int64                      `json:"latest_report"`
+	Greens        string                      `json:"greens"`
+	Fairways      string                      `json:"fairways"`
+	Bunkers       string                      `json:"bunkers"`
+	PaceOfPlay    string                      `json:"pace_of_play"`
+	CartPathOnly  bool                        `json:"cart_path_only"`
+	GreensAerated bool                        `json:"greens_aerated"`
+	FrostDelay    bool                        `json:"frost_delay"`
+	Reports       []*ConditionsReportResponse `json:"reports"`
+}
+
+// NewConditionsHandler creates a new conditions handler
+func NewConditionsHandler(dbService ConditionsDatabaseServiceInterface) *ConditionsHandler {
+	return &ConditionsHandler{
+		dbService: dbService,
+	}
+}
+
+// GetCurrentConditions returns the current conditions summary and active reports for a course
+func (h *ConditionsHandler) GetCurrentConditions(c echo.Context) error {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+
+	// Get user ID if authenticated
+	var userID *uint
+	if uid, err := GetUserID(c); err == nil {
+		userID = &uid
+	}
+
+	exists, err := h.dbService.CourseExists(uint(courseID))
+	if err != nil {
+		return InternalServerError(c, "Failed to verify course")
+	}
+	if !exists {
+		return NotFoundError(c, "Course")
+	}
+
+	conditions, err := h.dbService.GetCurrentConditions(uint(courseID), userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve course conditions")
+	}
+
+	return SuccessResponse(c, conditions)
+}
+
+// CreateConditionsReport posts a conditions report for a course
+func (h *ConditionsHandler) CreateConditionsReport(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+
+	var req ConditionsReportRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+
+	if validationErrors := validateConditionsReport(&req); len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+
+	exists, err := h.dbService.CourseExists(uint(courseID))
+	if err != nil {
+		return InternalServerError(c, "Failed to verify course")
+	}
+	if !exists {
+		return NotFoundError(c, "Course")
+	}
+
+	report, err := h.dbService.CreateConditionsReport(userID, uint(courseID), &req)
+	if err != nil {
+		return InternalServerError(c, "Failed to create conditions report")
+	}
+
+	return CreatedResponse(c, report)
+}
+
+// DeleteConditionsReport removes a conditions report posted by the authenticated user
+func (h *ConditionsHandler) DeleteConditionsReport(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+
+	reportID, err := strconv.ParseUint(c.Param("reportId"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid report ID")
+	}
+
+	report, err := h.dbService.GetConditionsReport(uint(reportID))
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve conditions report")
+	}
+	if report == nil || report.CourseID != uint(courseID) {
+		return NotFoundError(c, "Conditions report")
+	}
+
+	if report.UserID != userID {
+		return ForbiddenError(c, "You can only remove your own conditions reports")
+	}
+
+	if err := h.dbService.DeleteConditionsReport(userID, report.ID); err != nil {
+		return InternalServerError(c, "Failed to delete conditions report")
+	}
+
+	return NoContentResponse(c)
+}
+
+// RegisterRoutes registers conditions report routes
+func (h *ConditionsHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Public routes (optionally authenticated so authors can see which reports they can remove)
+	g.GET("/courses/:id/conditions", h.GetCurrentConditions, OptionalJWTMiddleware(jwtService))
+
+	// Protected routes (authentication required)
+	g.POST("/courses/:id/conditions", h.CreateConditionsReport, JWTMiddleware(jwtService))
+	g.DELETE("/courses/:id/conditions/:reportId", h.DeleteConditionsReport, JWTMiddleware(jwtService))
+}
+
+// validateConditionsReport normalizes the report fields in place and returns any validation errors
+func validateConditionsReport(req *ConditionsReportRequest) map[string]string {
+	validationErrors := make(map[string]string)
+	surfaceRatings := []string{"excellent", "good", "fair", "poor"}
+	paceRatings := []string{"fast", "average", "slow"}
+
+	req.Greens = strings.ToLower(strings.TrimSpace(req.Greens))
+	req.Fairways = strings.ToLower(strings.TrimSpace(req.Fairways))
+	req.Bunkers = strings.ToLower(strings.TrimSpace(req.Bunkers))
+	req.PaceOfPlay = strings.ToLower(strings.TrimSpace(req.PaceOfPlay))
+	req.Note = strings.TrimSpace(req.Note)
+
+	if req.Greens != "" && !contains(surfaceRatings, req.Greens) {
+		validationErrors["greens"] = "Greens must be 'excellent', 'good', 'fair' or 'poor'"
+	}
+	if req.Fairways != "" && !contains(surfaceRatings, req.Fairways) {
+		validationErrors["fairways"] = "Fairways must be 'excellent', 'good', 'fair' or 'poor'"
+	}
+	if req.Bunkers != "" && !contains(surfaceRatings, req.Bunkers) {
+		validationErrors["bunkers"] = "Bunkers must be 'excellent', 'good', 'fair' or 'poor'"
+	}
+	if req.PaceOfPlay != "" && !contains(paceRatings, req.PaceOfPlay) {
+		validationErrors["pace_of_play"] = "Pace of play must be 'fast', 'average' or 'slow'"
+	}
+	if len(req.Note) > 500 {
+		validationErrors["note"] = "Note must be 500 characters or less"
+	}
+	if req.ValidHours < 0 || req.ValidHours > 168 {
+		validationErrors["valid_hours"] = "Reports can be valid for 1 to 168 hours"
+	}
+
+	if req.Greens == "" && req.Fairways == "" && req.Bunkers == "" && req.PaceOfPlay == "" &&
+		!req.CartPathOnly && !req.GreensAerated && !req.FrostDelay && req.Note == "" {
+		validationErrors["report"] = "Fill in at least one condition"
+	}
+
+	return validationErrors
+}
+
+// Database interface for course conditions operations
+type ConditionsDatabaseServiceInterface interface {
+	CourseExists(courseID uint) (bool, error)
+	GetCurrentConditions(courseID uint, userID *uint) (*CurrentConditionsResponse, error)
+	GetConditionsReport(reportID uint) (*ConditionsReportResponse, error)
+	CreateConditionsReport(userID, courseID uint, req *ConditionsReportRequest) (*ConditionsReportResponse, error)
+	DeleteConditionsReport(userID, reportID uint) error
+}
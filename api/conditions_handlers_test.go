@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPI_CourseConditions_Get(t *testing.T) {
+	t.Run("Returns summary", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		greens := "good"
+		latest := int64(1700000000)
+		mockDB.On("CourseExists", uint(4)).Return(true, nil)
+		mockDB.On("GetCurrentConditions", uint(4), (*uint)(nil)).Return(&CurrentConditionsResponse{
+			CourseID:     4,
+			ReportCount:  1,
+			LatestReport: &latest,
+			Greens:       "good",
+			CartPathOnly: true,
+			Reports:      []*ConditionsReportResponse{{ID: 1, CourseID: 4, Greens: &greens, CartPathOnly: true}},
+		}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/4/conditions", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"cart_path_only":true`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Unknown course", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("CourseExists", uint(99)).Return(false, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/99/conditions", "", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestAPI_CourseConditions_Create(t *testing.T) {
+	t.Run("Creates report", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("CourseExists", uint(4)).Return(true, nil)
+		mockDB.On("CreateConditionsReport", user.ID, uint(4), mock.MatchedBy(func(req *ConditionsReportRequest) bool {
+			return req.Greens == "poor" && req.PaceOfPlay == "slow" && req.FrostDelay
+		})).Return(&ConditionsReportResponse{ID: 8, CourseID: 4, UserID: user.ID}, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/courses/4/conditions", token, map[string]interface{}{
+			"greens":       "Poor",
+			"pace_of_play": "slow",
+			"frost_delay":  true,
+		})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects invalid values", func(t *testing.T) {
+		e, mockDB, _, token := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/courses/4/conditions", token, map[string]interface{}{
+			"greens":      "soggy",
+			"valid_hours": 500,
+		})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "greens")
+		assert.Contains(t, rec.Body.String(), "valid_hours")
+		mockDB.AssertNotCalled(t, "CreateConditionsReport", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects empty report", func(t *testing.T) {
+		e, _, _, token := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/courses/4/conditions", token, map[string]interface{}{})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		e, _, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/courses/4/conditions", "", map[string]interface{}{"greens": "good"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestAPI_CourseConditions_Delete(t *testing.T) {
+	t.Run("Author can delete", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetConditionsReport", uint(8)).Return(&ConditionsReportResponse{ID: 8, CourseID: 4, UserID: user.ID}, nil)
+		mockDB.On("DeleteConditionsReport", user.ID, uint(8)).Return(nil)
+
+		rec := serveJSON(e, http.MethodDelete, "/api/v1/courses/4/conditions/8", token, nil)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Other users cannot", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetConditionsReport", uint(8)).Return(&ConditionsReportResponse{ID: 8, CourseID: 4, UserID: user.ID + 1}, nil)
+
+		rec := serveJSON(e, http.MethodDelete, "/api/v1/courses/4/conditions/8", token, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		mockDB.AssertNotCalled(t, "DeleteConditionsReport", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Report on another course", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetConditionsReport", uint(8)).Return(&ConditionsReportResponse{ID: 8, CourseID: 5, UserID: user.ID}, nil)
+
+		rec := serveJSON(e, http.MethodDelete, "/api/v1/courses/4/conditions/8", token, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"strings"
+)
+
+// AttributeOption is one entry in the curated review tag and amenity taxonomy
+type AttributeOption struct {
+	Slug  string `json:"slug"`
+	Label string `json:"label"`
+}
+
+// ReviewTagOptions are the tags a reviewer can apply to a course
+var ReviewTagOptions = []AttributeOption{
+	{Slug: "walking-friendly", Label: "Walking friendly"},
+	{Slug: "links-style", Label: "Links style"},
+	{Slug: "tough-greens", Label: "Tough greens"},
+	{Slug: "dog-friendly", Label: "Dog friendly"},
+}
+
+// AmenityOptions are the facilities a reviewer can confirm or deny
+var AmenityOptions = []AttributeOption{
+	{Slug: "range", Label: "Driving range"},
+	{Slug: "chipping-area", Label: "Chipping area"},
+	{Slug: "restaurant", Label: "Restaurant"},
+	{Slug: "carts", Label: "Carts"},
+	{Slug: "lockers", Label: "Lockers"},
+}
+
+// IsReviewTag reports whether slug is part of the curated tag taxonomy
+func IsReviewTag(slug string) bool {
+	return findAttributeOption(ReviewTagOptions, slug) != nil
+}
+
+// IsAmenity reports whether slug is a known amenity
+func IsAmenity(slug string) bool {
+	return findAttributeOption(AmenityOptions, slug) != nil
+}
+
+// AttributeLabel returns the display label for a tag or amenity slug, or the slug itself if unknown
+func AttributeLabel(slug string) string {
+	if option := findAttributeOption(ReviewTagOptions, slug); option != nil {
+		return option.Label
+	}
+	if option := findAttributeOption(AmenityOptions, slug); option != nil {
+		return option.Label
+	}
+	return slug
+}
+
+// NormalizeAttributeFilter flattens repeated and comma separated filter values
+// (?tags=a&tags=b or ?tags=a,b) into a de-duplicated list of lower case slugs
+func NormalizeAttributeFilter(values []string) []string {
+	var slugs []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			slug := strings.ToLower(strings.TrimSpace(part))
+			if slug == "" || seen[slug] {
+				continue
+			}
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs
+}
+
+// validateAttributeFilters normalizes tag and amenity filters in place and
+// returns an error message for the first value outside the taxonomy
+func validateAttributeFilters(tags, amenities *[]string) string {
+	*tags = NormalizeAttributeFilter(*tags)
+	*amenities = NormalizeAttributeFilter(*amenities)
+
+	for _, tag := range *tags {
+		if !IsReviewTag(tag) {
+			return "Unknown tag: " + tag
+		}
+	}
+	for _, amenity := range *amenities {
+		if !IsAmenity(amenity) {
+			return "Unknown amenity: " + amenity
+		}
+	}
+	return ""
+}
+
+func findAttributeOption(options []AttributeOption, slug string) *AttributeOption {
+	for i := range options {
+		if options[i].Slug == slug {
+			return &options[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNormalizeAttributeFilter(t *testing.T) {
+	slugs := NormalizeAttributeFilter([]string{"Range, carts", "range", " ", "lockers"})
+	assert.Equal(t, []string{"range", "carts", "lockers"}, slugs)
+	assert.Nil(t, NormalizeAttributeFilter(nil))
+}
+
+func TestAPI_SearchCourses_AttributeFilters(t *testing.T) {
+	t.Run("Filters are passed through normalized", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		courses := []*CourseResponse{{ID: 1, Name: "Dunes", Tags: []string{"links-style"}, Amenities: []string{"range"}}}
+		mockDB.On("SearchCourses", mock.MatchedBy(func(search *CourseSearchRequest) bool {
+			return assert.ObjectsAreEqual([]string{"links-style", "walking-friendly"}, search.Tags) &&
+				assert.ObjectsAreEqual([]string{"range", "restaurant"}, search.Amenities)
+		}), (*uint)(nil), 1, 20).Return(courses, 1, nil)
+		mockDB.On("GetCourseFacetCounts", mock.Anything).Return(&CourseFacetCounts{}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/search?tags=links-style,walking-friendly&amenities=range&amenities=Restaurant", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"tags":["links-style"]`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Unknown tag is rejected", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/search?tags=haunted", "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Unknown tag: haunted")
+		mockDB.AssertNotCalled(t, "SearchCourses", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAPI_MapBounds_AttributeFilters(t *testing.T) {
+	boundsQuery := "/api/v1/map/courses/bounds?north_lat=41&south_lat=40&east_lng=-73&west_lng=-74"
+
+	t.Run("Filters are passed through", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("GetCoursesInBounds", mock.MatchedBy(func(bounds *BoundsRequest) bool {
+			return assert.ObjectsAreEqual([]string{"chipping-area"}, bounds.Amenities) && len(bounds.Tags) == 0
+		}), (*uint)(nil)).Return([]*MapCourseResponse{{ID: 3, Name: "Bethpage", Amenities: []string{"chipping-area"}}}, nil)
+
+		rec := serveJSON(e, http.MethodGet, boundsQuery+"&amenities=chipping-area", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Unknown amenity is rejected", func(t *testing.T) {
+		e, _, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodGet, boundsQuery+"&amenities=helipad", "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Unknown amenity: helipad")
+	})
+
+	t.Run("Clusters validate filters too", func(t *testing.T) {
+		e, _, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses/clusters?north_lat=41&south_lat=40&east_lng=-73&west_lng=-74&tags=spooky", "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
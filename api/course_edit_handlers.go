@@ -0,0 +1,325 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxCourseEditChanges    = 60
+	maxCourseEditNoteLength = 500
+)
+
+var (
+	ErrCourseEditNotFound  = errors.New("suggested edit not found")
+	ErrCourseEditForbidden = errors.New("not allowed to change this suggested edit")
+	ErrCourseEditAnswered  = errors.New("suggested edit already answered")
+	ErrCourseEditOwnCourse = errors.New("course created by the proposer")
+)
+
+// CourseEditError is returned when a suggested edit fails validation, or
+// can't be accepted because the course has changed since. Its message says
+// what to fix.
+type CourseEditError struct {
+	Message  string
+	Conflict bool // The course changed since the edit was suggested
+}
+
+func (e *CourseEditError) Error() string {
+	return e.Message
+}
+
+// CourseEditHandler handles edits to a course suggested by golfers who didn't
+// create it, and the queue its creator and the admins answer them from
+type CourseEditHandler struct {
+	dbService CourseEditDatabaseServiceInterface
+}
+
+// CourseFieldChangeRequest is one suggested change. Field is "name",
+// "address", "description" or "holes.<number>.par|yardage|description".
+type CourseFieldChangeRequest struct {
+	Field string `json:"field"`
+	To    string `json:"to"`
+}
+
+// CourseEditRequest suggests changes to a course
+type CourseEditRequest struct {
+	Changes []CourseFieldChangeRequest `json:"changes"`
+	Note    string                     `json:"note"`
+}
+
+// CourseEditAnswerRequest accepts or rejects a suggestion
+type CourseEditAnswerRequest struct {
+	Note string `json:"note"`
+}
+
+// CourseFieldChangeResponse is one suggested change with the value it
+// replaces
+type CourseFieldChangeResponse struct {
+	Field string `json:"field"`
+	Label string `json:"label"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// CourseEditResponse is a suggested edit and its answer
+type CourseEditResponse struct {
+	ID           uint                        `json:"id"`
+	CourseID     uint                        `json:"course_id"`
+	CourseName   string                      `json:"course_name"`
+	UserID       uint                        `json:"user_id"`
+	ProposerName string                      `json:"proposer_name"`
+	Changes      []CourseFieldChangeResponse `json:"changes"`
+	Note         *string                     `json:"note,omitempty"`
+	Status       string                      `json:"status"` // "pending", "accepted", "rejected" or "withdrawn"
+	EscalatedAt  *int64                      `json:"escalated_at,omitempty"`
+	ReviewedBy   *uint                       `json:"reviewed_by,omitempty"`
+	ReviewerName string                      `json:"reviewer_name,omitempty"`
+	ReviewNote   *string                     `json:"review_note,omitempty"`
+	ReviewedAt   *int64                      `json:"reviewed_at,omitempty"`
+	CreatedAt    int64                       `json:"created_at"`
+	CanReview    bool                        `json:"can_review"`
+	CanWithdraw  bool                        `json:"can_withdraw"`
+}
+
+// NewCourseEditHandler creates a new course edit handler
+func NewCourseEditHandler(dbService CourseEditDatabaseServiceInterface) *CourseEditHandler {
+	return &CourseEditHandler{dbService: dbService}
+}
+
+// ProposeCourseEdit suggests changes to a course. The course's creator, or
+// the admins when nobody created it, are asked to review them.
+func (h *CourseEditHandler) ProposeCourseEdit(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+
+	var req CourseEditRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	if validationErrors := validateCourseEditRequest(req); len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+
+	edit, err := h.dbService.ProposeCourseEdit(userID, uint(courseID), req.Changes, req.Note)
+	if err != nil {
+		return courseEditError(c, err, "Failed to suggest edit")
+	}
+	if edit == nil {
+		return NotFoundError(c, "Course")
+	}
+
+	return CreatedResponse(c, edit)
+}
+
+// GetCourseEdits returns a course's pending suggestions: every one for its
+// creator and the admins, otherwise the user's own
+func (h *CourseEditHandler) GetCourseEdits(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+
+	edits, err := h.dbService.GetCourseEditsForCourse(userID, uint(courseID))
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve suggested edits")
+	}
+	if edits == nil {
+		return NotFoundError(c, "Course")
+	}
+
+	return SuccessResponse(c, edits)
+}
+
+// GetCourseEditQueue returns the pending suggestions the user can answer,
+// oldest first
+func (h *CourseEditHandler) GetCourseEditQueue(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	edits, err := h.dbService.GetCourseEditQueue(userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve suggested edits")
+	}
+
+	return SuccessResponse(c, edits)
+}
+
+// GetMyCourseEdits returns the edits the user suggested, newest first
+func (h *CourseEditHandler) GetMyCourseEdits(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	edits, err := h.dbService.GetUserCourseEdits(userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve suggested edits")
+	}
+
+	return SuccessResponse(c, edits)
+}
+
+// GetCourseEdit returns one suggestion to its proposer, the course's creator
+// or an admin
+func (h *CourseEditHandler) GetCourseEdit(c echo.Context) error {
+	userID, editID, ok, err := parseCourseEditParams(c)
+	if !ok {
+		return err
+	}
+
+	edit, err := h.dbService.GetCourseEdit(userID, editID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve suggested edit")
+	}
+	if edit == nil {
+		return NotFoundError(c, "Suggested edit")
+	}
+
+	return SuccessResponse(c, edit)
+}
+
+// AcceptCourseEdit applies a suggestion to the course
+func (h *CourseEditHandler) AcceptCourseEdit(c echo.Context) error {
+	return h.answerCourseEdit(c, h.dbService.AcceptCourseEdit, "Failed to accept suggested edit")
+}
+
+// RejectCourseEdit turns a suggestion down
+func (h *CourseEditHandler) RejectCourseEdit(c echo.Context) error {
+	return h.answerCourseEdit(c, h.dbService.RejectCourseEdit, "Failed to reject suggested edit")
+}
+
+// WithdrawCourseEdit withdraws one of the user's pending suggestions
+func (h *CourseEditHandler) WithdrawCourseEdit(c echo.Context) error {
+	userID, editID, ok, err := parseCourseEditParams(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.dbService.WithdrawCourseEdit(userID, editID); err != nil {
+		return courseEditError(c, err, "Failed to withdraw suggested edit")
+	}
+
+	return NoContentResponse(c)
+}
+
+func (h *CourseEditHandler) answerCourseEdit(c echo.Context, answer func(userID, editID uint, note string) (*CourseEditResponse, error), message string) error {
+	userID, editID, ok, err := parseCourseEditParams(c)
+	if !ok {
+		return err
+	}
+
+	var req CourseEditAnswerRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	if len(req.Note) > maxCourseEditNoteLength {
+		return ValidationError(c, map[string]string{"note": fmt.Sprintf("Note must be at most %d characters", maxCourseEditNoteLength)})
+	}
+
+	edit, err := answer(userID, editID, req.Note)
+	if err != nil {
+		return courseEditError(c, err, message)
+	}
+
+	return SuccessResponse(c, edit)
+}
+
+// parseCourseEditParams returns the signed-in user's ID and the suggestion's
+// ID from the path. It writes the error response and returns false when
+// either is missing.
+func parseCourseEditParams(c echo.Context) (uint, uint, bool, error) {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return 0, 0, false, UnauthorizedError(c, "Authentication required")
+	}
+	editID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, 0, false, BadRequestError(c, "Invalid suggested edit ID")
+	}
+	return userID, uint(editID), true, nil
+}
+
+func validateCourseEditRequest(req CourseEditRequest) map[string]string {
+	errors := make(map[string]string)
+	if len(req.Changes) == 0 {
+		errors["changes"] = "At least one change is required"
+	} else if len(req.Changes) > maxCourseEditChanges {
+		errors["changes"] = fmt.Sprintf("At most %d changes can be suggested at once", maxCourseEditChanges)
+	}
+	for _, change := range req.Changes {
+		if change.Field == "" {
+			errors["changes"] = "Every change needs a field"
+			break
+		}
+	}
+	if len(req.Note) > maxCourseEditNoteLength {
+		errors["note"] = fmt.Sprintf("Note must be at most %d characters", maxCourseEditNoteLength)
+	}
+	return errors
+}
+
+// courseEditError answers a suggestion that couldn't be made or answered
+func courseEditError(c echo.Context, err error, message string) error {
+	var invalid *CourseEditError
+	switch {
+	case errors.As(err, &invalid):
+		if invalid.Conflict {
+			return ConflictError(c, invalid.Message)
+		}
+		return BadRequestError(c, invalid.Message)
+	case errors.Is(err, ErrCourseEditNotFound):
+		return NotFoundError(c, "Suggested edit")
+	case errors.Is(err, ErrCourseEditForbidden):
+		return ForbiddenError(c, "Only the course's creator or an admin can answer a suggested edit, and only its proposer can withdraw it")
+	case errors.Is(err, ErrCourseEditAnswered):
+		return ConflictError(c, "This suggested edit has already been answered")
+	case errors.Is(err, ErrCourseEditOwnCourse):
+		return ForbiddenError(c, "You created this course, so edit it directly")
+	}
+	return InternalServerError(c, message)
+}
+
+// RegisterRoutes registers suggested course edit routes
+func (h *CourseEditHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Protected routes
+	g.POST("/courses/:id/edits", h.ProposeCourseEdit, JWTMiddleware(jwtService))
+	g.GET("/courses/:id/edits", h.GetCourseEdits, JWTMiddleware(jwtService))
+	g.GET("/course-edits/queue", h.GetCourseEditQueue, JWTMiddleware(jwtService))
+	g.GET("/course-edits/mine", h.GetMyCourseEdits, JWTMiddleware(jwtService))
+	g.GET("/course-edits/:id", h.GetCourseEdit, JWTMiddleware(jwtService))
+	g.POST("/course-edits/:id/accept", h.AcceptCourseEdit, JWTMiddleware(jwtService))
+	g.POST("/course-edits/:id/reject", h.RejectCourseEdit, JWTMiddleware(jwtService))
+	g.DELETE("/course-edits/:id", h.WithdrawCourseEdit, JWTMiddleware(jwtService))
+}
+
+// Database interface for suggested course edit operations
+type CourseEditDatabaseServiceInterface interface {
+	// ProposeCourseEdit returns a *CourseEditError for changes that fail
+	// validation, and nil when the course doesn't exist
+	ProposeCourseEdit(userID, courseID uint, changes []CourseFieldChangeRequest, note string) (*CourseEditResponse, error)
+	GetCourseEditsForCourse(viewerID, courseID uint) ([]CourseEditResponse, error) // Nil when the course doesn't exist
+	GetCourseEditQueue(userID uint) ([]CourseEditResponse, error)
+	GetUserCourseEdits(userID uint) ([]CourseEditResponse, error)
+	GetCourseEdit(viewerID, editID uint) (*CourseEditResponse, error) // Nil when the viewer can't see it
+	// AcceptCourseEdit, RejectCourseEdit and WithdrawCourseEdit fail with the
+	// ErrCourseEdit errors, or a *CourseEditError when the course has changed
+	AcceptCourseEdit(userID, editID uint, note string) (*CourseEditResponse, error)
+	RejectCourseEdit(userID, editID uint, note string) (*CourseEditResponse, error)
+	WithdrawCourseEdit(userID, editID uint) error
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_CourseEdits(t *testing.T) {
+	t.Run("Suggests an edit", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		changes := []CourseFieldChangeRequest{{Field: "holes.7.par", To: "4"}}
+		mockDB.On("ProposeCourseEdit", user.ID, uint(3), changes, "Per the scorecard").Return(&CourseEditResponse{
+			ID: 5, CourseID: 3, UserID: user.ID, Status: "pending",
+			Changes: []CourseFieldChangeResponse{{Field: "holes.7.par", Label: "Hole 7 par", From: "5", To: "4"}},
+		}, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/courses/3/edits", token, CourseEditRequest{Changes: changes, Note: "Per the scorecard"})
+		require.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"label":"Hole 7 par","from":"5","to":"4"`)
+	})
+
+	t.Run("Validates suggestions", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+		rec := serveJSON(e, http.MethodPost, "/api/v1/courses/3/edits", token, CourseEditRequest{})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "At least one change is required")
+		e, mockDB, _, _ = setupAPITest(t)
+		rec = serveJSON(e, http.MethodPost, "/api/v1/courses/3/edits", "", CourseEditRequest{})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		mockDB.AssertNotCalled(t, "ProposeCourseEdit", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+		e, mockDB, user, token = setupAPITest(t)
+		changes := []CourseFieldChangeRequest{{Field: "holes.7.par", To: "9"}}
+		mockDB.On("ProposeCourseEdit", user.ID, uint(3), changes, "").
+			Return(nil, &CourseEditError{Message: "Invalid suggested edit: Hole 7 par must be a number from 3 to 6"})
+		rec = serveJSON(e, http.MethodPost, "/api/v1/courses/3/edits", token, CourseEditRequest{Changes: changes})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "must be a number from 3 to 6")
+
+		e, mockDB, user, token = setupAPITest(t)
+		mockDB.On("ProposeCourseEdit", user.ID, uint(99), changes, "").Return(nil, nil)
+		rec = serveJSON(e, http.MethodPost, "/api/v1/courses/99/edits", token, CourseEditRequest{Changes: changes})
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Lists the review queue and the user's suggestions", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+		mockDB.On("GetCourseEditQueue", user.ID).Return([]CourseEditResponse{{ID: 5, CanReview: true}}, nil)
+		rec := serveJSON(e, http.MethodGet, "/api/v1/course-edits/queue", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"can_review":true`)
+
+		e, mockDB, user, token = setupAPITest(t)
+		mockDB.On("GetUserCourseEdits", user.ID).Return([]CourseEditResponse{}, nil)
+		rec = serveJSON(e, http.MethodGet, "/api/v1/course-edits/mine", token, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		e, mockDB, user, token = setupAPITest(t)
+		mockDB.On("GetCourseEdit", user.ID, uint(8)).Return(nil, nil)
+		rec = serveJSON(e, http.MethodGet, "/api/v1/course-edits/8", token, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Answers suggestions", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			method string
+			path   string
+			setup  func(mockDB *MockDatabaseService, userID uint)
+			status int
+			body   string
+		}{
+			{"accepts", http.MethodPost, "/api/v1/course-edits/5/accept", func(mockDB *MockDatabaseService, userID uint) {
+				mockDB.On("AcceptCourseEdit", userID, uint(5), "").Return(&CourseEditResponse{ID: 5, Status: "accepted"}, nil)
+			}, http.StatusOK, `"status":"accepted"`},
+			{"conflict", http.MethodPost, "/api/v1/course-edits/6/accept", func(mockDB *MockDatabaseService, userID uint) {
+				mockDB.On("AcceptCourseEdit", userID, uint(6), "").
+					Return(nil, &CourseEditError{Message: "The course has changed since this edit was suggested: Hole 7 par", Conflict: true})
+			}, http.StatusConflict, "Hole 7 par"},
+			{"not allowed", http.MethodPost, "/api/v1/course-edits/7/reject", func(mockDB *MockDatabaseService, userID uint) {
+				mockDB.On("RejectCourseEdit", userID, uint(7), "").Return(nil, ErrCourseEditForbidden)
+			}, http.StatusForbidden, "creator or an admin"},
+			{"already answered", http.MethodPost, "/api/v1/course-edits/8/reject", func(mockDB *MockDatabaseService, userID uint) {
+				mockDB.On("RejectCourseEdit", userID, uint(8), "").Return(nil, ErrCourseEditAnswered)
+			}, http.StatusConflict, "already been answered"},
+			{"withdraws", http.MethodDelete, "/api/v1/course-edits/9", func(mockDB *MockDatabaseService, userID uint) {
+				mockDB.On("WithdrawCourseEdit", userID, uint(9)).Return(nil)
+			}, http.StatusNoContent, ""},
+		}
+		for _, tc := range cases {
+			e, mockDB, user, token := setupAPITest(t)
+			tc.setup(mockDB, user.ID)
+
+			var body interface{}
+			if tc.method == http.MethodPost {
+				body = CourseEditAnswerRequest{}
+			}
+			rec := serveJSON(e, tc.method, tc.path, token, body)
+			assert.Equal(t, tc.status, rec.Code, tc.name)
+			assert.Contains(t, rec.Body.String(), tc.body, tc.name)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"strings"
+)
+
+const (
+	minDifficultyFilter = 1
+	maxDifficultyFilter = 5
+	maxHoleCountFilter  = 18
+	minParFilter        = 3
+	maxParFilter        = 108
+)
+
+// PriceTiers are the course price rankings from cheapest to dearest
+var PriceTiers = []string{"$", "$$", "$$$", "$$$$"}
+
+// GradeScale lists letter grades from best to worst
+var GradeScale = []string{"S", "A", "B", "C", "D", "F"}
+
+// GradeCategories are the ranking categories that can be filtered by minimum grade
+var GradeCategories = []AttributeOption{
+	{Slug: "condition", Label: "Condition"},
+	{Slug: "walkability", Label: "Walkability"},
+	{Slug: "enjoyment", Label: "Enjoyment"},
+	{Slug: "vibe", Label: "Vibe"},
+	{Slug: "range", Label: "Range"},
+	{Slug: "amenities", Label: "Amenities"},
+	{Slug: "merch", Label: "Merch"},
+	{Slug: "glizzies", Label: "Glizzies"},
+}
+
+// CourseFacetCounts holds, for each facet, how many courses in the search
+// carry each value. A facet's counts ignore that facet's own filter, so
+// clients can show how many courses selecting another value would give.
+type CourseFacetCounts struct {
+	Price              []FacetValueCount            `json:"price"`
+	HandicapDifficulty []FacetValueCount            `json:"handicap_difficulty"`
+	HazardDifficulty   []FacetValueCount            `json:"hazard_difficulty"`
+	Grades             map[string][]FacetValueCount `json:"grades"` // Keyed by grade category
+	Holes              []FacetValueCount            `json:"holes"`
+	Par                []FacetValueCount            `json:"par"`
+}
+
+// FacetValueCount is one filter chip: a facet value and its course count
+type FacetValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// IsPriceTier reports whether value is one of PriceTiers
+func IsPriceTier(value string) bool {
+	return indexOf(PriceTiers, value) >= 0
+}
+
+// IsGrade reports whether value is one of GradeScale
+func IsGrade(value string) bool {
+	return indexOf(GradeScale, value) >= 0
+}
+
+// PriceTiersBetween returns the tiers from min to max inclusive; an empty
+// bound is open
+func PriceTiersBetween(min, max string) []string {
+	from, to := 0, len(PriceTiers)-1
+	if min != "" {
+		from = indexOf(PriceTiers, min)
+	}
+	if max != "" {
+		to = indexOf(PriceTiers, max)
+	}
+	if from < 0 || to < from {
+		return []string{}
+	}
+	return PriceTiers[from : to+1]
+}
+
+// GradesAtLeast returns grade and every better grade
+func GradesAtLeast(grade string) []string {
+	index := indexOf(GradeScale, grade)
+	if index < 0 {
+		return []string{}
+	}
+	return GradeScale[:index+1]
+}
+
+// GradeRating places a letter grade on the 0 to 10 scale of the min_rating
+// and max_rating filters: S is 10 and each grade below it 2 less, down to 0
+// for F. It returns nil for anything that isn't a grade.
+func GradeRating(grade string) *float64 {
+	index := indexOf(GradeScale, grade)
+	if index < 0 {
+		return nil
+	}
+	rating := 10 - 10*float64(index)/float64(len(GradeScale)-1)
+	return &rating
+}
+
+// IsGradeCategory reports whether slug is a filterable ranking category
+func IsGradeCategory(slug string) bool {
+	return findAttributeOption(GradeCategories, slug) != nil
+}
+
+// ParseGradeFilters turns min_grade values such as "condition:B" (repeated or
+// comma separated) into grade floors keyed by category. It returns an error
+// message for the first malformed value.
+func ParseGradeFilters(values []string) (map[string]string, string) {
+	floors := make(map[string]string)
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			category, grade, found := strings.Cut(part, ":")
+			category = strings.ToLower(strings.TrimSpace(category))
+			grade = strings.ToUpper(strings.TrimSpace(grade))
+			if !found || !IsGradeCategory(category) {
+				return nil, "Unknown grade category: " + part
+			}
+			if indexOf(GradeScale, grade) < 0 {
+				return nil, "Grade must be one of S, A, B, C, D or F: " + part
+			}
+			if previous, ok := floors[category]; ok && previous != grade {
+				return nil, "Conflicting minimum grades for " + category
+			}
+			floors[category] = grade
+		}
+	}
+	return floors, ""
+}
+
+// validateFacetFilters checks the ranking facet filters of a search and
+// returns an error message for the first invalid one
+func validateFacetFilters(search *CourseSearchRequest) string {
+	for _, price := range []string{search.MinPrice, search.MaxPrice} {
+		if price != "" && !IsPriceTier(price) {
+			return "Price must be one of $, $$, $$$ or $$$$"
+		}
+	}
+	if search.MinPrice != "" && search.MaxPrice != "" && indexOf(PriceTiers, search.MinPrice) > indexOf(PriceTiers, search.MaxPrice) {
+		return "Minimum price must not be above maximum price"
+	}
+
+	difficulties := []struct {
+		name     string
+		min, max *int
+	}{
+		{"Handicap difficulty", search.MinHandicapDifficulty, search.MaxHandicapDifficulty},
+		{"Hazard difficulty", search.MinHazardDifficulty, search.MaxHazardDifficulty},
+	}
+	for _, difficulty := range difficulties {
+		for _, bound := range []*int{difficulty.min, difficulty.max} {
+			if bound != nil && (*bound < minDifficultyFilter || *bound > maxDifficultyFilter) {
+				return difficulty.name + " must be between 1 and 5"
+			}
+		}
+		if difficulty.min != nil && difficulty.max != nil && *difficulty.min > *difficulty.max {
+			return difficulty.name + " minimum must not be above maximum"
+		}
+	}
+
+	if _, message := ParseGradeFilters(search.MinGrades); message != "" {
+		return message
+	}
+
+	if search.Holes != nil && (*search.Holes < 1 || *search.Holes > maxHoleCountFilter) {
+		return "Holes must be between 1 and 18"
+	}
+	for _, par := range []*int{search.MinPar, search.MaxPar} {
+		if par != nil && (*par < minParFilter || *par > maxParFilter) {
+			return "Par must be between 3 and 108"
+		}
+	}
+	if search.MinPar != nil && search.MaxPar != nil && *search.MinPar > *search.MaxPar {
+		return "Minimum par must not be above maximum par"
+	}
+	return ""
+}
+
+func indexOf(values []string, value string) int {
+	for i, candidate := range values {
+		if candidate == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseGradeFilters(t *testing.T) {
+	floors, message := ParseGradeFilters([]string{"Condition:b, walkability:A", "condition:B"})
+	assert.Empty(t, message)
+	assert.Equal(t, map[string]string{"condition": "B", "walkability": "A"}, floors)
+
+	for _, value := range []string{"condition", "haunted:A", "condition:Z", "condition:A,condition:B"} {
+		_, message := ParseGradeFilters([]string{value})
+		assert.NotEmpty(t, message, value)
+	}
+}
+
+func TestPriceTiersAndGrades(t *testing.T) {
+	assert.Equal(t, []string{"$$", "$$$"}, PriceTiersBetween("$$", "$$$"))
+	assert.Equal(t, []string{"$", "$$"}, PriceTiersBetween("", "$$"))
+	assert.Equal(t, []string{"S", "A", "B"}, GradesAtLeast("B"))
+	assert.Empty(t, GradesAtLeast(""))
+	assert.Equal(t, 10.0, *GradeRating("S"))
+	assert.Equal(t, 6.0, *GradeRating("B"))
+	assert.Equal(t, 0.0, *GradeRating("F"))
+	assert.Nil(t, GradeRating(""))
+}
+
+func TestAPI_SearchCourses_FacetFilters(t *testing.T) {
+	t.Run("Filters are passed through and facet counts returned", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		matches := mock.MatchedBy(func(search *CourseSearchRequest) bool {
+			return search.MinPrice == "$" && search.MaxPrice == "$$" &&
+				search.MinHandicapDifficulty != nil && *search.MinHandicapDifficulty == 2 &&
+				assert.ObjectsAreEqual([]string{"condition:B", "walkability:a"}, search.MinGrades) &&
+				search.Holes != nil && *search.Holes == 18 &&
+				search.MaxPar != nil && *search.MaxPar == 72
+		})
+		mockDB.On("SearchCourses", matches, (*uint)(nil), 1, 20).Return([]*CourseResponse{{ID: 3, Name: "Muni"}}, 1, nil)
+		mockDB.On("GetCourseFacetCounts", matches).Return(&CourseFacetCounts{
+			Price:  []FacetValueCount{{Value: "$", Count: 0}, {Value: "$$", Count: 1}},
+			Grades: map[string][]FacetValueCount{"condition": {{Value: "B", Count: 1}}},
+			Holes:  []FacetValueCount{{Value: "18", Count: 1}},
+		}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/search?min_price=%24&max_price=%24%24&min_handicap_difficulty=2&min_grade=condition:B&min_grade=walkability:a&holes=18&max_par=72", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"price":[{"value":"$","count":0},{"value":"$$","count":1}]`)
+		assert.Contains(t, rec.Body.String(), `"grades":{"condition":[{"value":"B","count":1}]}`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Invalid facets are rejected", func(t *testing.T) {
+		for _, query := range []string{
+			"min_price=cheap",
+			"min_price=%24%24%24&max_price=%24",
+			"min_hazard_difficulty=0",
+			"min_handicap_difficulty=4&max_handicap_difficulty=2",
+			"min_grade=condition:Z",
+			"min_grade=haunted:A",
+			"holes=19",
+			"min_par=80&max_par=70",
+		} {
+			e, mockDB, _, _ := setupAPITest(t)
+
+			rec := serveJSON(e, http.MethodGet, "/api/v1/courses/search?"+query, "", nil)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			mockDB.AssertNotCalled(t, "SearchCourses", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/labstack/echo/v4"
 )
@@ -59,6 +60,25 @@ type CourseResponse struct {
 	CanEdit     bool               `json:"can_edit"`
 	UserReview  *UserReviewSummary `json:"user_review,omitempty"`
 	Stats       *CourseStats       `json:"stats,omitempty"`
+	// Reviewer consensus on the curated tags and amenities
+	Tags      []string `json:"tags"`
+	Amenities []string `json:"amenities"`
+	// What reviewers mention most in their write-ups, from the review text analysis job
+	Mentions        []ReviewMentionResponse `json:"mentions"`
+	ReviewSentiment string                  `json:"review_sentiment,omitempty"` // positive, neutral or negative
+	// Full-text search results only: how well the course matched, and the
+	// matching passage as HTML with the matched words in <mark> tags
+	SearchRank *float64 `json:"search_rank,omitempty"`
+	Snippet    string   `json:"snippet,omitempty"`
+	// Nearby results only: kilometers from the search point
+	Distance *float64 `json:"distance,omitempty"`
+}
+
+// ReviewMentionResponse is a keyword or phrase that comes up across a course's reviews
+type ReviewMentionResponse struct {
+	Term      string `json:"term"`
+	Reviews   int    `json:"reviews"`   // Number of reviews that mention it
+	Sentiment string `json:"sentiment"` // positive, neutral or negative
 }
 
 // UserReviewSummary represents user's review summary for a course
@@ -87,8 +107,22 @@ type CourseSearchRequest struct {
 	Radius    *float64 `query:"radius"` // in kilometers
 	MinRating *float64 `query:"min_rating"`
 	MaxRating *float64 `query:"max_rating"`
-	SortBy    string   `query:"sort_by"` // "name", "rating", "distance", "created_at"
+	SortBy    string   `query:"sort_by"` // "relevance" (the default with q), "name", "rating", "distance", "created_at"
 	SortOrder string   `query:"sort_order"` // "asc", "desc"
+	Tags      []string `query:"tags"`      // Course must carry every tag, see ReviewTagOptions
+	Amenities []string `query:"amenities"` // Course must offer every amenity, see AmenityOptions
+
+	// Ranking facets, answered from the relational course_rankings table
+	MinPrice              string   `query:"min_price"` // "$" to "$$$$"
+	MaxPrice              string   `query:"max_price"`
+	MinHandicapDifficulty *int     `query:"min_handicap_difficulty"` // 1 to 5
+	MaxHandicapDifficulty *int     `query:"max_handicap_difficulty"`
+	MinHazardDifficulty   *int     `query:"min_hazard_difficulty"`
+	MaxHazardDifficulty   *int     `query:"max_hazard_difficulty"`
+	MinGrades             []string `query:"min_grade"` // "category:grade", e.g. "condition:B", see GradeCategories
+	Holes                 *int     `query:"holes"` // Holes on the card, e.g. 9 or 18
+	MinPar                *int     `query:"min_par"`
+	MaxPar                *int     `query:"max_par"`
 }
 
 // NewCourseHandler creates a new course handler
@@ -109,6 +143,11 @@ func (h *CourseHandler) GetCourses(c echo.Context) error {
 		return BadRequestError(c, "Invalid search parameters")
 	}
 
+	// Validate tag and amenity facets
+	if message := validateAttributeFilters(&search.Tags, &search.Amenities); message != "" {
+		return BadRequestError(c, message)
+	}
+
 	// Get user ID if authenticated
 	var userID *uint
 	if uid, err := GetUserID(c); err == nil {
@@ -325,7 +364,9 @@ func (h *CourseHandler) DeleteCourse(c echo.Context) error {
 	return NoContentResponse(c)
 }
 
-// SearchCourses performs course search with various filters
+// SearchCourses performs course search with various filters. A text query
+// (q) is matched against names, addresses, descriptions and reviews, allowing
+// for typos; matches come back best first with a highlighted snippet.
 func (h *CourseHandler) SearchCourses(c echo.Context) error {
 	// Get pagination parameters
 	pagination := GetPagination(c)
@@ -337,26 +378,8 @@ func (h *CourseHandler) SearchCourses(c echo.Context) error {
 	}
 
 	// Validate search parameters
-	if search.Radius != nil && (*search.Radius < 0 || *search.Radius > 1000) {
-		return BadRequestError(c, "Radius must be between 0 and 1000 kilometers")
-	}
-
-	if search.MinRating != nil && (*search.MinRating < 0 || *search.MinRating > 10) {
-		return BadRequestError(c, "Minimum rating must be between 0 and 10")
-	}
-
-	if search.MaxRating != nil && (*search.MaxRating < 0 || *search.MaxRating > 10) {
-		return BadRequestError(c, "Maximum rating must be between 0 and 10")
-	}
-
-	// Validate sort parameters
-	validSortFields := []string{"name", "rating", "distance", "created_at"}
-	if search.SortBy != "" && !contains(validSortFields, search.SortBy) {
-		return BadRequestError(c, "Invalid sort field")
-	}
-
-	if search.SortOrder != "" && search.SortOrder != "asc" && search.SortOrder != "desc" {
-		return BadRequestError(c, "Sort order must be 'asc' or 'desc'")
+	if message := validateCourseSearch(&search); message != "" {
+		return BadRequestError(c, message)
 	}
 
 	// Get user ID if authenticated
@@ -371,17 +394,70 @@ func (h *CourseHandler) SearchCourses(c echo.Context) error {
 		return InternalServerError(c, "Search failed")
 	}
 
+	facets, err := h.dbService.GetCourseFacetCounts(&search)
+	if err != nil {
+		return InternalServerError(c, "Search failed")
+	}
+
 	// Create paginated response
 	meta := &APIMeta{
 		Page:       pagination.Page,
 		PerPage:    pagination.PerPage,
 		Total:      total,
 		TotalPages: (total + pagination.PerPage - 1) / pagination.PerPage,
+		Facets:     facets,
 	}
 
 	return SuccessResponseWithMeta(c, courses, meta)
 }
 
+// validateCourseSearch checks and normalizes the parameters shared by course
+// search and the map exports, returning a message for the first problem
+func validateCourseSearch(search *CourseSearchRequest) string {
+	search.Query = strings.TrimSpace(search.Query)
+	if utf8.RuneCountInString(search.Query) > maxSearchQueryLength {
+		return "Search query must be at most 200 characters"
+	}
+
+	if search.Radius != nil && (*search.Radius < 0 || *search.Radius > 1000) {
+		return "Radius must be between 0 and 1000 kilometers"
+	}
+
+	if search.MinRating != nil && (*search.MinRating < 0 || *search.MinRating > 10) {
+		return "Minimum rating must be between 0 and 10"
+	}
+
+	if search.MaxRating != nil && (*search.MaxRating < 0 || *search.MaxRating > 10) {
+		return "Maximum rating must be between 0 and 10"
+	}
+
+	// Validate sort parameters
+	validSortFields := []string{"relevance", "name", "rating", "distance", "created_at"}
+	if search.SortBy != "" && !contains(validSortFields, search.SortBy) {
+		return "Invalid sort field"
+	}
+
+	if search.SortBy == "relevance" && search.Query == "" {
+		return "Sorting by relevance needs a search query"
+	}
+
+	if search.SortOrder != "" && search.SortOrder != "asc" && search.SortOrder != "desc" {
+		return "Sort order must be 'asc' or 'desc'"
+	}
+
+	// Validate tag and amenity facets
+	if message := validateAttributeFilters(&search.Tags, &search.Amenities); message != "" {
+		return message
+	}
+
+	// Validate ranking facets
+	if message := validateFacetFilters(search); message != "" {
+		return message
+	}
+
+	return ""
+}
+
 // GetNearbyCourses returns courses near a location
 func (h *CourseHandler) GetNearbyCourses(c echo.Context) error {
 	latParam := c.QueryParam("lat")
@@ -479,6 +555,7 @@ type CoursesDatabaseServiceInterface interface {
 	UpdateCourse(courseID uint, req *CourseUpdateRequest) (*CourseResponse, error)
 	DeleteCourse(courseID uint) error
 	SearchCourses(search *CourseSearchRequest, userID *uint, page, perPage int) ([]*CourseResponse, int, error)
+	GetCourseFacetCounts(search *CourseSearchRequest) (*CourseFacetCounts, error)
 	GetNearbyCoures(lat, lng, radius float64, userID *uint, page, perPage int) ([]*CourseResponse, int, error)
 	CourseExistsByNameAndAddress(name, address string) (bool, error)
 	IsUserCourseOwner(userID, courseID uint) (bool, error)
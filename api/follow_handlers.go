@@ -0,0 +1,233 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// FeedActivityTypes lists the activity types that appear in the feed, in the
+// order the feed filter offers them
+var FeedActivityTypes = []string{
+	"course_review",
+	"score_posted",
+	"conditions_report",
+	"review_comment",
+	"follow",
+	"course_added",
+}
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 50
+)
+
+var ErrInvalidFeedCursor = errors.New("invalid feed cursor")
+
+// FollowHandler handles follow and activity feed API endpoints
+type FollowHandler struct {
+	dbService FollowDatabaseServiceInterface
+}
+
+// FeedCursor marks the last activity of a feed page. The next page starts
+// strictly after it in (created_at, id) order, so new activity never shifts
+// the pages a client is walking through.
+type FeedCursor struct {
+	CreatedAt int64
+	ID        uint
+}
+
+// FeedQuery selects a page of the feed
+type FeedQuery struct {
+	Types  []string    // Empty means every feed activity type
+	Cursor *FeedCursor // Nil for the first page
+	Limit  int
+}
+
+// FollowResponse describes the follow relationship with a user after a change
+type FollowResponse struct {
+	UserID    uint  `json:"user_id"`
+	Following bool  `json:"following"`
+	Followers int64 `json:"followers"`
+}
+
+// FeedItemResponse is a single activity in the feed
+type FeedItemResponse struct {
+	ID             uint    `json:"id"`
+	ActivityType   string  `json:"activity_type"`
+	UserID         uint    `json:"user_id"`
+	UserName       string  `json:"user_name"`
+	CourseID       *uint   `json:"course_id,omitempty"`
+	CourseName     *string `json:"course_name,omitempty"`
+	TargetUserID   *uint   `json:"target_user_id,omitempty"`
+	TargetUserName *string `json:"target_user_name,omitempty"`
+	Summary        string  `json:"summary"`
+	CreatedAt      int64   `json:"created_at"`
+}
+
+// NewFollowHandler creates a new follow handler
+func NewFollowHandler(dbService FollowDatabaseServiceInterface) *FollowHandler {
+	return &FollowHandler{
+		dbService: dbService,
+	}
+}
+
+// FollowUser makes the authenticated user follow another user
+func (h *FollowHandler) FollowUser(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	followeeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid user ID")
+	}
+	if uint(followeeID) == userID {
+		return BadRequestError(c, "You can't follow yourself")
+	}
+
+	exists, err := h.dbService.UserExists(uint(followeeID))
+	if err != nil {
+		return InternalServerError(c, "Failed to verify user")
+	}
+	if !exists {
+		return NotFoundError(c, "User")
+	}
+
+	follow, err := h.dbService.FollowUser(userID, uint(followeeID))
+	if err != nil {
+		return InternalServerError(c, "Failed to follow user")
+	}
+
+	return SuccessResponse(c, follow)
+}
+
+// UnfollowUser stops the authenticated user following another user
+func (h *FollowHandler) UnfollowUser(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	followeeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid user ID")
+	}
+
+	if err := h.dbService.UnfollowUser(userID, uint(followeeID)); err != nil {
+		return InternalServerError(c, "Failed to unfollow user")
+	}
+
+	return NoContentResponse(c)
+}
+
+// GetFeed returns recent activity from the users the authenticated user follows
+func (h *FollowHandler) GetFeed(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	types, err := ParseFeedTypes(c.QueryParam("types"))
+	if err != nil {
+		return ValidationError(c, map[string]string{"types": err.Error()})
+	}
+
+	query := FeedQuery{Types: types, Limit: defaultFeedLimit}
+	if raw := c.QueryParam("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxFeedLimit {
+			return ValidationError(c, map[string]string{"limit": fmt.Sprintf("Limit must be between 1 and %d", maxFeedLimit)})
+		}
+		query.Limit = limit
+	}
+	if raw := c.QueryParam("cursor"); raw != "" {
+		cursor, err := DecodeFeedCursor(raw)
+		if err != nil {
+			return BadRequestError(c, "Invalid cursor")
+		}
+		query.Cursor = cursor
+	}
+
+	items, next, err := h.dbService.GetFeed(userID, query)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve activity feed")
+	}
+
+	meta := &APIMeta{PerPage: query.Limit}
+	if next != nil {
+		meta.NextCursor = next.Encode()
+	}
+	return SuccessResponseWithMeta(c, items, meta)
+}
+
+// RegisterRoutes registers follow and feed routes
+func (h *FollowHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Protected routes (authentication required)
+	g.POST("/users/:id/follow", h.FollowUser, JWTMiddleware(jwtService))
+	g.DELETE("/users/:id/follow", h.UnfollowUser, JWTMiddleware(jwtService))
+	g.GET("/feed", h.GetFeed, JWTMiddleware(jwtService))
+}
+
+// IsFeedActivityType reports whether activityType can be used as a feed filter
+func IsFeedActivityType(activityType string) bool {
+	return contains(FeedActivityTypes, activityType)
+}
+
+// ParseFeedTypes parses a comma-separated list of activity types. An empty
+// list selects every type.
+func ParseFeedTypes(raw string) ([]string, error) {
+	var types []string
+	for _, part := range strings.Split(raw, ",") {
+		activityType := strings.ToLower(strings.TrimSpace(part))
+		if activityType == "" || contains(types, activityType) {
+			continue
+		}
+		if !IsFeedActivityType(activityType) {
+			return nil, fmt.Errorf("Unknown activity type '%s'; use %s", activityType, strings.Join(FeedActivityTypes, ", "))
+		}
+		types = append(types, activityType)
+	}
+	return types, nil
+}
+
+// Encode returns the opaque form of the cursor handed to clients
+func (fc FeedCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d.%d", fc.CreatedAt, fc.ID)))
+}
+
+// DecodeFeedCursor parses a cursor produced by FeedCursor.Encode
+func DecodeFeedCursor(raw string) (*FeedCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidFeedCursor
+	}
+
+	createdAt, id, found := strings.Cut(string(decoded), ".")
+	if !found {
+		return nil, ErrInvalidFeedCursor
+	}
+	cursor := &FeedCursor{}
+	if cursor.CreatedAt, err = strconv.ParseInt(createdAt, 10, 64); err != nil || cursor.CreatedAt < 0 {
+		return nil, ErrInvalidFeedCursor
+	}
+	parsedID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil, ErrInvalidFeedCursor
+	}
+	cursor.ID = uint(parsedID)
+	return cursor, nil
+}
+
+// Database interface for follow and feed operations
+type FollowDatabaseServiceInterface interface {
+	UserExists(userID uint) (bool, error)
+	FollowUser(followerID, followeeID uint) (*FollowResponse, error)
+	UnfollowUser(followerID, followeeID uint) error
+	GetFeed(userID uint, query FeedQuery) ([]*FeedItemResponse, *FeedCursor, error)
+}
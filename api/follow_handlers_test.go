@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_Follow(t *testing.T) {
+	t.Run("Follows user", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("UserExists", uint(42)).Return(true, nil)
+		mockDB.On("FollowUser", user.ID, uint(42)).Return(&FollowResponse{UserID: 42, Following: true, Followers: 3}, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/users/42/follow", token, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"followers":3`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects following yourself", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/follow", user.ID), token, nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockDB.AssertNotCalled(t, "FollowUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		e, mockDB, _, token := setupAPITest(t)
+
+		mockDB.On("UserExists", uint(99)).Return(false, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/users/99/follow", token, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		e, _, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/users/42/follow", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Unfollows user", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("UnfollowUser", user.ID, uint(42)).Return(nil)
+
+		rec := serveJSON(e, http.MethodDelete, "/api/v1/users/42/follow", token, nil)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+}
+
+func TestAPI_Feed(t *testing.T) {
+	t.Run("Returns page with next cursor", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		cursor := &FeedCursor{CreatedAt: 1700000000, ID: 12}
+		mockDB.On("GetFeed", user.ID, FeedQuery{Types: []string{"course_review", "follow"}, Limit: 2}).Return([]*FeedItemResponse{
+			{ID: 14, ActivityType: "course_review", UserID: 5, UserName: "Sam", Summary: "Sam reviewed Pebble Creek"},
+			{ID: 12, ActivityType: "follow", UserID: 5, UserName: "Sam", Summary: "Sam started following Alex"},
+		}, cursor, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/feed?types=course_review,Follow&limit=2", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response APIResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		require.NotNil(t, response.Meta)
+		assert.Equal(t, cursor.Encode(), response.Meta.NextCursor)
+		assert.Len(t, response.Data.([]interface{}), 2)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Passes cursor through", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		cursor := FeedCursor{CreatedAt: 1700000000, ID: 12}
+		mockDB.On("GetFeed", user.ID, FeedQuery{Cursor: &cursor, Limit: 20}).Return([]*FeedItemResponse{}, nil, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/feed?cursor="+cursor.Encode(), token, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "next_cursor")
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects bad parameters", func(t *testing.T) {
+		for _, query := range []string{"types=tee_time", "limit=500", "cursor=not-a-cursor"} {
+			e, mockDB, _, token := setupAPITest(t)
+
+			rec := serveJSON(e, http.MethodGet, "/api/v1/feed?"+query, token, nil)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			mockDB.AssertNotCalled(t, "GetFeed", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestFeedCursor_RoundTrip(t *testing.T) {
+	cursor := FeedCursor{CreatedAt: 1700000000, ID: 12}
+	decoded, err := DecodeFeedCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, *decoded)
+
+	_, err = DecodeFeedCursor("MTcwMDAwMDAwMA")
+	assert.ErrorIs(t, err, ErrInvalidFeedCursor)
+}
@@ -0,0 +1,468 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultNearbyRadiusKm = 10.0
+	maxNearbyRadiusKm     = 500.0
+	defaultNearestCount   = 10
+	maxNearestCount       = 50
+	defaultClusterZoom    = 10
+	defaultMaxClusterSize = 50
+	// mapExportFlushInterval is how many courses an export writes between flushes
+	mapExportFlushInterval = 200
+)
+
+// ErrGeocodingUnavailable is returned by geocoding lookups when no geocoding
+// provider is configured
+var ErrGeocodingUnavailable = errors.New("geocoding is not configured")
+
+// GeoHandler handles location queries for the map: courses within a radius,
+// the nearest courses to a point, courses inside the visible bounds,
+// clustered markers for zoomed-out views, address geocoding, and GeoJSON,
+// KML and GPX exports
+type GeoHandler struct {
+	dbService GeoDatabaseServiceInterface
+}
+
+// NearbyRequest is a point to search around, with optional tag and amenity facets
+type NearbyRequest struct {
+	Latitude  float64
+	Longitude float64
+	Radius    float64 // in kilometers; unused by nearest-course queries
+	Tags      []string
+	Amenities []string
+}
+
+// NewGeoHandler creates a new geo handler
+func NewGeoHandler(dbService GeoDatabaseServiceInterface) *GeoHandler {
+	return &GeoHandler{dbService: dbService}
+}
+
+// GetNearbyCourses returns courses within a radius of a point, nearest first,
+// with each course's distance
+func (h *GeoHandler) GetNearbyCourses(c echo.Context) error {
+	req, message := parseNearbyRequest(c)
+	if message != "" {
+		return BadRequestError(c, message)
+	}
+
+	req.Radius = defaultNearbyRadiusKm
+	if value := c.QueryParam("radius"); value != "" {
+		radius, err := strconv.ParseFloat(value, 64)
+		if err != nil || radius <= 0 || radius > maxNearbyRadiusKm {
+			return BadRequestError(c, "Radius must be more than 0 and at most 500 kilometers")
+		}
+		req.Radius = radius
+	}
+
+	pagination := GetPagination(c)
+
+	var userID *uint
+	if uid, err := GetUserID(c); err == nil {
+		userID = &uid
+	}
+
+	courses, total, err := h.dbService.GetNearbyMapCourses(req, userID, pagination.Page, pagination.PerPage)
+	if err != nil {
+		return InternalServerError(c, "Failed to find nearby courses")
+	}
+
+	meta := &APIMeta{
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+		Total:      total,
+		TotalPages: (total + pagination.PerPage - 1) / pagination.PerPage,
+	}
+
+	return SuccessResponseWithMeta(c, courses, meta)
+}
+
+// GetNearestCourses returns the k courses closest to a point, however far away
+func (h *GeoHandler) GetNearestCourses(c echo.Context) error {
+	req, message := parseNearbyRequest(c)
+	if message != "" {
+		return BadRequestError(c, message)
+	}
+
+	k := defaultNearestCount
+	if value := c.QueryParam("k"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxNearestCount {
+			return BadRequestError(c, "k must be between 1 and 50")
+		}
+		k = parsed
+	}
+
+	var userID *uint
+	if uid, err := GetUserID(c); err == nil {
+		userID = &uid
+	}
+
+	courses, err := h.dbService.GetNearestCourses(req, k, userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to find nearest courses")
+	}
+
+	return SuccessResponse(c, courses)
+}
+
+// GetCoursesInBounds returns courses within geographic bounds
+func (h *GeoHandler) GetCoursesInBounds(c echo.Context) error {
+	var bounds BoundsRequest
+	if err := c.Bind(&bounds); err != nil {
+		return BadRequestError(c, "Invalid bounds parameters")
+	}
+
+	// Validate bounds
+	if bounds.NorthLat <= bounds.SouthLat {
+		return BadRequestError(c, "North latitude must be greater than south latitude")
+	}
+
+	if bounds.EastLng <= bounds.WestLng {
+		return BadRequestError(c, "East longitude must be greater than west longitude")
+	}
+
+	// Validate rating range
+	if bounds.MinRating != nil && bounds.MaxRating != nil && *bounds.MinRating > *bounds.MaxRating {
+		return BadRequestError(c, "Minimum rating cannot be greater than maximum rating")
+	}
+
+	// Validate tag and amenity facets
+	if message := validateAttributeFilters(&bounds.Tags, &bounds.Amenities); message != "" {
+		return BadRequestError(c, message)
+	}
+
+	// Get user ID if authenticated
+	var userID *uint
+	if uid, err := GetUserID(c); err == nil {
+		userID = &uid
+	}
+
+	courses, err := h.dbService.GetCoursesInBounds(&bounds, userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve courses in bounds")
+	}
+
+	return SuccessResponse(c, courses)
+}
+
+// GetClusteredCourses returns clustered course data for efficient map rendering
+func (h *GeoHandler) GetClusteredCourses(c echo.Context) error {
+	var bounds BoundsRequest
+	if err := c.Bind(&bounds); err != nil {
+		return BadRequestError(c, "Invalid bounds parameters")
+	}
+
+	// Get zoom level for clustering
+	zoomLevelParam := c.QueryParam("zoom")
+	zoomLevel := defaultClusterZoom
+	if zoomLevelParam != "" {
+		if z, err := strconv.Atoi(zoomLevelParam); err == nil && z >= 1 && z <= 20 {
+			zoomLevel = z
+		}
+	}
+
+	// Validate tag and amenity facets
+	if message := validateAttributeFilters(&bounds.Tags, &bounds.Amenities); message != "" {
+		return BadRequestError(c, message)
+	}
+
+	// Get user ID if authenticated
+	var userID *uint
+	if uid, err := GetUserID(c); err == nil {
+		userID = &uid
+	}
+
+	clusters, err := h.dbService.GetClusteredCourses(&bounds, userID, zoomLevel, parseMaxClusterSize(c))
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve clustered courses")
+	}
+
+	return SuccessResponse(c, clusters)
+}
+
+// ExpandCourseCluster returns the clusters a cluster splits into at the next
+// zoom level where its courses separate, so the map can drill down without
+// fetching the whole viewport again
+func (h *GeoHandler) ExpandCourseCluster(c echo.Context) error {
+	tags := c.QueryParams()["tags"]
+	amenities := c.QueryParams()["amenities"]
+	if message := validateAttributeFilters(&tags, &amenities); message != "" {
+		return BadRequestError(c, message)
+	}
+
+	var userID *uint
+	if uid, err := GetUserID(c); err == nil {
+		userID = &uid
+	}
+
+	expansion, err := h.dbService.ExpandCourseCluster(c.Param("id"), tags, amenities, userID, parseMaxClusterSize(c))
+	if err != nil {
+		return InternalServerError(c, "Failed to expand cluster")
+	}
+	if expansion == nil {
+		return NotFoundError(c, "Cluster")
+	}
+
+	return SuccessResponse(c, expansion)
+}
+
+// GeocodeAddress geocodes an address to coordinates
+func (h *GeoHandler) GeocodeAddress(c echo.Context) error {
+	var req GeocodeRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+
+	if len(req.Address) < 5 || len(req.Address) > 200 {
+		return ValidationError(c, map[string]string{
+			"address": "Address must be between 5 and 200 characters long",
+		})
+	}
+
+	result, err := h.dbService.GeocodeAddress(req.Address)
+	if err != nil {
+		if errors.Is(err, ErrGeocodingUnavailable) {
+			return ServiceUnavailableError(c, "Geocoding is not available")
+		}
+		return InternalServerError(c, "Geocoding failed")
+	}
+	if result == nil {
+		return NotFoundError(c, "Address")
+	}
+
+	return SuccessResponse(c, result)
+}
+
+// ReverseGeocode converts coordinates to an address
+func (h *GeoHandler) ReverseGeocode(c echo.Context) error {
+	latitude, longitude, message := parsePoint(c)
+	if message != "" {
+		return BadRequestError(c, message)
+	}
+
+	result, err := h.dbService.ReverseGeocode(latitude, longitude)
+	if err != nil {
+		if errors.Is(err, ErrGeocodingUnavailable) {
+			return ServiceUnavailableError(c, "Geocoding is not available")
+		}
+		return InternalServerError(c, "Reverse geocoding failed")
+	}
+	if result == nil {
+		return NotFoundError(c, "Address")
+	}
+
+	return SuccessResponse(c, result)
+}
+
+// ExportCourses streams the located courses matching a course search as
+// GeoJSON, KML or GPX, picked by the path's extension. It takes the course
+// search parameters, optional bounds and a property set.
+func (h *GeoHandler) ExportCourses(c echo.Context) error {
+	format := MapExportGeoJSON
+	switch {
+	case strings.HasSuffix(c.Path(), ".kml"):
+		format = MapExportKML
+	case strings.HasSuffix(c.Path(), ".gpx"):
+		format = MapExportGPX
+	}
+
+	req := &MapExportRequest{Properties: c.QueryParam("properties")}
+	if err := c.Bind(&req.Search); err != nil {
+		return BadRequestError(c, "Invalid search parameters")
+	}
+	if message := validateCourseSearch(&req.Search); message != "" {
+		return BadRequestError(c, message)
+	}
+
+	if req.Properties == "" {
+		req.Properties = MapPropertiesStandard
+	}
+	if !contains(MapPropertySets, req.Properties) {
+		return BadRequestError(c, "Properties must be one of minimal, standard or full")
+	}
+
+	bounds, message := parseExportBounds(c)
+	if message != "" {
+		return BadRequestError(c, message)
+	}
+	req.Bounds = bounds
+
+	// Headers are held back until the first course, so a failed lookup can
+	// still answer with an error
+	res := c.Response()
+	encoder := newMapExportEncoder(format, res, req.Properties, c.Scheme()+"://"+c.Request().Host)
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		res.Header().Set(echo.HeaderContentType, mapExportContentTypes[format])
+		if format != MapExportGeoJSON {
+			res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="courses.%s"`, format))
+		}
+		res.WriteHeader(http.StatusOK)
+		return encoder.Begin()
+	}
+
+	written := 0
+	err := h.dbService.ExportMapCourses(req, func(course *MapExportCourse) error {
+		if err := start(); err != nil {
+			return err
+		}
+		if err := encoder.Course(course); err != nil {
+			return err
+		}
+		written++
+		if written%mapExportFlushInterval == 0 {
+			res.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			return InternalServerError(c, "Failed to export courses")
+		}
+		// The response is under way, so the document is left unfinished
+		return err
+	}
+
+	if err := start(); err != nil {
+		return err
+	}
+	return encoder.End()
+}
+
+// parseExportBounds reads the optional north_lat, south_lat, east_lng and
+// west_lng parameters, which come all together or not at all
+func parseExportBounds(c echo.Context) (*BoundsRequest, string) {
+	params := []string{"north_lat", "south_lat", "east_lng", "west_lng"}
+	values := make([]float64, len(params))
+	given := 0
+	for i, param := range params {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		given++
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, "Invalid " + param
+		}
+		values[i] = parsed
+	}
+	if given == 0 {
+		return nil, ""
+	}
+	if given < len(params) {
+		return nil, "Bounds need north_lat, south_lat, east_lng and west_lng"
+	}
+
+	bounds := &BoundsRequest{NorthLat: values[0], SouthLat: values[1], EastLng: values[2], WestLng: values[3]}
+	if bounds.NorthLat > 90 || bounds.SouthLat < -90 || bounds.EastLng > 180 || bounds.WestLng < -180 {
+		return nil, "Bounds must be within -90 to 90 latitude and -180 to 180 longitude"
+	}
+	if bounds.NorthLat <= bounds.SouthLat {
+		return nil, "North latitude must be greater than south latitude"
+	}
+	if bounds.EastLng <= bounds.WestLng {
+		return nil, "East longitude must be greater than west longitude"
+	}
+	return bounds, ""
+}
+
+// parseMaxClusterSize reads the largest cluster whose courses are listed
+// inline; bigger clusters are expanded by ID instead
+func parseMaxClusterSize(c echo.Context) int {
+	maxClusterSize := defaultMaxClusterSize
+	if s, err := strconv.Atoi(c.QueryParam("max_cluster_size")); err == nil && s >= 10 && s <= 1000 {
+		maxClusterSize = s
+	}
+	return maxClusterSize
+}
+
+// parseNearbyRequest reads the lat, lng, tags and amenities parameters shared
+// by radius and nearest-course queries
+func parseNearbyRequest(c echo.Context) (*NearbyRequest, string) {
+	latitude, longitude, message := parsePoint(c)
+	if message != "" {
+		return nil, message
+	}
+
+	req := &NearbyRequest{
+		Latitude:  latitude,
+		Longitude: longitude,
+		Tags:      c.QueryParams()["tags"],
+		Amenities: c.QueryParams()["amenities"],
+	}
+	if message := validateAttributeFilters(&req.Tags, &req.Amenities); message != "" {
+		return nil, message
+	}
+	return req, ""
+}
+
+// parsePoint reads the lat and lng query parameters
+func parsePoint(c echo.Context) (float64, float64, string) {
+	latParam := c.QueryParam("lat")
+	lngParam := c.QueryParam("lng")
+	if latParam == "" || lngParam == "" {
+		return 0, 0, "Latitude and longitude are required"
+	}
+
+	latitude, err := strconv.ParseFloat(latParam, 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return 0, 0, "Invalid latitude"
+	}
+
+	longitude, err := strconv.ParseFloat(lngParam, 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return 0, 0, "Invalid longitude"
+	}
+	return latitude, longitude, ""
+}
+
+// RegisterRoutes registers geo query routes
+func (h *GeoHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Public routes (optionally authenticated)
+	g.GET("/map/courses/nearby", h.GetNearbyCourses, OptionalJWTMiddleware(jwtService))
+	g.GET("/map/courses/nearest", h.GetNearestCourses, OptionalJWTMiddleware(jwtService))
+	g.GET("/map/courses/bounds", h.GetCoursesInBounds, OptionalJWTMiddleware(jwtService))
+	g.GET("/map/courses/clusters", h.GetClusteredCourses, OptionalJWTMiddleware(jwtService))
+	g.GET("/map/courses/clusters/:id", h.ExpandCourseCluster, OptionalJWTMiddleware(jwtService))
+
+	// Exports (no authentication required)
+	g.GET("/map/courses.geojson", h.ExportCourses)
+	g.GET("/map/courses.kml", h.ExportCourses)
+	g.GET("/map/courses.gpx", h.ExportCourses)
+
+	// Geocoding routes (no authentication required)
+	g.POST("/map/geocode", h.GeocodeAddress)
+	g.GET("/map/reverse-geocode", h.ReverseGeocode)
+}
+
+// GeoDatabaseServiceInterface defines database operations for geo queries
+type GeoDatabaseServiceInterface interface {
+	GetNearbyMapCourses(req *NearbyRequest, userID *uint, page, perPage int) ([]*MapCourseResponse, int, error)
+	GetNearestCourses(req *NearbyRequest, k int, userID *uint) ([]*MapCourseResponse, error)
+	GetCoursesInBounds(bounds *BoundsRequest, userID *uint) ([]*MapCourseResponse, error)
+	GetClusteredCourses(bounds *BoundsRequest, userID *uint, zoomLevel, maxClusterSize int) ([]*CourseClusterResponse, error)
+	// ExpandCourseCluster returns nil when no cluster has that ID
+	ExpandCourseCluster(clusterID string, tags, amenities []string, userID *uint, maxClusterSize int) (*ClusterExpansionResponse, error)
+	// GeocodeAddress and ReverseGeocode return nil when nothing matches, and
+	// ErrGeocodingUnavailable when geocoding is off
+	GeocodeAddress(address string) (*GeocodeResponse, error)
+	ReverseGeocode(lat, lng float64) (*GeocodeResponse, error)
+	// ExportMapCourses hands each located course matching the request to emit,
+	// in search order, stopping at the first error emit returns
+	ExportMapCourses(req *MapExportRequest, emit func(*MapExportCourse) error) error
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPI_NearbyCourses(t *testing.T) {
+	t.Run("Returns courses with their distance", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		distance := 4.4
+		mockDB.On("GetNearbyMapCourses", mock.MatchedBy(func(req *NearbyRequest) bool {
+			return req.Latitude == 35.19 && req.Longitude == -79.47 && req.Radius == 25 &&
+				assert.ObjectsAreEqual([]string{"range"}, req.Amenities)
+		}), (*uint)(nil), 2, 5).Return([]*MapCourseResponse{{ID: 4, Name: "Mid Pines", Distance: &distance}}, 6, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses/nearby?lat=35.19&lng=-79.47&radius=25&amenities=Range&page=2&per_page=5", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"distance":4.4`)
+		assert.Contains(t, rec.Body.String(), `"total":6`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Defaults the radius", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("GetNearbyMapCourses", mock.MatchedBy(func(req *NearbyRequest) bool {
+			return req.Radius == 10
+		}), (*uint)(nil), 1, 20).Return([]*MapCourseResponse{}, 0, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses/nearby?lat=35&lng=-79", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects bad points and radii", func(t *testing.T) {
+		for _, query := range []string{"lat=35", "lat=91&lng=0", "lat=0&lng=-181", "lat=0&lng=0&radius=0", "lat=0&lng=0&radius=501", "lat=0&lng=0&tags=spooky"} {
+			e, mockDB, _, _ := setupAPITest(t)
+
+			rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses/nearby?"+query, "", nil)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			mockDB.AssertNotCalled(t, "GetNearbyMapCourses", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestAPI_NearestCourses(t *testing.T) {
+	t.Run("Returns the k nearest", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("GetNearestCourses", mock.MatchedBy(func(req *NearbyRequest) bool {
+			return req.Latitude == 36 && req.Longitude == -121
+		}), 3, (*uint)(nil)).Return([]*MapCourseResponse{{ID: 1, Name: "Pebble Beach"}}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses/nearest?lat=36&lng=-121&k=3", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"name":"Pebble Beach"`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects k out of range", func(t *testing.T) {
+		for _, k := range []string{"0", "51", "many"} {
+			e, mockDB, _, _ := setupAPITest(t)
+
+			rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses/nearest?lat=36&lng=-121&k="+k, "", nil)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, k)
+			mockDB.AssertNotCalled(t, "GetNearestCourses", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestAPI_ClusteredCourses(t *testing.T) {
+	t.Run("Returns clusters with expandable IDs", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("GetClusteredCourses", mock.MatchedBy(func(bounds *BoundsRequest) bool {
+			return bounds.NorthLat == 36 && bounds.WestLng == -80
+		}), (*uint)(nil), 6, 20).Return([]*CourseClusterResponse{{ID: "6-284-817", CourseCount: 3, ZoomLevel: 6}}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses/clusters?north_lat=36&south_lat=35&east_lng=-79&west_lng=-80&zoom=6&max_cluster_size=20", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"id":"6-284-817"`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Expands a cluster", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("ExpandCourseCluster", "6-284-817", []string(nil), []string{"range"}, (*uint)(nil), 50).Return(&ClusterExpansionResponse{
+			ClusterID:     "6-284-817",
+			ExpansionZoom: 9,
+			Clusters:      []*CourseClusterResponse{{ID: "9-2275-6538", CourseCount: 2, ZoomLevel: 9}, {ID: "9-2281-6534", CourseCount: 1, ZoomLevel: 9}},
+		}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses/clusters/6-284-817?amenities=Range", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"expansion_zoom":9`)
+		assert.Contains(t, rec.Body.String(), `"id":"9-2281-6534"`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Unknown clusters are not found", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("ExpandCourseCluster", "2-0-0", []string(nil), []string(nil), (*uint)(nil), 50).Return(nil, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses/clusters/2-0-0", "", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Expansion validates filters", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses/clusters/2-0-0?tags=spooky", "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockDB.AssertNotCalled(t, "ExpandCourseCluster", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAPI_Geocoding(t *testing.T) {
+	t.Run("Geocodes an address", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("GeocodeAddress", "1 Carolina Vista Dr, Pinehurst, NC").Return(&GeocodeResponse{
+			Address: "1 Carolina Vista Dr, Pinehurst, NC", Latitude: 35.1907, Longitude: -79.4704,
+			Components: &AddressComponents{City: "Pinehurst", State: "NC"},
+		}, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/map/geocode", "", GeocodeRequest{Address: "1 Carolina Vista Dr, Pinehurst, NC"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"latitude":35.1907`)
+		assert.Contains(t, rec.Body.String(), `"city":"Pinehurst"`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Unknown addresses are not found", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("GeocodeAddress", "Nowhere at all").Return(nil, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/map/geocode", "", GeocodeRequest{Address: "Nowhere at all"})
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Unavailable without a provider", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("GeocodeAddress", "Pinehurst, NC").Return(nil, ErrGeocodingUnavailable)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/map/geocode", "", GeocodeRequest{Address: "Pinehurst, NC"})
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+		e, mockDB, _, _ = setupAPITest(t)
+		mockDB.On("ReverseGeocode", 35.19, -79.47).Return(nil, ErrGeocodingUnavailable)
+
+		rec = serveJSON(e, http.MethodGet, "/api/v1/map/reverse-geocode?lat=35.19&lng=-79.47", "", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("Reverse geocodes a point", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("ReverseGeocode", 35.19, -79.47).Return(&GeocodeResponse{FormattedAddress: "Pinehurst, North Carolina"}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/reverse-geocode?lat=35.19&lng=-79.47", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"formatted_address":"Pinehurst, North Carolina"`)
+	})
+
+	t.Run("Rejects bad input", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/map/geocode", "", GeocodeRequest{Address: "NC"})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "validation_error")
+		mockDB.AssertNotCalled(t, "GeocodeAddress", mock.Anything)
+
+		e, mockDB, _, _ = setupAPITest(t)
+		rec = serveJSON(e, http.MethodGet, "/api/v1/map/reverse-geocode?lat=91&lng=0", "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockDB.AssertNotCalled(t, "ReverseGeocode", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAPI_ExportCourses(t *testing.T) {
+	course := &MapExportCourse{ID: 4, Name: "Mid Pines", Address: "Southern Pines, NC", Latitude: 35.1618, Longitude: -79.4379, Rating: "A"}
+
+	t.Run("Streams GeoJSON for a search", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("ExportMapCourses", mock.MatchedBy(func(req *MapExportRequest) bool {
+			return req.Search.Query == "pines" && req.Search.MinPrice == "$$" && req.Properties == MapPropertiesStandard &&
+				req.Bounds != nil && req.Bounds.NorthLat == 36 && req.Bounds.WestLng == -80 &&
+				assert.ObjectsAreEqual([]string{"range"}, req.Search.Amenities)
+		})).Return([]*MapExportCourse{course}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses.geojson?q=pines&min_price=$$&amenities=range&north_lat=36&south_lat=35&east_lng=-79&west_lng=-80", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/geo+json", rec.Header().Get("Content-Type"))
+		assert.Empty(t, rec.Header().Get("Content-Disposition"))
+		assert.Contains(t, rec.Body.String(), `"type":"FeatureCollection"`)
+		assert.Contains(t, rec.Body.String(), `"url":"http://example.com/course/4"`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Downloads KML and GPX", func(t *testing.T) {
+		for format, contentType := range map[string]string{"kml": "application/vnd.google-earth.kml+xml", "gpx": "application/gpx+xml"} {
+			e, mockDB, _, _ := setupAPITest(t)
+
+			mockDB.On("ExportMapCourses", mock.MatchedBy(func(req *MapExportRequest) bool {
+				return req.Bounds == nil && req.Properties == MapPropertiesMinimal
+			})).Return([]*MapExportCourse{course}, nil)
+
+			rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses."+format+"?properties=minimal", "", nil)
+			assert.Equal(t, http.StatusOK, rec.Code, format)
+			assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="courses.`+format+`"`, rec.Header().Get("Content-Disposition"))
+			assert.Contains(t, rec.Body.String(), "<name>Mid Pines</name>")
+		}
+	})
+
+	t.Run("Empty exports are still documents", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("ExportMapCourses", mock.Anything).Return(nil, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses.geojson", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"type":"FeatureCollection","features":[]}`, rec.Body.String())
+	})
+
+	t.Run("Failures before any course are errors", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("ExportMapCourses", mock.Anything).Return(nil, assert.AnError)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses.kml", "", nil)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "application/json")
+	})
+
+	t.Run("Rejects bad parameters", func(t *testing.T) {
+		for _, query := range []string{"properties=everything", "north_lat=36&south_lat=35", "north_lat=35&south_lat=36&east_lng=-79&west_lng=-80", "north_lat=north&south_lat=35&east_lng=-79&west_lng=-80", "tags=spooky", "min_price=cheap"} {
+			e, mockDB, _, _ := setupAPITest(t)
+
+			rec := serveJSON(e, http.MethodGet, "/api/v1/map/courses.geojson?"+query, "", nil)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			mockDB.AssertNotCalled(t, "ExportMapCourses", mock.Anything)
+		}
+	})
+}
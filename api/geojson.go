@@ -0,0 +1,95 @@
+package api
+
+// GeoJSONFeatureCollection is a GeoJSON FeatureCollection (RFC 7946)
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature is a single GeoJSON Feature
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONGeometry is a GeoJSON Point; coordinates are [longitude, latitude]
+type GeoJSONGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// NewFeatureCollection returns an empty FeatureCollection that encodes its
+// features as [] rather than null
+func NewFeatureCollection() GeoJSONFeatureCollection {
+	return GeoJSONFeatureCollection{Type: "FeatureCollection", Features: []GeoJSONFeature{}}
+}
+
+// NewPointFeature returns a Point feature at the given position
+func NewPointFeature(latitude, longitude float64, properties map[string]interface{}) GeoJSONFeature {
+	return GeoJSONFeature{
+		Type: "Feature",
+		Geometry: GeoJSONGeometry{
+			Type:        "Point",
+			Coordinates: []float64{longitude, latitude},
+		},
+		Properties: properties,
+	}
+}
+
+// GeoJSONShapeCollection is a FeatureCollection whose features can be any
+// shape, such as the tees, greens and hazards of a hole layout
+type GeoJSONShapeCollection struct {
+	Type     string                `json:"type"`
+	Features []GeoJSONShapeFeature `json:"features"`
+}
+
+// GeoJSONShapeFeature is a Feature with a Point, LineString or Polygon geometry
+type GeoJSONShapeFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONShape           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONShape is a Point, LineString or Polygon. Coordinates are a
+// [longitude, latitude] position, a list of positions, or a list of rings.
+type GeoJSONShape struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// NewShapeCollection returns an empty shape FeatureCollection that encodes
+// its features as [] rather than null
+func NewShapeCollection() GeoJSONShapeCollection {
+	return GeoJSONShapeCollection{Type: "FeatureCollection", Features: []GeoJSONShapeFeature{}}
+}
+
+// NewShapePointFeature returns a Point shape feature at the given position
+func NewShapePointFeature(latitude, longitude float64, properties map[string]interface{}) GeoJSONShapeFeature {
+	return newShapeFeature("Point", []float64{longitude, latitude}, properties)
+}
+
+// NewLineFeature returns a LineString feature through [longitude, latitude] positions
+func NewLineFeature(positions [][]float64, properties map[string]interface{}) GeoJSONShapeFeature {
+	return newShapeFeature("LineString", positions, properties)
+}
+
+// NewPolygonFeature returns a Polygon feature with a single outer ring of
+// [longitude, latitude] positions, closing the ring if it isn't already
+func NewPolygonFeature(ring [][]float64, properties map[string]interface{}) GeoJSONShapeFeature {
+	if len(ring) > 0 {
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			ring = append(ring[:len(ring):len(ring)], first)
+		}
+	}
+	return newShapeFeature("Polygon", [][][]float64{ring}, properties)
+}
+
+func newShapeFeature(kind string, coordinates interface{}, properties map[string]interface{}) GeoJSONShapeFeature {
+	return GeoJSONShapeFeature{
+		Type:       "Feature",
+		Geometry:   GeoJSONShape{Type: kind, Coordinates: coordinates},
+		Properties: properties,
+	}
+}
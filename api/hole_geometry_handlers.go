@@ -0,0 +1,274 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxHoleNumber = 18
+	// maxHoleGeometryImportBytes is the largest GeoJSON or OSM file an import accepts
+	maxHoleGeometryImportBytes = 10 << 20
+)
+
+// HoleGeometryError is returned when hole geometry or an import fails
+// validation. Its message says what to fix.
+type HoleGeometryError struct {
+	Message string
+}
+
+func (e *HoleGeometryError) Error() string {
+	return e.Message
+}
+
+// HoleGeometryHandler handles the mapped layout of each hole on a course:
+// tees, green, fairway and hazards
+type HoleGeometryHandler struct {
+	dbService HoleGeometryDatabaseServiceInterface
+}
+
+// HolePoint is a position on a hole
+type HolePoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// HoleTeeDetails is one set of tees on a hole
+type HoleTeeDetails struct {
+	Name  string    `json:"name"`
+	Point HolePoint `json:"point"`
+}
+
+// HoleHazardDetails is the outline of a bunker, water hazard or lateral water hazard
+type HoleHazardDetails struct {
+	Kind    string      `json:"kind"`
+	Polygon []HolePoint `json:"polygon"`
+}
+
+// HoleHazardPointDetails marks a hazard that isn't outlined by a single point
+type HoleHazardPointDetails struct {
+	Kind  string    `json:"kind"`
+	Point HolePoint `json:"point"`
+}
+
+// HoleGeometryDetails is the layout of one hole. Polygons are a single outer
+// ring; the green centre defaults to the middle of the green.
+type HoleGeometryDetails struct {
+	Tees         []HoleTeeDetails         `json:"tees"`
+	Green        []HolePoint              `json:"green,omitempty"`
+	GreenCenter  *HolePoint               `json:"green_center,omitempty"`
+	GreenFront   *HolePoint               `json:"green_front,omitempty"`
+	GreenBack    *HolePoint               `json:"green_back,omitempty"`
+	Fairway      []HolePoint              `json:"fairway,omitempty"`
+	Hazards      []HoleHazardDetails      `json:"hazards,omitempty"`
+	HazardPoints []HoleHazardPointDetails `json:"hazard_points,omitempty"`
+}
+
+// HoleGeometryResponse is a hole's saved layout with its par and yardage from
+// the course's card
+type HoleGeometryResponse struct {
+	CourseID   uint                `json:"course_id"`
+	HoleNumber int                 `json:"hole_number"`
+	Par        int                 `json:"par,omitempty"`
+	Yardage    int                 `json:"yardage,omitempty"`
+	Source     string              `json:"source"` // "manual", "geojson" or "osm"
+	Geometry   HoleGeometryDetails `json:"geometry"`
+	UpdatedAt  int64               `json:"updated_at"`
+}
+
+// HoleGeometryImportResponse summarizes an import
+type HoleGeometryImportResponse struct {
+	Format   string `json:"format"`
+	Holes    []int  `json:"holes"`
+	Features int    `json:"features"`
+	Skipped  int    `json:"skipped"`
+}
+
+// NewHoleGeometryHandler creates a new hole geometry handler
+func NewHoleGeometryHandler(dbService HoleGeometryDatabaseServiceInterface) *HoleGeometryHandler {
+	return &HoleGeometryHandler{dbService: dbService}
+}
+
+// GetCourseLayout returns every mapped hole on a course as a GeoJSON
+// FeatureCollection
+func (h *HoleGeometryHandler) GetCourseLayout(c echo.Context) error {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+
+	layout, err := h.dbService.GetCourseHoleLayout(uint(courseID))
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve hole layout")
+	}
+	if layout == nil {
+		return NotFoundError(c, "Course")
+	}
+
+	return SuccessResponse(c, layout)
+}
+
+// GetHoleGeometry returns one hole's layout
+func (h *HoleGeometryHandler) GetHoleGeometry(c echo.Context) error {
+	courseID, holeNumber, message := parseHoleParams(c)
+	if message != "" {
+		return BadRequestError(c, message)
+	}
+
+	hole, err := h.dbService.GetHoleGeometry(courseID, holeNumber)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve hole geometry")
+	}
+	if hole == nil {
+		return NotFoundError(c, "Hole geometry")
+	}
+
+	return SuccessResponse(c, hole)
+}
+
+// SaveHoleGeometry replaces one hole's layout. Only the course's creator can
+// map its holes.
+func (h *HoleGeometryHandler) SaveHoleGeometry(c echo.Context) error {
+	courseID, holeNumber, message := parseHoleParams(c)
+	if message != "" {
+		return BadRequestError(c, message)
+	}
+	userID, ok, err := h.courseOwner(c, courseID)
+	if !ok {
+		return err
+	}
+
+	var req HoleGeometryDetails
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+
+	hole, err := h.dbService.SaveHoleGeometry(userID, courseID, holeNumber, req)
+	if err != nil {
+		return holeGeometryError(c, err, "Failed to save hole geometry")
+	}
+
+	return SuccessResponse(c, hole)
+}
+
+// DeleteHoleGeometry removes one hole's layout
+func (h *HoleGeometryHandler) DeleteHoleGeometry(c echo.Context) error {
+	courseID, holeNumber, message := parseHoleParams(c)
+	if message != "" {
+		return BadRequestError(c, message)
+	}
+	if _, ok, err := h.courseOwner(c, courseID); !ok {
+		return err
+	}
+
+	deleted, err := h.dbService.DeleteHoleGeometry(courseID, holeNumber)
+	if err != nil {
+		return InternalServerError(c, "Failed to delete hole geometry")
+	}
+	if !deleted {
+		return NotFoundError(c, "Hole geometry")
+	}
+
+	return NoContentResponse(c)
+}
+
+// ImportHoleGeometry replaces the layout of every hole in an uploaded GeoJSON
+// FeatureCollection, OSM XML extract or Overpass JSON response. The file is
+// the request body.
+func (h *HoleGeometryHandler) ImportHoleGeometry(c echo.Context) error {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+	userID, ok, err := h.courseOwner(c, uint(courseID))
+	if !ok {
+		return err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, maxHoleGeometryImportBytes+1))
+	if err != nil {
+		return BadRequestError(c, "Failed to read import")
+	}
+	if len(data) > maxHoleGeometryImportBytes {
+		return BadRequestError(c, "Imports must be 10 MB or smaller")
+	}
+	if len(data) == 0 {
+		return BadRequestError(c, "Send the GeoJSON or OSM file as the request body")
+	}
+
+	summary, err := h.dbService.ImportHoleGeometry(userID, uint(courseID), data)
+	if err != nil {
+		return holeGeometryError(c, err, "Failed to import hole geometry")
+	}
+
+	return SuccessResponse(c, summary)
+}
+
+// courseOwner returns the signed-in user's ID. It writes the error response
+// and returns false when the user is signed out or didn't create the course.
+func (h *HoleGeometryHandler) courseOwner(c echo.Context, courseID uint) (uint, bool, error) {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return 0, false, UnauthorizedError(c, "Authentication required")
+	}
+
+	isOwner, err := h.dbService.IsUserCourseOwner(userID, courseID)
+	if err != nil {
+		return 0, false, NotFoundError(c, "Course")
+	}
+	if !isOwner {
+		return 0, false, ForbiddenError(c, "You can only map holes on courses you created")
+	}
+	return userID, true, nil
+}
+
+// parseHoleParams reads the course ID and hole number from the path,
+// returning a message for the first one that's invalid
+func parseHoleParams(c echo.Context) (uint, int, string) {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, 0, "Invalid course ID"
+	}
+	holeNumber, err := strconv.Atoi(c.Param("hole"))
+	if err != nil || holeNumber < 1 || holeNumber > maxHoleNumber {
+		return 0, 0, "Hole must be between 1 and 18"
+	}
+	return uint(courseID), holeNumber, ""
+}
+
+// holeGeometryError answers a failed save or import, passing validation
+// problems on to the user
+func holeGeometryError(c echo.Context, err error, message string) error {
+	var invalid *HoleGeometryError
+	if errors.As(err, &invalid) {
+		return BadRequestError(c, invalid.Message)
+	}
+	return InternalServerError(c, message)
+}
+
+// RegisterRoutes registers hole geometry routes
+func (h *HoleGeometryHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Public routes
+	g.GET("/courses/:id/holes/geometry", h.GetCourseLayout)
+	g.GET("/courses/:id/holes/:hole/geometry", h.GetHoleGeometry)
+
+	// Protected routes (course creator only)
+	g.PUT("/courses/:id/holes/:hole/geometry", h.SaveHoleGeometry, JWTMiddleware(jwtService))
+	g.DELETE("/courses/:id/holes/:hole/geometry", h.DeleteHoleGeometry, JWTMiddleware(jwtService))
+	g.POST("/courses/:id/holes/geometry/import", h.ImportHoleGeometry, JWTMiddleware(jwtService))
+}
+
+// Database interface for hole geometry operations
+type HoleGeometryDatabaseServiceInterface interface {
+	IsUserCourseOwner(userID, courseID uint) (bool, error)                        // Fails when the course doesn't exist
+	GetCourseHoleLayout(courseID uint) (*GeoJSONShapeCollection, error)           // Nil when the course doesn't exist
+	GetHoleGeometry(courseID uint, holeNumber int) (*HoleGeometryResponse, error) // Nil when the hole isn't mapped
+	// SaveHoleGeometry and ImportHoleGeometry return a *HoleGeometryError for
+	// geometry that fails validation
+	SaveHoleGeometry(userID, courseID uint, holeNumber int, details HoleGeometryDetails) (*HoleGeometryResponse, error)
+	DeleteHoleGeometry(courseID uint, holeNumber int) (bool, error) // False when the hole wasn't mapped
+	ImportHoleGeometry(userID, courseID uint, data []byte) (*HoleGeometryImportResponse, error)
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_HoleGeometry(t *testing.T) {
+	geometry := HoleGeometryDetails{
+		Tees:        []HoleTeeDetails{{Name: "Blue", Point: HolePoint{Latitude: 35.19, Longitude: -79.472}}},
+		GreenCenter: &HolePoint{Latitude: 35.193, Longitude: -79.47},
+	}
+
+	t.Run("Returns a course's layout without signing in", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		layout := NewShapeCollection()
+		layout.Features = append(layout.Features,
+			NewShapePointFeature(35.19, -79.472, map[string]interface{}{"hole": 1, "golf": "tee"}),
+			NewPolygonFeature([][]float64{{-79.4702, 35.1928}, {-79.4698, 35.1928}, {-79.4698, 35.1932}}, map[string]interface{}{"hole": 1, "golf": "green"}),
+		)
+		mockDB.On("GetCourseHoleLayout", uint(4)).Return(&layout, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/4/holes/geometry", "", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"type":"FeatureCollection"`)
+		assert.Contains(t, rec.Body.String(), `"coordinates":[[[-79.4702,35.1928],[-79.4698,35.1928],[-79.4698,35.1932],[-79.4702,35.1928]]]`, "closed ring")
+	})
+
+	t.Run("Returns 404 for unknown courses and unmapped holes", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+		mockDB.On("GetCourseHoleLayout", uint(99)).Return(nil, nil)
+		assert.Equal(t, http.StatusNotFound, serveJSON(e, http.MethodGet, "/api/v1/courses/99/holes/geometry", "", nil).Code)
+
+		e, mockDB, _, _ = setupAPITest(t)
+		mockDB.On("GetHoleGeometry", uint(4), 7).Return(nil, nil)
+		assert.Equal(t, http.StatusNotFound, serveJSON(e, http.MethodGet, "/api/v1/courses/4/holes/7/geometry", "", nil).Code)
+
+		e, mockDB, _, _ = setupAPITest(t)
+		assert.Equal(t, http.StatusBadRequest, serveJSON(e, http.MethodGet, "/api/v1/courses/4/holes/19/geometry", "", nil).Code)
+		mockDB.AssertNotCalled(t, "GetHoleGeometry", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Lets the course's creator map a hole", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("IsUserCourseOwner", user.ID, uint(4)).Return(true, nil)
+		mockDB.On("SaveHoleGeometry", user.ID, uint(4), 1, geometry).Return(&HoleGeometryResponse{
+			CourseID: 4, HoleNumber: 1, Par: 4, Source: "manual", Geometry: geometry,
+		}, nil)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/courses/4/holes/1/geometry", token, geometry)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"green_center":{"latitude":35.193,"longitude":-79.47}`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Only the creator can change holes", func(t *testing.T) {
+		requests := []struct {
+			method string
+			body   interface{}
+		}{
+			{http.MethodPut, geometry},
+			{http.MethodDelete, nil},
+		}
+		for _, request := range requests {
+			e, mockDB, user, token := setupAPITest(t)
+			mockDB.On("IsUserCourseOwner", user.ID, uint(4)).Return(false, nil)
+
+			rec := serveJSON(e, request.method, "/api/v1/courses/4/holes/1/geometry", token, request.body)
+			assert.Equal(t, http.StatusForbidden, rec.Code, request.method)
+			mockDB.AssertNotCalled(t, "SaveHoleGeometry", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			mockDB.AssertNotCalled(t, "DeleteHoleGeometry", mock.Anything, mock.Anything)
+		}
+
+		e, _, _, _ := setupAPITest(t)
+		rec := serveJSON(e, http.MethodPut, "/api/v1/courses/4/holes/1/geometry", "", geometry)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Explains invalid geometry", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("IsUserCourseOwner", user.ID, uint(4)).Return(true, nil)
+		mockDB.On("SaveHoleGeometry", user.ID, uint(4), 1, mock.Anything).Return(nil, &HoleGeometryError{Message: "Invalid hole geometry: the green crosses itself"})
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/courses/4/holes/1/geometry", token, geometry)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "the green crosses itself")
+	})
+
+	t.Run("Deletes a mapped hole", func(t *testing.T) {
+		for hole, status := range map[int]int{1: http.StatusNoContent, 2: http.StatusNotFound} {
+			e, mockDB, user, token := setupAPITest(t)
+			mockDB.On("IsUserCourseOwner", user.ID, uint(4)).Return(true, nil)
+			mockDB.On("DeleteHoleGeometry", uint(4), hole).Return(hole == 1, nil)
+
+			rec := serveJSON(e, http.MethodDelete, fmt.Sprintf("/api/v1/courses/4/holes/%d/geometry", hole), token, nil)
+			assert.Equal(t, status, rec.Code)
+		}
+	})
+
+	t.Run("Imports an uploaded file", func(t *testing.T) {
+		osm := `<osm><way id="1"><tag k="golf" v="hole"/></way></osm>`
+		upload := func(body string) (*httptest.ResponseRecorder, *MockDatabaseService) {
+			e, mockDB, user, token := setupAPITest(t)
+			mockDB.On("IsUserCourseOwner", user.ID, uint(4)).Return(true, nil)
+			mockDB.On("ImportHoleGeometry", user.ID, uint(4), []byte(osm)).Return(&HoleGeometryImportResponse{
+				Format: "osm", Holes: []int{1, 2}, Features: 9, Skipped: 1,
+			}, nil)
+
+			// The API only accepts JSON content types, so XML goes without one
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/courses/4/holes/geometry/import", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec, mockDB
+		}
+
+		rec, mockDB := upload(osm)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"holes":[1,2]`)
+		mockDB.AssertExpectations(t)
+
+		rec, mockDB = upload("")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockDB.AssertNotCalled(t, "ImportHoleGeometry", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
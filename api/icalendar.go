@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CalendarContentType is the media type of iCalendar (RFC 5545) documents
+const CalendarContentType = "text/calendar; charset=utf-8"
+
+// CalendarEvent is a single VEVENT in an iCalendar document
+type CalendarEvent struct {
+	UID         string // Stable across revisions so calendars update the event in place
+	Sequence    int    // Revision number; higher revisions replace lower ones
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Location    string
+	Description string
+	URL         string
+	Cancelled   bool
+	Latitude    *float64
+	Longitude   *float64
+}
+
+// NewCalendar encodes events as an iCalendar document. name is shown by
+// calendar apps that subscribe to the document as a feed.
+func NewCalendar(name string, events []CalendarEvent) []byte {
+	var buf bytes.Buffer
+	stamp := formatCalendarTime(time.Now())
+
+	writeCalendarLine(&buf, "BEGIN:VCALENDAR")
+	writeCalendarLine(&buf, "VERSION:2.0")
+	writeCalendarLine(&buf, "PRODID:-//Course Management//Outings//EN")
+	writeCalendarLine(&buf, "CALSCALE:GREGORIAN")
+	writeCalendarLine(&buf, "METHOD:PUBLISH")
+	if name != "" {
+		writeCalendarLine(&buf, "X-WR-CALNAME:"+escapeCalendarText(name))
+	}
+
+	for _, event := range events {
+		status := "CONFIRMED"
+		if event.Cancelled {
+			status = "CANCELLED"
+		}
+
+		writeCalendarLine(&buf, "BEGIN:VEVENT")
+		writeCalendarLine(&buf, "UID:"+event.UID)
+		writeCalendarLine(&buf, fmt.Sprintf("SEQUENCE:%d", event.Sequence))
+		writeCalendarLine(&buf, "DTSTAMP:"+stamp)
+		writeCalendarLine(&buf, "DTSTART:"+formatCalendarTime(event.Start))
+		writeCalendarLine(&buf, "DTEND:"+formatCalendarTime(event.End))
+		writeCalendarLine(&buf, "SUMMARY:"+escapeCalendarText(event.Summary))
+		if event.Location != "" {
+			writeCalendarLine(&buf, "LOCATION:"+escapeCalendarText(event.Location))
+		}
+		if event.Latitude != nil && event.Longitude != nil {
+			writeCalendarLine(&buf, fmt.Sprintf("GEO:%.6f;%.6f", *event.Latitude, *event.Longitude))
+		}
+		if event.Description != "" {
+			writeCalendarLine(&buf, "DESCRIPTION:"+escapeCalendarText(event.Description))
+		}
+		if event.URL != "" {
+			writeCalendarLine(&buf, "URL:"+event.URL)
+		}
+		writeCalendarLine(&buf, "STATUS:"+status)
+		writeCalendarLine(&buf, "END:VEVENT")
+	}
+
+	writeCalendarLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+func formatCalendarTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeCalendarText escapes a TEXT value (RFC 5545 section 3.3.11)
+func escapeCalendarText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	)
+	return replacer.Replace(text)
+}
+
+// writeCalendarLine writes a content line, folding it so no physical line is
+// longer than 75 octets and no UTF-8 sequence is split (RFC 5545 section 3.1)
+func writeCalendarLine(buf *bytes.Buffer, line string) {
+	const limit = 75
+
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		width = limit - 1 // The leading space counts towards the limit
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCalendar(t *testing.T) {
+	start := time.Date(2026, 5, 2, 14, 30, 0, 0, time.FixedZone("EDT", -4*60*60))
+
+	t.Run("Encodes events with escaped text", func(t *testing.T) {
+		body := string(NewCalendar("Outings", []CalendarEvent{{
+			UID:         "outing-7@example.com",
+			Sequence:    2,
+			Start:       start,
+			End:         start.Add(5 * time.Hour),
+			Summary:     "Saturday round; bring balls, lots",
+			Location:    "Pebble Creek",
+			Description: "Carts booked\nMeet at the range",
+			URL:         "https://golf.example.com/outings/7",
+		}}))
+
+		assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n"))
+		assert.True(t, strings.HasSuffix(body, "END:VCALENDAR\r\n"))
+		assert.Contains(t, body, "UID:outing-7@example.com\r\n")
+		assert.Contains(t, body, "SEQUENCE:2\r\n")
+		assert.Contains(t, body, "DTSTART:20260502T183000Z\r\n")
+		assert.Contains(t, body, "DTEND:20260502T233000Z\r\n")
+		assert.Contains(t, body, `SUMMARY:Saturday round\; bring balls\, lots`)
+		assert.Contains(t, body, `DESCRIPTION:Carts booked\nMeet at the range`)
+		assert.Contains(t, body, "URL:https://golf.example.com/outings/7\r\n")
+		assert.Contains(t, body, "STATUS:CONFIRMED\r\n")
+	})
+
+	t.Run("Marks cancelled events", func(t *testing.T) {
+		body := string(NewCalendar("", []CalendarEvent{{UID: "outing-1", Start: start, End: start, Cancelled: true}}))
+
+		assert.Contains(t, body, "STATUS:CANCELLED\r\n")
+		assert.NotContains(t, body, "X-WR-CALNAME")
+	})
+
+	t.Run("Folds long lines without splitting characters", func(t *testing.T) {
+		body := string(NewCalendar("", []CalendarEvent{{
+			UID:         "outing-1",
+			Start:       start,
+			End:         start,
+			Description: strings.Repeat("Fairways é ", 30),
+		}}))
+
+		for _, line := range strings.Split(strings.TrimSuffix(body, "\r\n"), "\r\n") {
+			assert.LessOrEqual(t, len(line), 75)
+			assert.True(t, strings.ToValidUTF8(line, "?") == line, "line %q splits a character", line)
+		}
+		unfolded := strings.ReplaceAll(body, "\r\n ", "")
+		assert.Contains(t, unfolded, "DESCRIPTION:"+strings.Repeat("Fairways é ", 30))
+	})
+}
@@ -124,6 +124,14 @@ func (m *MockDatabaseService) SearchCourses(search *CourseSearchRequest, userID
 	return args.Get(0).([]*CourseResponse), args.Int(1), args.Error(2)
 }
 
+func (m *MockDatabaseService) GetCourseFacetCounts(search *CourseSearchRequest) (*CourseFacetCounts, error) {
+	args := m.Called(search)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CourseFacetCounts), args.Error(1)
+}
+
 func (m *MockDatabaseService) GetNearbyCoures(lat, lng, radius float64, userID *uint, page, perPage int) ([]*CourseResponse, int, error) {
 	args := m.Called(lat, lng, radius, userID, page, perPage)
 	return args.Get(0).([]*CourseResponse), args.Int(1), args.Error(2)
@@ -201,11 +209,29 @@ func (m *MockDatabaseService) GetCoursesInBounds(bounds *BoundsRequest, userID *
 	return args.Get(0).([]*MapCourseResponse), args.Error(1)
 }
 
+func (m *MockDatabaseService) GetNearbyMapCourses(req *NearbyRequest, userID *uint, page, perPage int) ([]*MapCourseResponse, int, error) {
+	args := m.Called(req, userID, page, perPage)
+	return args.Get(0).([]*MapCourseResponse), args.Int(1), args.Error(2)
+}
+
+func (m *MockDatabaseService) GetNearestCourses(req *NearbyRequest, k int, userID *uint) ([]*MapCourseResponse, error) {
+	args := m.Called(req, k, userID)
+	return args.Get(0).([]*MapCourseResponse), args.Error(1)
+}
+
 func (m *MockDatabaseService) GetClusteredCourses(bounds *BoundsRequest, userID *uint, zoomLevel, maxClusterSize int) ([]*CourseClusterResponse, error) {
 	args := m.Called(bounds, userID, zoomLevel, maxClusterSize)
 	return args.Get(0).([]*CourseClusterResponse), args.Error(1)
 }
 
+func (m *MockDatabaseService) ExpandCourseCluster(clusterID string, tags, amenities []string, userID *uint, maxClusterSize int) (*ClusterExpansionResponse, error) {
+	args := m.Called(clusterID, tags, amenities, userID, maxClusterSize)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ClusterExpansionResponse), args.Error(1)
+}
+
 func (m *MockDatabaseService) GeocodeAddress(address string) (*GeocodeResponse, error) {
 	args := m.Called(address)
 	if args.Get(0) == nil {
@@ -214,6 +240,19 @@ func (m *MockDatabaseService) GeocodeAddress(address string) (*GeocodeResponse,
 	return args.Get(0).(*GeocodeResponse), args.Error(1)
 }
 
+// ExportMapCourses emits the mocked courses in order
+func (m *MockDatabaseService) ExportMapCourses(req *MapExportRequest, emit func(*MapExportCourse) error) error {
+	args := m.Called(req)
+	if courses, ok := args.Get(0).([]*MapExportCourse); ok {
+		for _, course := range courses {
+			if err := emit(course); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
 func (m *MockDatabaseService) ReverseGeocode(lat, lng float64) (*GeocodeResponse, error) {
 	args := m.Called(lat, lng)
 	if args.Get(0) == nil {
@@ -240,6 +279,469 @@ func (m *MockDatabaseService) GetMapStatistics() (*MapStatisticsResponse, error)
 	return args.Get(0).(*MapStatisticsResponse), args.Error(1)
 }
 
+// CommentDatabaseServiceInterface methods
+func (m *MockDatabaseService) CanViewReview(reviewID uint, userID *uint) (bool, error) {
+	args := m.Called(reviewID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetReviewComment(commentID uint) (*CommentResponse, error) {
+	args := m.Called(commentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CommentResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetReviewComments(reviewID uint, userID *uint, page, perPage int) ([]*CommentResponse, int, error) {
+	args := m.Called(reviewID, userID, page, perPage)
+	return args.Get(0).([]*CommentResponse), args.Int(1), args.Error(2)
+}
+
+func (m *MockDatabaseService) CreateReviewComment(userID, reviewID uint, req *CommentCreateRequest) (*CommentResponse, error) {
+	args := m.Called(userID, reviewID, req)
+	return args.Get(0).(*CommentResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) UpdateReviewComment(userID, commentID uint, req *CommentUpdateRequest) (*CommentResponse, error) {
+	args := m.Called(userID, commentID, req)
+	return args.Get(0).(*CommentResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) DeleteReviewComment(userID, commentID uint) error {
+	args := m.Called(userID, commentID)
+	return args.Error(0)
+}
+
+func (m *MockDatabaseService) CanModerateReviewComments(userID, reviewID uint) (bool, error) {
+	args := m.Called(userID, reviewID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabaseService) SetReviewCommentStatus(userID, commentID uint, status string) (*CommentResponse, error) {
+	args := m.Called(userID, commentID, status)
+	return args.Get(0).(*CommentResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetCurrentConditions(courseID uint, userID *uint) (*CurrentConditionsResponse, error) {
+	args := m.Called(courseID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CurrentConditionsResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetConditionsReport(reportID uint) (*ConditionsReportResponse, error) {
+	args := m.Called(reportID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ConditionsReportResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) CreateConditionsReport(userID, courseID uint, req *ConditionsReportRequest) (*ConditionsReportResponse, error) {
+	args := m.Called(userID, courseID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ConditionsReportResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) DeleteConditionsReport(userID, reportID uint) error {
+	args := m.Called(userID, reportID)
+	return args.Error(0)
+}
+
+func (m *MockDatabaseService) UserExists(userID uint) (bool, error) {
+	args := m.Called(userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabaseService) FollowUser(followerID, followeeID uint) (*FollowResponse, error) {
+	args := m.Called(followerID, followeeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*FollowResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) UnfollowUser(followerID, followeeID uint) error {
+	args := m.Called(followerID, followeeID)
+	return args.Error(0)
+}
+
+func (m *MockDatabaseService) GetFeed(userID uint, query FeedQuery) ([]*FeedItemResponse, *FeedCursor, error) {
+	args := m.Called(userID, query)
+	var next *FeedCursor
+	if args.Get(1) != nil {
+		next = args.Get(1).(*FeedCursor)
+	}
+	return args.Get(0).([]*FeedItemResponse), next, args.Error(2)
+}
+
+func (m *MockDatabaseService) GetNotifications(userID uint, unreadOnly bool, page, perPage int) ([]*NotificationResponse, int, error) {
+	args := m.Called(userID, unreadOnly, page, perPage)
+	return args.Get(0).([]*NotificationResponse), args.Int(1), args.Error(2)
+}
+
+func (m *MockDatabaseService) CountUnreadNotifications(userID uint) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDatabaseService) MarkNotificationRead(userID, notificationID uint) (bool, error) {
+	args := m.Called(userID, notificationID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabaseService) MarkAllNotificationsRead(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockDatabaseService) GetNotificationPreferences(userID uint) ([]*NotificationPreference, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]*NotificationPreference), args.Error(1)
+}
+
+func (m *MockDatabaseService) UpdateNotificationPreferences(userID uint, preferences []NotificationPreference) ([]*NotificationPreference, error) {
+	args := m.Called(userID, preferences)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*NotificationPreference), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetPublicProfile(userID uint, viewerID *uint) (*PublicProfileResponse, error) {
+	args := m.Called(userID, viewerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PublicProfileResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetProfilePrivacy(userID uint) (*ProfilePrivacy, error) {
+	args := m.Called(userID)
+	return args.Get(0).(*ProfilePrivacy), args.Error(1)
+}
+
+func (m *MockDatabaseService) UpdateProfilePrivacy(userID uint, privacy ProfilePrivacy) (*ProfilePrivacy, error) {
+	args := m.Called(userID, privacy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ProfilePrivacy), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetCourseLists(userID uint) ([]*CourseListResponse, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]*CourseListResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetCourseListsMap(userID uint) (GeoJSONFeatureCollection, error) {
+	args := m.Called(userID)
+	return args.Get(0).(GeoJSONFeatureCollection), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetCourseList(userID, listID uint) (*CourseListResponse, error) {
+	args := m.Called(userID, listID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CourseListResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetSharedCourseList(token string) (*CourseListResponse, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CourseListResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) CreateCourseList(userID uint, name string) (*CourseListResponse, error) {
+	args := m.Called(userID, name)
+	return args.Get(0).(*CourseListResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) RenameCourseList(userID, listID uint, name string) (*CourseListResponse, error) {
+	args := m.Called(userID, listID, name)
+	return args.Get(0).(*CourseListResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) DeleteCourseList(userID, listID uint) error {
+	args := m.Called(userID, listID)
+	return args.Error(0)
+}
+
+func (m *MockDatabaseService) AddCourseToList(userID, listID, courseID uint) (*CourseListResponse, error) {
+	args := m.Called(userID, listID, courseID)
+	return args.Get(0).(*CourseListResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) RemoveCourseFromList(userID, listID, courseID uint) (*CourseListResponse, error) {
+	args := m.Called(userID, listID, courseID)
+	return args.Get(0).(*CourseListResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) ReorderCourseList(userID, listID uint, courseIDs []uint) (*CourseListResponse, error) {
+	args := m.Called(userID, listID, courseIDs)
+	return args.Get(0).(*CourseListResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) SetCourseListSharing(userID, listID uint, shared bool) (*CourseListResponse, error) {
+	args := m.Called(userID, listID, shared)
+	return args.Get(0).(*CourseListResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetOutings(userID uint) ([]*OutingResponse, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]*OutingResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetOuting(userID, outingID uint) (*OutingResponse, error) {
+	args := m.Called(userID, outingID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*OutingResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) CreateOuting(userID uint, details OutingDetails) (*OutingResponse, error) {
+	args := m.Called(userID, details)
+	return args.Get(0).(*OutingResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) UpdateOuting(userID, outingID uint, details OutingDetails) (*OutingResponse, error) {
+	args := m.Called(userID, outingID, details)
+	return args.Get(0).(*OutingResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) CancelOuting(userID, outingID uint) error {
+	args := m.Called(userID, outingID)
+	return args.Error(0)
+}
+
+func (m *MockDatabaseService) InviteToOuting(userID, outingID uint, userIDs []uint, emails []string) (*OutingResponse, error) {
+	args := m.Called(userID, outingID, userIDs, emails)
+	return args.Get(0).(*OutingResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) RSVPOuting(userID, outingID uint, rsvp string) (*OutingResponse, error) {
+	args := m.Called(userID, outingID, rsvp)
+	return args.Get(0).(*OutingResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) LinkOutingScore(userID, outingID, scoreID uint) (bool, error) {
+	args := m.Called(userID, outingID, scoreID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetOutingResults(userID, outingID uint) ([]*OutingResultResponse, error) {
+	args := m.Called(userID, outingID)
+	return args.Get(0).([]*OutingResultResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetOutingCalendar(userID, outingID uint, baseURL string) ([]byte, error) {
+	args := m.Called(userID, outingID, baseURL)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetOutingCalendarFeedToken(userID uint) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
+// ItineraryDatabaseServiceInterface methods
+func (m *MockDatabaseService) PlanItinerary(details ItineraryDetails) (*ItineraryResponse, error) {
+	args := m.Called(details)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ItineraryResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) CreateItinerary(userID uint, details ItineraryDetails) (*ItineraryResponse, error) {
+	args := m.Called(userID, details)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ItineraryResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetItineraries(userID uint) ([]*ItineraryResponse, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]*ItineraryResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetItinerary(userID, itineraryID uint) (*ItineraryResponse, error) {
+	args := m.Called(userID, itineraryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ItineraryResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetSharedItinerary(token string) (*ItineraryResponse, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ItineraryResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) DeleteItinerary(userID, itineraryID uint) error {
+	args := m.Called(userID, itineraryID)
+	return args.Error(0)
+}
+
+func (m *MockDatabaseService) SetItinerarySharing(userID, itineraryID uint, shared bool) (*ItineraryResponse, error) {
+	args := m.Called(userID, itineraryID, shared)
+	return args.Get(0).(*ItineraryResponse), args.Error(1)
+}
+
+// HoleGeometryDatabaseServiceInterface methods
+func (m *MockDatabaseService) GetCourseHoleLayout(courseID uint) (*GeoJSONShapeCollection, error) {
+	args := m.Called(courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*GeoJSONShapeCollection), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetHoleGeometry(courseID uint, holeNumber int) (*HoleGeometryResponse, error) {
+	args := m.Called(courseID, holeNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*HoleGeometryResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) SaveHoleGeometry(userID, courseID uint, holeNumber int, details HoleGeometryDetails) (*HoleGeometryResponse, error) {
+	args := m.Called(userID, courseID, holeNumber, details)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*HoleGeometryResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) DeleteHoleGeometry(courseID uint, holeNumber int) (bool, error) {
+	args := m.Called(courseID, holeNumber)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabaseService) ImportHoleGeometry(userID, courseID uint, data []byte) (*HoleGeometryImportResponse, error) {
+	args := m.Called(userID, courseID, data)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*HoleGeometryImportResponse), args.Error(1)
+}
+
+// YardageDatabaseServiceInterface methods
+func (m *MockDatabaseService) GetHoleYardage(courseID uint, holeNumber int, latitude, longitude float64) (*HoleYardageResponse, error) {
+	args := m.Called(courseID, holeNumber, latitude, longitude)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*HoleYardageResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) RecordShot(userID, courseID uint, req RoundShotRequest) (*RoundShotResponse, error) {
+	args := m.Called(userID, courseID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RoundShotResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetShotScorecard(userID, courseID uint, date string) (*ShotScorecardResponse, error) {
+	args := m.Called(userID, courseID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ShotScorecardResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) DeleteShot(userID, courseID, shotID uint) (bool, error) {
+	args := m.Called(userID, courseID, shotID)
+	return args.Bool(0), args.Error(1)
+}
+
+// CourseEditDatabaseServiceInterface methods
+func (m *MockDatabaseService) ProposeCourseEdit(userID, courseID uint, changes []CourseFieldChangeRequest, note string) (*CourseEditResponse, error) {
+	args := m.Called(userID, courseID, changes, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CourseEditResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetCourseEditsForCourse(viewerID, courseID uint) ([]CourseEditResponse, error) {
+	args := m.Called(viewerID, courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]CourseEditResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetCourseEditQueue(userID uint) ([]CourseEditResponse, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]CourseEditResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetUserCourseEdits(userID uint) ([]CourseEditResponse, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]CourseEditResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) GetCourseEdit(viewerID, editID uint) (*CourseEditResponse, error) {
+	args := m.Called(viewerID, editID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CourseEditResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) AcceptCourseEdit(userID, editID uint, note string) (*CourseEditResponse, error) {
+	args := m.Called(userID, editID, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CourseEditResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) RejectCourseEdit(userID, editID uint, note string) (*CourseEditResponse, error) {
+	args := m.Called(userID, editID, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CourseEditResponse), args.Error(1)
+}
+
+func (m *MockDatabaseService) WithdrawCourseEdit(userID, editID uint) error {
+	args := m.Called(userID, editID)
+	return args.Error(0)
+}
+
+// SearchDatabaseServiceInterface methods
+func (m *MockDatabaseService) GetSearchSuggestions(query string, limit int) (*SearchSuggestionsResponse, error) {
+	args := m.Called(query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*SearchSuggestionsResponse), args.Error(1)
+}
+
 // Integration Test Setup
 func setupTestAPI() (*echo.Echo, *MockDatabaseService, *JWTService) {
 	e := echo.New()
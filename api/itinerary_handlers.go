@@ -0,0 +1,340 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxItineraryCourses    = 25
+	maxItineraryDays       = 14
+	maxItineraryNameLength = 100
+)
+
+// ErrItineraryCourseUnavailable is returned when planning a trip to a course
+// that doesn't exist or has no location
+var ErrItineraryCourseUnavailable = errors.New("itinerary course doesn't exist or has no location")
+
+// ItineraryHandler handles road-trip planning and saved itinerary endpoints
+type ItineraryHandler struct {
+	dbService ItineraryDatabaseServiceInterface
+}
+
+// ItineraryRequest plans a trip from a starting point to a set of courses
+type ItineraryRequest struct {
+	Name          string               `json:"name"` // Only needed to save the itinerary
+	Start         ItineraryStopRequest `json:"start"`
+	CourseIDs     []uint               `json:"course_ids"`
+	Days          int                  `json:"days"` // Defaults to 1
+	ReturnToStart bool                 `json:"return_to_start"`
+}
+
+// ItineraryStopRequest is where a trip starts
+type ItineraryStopRequest struct {
+	Name      string   `json:"name"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+// ItineraryDetails is a validated ItineraryRequest
+type ItineraryDetails struct {
+	Name          string
+	Start         ItineraryStop
+	CourseIDs     []uint
+	Days          int
+	ReturnToStart bool
+}
+
+// ItineraryResponse is a planned trip. Saved itineraries also have an ID,
+// name and timestamps.
+type ItineraryResponse struct {
+	ID              uint                    `json:"id,omitempty"`
+	Name            string                  `json:"name,omitempty"`
+	Start           ItineraryStop           `json:"start"`
+	ReturnToStart   bool                    `json:"return_to_start"`
+	Routing         string                  `json:"routing"`   // The routing provider, e.g. "osrm" or "straight_line"
+	Estimated       bool                    `json:"estimated"` // True when drives are straight-line estimates rather than road routes
+	DistanceKm      float64                 `json:"distance_km"`
+	DurationSeconds int                     `json:"duration_seconds"`
+	Days            []*ItineraryDayResponse `json:"days"`
+	ShareToken      *string                 `json:"share_token,omitempty"` // Only shown to the owner, and only while shared
+	OwnerName       string                  `json:"owner_name,omitempty"`  // Only on shared itineraries
+	CreatedAt       int64                   `json:"created_at,omitempty"`
+	UpdatedAt       int64                   `json:"updated_at,omitempty"`
+}
+
+// ItineraryDayResponse is a day of a trip. Its first leg leaves from wherever
+// the day before ended.
+type ItineraryDayResponse struct {
+	Day             int                     `json:"day"`
+	DistanceKm      float64                 `json:"distance_km"`
+	DurationSeconds int                     `json:"duration_seconds"`
+	Legs            []*ItineraryLegResponse `json:"legs"`
+}
+
+// ItineraryLegResponse is one drive on a trip
+type ItineraryLegResponse struct {
+	From            ItineraryStop `json:"from"`
+	To              ItineraryStop `json:"to"`
+	DistanceKm      float64       `json:"distance_km"`
+	DurationSeconds int           `json:"duration_seconds"`
+}
+
+// ItineraryStop is the start of a trip or a course on it
+type ItineraryStop struct {
+	CourseID  uint    `json:"course_id,omitempty"` // Omitted for the start
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// NewItineraryHandler creates a new itinerary handler
+func NewItineraryHandler(dbService ItineraryDatabaseServiceInterface) *ItineraryHandler {
+	return &ItineraryHandler{
+		dbService: dbService,
+	}
+}
+
+// PlanItinerary plans a trip without saving it. No authentication is needed.
+func (h *ItineraryHandler) PlanItinerary(c echo.Context) error {
+	var req ItineraryRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	details, validationErrors := validateItineraryRequest(&req)
+	if len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+
+	itinerary, err := h.dbService.PlanItinerary(details)
+	if err != nil {
+		return itineraryPlanError(c, err)
+	}
+
+	return SuccessResponse(c, itinerary)
+}
+
+// CreateItinerary plans a trip and saves it for the authenticated user
+func (h *ItineraryHandler) CreateItinerary(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	var req ItineraryRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	details, validationErrors := validateItineraryRequest(&req)
+	if details.Name == "" {
+		validationErrors["name"] = "Name is required"
+	} else if utf8.RuneCountInString(details.Name) > maxItineraryNameLength {
+		validationErrors["name"] = fmt.Sprintf("Name must be %d characters or fewer", maxItineraryNameLength)
+	}
+	if len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+
+	itinerary, err := h.dbService.CreateItinerary(userID, details)
+	if err != nil {
+		return itineraryPlanError(c, err)
+	}
+
+	return CreatedResponse(c, itinerary)
+}
+
+// GetItineraries returns the authenticated user's saved itineraries, newest first
+func (h *ItineraryHandler) GetItineraries(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	itineraries, err := h.dbService.GetItineraries(userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve itineraries")
+	}
+
+	return SuccessResponse(c, itineraries)
+}
+
+// GetItinerary returns one of the authenticated user's itineraries
+func (h *ItineraryHandler) GetItinerary(c echo.Context) error {
+	_, itinerary, err := h.ownedItinerary(c)
+	if err != nil || itinerary == nil {
+		return err
+	}
+
+	return SuccessResponse(c, itinerary)
+}
+
+// DeleteItinerary removes one of the authenticated user's itineraries
+func (h *ItineraryHandler) DeleteItinerary(c echo.Context) error {
+	userID, itinerary, err := h.ownedItinerary(c)
+	if err != nil || itinerary == nil {
+		return err
+	}
+
+	if err := h.dbService.DeleteItinerary(userID, itinerary.ID); err != nil {
+		return InternalServerError(c, "Failed to delete itinerary")
+	}
+
+	return NoContentResponse(c)
+}
+
+// ShareItinerary turns on the share link of one of the authenticated user's
+// itineraries
+func (h *ItineraryHandler) ShareItinerary(c echo.Context) error {
+	return h.setSharing(c, true)
+}
+
+// UnshareItinerary turns off the share link of one of the authenticated
+// user's itineraries. Sharing it again gives it a new link.
+func (h *ItineraryHandler) UnshareItinerary(c echo.Context) error {
+	return h.setSharing(c, false)
+}
+
+// GetSharedItinerary returns an itinerary shared by link. No authentication
+// is needed.
+func (h *ItineraryHandler) GetSharedItinerary(c echo.Context) error {
+	itinerary, err := h.dbService.GetSharedItinerary(c.Param("token"))
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve itinerary")
+	}
+	if itinerary == nil {
+		return NotFoundError(c, "Itinerary")
+	}
+
+	itinerary.ShareToken = nil
+	return SuccessResponse(c, itinerary)
+}
+
+func (h *ItineraryHandler) setSharing(c echo.Context, shared bool) error {
+	userID, itinerary, err := h.ownedItinerary(c)
+	if err != nil || itinerary == nil {
+		return err
+	}
+
+	updated, err := h.dbService.SetItinerarySharing(userID, itinerary.ID, shared)
+	if err != nil {
+		return InternalServerError(c, "Failed to update itinerary sharing")
+	}
+
+	return SuccessResponse(c, updated)
+}
+
+// ownedItinerary loads the itinerary in the :id parameter. It writes the
+// error response and returns a nil itinerary when the user is signed out or
+// the itinerary isn't theirs.
+func (h *ItineraryHandler) ownedItinerary(c echo.Context) (uint, *ItineraryResponse, error) {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return 0, nil, UnauthorizedError(c, "Authentication required")
+	}
+
+	itineraryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, nil, BadRequestError(c, "Invalid itinerary ID")
+	}
+
+	itinerary, err := h.dbService.GetItinerary(userID, uint(itineraryID))
+	if err != nil {
+		return 0, nil, InternalServerError(c, "Failed to retrieve itinerary")
+	}
+	if itinerary == nil {
+		return 0, nil, NotFoundError(c, "Itinerary")
+	}
+
+	return userID, itinerary, nil
+}
+
+// itineraryPlanError answers a failed plan, telling the user when one of
+// their courses can't be routed to
+func itineraryPlanError(c echo.Context, err error) error {
+	if errors.Is(err, ErrItineraryCourseUnavailable) {
+		return ValidationError(c, map[string]string{"course_ids": "Every course must exist and have a location"})
+	}
+	return InternalServerError(c, "Failed to plan itinerary")
+}
+
+func validateItineraryRequest(req *ItineraryRequest) (ItineraryDetails, map[string]string) {
+	errors := make(map[string]string)
+	details := ItineraryDetails{
+		Name:          strings.TrimSpace(req.Name),
+		Start:         ItineraryStop{Name: strings.TrimSpace(req.Start.Name)},
+		Days:          req.Days,
+		ReturnToStart: req.ReturnToStart,
+	}
+
+	seen := make(map[uint]bool, len(req.CourseIDs))
+	for _, courseID := range req.CourseIDs {
+		if courseID == 0 {
+			errors["course_ids"] = "Course IDs must be positive"
+			break
+		}
+		if !seen[courseID] {
+			seen[courseID] = true
+			details.CourseIDs = append(details.CourseIDs, courseID)
+		}
+	}
+	switch {
+	case errors["course_ids"] != "":
+	case len(details.CourseIDs) == 0:
+		errors["course_ids"] = "At least one course is required"
+	case len(details.CourseIDs) > maxItineraryCourses:
+		errors["course_ids"] = fmt.Sprintf("Plan at most %d courses at a time", maxItineraryCourses)
+	}
+
+	if details.Days == 0 {
+		details.Days = 1
+	}
+	if details.Days < 1 || details.Days > maxItineraryDays {
+		errors["days"] = fmt.Sprintf("Days must be between 1 and %d", maxItineraryDays)
+	}
+
+	switch latitude, longitude := req.Start.Latitude, req.Start.Longitude; {
+	case latitude == nil || longitude == nil:
+		errors["start"] = "Start latitude and longitude are required"
+	case *latitude < -90 || *latitude > 90 || *longitude < -180 || *longitude > 180:
+		errors["start"] = "Start must be within -90 to 90 latitude and -180 to 180 longitude"
+	default:
+		details.Start.Latitude, details.Start.Longitude = *latitude, *longitude
+	}
+	if utf8.RuneCountInString(details.Start.Name) > maxItineraryNameLength {
+		errors["start"] = fmt.Sprintf("Start name must be %d characters or fewer", maxItineraryNameLength)
+	}
+	return details, errors
+}
+
+// RegisterRoutes registers itinerary routes
+func (h *ItineraryHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Public routes
+	g.POST("/itineraries/plan", h.PlanItinerary)
+	g.GET("/itineraries/shared/:token", h.GetSharedItinerary)
+
+	// Protected routes (authentication required)
+	g.GET("/user/itineraries", h.GetItineraries, JWTMiddleware(jwtService))
+	g.POST("/user/itineraries", h.CreateItinerary, JWTMiddleware(jwtService))
+	g.GET("/user/itineraries/:id", h.GetItinerary, JWTMiddleware(jwtService))
+	g.DELETE("/user/itineraries/:id", h.DeleteItinerary, JWTMiddleware(jwtService))
+	g.POST("/user/itineraries/:id/share", h.ShareItinerary, JWTMiddleware(jwtService))
+	g.DELETE("/user/itineraries/:id/share", h.UnshareItinerary, JWTMiddleware(jwtService))
+}
+
+// Database interface for itinerary operations
+type ItineraryDatabaseServiceInterface interface {
+	// PlanItinerary and CreateItinerary return ErrItineraryCourseUnavailable
+	// when a course doesn't exist or has no location
+	PlanItinerary(details ItineraryDetails) (*ItineraryResponse, error)
+	CreateItinerary(userID uint, details ItineraryDetails) (*ItineraryResponse, error)
+	GetItineraries(userID uint) ([]*ItineraryResponse, error)
+	GetItinerary(userID, itineraryID uint) (*ItineraryResponse, error) // Nil when the itinerary doesn't exist or isn't the user's
+	GetSharedItinerary(token string) (*ItineraryResponse, error)       // Nil when no itinerary is shared with the token
+	DeleteItinerary(userID, itineraryID uint) error
+	SetItinerarySharing(userID, itineraryID uint, shared bool) (*ItineraryResponse, error)
+}
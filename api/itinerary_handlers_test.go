@@ -0,0 +1,181 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testItinerary() *ItineraryResponse {
+	start := ItineraryStop{Name: "Southern Pines", Latitude: 35.174, Longitude: -79.392}
+	midPines := ItineraryStop{CourseID: 4, Name: "Mid Pines", Latitude: 35.1618, Longitude: -79.4379}
+	return &ItineraryResponse{
+		ID:              7,
+		Name:            "Sandhills weekend",
+		Start:           start,
+		Routing:         "straight_line",
+		Estimated:       true,
+		DistanceKm:      5.6,
+		DurationSeconds: 252,
+		Days: []*ItineraryDayResponse{
+			{Day: 1, DistanceKm: 5.6, DurationSeconds: 252, Legs: []*ItineraryLegResponse{{From: start, To: midPines, DistanceKm: 5.6, DurationSeconds: 252}}},
+		},
+	}
+}
+
+func TestAPI_Itineraries(t *testing.T) {
+	request := map[string]interface{}{
+		"start":      map[string]interface{}{"name": " Southern Pines ", "latitude": 35.174, "longitude": -79.392},
+		"course_ids": []uint{4, 9, 4},
+		"days":       2,
+	}
+	details := ItineraryDetails{
+		Start:     ItineraryStop{Name: "Southern Pines", Latitude: 35.174, Longitude: -79.392},
+		CourseIDs: []uint{4, 9},
+		Days:      2,
+	}
+
+	t.Run("Plans a trip without signing in", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		plan := testItinerary()
+		plan.ID, plan.Name = 0, ""
+		mockDB.On("PlanItinerary", details).Return(plan, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/itineraries/plan", "", request)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"estimated":true`)
+		assert.Contains(t, rec.Body.String(), `"to":{"course_id":4,"name":"Mid Pines"`)
+		assert.NotContains(t, rec.Body.String(), `"id":`, "unsaved plans have no ID")
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Defaults to a one-day trip", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("PlanItinerary", mock.MatchedBy(func(details ItineraryDetails) bool { return details.Days == 1 })).Return(testItinerary(), nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/itineraries/plan", "", map[string]interface{}{
+			"start": map[string]float64{"latitude": 35.174, "longitude": -79.392}, "course_ids": []uint{4},
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects invalid plans", func(t *testing.T) {
+		invalid := []map[string]interface{}{
+			{"start": map[string]float64{"latitude": 35.174, "longitude": -79.392}},
+			{"start": map[string]float64{"latitude": 35.174}, "course_ids": []uint{4}},
+			{"start": map[string]float64{"latitude": 95, "longitude": -79.392}, "course_ids": []uint{4}},
+			{"start": map[string]float64{"latitude": 35.174, "longitude": -79.392}, "course_ids": []uint{4}, "days": 15},
+			{"start": map[string]float64{"latitude": 35.174, "longitude": -79.392}, "course_ids": []uint{0}},
+		}
+		many := make([]uint, 26)
+		for i := range many {
+			many[i] = uint(i + 1)
+		}
+		invalid = append(invalid, map[string]interface{}{"start": map[string]float64{"latitude": 35.174, "longitude": -79.392}, "course_ids": many})
+
+		for _, body := range invalid {
+			e, mockDB, _, _ := setupAPITest(t)
+
+			rec := serveJSON(e, http.MethodPost, "/api/v1/itineraries/plan", "", body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+			mockDB.AssertNotCalled(t, "PlanItinerary", mock.Anything)
+		}
+	})
+
+	t.Run("Reports courses that can't be routed to", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("PlanItinerary", details).Return(nil, ErrItineraryCourseUnavailable)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/itineraries/plan", "", request)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "course_ids")
+	})
+
+	t.Run("Saves a named itinerary", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		named := details
+		named.Name = "Sandhills weekend"
+		mockDB.On("CreateItinerary", user.ID, named).Return(testItinerary(), nil)
+
+		body := map[string]interface{}{"name": " Sandhills weekend "}
+		for key, value := range request {
+			body[key] = value
+		}
+		rec := serveJSON(e, http.MethodPost, "/api/v1/user/itineraries", token, body)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Needs a name and a user to save", func(t *testing.T) {
+		for _, name := range []string{"", strings.Repeat("a", 101)} {
+			e, mockDB, _, token := setupAPITest(t)
+
+			body := map[string]interface{}{"name": name}
+			for key, value := range request {
+				body[key] = value
+			}
+			rec := serveJSON(e, http.MethodPost, "/api/v1/user/itineraries", token, body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			mockDB.AssertNotCalled(t, "CreateItinerary", mock.Anything, mock.Anything)
+		}
+
+		e, _, _, _ := setupAPITest(t)
+		rec := serveJSON(e, http.MethodPost, "/api/v1/user/itineraries", "", request)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Returns 404 for another user's itinerary", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetItinerary", user.ID, uint(9)).Return(nil, nil)
+
+		rec := serveJSON(e, http.MethodDelete, "/api/v1/user/itineraries/9", token, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockDB.AssertNotCalled(t, "DeleteItinerary", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Shares an itinerary", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		shared := testItinerary()
+		shared.ShareToken = stringPtr("abc123")
+		mockDB.On("GetItinerary", user.ID, uint(7)).Return(testItinerary(), nil)
+		mockDB.On("SetItinerarySharing", user.ID, uint(7), true).Return(shared, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/user/itineraries/7/share", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"share_token":"abc123"`)
+	})
+
+	t.Run("Shows shared itineraries without the token", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		shared := testItinerary()
+		shared.ShareToken = stringPtr("abc123")
+		shared.OwnerName = "Course Owner"
+		mockDB.On("GetSharedItinerary", "abc123").Return(shared, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/itineraries/shared/abc123", "", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"owner_name":"Course Owner"`)
+		assert.NotContains(t, rec.Body.String(), "share_token")
+	})
+
+	t.Run("Returns 404 for unknown share links", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("GetSharedItinerary", "nope").Return(nil, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/itineraries/shared/nope", "", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,372 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+const maxCourseListNameLength = 100
+
+// ListHandler handles want-to-play and named course list API endpoints
+type ListHandler struct {
+	dbService ListDatabaseServiceInterface
+}
+
+// CourseListResponse is a course list with its courses in order
+type CourseListResponse struct {
+	ID          uint                       `json:"id"`
+	Name        string                     `json:"name"`
+	Kind        string                     `json:"kind"`                  // "want_to_play" or "custom"
+	ShareToken  *string                    `json:"share_token,omitempty"` // Only shown to the owner, and only while shared
+	OwnerName   string                     `json:"owner_name,omitempty"`  // Only on shared lists
+	CourseCount int                        `json:"course_count"`
+	PlayedCount int                        `json:"played_count"`
+	Courses     []*CourseListEntryResponse `json:"courses"`
+	CreatedAt   int64                      `json:"created_at"`
+	UpdatedAt   int64                      `json:"updated_at"`
+}
+
+// CourseListEntryResponse is a course on a list. Played is derived from the
+// list owner's posted scores.
+type CourseListEntryResponse struct {
+	CourseID   uint     `json:"course_id"`
+	CourseName string   `json:"course_name"`
+	Address    string   `json:"address"`
+	Latitude   *float64 `json:"latitude,omitempty"`
+	Longitude  *float64 `json:"longitude,omitempty"`
+	Position   int      `json:"position"`
+	Played     bool     `json:"played"`
+	Rounds     int      `json:"rounds"`
+	BestScore  *int     `json:"best_score,omitempty"`
+	AddedAt    int64    `json:"added_at"`
+}
+
+// CourseListRequest creates or renames a list
+type CourseListRequest struct {
+	Name string `json:"name"`
+}
+
+// CourseListCourseRequest adds a course to a list
+type CourseListCourseRequest struct {
+	CourseID uint `json:"course_id"`
+}
+
+// CourseListOrderRequest puts a list's courses in a new order
+type CourseListOrderRequest struct {
+	CourseIDs []uint `json:"course_ids"`
+}
+
+// NewListHandler creates a new list handler
+func NewListHandler(dbService ListDatabaseServiceInterface) *ListHandler {
+	return &ListHandler{
+		dbService: dbService,
+	}
+}
+
+// GetLists returns the authenticated user's lists, want-to-play first
+func (h *ListHandler) GetLists(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	lists, err := h.dbService.GetCourseLists(userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve lists")
+	}
+
+	return SuccessResponse(c, lists)
+}
+
+// GetListsMap returns the courses on the authenticated user's lists as GeoJSON
+func (h *ListHandler) GetListsMap(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	collection, err := h.dbService.GetCourseListsMap(userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve lists map")
+	}
+
+	return SuccessResponse(c, collection)
+}
+
+// CreateList adds a named list for the authenticated user
+func (h *ListHandler) CreateList(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	var req CourseListRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	if validationErrors := validateCourseListName(req.Name); len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+
+	list, err := h.dbService.CreateCourseList(userID, strings.TrimSpace(req.Name))
+	if err != nil {
+		return InternalServerError(c, "Failed to create list")
+	}
+
+	return CreatedResponse(c, list)
+}
+
+// GetList returns one of the authenticated user's lists
+func (h *ListHandler) GetList(c echo.Context) error {
+	_, list, err := h.ownedList(c)
+	if err != nil || list == nil {
+		return err
+	}
+
+	return SuccessResponse(c, list)
+}
+
+// RenameList changes the name of one of the authenticated user's lists
+func (h *ListHandler) RenameList(c echo.Context) error {
+	userID, list, err := h.ownedList(c)
+	if err != nil || list == nil {
+		return err
+	}
+
+	var req CourseListRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	if validationErrors := validateCourseListName(req.Name); len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+
+	updated, err := h.dbService.RenameCourseList(userID, list.ID, strings.TrimSpace(req.Name))
+	if err != nil {
+		return InternalServerError(c, "Failed to rename list")
+	}
+
+	return SuccessResponse(c, updated)
+}
+
+// DeleteList removes one of the authenticated user's named lists
+func (h *ListHandler) DeleteList(c echo.Context) error {
+	userID, list, err := h.ownedList(c)
+	if err != nil || list == nil {
+		return err
+	}
+	if list.Kind == "want_to_play" {
+		return BadRequestError(c, "The want-to-play list can't be deleted")
+	}
+
+	if err := h.dbService.DeleteCourseList(userID, list.ID); err != nil {
+		return InternalServerError(c, "Failed to delete list")
+	}
+
+	return NoContentResponse(c)
+}
+
+// AddCourse puts a course at the end of one of the authenticated user's lists
+func (h *ListHandler) AddCourse(c echo.Context) error {
+	userID, list, err := h.ownedList(c)
+	if err != nil || list == nil {
+		return err
+	}
+
+	var req CourseListCourseRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	if req.CourseID == 0 {
+		return ValidationError(c, map[string]string{"course_id": "Course ID is required"})
+	}
+
+	exists, err := h.dbService.CourseExists(req.CourseID)
+	if err != nil {
+		return InternalServerError(c, "Failed to verify course")
+	}
+	if !exists {
+		return NotFoundError(c, "Course")
+	}
+
+	updated, err := h.dbService.AddCourseToList(userID, list.ID, req.CourseID)
+	if err != nil {
+		return InternalServerError(c, "Failed to add course to list")
+	}
+
+	return SuccessResponse(c, updated)
+}
+
+// RemoveCourse takes a course off one of the authenticated user's lists
+func (h *ListHandler) RemoveCourse(c echo.Context) error {
+	userID, list, err := h.ownedList(c)
+	if err != nil || list == nil {
+		return err
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("courseId"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+
+	updated, err := h.dbService.RemoveCourseFromList(userID, list.ID, uint(courseID))
+	if err != nil {
+		return InternalServerError(c, "Failed to remove course from list")
+	}
+
+	return SuccessResponse(c, updated)
+}
+
+// ReorderList puts the courses on one of the authenticated user's lists in a
+// new order. The order must include every course on the list exactly once.
+func (h *ListHandler) ReorderList(c echo.Context) error {
+	userID, list, err := h.ownedList(c)
+	if err != nil || list == nil {
+		return err
+	}
+
+	var req CourseListOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	if !sameCourses(list.Courses, req.CourseIDs) {
+		return ValidationError(c, map[string]string{"course_ids": "Must include every course on the list exactly once"})
+	}
+
+	updated, err := h.dbService.ReorderCourseList(userID, list.ID, req.CourseIDs)
+	if err != nil {
+		return InternalServerError(c, "Failed to reorder list")
+	}
+
+	return SuccessResponse(c, updated)
+}
+
+// ShareList turns on the share link of one of the authenticated user's lists
+func (h *ListHandler) ShareList(c echo.Context) error {
+	return h.setSharing(c, true)
+}
+
+// UnshareList turns off the share link of one of the authenticated user's
+// lists. Sharing it again gives it a new link.
+func (h *ListHandler) UnshareList(c echo.Context) error {
+	return h.setSharing(c, false)
+}
+
+// GetSharedList returns a list shared by link. No authentication is needed.
+func (h *ListHandler) GetSharedList(c echo.Context) error {
+	list, err := h.dbService.GetSharedCourseList(c.Param("token"))
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve list")
+	}
+	if list == nil {
+		return NotFoundError(c, "List")
+	}
+
+	list.ShareToken = nil
+	return SuccessResponse(c, list)
+}
+
+func (h *ListHandler) setSharing(c echo.Context, shared bool) error {
+	userID, list, err := h.ownedList(c)
+	if err != nil || list == nil {
+		return err
+	}
+
+	updated, err := h.dbService.SetCourseListSharing(userID, list.ID, shared)
+	if err != nil {
+		return InternalServerError(c, "Failed to update list sharing")
+	}
+
+	return SuccessResponse(c, updated)
+}
+
+// ownedList loads the list in the :id parameter. It writes the error response
+// and returns a nil list when the user is signed out or the list isn't theirs.
+func (h *ListHandler) ownedList(c echo.Context) (uint, *CourseListResponse, error) {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return 0, nil, UnauthorizedError(c, "Authentication required")
+	}
+
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, nil, BadRequestError(c, "Invalid list ID")
+	}
+
+	list, err := h.dbService.GetCourseList(userID, uint(listID))
+	if err != nil {
+		return 0, nil, InternalServerError(c, "Failed to retrieve list")
+	}
+	if list == nil {
+		return 0, nil, NotFoundError(c, "List")
+	}
+
+	return userID, list, nil
+}
+
+func validateCourseListName(name string) map[string]string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return map[string]string{"name": "Name is required"}
+	}
+	if utf8.RuneCountInString(name) > maxCourseListNameLength {
+		return map[string]string{"name": "Name must be 100 characters or fewer"}
+	}
+	return nil
+}
+
+// sameCourses reports whether courseIDs lists every course on the list exactly once
+func sameCourses(entries []*CourseListEntryResponse, courseIDs []uint) bool {
+	if len(entries) != len(courseIDs) {
+		return false
+	}
+	remaining := make(map[uint]bool, len(entries))
+	for _, entry := range entries {
+		remaining[entry.CourseID] = true
+	}
+	for _, courseID := range courseIDs {
+		if !remaining[courseID] {
+			return false
+		}
+		delete(remaining, courseID)
+	}
+	return true
+}
+
+// RegisterRoutes registers course list routes
+func (h *ListHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Public routes
+	g.GET("/lists/shared/:token", h.GetSharedList)
+
+	// Protected routes (authentication required)
+	g.GET("/user/lists", h.GetLists, JWTMiddleware(jwtService))
+	g.POST("/user/lists", h.CreateList, JWTMiddleware(jwtService))
+	g.GET("/user/lists/map", h.GetListsMap, JWTMiddleware(jwtService))
+	g.GET("/user/lists/:id", h.GetList, JWTMiddleware(jwtService))
+	g.PUT("/user/lists/:id", h.RenameList, JWTMiddleware(jwtService))
+	g.DELETE("/user/lists/:id", h.DeleteList, JWTMiddleware(jwtService))
+	g.POST("/user/lists/:id/courses", h.AddCourse, JWTMiddleware(jwtService))
+	g.DELETE("/user/lists/:id/courses/:courseId", h.RemoveCourse, JWTMiddleware(jwtService))
+	g.PUT("/user/lists/:id/order", h.ReorderList, JWTMiddleware(jwtService))
+	g.POST("/user/lists/:id/share", h.ShareList, JWTMiddleware(jwtService))
+	g.DELETE("/user/lists/:id/share", h.UnshareList, JWTMiddleware(jwtService))
+}
+
+// Database interface for course list operations
+type ListDatabaseServiceInterface interface {
+	CourseExists(courseID uint) (bool, error)
+	GetCourseLists(userID uint) ([]*CourseListResponse, error)
+	GetCourseListsMap(userID uint) (GeoJSONFeatureCollection, error)
+	GetCourseList(userID, listID uint) (*CourseListResponse, error) // Nil when the list doesn't exist or isn't the user's
+	GetSharedCourseList(token string) (*CourseListResponse, error)  // Nil when no list is shared with the token
+	CreateCourseList(userID uint, name string) (*CourseListResponse, error)
+	RenameCourseList(userID, listID uint, name string) (*CourseListResponse, error)
+	DeleteCourseList(userID, listID uint) error
+	AddCourseToList(userID, listID, courseID uint) (*CourseListResponse, error)
+	RemoveCourseFromList(userID, listID, courseID uint) (*CourseListResponse, error)
+	ReorderCourseList(userID, listID uint, courseIDs []uint) (*CourseListResponse, error)
+	SetCourseListSharing(userID, listID uint, shared bool) (*CourseListResponse, error)
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testCourseList() *CourseListResponse {
+	return &CourseListResponse{
+		ID:          4,
+		Name:        "Myrtle Beach trip",
+		Kind:        "custom",
+		CourseCount: 2,
+		PlayedCount: 1,
+		Courses: []*CourseListEntryResponse{
+			{CourseID: 10, CourseName: "Dunes", Position: 1, Played: true, Rounds: 1},
+			{CourseID: 11, CourseName: "Marsh", Position: 2},
+		},
+	}
+}
+
+func TestAPI_CourseLists(t *testing.T) {
+	t.Run("Returns the user's lists", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetCourseLists", user.ID).Return([]*CourseListResponse{
+			{ID: 3, Name: "Want to play", Kind: "want_to_play", Courses: []*CourseListEntryResponse{}},
+			testCourseList(),
+		}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/user/lists", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"kind":"want_to_play"`)
+		assert.Contains(t, rec.Body.String(), `"played":true`)
+	})
+
+	t.Run("Creates a list", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("CreateCourseList", user.ID, "Myrtle Beach trip").Return(&CourseListResponse{ID: 4, Name: "Myrtle Beach trip", Kind: "custom"}, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/user/lists", token, map[string]string{"name": "  Myrtle Beach trip "})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects blank and long names", func(t *testing.T) {
+		for _, name := range []string{"  ", strings.Repeat("a", 101)} {
+			e, mockDB, _, token := setupAPITest(t)
+
+			rec := serveJSON(e, http.MethodPost, "/api/v1/user/lists", token, map[string]string{"name": name})
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			mockDB.AssertNotCalled(t, "CreateCourseList", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Returns 404 for another user's list", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetCourseList", user.ID, uint(9)).Return(nil, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/user/lists/9", token, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Keeps the want-to-play list", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetCourseList", user.ID, uint(3)).Return(&CourseListResponse{ID: 3, Kind: "want_to_play"}, nil)
+
+		rec := serveJSON(e, http.MethodDelete, "/api/v1/user/lists/3", token, nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockDB.AssertNotCalled(t, "DeleteCourseList", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Adds a course", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetCourseList", user.ID, uint(4)).Return(testCourseList(), nil)
+		mockDB.On("CourseExists", uint(12)).Return(true, nil)
+		mockDB.On("AddCourseToList", user.ID, uint(4), uint(12)).Return(testCourseList(), nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/user/lists/4/courses", token, map[string]uint{"course_id": 12})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Returns 404 when adding a missing course", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetCourseList", user.ID, uint(4)).Return(testCourseList(), nil)
+		mockDB.On("CourseExists", uint(99)).Return(false, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/user/lists/4/courses", token, map[string]uint{"course_id": 99})
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockDB.AssertNotCalled(t, "AddCourseToList", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Reorders courses", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetCourseList", user.ID, uint(4)).Return(testCourseList(), nil)
+		mockDB.On("ReorderCourseList", user.ID, uint(4), []uint{11, 10}).Return(testCourseList(), nil)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/user/lists/4/order", token, map[string][]uint{"course_ids": {11, 10}})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects an incomplete order", func(t *testing.T) {
+		for _, order := range [][]uint{{10}, {10, 10}, {10, 12}} {
+			e, mockDB, user, token := setupAPITest(t)
+
+			mockDB.On("GetCourseList", user.ID, uint(4)).Return(testCourseList(), nil)
+
+			rec := serveJSON(e, http.MethodPut, "/api/v1/user/lists/4/order", token, map[string][]uint{"course_ids": order})
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			mockDB.AssertNotCalled(t, "ReorderCourseList", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Shares a list", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		shareToken := "abc123"
+		shared := testCourseList()
+		shared.ShareToken = &shareToken
+		mockDB.On("GetCourseList", user.ID, uint(4)).Return(testCourseList(), nil)
+		mockDB.On("SetCourseListSharing", user.ID, uint(4), true).Return(shared, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/user/lists/4/share", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"share_token":"abc123"`)
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		e, _, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/user/lists", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestAPI_SharedCourseList(t *testing.T) {
+	t.Run("Returns a shared list without its token", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		shareToken := "abc123"
+		shared := testCourseList()
+		shared.ShareToken = &shareToken
+		shared.OwnerName = "Sam"
+		mockDB.On("GetSharedCourseList", "abc123").Return(shared, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/lists/shared/abc123", "", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"owner_name":"Sam"`)
+		assert.NotContains(t, rec.Body.String(), "share_token")
+	})
+
+	t.Run("Returns 404 for unknown links", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("GetSharedCourseList", "nope").Return(nil, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/lists/shared/nope", "", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
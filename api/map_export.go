@@ -0,0 +1,301 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Map export formats
+const (
+	MapExportGeoJSON = "geojson"
+	MapExportKML     = "kml"
+	MapExportGPX     = "gpx"
+)
+
+// Property sets for map exports. Each set includes the ones before it.
+const (
+	MapPropertiesMinimal  = "minimal"  // id, name and rating
+	MapPropertiesStandard = "standard" // adds the address, creation time and course page URL
+	MapPropertiesFull     = "full"     // adds the description, review, rankings, holes, par, tags and amenities
+)
+
+// MapPropertySets lists the property sets, smallest first
+var MapPropertySets = []string{MapPropertiesMinimal, MapPropertiesStandard, MapPropertiesFull}
+
+// mapExportContentTypes are the media types each export format is served as
+var mapExportContentTypes = map[string]string{
+	MapExportGeoJSON: "application/geo+json",
+	MapExportKML:     "application/vnd.google-earth.kml+xml",
+	MapExportGPX:     "application/gpx+xml",
+}
+
+// MapExportRequest is a course search narrowed to the courses on the map,
+// optionally within bounds, and the properties to export for each
+type MapExportRequest struct {
+	Search     CourseSearchRequest
+	Bounds     *BoundsRequest // nil exports courses anywhere
+	Properties string
+}
+
+// MapExportCourse is a located course as the map exports write it
+type MapExportCourse struct {
+	ID                 uint
+	Name               string
+	Address            string
+	Latitude           float64
+	Longitude          float64
+	Rating             string // Overall rating; empty when the course has none
+	CreatedAt          int64
+	Description        string
+	Review             string
+	Price              string
+	HandicapDifficulty int
+	HazardDifficulty   int
+	Holes              int
+	Par                int
+	Tags               []string // Only filled for the full property set
+	Amenities          []string // Only filled for the full property set
+}
+
+// MapProperty is one exported course attribute
+type MapProperty struct {
+	Key   string
+	Value interface{}
+}
+
+// Properties returns the course's attributes in a property set, in a stable
+// order. siteURL prefixes the course page link.
+func (course *MapExportCourse) Properties(set, siteURL string) []MapProperty {
+	rating := course.Rating
+	if rating == "" {
+		rating = "-"
+	}
+	properties := []MapProperty{
+		{Key: "id", Value: course.ID},
+		{Key: "name", Value: course.Name},
+		{Key: "rating", Value: rating},
+	}
+	if set == MapPropertiesMinimal {
+		return properties
+	}
+
+	properties = append(properties,
+		MapProperty{Key: "address", Value: course.Address},
+		MapProperty{Key: "created_at", Value: course.CreatedAt},
+		MapProperty{Key: "url", Value: course.URL(siteURL)},
+	)
+	if set == MapPropertiesStandard {
+		return properties
+	}
+
+	tags, amenities := course.Tags, course.Amenities
+	if tags == nil {
+		tags = []string{}
+	}
+	if amenities == nil {
+		amenities = []string{}
+	}
+	return append(properties,
+		MapProperty{Key: "description", Value: course.Description},
+		MapProperty{Key: "review", Value: course.Review},
+		MapProperty{Key: "price", Value: course.Price},
+		MapProperty{Key: "handicap_difficulty", Value: course.HandicapDifficulty},
+		MapProperty{Key: "hazard_difficulty", Value: course.HazardDifficulty},
+		MapProperty{Key: "holes", Value: course.Holes},
+		MapProperty{Key: "par", Value: course.Par},
+		MapProperty{Key: "tags", Value: tags},
+		MapProperty{Key: "amenities", Value: amenities},
+	)
+}
+
+// URL is the course's page on the site
+func (course *MapExportCourse) URL(siteURL string) string {
+	return fmt.Sprintf("%s/course/%d", strings.TrimSuffix(siteURL, "/"), course.ID)
+}
+
+// mapExportEncoder writes courses to an export document one at a time, so
+// large exports never sit in memory whole
+type mapExportEncoder interface {
+	Begin() error
+	Course(course *MapExportCourse) error
+	End() error
+}
+
+func newMapExportEncoder(format string, w io.Writer, properties, siteURL string) mapExportEncoder {
+	switch format {
+	case MapExportKML:
+		return &kmlEncoder{w: w, xml: xml.NewEncoder(w), properties: properties, siteURL: siteURL}
+	case MapExportGPX:
+		return &gpxEncoder{w: w, xml: xml.NewEncoder(w), properties: properties, siteURL: siteURL}
+	default:
+		return &geoJSONEncoder{w: w, properties: properties, siteURL: siteURL}
+	}
+}
+
+// geoJSONEncoder writes a FeatureCollection of course points
+type geoJSONEncoder struct {
+	w          io.Writer
+	properties string
+	siteURL    string
+	written    int
+}
+
+func (e *geoJSONEncoder) Begin() error {
+	_, err := io.WriteString(e.w, `{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+func (e *geoJSONEncoder) Course(course *MapExportCourse) error {
+	properties := make(map[string]interface{})
+	for _, property := range course.Properties(e.properties, e.siteURL) {
+		properties[property.Key] = property.Value
+	}
+	feature, err := json.Marshal(NewPointFeature(course.Latitude, course.Longitude, properties))
+	if err != nil {
+		return err
+	}
+
+	if e.written > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.written++
+	_, err = e.w.Write(feature)
+	return err
+}
+
+func (e *geoJSONEncoder) End() error {
+	_, err := io.WriteString(e.w, "]}\n")
+	return err
+}
+
+// kmlEncoder writes a KML 2.2 document with a placemark per course. Course
+// properties go in each placemark's ExtendedData.
+type kmlEncoder struct {
+	w          io.Writer
+	xml        *xml.Encoder
+	properties string
+	siteURL    string
+}
+
+type kmlPlacemark struct {
+	XMLName     xml.Name  `xml:"Placemark"`
+	ID          string    `xml:"id,attr"`
+	Name        string    `xml:"name"`
+	Description string    `xml:"description,omitempty"`
+	Data        []kmlData `xml:"ExtendedData>Data"`
+	Coordinates string    `xml:"Point>coordinates"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+func (e *kmlEncoder) Begin() error {
+	_, err := io.WriteString(e.w, xml.Header+`<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>Golf courses</name>`)
+	return err
+}
+
+func (e *kmlEncoder) Course(course *MapExportCourse) error {
+	placemark := kmlPlacemark{
+		ID:          fmt.Sprintf("course-%d", course.ID),
+		Name:        course.Name,
+		Coordinates: formatCoordinate(course.Longitude) + "," + formatCoordinate(course.Latitude),
+	}
+	if e.properties != MapPropertiesMinimal {
+		placemark.Description = course.Address
+	}
+	for _, property := range course.Properties(e.properties, e.siteURL) {
+		if property.Key != "name" {
+			placemark.Data = append(placemark.Data, kmlData{Name: property.Key, Value: formatMapProperty(property.Value)})
+		}
+	}
+	if err := e.xml.Encode(placemark); err != nil {
+		return err
+	}
+	return e.xml.Flush()
+}
+
+func (e *kmlEncoder) End() error {
+	_, err := io.WriteString(e.w, "</Document></kml>\n")
+	return err
+}
+
+// gpxEncoder writes a GPX 1.1 document with a waypoint per course. GPX has
+// no free-form properties, so the rating goes in the comment and the address
+// in the description.
+type gpxEncoder struct {
+	w          io.Writer
+	xml        *xml.Encoder
+	properties string
+	siteURL    string
+}
+
+type gpxWaypoint struct {
+	XMLName     xml.Name `xml:"wpt"`
+	Latitude    string   `xml:"lat,attr"`
+	Longitude   string   `xml:"lon,attr"`
+	Name        string   `xml:"name"`
+	Comment     string   `xml:"cmt,omitempty"`
+	Description string   `xml:"desc,omitempty"`
+	Link        *gpxLink `xml:"link,omitempty"`
+	Symbol      string   `xml:"sym"`
+	Type        string   `xml:"type"`
+}
+
+type gpxLink struct {
+	Href string `xml:"href,attr"`
+}
+
+func (e *gpxEncoder) Begin() error {
+	_, err := io.WriteString(e.w, xml.Header+`<gpx version="1.1" creator="course_management" xmlns="http://www.topografix.com/GPX/1/1">`)
+	return err
+}
+
+func (e *gpxEncoder) Course(course *MapExportCourse) error {
+	waypoint := gpxWaypoint{
+		Latitude:  formatCoordinate(course.Latitude),
+		Longitude: formatCoordinate(course.Longitude),
+		Name:      course.Name,
+		Symbol:    "Golf Course",
+		Type:      "Golf Course",
+	}
+	if course.Rating != "" {
+		waypoint.Comment = "Rating: " + course.Rating
+	}
+	if e.properties != MapPropertiesMinimal {
+		waypoint.Description = course.Address
+		waypoint.Link = &gpxLink{Href: course.URL(e.siteURL)}
+	}
+	if e.properties == MapPropertiesFull && course.Description != "" {
+		waypoint.Description = strings.TrimSpace(waypoint.Description + "\n\n" + course.Description)
+	}
+	if err := e.xml.Encode(waypoint); err != nil {
+		return err
+	}
+	return e.xml.Flush()
+}
+
+func (e *gpxEncoder) End() error {
+	_, err := io.WriteString(e.w, "</gpx>\n")
+	return err
+}
+
+// formatCoordinate writes a coordinate to a tenth of a meter or so
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', 6, 64)
+}
+
+// formatMapProperty writes a property value as text
+func formatMapProperty(value interface{}) string {
+	if values, ok := value.([]string); ok {
+		return strings.Join(values, ",")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exportCourses = []*MapExportCourse{
+	{
+		ID: 4, Name: "Mid Pines", Address: "1010 Midland Rd, Southern Pines, NC", Latitude: 35.1618, Longitude: -79.4379,
+		Rating: "A", CreatedAt: 1712736000, Description: "Donald Ross & friends", Price: "$$$", Holes: 18, Par: 72,
+		Tags: []string{"classic"}, Amenities: []string{"range"},
+	},
+	{ID: 9, Name: "Tobacco <Road>", Address: "Sanford, NC", Latitude: 35.358, Longitude: -79.1756},
+}
+
+func encodeExport(t *testing.T, format, properties string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	encoder := newMapExportEncoder(format, &buf, properties, "https://golf.example/")
+	require.NoError(t, encoder.Begin())
+	for _, course := range exportCourses {
+		require.NoError(t, encoder.Course(course))
+	}
+	require.NoError(t, encoder.End())
+	return buf.Bytes()
+}
+
+func TestMapExportProperties(t *testing.T) {
+	keys := func(properties []MapProperty) []string {
+		var keys []string
+		for _, property := range properties {
+			keys = append(keys, property.Key)
+		}
+		return keys
+	}
+
+	assert.Equal(t, []string{"id", "name", "rating"}, keys(exportCourses[0].Properties(MapPropertiesMinimal, "")))
+	assert.Equal(t, []string{"id", "name", "rating", "address", "created_at", "url"}, keys(exportCourses[0].Properties(MapPropertiesStandard, "")))
+	assert.Len(t, exportCourses[0].Properties(MapPropertiesFull, ""), 15)
+
+	assert.Equal(t, "-", exportCourses[1].Properties(MapPropertiesMinimal, "")[2].Value, "unrated courses")
+	assert.Equal(t, "https://golf.example/course/4", exportCourses[0].URL("https://golf.example/"))
+}
+
+func TestMapExportGeoJSON(t *testing.T) {
+	var collection GeoJSONFeatureCollection
+	require.NoError(t, json.Unmarshal(encodeExport(t, MapExportGeoJSON, MapPropertiesFull), &collection))
+
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	require.Len(t, collection.Features, 2)
+	assert.Equal(t, []float64{-79.4379, 35.1618}, collection.Features[0].Geometry.Coordinates)
+	assert.Equal(t, "Mid Pines", collection.Features[0].Properties["name"])
+	assert.Equal(t, "https://golf.example/course/4", collection.Features[0].Properties["url"])
+	assert.Equal(t, []interface{}{"classic"}, collection.Features[0].Properties["tags"])
+	assert.Equal(t, []interface{}{}, collection.Features[1].Properties["amenities"], "empty lists, not null")
+
+	var empty GeoJSONFeatureCollection
+	var buf bytes.Buffer
+	encoder := newMapExportEncoder(MapExportGeoJSON, &buf, MapPropertiesStandard, "")
+	require.NoError(t, encoder.Begin())
+	require.NoError(t, encoder.End())
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &empty))
+	assert.NotNil(t, empty.Features)
+	assert.Empty(t, empty.Features)
+}
+
+func TestMapExportKML(t *testing.T) {
+	var document struct {
+		Name       string         `xml:"Document>name"`
+		Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+	}
+	data := encodeExport(t, MapExportKML, MapPropertiesFull)
+	require.NoError(t, xml.Unmarshal(data, &document), string(data))
+
+	assert.Equal(t, "Golf courses", document.Name)
+	require.Len(t, document.Placemarks, 2)
+	assert.Equal(t, "course-4", document.Placemarks[0].ID)
+	assert.Equal(t, "-79.437900,35.161800", document.Placemarks[0].Coordinates, "longitude first")
+	assert.Equal(t, "1010 Midland Rd, Southern Pines, NC", document.Placemarks[0].Description)
+	assert.Contains(t, document.Placemarks[0].Data, kmlData{Name: "description", Value: "Donald Ross & friends"})
+	assert.Contains(t, document.Placemarks[0].Data, kmlData{Name: "tags", Value: "classic"})
+	assert.Equal(t, "Tobacco <Road>", document.Placemarks[1].Name, "names are escaped")
+}
+
+func TestMapExportGPX(t *testing.T) {
+	var document struct {
+		Version   string        `xml:"version,attr"`
+		Waypoints []gpxWaypoint `xml:"wpt"`
+	}
+	data := encodeExport(t, MapExportGPX, MapPropertiesStandard)
+	require.NoError(t, xml.Unmarshal(data, &document), string(data))
+
+	assert.Equal(t, "1.1", document.Version)
+	require.Len(t, document.Waypoints, 2)
+	waypoint := document.Waypoints[0]
+	assert.Equal(t, "35.161800", waypoint.Latitude)
+	assert.Equal(t, "-79.437900", waypoint.Longitude)
+	assert.Equal(t, "Rating: A", waypoint.Comment)
+	assert.Equal(t, "1010 Midland Rd, Southern Pines, NC", waypoint.Description)
+	require.NotNil(t, waypoint.Link)
+	assert.Equal(t, "https://golf.example/course/4", waypoint.Link.Href)
+	assert.Empty(t, document.Waypoints[1].Comment, "unrated courses have no comment")
+
+	var minimal struct {
+		Waypoints []gpxWaypoint `xml:"wpt"`
+	}
+	require.NoError(t, xml.Unmarshal(encodeExport(t, MapExportGPX, MapPropertiesMinimal), &minimal))
+	require.Len(t, minimal.Waypoints, 2)
+	assert.Empty(t, minimal.Waypoints[0].Description)
+	assert.Nil(t, minimal.Waypoints[0].Link)
+}
@@ -23,6 +23,8 @@ type MapCourseResponse struct {
 	TotalReviews  int      `json:"total_reviews"`
 	CanEdit       bool     `json:"can_edit"`
 	Distance      *float64 `json:"distance,omitempty"` // in kilometers
+	Tags          []string `json:"tags,omitempty"`
+	Amenities     []string `json:"amenities,omitempty"`
 }
 
 // BoundsRequest represents geographic bounds for map queries
@@ -33,6 +35,8 @@ type BoundsRequest struct {
 	WestLng   float64 `query:"west_lng" validate:"required,min=-180,max=180"`
 	MinRating *float64 `query:"min_rating" validate:"omitempty,min=0,max=10"`
 	MaxRating *float64 `query:"max_rating" validate:"omitempty,min=0,max=10"`
+	Tags      []string `query:"tags"`      // Course must carry every tag
+	Amenities []string `query:"amenities"` // Course must offer every amenity
 }
 
 // GeocodeRequest represents a geocoding request
@@ -62,6 +66,7 @@ type AddressComponents struct {
 
 // CourseClusterResponse represents clustered course data for map display
 type CourseClusterResponse struct {
+	ID          string  `json:"id"` // Pass to /map/courses/clusters/:id to expand
 	Latitude    float64 `json:"latitude"`
 	Longitude   float64 `json:"longitude"`
 	CourseCount int     `json:"course_count"`
@@ -69,6 +74,13 @@ type CourseClusterResponse struct {
 	Courses     []*MapCourseResponse `json:"courses,omitempty"` // Only included for small clusters
 }
 
+// ClusterExpansionResponse is what a cluster breaks into when the map zooms in
+type ClusterExpansionResponse struct {
+	ClusterID     string                   `json:"cluster_id"`
+	ExpansionZoom int                      `json:"expansion_zoom"` // First zoom where the courses split apart
+	Clusters      []*CourseClusterResponse `json:"clusters"`
+}
+
 // NewMapHandler creates a new map handler
 func NewMapHandler(dbService MapDatabaseServiceInterface) *MapHandler {
 	return &MapHandler{
@@ -93,136 +105,6 @@ func (h *MapHandler) GetMapCourses(c echo.Context) error {
 	return SuccessResponse(c, courses)
 }
 
-// GetCoursesInBounds returns courses within geographic bounds
-func (h *MapHandler) GetCoursesInBounds(c echo.Context) error {
-	var bounds BoundsRequest
-	if err := c.Bind(&bounds); err != nil {
-		return BadRequestError(c, "Invalid bounds parameters")
-	}
-
-	// Validate bounds
-	if bounds.NorthLat <= bounds.SouthLat {
-		return BadRequestError(c, "North latitude must be greater than south latitude")
-	}
-
-	if bounds.EastLng <= bounds.WestLng {
-		return BadRequestError(c, "East longitude must be greater than west longitude")
-	}
-
-	// Validate rating range
-	if bounds.MinRating != nil && bounds.MaxRating != nil && *bounds.MinRating > *bounds.MaxRating {
-		return BadRequestError(c, "Minimum rating cannot be greater than maximum rating")
-	}
-
-	// Get user ID if authenticated
-	var userID *uint
-	if uid, err := GetUserID(c); err == nil {
-		userID = &uid
-	}
-
-	courses, err := h.dbService.GetCoursesInBounds(&bounds, userID)
-	if err != nil {
-		return InternalServerError(c, "Failed to retrieve courses in bounds")
-	}
-
-	return SuccessResponse(c, courses)
-}
-
-// GetClusteredCourses returns clustered course data for efficient map rendering
-func (h *MapHandler) GetClusteredCourses(c echo.Context) error {
-	var bounds BoundsRequest
-	if err := c.Bind(&bounds); err != nil {
-		return BadRequestError(c, "Invalid bounds parameters")
-	}
-
-	// Get zoom level for clustering
-	zoomLevelParam := c.QueryParam("zoom")
-	zoomLevel := 10 // Default zoom level
-	if zoomLevelParam != "" {
-		if z, err := strconv.Atoi(zoomLevelParam); err == nil && z >= 1 && z <= 20 {
-			zoomLevel = z
-		}
-	}
-
-	// Get cluster size preference
-	maxClusterSizeParam := c.QueryParam("max_cluster_size")
-	maxClusterSize := 50 // Default max cluster size
-	if maxClusterSizeParam != "" {
-		if s, err := strconv.Atoi(maxClusterSizeParam); err == nil && s >= 10 && s <= 1000 {
-			maxClusterSize = s
-		}
-	}
-
-	// Get user ID if authenticated
-	var userID *uint
-	if uid, err := GetUserID(c); err == nil {
-		userID = &uid
-	}
-
-	clusters, err := h.dbService.GetClusteredCourses(&bounds, userID, zoomLevel, maxClusterSize)
-	if err != nil {
-		return InternalServerError(c, "Failed to retrieve clustered courses")
-	}
-
-	return SuccessResponse(c, clusters)
-}
-
-// GeocodeAddress geocodes an address to coordinates
-func (h *MapHandler) GeocodeAddress(c echo.Context) error {
-	var req GeocodeRequest
-	if err := c.Bind(&req); err != nil {
-		return BadRequestError(c, "Invalid request format")
-	}
-
-	if len(req.Address) < 5 {
-		return ValidationError(c, map[string]string{
-			"address": "Address must be at least 5 characters long",
-		})
-	}
-
-	result, err := h.dbService.GeocodeAddress(req.Address)
-	if err != nil {
-		return InternalServerError(c, "Geocoding failed")
-	}
-
-	if result == nil {
-		return NotFoundError(c, "Address could not be geocoded")
-	}
-
-	return SuccessResponse(c, result)
-}
-
-// ReverseGeocode converts coordinates to address
-func (h *MapHandler) ReverseGeocode(c echo.Context) error {
-	latParam := c.QueryParam("lat")
-	lngParam := c.QueryParam("lng")
-
-	if latParam == "" || lngParam == "" {
-		return BadRequestError(c, "Latitude and longitude are required")
-	}
-
-	latitude, err := strconv.ParseFloat(latParam, 64)
-	if err != nil || latitude < -90 || latitude > 90 {
-		return BadRequestError(c, "Invalid latitude")
-	}
-
-	longitude, err := strconv.ParseFloat(lngParam, 64)
-	if err != nil || longitude < -180 || longitude > 180 {
-		return BadRequestError(c, "Invalid longitude")
-	}
-
-	result, err := h.dbService.ReverseGeocode(latitude, longitude)
-	if err != nil {
-		return InternalServerError(c, "Reverse geocoding failed")
-	}
-
-	if result == nil {
-		return NotFoundError(c, "Coordinates could not be reverse geocoded")
-	}
-
-	return SuccessResponse(c, result)
-}
-
 // GetCourseRoute returns directions between two courses or from location to course
 func (h *MapHandler) GetCourseRoute(c echo.Context) error {
 	fromCourseIDParam := c.QueryParam("from_course_id")
@@ -321,13 +203,7 @@ func (h *MapHandler) GetMapStatistics(c echo.Context) error {
 func (h *MapHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
 	// Public routes (optionally authenticated)
 	g.GET("/map/courses", h.GetMapCourses, OptionalJWTMiddleware(jwtService))
-	g.GET("/map/courses/bounds", h.GetCoursesInBounds, OptionalJWTMiddleware(jwtService))
-	g.GET("/map/courses/clusters", h.GetClusteredCourses, OptionalJWTMiddleware(jwtService))
 	g.GET("/map/statistics", h.GetMapStatistics)
-	
-	// Geocoding routes (no authentication required)
-	g.POST("/map/geocode", h.GeocodeAddress)
-	g.GET("/map/reverse-geocode", h.ReverseGeocode)
 	g.GET("/map/route", h.GetCourseRoute)
 }
 
@@ -385,10 +261,6 @@ type RegionStats struct {
 type MapDatabaseServiceInterface interface {
 	ReviewDatabaseServiceInterface
 	GetMapCourses(userID *uint) ([]*MapCourseResponse, error)
-	GetCoursesInBounds(bounds *BoundsRequest, userID *uint) ([]*MapCourseResponse, error)
-	GetClusteredCourses(bounds *BoundsRequest, userID *uint, zoomLevel, maxClusterSize int) ([]*CourseClusterResponse, error)
-	GeocodeAddress(address string) (*GeocodeResponse, error)
-	ReverseGeocode(lat, lng float64) (*GeocodeResponse, error)
 	GetCourseLocation(courseID uint) (*MapCourseResponse, error)
 	GetRoute(fromLat, fromLng, toLat, toLng float64) (*RouteResponse, error)
 	GetMapStatistics() (*MapStatisticsResponse, error)
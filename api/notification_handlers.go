@@ -0,0 +1,208 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// NotificationTypes lists the kinds of notifications users can receive, in the
+// order their preferences are shown
+var NotificationTypes = []string{
+	"course_reviewed",
+	"course_edited",
+	"course_edit_suggested",
+	"course_edit_answered",
+	"review_helpful",
+	"outing_invite",
+	"outing_update",
+}
+
+// NotificationHandler handles the notification inbox and preference API endpoints
+type NotificationHandler struct {
+	dbService NotificationDatabaseServiceInterface
+}
+
+// NotificationResponse is a single notification in the inbox
+type NotificationResponse struct {
+	ID        uint   `json:"id"`
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	ActorID   *uint  `json:"actor_id,omitempty"` // Omitted when the actor is anonymous
+	CourseID  *uint  `json:"course_id,omitempty"`
+	Read      bool   `json:"read"`
+	ReadAt    *int64 `json:"read_at"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// UnreadCountResponse reports how many inbox notifications are unread
+type UnreadCountResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+// NotificationPreference is how the user wants to hear about one notification type
+type NotificationPreference struct {
+	Type  string `json:"type"`
+	InApp bool   `json:"in_app"`
+	Email bool   `json:"email"`
+}
+
+// NotificationPreferencesRequest updates the preferences for the listed types.
+// Types that aren't listed keep their current preferences.
+type NotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences"`
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(dbService NotificationDatabaseServiceInterface) *NotificationHandler {
+	return &NotificationHandler{
+		dbService: dbService,
+	}
+}
+
+// GetNotifications returns the authenticated user's inbox, newest first
+func (h *NotificationHandler) GetNotifications(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	unreadOnly := false
+	if raw := c.QueryParam("unread"); raw != "" {
+		if unreadOnly, err = strconv.ParseBool(raw); err != nil {
+			return ValidationError(c, map[string]string{"unread": "Unread must be true or false"})
+		}
+	}
+
+	pagination := GetPagination(c)
+	notifications, total, err := h.dbService.GetNotifications(userID, unreadOnly, pagination.Page, pagination.PerPage)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve notifications")
+	}
+
+	meta := &APIMeta{
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+		Total:      total,
+		TotalPages: (total + pagination.PerPage - 1) / pagination.PerPage,
+	}
+
+	return SuccessResponseWithMeta(c, notifications, meta)
+}
+
+// GetUnreadCount returns how many of the authenticated user's notifications are unread
+func (h *NotificationHandler) GetUnreadCount(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	unread, err := h.dbService.CountUnreadNotifications(userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to count notifications")
+	}
+
+	return SuccessResponse(c, UnreadCountResponse{Unread: unread})
+}
+
+// MarkNotificationRead marks one of the authenticated user's notifications as read
+func (h *NotificationHandler) MarkNotificationRead(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid notification ID")
+	}
+
+	found, err := h.dbService.MarkNotificationRead(userID, uint(notificationID))
+	if err != nil {
+		return InternalServerError(c, "Failed to update notification")
+	}
+	if !found {
+		return NotFoundError(c, "Notification")
+	}
+
+	return NoContentResponse(c)
+}
+
+// MarkAllNotificationsRead marks every notification in the authenticated user's inbox as read
+func (h *NotificationHandler) MarkAllNotificationsRead(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	if err := h.dbService.MarkAllNotificationsRead(userID); err != nil {
+		return InternalServerError(c, "Failed to update notifications")
+	}
+
+	return NoContentResponse(c)
+}
+
+// GetPreferences returns the authenticated user's preference for every notification type
+func (h *NotificationHandler) GetPreferences(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	preferences, err := h.dbService.GetNotificationPreferences(userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve notification preferences")
+	}
+
+	return SuccessResponse(c, preferences)
+}
+
+// UpdatePreferences changes how the authenticated user hears about notification types
+func (h *NotificationHandler) UpdatePreferences(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	var req NotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+
+	if len(req.Preferences) == 0 {
+		return ValidationError(c, map[string]string{"preferences": "At least one preference is required"})
+	}
+	for _, preference := range req.Preferences {
+		if !contains(NotificationTypes, preference.Type) {
+			return ValidationError(c, map[string]string{"type": "Unknown notification type '" + preference.Type + "'"})
+		}
+	}
+
+	preferences, err := h.dbService.UpdateNotificationPreferences(userID, req.Preferences)
+	if err != nil {
+		return InternalServerError(c, "Failed to update notification preferences")
+	}
+
+	return SuccessResponse(c, preferences)
+}
+
+// RegisterRoutes registers notification routes
+func (h *NotificationHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Protected routes (authentication required)
+	g.GET("/notifications", h.GetNotifications, JWTMiddleware(jwtService))
+	g.GET("/notifications/unread-count", h.GetUnreadCount, JWTMiddleware(jwtService))
+	g.POST("/notifications/read-all", h.MarkAllNotificationsRead, JWTMiddleware(jwtService))
+	g.POST("/notifications/:id/read", h.MarkNotificationRead, JWTMiddleware(jwtService))
+	g.GET("/notifications/preferences", h.GetPreferences, JWTMiddleware(jwtService))
+	g.PUT("/notifications/preferences", h.UpdatePreferences, JWTMiddleware(jwtService))
+}
+
+// Database interface for notification operations
+type NotificationDatabaseServiceInterface interface {
+	GetNotifications(userID uint, unreadOnly bool, page, perPage int) ([]*NotificationResponse, int, error)
+	CountUnreadNotifications(userID uint) (int64, error)
+	MarkNotificationRead(userID, notificationID uint) (bool, error) // False when the user has no such notification
+	MarkAllNotificationsRead(userID uint) error
+	GetNotificationPreferences(userID uint) ([]*NotificationPreference, error)
+	UpdateNotificationPreferences(userID uint, preferences []NotificationPreference) ([]*NotificationPreference, error)
+}
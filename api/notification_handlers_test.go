@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_Notifications(t *testing.T) {
+	t.Run("Returns unread page with meta", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		actorID := uint(5)
+		mockDB.On("GetNotifications", user.ID, true, 1, 2).Return([]*NotificationResponse{
+			{ID: 9, Type: "course_reviewed", Message: "Sam reviewed Pebble Creek", ActorID: &actorID, CreatedAt: 1700000000},
+			{ID: 8, Type: "review_helpful", Message: "Sam found your review of Pebble Creek helpful", ActorID: &actorID, CreatedAt: 1699990000},
+		}, 3, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/notifications?unread=true&per_page=2", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response APIResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		require.NotNil(t, response.Meta)
+		assert.Equal(t, 3, response.Meta.Total)
+		assert.Equal(t, 2, response.Meta.TotalPages)
+		assert.Len(t, response.Data.([]interface{}), 2)
+		assert.Contains(t, rec.Body.String(), `"read":false`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects invalid unread filter", func(t *testing.T) {
+		e, mockDB, _, token := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/notifications?unread=maybe", token, nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockDB.AssertNotCalled(t, "GetNotifications", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Returns unread count", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("CountUnreadNotifications", user.ID).Return(int64(4), nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/notifications/unread-count", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"unread":4`)
+	})
+
+	t.Run("Marks notification read", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("MarkNotificationRead", user.ID, uint(9)).Return(true, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/notifications/9/read", token, nil)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Someone else's notification is not found", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("MarkNotificationRead", user.ID, uint(10)).Return(false, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/notifications/10/read", token, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Marks all notifications read", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("MarkAllNotificationsRead", user.ID).Return(nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/notifications/read-all", token, nil)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		e, _, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/notifications", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestAPI_NotificationPreferences(t *testing.T) {
+	t.Run("Updates preferences", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		update := []NotificationPreference{{Type: "course_edited", InApp: true, Email: false}}
+		mockDB.On("UpdateNotificationPreferences", user.ID, update).Return([]*NotificationPreference{
+			{Type: "course_reviewed", InApp: true, Email: true},
+			{Type: "course_edited", InApp: true, Email: false},
+			{Type: "review_helpful", InApp: true, Email: true},
+		}, nil)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/notifications/preferences", token, NotificationPreferencesRequest{Preferences: update})
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `{"type":"course_edited","in_app":true,"email":false}`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects unknown type", func(t *testing.T) {
+		e, mockDB, _, token := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/notifications/preferences", token, NotificationPreferencesRequest{
+			Preferences: []NotificationPreference{{Type: "birthday", Email: true}},
+		})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockDB.AssertNotCalled(t, "UpdateNotificationPreferences", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects empty update", func(t *testing.T) {
+		e, _, _, token := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/notifications/preferences", token, NotificationPreferencesRequest{})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,522 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxOutingTitleLength = 100
+	maxOutingNotesLength = 2000
+	maxOutingCapacity    = 200
+	maxOutingInvites     = 100
+)
+
+// OutingRSVPs are the answers an invitee can give
+var OutingRSVPs = []string{"going", "maybe", "declined"}
+
+// OutingHandler handles outing, RSVP and outing calendar API endpoints
+type OutingHandler struct {
+	dbService OutingDatabaseServiceInterface
+}
+
+// OutingResponse is an outing as seen by one of its players
+type OutingResponse struct {
+	ID            uint                     `json:"id"`
+	Title         string                   `json:"title"`
+	CourseID      uint                     `json:"course_id"`
+	CourseName    string                   `json:"course_name"`
+	CourseAddress string                   `json:"course_address"`
+	OrganizerID   uint                     `json:"organizer_id"`
+	OrganizerName string                   `json:"organizer_name"`
+	StartsAt      int64                    `json:"starts_at"`
+	Capacity      int                      `json:"capacity"`   // Zero when there's no limit
+	SpotsLeft     *int                     `json:"spots_left"` // Null when there's no limit
+	GoingCount    int                      `json:"going_count"`
+	Notes         *string                  `json:"notes"`
+	Cancelled     bool                     `json:"cancelled"`
+	CancelledAt   *int64                   `json:"cancelled_at,omitempty"`
+	IsOrganizer   bool                     `json:"is_organizer"`
+	MyRSVP        string                   `json:"my_rsvp,omitempty"`
+	Invitees      []*OutingInviteeResponse `json:"invitees"`
+	CreatedAt     int64                    `json:"created_at"`
+	UpdatedAt     int64                    `json:"updated_at"`
+}
+
+// OutingInviteeResponse is a person on an outing's invite list
+type OutingInviteeResponse struct {
+	InviteID    uint    `json:"invite_id"`
+	UserID      *uint   `json:"user_id,omitempty"` // Omitted for email invitees
+	Email       *string `json:"email,omitempty"`   // Only shown to the organizer
+	Name        string  `json:"name"`
+	RSVP        string  `json:"rsvp"`
+	RespondedAt *int64  `json:"responded_at"`
+}
+
+// OutingResultResponse is a player's score in an outing's results
+type OutingResultResponse struct {
+	Rank     int      `json:"rank"` // Players with the same score share a rank
+	UserID   uint     `json:"user_id"`
+	Name     string   `json:"name"`
+	ScoreID  uint     `json:"score_id"`
+	Score    int      `json:"score"`
+	Handicap *float64 `json:"handicap,omitempty"`
+	Net      *float64 `json:"net,omitempty"`
+}
+
+// OutingCalendarFeedResponse is the address of a user's outing calendar feed
+type OutingCalendarFeedResponse struct {
+	URL string `json:"url"`
+}
+
+// OutingRequest creates or changes an outing. StartsAt is RFC 3339.
+type OutingRequest struct {
+	CourseID      uint     `json:"course_id"` // Ignored when changing an outing
+	Title         string   `json:"title"`
+	StartsAt      string   `json:"starts_at"`
+	Capacity      int      `json:"capacity"`
+	Notes         string   `json:"notes"`
+	InviteUserIDs []uint   `json:"invite_user_ids"` // Only when creating
+	InviteEmails  []string `json:"invite_emails"`   // Only when creating
+}
+
+// OutingDetails are the validated fields of an OutingRequest
+type OutingDetails struct {
+	CourseID uint
+	Title    string
+	StartsAt time.Time
+	Capacity int
+	Notes    string
+}
+
+// OutingInviteRequest invites registered users and email addresses
+type OutingInviteRequest struct {
+	UserIDs []uint   `json:"user_ids"`
+	Emails  []string `json:"emails"`
+}
+
+// OutingRSVPRequest answers an invitation
+type OutingRSVPRequest struct {
+	RSVP string `json:"rsvp"`
+}
+
+// OutingScoreRequest links one of the player's scores to an outing
+type OutingScoreRequest struct {
+	ScoreID uint `json:"score_id"`
+}
+
+// NewOutingHandler creates a new outing handler
+func NewOutingHandler(dbService OutingDatabaseServiceInterface) *OutingHandler {
+	return &OutingHandler{
+		dbService: dbService,
+	}
+}
+
+// GetOutings returns the outings the authenticated user organizes or is invited to
+func (h *OutingHandler) GetOutings(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	outings, err := h.dbService.GetOutings(userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve outings")
+	}
+
+	return SuccessResponse(c, outings)
+}
+
+// CreateOuting schedules an outing organized by the authenticated user and
+// sends its first invitations
+func (h *OutingHandler) CreateOuting(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	var req OutingRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	details, validationErrors := validateOutingRequest(&req)
+	if req.CourseID == 0 {
+		validationErrors["course_id"] = "Course ID is required"
+	}
+	for field, message := range validateOutingInvitees(req.InviteUserIDs, req.InviteEmails) {
+		validationErrors["invite_"+field] = message
+	}
+	if len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+
+	exists, err := h.dbService.CourseExists(req.CourseID)
+	if err != nil {
+		return InternalServerError(c, "Failed to verify course")
+	}
+	if !exists {
+		return NotFoundError(c, "Course")
+	}
+	if err := h.checkUsersExist(c, req.InviteUserIDs); err != nil {
+		return err
+	}
+
+	outing, err := h.dbService.CreateOuting(userID, details)
+	if err != nil {
+		return InternalServerError(c, "Failed to create outing")
+	}
+	if len(req.InviteUserIDs)+len(req.InviteEmails) > 0 {
+		outing, err = h.dbService.InviteToOuting(userID, outing.ID, req.InviteUserIDs, req.InviteEmails)
+		if err != nil {
+			return InternalServerError(c, "Failed to send invitations")
+		}
+	}
+
+	return CreatedResponse(c, outing)
+}
+
+// GetOuting returns an outing the authenticated user organizes or is invited to
+func (h *OutingHandler) GetOuting(c echo.Context) error {
+	_, outing, err := h.visibleOuting(c)
+	if err != nil || outing == nil {
+		return err
+	}
+
+	return SuccessResponse(c, outing)
+}
+
+// UpdateOuting changes an outing's title, time, capacity or notes. Only the
+// organizer can change an outing.
+func (h *OutingHandler) UpdateOuting(c echo.Context) error {
+	userID, outing, err := h.organizedOuting(c)
+	if err != nil || outing == nil {
+		return err
+	}
+
+	var req OutingRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	details, validationErrors := validateOutingRequest(&req)
+	if len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+
+	updated, err := h.dbService.UpdateOuting(userID, outing.ID, details)
+	if err != nil {
+		return InternalServerError(c, "Failed to update outing")
+	}
+
+	return SuccessResponse(c, updated)
+}
+
+// CancelOuting calls off an outing. It stays listed, marked cancelled, so
+// subscribed calendars drop it.
+func (h *OutingHandler) CancelOuting(c echo.Context) error {
+	userID, outing, err := h.organizedOuting(c)
+	if err != nil || outing == nil {
+		return err
+	}
+
+	if err := h.dbService.CancelOuting(userID, outing.ID); err != nil {
+		return InternalServerError(c, "Failed to cancel outing")
+	}
+
+	return NoContentResponse(c)
+}
+
+// InviteToOuting adds registered users and email addresses to an outing
+func (h *OutingHandler) InviteToOuting(c echo.Context) error {
+	userID, outing, err := h.organizedOuting(c)
+	if err != nil || outing == nil {
+		return err
+	}
+
+	var req OutingInviteRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	validationErrors := validateOutingInvitees(req.UserIDs, req.Emails)
+	if len(req.UserIDs)+len(req.Emails) == 0 {
+		validationErrors["user_ids"] = "Invite at least one user or email address"
+	}
+	if len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+	if err := h.checkUsersExist(c, req.UserIDs); err != nil {
+		return err
+	}
+
+	updated, err := h.dbService.InviteToOuting(userID, outing.ID, req.UserIDs, req.Emails)
+	if err != nil {
+		return InternalServerError(c, "Failed to send invitations")
+	}
+
+	return SuccessResponse(c, updated)
+}
+
+// RSVPOuting records the authenticated user's answer to an invitation.
+// Answering going to a full outing puts the user on the waitlist.
+func (h *OutingHandler) RSVPOuting(c echo.Context) error {
+	userID, outing, err := h.visibleOuting(c)
+	if err != nil || outing == nil {
+		return err
+	}
+
+	var req OutingRSVPRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	if !contains(OutingRSVPs, req.RSVP) {
+		return ValidationError(c, map[string]string{"rsvp": "RSVP must be one of " + strings.Join(OutingRSVPs, ", ")})
+	}
+	if outing.Cancelled {
+		return ConflictError(c, "This outing has been cancelled")
+	}
+
+	updated, err := h.dbService.RSVPOuting(userID, outing.ID, req.RSVP)
+	if err != nil {
+		return InternalServerError(c, "Failed to save RSVP")
+	}
+
+	return SuccessResponse(c, updated)
+}
+
+// LinkOutingScore marks one of the authenticated user's scores as their round
+// at the outing
+func (h *OutingHandler) LinkOutingScore(c echo.Context) error {
+	userID, outing, err := h.visibleOuting(c)
+	if err != nil || outing == nil {
+		return err
+	}
+
+	var req OutingScoreRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	if req.ScoreID == 0 {
+		return ValidationError(c, map[string]string{"score_id": "Score ID is required"})
+	}
+	if outing.Cancelled || time.Now().Unix() < outing.StartsAt {
+		return ConflictError(c, "Scores can be linked once the outing has been played")
+	}
+	if outing.MyRSVP != "going" {
+		return ForbiddenError(c, "Only players who went can link scores")
+	}
+
+	linked, err := h.dbService.LinkOutingScore(userID, outing.ID, req.ScoreID)
+	if err != nil {
+		return InternalServerError(c, "Failed to link score")
+	}
+	if !linked {
+		return ValidationError(c, map[string]string{"score_id": "Must be one of your scores from the outing's course"})
+	}
+
+	results, err := h.dbService.GetOutingResults(userID, outing.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve results")
+	}
+
+	return SuccessResponse(c, results)
+}
+
+// GetOutingResults returns the scores of the players who went, best first
+func (h *OutingHandler) GetOutingResults(c echo.Context) error {
+	userID, outing, err := h.visibleOuting(c)
+	if err != nil || outing == nil {
+		return err
+	}
+
+	results, err := h.dbService.GetOutingResults(userID, outing.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve results")
+	}
+
+	return SuccessResponse(c, results)
+}
+
+// GetOutingCalendar returns an outing as an iCalendar (.ics) file
+func (h *OutingHandler) GetOutingCalendar(c echo.Context) error {
+	userID, outing, err := h.visibleOuting(c)
+	if err != nil || outing == nil {
+		return err
+	}
+
+	calendar, err := h.dbService.GetOutingCalendar(userID, outing.ID, RequestBaseURL(c))
+	if err != nil {
+		return InternalServerError(c, "Failed to build calendar")
+	}
+	if calendar == nil {
+		return NotFoundError(c, "Outing")
+	}
+
+	// The API middleware presets a JSON content type, which Blob won't replace
+	c.Response().Header().Set(echo.HeaderContentType, CalendarContentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="outing-%d.ics"`, outing.ID))
+	return c.Blob(http.StatusOK, CalendarContentType, calendar)
+}
+
+// GetOutingCalendarFeed returns the address of the authenticated user's
+// outing calendar feed, for subscribing from a calendar app. Anyone with the
+// address can read the feed.
+func (h *OutingHandler) GetOutingCalendarFeed(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	token, err := h.dbService.GetOutingCalendarFeedToken(userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve calendar feed")
+	}
+
+	return SuccessResponse(c, OutingCalendarFeedResponse{
+		URL: RequestBaseURL(c) + "/outings/calendar/" + token + ".ics",
+	})
+}
+
+// visibleOuting loads the outing in the :id parameter for the authenticated
+// user. It writes the error response itself; the outing is nil when it did.
+func (h *OutingHandler) visibleOuting(c echo.Context) (uint, *OutingResponse, error) {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return 0, nil, UnauthorizedError(c, "Authentication required")
+	}
+
+	outingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, nil, BadRequestError(c, "Invalid outing ID")
+	}
+
+	outing, err := h.dbService.GetOuting(userID, uint(outingID))
+	if err != nil {
+		return 0, nil, InternalServerError(c, "Failed to retrieve outing")
+	}
+	if outing == nil {
+		return 0, nil, NotFoundError(c, "Outing")
+	}
+	return userID, outing, nil
+}
+
+// organizedOuting is visibleOuting for changes only the organizer can make
+// to outings that haven't been cancelled
+func (h *OutingHandler) organizedOuting(c echo.Context) (uint, *OutingResponse, error) {
+	userID, outing, err := h.visibleOuting(c)
+	if err != nil || outing == nil {
+		return 0, nil, err
+	}
+	if !outing.IsOrganizer {
+		return 0, nil, ForbiddenError(c, "Only the organizer can change this outing")
+	}
+	if outing.Cancelled {
+		return 0, nil, ConflictError(c, "This outing has been cancelled")
+	}
+	return userID, outing, nil
+}
+
+// checkUsersExist writes a not found response if any of the users doesn't exist
+func (h *OutingHandler) checkUsersExist(c echo.Context, userIDs []uint) error {
+	for _, id := range userIDs {
+		exists, err := h.dbService.UserExists(id)
+		if err != nil {
+			return InternalServerError(c, "Failed to verify user")
+		}
+		if !exists {
+			return NotFoundError(c, "User")
+		}
+	}
+	return nil
+}
+
+func validateOutingRequest(req *OutingRequest) (OutingDetails, map[string]string) {
+	errors := make(map[string]string)
+	details := OutingDetails{
+		CourseID: req.CourseID,
+		Title:    strings.TrimSpace(req.Title),
+		Capacity: req.Capacity,
+		Notes:    strings.TrimSpace(req.Notes),
+	}
+
+	if details.Title == "" {
+		errors["title"] = "Title is required"
+	} else if utf8.RuneCountInString(details.Title) > maxOutingTitleLength {
+		errors["title"] = fmt.Sprintf("Title must be at most %d characters", maxOutingTitleLength)
+	}
+
+	startsAt, err := time.Parse(time.RFC3339, req.StartsAt)
+	switch {
+	case err != nil:
+		errors["starts_at"] = "Start time must be an RFC 3339 timestamp, e.g. 2026-05-02T08:30:00-04:00"
+	case !startsAt.After(time.Now()):
+		errors["starts_at"] = "Start time must be in the future"
+	default:
+		details.StartsAt = startsAt
+	}
+
+	if req.Capacity < 0 || req.Capacity > maxOutingCapacity {
+		errors["capacity"] = fmt.Sprintf("Capacity must be between 0 (no limit) and %d", maxOutingCapacity)
+	}
+	if utf8.RuneCountInString(details.Notes) > maxOutingNotesLength {
+		errors["notes"] = fmt.Sprintf("Notes must be at most %d characters", maxOutingNotesLength)
+	}
+	return details, errors
+}
+
+func validateOutingInvitees(userIDs []uint, emails []string) map[string]string {
+	errors := make(map[string]string)
+	if len(userIDs)+len(emails) > maxOutingInvites {
+		errors["user_ids"] = fmt.Sprintf("Invite at most %d people at a time", maxOutingInvites)
+	}
+	for _, email := range emails {
+		if _, err := mail.ParseAddress(strings.TrimSpace(email)); err != nil {
+			errors["emails"] = fmt.Sprintf("'%s' isn't a valid email address", email)
+			break
+		}
+	}
+	return errors
+}
+
+// RequestBaseURL is the site address the request was made to
+func RequestBaseURL(c echo.Context) string {
+	return c.Scheme() + "://" + c.Request().Host
+}
+
+// RegisterRoutes registers outing routes
+func (h *OutingHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Protected routes (authentication required)
+	g.GET("/user/outings", h.GetOutings, JWTMiddleware(jwtService))
+	g.POST("/user/outings", h.CreateOuting, JWTMiddleware(jwtService))
+	g.GET("/user/outings/calendar", h.GetOutingCalendarFeed, JWTMiddleware(jwtService))
+	g.GET("/user/outings/:id", h.GetOuting, JWTMiddleware(jwtService))
+	g.PUT("/user/outings/:id", h.UpdateOuting, JWTMiddleware(jwtService))
+	g.DELETE("/user/outings/:id", h.CancelOuting, JWTMiddleware(jwtService))
+	g.POST("/user/outings/:id/invites", h.InviteToOuting, JWTMiddleware(jwtService))
+	g.PUT("/user/outings/:id/rsvp", h.RSVPOuting, JWTMiddleware(jwtService))
+	g.PUT("/user/outings/:id/score", h.LinkOutingScore, JWTMiddleware(jwtService))
+	g.GET("/user/outings/:id/results", h.GetOutingResults, JWTMiddleware(jwtService))
+	g.GET("/user/outings/:id/calendar.ics", h.GetOutingCalendar, JWTMiddleware(jwtService))
+}
+
+// Database interface for outing operations
+type OutingDatabaseServiceInterface interface {
+	CourseExists(courseID uint) (bool, error)
+	UserExists(userID uint) (bool, error)
+	GetOutings(userID uint) ([]*OutingResponse, error)
+	GetOuting(userID, outingID uint) (*OutingResponse, error) // Nil unless the user organizes or is invited to the outing
+	CreateOuting(userID uint, details OutingDetails) (*OutingResponse, error)
+	UpdateOuting(userID, outingID uint, details OutingDetails) (*OutingResponse, error)
+	CancelOuting(userID, outingID uint) error
+	InviteToOuting(userID, outingID uint, userIDs []uint, emails []string) (*OutingResponse, error)
+	RSVPOuting(userID, outingID uint, rsvp string) (*OutingResponse, error)
+	LinkOutingScore(userID, outingID, scoreID uint) (bool, error) // False when the score isn't the user's or is from another course
+	GetOutingResults(userID, outingID uint) ([]*OutingResultResponse, error)
+	GetOutingCalendar(userID, outingID uint, baseURL string) ([]byte, error) // Nil unless the user can see the outing
+	GetOutingCalendarFeedToken(userID uint) (string, error)
+}
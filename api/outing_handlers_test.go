@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testOuting(organizer bool) *OutingResponse {
+	spotsLeft := 2
+	return &OutingResponse{
+		ID:            6,
+		Title:         "Saturday skins",
+		CourseID:      10,
+		CourseName:    "Dunes",
+		OrganizerID:   1,
+		OrganizerName: "Sam",
+		StartsAt:      time.Now().Add(48 * time.Hour).Unix(),
+		Capacity:      4,
+		SpotsLeft:     &spotsLeft,
+		GoingCount:    2,
+		IsOrganizer:   organizer,
+		MyRSVP:        "going",
+		Invitees: []*OutingInviteeResponse{
+			{InviteID: 1, Name: "Sam", RSVP: "going"},
+			{InviteID: 2, Name: "Guest", RSVP: "invited"},
+		},
+	}
+}
+
+func TestAPI_Outings(t *testing.T) {
+	startsAt := time.Now().Add(72 * time.Hour).Truncate(time.Second)
+
+	t.Run("Creates an outing and invites players", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("CourseExists", uint(10)).Return(true, nil)
+		mockDB.On("UserExists", uint(42)).Return(true, nil)
+		mockDB.On("CreateOuting", user.ID, mock.MatchedBy(func(details OutingDetails) bool {
+			return details.Title == "Saturday skins" && details.StartsAt.Equal(startsAt) && details.Capacity == 4
+		})).Return(testOuting(true), nil)
+		mockDB.On("InviteToOuting", user.ID, uint(6), []uint{42}, []string{"friend@example.com"}).Return(testOuting(true), nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/user/outings", token, map[string]interface{}{
+			"course_id":       10,
+			"title":           " Saturday skins ",
+			"starts_at":       startsAt.Format(time.RFC3339),
+			"capacity":        4,
+			"invite_user_ids": []uint{42},
+			"invite_emails":   []string{"friend@example.com"},
+		})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"spots_left":2`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects invalid outings", func(t *testing.T) {
+		for _, body := range []map[string]interface{}{
+			{"course_id": 10, "title": "", "starts_at": startsAt.Format(time.RFC3339)},
+			{"course_id": 10, "title": "Skins", "starts_at": "next Saturday"},
+			{"course_id": 10, "title": "Skins", "starts_at": time.Now().Add(-time.Hour).Format(time.RFC3339)},
+			{"course_id": 10, "title": "Skins", "starts_at": startsAt.Format(time.RFC3339), "capacity": 500},
+			{"course_id": 10, "title": "Skins", "starts_at": startsAt.Format(time.RFC3339), "invite_emails": []string{"not an email"}},
+			{"title": "Skins", "starts_at": startsAt.Format(time.RFC3339)},
+		} {
+			e, mockDB, _, token := setupAPITest(t)
+
+			rec := serveJSON(e, http.MethodPost, "/api/v1/user/outings", token, body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, "body %v", body)
+			mockDB.AssertNotCalled(t, "CreateOuting", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Hides outings the user isn't invited to", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetOuting", user.ID, uint(6)).Return(nil, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/user/outings/6", token, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Only the organizer changes an outing", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetOuting", user.ID, uint(6)).Return(testOuting(false), nil)
+
+		rec := serveJSON(e, http.MethodDelete, "/api/v1/user/outings/6", token, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		mockDB.AssertNotCalled(t, "CancelOuting", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Records an RSVP", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetOuting", user.ID, uint(6)).Return(testOuting(false), nil)
+		mockDB.On("RSVPOuting", user.ID, uint(6), "maybe").Return(testOuting(false), nil)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/user/outings/6/rsvp", token, map[string]string{"rsvp": "maybe"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects RSVPs to cancelled outings", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		cancelled := testOuting(false)
+		cancelled.Cancelled = true
+		mockDB.On("GetOuting", user.ID, uint(6)).Return(cancelled, nil)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/user/outings/6/rsvp", token, map[string]string{"rsvp": "going"})
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		mockDB.AssertNotCalled(t, "RSVPOuting", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Links a score once the outing has been played", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		played := testOuting(false)
+		played.StartsAt = time.Now().Add(-4 * time.Hour).Unix()
+		mockDB.On("GetOuting", user.ID, uint(6)).Return(played, nil)
+		mockDB.On("LinkOutingScore", user.ID, uint(6), uint(31)).Return(true, nil)
+		mockDB.On("GetOutingResults", user.ID, uint(6)).Return([]*OutingResultResponse{
+			{Rank: 1, UserID: user.ID, Name: "Sam", ScoreID: 31, Score: 81},
+		}, nil)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/user/outings/6/score", token, map[string]uint{"score_id": 31})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"score":81`)
+	})
+
+	t.Run("Won't link scores before the outing", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetOuting", user.ID, uint(6)).Return(testOuting(false), nil)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/user/outings/6/score", token, map[string]uint{"score_id": 31})
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		mockDB.AssertNotCalled(t, "LinkOutingScore", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Serves an outing as an iCalendar file", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetOuting", user.ID, uint(6)).Return(testOuting(false), nil)
+		mockDB.On("GetOutingCalendar", user.ID, uint(6), "http://example.com").Return([]byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"), nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/user/outings/6/calendar.ics", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, CalendarContentType, rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Header().Get("Content-Disposition"), "outing-6.ics")
+	})
+
+	t.Run("Returns the calendar feed address", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetOutingCalendarFeedToken", user.ID).Return("abc123", nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/user/outings/calendar", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"url":"http://example.com/outings/calendar/abc123.ics"`)
+	})
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ProfileAudiences lists who a profile section can be shown to, from widest to narrowest
+var ProfileAudiences = []string{
+	"public",
+	"followers",
+	"private",
+}
+
+// ProfileHandler handles public profile and profile privacy API endpoints
+type ProfileHandler struct {
+	dbService ProfileDatabaseServiceInterface
+}
+
+// PublicProfileResponse is a user's profile as the requesting user may see it.
+// Sections the user has hidden from the requester are null.
+type PublicProfileResponse struct {
+	UserID        uint                   `json:"user_id"`
+	DisplayName   string                 `json:"display_name"`
+	MemberSince   int64                  `json:"member_since"`
+	Followers     int64                  `json:"followers"`
+	Following     int64                  `json:"following"`
+	IsFollowing   bool                   `json:"is_following"` // Whether the requester follows this user
+	Handicap      *float64               `json:"handicap"`     // Null when unset or hidden
+	Reviews       *ProfileReviewsSection `json:"reviews"`
+	PlayedCourses *PlayedCoursesSection  `json:"played_courses"`
+	Activity      []*FeedItemResponse    `json:"activity"`
+}
+
+// ProfileReviewsSection lists a user's most recent reviews
+type ProfileReviewsSection struct {
+	Total int64                    `json:"total"`
+	Items []*ProfileReviewResponse `json:"items"`
+}
+
+// ProfileReviewResponse is one review on a profile
+type ProfileReviewResponse struct {
+	ID            uint    `json:"id"`
+	CourseID      uint    `json:"course_id"`
+	CourseName    string  `json:"course_name"`
+	OverallRating *string `json:"overall_rating,omitempty"`
+	ReviewText    *string `json:"review_text,omitempty"`
+	CreatedAt     int64   `json:"created_at"`
+}
+
+// PlayedCoursesSection summarizes the courses a user has posted scores at
+type PlayedCoursesSection struct {
+	CoursesPlayed int                      `json:"courses_played"`
+	RoundsPlayed  int                      `json:"rounds_played"`
+	BestScore     *int                     `json:"best_score,omitempty"`
+	Courses       []*PlayedCourseResponse  `json:"courses"`
+	Map           GeoJSONFeatureCollection `json:"map"` // One point per played course with a known location
+}
+
+// PlayedCourseResponse is one course a user has posted scores at
+type PlayedCourseResponse struct {
+	CourseID   uint   `json:"course_id"`
+	CourseName string `json:"course_name"`
+	Rounds     int    `json:"rounds"`
+	BestScore  int    `json:"best_score"`
+	LastPlayed int64  `json:"last_played"`
+}
+
+// ProfilePrivacy sets who can see each section of a profile. Display name,
+// member since and follower counts are always public.
+type ProfilePrivacy struct {
+	Handicap      string `json:"handicap"`
+	Reviews       string `json:"reviews"`
+	PlayedCourses string `json:"played_courses"`
+	Activity      string `json:"activity"`
+}
+
+// NewProfileHandler creates a new profile handler
+func NewProfileHandler(dbService ProfileDatabaseServiceInterface) *ProfileHandler {
+	return &ProfileHandler{
+		dbService: dbService,
+	}
+}
+
+// GetPublicProfile returns a user's profile, limited to the sections they share with the requester
+func (h *ProfileHandler) GetPublicProfile(c echo.Context) error {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid user ID")
+	}
+
+	var viewerID *uint
+	if uid, err := GetUserID(c); err == nil {
+		viewerID = &uid
+	}
+
+	profile, err := h.dbService.GetPublicProfile(uint(profileID), viewerID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve profile")
+	}
+	if profile == nil {
+		return NotFoundError(c, "User")
+	}
+
+	return SuccessResponse(c, profile)
+}
+
+// GetPrivacy returns the authenticated user's profile privacy settings
+func (h *ProfileHandler) GetPrivacy(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	privacy, err := h.dbService.GetProfilePrivacy(userID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve privacy settings")
+	}
+
+	return SuccessResponse(c, privacy)
+}
+
+// UpdatePrivacy changes who can see the authenticated user's profile sections.
+// Sections left out keep their current setting.
+func (h *ProfileHandler) UpdatePrivacy(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+
+	var req ProfilePrivacy
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+
+	validationErrors := make(map[string]string)
+	for field, audience := range map[string]string{
+		"handicap":       req.Handicap,
+		"reviews":        req.Reviews,
+		"played_courses": req.PlayedCourses,
+		"activity":       req.Activity,
+	} {
+		if audience != "" && !contains(ProfileAudiences, audience) {
+			validationErrors[field] = "Must be public, followers or private"
+		}
+	}
+	if len(validationErrors) > 0 {
+		return ValidationError(c, validationErrors)
+	}
+
+	privacy, err := h.dbService.UpdateProfilePrivacy(userID, req)
+	if err != nil {
+		return InternalServerError(c, "Failed to update privacy settings")
+	}
+
+	return SuccessResponse(c, privacy)
+}
+
+// RegisterRoutes registers profile routes
+func (h *ProfileHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Public routes (optionally authenticated so followers and the user see more)
+	g.GET("/users/:id", h.GetPublicProfile, OptionalJWTMiddleware(jwtService))
+
+	// Protected routes (authentication required)
+	g.GET("/user/privacy", h.GetPrivacy, JWTMiddleware(jwtService))
+	g.PUT("/user/privacy", h.UpdatePrivacy, JWTMiddleware(jwtService))
+}
+
+// Database interface for profile operations
+type ProfileDatabaseServiceInterface interface {
+	GetPublicProfile(userID uint, viewerID *uint) (*PublicProfileResponse, error) // Nil when the user doesn't exist
+	GetProfilePrivacy(userID uint) (*ProfilePrivacy, error)
+	UpdateProfilePrivacy(userID uint, privacy ProfilePrivacy) (*ProfilePrivacy, error)
+}
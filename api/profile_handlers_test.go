@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_PublicProfile(t *testing.T) {
+	t.Run("Returns profile to signed-out visitors", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		bestScore := 78
+		played := NewFeatureCollection()
+		played.Features = append(played.Features, NewPointFeature(36.57, -121.95, map[string]interface{}{"course_id": 3}))
+		mockDB.On("GetPublicProfile", uint(7), (*uint)(nil)).Return(&PublicProfileResponse{
+			UserID:      7,
+			DisplayName: "Sam",
+			PlayedCourses: &PlayedCoursesSection{
+				CoursesPlayed: 1,
+				RoundsPlayed:  2,
+				BestScore:     &bestScore,
+				Courses:       []*PlayedCourseResponse{{CourseID: 3, CourseName: "Pebble Creek", Rounds: 2, BestScore: 78}},
+				Map:           played,
+			},
+			Activity: []*FeedItemResponse{},
+		}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/users/7", "", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"handicap":null`)
+		assert.Contains(t, rec.Body.String(), `"reviews":null`)
+		assert.Contains(t, rec.Body.String(), `"coordinates":[-121.95,36.57]`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Passes the signed-in viewer", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetPublicProfile", uint(7), mock.MatchedBy(func(viewerID *uint) bool {
+			return viewerID != nil && *viewerID == user.ID
+		})).Return(&PublicProfileResponse{UserID: 7, DisplayName: "Sam", IsFollowing: true}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/users/7", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"is_following":true`)
+	})
+
+	t.Run("Returns 404 for unknown user", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("GetPublicProfile", uint(99), (*uint)(nil)).Return(nil, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/users/99", "", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Rejects invalid user ID", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/users/abc", "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockDB.AssertNotCalled(t, "GetPublicProfile", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAPI_ProfilePrivacy(t *testing.T) {
+	t.Run("Returns privacy settings", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("GetProfilePrivacy", user.ID).Return(&ProfilePrivacy{
+			Handicap: "private", Reviews: "public", PlayedCourses: "public", Activity: "public",
+		}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/user/privacy", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"handicap":"private"`)
+	})
+
+	t.Run("Updates privacy settings", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		update := ProfilePrivacy{Handicap: "followers"}
+		mockDB.On("UpdateProfilePrivacy", user.ID, update).Return(&ProfilePrivacy{
+			Handicap: "followers", Reviews: "public", PlayedCourses: "public", Activity: "public",
+		}, nil)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/user/privacy", token, map[string]string{"handicap": "followers"})
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"handicap":"followers"`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects unknown audience", func(t *testing.T) {
+		e, mockDB, _, token := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodPut, "/api/v1/user/privacy", token, map[string]string{"reviews": "friends"})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"reviews":"Must be public, followers or private"`)
+		mockDB.AssertNotCalled(t, "UpdateProfilePrivacy", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		e, _, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/user/privacy", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
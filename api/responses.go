@@ -28,10 +28,12 @@ type APIError struct {
 
 // APIMeta contains metadata for API responses (pagination, etc.)
 type APIMeta struct {
-	Page       int `json:"page,omitempty"`
-	PerPage    int `json:"per_page,omitempty"`
-	Total      int `json:"total,omitempty"`
-	TotalPages int `json:"total_pages,omitempty"`
+	Page       int                `json:"page,omitempty"`
+	PerPage    int                `json:"per_page,omitempty"`
+	Total      int                `json:"total,omitempty"`
+	TotalPages int                `json:"total_pages,omitempty"`
+	NextCursor string             `json:"next_cursor,omitempty"` // Set by cursor-paginated endpoints while more results remain
+	Facets     *CourseFacetCounts `json:"facets,omitempty"`      // Set by course search
 }
 
 // Pagination parameters for list endpoints
@@ -31,6 +31,7 @@ type ReviewCreateRequest struct {
 	Maintenance       *int    `json:"maintenance,omitempty" validate:"omitempty,min=1,max=10"`
 	Pace              *int    `json:"pace,omitempty" validate:"omitempty,min=1,max=10"`
 	Staff             *int    `json:"staff,omitempty" validate:"omitempty,min=1,max=10"`
+	Visibility        *string `json:"visibility,omitempty" validate:"omitempty,oneof=public anonymous private"` // Defaults to public
 }
 
 // ReviewUpdateRequest represents review update request
@@ -52,6 +53,7 @@ type ReviewUpdateRequest struct {
 	Maintenance       *int    `json:"maintenance,omitempty" validate:"omitempty,min=1,max=10"`
 	Pace              *int    `json:"pace,omitempty" validate:"omitempty,min=1,max=10"`
 	Staff             *int    `json:"staff,omitempty" validate:"omitempty,min=1,max=10"`
+	Visibility        *string `json:"visibility,omitempty" validate:"omitempty,oneof=public anonymous private"` // Unchanged when omitted
 }
 
 // ReviewResponse represents review data for API responses
@@ -79,6 +81,7 @@ type ReviewResponse struct {
 	Maintenance       *int    `json:"maintenance"`
 	Pace              *int    `json:"pace"`
 	Staff             *int    `json:"staff"`
+	Visibility        string  `json:"visibility"` // public, anonymous or private
 	CreatedAt         int64   `json:"created_at"`
 	UpdatedAt         int64   `json:"updated_at"`
 	// Additional metadata
@@ -156,6 +159,7 @@ func (h *ReviewHandler) GetCourseReviews(c echo.Context) error {
 	if err != nil {
 		return InternalServerError(c, "Failed to retrieve reviews")
 	}
+	reviews = applyReviewVisibility(reviews, userID)
 
 	// Create paginated response
 	meta := &APIMeta{
@@ -189,6 +193,10 @@ func (h *ReviewHandler) GetCourseReviewSummary(c echo.Context) error {
 	if err != nil {
 		return InternalServerError(c, "Failed to retrieve review summary")
 	}
+	if summary != nil {
+		// The summary endpoint is public, so recent reviews are shown as a guest sees them
+		summary.RecentReviews = applyReviewVisibility(summary.RecentReviews, nil)
+	}
 
 	return SuccessResponse(c, summary)
 }
@@ -249,6 +257,10 @@ func (h *ReviewHandler) CreateReview(c echo.Context) error {
 		validationErrors["review_text"] = "Review text must be 2000 characters or less"
 	}
 
+	if req.Visibility != nil && !IsReviewVisibility(*req.Visibility) {
+		validationErrors["visibility"] = reviewVisibilityMessage
+	}
+
 	if len(validationErrors) > 0 {
 		return ValidationError(c, validationErrors)
 	}
@@ -314,6 +326,10 @@ func (h *ReviewHandler) UpdateReview(c echo.Context) error {
 		validationErrors["overall_rating"] = "Overall rating must be between 1 and 10"
 	}
 
+	if req.Visibility != nil && !IsReviewVisibility(*req.Visibility) {
+		validationErrors["visibility"] = reviewVisibilityMessage
+	}
+
 	// Similar validation for other fields...
 	if len(validationErrors) > 0 {
 		return ValidationError(c, validationErrors)
@@ -438,6 +454,36 @@ func (h *ReviewHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
 	g.POST("/reviews/:id/helpful", h.MarkReviewHelpful, JWTMiddleware(jwtService))
 }
 
+const reviewVisibilityMessage = "Visibility must be 'public', 'anonymous' or 'private'"
+
+// IsReviewVisibility reports whether v is a supported review visibility
+func IsReviewVisibility(v string) bool {
+	return v == "public" || v == "anonymous" || v == "private"
+}
+
+// applyReviewVisibility enforces review visibility on reviews about to be
+// returned to userID (nil for guests). The database service is expected to
+// leave other users' private reviews out already; this is the last line of
+// defence, and it is where anonymous reviews lose their author details.
+func applyReviewVisibility(reviews []*ReviewResponse, userID *uint) []*ReviewResponse {
+	visible := make([]*ReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		isAuthor := userID != nil && *userID == review.UserID
+		switch {
+		case review.Visibility == "private" && !isAuthor:
+			continue
+		case review.Visibility == "anonymous" && !isAuthor:
+			anonymous := *review
+			anonymous.UserID = 0
+			anonymous.UserName = "Anonymous golfer"
+			anonymous.UserDisplayName = nil
+			review = &anonymous
+		}
+		visible = append(visible, review)
+	}
+	return visible
+}
+
 // Extended database interface for review operations
 type ReviewDatabaseServiceInterface interface {
 	CoursesDatabaseServiceInterface
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_CourseReviews_Visibility(t *testing.T) {
+	displayName := "Jamie"
+	reviews := func(authorID uint) []*ReviewResponse {
+		return []*ReviewResponse{
+			{ID: 1, CourseID: 4, UserID: 11, UserName: "Public Reviewer", Visibility: "public"},
+			{ID: 2, CourseID: 4, UserID: authorID, UserName: "Jamie Smith", UserDisplayName: &displayName, Visibility: "anonymous"},
+			{ID: 3, CourseID: 4, UserID: authorID, UserName: "Jamie Smith", Visibility: "private"},
+		}
+	}
+
+	t.Run("Guests", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("CourseExists", uint(4)).Return(true, nil)
+		mockDB.On("GetCourseReviews", uint(4), (*uint)(nil), "date", "desc", 1, 20).Return(reviews(12), 3, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/4/reviews", "", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response struct {
+			Data []ReviewResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		require.Len(t, response.Data, 2, "private reviews are never returned to other users")
+		assert.Equal(t, uint(0), response.Data[1].UserID)
+		assert.Equal(t, "Anonymous golfer", response.Data[1].UserName)
+		assert.Nil(t, response.Data[1].UserDisplayName)
+		assert.NotContains(t, rec.Body.String(), "Jamie")
+	})
+
+	t.Run("Author", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		mockDB.On("CourseExists", uint(4)).Return(true, nil)
+		mockDB.On("GetCourseReviews", uint(4), mock.Anything, "date", "desc", 1, 20).Return(reviews(user.ID), 3, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/4/reviews", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response struct {
+			Data []ReviewResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		require.Len(t, response.Data, 3)
+		assert.Equal(t, "Jamie Smith", response.Data[1].UserName)
+	})
+}
+
+func TestAPI_CreateReview_InvalidVisibility(t *testing.T) {
+	e, mockDB, _, token := setupAPITest(t)
+
+	rec := serveJSON(e, http.MethodPost, "/api/v1/reviews", token, map[string]interface{}{
+		"course_id":      4,
+		"overall_rating": 8,
+		"visibility":     "friends",
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "visibility")
+	mockDB.AssertNotCalled(t, "CreateReview", mock.Anything, mock.Anything)
+}
@@ -8,12 +8,25 @@ import (
 
 // APIRouter handles API route registration and configuration
 type APIRouter struct {
-	jwtService    *JWTService
-	authHandler   *AuthHandler
-	userHandler   *UserHandler
-	courseHandler *CourseHandler
-	reviewHandler *ReviewHandler
-	mapHandler    *MapHandler
+	jwtService          *JWTService
+	authHandler         *AuthHandler
+	userHandler         *UserHandler
+	courseHandler       *CourseHandler
+	reviewHandler       *ReviewHandler
+	mapHandler          *MapHandler
+	commentHandler      *CommentHandler
+	conditionsHandler   *ConditionsHandler
+	followHandler       *FollowHandler
+	notificationHandler *NotificationHandler
+	profileHandler      *ProfileHandler
+	listHandler         *ListHandler
+	outingHandler       *OutingHandler
+	searchHandler       *SearchHandler
+	geoHandler          *GeoHandler
+	itineraryHandler    *ItineraryHandler
+	holeGeometryHandler *HoleGeometryHandler
+	yardageHandler      *YardageHandler
+	courseEditHandler   *CourseEditHandler
 }
 
 // NewAPIRouter creates a new API router with all handlers
@@ -24,14 +37,40 @@ func NewAPIRouter(
 	courseHandler *CourseHandler,
 	reviewHandler *ReviewHandler,
 	mapHandler *MapHandler,
+	commentHandler *CommentHandler,
+	conditionsHandler *ConditionsHandler,
+	followHandler *FollowHandler,
+	notificationHandler *NotificationHandler,
+	profileHandler *ProfileHandler,
+	listHandler *ListHandler,
+	outingHandler *OutingHandler,
+	searchHandler *SearchHandler,
+	geoHandler *GeoHandler,
+	itineraryHandler *ItineraryHandler,
+	holeGeometryHandler *HoleGeometryHandler,
+	yardageHandler *YardageHandler,
+	courseEditHandler *CourseEditHandler,
 ) *APIRouter {
 	return &APIRouter{
-		jwtService:    jwtService,
-		authHandler:   authHandler,
-		userHandler:   userHandler,
-		courseHandler: courseHandler,
-		reviewHandler: reviewHandler,
-		mapHandler:    mapHandler,
+		jwtService:          jwtService,
+		authHandler:         authHandler,
+		userHandler:         userHandler,
+		courseHandler:       courseHandler,
+		reviewHandler:       reviewHandler,
+		mapHandler:          mapHandler,
+		commentHandler:      commentHandler,
+		conditionsHandler:   conditionsHandler,
+		followHandler:       followHandler,
+		notificationHandler: notificationHandler,
+		profileHandler:      profileHandler,
+		listHandler:         listHandler,
+		outingHandler:       outingHandler,
+		searchHandler:       searchHandler,
+		geoHandler:          geoHandler,
+		itineraryHandler:    itineraryHandler,
+		holeGeometryHandler: holeGeometryHandler,
+		yardageHandler:      yardageHandler,
+		courseEditHandler:   courseEditHandler,
 	}
 }
 
@@ -52,6 +91,19 @@ func (r *APIRouter) SetupRoutes(e *echo.Echo, apiConfig *APIConfig) {
 	r.courseHandler.RegisterRoutes(apiGroup, r.jwtService)
 	r.reviewHandler.RegisterRoutes(apiGroup, r.jwtService)
 	r.mapHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.commentHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.conditionsHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.followHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.notificationHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.profileHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.listHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.outingHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.searchHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.geoHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.itineraryHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.holeGeometryHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.yardageHandler.RegisterRoutes(apiGroup, r.jwtService)
+	r.courseEditHandler.RegisterRoutes(apiGroup, r.jwtService)
 
 	// Register additional API routes
 	r.registerStatisticsRoutes(apiGroup)
@@ -94,9 +146,6 @@ func (r *APIRouter) registerUtilityRoutes(g *echo.Group) {
 	utilGroup.POST("/validate/course", r.validateCourseData)
 	utilGroup.POST("/validate/review", r.validateReviewData)
 	
-	// Search suggestions
-	utilGroup.GET("/search/suggestions", r.getSearchSuggestions)
-	
 	// Export utilities (protected)
 	utilGroup.GET("/export/user-data", r.exportUserData, JWTMiddleware(r.jwtService))
 }
@@ -193,22 +242,6 @@ func (r *APIRouter) validateReviewData(c echo.Context) error {
 	return SuccessResponse(c, validation)
 }
 
-func (r *APIRouter) getSearchSuggestions(c echo.Context) error {
-	query := c.QueryParam("q")
-	if query == "" {
-		return BadRequestError(c, "Search query is required")
-	}
-
-	// TODO: Implement search suggestions
-	suggestions := map[string]interface{}{
-		"courses": []string{},
-		"locations": []string{},
-		"users": []string{},
-	}
-	
-	return SuccessResponse(c, suggestions)
-}
-
 func (r *APIRouter) exportUserData(c echo.Context) error {
 	userID, err := GetUserID(c)
 	if err != nil {
@@ -257,6 +290,19 @@ func (f *APIFactory) CreateAPIRouter() *APIRouter {
 	courseHandler := NewCourseHandler(f.dbService.(CoursesDatabaseServiceInterface))
 	reviewHandler := NewReviewHandler(f.dbService.(ReviewDatabaseServiceInterface))
 	mapHandler := NewMapHandler(f.dbService.(MapDatabaseServiceInterface))
+	commentHandler := NewCommentHandler(f.dbService.(CommentDatabaseServiceInterface))
+	conditionsHandler := NewConditionsHandler(f.dbService.(ConditionsDatabaseServiceInterface))
+	followHandler := NewFollowHandler(f.dbService.(FollowDatabaseServiceInterface))
+	notificationHandler := NewNotificationHandler(f.dbService.(NotificationDatabaseServiceInterface))
+	profileHandler := NewProfileHandler(f.dbService.(ProfileDatabaseServiceInterface))
+	listHandler := NewListHandler(f.dbService.(ListDatabaseServiceInterface))
+	outingHandler := NewOutingHandler(f.dbService.(OutingDatabaseServiceInterface))
+	searchHandler := NewSearchHandler(f.dbService.(SearchDatabaseServiceInterface))
+	geoHandler := NewGeoHandler(f.dbService.(GeoDatabaseServiceInterface))
+	itineraryHandler := NewItineraryHandler(f.dbService.(ItineraryDatabaseServiceInterface))
+	holeGeometryHandler := NewHoleGeometryHandler(f.dbService.(HoleGeometryDatabaseServiceInterface))
+	yardageHandler := NewYardageHandler(f.dbService.(YardageDatabaseServiceInterface))
+	courseEditHandler := NewCourseEditHandler(f.dbService.(CourseEditDatabaseServiceInterface))
 
 	return NewAPIRouter(
 		f.config.JWTService,
@@ -265,5 +311,18 @@ func (f *APIFactory) CreateAPIRouter() *APIRouter {
 		courseHandler,
 		reviewHandler,
 		mapHandler,
+		commentHandler,
+		conditionsHandler,
+		followHandler,
+		notificationHandler,
+		profileHandler,
+		listHandler,
+		outingHandler,
+		searchHandler,
+		geoHandler,
+		itineraryHandler,
+		holeGeometryHandler,
+		yardageHandler,
+		courseEditHandler,
 	)
 }
\ No newline at end of file
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxSearchQueryLength   = 200
+	defaultSuggestionLimit = 5
+	maxSuggestionLimit     = 10
+)
+
+// SearchHandler handles search typeahead endpoints. Full-text course search
+// itself is served by CourseHandler.SearchCourses.
+type SearchHandler struct {
+	dbService SearchDatabaseServiceInterface
+}
+
+// SearchSuggestionsResponse holds typeahead completions by kind
+type SearchSuggestionsResponse struct {
+	Courses   []*CourseSuggestionResponse `json:"courses"`
+	Locations []string                    `json:"locations"`
+	Users     []*UserSuggestionResponse   `json:"users"`
+}
+
+// CourseSuggestionResponse is a course whose name starts with the typed text
+type CourseSuggestionResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserSuggestionResponse is a user whose display name starts with the typed text
+type UserSuggestionResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(dbService SearchDatabaseServiceInterface) *SearchHandler {
+	return &SearchHandler{dbService: dbService}
+}
+
+// GetSearchSuggestions returns courses, cities and users starting with the
+// typed text, for search box typeahead
+func (h *SearchHandler) GetSearchSuggestions(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return BadRequestError(c, "Search query is required")
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return BadRequestError(c, "Search query must be at most 200 characters")
+	}
+
+	limit := defaultSuggestionLimit
+	if value := c.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSuggestionLimit {
+			return BadRequestError(c, "Limit must be between 1 and 10")
+		}
+		limit = parsed
+	}
+
+	suggestions, err := h.dbService.GetSearchSuggestions(query, limit)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve suggestions")
+	}
+
+	return SuccessResponse(c, suggestions)
+}
+
+// RegisterRoutes registers search routes
+func (h *SearchHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Public routes
+	g.GET("/utils/search/suggestions", h.GetSearchSuggestions)
+}
+
+// SearchDatabaseServiceInterface defines database operations for search typeahead
+type SearchDatabaseServiceInterface interface {
+	GetSearchSuggestions(query string, limit int) (*SearchSuggestionsResponse, error)
+}
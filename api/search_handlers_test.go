@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPI_SearchSuggestions(t *testing.T) {
+	t.Run("Returns courses, locations and users", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		mockDB.On("GetSearchSuggestions", "pine", 3).Return(&SearchSuggestionsResponse{
+			Courses:   []*CourseSuggestionResponse{{ID: 2, Name: "Pine Ridge"}},
+			Locations: []string{"Pinehurst"},
+			Users:     []*UserSuggestionResponse{},
+		}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/utils/search/suggestions?q=+pine+&limit=3", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"courses":[{"id":2,"name":"Pine Ridge"}]`)
+		assert.Contains(t, rec.Body.String(), `"locations":["Pinehurst"]`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects bad queries and limits", func(t *testing.T) {
+		for _, query := range []string{"", "q=+", "q=pine&limit=0", "q=pine&limit=50", "q=" + strings.Repeat("a", 201)} {
+			e, mockDB, _, _ := setupAPITest(t)
+
+			rec := serveJSON(e, http.MethodGet, "/api/v1/utils/search/suggestions?"+query, "", nil)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			mockDB.AssertNotCalled(t, "GetSearchSuggestions", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestAPI_SearchCourses_FullText(t *testing.T) {
+	t.Run("Returns ranked matches with snippets", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		rank := 0.8
+		courses := []*CourseResponse{{ID: 1, Name: "Sandy Dunes Links", SearchRank: &rank, Snippet: "deep <mark>pot</mark> bunkers"}}
+		mockDB.On("SearchCourses", mock.MatchedBy(func(search *CourseSearchRequest) bool {
+			return search.Query == "pot bunkers" && search.SortBy == "relevance"
+		}), (*uint)(nil), 1, 20).Return(courses, 1, nil)
+		mockDB.On("GetCourseFacetCounts", mock.Anything).Return(&CourseFacetCounts{}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/search?q=+pot+bunkers+&sort_by=relevance", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"search_rank":0.8`)
+		assert.Contains(t, rec.Body.String(), `"snippet":"deep \u003cmark\u003epot\u003c/mark\u003e bunkers"`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Relevance needs a query", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/search?sort_by=relevance", "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockDB.AssertNotCalled(t, "SearchCourses", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+// setupAPITest creates a fresh API per request; the API rate limiter only
+// allows a single burst request per test server.
+func setupAPITest(t *testing.T) (*echo.Echo, *MockDatabaseService, *UserResponse, string) {
+	e, mockDB, jwtService := setupTestAPI()
+	user := createTestUser()
+	tokens, err := generateTestTokens(jwtService, user)
+	require.NoError(t, err)
+	return e, mockDB, user, tokens.AccessToken
+}
+
+func serveJSON(e *echo.Echo, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		reqBody, _ := json.Marshal(body)
+		reader = bytes.NewReader(reqBody)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+const maxShotClubLength = 20
+
+var (
+	// ErrHoleNotDetected is returned when no mapped hole is near a position
+	// and the request didn't name one
+	ErrHoleNotDetected = errors.New("no mapped hole near this position")
+	// ErrHoleShotLimit is returned when a hole already has as many shots as
+	// a round can track
+	ErrHoleShotLimit = errors.New("hole has reached the shot limit")
+)
+
+// YardageHandler handles GPS yardages from a course's hole geometry and the
+// shots a player tracks during a round
+type YardageHandler struct {
+	dbService YardageDatabaseServiceInterface
+}
+
+// GreenYardageDetails is the distance in yards to each part of the green,
+// null when the hole doesn't map it
+type GreenYardageDetails struct {
+	Front  *int `json:"front"`
+	Center *int `json:"center"`
+	Back   *int `json:"back"`
+}
+
+// HazardYardageDetails is the distance in yards to reach and to carry a hazard
+type HazardYardageDetails struct {
+	Kind  string `json:"kind"`
+	Reach int    `json:"reach"`
+	Carry int    `json:"carry"`
+	Side  string `json:"side"` // "left", "right" or "center"
+}
+
+// LayupPointDetails is a spot on the line to the green short of the green centre
+type LayupPointDetails struct {
+	ToGreen  int       `json:"to_green"`
+	Distance int       `json:"distance"`
+	Point    HolePoint `json:"point"`
+	InHazard bool      `json:"in_hazard"`
+}
+
+// HoleYardageResponse is what a rangefinder shows from the player's position
+type HoleYardageResponse struct {
+	HoleNumber int                    `json:"hole_number"`
+	Detected   bool                   `json:"detected"` // True when the hole was found from the position
+	Par        int                    `json:"par,omitempty"`
+	Yardage    int                    `json:"yardage,omitempty"`
+	Green      GreenYardageDetails    `json:"green"`
+	Hazards    []HazardYardageDetails `json:"hazards"`
+	Layups     []LayupPointDetails    `json:"layups"`
+}
+
+// RoundShotRequest records a GPS position as the player's next shot
+type RoundShotRequest struct {
+	Latitude   *float64 `json:"latitude"`
+	Longitude  *float64 `json:"longitude"`
+	HoleNumber int      `json:"hole_number,omitempty"` // Found from the position when omitted
+	DatePlayed string   `json:"date_played,omitempty"` // YYYY-MM-DD, defaults to today
+	Club       string   `json:"club,omitempty"`
+}
+
+// RoundShotResponse is a tracked shot
+type RoundShotResponse struct {
+	ID         uint    `json:"id"`
+	DatePlayed string  `json:"date_played"`
+	HoleNumber int     `json:"hole_number"`
+	ShotNumber int     `json:"shot_number"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Club       *string `json:"club,omitempty"`
+	Distance   *int    `json:"distance,omitempty"` // Yards to the next shot on the hole
+	CreatedAt  int64   `json:"created_at"`
+}
+
+// ShotHoleResponse is one hole of a tracked round
+type ShotHoleResponse struct {
+	HoleNumber int                 `json:"hole_number"`
+	Par        int                 `json:"par,omitempty"`
+	Strokes    int                 `json:"strokes"`
+	Remaining  *int                `json:"remaining,omitempty"` // Yards from the last shot to the green centre
+	Shots      []RoundShotResponse `json:"shots"`
+}
+
+// ShotScorecardResponse is a player's tracked round on one day
+type ShotScorecardResponse struct {
+	CourseID   uint               `json:"course_id"`
+	DatePlayed string             `json:"date_played"`
+	ScoreID    *uint              `json:"score_id"` // The score posted for the same day, if any
+	Strokes    int                `json:"strokes"`
+	Holes      []ShotHoleResponse `json:"holes"`
+}
+
+// NewYardageHandler creates a new yardage handler
+func NewYardageHandler(dbService YardageDatabaseServiceInterface) *YardageHandler {
+	return &YardageHandler{dbService: dbService}
+}
+
+// GetHoleYardage returns the distances from a position to the green, hazards
+// and layup points of a hole. The hole is found from the position unless the
+// hole query parameter names it.
+func (h *YardageHandler) GetHoleYardage(c echo.Context) error {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+	latitude, longitude, message := parsePoint(c)
+	if message != "" {
+		return BadRequestError(c, message)
+	}
+	holeNumber := 0
+	if param := c.QueryParam("hole"); param != "" {
+		holeNumber, err = strconv.Atoi(param)
+		if err != nil || holeNumber < 1 || holeNumber > maxHoleNumber {
+			return BadRequestError(c, "Hole must be between 1 and 18")
+		}
+	}
+
+	yardage, err := h.dbService.GetHoleYardage(uint(courseID), holeNumber, latitude, longitude)
+	if err != nil {
+		if errors.Is(err, ErrHoleNotDetected) {
+			return ValidationError(c, map[string]string{"hole": "No mapped hole is near this position, so choose the hole"})
+		}
+		return InternalServerError(c, "Failed to measure yardage")
+	}
+	if yardage == nil {
+		return NotFoundError(c, "Hole geometry")
+	}
+
+	return SuccessResponse(c, yardage)
+}
+
+// RecordShot records the authenticated user's position as their next shot
+func (h *YardageHandler) RecordShot(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+
+	var req RoundShotRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError(c, "Invalid request format")
+	}
+	if errs := validateRoundShotRequest(&req); len(errs) > 0 {
+		return ValidationError(c, errs)
+	}
+
+	shot, err := h.dbService.RecordShot(userID, uint(courseID), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrHoleNotDetected):
+			return ValidationError(c, map[string]string{"hole_number": "No mapped hole is near this position, so choose the hole"})
+		case errors.Is(err, ErrHoleShotLimit):
+			return ConflictError(c, "This hole already has as many shots as a round can track")
+		}
+		return InternalServerError(c, "Failed to record shot")
+	}
+	if shot == nil {
+		return NotFoundError(c, "Course")
+	}
+
+	return CreatedResponse(c, shot)
+}
+
+// GetShotScorecard returns the authenticated user's tracked shots for a round,
+// today's unless the date query parameter names the day
+func (h *YardageHandler) GetShotScorecard(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+	date := c.QueryParam("date")
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return BadRequestError(c, "Date must be YYYY-MM-DD")
+		}
+	}
+
+	scorecard, err := h.dbService.GetShotScorecard(userID, uint(courseID), date)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve shots")
+	}
+	if scorecard == nil {
+		return NotFoundError(c, "Course")
+	}
+
+	return SuccessResponse(c, scorecard)
+}
+
+// DeleteShot removes one of the authenticated user's shots
+func (h *YardageHandler) DeleteShot(c echo.Context) error {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return UnauthorizedError(c, "Authentication required")
+	}
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid course ID")
+	}
+	shotID, err := strconv.ParseUint(c.Param("shotId"), 10, 32)
+	if err != nil {
+		return BadRequestError(c, "Invalid shot ID")
+	}
+
+	deleted, err := h.dbService.DeleteShot(userID, uint(courseID), uint(shotID))
+	if err != nil {
+		return InternalServerError(c, "Failed to delete shot")
+	}
+	if !deleted {
+		return NotFoundError(c, "Shot")
+	}
+
+	return NoContentResponse(c)
+}
+
+func validateRoundShotRequest(req *RoundShotRequest) map[string]string {
+	errors := make(map[string]string)
+	if req.Latitude == nil || *req.Latitude < -90 || *req.Latitude > 90 {
+		errors["latitude"] = "Latitude must be between -90 and 90"
+	}
+	if req.Longitude == nil || *req.Longitude < -180 || *req.Longitude > 180 {
+		errors["longitude"] = "Longitude must be between -180 and 180"
+	}
+	if req.HoleNumber < 0 || req.HoleNumber > maxHoleNumber {
+		errors["hole_number"] = "Hole must be between 1 and 18"
+	}
+
+	req.DatePlayed = strings.TrimSpace(req.DatePlayed)
+	if req.DatePlayed != "" {
+		played, err := time.Parse("2006-01-02", req.DatePlayed)
+		switch {
+		case err != nil:
+			errors["date_played"] = "Date must be YYYY-MM-DD"
+		case played.After(time.Now().AddDate(0, 0, 1)):
+			errors["date_played"] = "Rounds can't be in the future"
+		}
+	}
+
+	req.Club = strings.TrimSpace(req.Club)
+	if utf8.RuneCountInString(req.Club) > maxShotClubLength {
+		errors["club"] = "Club must be 20 characters or fewer"
+	}
+	return errors
+}
+
+// RegisterRoutes registers yardage and shot tracking routes
+func (h *YardageHandler) RegisterRoutes(g *echo.Group, jwtService *JWTService) {
+	// Public routes
+	g.GET("/courses/:id/yardage", h.GetHoleYardage)
+
+	// Protected routes
+	g.GET("/courses/:id/shots", h.GetShotScorecard, JWTMiddleware(jwtService))
+	g.POST("/courses/:id/shots", h.RecordShot, JWTMiddleware(jwtService))
+	g.DELETE("/courses/:id/shots/:shotId", h.DeleteShot, JWTMiddleware(jwtService))
+}
+
+// Database interface for yardage and shot tracking operations
+type YardageDatabaseServiceInterface interface {
+	// GetHoleYardage finds the hole from the position when holeNumber is
+	// zero, returning ErrHoleNotDetected when none is near. It returns nil
+	// when the course or hole isn't mapped.
+	GetHoleYardage(courseID uint, holeNumber int, latitude, longitude float64) (*HoleYardageResponse, error)
+	// RecordShot returns ErrHoleNotDetected like GetHoleYardage, and
+	// ErrHoleShotLimit when the hole is full. It returns nil when the course
+	// doesn't exist.
+	RecordShot(userID, courseID uint, req RoundShotRequest) (*RoundShotResponse, error)
+	GetShotScorecard(userID, courseID uint, date string) (*ShotScorecardResponse, error) // Nil when the course doesn't exist
+	DeleteShot(userID, courseID, shotID uint) (bool, error)                              // False when the shot isn't the user's
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_Yardage(t *testing.T) {
+	t.Run("Measures a hole from the player's position without signing in", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+
+		center := 412
+		mockDB.On("GetHoleYardage", uint(4), 0, 35.19, -79.472).Return(&HoleYardageResponse{
+			HoleNumber: 1, Detected: true, Par: 4,
+			Green:   GreenYardageDetails{Center: &center},
+			Hazards: []HazardYardageDetails{{Kind: "bunker", Reach: 352, Carry: 364, Side: "right"}},
+			Layups:  []LayupPointDetails{},
+		}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/4/yardage?lat=35.19&lng=-79.472", "", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"green":{"front":null,"center":412,"back":null}`)
+		assert.Contains(t, rec.Body.String(), `"detected":true`)
+	})
+
+	t.Run("Validates the position and hole", func(t *testing.T) {
+		for _, query := range []string{"lat=35.19", "lat=95&lng=-79.47", "lat=35.19&lng=-79.47&hole=19"} {
+			e, mockDB, _, _ := setupAPITest(t)
+			rec := serveJSON(e, http.MethodGet, "/api/v1/courses/4/yardage?"+query, "", nil)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			mockDB.AssertNotCalled(t, "GetHoleYardage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Asks for the hole when none is near", func(t *testing.T) {
+		e, mockDB, _, _ := setupAPITest(t)
+		mockDB.On("GetHoleYardage", uint(4), 0, 35.3, -79.4).Return(nil, ErrHoleNotDetected)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/4/yardage?lat=35.3&lng=-79.4", "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "choose the hole")
+
+		e, mockDB, _, _ = setupAPITest(t)
+		mockDB.On("GetHoleYardage", uint(4), 7, 35.19, -79.472).Return(nil, nil)
+		rec = serveJSON(e, http.MethodGet, "/api/v1/courses/4/yardage?lat=35.19&lng=-79.472&hole=7", "", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Records a shot", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+
+		latitude, longitude := 35.19, -79.472
+		req := RoundShotRequest{Latitude: &latitude, Longitude: &longitude, Club: "Driver"}
+		mockDB.On("RecordShot", user.ID, uint(4), req).Return(&RoundShotResponse{
+			ID: 8, DatePlayed: "2026-04-10", HoleNumber: 1, ShotNumber: 1, Latitude: latitude, Longitude: longitude,
+		}, nil)
+
+		rec := serveJSON(e, http.MethodPost, "/api/v1/courses/4/shots", token, map[string]interface{}{
+			"latitude": latitude, "longitude": longitude, "club": " Driver ",
+		})
+		require.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"shot_number":1`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Rejects invalid shots", func(t *testing.T) {
+		bodies := []map[string]interface{}{
+			{"longitude": -79.472},
+			{"latitude": 35.19, "longitude": -79.472, "hole_number": 19},
+			{"latitude": 35.19, "longitude": -79.472, "date_played": "April 10"},
+			{"latitude": 35.19, "longitude": -79.472, "date_played": "2999-01-01"},
+		}
+		for _, body := range bodies {
+			e, mockDB, _, token := setupAPITest(t)
+			rec := serveJSON(e, http.MethodPost, "/api/v1/courses/4/shots", token, body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, "%v", body)
+			mockDB.AssertNotCalled(t, "RecordShot", mock.Anything, mock.Anything, mock.Anything)
+		}
+
+		e, mockDB, user, token := setupAPITest(t)
+		mockDB.On("RecordShot", user.ID, uint(4), mock.Anything).Return(nil, ErrHoleShotLimit)
+		rec := serveJSON(e, http.MethodPost, "/api/v1/courses/4/shots", token, map[string]interface{}{
+			"latitude": 35.19, "longitude": -79.472, "hole_number": 1,
+		})
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		e, _, _, _ = setupAPITest(t)
+		rec = serveJSON(e, http.MethodPost, "/api/v1/courses/4/shots", "", map[string]interface{}{"latitude": 35.19, "longitude": -79.472})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Returns the round's scorecard", func(t *testing.T) {
+		e, mockDB, user, token := setupAPITest(t)
+		mockDB.On("GetShotScorecard", user.ID, uint(4), "2026-04-10").Return(&ShotScorecardResponse{
+			CourseID: 4, DatePlayed: "2026-04-10", Strokes: 1,
+			Holes: []ShotHoleResponse{{HoleNumber: 1, Strokes: 1, Shots: []RoundShotResponse{{ID: 8, HoleNumber: 1, ShotNumber: 1}}}},
+		}, nil)
+
+		rec := serveJSON(e, http.MethodGet, "/api/v1/courses/4/shots?date=2026-04-10", token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"score_id":null`)
+
+		e, _, _, token = setupAPITest(t)
+		rec = serveJSON(e, http.MethodGet, "/api/v1/courses/4/shots?date=yesterday", token, nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Deletes a shot", func(t *testing.T) {
+		for shotID, status := range map[uint]int{8: http.StatusNoContent, 9: http.StatusNotFound} {
+			e, mockDB, user, token := setupAPITest(t)
+			mockDB.On("DeleteShot", user.ID, uint(4), shotID).Return(shotID == 8, nil)
+
+			rec := serveJSON(e, http.MethodDelete, fmt.Sprintf("/api/v1/courses/4/shots/%d", shotID), token, nil)
+			assert.Equal(t, status, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+
+	"course_management/api"
+)
+
+// Review comment methods for APIDBServiceAdapter (implements api.CommentDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) CanViewReview(reviewID uint, userID *uint) (bool, error) {
+	return NewReviewCommentService().CanViewReview(reviewID, userID)
+}
+
+func (a *APIDBServiceAdapter) GetReviewComment(commentID uint) (*api.CommentResponse, error) {
+	commentService := NewReviewCommentService()
+	comment, err := commentService.GetComment(commentID)
+	if err != nil {
+		if errors.Is(err, ErrCommentNotFound) {
+			return nil, nil
+		}
+		return nil, commentError(err)
+	}
+
+	return toAPICommentResponse(ReviewCommentThread{
+		ReviewComment: *comment,
+		AuthorName:    commentService.AuthorName(comment.UserID),
+	}), nil
+}
+
+func (a *APIDBServiceAdapter) GetReviewComments(reviewID uint, userID *uint, page, perPage int) ([]*api.CommentResponse, int, error) {
+	threads, total, err := NewReviewCommentService().GetReviewComments(reviewID, userID, page, perPage)
+	if err != nil {
+		return nil, 0, commentError(err)
+	}
+
+	comments := make([]*api.CommentResponse, 0, len(threads))
+	for _, thread := range threads {
+		comments = append(comments, toAPICommentResponse(thread))
+	}
+	return comments, int(total), nil
+}
+
+func (a *APIDBServiceAdapter) CreateReviewComment(userID, reviewID uint, req *api.CommentCreateRequest) (*api.CommentResponse, error) {
+	commentService := NewReviewCommentService()
+	comment, err := commentService.CreateComment(userID, reviewID, req.ParentID, req.Body)
+	if err != nil {
+		return nil, commentError(err)
+	}
+	return commentResponse(commentService, comment, userID, false)
+}
+
+func (a *APIDBServiceAdapter) UpdateReviewComment(userID, commentID uint, req *api.CommentUpdateRequest) (*api.CommentResponse, error) {
+	commentService := NewReviewCommentService()
+	comment, err := commentService.UpdateComment(userID, commentID, req.Body)
+	if err != nil {
+		return nil, commentError(err)
+	}
+	return commentResponse(commentService, comment, userID, false)
+}
+
+func (a *APIDBServiceAdapter) DeleteReviewComment(userID, commentID uint) error {
+	return commentError(NewReviewCommentService().DeleteComment(userID, commentID))
+}
+
+func (a *APIDBServiceAdapter) CanModerateReviewComments(userID, reviewID uint) (bool, error) {
+	canModerate, err := NewReviewCommentService().CanModerate(userID, reviewID)
+	return canModerate, commentError(err)
+}
+
+func (a *APIDBServiceAdapter) SetReviewCommentStatus(userID, commentID uint, status string) (*api.CommentResponse, error) {
+	commentService := NewReviewCommentService()
+	comment, err := commentService.SetCommentStatus(userID, commentID, status)
+	if err != nil {
+		return nil, commentError(err)
+	}
+	return commentResponse(commentService, comment, userID, true)
+}
+
+// commentResponse returns a comment as viewerID sees it
+func commentResponse(commentService *ReviewCommentService, comment *ReviewComment, viewerID uint, canModerate bool) (*api.CommentResponse, error) {
+	thread, err := commentService.CommentThread(comment, &viewerID, canModerate)
+	if err != nil {
+		return nil, commentError(err)
+	}
+	return toAPICommentResponse(thread), nil
+}
+
+// commentError maps comment service errors to the api package's, so the
+// handlers can answer 404 or 403 instead of 500
+func commentError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrCommentNotFound):
+		return api.ErrCommentNotFound
+	case errors.Is(err, ErrReviewNotFound):
+		return api.ErrCommentReviewNotFound
+	case errors.Is(err, ErrCommentForbidden):
+		return api.ErrCommentForbidden
+	}
+	return err
+}
+
+func toAPICommentResponse(thread ReviewCommentThread) *api.CommentResponse {
+	response := &api.CommentResponse{
+		ID:              thread.ID,
+		ReviewID:        thread.ReviewID,
+		ParentID:        thread.ParentID,
+		UserID:          thread.UserID,
+		AuthorName:      thread.AuthorName,
+		Body:            thread.Body,
+		IsOwnerResponse: thread.IsOwnerResponse,
+		Status:          thread.Status,
+		ModerationNote:  thread.ModerationNote,
+		EditedAt:        thread.EditedAt,
+		CreatedAt:       thread.CreatedAt,
+		UpdatedAt:       thread.UpdatedAt,
+		CanEdit:         thread.CanEdit,
+		CanModerate:     thread.CanModerate,
+	}
+
+	for _, reply := range thread.Replies {
+		response.Replies = append(response.Replies, toAPICommentResponse(reply))
+	}
+	return response
+}
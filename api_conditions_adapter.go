@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+
+	"course_management/api"
+)
+
+// Conditions report methods for APIDBServiceAdapter (implements api.ConditionsDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) CourseExists(courseID uint) (bool, error) {
+	course, err := a.dbService.GetCourseByID(courseID)
+	if err != nil {
+		return false, err
+	}
+	return course != nil, nil
+}
+
+func (a *APIDBServiceAdapter) GetCurrentConditions(courseID uint, userID *uint) (*api.CurrentConditionsResponse, error) {
+	conditions, err := NewConditionsReportService().GetCurrentConditions(courseID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &api.CurrentConditionsResponse{
+		CourseID:      conditions.CourseID,
+		ReportCount:   conditions.ReportCount,
+		LatestReport:  conditions.LatestReport,
+		Greens:        conditions.Greens,
+		Fairways:      conditions.Fairways,
+		Bunkers:       conditions.Bunkers,
+		PaceOfPlay:    conditions.PaceOfPlay,
+		CartPathOnly:  conditions.CartPathOnly,
+		GreensAerated: conditions.GreensAerated,
+		FrostDelay:    conditions.FrostDelay,
+		Reports:       make([]*api.ConditionsReportResponse, 0, len(conditions.Reports)),
+	}
+	for _, report := range conditions.Reports {
+		response.Reports = append(response.Reports, toAPIConditionsReportResponse(report))
+	}
+	return response, nil
+}
+
+func (a *APIDBServiceAdapter) GetConditionsReport(reportID uint) (*api.ConditionsReportResponse, error) {
+	report, err := NewConditionsReportService().GetReport(reportID)
+	if err != nil {
+		if errors.Is(err, ErrConditionsReportNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toAPIConditionsReportResponse(ConditionsReportView{
+		ConditionsReport: *report,
+		AuthorName:       NewReviewCommentService().AuthorName(report.UserID),
+	}), nil
+}
+
+func (a *APIDBServiceAdapter) CreateConditionsReport(userID, courseID uint, req *api.ConditionsReportRequest) (*api.ConditionsReportResponse, error) {
+	report, err := NewConditionsReportService().CreateReport(userID, courseID, ConditionsReportFormData{
+		Greens:        req.Greens,
+		Fairways:      req.Fairways,
+		Bunkers:       req.Bunkers,
+		PaceOfPlay:    req.PaceOfPlay,
+		CartPathOnly:  req.CartPathOnly,
+		GreensAerated: req.GreensAerated,
+		FrostDelay:    req.FrostDelay,
+		Note:          req.Note,
+		ValidHours:    req.ValidHours,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toAPIConditionsReportResponse(ConditionsReportView{
+		ConditionsReport: *report,
+		AuthorName:       NewReviewCommentService().AuthorName(userID),
+		CanDelete:        true,
+	}), nil
+}
+
+func (a *APIDBServiceAdapter) DeleteConditionsReport(userID, reportID uint) error {
+	return NewConditionsReportService().DeleteReport(userID, reportID)
+}
+
+func toAPIConditionsReportResponse(report ConditionsReportView) *api.ConditionsReportResponse {
+	return &api.ConditionsReportResponse{
+		ID:            report.ID,
+		CourseID:      report.CourseID,
+		UserID:        report.UserID,
+		AuthorName:    report.AuthorName,
+		Greens:        report.Greens,
+		Fairways:      report.Fairways,
+		Bunkers:       report.Bunkers,
+		PaceOfPlay:    report.PaceOfPlay,
+		CartPathOnly:  report.CartPathOnly,
+		GreensAerated: report.GreensAerated,
+		FrostDelay:    report.FrostDelay,
+		Note:          report.Note,
+		ExpiresAt:     report.ExpiresAt,
+		CreatedAt:     report.CreatedAt,
+		CanDelete:     report.CanDelete,
+	}
+}
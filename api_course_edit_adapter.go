@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"course_management/api"
+)
+
+// Suggested course edit methods for APIDBServiceAdapter (implements api.CourseEditDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) ProposeCourseEdit(userID, courseID uint, changes []api.CourseFieldChangeRequest, note string) (*api.CourseEditResponse, error) {
+	proposed := make([]CourseFieldChange, 0, len(changes))
+	for _, change := range changes {
+		proposed = append(proposed, CourseFieldChange{Field: change.Field, To: change.To})
+	}
+
+	service := NewCourseEditService()
+	proposal, err := service.Propose(userID, courseID, proposed, note)
+	if err != nil {
+		if errors.Is(err, ErrCourseNotFound) {
+			return nil, nil
+		}
+		return nil, courseEditError(err)
+	}
+	return a.GetCourseEdit(userID, proposal.ID)
+}
+
+func (a *APIDBServiceAdapter) GetCourseEditsForCourse(viewerID, courseID uint) ([]api.CourseEditResponse, error) {
+	views, err := NewCourseEditService().ListForCourse(viewerID, courseID)
+	if err != nil {
+		if errors.Is(err, ErrCourseNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return toAPICourseEdits(views), nil
+}
+
+func (a *APIDBServiceAdapter) GetCourseEditQueue(userID uint) ([]api.CourseEditResponse, error) {
+	views, err := NewCourseEditService().Queue(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toAPICourseEdits(views), nil
+}
+
+func (a *APIDBServiceAdapter) GetUserCourseEdits(userID uint) ([]api.CourseEditResponse, error) {
+	views, err := NewCourseEditService().ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toAPICourseEdits(views), nil
+}
+
+func (a *APIDBServiceAdapter) GetCourseEdit(viewerID, editID uint) (*api.CourseEditResponse, error) {
+	view, err := NewCourseEditService().Get(viewerID, editID)
+	if err != nil {
+		if errors.Is(err, ErrCourseEditNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	edit := toAPICourseEdit(*view)
+	return &edit, nil
+}
+
+func (a *APIDBServiceAdapter) AcceptCourseEdit(userID, editID uint, note string) (*api.CourseEditResponse, error) {
+	if _, err := NewCourseEditService().Accept(userID, editID, note); err != nil {
+		return nil, courseEditError(err)
+	}
+	return a.GetCourseEdit(userID, editID)
+}
+
+func (a *APIDBServiceAdapter) RejectCourseEdit(userID, editID uint, note string) (*api.CourseEditResponse, error) {
+	if _, err := NewCourseEditService().Reject(userID, editID, note); err != nil {
+		return nil, courseEditError(err)
+	}
+	return a.GetCourseEdit(userID, editID)
+}
+
+func (a *APIDBServiceAdapter) WithdrawCourseEdit(userID, editID uint) error {
+	return courseEditError(NewCourseEditService().Withdraw(userID, editID))
+}
+
+// courseEditError converts course edit service errors into the API's
+func courseEditError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrInvalidCourseEdit):
+		return &api.CourseEditError{Message: courseEditSentence(err)}
+	case errors.Is(err, ErrCourseEditConflict):
+		return &api.CourseEditError{Message: courseEditSentence(err), Conflict: true}
+	case errors.Is(err, ErrCourseEditNotFound):
+		return api.ErrCourseEditNotFound
+	case errors.Is(err, ErrCourseEditForbidden):
+		return api.ErrCourseEditForbidden
+	case errors.Is(err, ErrCourseEditAnswered):
+		return api.ErrCourseEditAnswered
+	case errors.Is(err, ErrCourseEditOwnCourse):
+		return api.ErrCourseEditOwnCourse
+	}
+	return err
+}
+
+// courseEditSentence writes an error's message as a sentence
+func courseEditSentence(err error) string {
+	message := []rune(strings.TrimSpace(err.Error()))
+	message[0] = unicode.ToUpper(message[0])
+	return string(message)
+}
+
+func toAPICourseEdits(views []CourseEditProposalView) []api.CourseEditResponse {
+	edits := make([]api.CourseEditResponse, 0, len(views))
+	for _, view := range views {
+		edits = append(edits, toAPICourseEdit(view))
+	}
+	return edits
+}
+
+func toAPICourseEdit(view CourseEditProposalView) api.CourseEditResponse {
+	changes := make([]api.CourseFieldChangeResponse, 0, len(view.Changes))
+	for _, change := range view.Changes {
+		changes = append(changes, api.CourseFieldChangeResponse{
+			Field: change.Field,
+			Label: change.Label(),
+			From:  change.From,
+			To:    change.To,
+		})
+	}
+
+	return api.CourseEditResponse{
+		ID:           view.ID,
+		CourseID:     view.CourseID,
+		CourseName:   view.CourseName,
+		UserID:       view.UserID,
+		ProposerName: view.ProposerName,
+		Changes:      changes,
+		Note:         view.Note,
+		Status:       view.Status,
+		EscalatedAt:  view.EscalatedAt,
+		ReviewedBy:   view.ReviewedBy,
+		ReviewerName: view.ReviewerName,
+		ReviewNote:   view.ReviewNote,
+		ReviewedAt:   view.ReviewedAt,
+		CreatedAt:    view.CreatedAt,
+		CanReview:    view.CanReview,
+		CanWithdraw:  view.CanWithdraw,
+	}
+}
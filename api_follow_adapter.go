@@ -0,0 +1,66 @@
+package main
+
+import (
+	"course_management/api"
+)
+
+// Follow and feed methods for APIDBServiceAdapter (implements api.FollowDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) UserExists(userID uint) (bool, error) {
+	user, err := a.dbService.GetUserByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return user != nil, nil
+}
+
+func (a *APIDBServiceAdapter) FollowUser(followerID, followeeID uint) (*api.FollowResponse, error) {
+	followService := NewFollowService()
+	if err := followService.Follow(followerID, followeeID); err != nil {
+		return nil, err
+	}
+
+	followers, err := followService.CountFollowers(followeeID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.FollowResponse{UserID: followeeID, Following: true, Followers: followers}, nil
+}
+
+func (a *APIDBServiceAdapter) UnfollowUser(followerID, followeeID uint) error {
+	return NewFollowService().Unfollow(followerID, followeeID)
+}
+
+func (a *APIDBServiceAdapter) GetFeed(userID uint, query api.FeedQuery) ([]*api.FeedItemResponse, *api.FeedCursor, error) {
+	page, err := NewFollowService().GetFeed(userID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]*api.FeedItemResponse, 0, len(page.Items))
+	for _, item := range page.Items {
+		items = append(items, toAPIFeedItem(item))
+	}
+	return items, page.Next, nil
+}
+
+func toAPIFeedItem(item FeedItem) *api.FeedItemResponse {
+	response := &api.FeedItemResponse{
+		ID:           item.ID,
+		ActivityType: item.ActivityType,
+		UserID:       item.UserID,
+		UserName:     item.ActorName,
+		CourseID:     item.CourseID,
+		TargetUserID: item.TargetUserID,
+		Summary:      item.Summary(),
+		CreatedAt:    item.CreatedAt,
+	}
+	objectName := item.ObjectName
+	switch {
+	case item.TargetUserID != nil:
+		response.TargetUserName = &objectName
+	case item.CourseID != nil:
+		response.CourseName = &objectName
+	}
+	return response
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"course_management/api"
+	"course_management/services"
+)
+
+// geocodeRequestTimeout bounds a geocoding API request, including any wait
+// under the provider's rate limit
+const geocodeRequestTimeout = 15 * time.Second
+
+// Geo query methods for APIDBServiceAdapter (implements api.GeoDatabaseServiceInterface
+// and the nearby part of api.CoursesDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) GetNearbyMapCourses(req *api.NearbyRequest, userID *uint, page, perPage int) ([]*api.MapCourseResponse, int, error) {
+	courseIDs, err := NewCourseAttributeService().FindCourseIDsWithAttributes(req.Tags, req.Amenities)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	locations, total, err := NewCourseGeoService().Nearby(req.Latitude, req.Longitude, req.Radius, courseIDs, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses, err := mapCourseResponses(locations, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return responses, total, nil
+}
+
+func (a *APIDBServiceAdapter) GetNearestCourses(req *api.NearbyRequest, k int, userID *uint) ([]*api.MapCourseResponse, error) {
+	courseIDs, err := NewCourseAttributeService().FindCourseIDsWithAttributes(req.Tags, req.Amenities)
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := NewCourseGeoService().Nearest(req.Latitude, req.Longitude, k, courseIDs)
+	if err != nil {
+		return nil, err
+	}
+	return mapCourseResponses(locations, userID)
+}
+
+func (a *APIDBServiceAdapter) GetCoursesInBounds(bounds *api.BoundsRequest, userID *uint) ([]*api.MapCourseResponse, error) {
+	courseIDs, err := NewCourseAttributeService().FindCourseIDsWithAttributes(bounds.Tags, bounds.Amenities)
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := NewCourseGeoService().InBounds(GeoBounds{
+		North: bounds.NorthLat,
+		South: bounds.SouthLat,
+		East:  bounds.EastLng,
+		West:  bounds.WestLng,
+	}, courseIDs)
+	if err != nil {
+		return nil, err
+	}
+	courses, err := mapCourseResponses(locations, userID)
+	if err != nil || (bounds.MinRating == nil && bounds.MaxRating == nil) {
+		return courses, err
+	}
+
+	// Unrated courses are left out once a rating bound is set
+	rated := make([]*api.MapCourseResponse, 0, len(courses))
+	for _, course := range courses {
+		if course.OverallRating == nil ||
+			(bounds.MinRating != nil && *course.OverallRating < *bounds.MinRating) ||
+			(bounds.MaxRating != nil && *course.OverallRating > *bounds.MaxRating) {
+			continue
+		}
+		rated = append(rated, course)
+	}
+	return rated, nil
+}
+
+func (a *APIDBServiceAdapter) GetClusteredCourses(bounds *api.BoundsRequest, userID *uint, zoomLevel, maxClusterSize int) ([]*api.CourseClusterResponse, error) {
+	courseIDs, err := NewCourseAttributeService().FindCourseIDsWithAttributes(bounds.Tags, bounds.Amenities)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters, err := NewCourseClusterService().Clusters(GeoBounds{
+		North: bounds.NorthLat,
+		South: bounds.SouthLat,
+		East:  bounds.EastLng,
+		West:  bounds.WestLng,
+	}, zoomLevel, courseIDs)
+	if err != nil {
+		return nil, err
+	}
+	return clusterResponses(clusters, userID, maxClusterSize)
+}
+
+func (a *APIDBServiceAdapter) ExpandCourseCluster(clusterID string, tags, amenities []string, userID *uint, maxClusterSize int) (*api.ClusterExpansionResponse, error) {
+	courseIDs, err := NewCourseAttributeService().FindCourseIDsWithAttributes(tags, amenities)
+	if err != nil {
+		return nil, err
+	}
+
+	expansion, err := NewCourseClusterService().Expand(clusterID, courseIDs)
+	if err != nil {
+		if errors.Is(err, ErrCourseClusterNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	clusters, err := clusterResponses(expansion.Clusters, userID, maxClusterSize)
+	if err != nil {
+		return nil, err
+	}
+	return &api.ClusterExpansionResponse{
+		ClusterID:     expansion.ClusterID,
+		ExpansionZoom: expansion.ExpansionZoom,
+		Clusters:      clusters,
+	}, nil
+}
+
+func (a *APIDBServiceAdapter) GeocodeAddress(address string) (*api.GeocodeResponse, error) {
+	geocoder := GetGeocodingService()
+	if geocoder == nil {
+		return nil, api.ErrGeocodingUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), geocodeRequestTimeout)
+	defer cancel()
+	return geocodeResponse(geocoder.Geocode(ctx, address))
+}
+
+func (a *APIDBServiceAdapter) ReverseGeocode(lat, lng float64) (*api.GeocodeResponse, error) {
+	geocoder := GetGeocodingService()
+	if geocoder == nil {
+		return nil, api.ErrGeocodingUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), geocodeRequestTimeout)
+	defer cancel()
+	return geocodeResponse(geocoder.ReverseGeocode(ctx, lat, lng))
+}
+
+// geocodeResponse converts a geocoding result, reporting no match as nil
+func geocodeResponse(result *services.GeocodeResult, err error) (*api.GeocodeResponse, error) {
+	if err != nil {
+		if errors.Is(err, services.ErrGeocodeNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	components := result.Components
+	return &api.GeocodeResponse{
+		Address:          result.Address,
+		FormattedAddress: result.FormattedAddress,
+		Latitude:         result.Latitude,
+		Longitude:        result.Longitude,
+		Confidence:       result.Confidence,
+		Components: &api.AddressComponents{
+			StreetNumber: components.StreetNumber,
+			Route:        components.Route,
+			City:         components.City,
+			State:        components.State,
+			Country:      components.Country,
+			PostalCode:   components.PostalCode,
+		},
+	}, nil
+}
+
+// GetNearbyCoures returns a page of courses within radius kilometers, nearest first
+func (a *APIDBServiceAdapter) GetNearbyCoures(lat, lng, radius float64, userID *uint, page, perPage int) ([]*api.CourseResponse, int, error) {
+	locations, total, err := NewCourseGeoService().Nearby(lat, lng, radius, nil, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*api.CourseResponse, 0, len(locations))
+	for _, location := range locations {
+		latitude, longitude := location.Latitude, location.Longitude
+		responses = append(responses, &api.CourseResponse{
+			ID:        location.CourseID,
+			Name:      location.Name,
+			Address:   location.Address,
+			Latitude:  &latitude,
+			Longitude: &longitude,
+			CreatedBy: location.CreatedBy,
+			CanEdit:   userID != nil && location.CreatedBy != nil && *location.CreatedBy == *userID,
+			Holes:     []api.HoleData{},
+			Tags:      []string{},
+			Amenities: []string{},
+			Mentions:  []api.ReviewMentionResponse{},
+			Distance:  location.Distance,
+		})
+	}
+	return responses, total, nil
+}
+
+// mapCourseResponses converts geo query results to map markers, counting
+// each course's public and anonymous reviews and rating each course by its
+// overall grade
+func mapCourseResponses(locations []CourseLocation, userID *uint) ([]*api.MapCourseResponse, error) {
+	responses := make([]*api.MapCourseResponse, 0, len(locations))
+	if len(locations) == 0 {
+		return responses, nil
+	}
+
+	db := GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	courseIDs := make([]uint, 0, len(locations))
+	for _, location := range locations {
+		courseIDs = append(courseIDs, location.CourseID)
+	}
+	var reviewCounts []struct {
+		CourseID uint
+		Reviews  int
+	}
+	err := db.Model(&CourseReview{}).Scopes(aggregatedReviews).
+		Select("course_id, COUNT(*) AS reviews").
+		Where("course_id IN ?", courseIDs).
+		Group("course_id").
+		Scan(&reviewCounts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reviews: %v", err)
+	}
+	reviews := make(map[uint]int, len(reviewCounts))
+	for _, count := range reviewCounts {
+		reviews[count.CourseID] = count.Reviews
+	}
+
+	var courses []CourseDB
+	if err := db.Select("id", "course_data").Where("id IN ?", courseIDs).Find(&courses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get course ratings: %v", err)
+	}
+	ratings := make(map[uint]*float64, len(courses))
+	for _, course := range courses {
+		var data Course
+		if course.CourseData != "" && json.Unmarshal([]byte(course.CourseData), &data) == nil {
+			ratings[course.ID] = api.GradeRating(data.OverallRating)
+		}
+	}
+
+	for _, location := range locations {
+		latitude, longitude := location.Latitude, location.Longitude
+		responses = append(responses, &api.MapCourseResponse{
+			ID:            location.CourseID,
+			Name:          location.Name,
+			Address:       location.Address,
+			Latitude:      &latitude,
+			Longitude:     &longitude,
+			OverallRating: ratings[location.CourseID],
+			TotalReviews:  reviews[location.CourseID],
+			CanEdit:       userID != nil && location.CreatedBy != nil && *location.CreatedBy == *userID,
+			Distance:      location.Distance,
+		})
+	}
+	return responses, nil
+}
+
+// clusterResponses converts clusters to map markers, listing the courses of
+// clusters with at most maxClusterSize of them
+func clusterResponses(clusters []CourseCluster, userID *uint, maxClusterSize int) ([]*api.CourseClusterResponse, error) {
+	var listed []uint
+	for _, cluster := range clusters {
+		if len(cluster.CourseIDs) <= maxClusterSize {
+			listed = append(listed, cluster.CourseIDs...)
+		}
+	}
+
+	markers := make(map[uint]*api.MapCourseResponse, len(listed))
+	if len(listed) > 0 {
+		locations, err := NewCourseGeoService().Locations(listed)
+		if err != nil {
+			return nil, err
+		}
+		courses, err := mapCourseResponses(locations, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, course := range courses {
+			markers[course.ID] = course
+		}
+	}
+
+	responses := make([]*api.CourseClusterResponse, 0, len(clusters))
+	for _, cluster := range clusters {
+		response := &api.CourseClusterResponse{
+			ID:          cluster.ID,
+			Latitude:    cluster.Latitude,
+			Longitude:   cluster.Longitude,
+			CourseCount: len(cluster.CourseIDs),
+			ZoomLevel:   cluster.Zoom,
+		}
+		if len(cluster.CourseIDs) <= maxClusterSize {
+			response.Courses = make([]*api.MapCourseResponse, 0, len(cluster.CourseIDs))
+			for _, courseID := range cluster.CourseIDs {
+				if marker, ok := markers[courseID]; ok {
+					response.Courses = append(response.Courses, marker)
+				}
+			}
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
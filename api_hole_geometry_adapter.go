@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"course_management/api"
+)
+
+// Hole geometry methods for APIDBServiceAdapter (implements api.HoleGeometryDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) IsUserCourseOwner(userID, courseID uint) (bool, error) {
+	return a.dbService.CanEditCourse(courseID, userID)
+}
+
+func (a *APIDBServiceAdapter) GetCourseHoleLayout(courseID uint) (*api.GeoJSONShapeCollection, error) {
+	holes, err := NewCourseHoleGeometryService().GetLayout(courseID)
+	if err != nil {
+		if errors.Is(err, ErrCourseNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	layout := holeLayoutCollection(holes)
+	return &layout, nil
+}
+
+func (a *APIDBServiceAdapter) GetHoleGeometry(courseID uint, holeNumber int) (*api.HoleGeometryResponse, error) {
+	hole, err := NewCourseHoleGeometryService().GetHole(courseID, holeNumber)
+	if err != nil {
+		if errors.Is(err, ErrHoleGeometryNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return toAPIHoleGeometry(*hole), nil
+}
+
+func (a *APIDBServiceAdapter) SaveHoleGeometry(userID, courseID uint, holeNumber int, details api.HoleGeometryDetails) (*api.HoleGeometryResponse, error) {
+	hole, err := NewCourseHoleGeometryService().SaveHole(courseID, holeNumber, fromAPIHoleGeometry(details), userID)
+	if err != nil {
+		return nil, holeGeometryError(err)
+	}
+	return toAPIHoleGeometry(*hole), nil
+}
+
+func (a *APIDBServiceAdapter) DeleteHoleGeometry(courseID uint, holeNumber int) (bool, error) {
+	err := NewCourseHoleGeometryService().DeleteHole(courseID, holeNumber)
+	if errors.Is(err, ErrHoleGeometryNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (a *APIDBServiceAdapter) ImportHoleGeometry(userID, courseID uint, data []byte) (*api.HoleGeometryImportResponse, error) {
+	summary, err := NewCourseHoleGeometryService().Import(courseID, data, userID)
+	if err != nil {
+		return nil, holeGeometryError(err)
+	}
+
+	return &api.HoleGeometryImportResponse{
+		Format:   summary.Format,
+		Holes:    summary.Holes,
+		Features: summary.Features,
+		Skipped:  summary.Skipped,
+	}, nil
+}
+
+// holeGeometryError reports geometry that failed validation as the API's
+// error, with the problem as a sentence
+func holeGeometryError(err error) error {
+	if !errors.Is(err, ErrInvalidHoleGeometry) {
+		return err
+	}
+
+	message := []rune(err.Error())
+	message[0] = unicode.ToUpper(message[0])
+	return &api.HoleGeometryError{Message: strings.TrimSpace(string(message))}
+}
+
+func fromAPIHoleGeometry(details api.HoleGeometryDetails) HoleGeometry {
+	points := func(list []api.HolePoint) []GeoPoint {
+		if list == nil {
+			return nil
+		}
+		converted := make([]GeoPoint, len(list))
+		for i, point := range list {
+			converted[i] = GeoPoint{Latitude: point.Latitude, Longitude: point.Longitude}
+		}
+		return converted
+	}
+
+	geometry := HoleGeometry{
+		Green:   points(details.Green),
+		Fairway: points(details.Fairway),
+	}
+	for _, tee := range details.Tees {
+		geometry.Tees = append(geometry.Tees, HoleTee{
+			Name:  tee.Name,
+			Point: GeoPoint{Latitude: tee.Point.Latitude, Longitude: tee.Point.Longitude},
+		})
+	}
+	point := func(p *api.HolePoint) *GeoPoint {
+		if p == nil {
+			return nil
+		}
+		return &GeoPoint{Latitude: p.Latitude, Longitude: p.Longitude}
+	}
+	geometry.GreenCenter = point(details.GreenCenter)
+	geometry.GreenFront = point(details.GreenFront)
+	geometry.GreenBack = point(details.GreenBack)
+	for _, hazard := range details.Hazards {
+		geometry.Hazards = append(geometry.Hazards, HoleHazard{Kind: hazard.Kind, Polygon: points(hazard.Polygon)})
+	}
+	for _, hazard := range details.HazardPoints {
+		geometry.HazardPoints = append(geometry.HazardPoints, HoleHazardPoint{Kind: hazard.Kind, Point: *point(&hazard.Point)})
+	}
+	return geometry
+}
+
+func toAPIHoleGeometry(hole HoleGeometryView) *api.HoleGeometryResponse {
+	points := func(list []GeoPoint) []api.HolePoint {
+		if list == nil {
+			return nil
+		}
+		converted := make([]api.HolePoint, len(list))
+		for i, point := range list {
+			converted[i] = api.HolePoint{Latitude: point.Latitude, Longitude: point.Longitude}
+		}
+		return converted
+	}
+
+	geometry := api.HoleGeometryDetails{
+		Tees:    make([]api.HoleTeeDetails, 0, len(hole.Geometry.Tees)),
+		Green:   points(hole.Geometry.Green),
+		Fairway: points(hole.Geometry.Fairway),
+	}
+	for _, tee := range hole.Geometry.Tees {
+		geometry.Tees = append(geometry.Tees, api.HoleTeeDetails{
+			Name:  tee.Name,
+			Point: api.HolePoint{Latitude: tee.Point.Latitude, Longitude: tee.Point.Longitude},
+		})
+	}
+	point := func(p *GeoPoint) *api.HolePoint {
+		if p == nil {
+			return nil
+		}
+		return &api.HolePoint{Latitude: p.Latitude, Longitude: p.Longitude}
+	}
+	geometry.GreenCenter = point(hole.Geometry.GreenCenter)
+	geometry.GreenFront = point(hole.Geometry.GreenFront)
+	geometry.GreenBack = point(hole.Geometry.GreenBack)
+	for _, hazard := range hole.Geometry.Hazards {
+		geometry.Hazards = append(geometry.Hazards, api.HoleHazardDetails{Kind: hazard.Kind, Polygon: points(hazard.Polygon)})
+	}
+	for _, hazard := range hole.Geometry.HazardPoints {
+		geometry.HazardPoints = append(geometry.HazardPoints, api.HoleHazardPointDetails{Kind: hazard.Kind, Point: *point(&hazard.Point)})
+	}
+
+	return &api.HoleGeometryResponse{
+		CourseID:   hole.CourseID,
+		HoleNumber: hole.HoleNumber,
+		Par:        hole.Par,
+		Yardage:    hole.Yardage,
+		Source:     hole.Source,
+		Geometry:   geometry,
+		UpdatedAt:  hole.UpdatedAt,
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+
+	"course_management/api"
+)
+
+// Itinerary methods for APIDBServiceAdapter (implements api.ItineraryDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) PlanItinerary(details api.ItineraryDetails) (*api.ItineraryResponse, error) {
+	plan, err := NewItineraryService().Plan(toItineraryInput(details))
+	if err != nil {
+		return nil, itineraryPlanError(err)
+	}
+	return toAPITripPlan(*plan), nil
+}
+
+func (a *APIDBServiceAdapter) CreateItinerary(userID uint, details api.ItineraryDetails) (*api.ItineraryResponse, error) {
+	itinerary, err := NewItineraryService().CreateItinerary(userID, toItineraryInput(details))
+	if err != nil {
+		return nil, itineraryPlanError(err)
+	}
+	return toAPIItinerary(*itinerary), nil
+}
+
+func (a *APIDBServiceAdapter) GetItineraries(userID uint) ([]*api.ItineraryResponse, error) {
+	itineraries, err := NewItineraryService().GetItineraries(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*api.ItineraryResponse, 0, len(itineraries))
+	for _, itinerary := range itineraries {
+		responses = append(responses, toAPIItinerary(itinerary))
+	}
+	return responses, nil
+}
+
+func (a *APIDBServiceAdapter) GetItinerary(userID, itineraryID uint) (*api.ItineraryResponse, error) {
+	return itineraryResponse(NewItineraryService().GetItinerary(userID, itineraryID))
+}
+
+func (a *APIDBServiceAdapter) GetSharedItinerary(token string) (*api.ItineraryResponse, error) {
+	return itineraryResponse(NewItineraryService().GetSharedItinerary(token))
+}
+
+func (a *APIDBServiceAdapter) DeleteItinerary(userID, itineraryID uint) error {
+	return NewItineraryService().DeleteItinerary(userID, itineraryID)
+}
+
+func (a *APIDBServiceAdapter) SetItinerarySharing(userID, itineraryID uint, shared bool) (*api.ItineraryResponse, error) {
+	return itineraryResponse(NewItineraryService().SetSharing(userID, itineraryID, shared))
+}
+
+// itineraryResponse converts an itinerary service result, reporting
+// itineraries that don't exist or aren't the user's as nil
+func itineraryResponse(itinerary *ItineraryView, err error) (*api.ItineraryResponse, error) {
+	if err != nil {
+		if errors.Is(err, ErrItineraryNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return toAPIItinerary(*itinerary), nil
+}
+
+// itineraryPlanError reports courses that can't be routed to as the API's error
+func itineraryPlanError(err error) error {
+	if errors.Is(err, ErrItineraryCourseNotLocated) {
+		return api.ErrItineraryCourseUnavailable
+	}
+	return err
+}
+
+func toItineraryInput(details api.ItineraryDetails) ItineraryInput {
+	return ItineraryInput{
+		Name:          details.Name,
+		Start:         TripStop{Name: details.Start.Name, Latitude: details.Start.Latitude, Longitude: details.Start.Longitude},
+		CourseIDs:     details.CourseIDs,
+		Days:          details.Days,
+		ReturnToStart: details.ReturnToStart,
+	}
+}
+
+func toAPIItinerary(itinerary ItineraryView) *api.ItineraryResponse {
+	response := toAPITripPlan(itinerary.Plan)
+	response.ID = itinerary.ID
+	response.Name = itinerary.Name
+	response.ShareToken = itinerary.ShareToken
+	response.OwnerName = itinerary.OwnerName
+	response.CreatedAt = itinerary.CreatedAt
+	response.UpdatedAt = itinerary.UpdatedAt
+	return response
+}
+
+func toAPITripPlan(plan TripPlan) *api.ItineraryResponse {
+	response := &api.ItineraryResponse{
+		Start:           toAPITripStop(plan.Start),
+		ReturnToStart:   plan.ReturnToStart,
+		Routing:         plan.Provider,
+		Estimated:       plan.Provider == "straight_line",
+		DistanceKm:      plan.DistanceKm,
+		DurationSeconds: plan.DurationSeconds,
+		Days:            make([]*api.ItineraryDayResponse, 0, len(plan.Days)),
+	}
+	for _, day := range plan.Days {
+		dayResponse := &api.ItineraryDayResponse{
+			Day:             day.Day,
+			DistanceKm:      day.DistanceKm,
+			DurationSeconds: day.DurationSeconds,
+			Legs:            make([]*api.ItineraryLegResponse, 0, len(day.Legs)),
+		}
+		for _, leg := range day.Legs {
+			dayResponse.Legs = append(dayResponse.Legs, &api.ItineraryLegResponse{
+				From:            toAPITripStop(leg.From),
+				To:              toAPITripStop(leg.To),
+				DistanceKm:      leg.DistanceKm,
+				DurationSeconds: leg.DurationSeconds,
+			})
+		}
+		response.Days = append(response.Days, dayResponse)
+	}
+	return response
+}
+
+func toAPITripStop(stop TripStop) api.ItineraryStop {
+	return api.ItineraryStop{
+		CourseID:  stop.CourseID,
+		Name:      stop.Name,
+		Latitude:  stop.Latitude,
+		Longitude: stop.Longitude,
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+
+	"course_management/api"
+)
+
+// Course list methods for APIDBServiceAdapter (implements api.ListDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) GetCourseLists(userID uint) ([]*api.CourseListResponse, error) {
+	lists, err := NewCourseListService().GetLists(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*api.CourseListResponse, 0, len(lists))
+	for _, list := range lists {
+		responses = append(responses, toAPICourseList(list))
+	}
+	return responses, nil
+}
+
+func (a *APIDBServiceAdapter) GetCourseListsMap(userID uint) (api.GeoJSONFeatureCollection, error) {
+	lists, err := NewCourseListService().GetLists(userID)
+	if err != nil {
+		return api.GeoJSONFeatureCollection{}, err
+	}
+	return CourseListsMap(lists), nil
+}
+
+func (a *APIDBServiceAdapter) GetCourseList(userID, listID uint) (*api.CourseListResponse, error) {
+	return courseListResponse(NewCourseListService().GetList(userID, listID))
+}
+
+func (a *APIDBServiceAdapter) GetSharedCourseList(token string) (*api.CourseListResponse, error) {
+	return courseListResponse(NewCourseListService().GetSharedList(token))
+}
+
+func (a *APIDBServiceAdapter) CreateCourseList(userID uint, name string) (*api.CourseListResponse, error) {
+	return courseListResponse(NewCourseListService().CreateList(userID, name))
+}
+
+func (a *APIDBServiceAdapter) RenameCourseList(userID, listID uint, name string) (*api.CourseListResponse, error) {
+	return courseListResponse(NewCourseListService().RenameList(userID, listID, name))
+}
+
+func (a *APIDBServiceAdapter) DeleteCourseList(userID, listID uint) error {
+	return NewCourseListService().DeleteList(userID, listID)
+}
+
+func (a *APIDBServiceAdapter) AddCourseToList(userID, listID, courseID uint) (*api.CourseListResponse, error) {
+	return courseListResponse(NewCourseListService().AddCourse(userID, listID, courseID))
+}
+
+func (a *APIDBServiceAdapter) RemoveCourseFromList(userID, listID, courseID uint) (*api.CourseListResponse, error) {
+	return courseListResponse(NewCourseListService().RemoveCourse(userID, listID, courseID))
+}
+
+func (a *APIDBServiceAdapter) ReorderCourseList(userID, listID uint, courseIDs []uint) (*api.CourseListResponse, error) {
+	return courseListResponse(NewCourseListService().ReorderCourses(userID, listID, courseIDs))
+}
+
+func (a *APIDBServiceAdapter) SetCourseListSharing(userID, listID uint, shared bool) (*api.CourseListResponse, error) {
+	return courseListResponse(NewCourseListService().SetSharing(userID, listID, shared))
+}
+
+// courseListResponse converts a list service result, reporting lists that
+// don't exist or aren't the user's as nil
+func courseListResponse(list *CourseListView, err error) (*api.CourseListResponse, error) {
+	if err != nil {
+		if errors.Is(err, ErrCourseListNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return toAPICourseList(*list), nil
+}
+
+func toAPICourseList(list CourseListView) *api.CourseListResponse {
+	response := &api.CourseListResponse{
+		ID:          list.ID,
+		Name:        list.Name,
+		Kind:        list.Kind,
+		ShareToken:  list.ShareToken,
+		OwnerName:   list.OwnerName,
+		CourseCount: len(list.Courses),
+		PlayedCount: list.PlayedCount,
+		Courses:     make([]*api.CourseListEntryResponse, 0, len(list.Courses)),
+		CreatedAt:   list.CreatedAt,
+		UpdatedAt:   list.UpdatedAt,
+	}
+	for _, entry := range list.Courses {
+		response.Courses = append(response.Courses, &api.CourseListEntryResponse{
+			CourseID:   entry.CourseID,
+			CourseName: entry.CourseName,
+			Address:    entry.Address,
+			Latitude:   entry.Latitude,
+			Longitude:  entry.Longitude,
+			Position:   entry.Position,
+			Played:     entry.Played,
+			Rounds:     entry.Rounds,
+			BestScore:  entry.BestScore,
+			AddedAt:    entry.AddedAt,
+		})
+	}
+	return response
+}
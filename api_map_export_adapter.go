@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"course_management/api"
+)
+
+// mapExportBatchSize is how many courses an export loads from the database at a time
+const mapExportBatchSize = 500
+
+// Map export methods for APIDBServiceAdapter (implements the export part of
+// api.GeoDatabaseServiceInterface)
+
+// ExportMapCourses emits the located courses matching a search, in the order
+// SearchCourses lists them. Only IDs and locations are held for the whole
+// export; course data is loaded and emitted a batch at a time.
+func (a *APIDBServiceAdapter) ExportMapCourses(req *api.MapExportRequest, emit func(*api.MapExportCourse) error) error {
+	results, _, err := searchCandidates(&req.Search)
+	if err != nil {
+		return err
+	}
+	courseIDs, err := NewCourseFacetService().FilterCourseIDs(courseFacetFilter(&req.Search, nil))
+	if err != nil {
+		return err
+	}
+	results = keepCourseIDs(results, courseIDs)
+	sortSearchResults(results, &req.Search)
+
+	located := make([]uint, 0, len(results))
+	for _, result := range results {
+		if result.Latitude == nil || result.Longitude == nil {
+			continue
+		}
+		if bounds := req.Bounds; bounds != nil {
+			lat, lng := *result.Latitude, *result.Longitude
+			if lat < bounds.SouthLat || lat > bounds.NorthLat || lng < bounds.WestLng || lng > bounds.EastLng {
+				continue
+			}
+		}
+		located = append(located, result.CourseID)
+	}
+
+	for start := 0; start < len(located); start += mapExportBatchSize {
+		batch := located[start:min(start+mapExportBatchSize, len(located))]
+		courses, err := loadMapExportCourses(batch, req.Properties == api.MapPropertiesFull)
+		if err != nil {
+			return err
+		}
+		for _, courseID := range batch {
+			if course, ok := courses[courseID]; ok {
+				if err := emit(course); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// loadMapExportCourses reads a batch of courses for export. Tags and
+// amenities cost another query, so they're only loaded when asked for.
+func loadMapExportCourses(courseIDs []uint, withAttributes bool) (map[uint]*api.MapExportCourse, error) {
+	db := GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var rows []CourseDB
+	err := db.Select("id", "name", "address", "course_data", "latitude", "longitude", "created_at").
+		Where("id IN ? AND latitude IS NOT NULL AND longitude IS NOT NULL", courseIDs).
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load courses for export: %v", err)
+	}
+
+	var attributes map[uint]*CourseAttributeSummary
+	if withAttributes {
+		if attributes, err = NewCourseAttributeService().ListCourseAttributes(courseIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	courses := make(map[uint]*api.MapExportCourse, len(rows))
+	for _, row := range rows {
+		// A course whose data doesn't parse is still exported by name and place
+		var course Course
+		_ = json.Unmarshal([]byte(row.CourseData), &course)
+
+		par := 0
+		for _, hole := range course.Holes {
+			par += hole.Par
+		}
+		export := &api.MapExportCourse{
+			ID:                 row.ID,
+			Name:               row.Name,
+			Address:            row.Address,
+			Latitude:           *row.Latitude,
+			Longitude:          *row.Longitude,
+			Rating:             course.OverallRating,
+			CreatedAt:          row.CreatedAt,
+			Description:        course.Description,
+			Review:             course.Review,
+			Price:              course.Ranks.Price,
+			HandicapDifficulty: course.Ranks.HandicapDifficulty,
+			HazardDifficulty:   course.Ranks.HazardDifficulty,
+			Holes:              len(course.Holes),
+			Par:                par,
+		}
+		if summary := attributes[row.ID]; summary != nil {
+			export.Tags, export.Amenities = attributeSlugs(summary.Tags), attributeSlugs(summary.Amenities)
+		}
+		courses[row.ID] = export
+	}
+	return courses, nil
+}
+
+// attributeSlugs lists the slugs of course attributes
+func attributeSlugs(labels []CourseAttributeLabel) []string {
+	slugs := make([]string, 0, len(labels))
+	for _, label := range labels {
+		slugs = append(slugs, label.Slug)
+	}
+	return slugs
+}
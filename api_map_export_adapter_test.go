@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"course_management/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportedNames(t *testing.T, req *api.MapExportRequest) ([]string, []*api.MapExportCourse) {
+	t.Helper()
+
+	var names []string
+	var courses []*api.MapExportCourse
+	err := (&APIDBServiceAdapter{}).ExportMapCourses(req, func(course *api.MapExportCourse) error {
+		names = append(names, course.Name)
+		courses = append(courses, course)
+		return nil
+	})
+	require.NoError(t, err)
+	return names, courses
+}
+
+func TestExportMapCourses(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedGeoCourses(t, db)
+	require.NoError(t, db.Model(&CourseDB{}).Where("id = ?", ids["Pinehurst No. 2"]).Update("course_data",
+		`{"overallRating":"S","description":"Donald Ross","ranks":{"price":"$$$$","handicapDifficulty":5},"holes":[{"number":1,"par":4},{"number":2,"par":5}]}`).Error)
+	require.NoError(t, db.Create(&CourseAttribute{CourseID: ids["Pinehurst No. 2"], Kind: AttributeKindTag, Slug: "tough-greens", Votes: 2, Voters: 2}).Error)
+	require.NoError(t, db.Create(&CourseAttribute{CourseID: ids["Mid Pines"], Kind: AttributeKindAmenity, Slug: "range", Votes: 1, Voters: 1}).Error)
+	sandhills := &api.BoundsRequest{NorthLat: 36, SouthLat: 35, EastLng: -79, WestLng: -80}
+
+	names, _ := exportedNames(t, &api.MapExportRequest{Properties: api.MapPropertiesStandard})
+	assert.Equal(t, []string{"Fiji East", "Fiji West", "Mid Pines", "Pebble Beach", "Pinehurst No. 2", "Tobacco Road"}, names, "every located course, by name")
+
+	names, _ = exportedNames(t, &api.MapExportRequest{Search: api.CourseSearchRequest{SortBy: "name", SortOrder: "desc"}, Bounds: sandhills})
+	assert.Equal(t, []string{"Tobacco Road", "Pinehurst No. 2", "Mid Pines"}, names, "within the bounds, in the search's order")
+
+	names, _ = exportedNames(t, &api.MapExportRequest{Search: api.CourseSearchRequest{Amenities: []string{"range"}}})
+	assert.Equal(t, []string{"Mid Pines"}, names, "filtered like a search")
+
+	_, courses := exportedNames(t, &api.MapExportRequest{Search: api.CourseSearchRequest{Tags: []string{"tough-greens"}}, Properties: api.MapPropertiesFull})
+	require.Len(t, courses, 1)
+	assert.Equal(t, api.MapExportCourse{
+		ID: ids["Pinehurst No. 2"], Name: "Pinehurst No. 2", Address: "Pinehurst No. 2", Latitude: 35.1907, Longitude: -79.4704,
+		Rating: "S", CreatedAt: courses[0].CreatedAt, Description: "Donald Ross", Price: "$$$$", HandicapDifficulty: 5,
+		Holes: 2, Par: 9, Tags: []string{"tough-greens"}, Amenities: []string{},
+	}, *courses[0])
+
+	_, courses = exportedNames(t, &api.MapExportRequest{Search: api.CourseSearchRequest{Tags: []string{"tough-greens"}}, Properties: api.MapPropertiesStandard})
+	require.Len(t, courses, 1)
+	assert.Nil(t, courses[0].Tags, "attributes are only loaded for the full set")
+
+	emitted := 0
+	err := (&APIDBServiceAdapter{}).ExportMapCourses(&api.MapExportRequest{}, func(*api.MapExportCourse) error {
+		emitted++
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 1, emitted, "stops at the first failed write")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+
+	"course_management/api"
+)
+
+// Notification methods for APIDBServiceAdapter (implements api.NotificationDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) GetNotifications(userID uint, unreadOnly bool, page, perPage int) ([]*api.NotificationResponse, int, error) {
+	notifications, total, err := NewNotificationService().GetNotifications(userID, unreadOnly, page, perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*api.NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		responses = append(responses, &api.NotificationResponse{
+			ID:        notification.ID,
+			Type:      notification.Type,
+			Message:   notification.Message,
+			ActorID:   notification.ActorID,
+			CourseID:  notification.CourseID,
+			Read:      notification.ReadAt != nil,
+			ReadAt:    notification.ReadAt,
+			CreatedAt: notification.CreatedAt,
+		})
+	}
+	return responses, int(total), nil
+}
+
+func (a *APIDBServiceAdapter) CountUnreadNotifications(userID uint) (int64, error) {
+	return NewNotificationService().CountUnread(userID)
+}
+
+func (a *APIDBServiceAdapter) MarkNotificationRead(userID, notificationID uint) (bool, error) {
+	if err := NewNotificationService().MarkRead(userID, notificationID); err != nil {
+		if errors.Is(err, ErrNotificationNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *APIDBServiceAdapter) MarkAllNotificationsRead(userID uint) error {
+	return NewNotificationService().MarkAllRead(userID)
+}
+
+func (a *APIDBServiceAdapter) GetNotificationPreferences(userID uint) ([]*api.NotificationPreference, error) {
+	settings, err := NewNotificationService().GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toAPINotificationPreferences(settings), nil
+}
+
+func (a *APIDBServiceAdapter) UpdateNotificationPreferences(userID uint, preferences []api.NotificationPreference) ([]*api.NotificationPreference, error) {
+	notificationService := NewNotificationService()
+
+	settings := make([]NotificationSetting, 0, len(preferences))
+	for _, preference := range preferences {
+		settings = append(settings, NotificationSetting{Type: preference.Type, InApp: preference.InApp, Email: preference.Email})
+	}
+	if err := notificationService.UpdateSettings(userID, settings); err != nil {
+		return nil, err
+	}
+
+	return a.GetNotificationPreferences(userID)
+}
+
+func toAPINotificationPreferences(settings []NotificationSetting) []*api.NotificationPreference {
+	preferences := make([]*api.NotificationPreference, 0, len(settings))
+	for _, setting := range settings {
+		preferences = append(preferences, &api.NotificationPreference{
+			Type:  setting.Type,
+			InApp: setting.InApp,
+			Email: setting.Email,
+		})
+	}
+	return preferences
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+
+	"course_management/api"
+)
+
+// Outing methods for APIDBServiceAdapter (implements api.OutingDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) GetOutings(userID uint) ([]*api.OutingResponse, error) {
+	outings, err := NewOutingService().GetOutings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*api.OutingResponse, 0, len(outings))
+	for _, outing := range outings {
+		responses = append(responses, toAPIOuting(outing))
+	}
+	return responses, nil
+}
+
+func (a *APIDBServiceAdapter) GetOuting(userID, outingID uint) (*api.OutingResponse, error) {
+	return outingResponse(NewOutingService().GetOuting(userID, outingID))
+}
+
+func (a *APIDBServiceAdapter) CreateOuting(userID uint, details api.OutingDetails) (*api.OutingResponse, error) {
+	return outingResponse(NewOutingService().CreateOuting(userID, toOutingInput(details)))
+}
+
+func (a *APIDBServiceAdapter) UpdateOuting(userID, outingID uint, details api.OutingDetails) (*api.OutingResponse, error) {
+	return outingResponse(NewOutingService().UpdateOuting(userID, outingID, toOutingInput(details)))
+}
+
+func (a *APIDBServiceAdapter) CancelOuting(userID, outingID uint) error {
+	return NewOutingService().CancelOuting(userID, outingID)
+}
+
+func (a *APIDBServiceAdapter) InviteToOuting(userID, outingID uint, userIDs []uint, emails []string) (*api.OutingResponse, error) {
+	return outingResponse(NewOutingService().Invite(userID, outingID, userIDs, emails))
+}
+
+func (a *APIDBServiceAdapter) RSVPOuting(userID, outingID uint, rsvp string) (*api.OutingResponse, error) {
+	return outingResponse(NewOutingService().RSVP(userID, outingID, rsvp))
+}
+
+func (a *APIDBServiceAdapter) LinkOutingScore(userID, outingID, scoreID uint) (bool, error) {
+	if err := NewOutingService().LinkScore(userID, outingID, scoreID); err != nil {
+		if errors.Is(err, ErrOutingScoreMismatch) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *APIDBServiceAdapter) GetOutingResults(userID, outingID uint) ([]*api.OutingResultResponse, error) {
+	_, results, err := NewOutingService().GetResults(userID, outingID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*api.OutingResultResponse, 0, len(results))
+	for _, result := range results {
+		responses = append(responses, &api.OutingResultResponse{
+			Rank:     result.Rank,
+			UserID:   result.UserID,
+			Name:     result.Name,
+			ScoreID:  result.ScoreID,
+			Score:    result.Score,
+			Handicap: result.Handicap,
+			Net:      result.Net,
+		})
+	}
+	return responses, nil
+}
+
+func (a *APIDBServiceAdapter) GetOutingCalendar(userID, outingID uint, baseURL string) ([]byte, error) {
+	outing, err := NewOutingService().GetOuting(userID, outingID)
+	if err != nil {
+		if errors.Is(err, ErrOutingNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return api.NewCalendar(outing.Title, OutingCalendarEvents([]OutingView{*outing}, baseURL)), nil
+}
+
+func (a *APIDBServiceAdapter) GetOutingCalendarFeedToken(userID uint) (string, error) {
+	return NewOutingService().CalendarFeedToken(userID)
+}
+
+// outingResponse converts an outing service result, reporting outings that
+// don't exist or the user can't see as nil
+func outingResponse(outing *OutingView, err error) (*api.OutingResponse, error) {
+	if err != nil {
+		if errors.Is(err, ErrOutingNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return toAPIOuting(*outing), nil
+}
+
+func toOutingInput(details api.OutingDetails) OutingInput {
+	return OutingInput{
+		CourseID: details.CourseID,
+		Title:    details.Title,
+		StartsAt: details.StartsAt,
+		Capacity: details.Capacity,
+		Notes:    details.Notes,
+	}
+}
+
+func toAPIOuting(outing OutingView) *api.OutingResponse {
+	response := &api.OutingResponse{
+		ID:            outing.ID,
+		Title:         outing.Title,
+		CourseID:      outing.CourseID,
+		CourseName:    outing.CourseName,
+		CourseAddress: outing.CourseAddress,
+		OrganizerID:   outing.OrganizerID,
+		OrganizerName: outing.OrganizerName,
+		StartsAt:      outing.StartsAt,
+		Capacity:      outing.Capacity,
+		GoingCount:    outing.GoingCount,
+		Notes:         outing.Notes,
+		Cancelled:     outing.CancelledAt != nil,
+		CancelledAt:   outing.CancelledAt,
+		IsOrganizer:   outing.IsOrganizer,
+		Invitees:      make([]*api.OutingInviteeResponse, 0, len(outing.Invitees)),
+		CreatedAt:     outing.CreatedAt,
+		UpdatedAt:     outing.UpdatedAt,
+	}
+	if outing.SpotsLeft >= 0 {
+		spotsLeft := outing.SpotsLeft
+		response.SpotsLeft = &spotsLeft
+	}
+	if outing.MyInvite != nil {
+		response.MyRSVP = outing.MyInvite.RSVP
+	}
+	for _, invitee := range outing.Invitees {
+		response.Invitees = append(response.Invitees, &api.OutingInviteeResponse{
+			InviteID:    invitee.InviteID,
+			UserID:      invitee.UserID,
+			Email:       invitee.Email,
+			Name:        invitee.Name,
+			RSVP:        invitee.RSVP,
+			RespondedAt: invitee.RespondedAt,
+		})
+	}
+	return response
+}
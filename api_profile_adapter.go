@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+
+	"course_management/api"
+)
+
+// Profile methods for APIDBServiceAdapter (implements api.ProfileDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) GetPublicProfile(userID uint, viewerID *uint) (*api.PublicProfileResponse, error) {
+	profile, err := NewProfileService().GetProfile(userID, viewerID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	response := &api.PublicProfileResponse{
+		UserID:      profile.UserID,
+		DisplayName: profile.Name,
+		MemberSince: profile.MemberSince,
+		Followers:   profile.Followers,
+		Following:   profile.Following,
+		IsFollowing: profile.IsFollowing,
+		Handicap:    profile.Handicap,
+	}
+
+	if profile.ShowReviews {
+		section := &api.ProfileReviewsSection{
+			Total: profile.ReviewCount,
+			Items: make([]*api.ProfileReviewResponse, 0, len(profile.Reviews)),
+		}
+		for _, review := range profile.Reviews {
+			section.Items = append(section.Items, &api.ProfileReviewResponse{
+				ID:            review.ID,
+				CourseID:      review.CourseID,
+				CourseName:    review.CourseName,
+				OverallRating: review.OverallRating,
+				ReviewText:    review.ReviewText,
+				CreatedAt:     review.CreatedAt,
+			})
+		}
+		response.Reviews = section
+	}
+
+	if profile.ShowPlayed {
+		section := &api.PlayedCoursesSection{
+			CoursesPlayed: profile.CoursesPlayed(),
+			RoundsPlayed:  profile.RoundsPlayed,
+			BestScore:     profile.BestScore,
+			Courses:       make([]*api.PlayedCourseResponse, 0, len(profile.Played)),
+			Map:           profile.PlayedMap(),
+		}
+		for _, played := range profile.Played {
+			section.Courses = append(section.Courses, &api.PlayedCourseResponse{
+				CourseID:   played.CourseID,
+				CourseName: played.CourseName,
+				Rounds:     played.Rounds,
+				BestScore:  played.BestScore,
+				LastPlayed: played.LastPlayed,
+			})
+		}
+		response.PlayedCourses = section
+	}
+
+	if profile.ShowActivity {
+		response.Activity = make([]*api.FeedItemResponse, 0, len(profile.Activity))
+		for _, item := range profile.Activity {
+			response.Activity = append(response.Activity, toAPIFeedItem(item))
+		}
+	}
+
+	return response, nil
+}
+
+func (a *APIDBServiceAdapter) GetProfilePrivacy(userID uint) (*api.ProfilePrivacy, error) {
+	privacy, err := NewProfileService().GetPrivacy(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toAPIProfilePrivacy(privacy), nil
+}
+
+func (a *APIDBServiceAdapter) UpdateProfilePrivacy(userID uint, update api.ProfilePrivacy) (*api.ProfilePrivacy, error) {
+	privacy, err := NewProfileService().UpdatePrivacy(userID, ProfilePrivacy{
+		Handicap:      update.Handicap,
+		Reviews:       update.Reviews,
+		PlayedCourses: update.PlayedCourses,
+		Activity:      update.Activity,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toAPIProfilePrivacy(privacy), nil
+}
+
+func toAPIProfilePrivacy(privacy ProfilePrivacy) *api.ProfilePrivacy {
+	return &api.ProfilePrivacy{
+		Handicap:      privacy.Handicap,
+		Reviews:       privacy.Reviews,
+		PlayedCourses: privacy.PlayedCourses,
+		Activity:      privacy.Activity,
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"course_management/api"
+)
+
+// Search methods for APIDBServiceAdapter (implements api.SearchDatabaseServiceInterface
+// and the full-text part of api.CoursesDatabaseServiceInterface)
+
+// SearchCourses returns a page of the courses matching the search, ranked by
+// relevance unless sorted by name. Without a text query every course
+// matches, by name. Tag, amenity and ranking facets narrow the matches.
+func (a *APIDBServiceAdapter) SearchCourses(search *api.CourseSearchRequest, userID *uint, page, perPage int) ([]*api.CourseResponse, int, error) {
+	results, hasQuery, err := searchCandidates(search)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	courseIDs, err := NewCourseFacetService().FilterCourseIDs(courseFacetFilter(search, nil))
+	if err != nil {
+		return nil, 0, err
+	}
+	results = keepCourseIDs(results, courseIDs)
+	sortSearchResults(results, search)
+
+	total := len(results)
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+
+	responses := make([]*api.CourseResponse, 0, end-start)
+	for _, result := range results[start:end] {
+		response := &api.CourseResponse{
+			ID:        result.CourseID,
+			Name:      result.Name,
+			Address:   result.Address,
+			Latitude:  result.Latitude,
+			Longitude: result.Longitude,
+			Holes:     []api.HoleData{},
+			Tags:      []string{},
+			Amenities: []string{},
+			Mentions:  []api.ReviewMentionResponse{},
+			Snippet:   result.Snippet,
+		}
+		if hasQuery {
+			rank := result.Rank
+			response.SearchRank = &rank
+		}
+		responses = append(responses, response)
+	}
+	return responses, total, nil
+}
+
+// GetCourseFacetCounts counts the courses matching the search by price,
+// difficulty, grade, hole count and par
+func (a *APIDBServiceAdapter) GetCourseFacetCounts(search *api.CourseSearchRequest) (*api.CourseFacetCounts, error) {
+	var candidates []uint
+	if len(searchTerms(search.Query)) > 0 || len(search.Tags) > 0 || len(search.Amenities) > 0 {
+		results, _, err := searchCandidates(search)
+		if err != nil {
+			return nil, err
+		}
+		candidates = make([]uint, 0, len(results))
+		for _, result := range results {
+			candidates = append(candidates, result.CourseID)
+		}
+	}
+
+	counts, err := NewCourseFacetService().CountFacets(courseFacetFilter(search, candidates))
+	if err != nil {
+		return nil, err
+	}
+
+	response := &api.CourseFacetCounts{
+		Price:              orderedFacetCounts(counts.Price, api.PriceTiers),
+		HandicapDifficulty: numericFacetCounts(counts.HandicapDifficulty),
+		HazardDifficulty:   numericFacetCounts(counts.HazardDifficulty),
+		Grades:             make(map[string][]api.FacetValueCount, len(counts.Grades)),
+		Holes:              numericFacetCounts(counts.Holes),
+		Par:                numericFacetCounts(counts.Par),
+	}
+	for category, grades := range counts.Grades {
+		response.Grades[category] = orderedFacetCounts(grades, api.GradeScale)
+	}
+	return response, nil
+}
+
+// searchCandidates returns the courses matching the text query, tags and
+// amenities of a search, and whether it had a text query
+func searchCandidates(search *api.CourseSearchRequest) ([]CourseSearchResult, bool, error) {
+	hasQuery := len(searchTerms(search.Query)) > 0
+	var results []CourseSearchResult
+	if hasQuery {
+		var err error
+		if results, err = NewCourseSearchService().MatchCourses(search.Query); err != nil {
+			return nil, false, err
+		}
+	} else {
+		db := GetDB()
+		if db == nil {
+			return nil, false, fmt.Errorf("database not connected")
+		}
+		var courses []CourseDB
+		if err := db.Select("id", "name", "address", "latitude", "longitude").Order("name, id").Find(&courses).Error; err != nil {
+			return nil, false, fmt.Errorf("failed to get courses: %v", err)
+		}
+		for _, course := range courses {
+			results = append(results, CourseSearchResult{
+				CourseID:  course.ID,
+				Name:      course.Name,
+				Address:   course.Address,
+				Latitude:  course.Latitude,
+				Longitude: course.Longitude,
+			})
+		}
+	}
+
+	courseIDs, err := NewCourseAttributeService().FindCourseIDsWithAttributes(search.Tags, search.Amenities)
+	if err != nil {
+		return nil, false, err
+	}
+	return keepCourseIDs(results, courseIDs), hasQuery, nil
+}
+
+// sortSearchResults orders results by name when the search asks for it,
+// leaving them ranked otherwise
+func sortSearchResults(results []CourseSearchResult, search *api.CourseSearchRequest) {
+	if search.SortBy != "name" {
+		return
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if search.SortOrder == "desc" {
+			return results[i].Name > results[j].Name
+		}
+		return results[i].Name < results[j].Name
+	})
+}
+
+// keepCourseIDs drops results whose course isn't listed; nil keeps them all
+func keepCourseIDs(results []CourseSearchResult, courseIDs []uint) []CourseSearchResult {
+	if courseIDs == nil {
+		return results
+	}
+	allowed := make(map[uint]bool, len(courseIDs))
+	for _, id := range courseIDs {
+		allowed[id] = true
+	}
+	filtered := results[:0]
+	for _, result := range results {
+		if allowed[result.CourseID] {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+func courseFacetFilter(search *api.CourseSearchRequest, candidates []uint) CourseFacetFilter {
+	grades, _ := api.ParseGradeFilters(search.MinGrades)
+	return CourseFacetFilter{
+		CourseIDs:             candidates,
+		MinPrice:              search.MinPrice,
+		MaxPrice:              search.MaxPrice,
+		MinHandicapDifficulty: search.MinHandicapDifficulty,
+		MaxHandicapDifficulty: search.MaxHandicapDifficulty,
+		MinHazardDifficulty:   search.MinHazardDifficulty,
+		MaxHazardDifficulty:   search.MaxHazardDifficulty,
+		MinGrades:             grades,
+		Holes:                 search.Holes,
+		MinPar:                search.MinPar,
+		MaxPar:                search.MaxPar,
+	}
+}
+
+// orderedFacetCounts lists every known value in order, including those no course has
+func orderedFacetCounts(counts map[string]int, values []string) []api.FacetValueCount {
+	ordered := make([]api.FacetValueCount, 0, len(values))
+	for _, value := range values {
+		ordered = append(ordered, api.FacetValueCount{Value: value, Count: counts[value]})
+	}
+	return ordered
+}
+
+// numericFacetCounts lists the values courses have, smallest first
+func numericFacetCounts(counts map[string]int) []api.FacetValueCount {
+	ordered := make([]api.FacetValueCount, 0, len(counts))
+	for value, count := range counts {
+		ordered = append(ordered, api.FacetValueCount{Value: value, Count: count})
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		left, _ := strconv.Atoi(ordered[i].Value)
+		right, _ := strconv.Atoi(ordered[j].Value)
+		return left < right
+	})
+	return ordered
+}
+
+func (a *APIDBServiceAdapter) GetSearchSuggestions(query string, limit int) (*api.SearchSuggestionsResponse, error) {
+	suggestions, err := NewCourseSearchService().Suggest(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &api.SearchSuggestionsResponse{
+		Courses:   make([]*api.CourseSuggestionResponse, 0, len(suggestions.Courses)),
+		Locations: suggestions.Locations,
+		Users:     make([]*api.UserSuggestionResponse, 0, len(suggestions.Users)),
+	}
+	for _, course := range suggestions.Courses {
+		response.Courses = append(response.Courses, &api.CourseSuggestionResponse{ID: course.ID, Name: course.Name})
+	}
+	for _, user := range suggestions.Users {
+		response.Users = append(response.Users, &api.UserSuggestionResponse{ID: user.ID, Name: user.Name})
+	}
+	return response, nil
+}
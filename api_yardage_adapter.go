@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+
+	"course_management/api"
+)
+
+// Yardage and shot tracking methods for APIDBServiceAdapter (implements api.YardageDatabaseServiceInterface)
+
+func (a *APIDBServiceAdapter) GetHoleYardage(courseID uint, holeNumber int, latitude, longitude float64) (*api.HoleYardageResponse, error) {
+	yardage, err := NewCourseHoleGeometryService().Yardage(courseID, holeNumber, GeoPoint{Latitude: latitude, Longitude: longitude})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrCourseNotFound), errors.Is(err, ErrHoleGeometryNotFound):
+			return nil, nil
+		case errors.Is(err, ErrHoleNotDetected):
+			return nil, api.ErrHoleNotDetected
+		}
+		return nil, err
+	}
+
+	response := &api.HoleYardageResponse{
+		HoleNumber: yardage.HoleNumber,
+		Detected:   yardage.Detected,
+		Par:        yardage.Par,
+		Yardage:    yardage.Yardage,
+		Green: api.GreenYardageDetails{
+			Front:  yardage.Green.Front,
+			Center: yardage.Green.Center,
+			Back:   yardage.Green.Back,
+		},
+		Hazards: make([]api.HazardYardageDetails, len(yardage.Hazards)),
+		Layups:  make([]api.LayupPointDetails, len(yardage.Layups)),
+	}
+	for i, hazard := range yardage.Hazards {
+		response.Hazards[i] = api.HazardYardageDetails{Kind: hazard.Kind, Reach: hazard.Reach, Carry: hazard.Carry, Side: hazard.Side}
+	}
+	for i, layup := range yardage.Layups {
+		response.Layups[i] = api.LayupPointDetails{
+			ToGreen:  layup.ToGreen,
+			Distance: layup.Distance,
+			Point:    api.HolePoint{Latitude: layup.Point.Latitude, Longitude: layup.Point.Longitude},
+			InHazard: layup.InHazard,
+		}
+	}
+	return response, nil
+}
+
+func (a *APIDBServiceAdapter) RecordShot(userID, courseID uint, req api.RoundShotRequest) (*api.RoundShotResponse, error) {
+	shot, err := NewShotTrackingService().RecordShot(userID, courseID, ShotInput{
+		DatePlayed: req.DatePlayed,
+		HoleNumber: req.HoleNumber,
+		Position:   GeoPoint{Latitude: *req.Latitude, Longitude: *req.Longitude},
+		Club:       req.Club,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrCourseNotFound):
+			return nil, nil
+		case errors.Is(err, ErrHoleNotDetected):
+			return nil, api.ErrHoleNotDetected
+		case errors.Is(err, ErrShotLimitReached):
+			return nil, api.ErrHoleShotLimit
+		}
+		return nil, err
+	}
+	return toAPIRoundShot(TrackedShot{RoundShot: *shot}), nil
+}
+
+func (a *APIDBServiceAdapter) GetShotScorecard(userID, courseID uint, date string) (*api.ShotScorecardResponse, error) {
+	scorecard, err := NewShotTrackingService().GetScorecard(userID, courseID, date)
+	if err != nil {
+		if errors.Is(err, ErrCourseNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	response := &api.ShotScorecardResponse{
+		CourseID:   scorecard.CourseID,
+		DatePlayed: scorecard.DatePlayed,
+		ScoreID:    scorecard.ScoreID,
+		Strokes:    scorecard.Strokes,
+		Holes:      make([]api.ShotHoleResponse, len(scorecard.Holes)),
+	}
+	for i, hole := range scorecard.Holes {
+		shots := make([]api.RoundShotResponse, len(hole.Shots))
+		for j, shot := range hole.Shots {
+			shots[j] = *toAPIRoundShot(shot)
+		}
+		response.Holes[i] = api.ShotHoleResponse{
+			HoleNumber: hole.HoleNumber,
+			Par:        hole.Par,
+			Strokes:    hole.Strokes,
+			Remaining:  hole.Remaining,
+			Shots:      shots,
+		}
+	}
+	return response, nil
+}
+
+func (a *APIDBServiceAdapter) DeleteShot(userID, courseID, shotID uint) (bool, error) {
+	err := NewShotTrackingService().DeleteShot(userID, courseID, shotID)
+	if errors.Is(err, ErrShotNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func toAPIRoundShot(shot TrackedShot) *api.RoundShotResponse {
+	return &api.RoundShotResponse{
+		ID:         shot.ID,
+		DatePlayed: shot.DatePlayed,
+		HoleNumber: shot.HoleNumber,
+		ShotNumber: shot.ShotNumber,
+		Latitude:   shot.Latitude,
+		Longitude:  shot.Longitude,
+		Club:       shot.Club,
+		Distance:   shot.Distance,
+		CreatedAt:  shot.CreatedAt,
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// command is a maintenance task run as `./main <name> [flags]` in place of
+// the server
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{name: "import-osm", summary: "Import golf courses from an OSM XML or PBF extract", run: runImportOSM},
+	{name: "coursectl", summary: "Validate, import and export course files (validate|import|export)", run: runCoursectl},
+	{name: "course-duplicates", summary: "List courses that look like duplicates of each other", run: runCourseDuplicates},
+	{name: "merge-courses", summary: "Merge a duplicate course into the course that stays", run: runMergeCourses},
+	{name: "admins", summary: "List, grant or revoke admins (list|grant|revoke)", run: runAdmins},
+}
+
+// runCommand runs the subcommand named by args[0] and returns the exit code
+func runCommand(args []string) int {
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		if err := cmd.run(args[1:], os.Stdout); err != nil {
+			if err != flag.ErrHelp {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+			}
+			return 1
+		}
+		return 0
+	}
+
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, fmt.Sprintf("  %-18s %s", cmd.name, cmd.summary))
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\nCommands:\n%s\n\nRun without arguments to start the server.\n", args[0], strings.Join(names, "\n"))
+	return 2
+}
+
+// runImportOSM reports what an extract would add and, with -apply, adds it
+func runImportOSM(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("import-osm", flag.ContinueOnError)
+	apply := flags.Bool("apply", false, "create the new courses; without it nothing is written")
+	createdBy := flags.Uint("user", 0, "user ID to record as the courses' creator")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: import-osm [-apply] [-user ID] <extract.osm.pbf|extract.osm|extract.osm.bz2>\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one extract file")
+	}
+
+	if err := InitDatabase(); err != nil {
+		return err
+	}
+	importer := NewOSMCourseImporter()
+	report, err := importer.Plan(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *apply && len(report.New) > 0 {
+		var user *uint
+		if *createdBy != 0 {
+			id := *createdBy
+			if err := importer.db.First(&User{}, id).Error; err != nil {
+				return fmt.Errorf("user %d not found", id)
+			}
+			user = &id
+		}
+		if err := importer.Apply(report, user); err != nil {
+			return err
+		}
+	}
+	writeOSMImportReport(stdout, report)
+	return nil
+}
+
+// runCourseDuplicates lists candidate duplicate pairs, best first
+func runCourseDuplicates(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("course-duplicates", flag.ContinueOnError)
+	minScore := flags.Float64("min-score", defaultDuplicateMinScore, "lowest duplicate score to list, from 0 to 1")
+	limit := flags.Int("limit", 0, "list at most this many pairs; 0 lists all")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: course-duplicates [-min-score 0.8] [-limit N]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments")
+	}
+	if *minScore < 0 || *minScore > 1 {
+		return fmt.Errorf("-min-score must be between 0 and 1")
+	}
+
+	if err := InitDatabase(); err != nil {
+		return err
+	}
+	candidates, err := NewCourseDuplicateService().FindCandidates(*minScore)
+	if err != nil {
+		return err
+	}
+	if *limit > 0 && len(candidates) > *limit {
+		candidates = candidates[:*limit]
+	}
+	writeCourseDuplicates(stdout, candidates, *minScore)
+	return nil
+}
+
+// runMergeCourses reports what merging a duplicate would move and, with
+// -apply, merges it
+func runMergeCourses(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("merge-courses", flag.ContinueOnError)
+	apply := flags.Bool("apply", false, "merge the courses; without it nothing is written")
+	mergedBy := flags.Uint("user", 0, "user ID to record as having made the merge")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: merge-courses [-apply] [-user ID] <duplicate course ID> <surviving course ID>\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected the duplicate and surviving course IDs")
+	}
+	ids, err := parseCourseIDs(strings.Join(flags.Args(), ","))
+	if err != nil {
+		return err
+	}
+
+	if err := InitDatabase(); err != nil {
+		return err
+	}
+	options := CourseMergeOptions{DryRun: !*apply}
+	if *mergedBy != 0 {
+		id := *mergedBy
+		if err := GetDB().First(&User{}, id).Error; err != nil {
+			return fmt.Errorf("user %d not found", id)
+		}
+		options.MergedBy = &id
+	}
+
+	var survivor CourseDB
+	if err := GetDB().Select("id", "name").First(&survivor, ids[1]).Error; err != nil {
+		return fmt.Errorf("course %d not found", ids[1])
+	}
+	merge, err := NewCourseMergeService().Merge(ids[0], ids[1], options)
+	if err != nil {
+		return err
+	}
+	writeCourseMergeReport(stdout, merge, survivor.Name, *apply)
+	return nil
+}
+
+func runAdmins(args []string, stdout io.Writer) error {
+	usage := "usage: admins list | admins grant <user ID or email> | admins revoke <user ID or email>"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return errors.New(usage)
+		}
+	case "grant", "revoke":
+		if len(args) != 2 {
+			return errors.New(usage)
+		}
+	default:
+		return fmt.Errorf("unknown admins command %q; %s", args[0], usage)
+	}
+
+	if err := InitDatabase(); err != nil {
+		return err
+	}
+	service := NewAdminService()
+	if args[0] == "list" {
+		admins, err := service.ListAdmins()
+		if err != nil {
+			return err
+		}
+		for _, admin := range admins {
+			fmt.Fprintf(stdout, "%d\t%s\t%s\n", admin.ID, admin.Email, admin.Name)
+		}
+		fmt.Fprintf(stdout, "%d admins.\n", len(admins))
+		return nil
+	}
+
+	user, err := service.SetAdmin(args[1], args[0] == "grant")
+	if err != nil {
+		return fmt.Errorf("%s: %v", args[1], err)
+	}
+	if user.IsAdmin {
+		fmt.Fprintf(stdout, "User %d (%s) is now an admin.\n", user.ID, user.Email)
+	} else {
+		fmt.Fprintf(stdout, "User %d (%s) is no longer an admin.\n", user.ID, user.Email)
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultConditionsLifetime = 72 * time.Hour
+	maxConditionsLifetime     = 7 * 24 * time.Hour
+
+	// Reports lose half their weight in the current-conditions summary every conditionsHalfLife
+	conditionsHalfLife = 24 * time.Hour
+
+	maxConditionsNoteLength = 500
+)
+
+var (
+	ErrCourseNotFound            = errors.New("course not found")
+	ErrConditionsReportNotFound  = errors.New("conditions report not found")
+	ErrConditionsReportForbidden = errors.New("you can only remove your own conditions reports")
+	ErrInvalidConditionsReport   = errors.New("invalid conditions report")
+)
+
+// Surface ratings from best to worst, and pace of play from quickest to slowest
+var (
+	ConditionsSurfaceRatings = []string{"excellent", "good", "fair", "poor"}
+	ConditionsPaceRatings    = []string{"fast", "average", "slow"}
+)
+
+type ConditionsReportService struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+func NewConditionsReportService() *ConditionsReportService {
+	return &ConditionsReportService{
+		db:  GetDB(),
+		now: time.Now,
+	}
+}
+
+// CreateReport validates and stores a conditions report and records it in the user's activity
+func (s *ConditionsReportService) CreateReport(userID, courseID uint, formData ConditionsReportFormData) (*ConditionsReport, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	report, err := s.buildReport(formData)
+	if err != nil {
+		return nil, err
+	}
+	report.UserID = userID
+	report.CourseID = courseID
+
+	var course CourseDB
+	if err := s.db.Select("id").First(&course, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, fmt.Errorf("failed to find course: %v", err)
+	}
+
+	if err := s.db.Create(report).Error; err != nil {
+		return nil, fmt.Errorf("failed to save conditions report: %v", err)
+	}
+
+	log.Printf("✅ User %d posted conditions report %d for course %d", userID, report.ID, courseID)
+
+	reviewService := &ReviewService{db: s.db}
+	reviewService.createActivity(userID, "conditions_report", &courseID, map[string]any{
+		"report_id":  report.ID,
+		"expires_at": report.ExpiresAt,
+	})
+
+	return report, nil
+}
+
+// DeleteReport removes a report. Only its author may remove it.
+func (s *ConditionsReportService) DeleteReport(userID, reportID uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	report, err := s.GetReport(reportID)
+	if err != nil {
+		return err
+	}
+
+	if report.UserID != userID {
+		log.Printf("🚨 [SECURITY] User %d attempted to delete conditions report %d owned by user %d", userID, reportID, report.UserID)
+		return ErrConditionsReportForbidden
+	}
+
+	if err := s.db.Delete(report).Error; err != nil {
+		return fmt.Errorf("failed to delete conditions report: %v", err)
+	}
+	return nil
+}
+
+// GetReport returns a single conditions report, expired or not
+func (s *ConditionsReportService) GetReport(reportID uint) (*ConditionsReport, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var report ConditionsReport
+	if err := s.db.First(&report, reportID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrConditionsReportNotFound
+		}
+		return nil, fmt.Errorf("failed to find conditions report: %v", err)
+	}
+	return &report, nil
+}
+
+// GetActiveReports returns the unexpired reports for a course, newest first
+func (s *ConditionsReportService) GetActiveReports(courseID uint) ([]ConditionsReport, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var reports []ConditionsReport
+	result := s.db.Where("course_id = ? AND expires_at > ?", courseID, s.now().Unix()).
+		Order("created_at DESC, id DESC").
+		Find(&reports)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get conditions reports: %v", result.Error)
+	}
+	return reports, nil
+}
+
+// GetCurrentConditions summarizes a course's active reports. Each report is
+// weighted by its age, halving every conditionsHalfLife, so this morning's
+// frost delay outweighs a report from three days ago.
+func (s *ConditionsReportService) GetCurrentConditions(courseID uint, viewerID *uint) (*CurrentConditions, error) {
+	reports, err := s.GetActiveReports(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	summary := &CurrentConditions{
+		CourseID:    courseID,
+		ReportCount: len(reports),
+		Reports:     make([]ConditionsReportView, 0, len(reports)),
+	}
+	if len(reports) == 0 {
+		return summary, nil
+	}
+
+	latest := reports[0].CreatedAt
+	summary.LatestReport = &latest
+
+	weights := make([]float64, len(reports))
+	for i, report := range reports {
+		age := now.Sub(time.Unix(report.CreatedAt, 0))
+		if age < 0 {
+			age = 0
+		}
+		weights[i] = math.Pow(0.5, age.Hours()/conditionsHalfLife.Hours())
+	}
+
+	summary.Greens = weightedRating(reports, weights, ConditionsSurfaceRatings, func(r ConditionsReport) *string { return r.Greens })
+	summary.Fairways = weightedRating(reports, weights, ConditionsSurfaceRatings, func(r ConditionsReport) *string { return r.Fairways })
+	summary.Bunkers = weightedRating(reports, weights, ConditionsSurfaceRatings, func(r ConditionsReport) *string { return r.Bunkers })
+	summary.PaceOfPlay = weightedRating(reports, weights, ConditionsPaceRatings, func(r ConditionsReport) *string { return r.PaceOfPlay })
+	summary.CartPathOnly = weightedFlag(reports, weights, func(r ConditionsReport) bool { return r.CartPathOnly })
+	summary.GreensAerated = weightedFlag(reports, weights, func(r ConditionsReport) bool { return r.GreensAerated })
+	summary.FrostDelay = weightedFlag(reports, weights, func(r ConditionsReport) bool { return r.FrostDelay })
+
+	userIDs := make([]uint, 0, len(reports))
+	for _, report := range reports {
+		userIDs = append(userIDs, report.UserID)
+	}
+	names, err := (&ReviewCommentService{db: s.db}).authorNames(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, report := range reports {
+		summary.Reports = append(summary.Reports, ConditionsReportView{
+			ConditionsReport: report,
+			AuthorName:       names[report.UserID],
+			PostedAgo:        formatTimeAgo(now, report.CreatedAt),
+			CanDelete:        viewerID != nil && *viewerID == report.UserID,
+		})
+	}
+
+	return summary, nil
+}
+
+func (s *ConditionsReportService) buildReport(formData ConditionsReportFormData) (*ConditionsReport, error) {
+	report := &ConditionsReport{
+		CartPathOnly:  formData.CartPathOnly,
+		GreensAerated: formData.GreensAerated,
+		FrostDelay:    formData.FrostDelay,
+	}
+
+	var err error
+	if report.Greens, err = parseConditionsValue("greens", formData.Greens, ConditionsSurfaceRatings); err != nil {
+		return nil, err
+	}
+	if report.Fairways, err = parseConditionsValue("fairways", formData.Fairways, ConditionsSurfaceRatings); err != nil {
+		return nil, err
+	}
+	if report.Bunkers, err = parseConditionsValue("bunkers", formData.Bunkers, ConditionsSurfaceRatings); err != nil {
+		return nil, err
+	}
+	if report.PaceOfPlay, err = parseConditionsValue("pace of play", formData.PaceOfPlay, ConditionsPaceRatings); err != nil {
+		return nil, err
+	}
+
+	note := strings.TrimSpace(formData.Note)
+	if len(note) > maxConditionsNoteLength {
+		return nil, fmt.Errorf("%w: note must be %d characters or less", ErrInvalidConditionsReport, maxConditionsNoteLength)
+	}
+	if note != "" {
+		report.Note = &note
+	}
+
+	if report.Greens == nil && report.Fairways == nil && report.Bunkers == nil && report.PaceOfPlay == nil &&
+		!report.CartPathOnly && !report.GreensAerated && !report.FrostDelay && report.Note == nil {
+		return nil, fmt.Errorf("%w: fill in at least one condition", ErrInvalidConditionsReport)
+	}
+
+	lifetime := defaultConditionsLifetime
+	if formData.ValidHours < 0 {
+		return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidConditionsReport)
+	}
+	// Checked before converting, as a large hour count overflows a Duration
+	if formData.ValidHours > int(maxConditionsLifetime.Hours()) {
+		return nil, fmt.Errorf("%w: reports can last at most %d hours", ErrInvalidConditionsReport, int(maxConditionsLifetime.Hours()))
+	}
+	if formData.ValidHours > 0 {
+		lifetime = time.Duration(formData.ValidHours) * time.Hour
+	}
+	report.CreatedAt = s.now().Unix()
+	report.ExpiresAt = s.now().Add(lifetime).Unix()
+
+	return report, nil
+}
+
+func parseConditionsValue(field, value string, allowed []string) (*string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return nil, nil
+	}
+	for _, option := range allowed {
+		if option == value {
+			return &value, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s must be one of %s", ErrInvalidConditionsReport, field, strings.Join(allowed, ", "))
+}
+
+// weightedRating averages the position of each report's value on the scale and
+// returns the closest scale value, or "" when no report rated the field
+func weightedRating(reports []ConditionsReport, weights []float64, scale []string, value func(ConditionsReport) *string) string {
+	var total, weightSum float64
+	for i, report := range reports {
+		v := value(report)
+		if v == nil {
+			continue
+		}
+		for position, option := range scale {
+			if option == *v {
+				total += float64(position) * weights[i]
+				weightSum += weights[i]
+				break
+			}
+		}
+	}
+	if weightSum == 0 {
+		return ""
+	}
+	return scale[int(math.Round(total/weightSum))]
+}
+
+// weightedFlag reports whether reports carrying more than half of the total weight set the flag
+func weightedFlag(reports []ConditionsReport, weights []float64, flag func(ConditionsReport) bool) bool {
+	var set, weightSum float64
+	for i, report := range reports {
+		weightSum += weights[i]
+		if flag(report) {
+			set += weights[i]
+		}
+	}
+	return weightSum > 0 && set*2 > weightSum
+}
+
+func formatTimeAgo(now time.Time, unix int64) string {
+	age := now.Sub(time.Unix(unix, 0))
+	switch {
+	case age < time.Hour:
+		return "just now"
+	case age < 2*time.Hour:
+		return "1 hour ago"
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%d hours ago", int(age.Hours()))
+	case age < 48*time.Hour:
+		return "yesterday"
+	default:
+		return fmt.Sprintf("%d days ago", int(age.Hours()/24))
+	}
+}
+
+// ParseConditionsReportFormData parses the conditions report form from an HTTP request
+func ParseConditionsReportFormData(getFormValue func(string) string) ConditionsReportFormData {
+	validHours, err := strconv.Atoi(getFormValue("valid-hours"))
+	if err != nil {
+		validHours = 0
+	}
+
+	return ConditionsReportFormData{
+		Greens:        getFormValue("greens"),
+		Fairways:      getFormValue("fairways"),
+		Bunkers:       getFormValue("bunkers"),
+		PaceOfPlay:    getFormValue("pace-of-play"),
+		CartPathOnly:  getFormValue("cart-path-only") != "",
+		GreensAerated: getFormValue("greens-aerated") != "",
+		FrostDelay:    getFormValue("frost-delay") != "",
+		Note:          getFormValue("note"),
+		ValidHours:    validHours,
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conditionsServiceAt returns a service whose clock reads now
+func conditionsServiceAt(now time.Time) *ConditionsReportService {
+	service := NewConditionsReportService()
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestConditionsReportService_CreateAndValidate(t *testing.T) {
+	db := setupTestDatabase(t)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+	now := time.Date(2024, 4, 10, 8, 0, 0, 0, time.UTC)
+	service := conditionsServiceAt(now)
+
+	report, err := service.CreateReport(golfer.ID, course.ID, ConditionsReportFormData{
+		Greens:       " Good ",
+		PaceOfPlay:   "slow",
+		CartPathOnly: true,
+		Note:         "Wet after the storm",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, report.Greens)
+	assert.Equal(t, "good", *report.Greens)
+	assert.Nil(t, report.Fairways)
+	assert.Equal(t, now.Add(defaultConditionsLifetime).Unix(), report.ExpiresAt)
+
+	var activities int64
+	db.Model(&UserActivity{}).Where("activity_type = ? AND user_id = ?", "conditions_report", golfer.ID).Count(&activities)
+	assert.Equal(t, int64(1), activities)
+
+	_, err = service.CreateReport(golfer.ID, course.ID, ConditionsReportFormData{Greens: "soggy"})
+	assert.ErrorIs(t, err, ErrInvalidConditionsReport)
+
+	_, err = service.CreateReport(golfer.ID, course.ID, ConditionsReportFormData{})
+	assert.ErrorIs(t, err, ErrInvalidConditionsReport)
+
+	for _, hours := range []int{200, 2562048, math.MaxInt} {
+		_, err = service.CreateReport(golfer.ID, course.ID, ConditionsReportFormData{Greens: "good", ValidHours: hours})
+		assert.ErrorIs(t, err, ErrInvalidConditionsReport, "%d hours", hours)
+	}
+
+	_, err = service.CreateReport(golfer.ID, 9999, ConditionsReportFormData{Greens: "good"})
+	assert.ErrorIs(t, err, ErrCourseNotFound)
+}
+
+func TestConditionsReportService_RecencyWeightedSummary(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	start := time.Date(2024, 4, 10, 8, 0, 0, 0, time.UTC)
+
+	// Three days ago: two reports of excellent greens and good bunkers
+	old := conditionsServiceAt(start)
+	for _, userID := range []uint{owner.ID, reviewer.ID} {
+		_, err := old.CreateReport(userID, course.ID, ConditionsReportFormData{Greens: "excellent", Bunkers: "good", ValidHours: 168})
+		require.NoError(t, err)
+	}
+
+	// This morning: greens were aerated and carts are restricted
+	now := start.Add(72 * time.Hour)
+	recent := conditionsServiceAt(now.Add(-time.Hour))
+	_, err := recent.CreateReport(golfer.ID, course.ID, ConditionsReportFormData{Greens: "poor", CartPathOnly: true, GreensAerated: true})
+	require.NoError(t, err)
+
+	// A report that has already expired is ignored
+	expired := conditionsServiceAt(now.Add(-30 * time.Hour))
+	_, err = expired.CreateReport(golfer.ID, course.ID, ConditionsReportFormData{FrostDelay: true, ValidHours: 24})
+	require.NoError(t, err)
+
+	summary, err := conditionsServiceAt(now).GetCurrentConditions(course.ID, &golfer.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, summary.ReportCount)
+	// One fresh "poor" outweighs two three-day-old "excellent" reports, pulling the blend to "fair"
+	assert.Equal(t, "fair", summary.Greens)
+	assert.Equal(t, "good", summary.Bunkers, "only the older reports rated bunkers")
+	assert.Empty(t, summary.Fairways)
+	assert.True(t, summary.CartPathOnly)
+	assert.True(t, summary.GreensAerated)
+	assert.False(t, summary.FrostDelay)
+
+	require.Len(t, summary.Reports, 3)
+	assert.Equal(t, golfer.ID, summary.Reports[0].UserID, "newest first")
+	assert.True(t, summary.Reports[0].CanDelete)
+	assert.False(t, summary.Reports[1].CanDelete)
+	assert.Equal(t, "1 hour ago", summary.Reports[0].PostedAgo)
+	assert.Equal(t, "3 days ago", summary.Reports[1].PostedAgo)
+}
+
+func TestConditionsReportService_Delete(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewConditionsReportService()
+
+	report, err := service.CreateReport(golfer.ID, course.ID, ConditionsReportFormData{Fairways: "fair"})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, service.DeleteReport(owner.ID, report.ID), ErrConditionsReportForbidden)
+	require.NoError(t, service.DeleteReport(golfer.ID, report.ID))
+	assert.ErrorIs(t, service.DeleteReport(golfer.ID, report.ID), ErrConditionsReportNotFound)
+}
+
+func TestCourseConditionsTemplate(t *testing.T) {
+	db := setupTestDatabase(t)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+	service := NewConditionsReportService()
+
+	_, err := service.CreateReport(golfer.ID, course.ID, ParseConditionsReportFormData(func(key string) string {
+		return map[string]string{"greens": "excellent", "frost-delay": "yes", "note": "Frost until 9am", "valid-hours": "24"}[key]
+	}))
+	require.NoError(t, err)
+
+	conditions, err := service.GetCurrentConditions(course.ID, &golfer.ID)
+	require.NoError(t, err)
+
+	templates := NewTemplates("views")
+	var out bytes.Buffer
+	err = templates.templates.ExecuteTemplate(&out, "course-conditions", CourseConditionsData{
+		CourseIndex: 2,
+		Conditions:  conditions,
+		IsLoggedIn:  true,
+	})
+	require.NoError(t, err)
+
+	html := out.String()
+	assert.Contains(t, html, `<span class="attribute-chip amenity">Frost delay</span>`)
+	assert.Contains(t, html, "Frost until 9am")
+	assert.Contains(t, html, `hx-delete="/course/2/conditions/`)
+	assert.Contains(t, html, `hx-post="/course/2/conditions"`)
+}
@@ -11,15 +11,17 @@ import (
 
 // Config represents the complete application configuration
 type Config struct {
-	Environment string         `mapstructure:"environment"`
-	Server      ServerConfig   `mapstructure:"server"`
-	Database    DatabaseConfig `mapstructure:"database"`
-	Google      GoogleConfig   `mapstructure:"google"`
-	Security    SecurityConfig `mapstructure:"security"`
-	Mapbox      MapboxConfig   `mapstructure:"mapbox"`
-	Logging     LoggingConfig  `mapstructure:"logging"`
-	Paths       PathsConfig    `mapstructure:"paths"`
-	Cache       CacheConfig    `mapstructure:"cache"`
+	Environment string            `mapstructure:"environment"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	Google      GoogleConfig      `mapstructure:"google"`
+	Security    SecurityConfig    `mapstructure:"security"`
+	Mapbox      MapboxConfig      `mapstructure:"mapbox"`
+	Logging     LoggingConfig     `mapstructure:"logging"`
+	Paths       PathsConfig       `mapstructure:"paths"`
+	Cache       CacheConfig       `mapstructure:"cache"`
+	Email       EmailConfig       `mapstructure:"email"`
+	CourseEdits CourseEditsConfig `mapstructure:"course_edits"`
 }
 
 // ServerConfig contains server-related configuration
@@ -99,6 +101,25 @@ type CacheConfig struct {
 	MaxMemoryMB  int           `mapstructure:"max_memory_mb"`
 }
 
+// EmailConfig contains notification email configuration
+type EmailConfig struct {
+	Sender         string        `mapstructure:"sender"` // smtp, file, stdout or none
+	From           string        `mapstructure:"from"`
+	SMTPHost       string        `mapstructure:"smtp_host"`
+	SMTPPort       int           `mapstructure:"smtp_port"`
+	SMTPUsername   string        `mapstructure:"smtp_username"`
+	SMTPPassword   string        `mapstructure:"smtp_password"`
+	OutboxPath     string        `mapstructure:"outbox_path"`     // Where the file sender appends emails
+	DigestInterval time.Duration `mapstructure:"digest_interval"` // Zero turns digests off
+	BaseURL        string        `mapstructure:"base_url"`        // Site address used in email links
+}
+
+// CourseEditsConfig contains configuration for edits suggested to courses by
+// golfers who didn't add them
+type CourseEditsConfig struct {
+	EscalateAfter time.Duration `mapstructure:"escalate_after"` // Unanswered suggestions go to the admins after this long; zero never escalates
+}
+
 // LoadConfig loads configuration from environment variables with validation
 func LoadConfig() (*Config, error) {
 	config := &Config{
@@ -164,6 +185,20 @@ func LoadConfig() (*Config, error) {
 			DefaultTTL:   getDurationOrDefault("CACHE_DEFAULT_TTL", 30*time.Minute),
 			MaxMemoryMB:  getIntOrDefault("CACHE_MAX_MEMORY_MB", 100),
 		},
+		Email: EmailConfig{
+			Sender:         getEnvOrDefault("EMAIL_SENDER", "none"),
+			From:           getEnvOrDefault("EMAIL_FROM", ""),
+			SMTPHost:       getEnvOrDefault("SMTP_HOST", ""),
+			SMTPPort:       getIntOrDefault("SMTP_PORT", 587),
+			SMTPUsername:   getEnvOrDefault("SMTP_USERNAME", ""),
+			SMTPPassword:   getEnvOrDefault("SMTP_PASSWORD", ""),
+			OutboxPath:     getEnvOrDefault("EMAIL_OUTBOX_PATH", "logs/outbox.eml"),
+			DigestInterval: getDurationOrDefault("EMAIL_DIGEST_INTERVAL", 24*time.Hour),
+			BaseURL:        getEnvOrDefault("PUBLIC_BASE_URL", "http://localhost:8080"),
+		},
+		CourseEdits: CourseEditsConfig{
+			EscalateAfter: getDurationOrDefault("COURSE_EDIT_ESCALATE_AFTER", 7*24*time.Hour),
+		},
 	}
 
 	// Validate configuration
@@ -216,6 +251,26 @@ func (c *Config) Validate() error {
 		errors = append(errors, "database name cannot be empty")
 	}
 
+	// Validate email configuration; no sender means none
+	validSenders := []string{"smtp", "file", "stdout", "none"}
+	if c.Email.Sender != "" && !contains(validSenders, c.Email.Sender) {
+		errors = append(errors, fmt.Sprintf("invalid email sender '%s', must be one of: %s", c.Email.Sender, strings.Join(validSenders, ", ")))
+	}
+	if c.Email.Sender == "smtp" {
+		if c.Email.SMTPHost == "" {
+			errors = append(errors, "SMTP_HOST is required when EMAIL_SENDER is smtp")
+		}
+		if c.Email.From == "" {
+			errors = append(errors, "EMAIL_FROM is required when EMAIL_SENDER is smtp")
+		}
+	}
+	if c.Email.DigestInterval < 0 {
+		errors = append(errors, "email digest interval cannot be negative")
+	}
+	if c.CourseEdits.EscalateAfter < 0 {
+		errors = append(errors, "course edit escalation period cannot be negative")
+	}
+
 	// Validate paths exist (except in testing)
 	if c.Environment != "testing" {
 		pathChecks := map[string]string{
@@ -195,6 +195,37 @@ func TestConfigValidation(t *testing.T) {
 			t.Error("Config with empty required fields should fail validation")
 		}
 	})
+
+	t.Run("EmailSender", func(t *testing.T) {
+		config := &Config{
+			Environment: "testing",
+			Server: ServerConfig{
+				Port: "8080",
+			},
+			Database: DatabaseConfig{
+				Host: "localhost",
+				Name: "testdb",
+			},
+			Email: EmailConfig{
+				Sender: "pigeon",
+			},
+		}
+
+		if err := config.Validate(); err == nil {
+			t.Error("Unknown email sender should fail validation")
+		}
+
+		config.Email.Sender = "smtp"
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "SMTP_HOST") {
+			t.Errorf("SMTP sender without a host should fail validation, got %v", err)
+		}
+
+		config.Email.SMTPHost = "smtp.example.com"
+		config.Email.From = "Course Management <noreply@example.com>"
+		if err := config.Validate(); err != nil {
+			t.Errorf("Complete SMTP config should pass validation: %v", err)
+		}
+	})
 }
 
 func TestConfigHelperMethods(t *testing.T) {
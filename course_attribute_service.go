@@ -0,0 +1,326 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"course_management/api"
+
+	"gorm.io/gorm"
+)
+
+// Course attribute kinds
+const (
+	AttributeKindTag     = "tag"
+	AttributeKindAmenity = "amenity"
+)
+
+var ErrUnknownCourseAttribute = errors.New("unknown tag or amenity")
+
+// CourseAttributeService stores reviewers' tag and amenity answers and keeps the
+// course-level consensus in course_attributes up to date.
+//
+// A tag applies to a course when more than half of the reviewers who filled in
+// the structured section picked it. An amenity applies when more reviewers
+// said yes than no; reviewers who skipped the question don't count either way.
+type CourseAttributeService struct {
+	db *gorm.DB
+}
+
+func NewCourseAttributeService() *CourseAttributeService {
+	return &CourseAttributeService{
+		db: GetDB(),
+	}
+}
+
+// SaveReviewAttributes replaces the tags and amenity answers attached to a review
+// and refreshes the course consensus
+func (s *CourseAttributeService) SaveReviewAttributes(reviewID, courseID uint, tags []string, amenityVotes map[string]bool) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	var votes []ReviewAttributeVote
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		if !api.IsReviewTag(tag) {
+			return fmt.Errorf("%w: %s", ErrUnknownCourseAttribute, tag)
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		votes = append(votes, ReviewAttributeVote{ReviewID: reviewID, CourseID: courseID, Kind: AttributeKindTag, Slug: tag, Value: true})
+	}
+	for amenity, available := range amenityVotes {
+		if !api.IsAmenity(amenity) {
+			return fmt.Errorf("%w: %s", ErrUnknownCourseAttribute, amenity)
+		}
+		votes = append(votes, ReviewAttributeVote{ReviewID: reviewID, CourseID: courseID, Kind: AttributeKindAmenity, Slug: amenity, Value: available})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("review_id = ?", reviewID).Delete(&ReviewAttributeVote{}).Error; err != nil {
+			return err
+		}
+		if len(votes) == 0 {
+			return nil
+		}
+		return tx.Create(&votes).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save review attributes: %v", err)
+	}
+
+	return s.RecomputeCourseAttributes(courseID)
+}
+
+// GetReviewAttributes returns the tags and amenity answers saved with a review
+func (s *CourseAttributeService) GetReviewAttributes(reviewID uint) ([]string, map[string]bool, error) {
+	if s.db == nil {
+		return nil, nil, fmt.Errorf("database not connected")
+	}
+
+	var votes []ReviewAttributeVote
+	if err := s.db.Where("review_id = ?", reviewID).Find(&votes).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get review attributes: %v", err)
+	}
+
+	var tags []string
+	amenityVotes := make(map[string]bool)
+	for _, vote := range votes {
+		switch vote.Kind {
+		case AttributeKindTag:
+			tags = append(tags, vote.Slug)
+		case AttributeKindAmenity:
+			amenityVotes[vote.Slug] = vote.Value
+		}
+	}
+	return tags, amenityVotes, nil
+}
+
+// DeleteReviewAttributes removes a review's answers and refreshes the course consensus
+func (s *CourseAttributeService) DeleteReviewAttributes(reviewID, courseID uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	if err := s.db.Where("review_id = ?", reviewID).Delete(&ReviewAttributeVote{}).Error; err != nil {
+		return fmt.Errorf("failed to delete review attributes: %v", err)
+	}
+	return s.RecomputeCourseAttributes(courseID)
+}
+
+// RecomputeCourseAttributes rebuilds the majority-vote attributes for a course
+func (s *CourseAttributeService) RecomputeCourseAttributes(courseID uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	// Private notes don't count towards the consensus
+	privateReviews := s.db.Model(&CourseReview{}).Select("id").
+		Where("course_id = ? AND visibility = ?", courseID, ReviewVisibilityPrivate)
+
+	var votes []ReviewAttributeVote
+	if err := s.db.Where("course_id = ? AND review_id NOT IN (?)", courseID, privateReviews).Find(&votes).Error; err != nil {
+		return fmt.Errorf("failed to load attribute votes: %v", err)
+	}
+
+	reviewers := make(map[uint]bool)
+	tagVotes := make(map[string]int)
+	amenityYes := make(map[string]int)
+	amenityAnswers := make(map[string]int)
+	for _, vote := range votes {
+		reviewers[vote.ReviewID] = true
+		switch vote.Kind {
+		case AttributeKindTag:
+			tagVotes[vote.Slug]++
+		case AttributeKindAmenity:
+			amenityAnswers[vote.Slug]++
+			if vote.Value {
+				amenityYes[vote.Slug]++
+			}
+		}
+	}
+
+	var attributes []CourseAttribute
+	for slug, count := range tagVotes {
+		if count*2 > len(reviewers) {
+			attributes = append(attributes, CourseAttribute{CourseID: courseID, Kind: AttributeKindTag, Slug: slug, Votes: count, Voters: len(reviewers)})
+		}
+	}
+	for slug, answers := range amenityAnswers {
+		if amenityYes[slug]*2 > answers {
+			attributes = append(attributes, CourseAttribute{CourseID: courseID, Kind: AttributeKindAmenity, Slug: slug, Votes: amenityYes[slug], Voters: answers})
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("course_id = ?", courseID).Delete(&CourseAttribute{}).Error; err != nil {
+			return err
+		}
+		if len(attributes) == 0 {
+			return nil
+		}
+		return tx.Create(&attributes).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update course attributes: %v", err)
+	}
+
+	log.Printf("✅ Recomputed %d attributes for course %d from %d reviews", len(attributes), courseID, len(reviewers))
+	return nil
+}
+
+// GetCourseAttributes returns the agreed tags and amenities for a course in taxonomy order
+func (s *CourseAttributeService) GetCourseAttributes(courseID uint) (*CourseAttributeSummary, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var attributes []CourseAttribute
+	if err := s.db.Where("course_id = ?", courseID).Find(&attributes).Error; err != nil {
+		return nil, fmt.Errorf("failed to get course attributes: %v", err)
+	}
+	return summarizeCourseAttributes(courseID, attributes), nil
+}
+
+// ListCourseAttributes is GetCourseAttributes for many courses in one query.
+// Courses without attributes get an empty summary.
+func (s *CourseAttributeService) ListCourseAttributes(courseIDs []uint) (map[uint]*CourseAttributeSummary, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var attributes []CourseAttribute
+	if len(courseIDs) > 0 {
+		if err := s.db.Where("course_id IN ?", courseIDs).Find(&attributes).Error; err != nil {
+			return nil, fmt.Errorf("failed to get course attributes: %v", err)
+		}
+	}
+
+	byCourse := make(map[uint][]CourseAttribute)
+	for _, attribute := range attributes {
+		byCourse[attribute.CourseID] = append(byCourse[attribute.CourseID], attribute)
+	}
+	summaries := make(map[uint]*CourseAttributeSummary, len(courseIDs))
+	for _, courseID := range courseIDs {
+		summaries[courseID] = summarizeCourseAttributes(courseID, byCourse[courseID])
+	}
+	return summaries, nil
+}
+
+// summarizeCourseAttributes orders a course's attributes by the taxonomy
+func summarizeCourseAttributes(courseID uint, attributes []CourseAttribute) *CourseAttributeSummary {
+	byKey := make(map[string]CourseAttribute)
+	for _, attribute := range attributes {
+		byKey[attribute.Kind+":"+attribute.Slug] = attribute
+	}
+
+	summary := &CourseAttributeSummary{CourseID: courseID}
+	for _, option := range api.ReviewTagOptions {
+		if attribute, ok := byKey[AttributeKindTag+":"+option.Slug]; ok {
+			summary.Tags = append(summary.Tags, CourseAttributeLabel{Slug: option.Slug, Label: option.Label, Votes: attribute.Votes, Voters: attribute.Voters})
+		}
+	}
+	for _, option := range api.AmenityOptions {
+		if attribute, ok := byKey[AttributeKindAmenity+":"+option.Slug]; ok {
+			summary.Amenities = append(summary.Amenities, CourseAttributeLabel{Slug: option.Slug, Label: option.Label, Votes: attribute.Votes, Voters: attribute.Voters})
+		}
+	}
+	return summary
+}
+
+// FindCourseIDsWithAttributes returns the IDs of courses that carry every given tag and amenity
+func (s *CourseAttributeService) FindCourseIDsWithAttributes(tags, amenities []string) ([]uint, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	query := s.db.Model(&CourseAttribute{})
+	switch {
+	case len(tags) > 0 && len(amenities) > 0:
+		query = query.Where("(kind = ? AND slug IN ?) OR (kind = ? AND slug IN ?)", AttributeKindTag, tags, AttributeKindAmenity, amenities)
+	case len(tags) > 0:
+		query = query.Where("kind = ? AND slug IN ?", AttributeKindTag, tags)
+	case len(amenities) > 0:
+		query = query.Where("kind = ? AND slug IN ?", AttributeKindAmenity, amenities)
+	default:
+		return nil, nil
+	}
+
+	var courseIDs []uint
+	err := query.Group("course_id").
+		Having("COUNT(*) = ?", len(tags)+len(amenities)).
+		Pluck("course_id", &courseIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter courses by attributes: %v", err)
+	}
+
+	sort.Slice(courseIDs, func(i, j int) bool { return courseIDs[i] < courseIDs[j] })
+	return courseIDs, nil
+}
+
+// FindCourseKeysWithAttributes is FindCourseIDsWithAttributes keyed by course name and
+// address, for filtering the JSON course list whose IDs are array indexes
+func (s *CourseAttributeService) FindCourseKeysWithAttributes(tags, amenities []string) (map[string]bool, error) {
+	courseIDs, err := s.FindCourseIDsWithAttributes(tags, amenities)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool)
+	if len(courseIDs) == 0 {
+		return keys, nil
+	}
+
+	var courses []CourseDB
+	if err := s.db.Select("id", "name", "address").Where("id IN ?", courseIDs).Find(&courses).Error; err != nil {
+		return nil, fmt.Errorf("failed to load filtered courses: %v", err)
+	}
+	for _, course := range courses {
+		keys[courseAttributeKey(course.Name, course.Address)] = true
+	}
+	return keys, nil
+}
+
+func courseAttributeKey(name, address string) string {
+	return name + "|" + address
+}
+
+// ReviewAttributeField is a tag checkbox or amenity select on the review form
+type ReviewAttributeField struct {
+	Slug    string
+	Label   string
+	Checked bool   // Tag picked
+	Answer  string // Amenity answer: "yes", "no" or "" when unanswered
+}
+
+// BuildReviewAttributeFields lays out the review form inputs in taxonomy order,
+// pre-filled with a reviewer's previous answers
+func BuildReviewAttributeFields(tags []string, amenityVotes map[string]bool) ([]ReviewAttributeField, []ReviewAttributeField) {
+	picked := make(map[string]bool)
+	for _, tag := range tags {
+		picked[tag] = true
+	}
+
+	tagFields := make([]ReviewAttributeField, 0, len(api.ReviewTagOptions))
+	for _, option := range api.ReviewTagOptions {
+		tagFields = append(tagFields, ReviewAttributeField{Slug: option.Slug, Label: option.Label, Checked: picked[option.Slug]})
+	}
+
+	amenityFields := make([]ReviewAttributeField, 0, len(api.AmenityOptions))
+	for _, option := range api.AmenityOptions {
+		field := ReviewAttributeField{Slug: option.Slug, Label: option.Label}
+		if available, answered := amenityVotes[option.Slug]; answered {
+			field.Answer = "no"
+			if available {
+				field.Answer = "yes"
+			}
+		}
+		amenityFields = append(amenityFields, field)
+	}
+	return tagFields, amenityFields
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createAttributeReview(t *testing.T, db *gorm.DB, courseID uint, reviewer string, tags []string, amenities map[string]bool) uint {
+	t.Helper()
+
+	user := seedUser(t, db, reviewer, reviewer)
+
+	_, err := NewReviewService().CreateOrUpdateReview(user.ID, courseID, ReviewFormData{
+		OverallRating: "B",
+		Tags:          tags,
+		AmenityVotes:  amenities,
+	})
+	require.NoError(t, err)
+	return user.ID
+}
+
+func TestCourseAttributeService_MajorityVote(t *testing.T) {
+	db := setupTestDatabase(t)
+	course := seedCourse(t, db, nil)
+	service := NewCourseAttributeService()
+
+	// A review that skipped the structured section doesn't count
+	seedReview(t, db, course.ID, seedUser(t, db, "reviewer", "Reviewer").ID)
+	createAttributeReview(t, db, course.ID, "a", []string{"links-style", "tough-greens"}, map[string]bool{"range": true, "lockers": false})
+	createAttributeReview(t, db, course.ID, "b", []string{"links-style"}, map[string]bool{"range": true, "lockers": true})
+	thirdID := createAttributeReview(t, db, course.ID, "c", []string{"dog-friendly"}, map[string]bool{"range": false})
+
+	summary, err := service.GetCourseAttributes(course.ID)
+	require.NoError(t, err)
+
+	require.Len(t, summary.Tags, 1, "tough-greens and dog-friendly only have a third of the votes")
+	assert.Equal(t, "links-style", summary.Tags[0].Slug)
+	assert.Equal(t, "Links style", summary.Tags[0].Label)
+	assert.Equal(t, 2, summary.Tags[0].Votes)
+	assert.Equal(t, 3, summary.Tags[0].Voters)
+
+	// range is 2 yes to 1 no; lockers is tied so it doesn't apply
+	require.Len(t, summary.Amenities, 1)
+	assert.Equal(t, "range", summary.Amenities[0].Slug)
+
+	// Deleting a review drops its votes
+	require.NoError(t, NewReviewService().DeleteUserReview(thirdID, course.ID))
+	summary, err = service.GetCourseAttributes(course.ID)
+	require.NoError(t, err)
+	assert.Len(t, summary.Tags, 1)
+	require.Len(t, summary.Amenities, 1)
+	assert.Equal(t, 2, summary.Amenities[0].Voters)
+}
+
+func TestCourseAttributeService_EditingReviewReplacesVotes(t *testing.T) {
+	db := setupTestDatabase(t)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+	service := NewCourseAttributeService()
+
+	reviewService := NewReviewService()
+	_, err := reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{Tags: []string{"walking-friendly"}, AmenityVotes: map[string]bool{"carts": true}})
+	require.NoError(t, err)
+
+	review, err := reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{Tags: []string{"dog-friendly"}})
+	require.NoError(t, err)
+
+	tags, amenities, err := service.GetReviewAttributes(review.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dog-friendly"}, tags)
+	assert.Empty(t, amenities)
+
+	err = service.SaveReviewAttributes(review.ID, course.ID, []string{"haunted"}, nil)
+	assert.ErrorIs(t, err, ErrUnknownCourseAttribute)
+}
+
+func TestCourseAttributeService_FindCourses(t *testing.T) {
+	db := setupTestDatabase(t)
+	service := NewCourseAttributeService()
+
+	var courseIDs []uint
+	for i := 0; i < 3; i++ {
+		course := CourseDB{Name: fmt.Sprintf("Course %d", i), Address: "Somewhere", Hash: fmt.Sprintf("hash-%d", i), CourseData: "{}"}
+		require.NoError(t, db.Create(&course).Error)
+		courseIDs = append(courseIDs, course.ID)
+	}
+
+	createAttributeReview(t, db, courseIDs[0], "r0", []string{"links-style"}, map[string]bool{"range": true, "restaurant": true})
+	createAttributeReview(t, db, courseIDs[1], "r1", []string{"links-style"}, map[string]bool{"range": true})
+	createAttributeReview(t, db, courseIDs[2], "r2", nil, map[string]bool{"range": true, "restaurant": true})
+
+	matches, err := service.FindCourseIDsWithAttributes([]string{"links-style"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{courseIDs[0], courseIDs[1]}, matches)
+
+	matches, err = service.FindCourseIDsWithAttributes(nil, []string{"range", "restaurant"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{courseIDs[0], courseIDs[2]}, matches)
+
+	matches, err = service.FindCourseIDsWithAttributes([]string{"links-style"}, []string{"restaurant"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{courseIDs[0]}, matches)
+
+	keys, err := service.FindCourseKeysWithAttributes([]string{"links-style"}, []string{"restaurant"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{courseAttributeKey("Course 0", "Somewhere"): true}, keys)
+}
+
+func TestParseReviewFormData_Attributes(t *testing.T) {
+	form := map[string]string{
+		"tag-tough-greens":      "yes",
+		"amenity-range":         "yes",
+		"amenity-chipping-area": "no",
+		"amenity-lockers":       "",
+	}
+	data := ParseReviewFormData(func(key string) string { return form[key] })
+
+	assert.Equal(t, []string{"tough-greens"}, data.Tags)
+	assert.Equal(t, map[string]bool{"range": true, "chipping-area": false}, data.AmenityVotes)
+}
+
+func TestReviewCourseTemplate_AttributeFields(t *testing.T) {
+	tagFields, amenityFields := BuildReviewAttributeFields([]string{"links-style"}, map[string]bool{"carts": false})
+
+	templates := NewTemplates("views")
+	var out bytes.Buffer
+	err := templates.templates.ExecuteTemplate(&out, "review-course", map[string]interface{}{
+		"Course":        &CourseDB{ID: 1, Name: "Dunes"},
+		"TagFields":     tagFields,
+		"AmenityFields": amenityFields,
+	})
+	require.NoError(t, err)
+
+	html := out.String()
+	assert.Contains(t, html, `name="tag-links-style" value="yes" checked`)
+	assert.Contains(t, html, `name="amenity-carts"`)
+	assert.Contains(t, html, `<option value="no" selected>No</option>`)
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Course import actions
+const (
+	courseImportCreate    = "create"
+	courseImportUpdate    = "update"
+	courseImportUnchanged = "unchanged"
+	courseImportInvalid   = "invalid"
+	courseImportFailed    = "failed"
+)
+
+var ErrInvalidCourseRecords = errors.New("invalid course records")
+
+// courseExportKeys are the course_data keys the export builds from columns
+// and the relational ranking and hole rows; any other keys are copied through after them
+var courseExportKeys = map[string]bool{
+	"id": true, "name": true, "description": true, "address": true, "overallRating": true,
+	"ranks": true, "review": true, "holes": true, "scores": true, "latitude": true, "longitude": true,
+}
+
+// CourseImportOptions controls how records are written
+type CourseImportOptions struct {
+	// DryRun plans every record without writing anything
+	DryRun bool
+	// Transaction writes all records or none: one invalid record or failed
+	// write leaves the database as it was
+	Transaction bool
+	// CreatedBy is recorded on created courses and as the updater of others
+	CreatedBy *uint
+}
+
+// CourseImportResult is what happened, or would happen, to one record
+type CourseImportResult struct {
+	Source   string
+	Line     int
+	Name     string
+	Action   string
+	CourseID uint // The created or updated course; zero for a planned create
+	Errors   []courseRecordError
+}
+
+// CourseImportReport sums up an import
+type CourseImportReport struct {
+	Results   []CourseImportResult
+	DryRun    bool
+	Created   int
+	Updated   int
+	Unchanged int
+	Invalid   int
+	Failed    int
+	// Unlocated counts new courses without coordinates, which need geocoding
+	Unlocated int
+}
+
+func (r *CourseImportReport) count(action string, delta int) {
+	switch action {
+	case courseImportCreate:
+		r.Created += delta
+	case courseImportUpdate:
+		r.Updated += delta
+	case courseImportUnchanged:
+		r.Unchanged += delta
+	case courseImportInvalid:
+		r.Invalid += delta
+	case courseImportFailed:
+		r.Failed += delta
+	}
+}
+
+// CourseBulkService imports and exports courses in the courses/schema.json
+// format
+type CourseBulkService struct {
+	db *gorm.DB
+}
+
+func NewCourseBulkService() *CourseBulkService {
+	return &CourseBulkService{db: GetDB()}
+}
+
+// courseImportPlan is the write a valid record needs
+type courseImportPlan struct {
+	result    int // Index into the report's results
+	existing  *CourseDB
+	name      string
+	address   string
+	hash      string
+	data      string
+	course    Course
+	latitude  *float64
+	longitude *float64
+	unchanged bool
+}
+
+// Import upserts records by course hash. A record's fields replace the
+// matching keys of the existing course_data, so keys the file doesn't carry
+// are kept. Invalid records are reported and skipped, or stop the whole
+// import in a transaction.
+func (s *CourseBulkService) Import(records []courseRecord, options CourseImportOptions) (*CourseImportReport, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	report := &CourseImportReport{DryRun: options.DryRun}
+	var plans []*courseImportPlan
+	seen := make(map[string]int)
+	for _, record := range records {
+		result := CourseImportResult{Source: record.Source, Line: record.Line, Errors: record.Violations}
+		if name := record.Value.field("name"); name != nil && name.Kind == jsonString {
+			result.Name = name.Text
+		}
+
+		var plan *courseImportPlan
+		if len(result.Errors) == 0 {
+			var problem string
+			var err error
+			plan, problem, err = s.plan(record)
+			if err != nil {
+				return nil, err
+			}
+			if problem == "" {
+				if first, ok := seen[plan.hash]; ok {
+					problem = fmt.Sprintf("same course as %s:%d", report.Results[first].Source, report.Results[first].Line)
+				}
+			}
+			if problem != "" {
+				result.Errors = []courseRecordError{{Source: record.Source, Line: record.Line, Column: record.Value.Column, Message: problem}}
+				plan = nil
+			}
+		}
+
+		switch {
+		case plan == nil:
+			result.Action = courseImportInvalid
+		case plan.existing == nil:
+			result.Action = courseImportCreate
+			if plan.latitude == nil {
+				report.Unlocated++
+			}
+		default:
+			result.CourseID = plan.existing.ID
+			result.Action = courseImportUpdate
+			if plan.unchanged {
+				result.Action = courseImportUnchanged
+			}
+		}
+		if plan != nil {
+			seen[plan.hash] = len(report.Results)
+			plan.result = len(report.Results)
+			if result.Action != courseImportUnchanged {
+				plans = append(plans, plan)
+			}
+		}
+		report.count(result.Action, 1)
+		report.Results = append(report.Results, result)
+	}
+
+	if options.Transaction && report.Invalid > 0 {
+		return report, ErrInvalidCourseRecords
+	}
+	if options.DryRun || len(plans) == 0 {
+		return report, nil
+	}
+
+	if options.Transaction {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			for _, plan := range plans {
+				if err := s.write(tx, plan, options.CreatedBy, report); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			// Nothing was kept, so the planned counts stand but no IDs do
+			for _, plan := range plans {
+				if report.Results[plan.result].Action == courseImportCreate {
+					report.Results[plan.result].CourseID = 0
+				}
+			}
+			return report, err
+		}
+	} else {
+		for _, plan := range plans {
+			err := s.db.Transaction(func(tx *gorm.DB) error {
+				return s.write(tx, plan, options.CreatedBy, report)
+			})
+			if err != nil {
+				result := &report.Results[plan.result]
+				report.count(result.Action, -1)
+				report.count(courseImportFailed, 1)
+				result.Action, result.CourseID = courseImportFailed, 0
+				result.Errors = []courseRecordError{{Source: result.Source, Line: result.Line, Message: err.Error()}}
+			}
+		}
+	}
+
+	invalidateCourseTiles(s.db)
+	invalidateCourseClusters(s.db)
+	log.Printf("[COURSECTL] Imported courses: %d created, %d updated, %d unchanged, %d invalid, %d failed",
+		report.Created, report.Updated, report.Unchanged, report.Invalid, report.Failed)
+	return report, nil
+}
+
+// plan works out a record's write. A problem is a reason the record can't be
+// imported; an error is a database failure.
+func (s *CourseBulkService) plan(record courseRecord) (*courseImportPlan, string, error) {
+	plan := &courseImportPlan{}
+	if name := record.Value.field("name"); name != nil {
+		plan.name = strings.TrimSpace(name.Text)
+	}
+	if address := record.Value.field("address"); address != nil {
+		plan.address = strings.TrimSpace(address.Text)
+	}
+	if plan.name == "" {
+		return nil, "the course name is empty", nil
+	}
+	plan.hash = GenerateCourseHash(plan.name, plan.address)
+
+	var existing CourseDB
+	err := s.db.Where("hash = ?", plan.hash).First(&existing).Error
+	switch {
+	case err == nil:
+		plan.existing = &existing
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, "", fmt.Errorf("failed to look up course: %v", err)
+	default:
+		// A merged duplicate would come back as a new course
+		var redirect CourseRedirect
+		err := s.db.Where("from_hash = ?", plan.hash).First(&redirect).Error
+		if err == nil {
+			return nil, fmt.Sprintf("this course was merged into course %d", redirect.ToCourseID), nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", fmt.Errorf("failed to look up course redirect: %v", err)
+		}
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if plan.existing != nil {
+		// Malformed stored data is replaced by the record
+		_ = json.Unmarshal([]byte(plan.existing.CourseData), &fields)
+		if location := courseLocation(plan.existing); location != nil {
+			fields["latitude"], _ = json.Marshal(location.Latitude)
+			fields["longitude"], _ = json.Marshal(location.Longitude)
+		}
+	}
+	for _, field := range record.Value.Fields {
+		if field.Key == "id" {
+			continue
+		}
+		encoded, err := field.Value.MarshalJSON()
+		if err != nil {
+			return nil, "", err
+		}
+		fields[field.Key] = encoded
+	}
+	fields["name"], _ = json.Marshal(plan.name)
+	fields["address"], _ = json.Marshal(plan.address)
+
+	latitude, longitude := record.Value.field("latitude"), record.Value.field("longitude")
+	if latitude != nil && longitude != nil && latitude.Kind == jsonNumber && longitude.Kind == jsonNumber {
+		lat, latErr := strconv.ParseFloat(latitude.Text, 64)
+		lng, lngErr := strconv.ParseFloat(longitude.Text, 64)
+		if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			return nil, "latitude and longitude must be a position on the map", nil
+		}
+		plan.latitude, plan.longitude = &lat, &lng
+	}
+
+	data, err := canonicalCourseData(fields)
+	if err != nil {
+		return nil, fmt.Sprintf("the course data can't be stored: %v", err), nil
+	}
+	if err := json.Unmarshal([]byte(data), &plan.course); err != nil {
+		return nil, fmt.Sprintf("the course data can't be read: %v", err), nil
+	}
+	plan.data = data
+
+	// Compare like with like: the stored data as it would be written now
+	if plan.existing != nil && plan.name == plan.existing.Name && plan.address == plan.existing.Address {
+		stored := make(map[string]json.RawMessage)
+		if json.Unmarshal([]byte(plan.existing.CourseData), &stored) == nil {
+			if location := courseLocation(plan.existing); location != nil {
+				stored["latitude"], _ = json.Marshal(location.Latitude)
+				stored["longitude"], _ = json.Marshal(location.Longitude)
+			}
+			canonical, err := canonicalCourseData(stored)
+			plan.unchanged = err == nil && canonical == data
+		}
+	}
+	return plan, "", nil
+}
+
+// canonicalCourseData encodes course data with sorted keys and no spacing,
+// so equal data compares equal
+func canonicalCourseData(fields map[string]json.RawMessage) (string, error) {
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	var value interface{}
+	if err := json.Unmarshal(encoded, &value); err != nil {
+		return "", err
+	}
+	encoded, err = json.Marshal(value)
+	return string(encoded), err
+}
+
+func (s *CourseBulkService) write(tx *gorm.DB, plan *courseImportPlan, userID *uint, report *CourseImportReport) error {
+	var row CourseDB
+	if plan.existing != nil {
+		row = *plan.existing
+		if userID != nil {
+			row.UpdatedBy = userID
+		}
+	} else {
+		row = CourseDB{CreatedBy: userID, UpdatedBy: userID}
+	}
+	row.Name, row.Address, row.Hash, row.CourseData = plan.name, plan.address, plan.hash, plan.data
+	if plan.latitude != nil {
+		row.Latitude, row.Longitude = plan.latitude, plan.longitude
+	}
+
+	if err := tx.Save(&row).Error; err != nil {
+		return fmt.Errorf("failed to save %s: %v", plan.name, err)
+	}
+	if err := (&CourseFacetService{db: tx}).SyncCourse(row.ID, plan.course); err != nil {
+		return err
+	}
+	report.Results[plan.result].CourseID = row.ID
+	return nil
+}
+
+// courseExportRecord is a course in the schema's layout, in its key order
+type courseExportRecord struct {
+	ID            uint     `json:"id"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	Address       string   `json:"address"`
+	OverallRating string   `json:"overallRating"`
+	Ranks         Ranking  `json:"ranks"`
+	Review        string   `json:"review"`
+	Holes         []Hole   `json:"holes"`
+	Scores        []Score  `json:"scores"`
+	Latitude      *float64 `json:"latitude,omitempty"`
+	Longitude     *float64 `json:"longitude,omitempty"`
+}
+
+// Export returns courses in the schema format, built from the course rows and
+// their ranking and hole rows. Text fields the relational model doesn't hold
+// come from course_data, along with any extra keys it has. All courses are
+// exported when courseIDs is empty.
+func (s *CourseBulkService) Export(courseIDs []uint) ([]*jsonValue, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	query := s.db.Order("id")
+	if len(courseIDs) > 0 {
+		query = query.Where("id IN ?", courseIDs)
+	}
+	var courses []CourseDB
+	if err := query.Find(&courses).Error; err != nil {
+		return nil, fmt.Errorf("failed to load courses: %v", err)
+	}
+	if len(courses) == 0 {
+		return []*jsonValue{}, nil
+	}
+	ids := make([]uint, len(courses))
+	for i, course := range courses {
+		ids[i] = course.ID
+	}
+
+	var rankings []CourseRanking
+	if err := s.db.Where("course_id IN ?", ids).Find(&rankings).Error; err != nil {
+		return nil, fmt.Errorf("failed to load rankings: %v", err)
+	}
+	rankingByCourse := make(map[uint]CourseRanking, len(rankings))
+	for _, ranking := range rankings {
+		rankingByCourse[ranking.CourseID] = ranking
+	}
+
+	var holes []CourseHole
+	if err := s.db.Where("course_id IN ?", ids).Order("hole_number").Find(&holes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load holes: %v", err)
+	}
+	holesByCourse := make(map[uint][]Hole)
+	for _, hole := range holes {
+		holesByCourse[hole.CourseID] = append(holesByCourse[hole.CourseID], Hole{
+			Number: hole.HoleNumber, Par: safeIntValue(hole.Par), Yardage: safeIntValue(hole.Yardage), Description: hole.Description,
+		})
+	}
+
+	exported := make([]*jsonValue, 0, len(courses))
+	for _, course := range courses {
+		var data Course
+		stored, err := parseJSONDocument([]byte(course.CourseData), 1)
+		if err == nil && stored.Kind == jsonObject {
+			_ = json.Unmarshal([]byte(course.CourseData), &data)
+		} else {
+			stored = &jsonValue{Kind: jsonObject}
+		}
+
+		record := courseExportRecord{
+			ID:            course.ID,
+			Name:          course.Name,
+			Description:   data.Description,
+			Address:       course.Address,
+			OverallRating: data.OverallRating,
+			Ranks:         data.Ranks,
+			Review:        data.Review,
+			Holes:         data.Holes,
+			Scores:        data.Scores,
+			Latitude:      course.Latitude,
+			Longitude:     course.Longitude,
+		}
+		// The relational rows are only trusted once they've been synced
+		if ranking, ok := rankingByCourse[course.ID]; ok {
+			record.Ranks = rankingFromFacet(ranking)
+			record.Holes = holesByCourse[course.ID]
+		}
+		if record.Holes == nil {
+			record.Holes = []Hole{}
+		}
+		if record.Scores == nil {
+			record.Scores = []Score{}
+		}
+
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode course %d: %v", course.ID, err)
+		}
+		value, err := parseJSONDocument(encoded, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode course %d: %v", course.ID, err)
+		}
+		for _, field := range stored.Fields {
+			if !courseExportKeys[field.Key] && strings.ToLower(field.Key) != "id" {
+				value.Fields = append(value.Fields, field)
+			}
+		}
+		exported = append(exported, value)
+	}
+	return exported, nil
+}
+
+func rankingFromFacet(ranking CourseRanking) Ranking {
+	grade := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return *value
+	}
+	difficulty := func(value *int) int {
+		if value == nil {
+			return 0
+		}
+		return *value
+	}
+	return Ranking{
+		Price:              ranking.Price,
+		HandicapDifficulty: difficulty(ranking.HandicapDifficulty),
+		HazardDifficulty:   difficulty(ranking.HazardDifficulty),
+		Merch:              grade(ranking.Merch),
+		Condition:          grade(ranking.Condition),
+		EnjoymentRating:    grade(ranking.EnjoymentRating),
+		Vibe:               grade(ranking.Vibe),
+		Range:              grade(ranking.RangeRating),
+		Amenities:          grade(ranking.Amenities),
+		Glizzies:           grade(ranking.Glizzies),
+		Walkability:        grade(ranking.Walkability),
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestCourseRecords(t *testing.T, lines ...string) []courseRecord {
+	t.Helper()
+	schema, err := loadJSONSchema(defaultCourseSchema)
+	require.NoError(t, err)
+	records, fileErr := readCourseRecords(writeCourseFile(t, "courses.ndjson", strings.Join(lines, "\n")), "", schema)
+	require.Nil(t, fileErr)
+	return records
+}
+
+func importActions(report *CourseImportReport) []string {
+	actions := make([]string, len(report.Results))
+	for i, result := range report.Results {
+		actions[i] = result.Action
+	}
+	return actions
+}
+
+func TestCourseBulkService_Import(t *testing.T) {
+	db := setupTestDatabase(t)
+	service := NewCourseBulkService()
+
+	existing := CourseDB{Name: "Mid Pines", Address: "Southern Pines, NC", CourseData: `{"name": "Mid Pines", "address": "Southern Pines, NC", "website": "https://midpines.example", "description": "Old"}`}
+	require.NoError(t, db.Create(&existing).Error)
+
+	updated := `{"name": "Mid Pines", "address": "Southern Pines, NC", "description": "Ross classic", "overallRating": "A", "ranks": {"price": "$$$", "handicapDifficulty": 9, "hazardDifficulty": 2, "condition": "A", "merch": "B", "enjoymentRating": "A", "vibe": "S", "range": "B", "amenities": "B", "glizzies": "C"}, "latitude": 35.1618, "longitude": -79.4379}`
+	invalid := strings.Replace(testCourseJSON, `"Sandhills Links"`, `"Broken"`, 1)
+	invalid = strings.Replace(invalid, `"overallRating": "A"`, `"overallRating": "Z"`, 1)
+	records := readTestCourseRecords(t, testCourseJSON, updated, invalid, testCourseJSON)
+
+	t.Run("dry run plans without writing", func(t *testing.T) {
+		report, err := service.Import(records, CourseImportOptions{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{courseImportCreate, courseImportUpdate, courseImportInvalid, courseImportInvalid}, importActions(report))
+		assert.Equal(t, "same course as "+records[0].Source+":1", report.Results[3].Errors[0].Message)
+		assert.Equal(t, existing.ID, report.Results[1].CourseID)
+		assert.Equal(t, 1, report.Unlocated)
+
+		var count int64
+		db.Model(&CourseDB{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("a transaction stops on an invalid record", func(t *testing.T) {
+		report, err := service.Import(records, CourseImportOptions{Transaction: true})
+		assert.ErrorIs(t, err, ErrInvalidCourseRecords)
+		assert.Equal(t, 2, report.Invalid)
+
+		var count int64
+		db.Model(&CourseDB{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("valid records are upserted by hash", func(t *testing.T) {
+		user := seedUser(t, db, "importer", "Importer")
+
+		report, err := service.Import(records, CourseImportOptions{CreatedBy: &user.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 1, 0, 2, 0}, []int{report.Created, report.Updated, report.Unchanged, report.Invalid, report.Failed})
+
+		var created CourseDB
+		require.NoError(t, db.First(&created, report.Results[0].CourseID).Error)
+		assert.Equal(t, "Sandhills Links", created.Name)
+		assert.Equal(t, GenerateCourseHash("Sandhills Links", "100 Links Drive, Pinehurst, NC"), created.Hash)
+		assert.Equal(t, user.ID, *created.CreatedBy)
+		var holes []CourseHole
+		require.NoError(t, db.Where("course_id = ?", created.ID).Order("hole_number").Find(&holes).Error)
+		require.Len(t, holes, 2)
+		assert.Equal(t, intPtr(165), holes[1].Yardage)
+
+		var merged CourseDB
+		require.NoError(t, db.First(&merged, existing.ID).Error)
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(merged.CourseData), &data))
+		assert.Equal(t, "Ross classic", data["description"])
+		assert.Equal(t, "https://midpines.example", data["website"], "keys the file doesn't carry are kept")
+		assert.Equal(t, user.ID, *merged.UpdatedBy)
+		require.NotNil(t, merged.Latitude)
+		assert.InDelta(t, 35.1618, *merged.Latitude, 1e-9)
+
+		// Importing the same file again changes nothing
+		report, err = service.Import(records[:2], CourseImportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{courseImportUnchanged, courseImportUnchanged}, importActions(report))
+	})
+
+	t.Run("a failed write rolls back the transaction", func(t *testing.T) {
+		renamed := readTestCourseRecords(t,
+			strings.Replace(testCourseJSON, `"Sandhills Links"`, `"Sandhills Dunes"`, 1),
+			strings.Replace(updated, `"Ross classic"`, `"Donald Ross classic"`, 1),
+		)
+		require.NoError(t, db.Migrator().DropTable(&CourseHole{}))
+		t.Cleanup(func() { require.NoError(t, db.AutoMigrate(&CourseHole{})) })
+
+		report, err := service.Import(renamed, CourseImportOptions{Transaction: true})
+		require.Error(t, err)
+		assert.Equal(t, uint(0), report.Results[0].CourseID)
+		var count int64
+		db.Model(&CourseDB{}).Where("name = ?", "Sandhills Dunes").Count(&count)
+		assert.Equal(t, int64(0), count)
+		var merged CourseDB
+		require.NoError(t, db.First(&merged, existing.ID).Error)
+		assert.NotContains(t, merged.CourseData, "Donald Ross")
+
+		// Without a transaction each record stands alone
+		report, err = service.Import(renamed, CourseImportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Failed)
+		assert.Contains(t, report.Results[0].Errors[0].Message, "course_holes")
+	})
+}
+
+func TestCourseBulkService_Export(t *testing.T) {
+	db := setupTestDatabase(t)
+	service := NewCourseBulkService()
+
+	report, err := service.Import(readTestCourseRecords(t, testCourseJSON), CourseImportOptions{})
+	require.NoError(t, err)
+	courseID := report.Results[0].CourseID
+	legacy := CourseDB{Name: "Legacy", Address: "Somewhere", CourseData: `{"name": "Legacy", "description": "From JSON", "holes": [{"number": 1, "par": 5, "yardage": 510}], "ID": 7, "designer": "Ross"}`}
+	require.NoError(t, db.Create(&legacy).Error)
+
+	// The hole rows are what's exported once a course is synced
+	require.NoError(t, db.Model(&CourseHole{}).Where("course_id = ? AND hole_number = ?", courseID, 2).Update("yardage", 170).Error)
+
+	courses, err := service.Export(nil)
+	require.NoError(t, err)
+	require.Len(t, courses, 2)
+
+	encoded, err := courses[0].field("holes").MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"number": 1, "par": 4, "yardage": 401, "description": ""}, {"number": 2, "par": 3, "yardage": 170, "description": "Over water"}]`, string(encoded))
+	assert.Equal(t, "12", courses[0].field("ranks").field("handicapDifficulty").Text)
+
+	encoded, err = courses[1].MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, fmt.Sprintf(`{"id": %d, "name": "Legacy", "description": "From JSON", "address": "Somewhere", "overallRating": "",
+		"ranks": {"price": "", "handicapDifficulty": 0, "hazardDifficulty": 0, "merch": "", "condition": "", "enjoymentRating": "", "vibe": "", "range": "", "amenities": "", "glizzies": "", "walkability": ""},
+		"review": "", "holes": [{"number": 1, "par": 5, "yardage": 510, "description": ""}], "scores": [], "designer": "Ross"}`, legacy.ID), string(encoded))
+
+	only, err := service.Export([]uint{legacy.ID})
+	require.NoError(t, err)
+	require.Len(t, only, 1)
+	assert.Equal(t, "Legacy", only[0].field("name").Text)
+
+	// An export imports back as unchanged
+	var out strings.Builder
+	require.NoError(t, writeCourseRecords(&out, courseFormatNDJSON, courses[:1]))
+	report, err = service.Import(readTestCourseRecords(t, strings.TrimSpace(out.String())), CourseImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, courseImportUpdate, report.Results[0].Action, "the edited hole row is written back to course_data")
+	report, err = service.Import(readTestCourseRecords(t, strings.TrimSpace(out.String())), CourseImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, courseImportUnchanged, report.Results[0].Action)
+}
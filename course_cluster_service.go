@@ -0,0 +1,420 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxClusterZoom is the deepest zoom level clusters are built for
+	MaxClusterZoom = 20
+	// clusterCellsPerTile splits each 256px map tile into 64px cells; courses
+	// sharing a cell are one marker at that zoom
+	clusterCellsPerTile = 4
+	// clusterRecheckInterval is how often the cached clusters are compared with
+	// the database, which catches courses geocoded by other processes
+	clusterRecheckInterval = time.Minute
+	// maxMercatorLatitude is as far north or south as web map tiles reach
+	maxMercatorLatitude = 85.05112878
+)
+
+var ErrCourseClusterNotFound = errors.New("course cluster not found")
+
+// courseClusterCaches holds, per connection, the precomputed clusters
+var courseClusterCaches sync.Map
+
+// CourseClusterService groups located courses into map markers. Each zoom
+// level lays a grid over the Web Mercator map; a cell at one zoom splits into
+// four at the next, so a cluster's ID names its cell and expanding it only
+// needs the courses inside. Clusters for every zoom are built once and kept
+// by zoom and tile until a course is geocoded, moved or removed.
+type CourseClusterService struct {
+	db *gorm.DB
+}
+
+func NewCourseClusterService() *CourseClusterService {
+	return &CourseClusterService{
+		db: GetDB(),
+	}
+}
+
+// CourseCluster is a map marker standing for one or more courses
+type CourseCluster struct {
+	ID        string
+	Zoom      int
+	Latitude  float64
+	Longitude float64
+	CourseIDs []uint
+	cell      clusterCell
+}
+
+// CourseClusterExpansion is what a cluster breaks into: the clusters at the
+// first zoom where its courses no longer share one marker
+type CourseClusterExpansion struct {
+	ClusterID     string
+	ExpansionZoom int
+	Clusters      []CourseCluster
+}
+
+type clusterCell struct {
+	zoom int
+	x, y int
+}
+
+type clusterTile struct {
+	x, y int
+}
+
+type clusterPoint struct {
+	courseID uint
+	lat, lng float64
+	x, y     float64 // Web Mercator position, 0 to 1 across the world
+}
+
+// courseClusterLevels is one build of the clusters. It isn't changed once
+// built; invalidation swaps in a new one.
+type courseClusterLevels struct {
+	points map[uint]clusterPoint
+	zooms  [MaxClusterZoom + 1]map[clusterTile][]*CourseCluster
+}
+
+type courseClusterCache struct {
+	mu        sync.Mutex
+	levels    *courseClusterLevels
+	signature courseLocationSignature
+	checkedAt time.Time
+}
+
+// courseLocationSignature changes whenever a located course is added,
+// removed or saved
+type courseLocationSignature struct {
+	Located     int64
+	LastUpdated int64
+}
+
+// Clusters returns the clusters at a zoom level whose cells overlap the
+// bounds. courseIDs limits which courses are counted; nil means every course.
+func (s *CourseClusterService) Clusters(bounds GeoBounds, zoom int, courseIDs []uint) ([]CourseCluster, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+	if zoom < 0 || zoom > MaxClusterZoom {
+		return nil, fmt.Errorf("zoom must be between 0 and %d", MaxClusterZoom)
+	}
+
+	levels, err := s.levels()
+	if err != nil {
+		return nil, err
+	}
+
+	minX, minY := mercatorCell(bounds.North, bounds.West, zoom)
+	maxX, maxY := mercatorCell(bounds.South, bounds.East, zoom)
+	keep := courseIDSet(courseIDs)
+
+	var clusters []CourseCluster
+	for _, cluster := range levels.tilesOverlapping(zoom, minX, minY, maxX, maxY) {
+		if cluster.cell.x < minX || cluster.cell.x > maxX || cluster.cell.y < minY || cluster.cell.y > maxY {
+			continue
+		}
+		if filtered, ok := levels.filter(cluster, keep); ok {
+			clusters = append(clusters, filtered)
+		}
+	}
+	sortClusters(clusters)
+	return clusters, nil
+}
+
+// Expand returns the clusters a cluster breaks into. It steps down the zoom
+// levels until the courses split apart, stopping at MaxClusterZoom for courses
+// that share a spot.
+func (s *CourseClusterService) Expand(clusterID string, courseIDs []uint) (*CourseClusterExpansion, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	cell, ok := parseClusterID(clusterID)
+	if !ok {
+		return nil, ErrCourseClusterNotFound
+	}
+
+	levels, err := s.levels()
+	if err != nil {
+		return nil, err
+	}
+
+	var members []uint
+	tile := clusterTile{x: cell.x / clusterCellsPerTile, y: cell.y / clusterCellsPerTile}
+	for _, cluster := range levels.zooms[cell.zoom][tile] {
+		if cluster.cell == cell {
+			members = cluster.CourseIDs
+			break
+		}
+	}
+	keep := courseIDSet(courseIDs)
+	points := make([]clusterPoint, 0, len(members))
+	for _, courseID := range members {
+		if keep == nil || keep[courseID] {
+			points = append(points, levels.points[courseID])
+		}
+	}
+	if len(points) == 0 {
+		return nil, ErrCourseClusterNotFound
+	}
+
+	zoom := cell.zoom
+	clusters := []CourseCluster{*newCourseCluster(cell, points)}
+	for zoom < MaxClusterZoom {
+		zoom++
+		clusters = clusterPoints(points, zoom)
+		if len(clusters) > 1 {
+			break
+		}
+	}
+	sortClusters(clusters)
+
+	return &CourseClusterExpansion{
+		ClusterID:     clusterID,
+		ExpansionZoom: zoom,
+		Clusters:      clusters,
+	}, nil
+}
+
+// levels returns the current clusters, building them when there are none yet
+// or the located courses have changed since they were built
+func (s *CourseClusterService) levels() (*courseClusterLevels, error) {
+	value, _ := courseClusterCaches.LoadOrStore(s.db, &courseClusterCache{})
+	cache := value.(*courseClusterCache)
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.levels != nil && time.Since(cache.checkedAt) < clusterRecheckInterval {
+		return cache.levels, nil
+	}
+
+	signature, err := loadCourseLocationSignature(s.db)
+	if err != nil {
+		return nil, err
+	}
+	if cache.levels != nil && signature == cache.signature {
+		cache.checkedAt = time.Now()
+		return cache.levels, nil
+	}
+
+	var locations []CourseLocation
+	err = s.db.Model(&CourseDB{}).
+		Select("id AS course_id, latitude, longitude").
+		Where("latitude IS NOT NULL AND longitude IS NOT NULL").
+		Scan(&locations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load course locations: %v", err)
+	}
+
+	started := time.Now()
+	cache.levels = buildCourseClusterLevels(locations)
+	cache.signature = signature
+	cache.checkedAt = time.Now()
+	log.Printf("[CLUSTERS] Built clusters for %d courses in %v", len(locations), time.Since(started))
+	return cache.levels, nil
+}
+
+// loadCourseLocationSignature reads the current signature of the located courses
+func loadCourseLocationSignature(db *gorm.DB) (courseLocationSignature, error) {
+	var signature courseLocationSignature
+	err := db.Model(&CourseDB{}).
+		Select("COUNT(*) AS located, COALESCE(MAX(updated_at), 0) AS last_updated").
+		Where("latitude IS NOT NULL AND longitude IS NOT NULL").
+		Scan(&signature).Error
+	if err != nil {
+		return signature, fmt.Errorf("failed to check course locations: %v", err)
+	}
+	return signature, nil
+}
+
+// invalidateCourseClusters drops the cached clusters so the next request
+// rebuilds them. Call it when a course is geocoded, moved or removed.
+func invalidateCourseClusters(db *gorm.DB) {
+	if value, ok := courseClusterCaches.Load(db); ok {
+		cache := value.(*courseClusterCache)
+		cache.mu.Lock()
+		cache.levels = nil
+		cache.mu.Unlock()
+	}
+}
+
+func buildCourseClusterLevels(locations []CourseLocation) *courseClusterLevels {
+	levels := &courseClusterLevels{points: make(map[uint]clusterPoint, len(locations))}
+	points := make([]clusterPoint, 0, len(locations))
+	for _, location := range locations {
+		x, y := mercator(location.Latitude, location.Longitude)
+		point := clusterPoint{courseID: location.CourseID, lat: location.Latitude, lng: location.Longitude, x: x, y: y}
+		levels.points[location.CourseID] = point
+		points = append(points, point)
+	}
+
+	for zoom := 0; zoom <= MaxClusterZoom; zoom++ {
+		tiles := make(map[clusterTile][]*CourseCluster)
+		clusters := clusterPoints(points, zoom)
+		for i := range clusters {
+			cluster := &clusters[i]
+			tile := clusterTile{x: cluster.cell.x / clusterCellsPerTile, y: cluster.cell.y / clusterCellsPerTile}
+			tiles[tile] = append(tiles[tile], cluster)
+		}
+		levels.zooms[zoom] = tiles
+	}
+	return levels
+}
+
+// tilesOverlapping returns the clusters in the tiles covering a range of cells.
+// Wide viewports at deep zooms cover far more tiles than hold courses, so
+// those walk the tiles that exist instead.
+func (l *courseClusterLevels) tilesOverlapping(zoom, minX, minY, maxX, maxY int) []*CourseCluster {
+	tiles := l.zooms[zoom]
+	minTileX, minTileY := minX/clusterCellsPerTile, minY/clusterCellsPerTile
+	maxTileX, maxTileY := maxX/clusterCellsPerTile, maxY/clusterCellsPerTile
+
+	var clusters []*CourseCluster
+	if (maxTileX-minTileX+1)*(maxTileY-minTileY+1) > len(tiles) {
+		for tile, tileClusters := range tiles {
+			if tile.x >= minTileX && tile.x <= maxTileX && tile.y >= minTileY && tile.y <= maxTileY {
+				clusters = append(clusters, tileClusters...)
+			}
+		}
+		return clusters
+	}
+
+	for x := minTileX; x <= maxTileX; x++ {
+		for y := minTileY; y <= maxTileY; y++ {
+			clusters = append(clusters, tiles[clusterTile{x: x, y: y}]...)
+		}
+	}
+	return clusters
+}
+
+// filter narrows a cluster to the kept courses, recentering it on them. It
+// reports false when none are kept.
+func (l *courseClusterLevels) filter(cluster *CourseCluster, keep map[uint]bool) (CourseCluster, bool) {
+	if keep == nil {
+		return *cluster, true
+	}
+	points := make([]clusterPoint, 0, len(cluster.CourseIDs))
+	for _, courseID := range cluster.CourseIDs {
+		if keep[courseID] {
+			points = append(points, l.points[courseID])
+		}
+	}
+	if len(points) == 0 {
+		return CourseCluster{}, false
+	}
+	return *newCourseCluster(cluster.cell, points), true
+}
+
+// clusterPoints groups points by their grid cell at a zoom level
+func clusterPoints(points []clusterPoint, zoom int) []CourseCluster {
+	scale := float64(int(1)<<zoom) * clusterCellsPerTile
+	cells := make(map[clusterCell][]clusterPoint)
+	for _, point := range points {
+		cell := clusterCell{zoom: zoom, x: gridIndex(point.x, scale), y: gridIndex(point.y, scale)}
+		cells[cell] = append(cells[cell], point)
+	}
+
+	clusters := make([]CourseCluster, 0, len(cells))
+	for cell, members := range cells {
+		clusters = append(clusters, *newCourseCluster(cell, members))
+	}
+	return clusters
+}
+
+// newCourseCluster places a cluster at the average position of its courses
+func newCourseCluster(cell clusterCell, points []clusterPoint) *CourseCluster {
+	cluster := &CourseCluster{
+		ID:        fmt.Sprintf("%d-%d-%d", cell.zoom, cell.x, cell.y),
+		Zoom:      cell.zoom,
+		CourseIDs: make([]uint, 0, len(points)),
+		cell:      cell,
+	}
+	for _, point := range points {
+		cluster.Latitude += point.lat
+		cluster.Longitude += point.lng
+		cluster.CourseIDs = append(cluster.CourseIDs, point.courseID)
+	}
+	cluster.Latitude /= float64(len(points))
+	cluster.Longitude /= float64(len(points))
+	sort.Slice(cluster.CourseIDs, func(i, j int) bool { return cluster.CourseIDs[i] < cluster.CourseIDs[j] })
+	return cluster
+}
+
+// parseClusterID reads a "zoom-x-y" cluster ID
+func parseClusterID(clusterID string) (clusterCell, bool) {
+	parts := strings.Split(clusterID, "-")
+	if len(parts) != 3 {
+		return clusterCell{}, false
+	}
+	var values [3]int
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 0 {
+			return clusterCell{}, false
+		}
+		values[i] = value
+	}
+	if values[0] > MaxClusterZoom {
+		return clusterCell{}, false
+	}
+	cells := (1 << values[0]) * clusterCellsPerTile
+	if values[1] >= cells || values[2] >= cells {
+		return clusterCell{}, false
+	}
+	return clusterCell{zoom: values[0], x: values[1], y: values[2]}, true
+}
+
+// mercator projects a point onto the Web Mercator square, with x running east
+// from the antimeridian and y south from the top of the map
+func mercator(lat, lng float64) (x, y float64) {
+	lat = math.Max(-maxMercatorLatitude, math.Min(maxMercatorLatitude, lat))
+	sin := math.Sin(lat * math.Pi / 180)
+	x = (lng + 180) / 360
+	y = 0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)
+	return x, y
+}
+
+// mercatorCell returns the grid cell holding a point at a zoom level
+func mercatorCell(lat, lng float64, zoom int) (x, y int) {
+	scale := float64(int(1)<<zoom) * clusterCellsPerTile
+	mx, my := mercator(lat, lng)
+	return gridIndex(mx, scale), gridIndex(my, scale)
+}
+
+func gridIndex(position, scale float64) int {
+	index := int(math.Floor(position * scale))
+	return max(0, min(index, int(scale)-1))
+}
+
+func courseIDSet(courseIDs []uint) map[uint]bool {
+	if courseIDs == nil {
+		return nil
+	}
+	set := make(map[uint]bool, len(courseIDs))
+	for _, courseID := range courseIDs {
+		set[courseID] = true
+	}
+	return set
+}
+
+func sortClusters(clusters []CourseCluster) {
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].cell.y != clusters[j].cell.y {
+			return clusters[i].cell.y < clusters[j].cell.y
+		}
+		return clusters[i].cell.x < clusters[j].cell.x
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var wholeWorld = GeoBounds{North: 85, South: -85, East: 180, West: -180}
+
+func clusterCounts(clusters []CourseCluster) []int {
+	counts := make([]int, 0, len(clusters))
+	for _, cluster := range clusters {
+		counts = append(counts, len(cluster.CourseIDs))
+	}
+	return counts
+}
+
+func TestCourseClusterService_Clusters(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedGeoCourses(t, db)
+	service := NewCourseClusterService()
+
+	clusters, err := service.Clusters(wholeWorld, 2, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{3, 1, 1, 1}, clusterCounts(clusters), "the Sandhills courses share a marker; Fiji splits at the antimeridian")
+
+	var sandhills CourseCluster
+	for _, cluster := range clusters {
+		if len(cluster.CourseIDs) == 3 {
+			sandhills = cluster
+		}
+	}
+	assert.Equal(t, 2, sandhills.Zoom)
+	assert.ElementsMatch(t, []uint{ids["Pinehurst No. 2"], ids["Mid Pines"], ids["Tobacco Road"]}, sandhills.CourseIDs)
+	assert.InDelta(t, 35.24, sandhills.Latitude, 0.01, "placed at the average of its courses")
+
+	clusters, err = service.Clusters(GeoBounds{North: 36, South: 35, East: -79, West: -80}, 2, nil)
+	require.NoError(t, err)
+	require.Len(t, clusters, 1, "only cells overlapping the bounds")
+	assert.Equal(t, sandhills.ID, clusters[0].ID)
+
+	clusters, err = service.Clusters(wholeWorld, 2, []uint{ids["Tobacco Road"]})
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+	assert.Equal(t, sandhills.ID, clusters[0].ID, "filtering keeps the cluster's ID")
+	assert.InDelta(t, 35.358, clusters[0].Latitude, 0.001, "and recenters it on the courses left")
+
+	clusters, err = service.Clusters(wholeWorld, MaxClusterZoom, nil)
+	require.NoError(t, err)
+	assert.Len(t, clusters, 6, "every course is its own marker when zoomed all the way in")
+
+	_, err = service.Clusters(wholeWorld, MaxClusterZoom+1, nil)
+	assert.Error(t, err)
+}
+
+func TestCourseClusterService_Expand(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedGeoCourses(t, db)
+	service := NewCourseClusterService()
+
+	clusters, err := service.Clusters(GeoBounds{North: 36, South: 35, East: -79, West: -80}, 2, nil)
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+
+	expansion, err := service.Expand(clusters[0].ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, clusters[0].ID, expansion.ClusterID)
+	assert.Greater(t, expansion.ExpansionZoom, 2)
+	require.Greater(t, len(expansion.Clusters), 1, "expands to the first zoom where the courses split")
+	total := 0
+	for _, child := range expansion.Clusters {
+		assert.Equal(t, expansion.ExpansionZoom, child.Zoom)
+		total += len(child.CourseIDs)
+	}
+	assert.Equal(t, 3, total)
+
+	expansion, err = service.Expand(clusters[0].ID, []uint{ids["Tobacco Road"]})
+	require.NoError(t, err)
+	assert.Equal(t, MaxClusterZoom, expansion.ExpansionZoom, "a single course never splits")
+	require.Len(t, expansion.Clusters, 1)
+	assert.Equal(t, []uint{ids["Tobacco Road"]}, expansion.Clusters[0].CourseIDs)
+
+	_, err = service.Expand(clusters[0].ID, []uint{ids["Pebble Beach"]})
+	assert.ErrorIs(t, err, ErrCourseClusterNotFound, "none of the cluster's courses match the filter")
+
+	for _, clusterID := range []string{"2-0-0", "junk", "21-0-0", "2-16-0", "-1-0-0"} {
+		_, err = service.Expand(clusterID, nil)
+		assert.ErrorIs(t, err, ErrCourseClusterNotFound, clusterID)
+	}
+}
+
+func TestCourseClusterService_Invalidation(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedGeoCourses(t, db)
+	service := NewCourseClusterService()
+	pebbleBay := GeoBounds{North: 37, South: 36, East: -121, West: -122}
+
+	clusters, err := service.Clusters(pebbleBay, 5, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, clusterCounts(clusters))
+
+	lat, lng := 36.5725, -121.9486
+	require.NoError(t, db.Create(&CourseDB{Name: "Cypress Point", Address: "Pebble Beach", Hash: "cypress", CourseData: "{}", Latitude: &lat, Longitude: &lng}).Error)
+	clusters, err = service.Clusters(pebbleBay, 5, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, clusterCounts(clusters), "served from the cache until it is invalidated or rechecked")
+
+	invalidateCourseClusters(db)
+	clusters, err = service.Clusters(pebbleBay, 5, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, clusterCounts(clusters))
+
+	require.NoError(t, NewDatabaseService().DeleteCourse(ids["Pebble Beach"]))
+	clusters, err = service.Clusters(pebbleBay, 5, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, clusterCounts(clusters), "deleting a located course invalidates the clusters")
+}
+
+func TestMercatorCells(t *testing.T) {
+	x, y := mercator(0, 0)
+	assert.InDelta(t, 0.5, x, 1e-9)
+	assert.InDelta(t, 0.5, y, 1e-9)
+
+	_, y = mercator(90, 0)
+	assert.InDelta(t, 0, y, 1e-6, "clamped to the top of the map")
+
+	cellX, cellY := mercatorCell(0, 180, 0)
+	assert.Equal(t, clusterCellsPerTile-1, cellX, "the antimeridian's east edge stays on the map")
+	assert.Equal(t, clusterCellsPerTile/2, cellY)
+
+	cell, ok := parseClusterID("3-10-20")
+	require.True(t, ok)
+	assert.Equal(t, clusterCell{zoom: 3, x: 10, y: 20}, cell)
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"course_management/services"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Pairs scoring below this aren't reported by default
+	defaultDuplicateMinScore = 0.8
+
+	// Courses this close are on the same site; the distance score falls from
+	// there to zero at duplicateFarKm
+	duplicateSameSiteKm = 0.2
+	duplicateFarKm      = 5.0
+	// Courses whose names share no word are only compared this close together
+	duplicateNearbyKm = 1.0
+
+	// How much each signal counts towards a pair's score. A signal one of the
+	// courses has no data for is left out and the others share its weight.
+	duplicateNameWeight     = 0.5
+	duplicateAddressWeight  = 0.2
+	duplicateDistanceWeight = 0.3
+)
+
+// courseNameAbbreviations are spelled out before names are compared, so
+// "Bath CC" reads as "Bath Country Club"
+var courseNameAbbreviations = map[string][]string{
+	"cc":   {"country", "club"},
+	"gc":   {"golf", "club"},
+	"gcc":  {"golf", "country", "club"},
+	"g":    {"golf"},
+	"gl":   {"golf", "links"},
+	"ctry": {"country"},
+	"mt":   {"mount"},
+	"ft":   {"fort"},
+	"st":   {"saint"},
+}
+
+// courseNameStopWords are dropped from names
+var courseNameStopWords = map[string]bool{"the": true, "and": true, "at": true, "of": true, "no": true}
+
+// courseNameGenericWords say what kind of place a course is rather than which
+// one, so they count for less when names are compared
+var courseNameGenericWords = map[string]bool{
+	"golf": true, "club": true, "country": true, "course": true, "courses": true, "links": true,
+	"resort": true, "spa": true, "hotel": true, "park": true, "municipal": true, "public": true,
+}
+
+// courseAddressAbbreviations give address words one spelling
+var courseAddressAbbreviations = map[string]string{
+	"street": "st", "road": "rd", "drive": "dr", "avenue": "ave", "av": "ave", "highway": "hwy",
+	"boulevard": "blvd", "lane": "ln", "court": "ct", "parkway": "pkwy", "place": "pl", "circle": "cir",
+	"route": "rte", "trail": "trl", "terrace": "ter", "mount": "mt", "saint": "st",
+	"north": "n", "south": "s", "east": "e", "west": "w",
+	"northeast": "ne", "northwest": "nw", "southeast": "se", "southwest": "sw",
+}
+
+// courseAddressUnitWords start a suite or unit number, which is dropped along
+// with the word so a suite doesn't make a second course
+var courseAddressUnitWords = map[string]bool{
+	"suite": true, "ste": true, "unit": true, "apt": true, "apartment": true,
+	"building": true, "bldg": true, "floor": true, "fl": true, "room": true, "rm": true,
+}
+
+var courseAddressUnitNumber = regexp.MustCompile(`#\s*[\p{L}\p{N}-]+`)
+
+// CourseDuplicateCourse is one course of a candidate pair
+type CourseDuplicateCourse struct {
+	ID      uint
+	Name    string
+	Address string
+	Reviews int64
+	Scores  int64
+}
+
+// CourseDuplicateCandidate is a pair of courses that may be the same one.
+// Course is the one to merge away and Survivor the one to keep: whichever has
+// more reviews and scores, or the older course on a tie.
+type CourseDuplicateCandidate struct {
+	Course    CourseDuplicateCourse
+	Survivor  CourseDuplicateCourse
+	Score     float64
+	NameScore float64
+	// AddressScore is -1 when either course has no address
+	AddressScore float64
+	// DistanceMeters is -1 when either course isn't on the map
+	DistanceMeters float64
+}
+
+// CourseDuplicateService finds courses that were added more than once
+type CourseDuplicateService struct {
+	db *gorm.DB
+}
+
+func NewCourseDuplicateService() *CourseDuplicateService {
+	return &CourseDuplicateService{db: GetDB()}
+}
+
+// FindCandidates returns the pairs of courses scoring at least minScore, best
+// first. Only pairs whose names share a word, or that are within
+// duplicateNearbyKm of each other, are scored.
+func (s *CourseDuplicateService) FindCandidates(minScore float64) ([]CourseDuplicateCandidate, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var courses []CourseDB
+	if err := s.db.Select("id", "name", "address", "latitude", "longitude").Order("id").Find(&courses).Error; err != nil {
+		return nil, fmt.Errorf("failed to load courses: %v", err)
+	}
+
+	profiles := make([]courseDuplicateProfile, len(courses))
+	byWord := make(map[string][]int)
+	bands := make(map[int][]int)
+	for i := range courses {
+		profiles[i] = newCourseDuplicateProfile(courses[i])
+		for _, key := range courseDuplicateBlockKeys(profiles[i].name) {
+			byWord[key] = append(byWord[key], i)
+		}
+		if location := profiles[i].location; location != nil {
+			band := osmLatitudeBand(location.Latitude)
+			bands[band] = append(bands[band], i)
+		}
+	}
+
+	pairs := make(map[[2]int]bool)
+	for _, members := range byWord {
+		for a := 0; a < len(members); a++ {
+			for b := a + 1; b < len(members); b++ {
+				if members[a] != members[b] {
+					pairs[[2]int{members[a], members[b]}] = true
+				}
+			}
+		}
+	}
+	for band, members := range bands {
+		for _, i := range members {
+			for _, neighbour := range []int{band - 1, band, band + 1} {
+				for _, j := range bands[neighbour] {
+					if j <= i {
+						continue
+					}
+					a, b := profiles[i].location, profiles[j].location
+					if services.HaversineKm(a.Latitude, a.Longitude, b.Latitude, b.Longitude) <= duplicateNearbyKm {
+						pairs[[2]int{i, j}] = true
+					}
+				}
+			}
+		}
+	}
+
+	var candidates []CourseDuplicateCandidate
+	involved := make(map[uint]bool)
+	for pair := range pairs {
+		candidate := scoreCourseDuplicate(profiles[pair[1]], profiles[pair[0]])
+		if candidate.Score >= minScore {
+			candidates = append(candidates, candidate)
+			involved[candidate.Course.ID] = true
+			involved[candidate.Survivor.ID] = true
+		}
+	}
+	if len(candidates) == 0 {
+		return []CourseDuplicateCandidate{}, nil
+	}
+
+	reviews, scores, err := s.activityCounts(involved)
+	if err != nil {
+		return nil, err
+	}
+	for i := range candidates {
+		candidate := &candidates[i]
+		for _, course := range []*CourseDuplicateCourse{&candidate.Course, &candidate.Survivor} {
+			course.Reviews, course.Scores = reviews[course.ID], scores[course.ID]
+		}
+		// Keep the course people have used; the older one on a tie
+		course, survivor := candidate.Course, candidate.Survivor
+		if course.Reviews+course.Scores > survivor.Reviews+survivor.Scores {
+			candidate.Course, candidate.Survivor = survivor, course
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Survivor.ID != b.Survivor.ID {
+			return a.Survivor.ID < b.Survivor.ID
+		}
+		return a.Course.ID < b.Course.ID
+	})
+	return candidates, nil
+}
+
+// activityCounts returns how many reviews and scores each course has
+func (s *CourseDuplicateService) activityCounts(courseIDs map[uint]bool) (map[uint]int64, map[uint]int64, error) {
+	ids := make([]uint, 0, len(courseIDs))
+	for id := range courseIDs {
+		ids = append(ids, id)
+	}
+
+	type courseCount struct {
+		CourseID uint
+		Count    int64
+	}
+	count := func(model interface{}) (map[uint]int64, error) {
+		var rows []courseCount
+		err := s.db.Model(model).Select("course_id, COUNT(*) AS count").
+			Where("course_id IN ?", ids).Group("course_id").Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		counts := make(map[uint]int64, len(rows))
+		for _, row := range rows {
+			counts[row.CourseID] = row.Count
+		}
+		return counts, nil
+	}
+
+	reviews, err := count(&CourseReview{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count reviews: %v", err)
+	}
+	scores, err := count(&UserCourseScore{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count scores: %v", err)
+	}
+	return reviews, scores, nil
+}
+
+// courseDuplicateProfile is a course prepared for comparison
+type courseDuplicateProfile struct {
+	course   CourseDB
+	name     []string
+	address  []string
+	location *GeoPoint
+}
+
+func newCourseDuplicateProfile(course CourseDB) courseDuplicateProfile {
+	return courseDuplicateProfile{
+		course:   course,
+		name:     courseNameWords(course.Name),
+		address:  courseAddressWords(course.Address),
+		location: courseLocation(&course),
+	}
+}
+
+// scoreCourseDuplicate scores how likely two courses are to be the same one,
+// from 0 to 1. Course is a and Survivor is b.
+func scoreCourseDuplicate(a, b courseDuplicateProfile) CourseDuplicateCandidate {
+	candidate := CourseDuplicateCandidate{
+		Course:         CourseDuplicateCourse{ID: a.course.ID, Name: a.course.Name, Address: a.course.Address},
+		Survivor:       CourseDuplicateCourse{ID: b.course.ID, Name: b.course.Name, Address: b.course.Address},
+		NameScore:      wordSimilarity(a.name, b.name, courseNameWordWeight),
+		AddressScore:   -1,
+		DistanceMeters: -1,
+	}
+
+	score, weight := candidate.NameScore*duplicateNameWeight, duplicateNameWeight
+	if len(a.address) > 0 && len(b.address) > 0 {
+		candidate.AddressScore = wordSimilarity(a.address, b.address, nil)
+		score += candidate.AddressScore * duplicateAddressWeight
+		weight += duplicateAddressWeight
+	}
+	if a.location != nil && b.location != nil {
+		km := services.HaversineKm(a.location.Latitude, a.location.Longitude, b.location.Latitude, b.location.Longitude)
+		candidate.DistanceMeters = km * 1000
+		score += duplicateDistanceScore(km) * duplicateDistanceWeight
+		weight += duplicateDistanceWeight
+	}
+	candidate.Score = score / weight
+	return candidate
+}
+
+func duplicateDistanceScore(km float64) float64 {
+	switch {
+	case km <= duplicateSameSiteKm:
+		return 1
+	case km >= duplicateFarKm:
+		return 0
+	}
+	return 1 - (km-duplicateSameSiteKm)/(duplicateFarKm-duplicateSameSiteKm)
+}
+
+// splitWords lowercases text and splits it into words of letters and digits
+func splitWords(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "&", " and ")
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// courseNameWords returns a course name's words with abbreviations spelled out
+// and stop words dropped
+func courseNameWords(name string) []string {
+	var words []string
+	for _, word := range splitWords(name) {
+		expanded, ok := courseNameAbbreviations[word]
+		if !ok {
+			expanded = []string{word}
+		}
+		for _, word := range expanded {
+			if !courseNameStopWords[word] {
+				words = append(words, word)
+			}
+		}
+	}
+	return words
+}
+
+// courseAddressWords returns an address's words with one spelling for street
+// types and directions, and without unit numbers
+func courseAddressWords(address string) []string {
+	var words []string
+	skipNext := false
+	for _, word := range splitWords(courseAddressUnitNumber.ReplaceAllString(address, " ")) {
+		switch {
+		case skipNext:
+			skipNext = false
+		case courseAddressUnitWords[word]:
+			skipNext = true
+		case word == "usa":
+		default:
+			if short, ok := courseAddressAbbreviations[word]; ok {
+				word = short
+			}
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+func courseNameWordWeight(word string) float64 {
+	if courseNameGenericWords[word] {
+		return 0.3
+	}
+	return 1
+}
+
+// courseDuplicateBlockKeys are the keys that put two courses up for
+// comparison: the start of each distinctive word, so a misspelling later in
+// the word still matches
+func courseDuplicateBlockKeys(words []string) []string {
+	var keys []string
+	for _, word := range words {
+		if courseNameGenericWords[word] || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+		if runes := []rune(word); len(runes) > 4 {
+			word = string(runes[:4])
+		}
+		keys = append(keys, word)
+	}
+	return keys
+}
+
+// wordSimilarity is the weighted Dice coefficient of two word lists: the
+// weight of the words they share over the weight of all their words. Words
+// of five letters or more also match one edit apart. A nil weight counts
+// every word as 1.
+func wordSimilarity(a, b []string, weight func(string) float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if weight == nil {
+		weight = func(string) float64 { return 1 }
+	}
+
+	var total, shared float64
+	for _, word := range a {
+		total += weight(word)
+	}
+	for _, word := range b {
+		total += weight(word)
+	}
+
+	used := make([]bool, len(b))
+	for _, word := range a {
+		match := -1
+		for j, other := range b {
+			if used[j] {
+				continue
+			}
+			if word == other {
+				match = j
+				break
+			}
+			if match < 0 && utf8.RuneCountInString(word) >= 5 && withinEditDistance(word, other, 1) {
+				match = j
+			}
+		}
+		if match >= 0 {
+			used[match] = true
+			shared += weight(word) + weight(b[match])
+		}
+	}
+	return shared / total
+}
+
+// writeCourseDuplicates prints candidate pairs for the course-duplicates command
+func writeCourseDuplicates(w io.Writer, candidates []CourseDuplicateCandidate, minScore float64) {
+	describe := func(course CourseDuplicateCourse) string {
+		text := fmt.Sprintf("course %d %s", course.ID, course.Name)
+		if course.Address != "" {
+			text += ", " + course.Address
+		}
+		return text + fmt.Sprintf(" (%d reviews, %d scores)", course.Reviews, course.Scores)
+	}
+
+	fmt.Fprintf(w, "Possible duplicate courses scoring %.2f or more:\n", minScore)
+	for _, candidate := range candidates {
+		fmt.Fprintf(w, "\n  %.2f  %s\n", candidate.Score, describe(candidate.Course))
+		fmt.Fprintf(w, "        into %s\n", describe(candidate.Survivor))
+
+		signals := []string{fmt.Sprintf("name %.2f", candidate.NameScore)}
+		if candidate.AddressScore >= 0 {
+			signals = append(signals, fmt.Sprintf("address %.2f", candidate.AddressScore))
+		}
+		if candidate.DistanceMeters >= 0 {
+			signals = append(signals, fmt.Sprintf("%.0f m apart", candidate.DistanceMeters))
+		}
+		fmt.Fprintf(w, "        %s\n", strings.Join(signals, ", "))
+		fmt.Fprintf(w, "        ./main merge-courses %d %d\n", candidate.Course.ID, candidate.Survivor.ID)
+	}
+
+	fmt.Fprintf(w, "\n%d candidate pairs.\n", len(candidates))
+}
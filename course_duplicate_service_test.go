@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCourseDuplicateWords(t *testing.T) {
+	assert.Equal(t, []string{"bath", "country", "club"}, courseNameWords("The Bath CC"))
+	assert.Equal(t, []string{"pinehurst", "2"}, courseNameWords("Pinehurst No. 2"))
+	assert.Equal(t, []string{"golf", "country", "club", "saint", "andrews"}, courseNameWords("G&CC St. Andrews"))
+
+	assert.Equal(t, []string{"100", "main", "st", "bath", "nc"}, courseAddressWords("100 Main Street, Suite 200, Bath, NC USA"))
+	assert.Equal(t, []string{"100", "main", "st", "bath", "nc"}, courseAddressWords("100 Main St #4B, Bath, NC"))
+	assert.Equal(t, []string{"1", "n", "carolina", "dr"}, courseAddressWords("1 North Carolina Drive"))
+
+	assert.Equal(t, 1.0, wordSimilarity([]string{"pinehurst"}, []string{"pinehirst"}, nil), "one edit apart")
+	assert.Equal(t, 0.0, wordSimilarity([]string{"no2"}, []string{"no4"}, nil), "short words must match exactly")
+	assert.InDelta(t, 2.6/3.2, wordSimilarity(courseNameWords("Bath Country Club"), courseNameWords("Bath Golf Club"), courseNameWordWeight), 1e-9)
+	assert.Equal(t, 0.0, wordSimilarity(nil, []string{"bath"}, nil))
+}
+
+func TestCourseDuplicateService_FindCandidates(t *testing.T) {
+	db := setupTestDatabase(t)
+
+	located := func(name, address string, latitude, longitude float64) CourseDB {
+		return CourseDB{Name: name, Address: address, CourseData: "{}", Latitude: &latitude, Longitude: &longitude}
+	}
+	courses := []CourseDB{
+		located("Bath Country Club", "100 Main Street, Bath, NC", 35.4770, -76.8113),
+		{Name: "Bath CC", Address: "100 Main St Suite 2, Bath, NC", CourseData: "{}"},
+		located("Pinehurst No. 2", "1 Carolina Vista Dr, Pinehurst, NC", 35.1907, -79.4704),
+		located("Pinehurst No. 4", "1 Carolina Vista Dr, Pinehurst, NC", 35.1915, -79.4712),
+		located("Willow Creek Golf Club", "", 35.0, -80.0),
+		located("Willow Creek", "", 40.0, -90.0),
+		located("Foxfire", "", 35.1910, -79.4708),
+	}
+	for i := range courses {
+		require.NoError(t, db.Create(&courses[i]).Error)
+	}
+	// The newer Bath course has the reviews, so it's the one to keep
+	require.NoError(t, db.Create(&CourseReview{CourseID: courses[1].ID, UserID: 1}).Error)
+
+	candidates, err := NewCourseDuplicateService().FindCandidates(0.7)
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+
+	bath := candidates[0]
+	assert.Equal(t, courses[0].ID, bath.Course.ID)
+	assert.Equal(t, courses[1].ID, bath.Survivor.ID)
+	assert.Equal(t, int64(1), bath.Survivor.Reviews)
+	assert.InDelta(t, 1.0, bath.Score, 1e-9)
+	assert.Equal(t, 1.0, bath.AddressScore, "the suite number is ignored")
+	assert.Equal(t, -1.0, bath.DistanceMeters)
+
+	pinehurst := candidates[1]
+	assert.Equal(t, courses[3].ID, pinehurst.Course.ID, "the older course stays on a tie")
+	assert.Equal(t, courses[2].ID, pinehurst.Survivor.ID)
+	assert.Equal(t, 0.5, pinehurst.NameScore)
+	assert.InDelta(t, 0.75, pinehurst.Score, 1e-9)
+	assert.InDelta(t, 112, pinehurst.DistanceMeters, 5)
+
+	// Different numbered courses at one resort aren't flagged by default
+	candidates, err = NewCourseDuplicateService().FindCandidates(defaultDuplicateMinScore)
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, courses[0].ID, candidates[0].Course.ID)
+}
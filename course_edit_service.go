@@ -0,0 +1,743 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Suggested course edit statuses
+const (
+	CourseEditPending   = "pending"
+	CourseEditAccepted  = "accepted"
+	CourseEditRejected  = "rejected"
+	CourseEditWithdrawn = "withdrawn"
+)
+
+const (
+	maxCourseEditNoteLength = 500
+	maxCourseEditsListed    = 50
+
+	// How often unanswered suggestions are checked for escalation
+	courseEditEscalationCheck = time.Hour
+)
+
+var (
+	ErrCourseEditNotFound  = errors.New("suggested edit not found")
+	ErrCourseEditForbidden = errors.New("you don't have permission to change this suggested edit")
+	ErrCourseEditAnswered  = errors.New("this suggested edit has already been answered")
+	ErrCourseEditConflict  = errors.New("the course has changed since this edit was suggested")
+	ErrCourseEditOwnCourse = errors.New("you can edit your own course directly")
+	ErrInvalidCourseEdit   = errors.New("invalid suggested edit")
+)
+
+// courseEditField is a course detail anyone can suggest a change to, with the
+// limits the course form applies. Rankings and the review are the reviewer's
+// own opinion, so they can't be suggested.
+type courseEditField struct {
+	label    string
+	min, max int // Characters, or the value for numbers
+	number   bool
+}
+
+var courseEditFields = map[string]courseEditField{
+	"name":              {label: "Name", min: 3, max: 100},
+	"address":           {label: "Address", min: 10, max: 200},
+	"description":       {label: "Description", min: 10, max: 500},
+	"holes.par":         {label: "par", min: 3, max: 6, number: true},
+	"holes.yardage":     {label: "yardage", min: 50, max: 800, number: true},
+	"holes.description": {label: "notes", max: 500},
+}
+
+// CourseEditService handles edits to a course suggested by golfers who can't
+// edit it, and the review queue its creator and the admins answer them from
+type CourseEditService struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+func NewCourseEditService() *CourseEditService {
+	return &CourseEditService{
+		db:  GetDB(),
+		now: time.Now,
+	}
+}
+
+// Propose suggests changes to a course. Each change needs its Field and To;
+// the course's current value is recorded as From, and changes that match it
+// are dropped. The course's creator is notified, or the admins when nobody
+// created it.
+func (s *CourseEditService) Propose(userID, courseID uint, changes []CourseFieldChange, note string) (*CourseEditProposal, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	course, err := s.getCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if course.CreatedBy != nil && *course.CreatedBy == userID {
+		return nil, ErrCourseEditOwnCourse
+	}
+
+	note = strings.TrimSpace(note)
+	if len(note) > maxCourseEditNoteLength {
+		return nil, fmt.Errorf("%w: note must be %d characters or less", ErrInvalidCourseEdit, maxCourseEditNoteLength)
+	}
+
+	current := courseEditDetails(course)
+	seen := make(map[string]bool, len(changes))
+	kept := make([]CourseFieldChange, 0, len(changes))
+	for _, change := range changes {
+		change.Field = strings.TrimSpace(change.Field)
+		hole, name, field, err := parseCourseEditField(change.Field)
+		if err != nil {
+			return nil, err
+		}
+		if seen[change.Field] {
+			return nil, fmt.Errorf("%w: %s is changed more than once", ErrInvalidCourseEdit, change.Label())
+		}
+		seen[change.Field] = true
+
+		to := strings.TrimSpace(change.To)
+		if err := field.validate(change.Label(), to); err != nil {
+			return nil, err
+		}
+		from := courseFieldValue(current, hole, name)
+		if from == to {
+			continue
+		}
+		kept = append(kept, CourseFieldChange{Field: change.Field, From: from, To: to})
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("%w: change at least one detail", ErrInvalidCourseEdit)
+	}
+
+	proposal := CourseEditProposal{
+		CourseID: courseID,
+		UserID:   userID,
+		Changes:  kept,
+		Status:   CourseEditPending,
+	}
+	if note != "" {
+		proposal.Note = &note
+	}
+	if course.CreatedBy == nil {
+		// Imported courses have nobody but the admins to answer
+		escalatedAt := s.now().Unix()
+		proposal.EscalatedAt = &escalatedAt
+	}
+	if err := s.db.Create(&proposal).Error; err != nil {
+		return nil, fmt.Errorf("failed to save suggested edit: %v", err)
+	}
+
+	log.Printf("[COURSE_EDITS] User %d suggested edit %d to course %d (%d changes)", userID, proposal.ID, courseID, len(kept))
+
+	message := fmt.Sprintf("%s suggested an edit to %s", s.userName(userID), course.Name)
+	if proposal.EscalatedAt != nil {
+		s.notifyAdmins(&proposal, message)
+	} else {
+		s.notifyUser(*course.CreatedBy, "course_edit_suggested", &userID, courseID, message)
+	}
+	return &proposal, nil
+}
+
+// Get returns a suggested edit to its proposer, the course's creator or an
+// admin. Anyone else gets ErrCourseEditNotFound.
+func (s *CourseEditService) Get(viewerID, proposalID uint) (*CourseEditProposalView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	proposal, err := s.getProposal(proposalID)
+	if err != nil {
+		return nil, err
+	}
+	views, err := s.views(viewerID, []CourseEditProposal{*proposal})
+	if err != nil {
+		return nil, err
+	}
+	if !views[0].CanReview && proposal.UserID != viewerID {
+		return nil, ErrCourseEditNotFound
+	}
+	return &views[0], nil
+}
+
+// Queue returns the pending suggestions a user can answer, oldest first:
+// those for courses they created and, for admins, every escalated one
+func (s *CourseEditService) Queue(userID uint) ([]CourseEditProposalView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	admin, err := (&AdminService{db: s.db}).IsAdmin(userID)
+	if err != nil {
+		return nil, err
+	}
+	created := s.db.Model(&CourseDB{}).Select("id").Where("created_by = ?", userID)
+	query := s.db.Where("status = ?", CourseEditPending)
+	if admin {
+		query = query.Where(s.db.Where("course_id IN (?)", created).Or("escalated_at IS NOT NULL"))
+	} else {
+		query = query.Where("course_id IN (?)", created)
+	}
+
+	var proposals []CourseEditProposal
+	if err := query.Order("created_at, id").Find(&proposals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get suggested edits: %v", err)
+	}
+	return s.views(userID, proposals)
+}
+
+// ListByUser returns the edits a user suggested, newest first
+func (s *CourseEditService) ListByUser(userID uint) ([]CourseEditProposalView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var proposals []CourseEditProposal
+	if err := s.db.Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(maxCourseEditsListed).
+		Find(&proposals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get suggested edits: %v", err)
+	}
+	return s.views(userID, proposals)
+}
+
+// ListForCourse returns a course's pending suggestions that the viewer can
+// see: all of them for the course's creator and admins, otherwise the
+// viewer's own
+func (s *CourseEditService) ListForCourse(viewerID, courseID uint) ([]CourseEditProposalView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	course, err := s.getCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+	canReview, err := s.canReview(viewerID, course)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.Where("course_id = ? AND status = ?", courseID, CourseEditPending)
+	if !canReview {
+		query = query.Where("user_id = ?", viewerID)
+	}
+	var proposals []CourseEditProposal
+	if err := query.Order("created_at, id").Find(&proposals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get suggested edits: %v", err)
+	}
+	return s.views(viewerID, proposals)
+}
+
+// Accept applies a pending suggestion to the course, recording its proposer
+// as the course's last editor. It fails with ErrCourseEditConflict when a
+// field it changes has been edited since it was suggested.
+func (s *CourseEditService) Accept(userID, proposalID uint, note string) (*CourseEditProposal, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	proposal, course, err := s.answerable(userID, proposalID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := courseEditDetails(course)
+	var conflicts []string
+	for _, change := range proposal.Changes {
+		hole, name, _, err := parseCourseEditField(change.Field)
+		if err != nil || courseFieldValue(current, hole, name) != change.From {
+			conflicts = append(conflicts, change.Label())
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrCourseEditConflict, strings.Join(conflicts, ", "))
+	}
+
+	data, err := applyCourseEdit(course.CourseData, proposal.Changes)
+	if err != nil {
+		return nil, err
+	}
+	var updated Course
+	if err := json.Unmarshal([]byte(data), &updated); err != nil {
+		return nil, fmt.Errorf("failed to read edited course: %v", err)
+	}
+
+	moved := updated.Address != course.Address
+	course.CourseData = data
+	course.Name = updated.Name
+	course.Address = updated.Address
+	course.UpdatedBy = &proposal.UserID
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.answer(tx, proposal, CourseEditAccepted, userID, note); err != nil {
+			return err
+		}
+		if err := tx.Save(course).Error; err != nil {
+			return fmt.Errorf("failed to update course: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[COURSE_EDITS] User %d accepted edit %d to course %d by user %d", userID, proposal.ID, course.ID, proposal.UserID)
+	courseSaved(s.db, course, updated, moved, proposal.UserID)
+	s.notifyUser(proposal.UserID, "course_edit_answered", &userID, course.ID,
+		fmt.Sprintf("%s accepted your edit to %s", s.userName(userID), course.Name))
+	return proposal, nil
+}
+
+// Reject turns down a pending suggestion. The note is shown to its proposer.
+func (s *CourseEditService) Reject(userID, proposalID uint, note string) (*CourseEditProposal, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	proposal, course, err := s.answerable(userID, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.answer(s.db, proposal, CourseEditRejected, userID, note); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[COURSE_EDITS] User %d rejected edit %d to course %d", userID, proposal.ID, course.ID)
+	s.notifyUser(proposal.UserID, "course_edit_answered", &userID, course.ID,
+		fmt.Sprintf("%s didn't accept your edit to %s", s.userName(userID), course.Name))
+	return proposal, nil
+}
+
+// Withdraw takes back a pending suggestion. Only its proposer may withdraw it.
+func (s *CourseEditService) Withdraw(userID, proposalID uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	proposal, err := s.getProposal(proposalID)
+	if err != nil {
+		return err
+	}
+	if proposal.UserID != userID {
+		return ErrCourseEditForbidden
+	}
+
+	result := s.db.Model(&CourseEditProposal{}).
+		Where("id = ? AND status = ?", proposalID, CourseEditPending).
+		Update("status", CourseEditWithdrawn)
+	if result.Error != nil {
+		return fmt.Errorf("failed to withdraw suggested edit: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrCourseEditAnswered
+	}
+	return nil
+}
+
+// RunEscalations escalates suggestions left unanswered for longer than after
+// until ctx is cancelled
+func (s *CourseEditService) RunEscalations(ctx context.Context, after time.Duration) {
+	ticker := time.NewTicker(courseEditEscalationCheck)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			escalated, err := s.EscalateStale(after)
+			if err != nil {
+				log.Printf("[COURSE_EDITS] Failed to escalate suggested edits: %v", err)
+			}
+			if escalated > 0 {
+				log.Printf("[COURSE_EDITS] Escalated %d suggested edits to the admins", escalated)
+			}
+		}
+	}
+}
+
+// EscalateStale passes pending suggestions older than after to the admins and
+// returns how many were escalated. Each suggestion is escalated once.
+func (s *CourseEditService) EscalateStale(after time.Duration) (int, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not connected")
+	}
+
+	now := s.now()
+	var proposals []CourseEditProposal
+	if err := s.db.Where("status = ? AND escalated_at IS NULL AND created_at <= ?", CourseEditPending, now.Add(-after).Unix()).
+		Order("created_at, id").
+		Find(&proposals).Error; err != nil {
+		return 0, fmt.Errorf("failed to find unanswered suggested edits: %v", err)
+	}
+
+	escalated := 0
+	for i := range proposals {
+		proposal := &proposals[i]
+		result := s.db.Model(&CourseEditProposal{}).
+			Where("id = ? AND escalated_at IS NULL", proposal.ID).
+			Update("escalated_at", now.Unix())
+		if result.Error != nil {
+			return escalated, fmt.Errorf("failed to escalate suggested edit %d: %v", proposal.ID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue // Another instance got there first
+		}
+		escalated++
+
+		var course CourseDB
+		if err := s.db.Select("id", "name").First(&course, proposal.CourseID).Error; err != nil {
+			continue
+		}
+		s.notifyAdmins(proposal, fmt.Sprintf("%s's edit to %s has waited %s for an answer",
+			s.userName(proposal.UserID), course.Name, courseEditWait(now.Sub(time.Unix(proposal.CreatedAt, 0)))))
+	}
+	return escalated, nil
+}
+
+// Label names the changed field for display, e.g. "Hole 7 par"
+func (c CourseFieldChange) Label() string {
+	hole, _, field, err := parseCourseEditField(c.Field)
+	if err != nil {
+		return c.Field
+	}
+	if hole == 0 {
+		return field.label
+	}
+	return fmt.Sprintf("Hole %d %s", hole, field.label)
+}
+
+// answerable loads a pending suggestion and its course for a user who may
+// answer it
+func (s *CourseEditService) answerable(userID, proposalID uint) (*CourseEditProposal, *CourseDB, error) {
+	proposal, err := s.getProposal(proposalID)
+	if err != nil {
+		return nil, nil, err
+	}
+	course, err := s.getCourse(proposal.CourseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	canReview, err := s.canReview(userID, course)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !canReview {
+		log.Printf("[SECURITY] User %d attempted to answer suggested edit %d to course %d", userID, proposalID, course.ID)
+		return nil, nil, ErrCourseEditForbidden
+	}
+	if proposal.Status != CourseEditPending {
+		return nil, nil, ErrCourseEditAnswered
+	}
+	return proposal, course, nil
+}
+
+// answer records a decision on a suggestion, unless someone else answered it first
+func (s *CourseEditService) answer(db *gorm.DB, proposal *CourseEditProposal, status string, userID uint, note string) error {
+	now := s.now().Unix()
+	var reviewNote *string
+	if note = strings.TrimSpace(note); note != "" {
+		if len(note) > maxCourseEditNoteLength {
+			return fmt.Errorf("%w: note must be %d characters or less", ErrInvalidCourseEdit, maxCourseEditNoteLength)
+		}
+		reviewNote = &note
+	}
+
+	result := db.Model(&CourseEditProposal{}).
+		Where("id = ? AND status = ?", proposal.ID, CourseEditPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": userID,
+			"reviewed_at": now,
+			"review_note": reviewNote,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to answer suggested edit: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrCourseEditAnswered
+	}
+
+	proposal.Status = status
+	proposal.ReviewedBy = &userID
+	proposal.ReviewedAt = &now
+	proposal.ReviewNote = reviewNote
+	return nil
+}
+
+// canReview reports whether a user may answer suggestions for a course
+func (s *CourseEditService) canReview(userID uint, course *CourseDB) (bool, error) {
+	if course.CreatedBy != nil && *course.CreatedBy == userID {
+		return true, nil
+	}
+	return (&AdminService{db: s.db}).IsAdmin(userID)
+}
+
+func (s *CourseEditService) getProposal(proposalID uint) (*CourseEditProposal, error) {
+	var proposal CourseEditProposal
+	if err := s.db.First(&proposal, proposalID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCourseEditNotFound
+		}
+		return nil, fmt.Errorf("failed to find suggested edit: %v", err)
+	}
+	return &proposal, nil
+}
+
+func (s *CourseEditService) getCourse(courseID uint) (*CourseDB, error) {
+	var course CourseDB
+	if err := s.db.First(&course, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, fmt.Errorf("failed to find course: %v", err)
+	}
+	return &course, nil
+}
+
+// views prepares suggestions for display to one viewer
+func (s *CourseEditService) views(viewerID uint, proposals []CourseEditProposal) ([]CourseEditProposalView, error) {
+	views := make([]CourseEditProposalView, 0, len(proposals))
+	if len(proposals) == 0 {
+		return views, nil
+	}
+
+	admin, err := (&AdminService{db: s.db}).IsAdmin(viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	courseIDs := make([]uint, 0, len(proposals))
+	userIDs := make([]uint, 0, len(proposals)*2)
+	for _, proposal := range proposals {
+		courseIDs = append(courseIDs, proposal.CourseID)
+		userIDs = append(userIDs, proposal.UserID)
+		if proposal.ReviewedBy != nil {
+			userIDs = append(userIDs, *proposal.ReviewedBy)
+		}
+	}
+	var courses []CourseDB
+	if err := s.db.Select("id", "name", "created_by").Where("id IN ?", courseIDs).Find(&courses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get courses: %v", err)
+	}
+	byID := make(map[uint]CourseDB, len(courses))
+	for _, course := range courses {
+		byID[course.ID] = course
+	}
+	names, err := (&ReviewCommentService{db: s.db}).authorNames(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, proposal := range proposals {
+		course := byID[proposal.CourseID]
+		view := CourseEditProposalView{
+			CourseEditProposal: proposal,
+			CourseName:         course.Name,
+			ProposerName:       names[proposal.UserID],
+			CanReview:          admin || (course.CreatedBy != nil && *course.CreatedBy == viewerID),
+			CanWithdraw:        proposal.UserID == viewerID && proposal.Status == CourseEditPending,
+		}
+		if proposal.ReviewedBy != nil {
+			view.ReviewerName = names[*proposal.ReviewedBy]
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (s *CourseEditService) userName(userID uint) string {
+	return (&ReviewCommentService{db: s.db}).AuthorName(userID)
+}
+
+// notifyAdmins tells every admin but the proposer about a suggestion
+func (s *CourseEditService) notifyAdmins(proposal *CourseEditProposal, message string) {
+	admins, err := (&AdminService{db: s.db}).ListAdmins()
+	if err != nil {
+		log.Printf("[COURSE_EDITS] Failed to notify admins about suggested edit %d: %v", proposal.ID, err)
+		return
+	}
+	if len(admins) == 0 {
+		log.Printf("[COURSE_EDITS] Suggested edit %d needs an admin, but there are none", proposal.ID)
+	}
+	for _, admin := range admins {
+		if admin.ID != proposal.UserID {
+			s.notifyUser(admin.ID, "course_edit_suggested", &proposal.UserID, proposal.CourseID, message)
+		}
+	}
+}
+
+func (s *CourseEditService) notifyUser(recipientID uint, notificationType string, actorID *uint, courseID uint, message string) {
+	notifications := &NotificationService{db: s.db}
+	if err := notifications.notify(recipientID, notificationType, actorID, &courseID, message); err != nil {
+		log.Printf("[COURSE_EDITS] Failed to notify user %d: %v", recipientID, err)
+	}
+}
+
+// parseCourseEditField splits a change's field into the hole it's on, zero
+// for the course itself, and the field's name
+func parseCourseEditField(field string) (int, string, courseEditField, error) {
+	parts := strings.Split(field, ".")
+	if len(parts) == 1 {
+		if spec, ok := courseEditFields[field]; ok {
+			return 0, field, spec, nil
+		}
+	}
+	if len(parts) == 3 && parts[0] == "holes" {
+		if spec, ok := courseEditFields["holes."+parts[2]]; ok {
+			number, err := strconv.Atoi(parts[1])
+			if err != nil || number < 1 || number > MaxHoleNumber {
+				return 0, "", courseEditField{}, fmt.Errorf("%w: hole numbers run from 1 to %d", ErrInvalidCourseEdit, MaxHoleNumber)
+			}
+			return number, parts[2], spec, nil
+		}
+	}
+	return 0, "", courseEditField{}, fmt.Errorf("%w: %q can't be changed", ErrInvalidCourseEdit, field)
+}
+
+func (f courseEditField) validate(label, value string) error {
+	if f.number {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < f.min || number > f.max {
+			return fmt.Errorf("%w: %s must be a number from %d to %d", ErrInvalidCourseEdit, label, f.min, f.max)
+		}
+		return nil
+	}
+	if length := len([]rune(value)); length < f.min || length > f.max {
+		if f.min == 0 {
+			return fmt.Errorf("%w: %s must be %d characters or less", ErrInvalidCourseEdit, label, f.max)
+		}
+		return fmt.Errorf("%w: %s must be %d to %d characters", ErrInvalidCourseEdit, label, f.min, f.max)
+	}
+	return nil
+}
+
+// courseEditDetails reads the details suggestions can change from a course.
+// The name and address columns are the ones the site shows.
+func courseEditDetails(course *CourseDB) Course {
+	var details Course
+	_ = json.Unmarshal([]byte(course.CourseData), &details)
+	details.Name = course.Name
+	details.Address = course.Address
+	return details
+}
+
+// courseFieldValue returns a field's value as a suggestion records it, or ""
+// for a hole the card doesn't have
+func courseFieldValue(course Course, hole int, name string) string {
+	if hole == 0 {
+		switch name {
+		case "name":
+			return course.Name
+		case "address":
+			return course.Address
+		case "description":
+			return course.Description
+		}
+		return ""
+	}
+
+	for _, h := range course.Holes {
+		if h.Number != hole {
+			continue
+		}
+		switch name {
+		case "par":
+			return strconv.Itoa(h.Par)
+		case "yardage":
+			return strconv.Itoa(h.Yardage)
+		case "description":
+			return h.Description
+		}
+	}
+	return ""
+}
+
+// applyCourseEdit writes changes into a course's stored data, keeping the
+// fields the Course struct doesn't know about. Holes the card doesn't have
+// are added in number order.
+func applyCourseEdit(courseData string, changes []CourseFieldChange) (string, error) {
+	data := map[string]interface{}{}
+	if strings.TrimSpace(courseData) != "" {
+		decoder := json.NewDecoder(strings.NewReader(courseData))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			return "", fmt.Errorf("failed to read course data: %v", err)
+		}
+	}
+
+	holes, _ := data["holes"].([]interface{})
+	for _, change := range changes {
+		hole, name, field, err := parseCourseEditField(change.Field)
+		if err != nil {
+			return "", err
+		}
+		var value interface{} = change.To
+		if field.number {
+			value, _ = strconv.Atoi(change.To)
+		}
+		if hole == 0 {
+			data[name] = value
+			continue
+		}
+
+		var target map[string]interface{}
+		for _, h := range holes {
+			if entry, ok := h.(map[string]interface{}); ok && courseHoleNumber(entry) == hole {
+				target = entry
+			}
+		}
+		if target == nil {
+			target = map[string]interface{}{"number": hole, "par": 0, "yardage": 0, "description": ""}
+			holes = append(holes, target)
+		}
+		target[name] = value
+	}
+	if holes != nil {
+		sort.SliceStable(holes, func(i, j int) bool {
+			a, _ := holes[i].(map[string]interface{})
+			b, _ := holes[j].(map[string]interface{})
+			return courseHoleNumber(a) < courseHoleNumber(b)
+		})
+		data["holes"] = holes
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to write course data: %v", err)
+	}
+	return string(encoded), nil
+}
+
+// courseHoleNumber reads the number of a hole decoded from course data
+func courseHoleNumber(hole map[string]interface{}) int {
+	switch number := hole["number"].(type) {
+	case json.Number:
+		n, _ := number.Int64()
+		return int(n)
+	case int:
+		return number
+	}
+	return 0
+}
+
+// courseEditWait describes how long a suggestion has waited, in whole days
+// once it's a couple of days old
+func courseEditWait(wait time.Duration) string {
+	if wait >= 48*time.Hour {
+		return fmt.Sprintf("%d days", int(wait.Hours()/24))
+	}
+	return fmt.Sprintf("%d hours", int(wait.Hours()))
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type courseEditFixtures struct {
+	owner    User
+	golfer   User
+	admin    User
+	course   CourseDB
+	imported CourseDB // Added by an import, so nobody owns it
+}
+
+func seedCourseEditFixtures(t *testing.T) courseEditFixtures {
+	t.Helper()
+	db := GetDB()
+
+	golfer, admin := "Sam", "Alex"
+	f := courseEditFixtures{
+		owner:  User{GoogleID: "owner", Email: "owner@example.com", Name: "Owner"},
+		golfer: User{GoogleID: "golfer", Email: "golfer@example.com", Name: "Golfer", DisplayName: &golfer},
+		admin:  User{GoogleID: "admin", Email: "admin@example.com", Name: "Admin", DisplayName: &admin, IsAdmin: true},
+	}
+	for _, user := range []*User{&f.owner, &f.golfer, &f.admin} {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	f.course = CourseDB{Name: "Pine Valley", Address: "1 Pine Valley Rd, Pine Valley, NJ", CreatedBy: &f.owner.ID,
+		CourseData: `{"name": "Pine Valley", "address": "1 Pine Valley Rd, Pine Valley, NJ", "description": "Sandy heathland course", "website": "https://pv.example",
+			"holes": [{"number": 1, "par": 4, "yardage": 421, "description": ""}, {"number": 2, "par": 5, "yardage": 367, "description": "Uphill"}]}`}
+	f.imported = CourseDB{Name: "Dunes Links", Address: "2 Shore Rd, Dunes, NJ", CourseData: `{"name": "Dunes Links", "description": "Seaside links"}`}
+	require.NoError(t, db.Create(&f.course).Error)
+	require.NoError(t, db.Create(&f.imported).Error)
+	return f
+}
+
+func notificationTypes(t *testing.T, userID uint) []string {
+	t.Helper()
+	var types []string
+	require.NoError(t, GetDB().Model(&Notification{}).Where("user_id = ?", userID).Order("id").Pluck("type", &types).Error)
+	return types
+}
+
+func TestCourseEditService_Propose(t *testing.T) {
+	setupTestDatabase(t)
+	f := seedCourseEditFixtures(t)
+	service := NewCourseEditService()
+
+	t.Run("records what changes and tells the owner", func(t *testing.T) {
+		proposal, err := service.Propose(f.golfer.ID, f.course.ID, []CourseFieldChange{
+			{Field: "holes.2.par", To: "4"},
+			{Field: "holes.3.yardage", To: " 180 "},
+			{Field: "description", To: "Sandy heathland course"}, // Unchanged, so dropped
+		}, "The card at the clubhouse says par 4")
+		require.NoError(t, err)
+
+		assert.Equal(t, CourseEditPending, proposal.Status)
+		assert.Nil(t, proposal.EscalatedAt)
+		assert.Equal(t, []CourseFieldChange{
+			{Field: "holes.2.par", From: "5", To: "4"},
+			{Field: "holes.3.yardage", From: "", To: "180"},
+		}, proposal.Changes)
+		assert.Equal(t, "Hole 2 par", proposal.Changes[0].Label())
+		assert.Equal(t, []string{"course_edit_suggested"}, notificationTypes(t, f.owner.ID))
+		assert.Empty(t, notificationTypes(t, f.admin.ID))
+	})
+
+	t.Run("suggestions for unowned courses go straight to the admins", func(t *testing.T) {
+		proposal, err := service.Propose(f.golfer.ID, f.imported.ID, []CourseFieldChange{{Field: "name", To: "Dunes Golf Links"}}, "")
+		require.NoError(t, err)
+		assert.NotNil(t, proposal.EscalatedAt)
+		assert.Equal(t, []string{"course_edit_suggested"}, notificationTypes(t, f.admin.ID))
+	})
+
+	t.Run("rejects invalid suggestions", func(t *testing.T) {
+		cases := map[string][]CourseFieldChange{
+			"unknown field":    {{Field: "ranks.price", To: "$"}},
+			"hole out of card": {{Field: "holes.19.par", To: "4"}},
+			"par out of range": {{Field: "holes.1.par", To: "9"}},
+			"short name":       {{Field: "name", To: "PV"}},
+			"twice":            {{Field: "name", To: "Pine Valley GC"}, {Field: "name", To: "Pine Valley Golf Club"}},
+			"nothing changed":  {{Field: "holes.1.par", To: "4"}},
+		}
+		for name, changes := range cases {
+			_, err := service.Propose(f.golfer.ID, f.course.ID, changes, "")
+			assert.ErrorIs(t, err, ErrInvalidCourseEdit, name)
+		}
+
+		_, err := service.Propose(f.owner.ID, f.course.ID, []CourseFieldChange{{Field: "holes.1.par", To: "5"}}, "")
+		assert.ErrorIs(t, err, ErrCourseEditOwnCourse)
+		_, err = service.Propose(f.golfer.ID, 9999, []CourseFieldChange{{Field: "holes.1.par", To: "5"}}, "")
+		assert.ErrorIs(t, err, ErrCourseNotFound)
+	})
+}
+
+func TestCourseEditService_Answer(t *testing.T) {
+	db := setupTestDatabase(t)
+	f := seedCourseEditFixtures(t)
+	service := NewCourseEditService()
+
+	propose := func(changes ...CourseFieldChange) *CourseEditProposal {
+		proposal, err := service.Propose(f.golfer.ID, f.course.ID, changes, "")
+		require.NoError(t, err)
+		return proposal
+	}
+
+	t.Run("only the owner and admins can answer", func(t *testing.T) {
+		proposal := propose(CourseFieldChange{Field: "holes.1.yardage", To: "430"})
+		_, err := service.Accept(f.golfer.ID, proposal.ID, "")
+		assert.ErrorIs(t, err, ErrCourseEditForbidden)
+
+		queue, err := service.Queue(f.owner.ID)
+		require.NoError(t, err)
+		require.Len(t, queue, 1)
+		assert.True(t, queue[0].CanReview)
+		assert.Equal(t, "Pine Valley", queue[0].CourseName)
+
+		queue, err = service.Queue(f.admin.ID)
+		require.NoError(t, err)
+		assert.Empty(t, queue, "admins only queue escalated suggestions")
+
+		_, err = service.Reject(f.admin.ID, proposal.ID, "Measured from the back tee it's 421")
+		require.NoError(t, err)
+		_, err = service.Reject(f.owner.ID, proposal.ID, "")
+		assert.ErrorIs(t, err, ErrCourseEditAnswered)
+
+		mine, err := service.ListByUser(f.golfer.ID)
+		require.NoError(t, err)
+		require.Len(t, mine, 1)
+		assert.Equal(t, CourseEditRejected, mine[0].Status)
+		assert.Equal(t, "Alex", mine[0].ReviewerName)
+		assert.False(t, mine[0].CanWithdraw)
+		assert.Equal(t, []string{"course_edit_answered"}, notificationTypes(t, f.golfer.ID))
+	})
+
+	t.Run("accepting applies the changes with attribution", func(t *testing.T) {
+		proposal := propose(
+			CourseFieldChange{Field: "name", To: "Pine Valley Golf Club"},
+			CourseFieldChange{Field: "holes.2.par", To: "4"},
+			CourseFieldChange{Field: "holes.3.par", To: "3"},
+		)
+		accepted, err := service.Accept(f.owner.ID, proposal.ID, "Thanks!")
+		require.NoError(t, err)
+		assert.Equal(t, CourseEditAccepted, accepted.Status)
+		assert.Equal(t, f.owner.ID, *accepted.ReviewedBy)
+
+		var course CourseDB
+		require.NoError(t, db.First(&course, f.course.ID).Error)
+		assert.Equal(t, "Pine Valley Golf Club", course.Name)
+		assert.Equal(t, f.golfer.ID, *course.UpdatedBy)
+
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(course.CourseData), &data))
+		assert.Equal(t, "Pine Valley Golf Club", data["name"])
+		assert.Equal(t, "https://pv.example", data["website"], "fields outside the Course struct are kept")
+		holes := data["holes"].([]interface{})
+		require.Len(t, holes, 3)
+		assert.Equal(t, 4.0, holes[1].(map[string]interface{})["par"])
+		assert.Equal(t, "Uphill", holes[1].(map[string]interface{})["description"])
+		assert.Equal(t, 3.0, holes[2].(map[string]interface{})["number"])
+
+		var synced []CourseHole
+		require.NoError(t, db.Where("course_id = ?", f.course.ID).Order("hole_number").Find(&synced).Error)
+		require.Len(t, synced, 3)
+		assert.Equal(t, intPtr(4), synced[1].Par)
+	})
+
+	t.Run("accepting fails when the course changed since", func(t *testing.T) {
+		first := propose(CourseFieldChange{Field: "holes.1.par", To: "5"})
+		second := propose(CourseFieldChange{Field: "holes.1.par", To: "3"})
+		_, err := service.Accept(f.owner.ID, first.ID, "")
+		require.NoError(t, err)
+
+		_, err = service.Accept(f.owner.ID, second.ID, "")
+		assert.ErrorIs(t, err, ErrCourseEditConflict)
+		assert.Contains(t, err.Error(), "Hole 1 par")
+		_, err = service.Reject(f.owner.ID, second.ID, "")
+		assert.NoError(t, err, "a stale suggestion can still be rejected")
+	})
+
+	t.Run("only the proposer can withdraw", func(t *testing.T) {
+		proposal := propose(CourseFieldChange{Field: "holes.2.description", To: "Uphill to a blind green"})
+		assert.ErrorIs(t, service.Withdraw(f.owner.ID, proposal.ID), ErrCourseEditForbidden)
+		require.NoError(t, service.Withdraw(f.golfer.ID, proposal.ID))
+		_, err := service.Accept(f.owner.ID, proposal.ID, "")
+		assert.ErrorIs(t, err, ErrCourseEditAnswered)
+
+		_, err = service.Get(f.admin.ID, proposal.ID)
+		assert.NoError(t, err)
+		_, err = service.Get(9999, proposal.ID)
+		assert.ErrorIs(t, err, ErrCourseEditNotFound)
+	})
+}
+
+func TestCourseEditService_EscalateStale(t *testing.T) {
+	db := setupTestDatabase(t)
+	f := seedCourseEditFixtures(t)
+	service := NewCourseEditService()
+	now := time.Unix(1700000000, 0)
+	service.now = func() time.Time { return now }
+
+	old := CourseEditProposal{CourseID: f.course.ID, UserID: f.golfer.ID, Status: CourseEditPending,
+		Changes: []CourseFieldChange{{Field: "holes.1.par", From: "4", To: "5"}}, CreatedAt: now.Add(-8 * 24 * time.Hour).Unix()}
+	recent := CourseEditProposal{CourseID: f.course.ID, UserID: f.golfer.ID, Status: CourseEditPending,
+		Changes: []CourseFieldChange{{Field: "holes.2.par", From: "5", To: "4"}}, CreatedAt: now.Add(-time.Hour).Unix()}
+	require.NoError(t, db.Create(&old).Error)
+	require.NoError(t, db.Create(&recent).Error)
+
+	escalated, err := service.EscalateStale(7 * 24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, escalated)
+	escalated, err = service.EscalateStale(7 * 24 * time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, escalated, "suggestions are escalated once")
+
+	var notification Notification
+	require.NoError(t, db.Where("user_id = ?", f.admin.ID).First(&notification).Error)
+	assert.Equal(t, "Sam's edit to Pine Valley has waited 8 days for an answer", notification.Message)
+
+	queue, err := service.Queue(f.admin.ID)
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+	assert.Equal(t, old.ID, queue[0].ID)
+	_, err = service.Accept(f.admin.ID, old.ID, "")
+	assert.NoError(t, err)
+}
+
+func TestParseCourseEditFormData(t *testing.T) {
+	current := Course{Name: "Pine Valley", Address: "1 Pine Valley Rd", Description: "Sandy heathland course",
+		Holes: []Hole{{Number: 1, Par: 4, Yardage: 421}, {Number: 2, Par: 5, Yardage: 367, Description: "Uphill"}}}
+	form := map[string]string{
+		"name": "Pine Valley", "address": " 1 Pine Valley Rd ", "description": "Sandy heathland course",
+		"hole-1-par": "4", "hole-1-yardage": "430", "hole-1-notes": "",
+		"hole-2-par": "4", "hole-2-yardage": "367", "hole-2-notes": "Uphill",
+	}
+
+	changes := ParseCourseEditFormData(current, func(key string) string { return form[key] })
+	assert.Equal(t, []CourseFieldChange{
+		{Field: "holes.1.yardage", To: "430"},
+		{Field: "holes.2.par", To: "4"},
+	}, changes)
+
+	assert.Len(t, courseEditHoleRows(Course{}), 18, "courses without a card get an empty one to fill in")
+}
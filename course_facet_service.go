@@ -0,0 +1,338 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"course_management/api"
+
+	"gorm.io/gorm"
+)
+
+// courseGradeColumns maps filterable grade categories to course_rankings
+// columns
+var courseGradeColumns = map[string]string{
+	"condition":   "condition",
+	"walkability": "walkability",
+	"enjoyment":   "enjoyment_rating",
+	"vibe":        "vibe",
+	"range":       "range_rating",
+	"amenities":   "amenities",
+	"merch":       "merch",
+	"glizzies":    "glizzies",
+}
+
+// courseFacetSource is course_rankings joined to per-course hole totals. Par
+// is only totalled when every hole on the card has one.
+const courseFacetSource = `course_rankings AS r
+	JOIN course_dbs AS c ON c.id = r.course_id
+	LEFT JOIN (
+		SELECT course_id, COUNT(*) AS hole_count,
+			CASE WHEN COUNT(par) = COUNT(*) THEN SUM(par) END AS total_par
+		FROM course_holes GROUP BY course_id
+	) AS h ON h.course_id = r.course_id`
+
+// CourseFacetService answers price, difficulty, grade, hole count and par
+// filters from the relational course_rankings and course_holes tables of
+// migrations/001_relational_schema.sql. course_data is still what the app
+// writes, so the service keeps those tables in step with it.
+type CourseFacetService struct {
+	db *gorm.DB
+}
+
+func NewCourseFacetService() *CourseFacetService {
+	return &CourseFacetService{
+		db: GetDB(),
+	}
+}
+
+// CourseFacetFilter narrows courses by their rankings and card. Empty fields
+// don't filter.
+type CourseFacetFilter struct {
+	CourseIDs             []uint // Candidate courses, nil for every course
+	MinPrice              string
+	MaxPrice              string
+	MinHandicapDifficulty *int
+	MaxHandicapDifficulty *int
+	MinHazardDifficulty   *int
+	MaxHazardDifficulty   *int
+	MinGrades             map[string]string // Grade category to lowest accepted grade
+	Holes                 *int
+	MinPar                *int
+	MaxPar                *int
+}
+
+// IsEmpty reports whether the filter has no facet conditions
+func (f CourseFacetFilter) IsEmpty() bool {
+	return f.MinPrice == "" && f.MaxPrice == "" &&
+		f.MinHandicapDifficulty == nil && f.MaxHandicapDifficulty == nil &&
+		f.MinHazardDifficulty == nil && f.MaxHazardDifficulty == nil &&
+		len(f.MinGrades) == 0 && f.Holes == nil && f.MinPar == nil && f.MaxPar == nil
+}
+
+// CourseFacetCounts holds course counts by facet value. Grades are keyed by
+// category, then grade.
+type CourseFacetCounts struct {
+	Price              map[string]int
+	HandicapDifficulty map[string]int
+	HazardDifficulty   map[string]int
+	Grades             map[string]map[string]int
+	Holes              map[string]int
+	Par                map[string]int
+}
+
+// Facet names used to leave a facet's own condition out of its counts
+const (
+	facetPrice              = "price"
+	facetHandicapDifficulty = "handicap_difficulty"
+	facetHazardDifficulty   = "hazard_difficulty"
+	facetHoles              = "holes"
+	facetPar                = "par"
+	facetGradePrefix        = "grade:"
+)
+
+// FilterCourseIDs returns the IDs of candidate courses that pass every facet
+// condition, or nil when the filter has none
+func (s *CourseFacetService) FilterCourseIDs(filter CourseFacetFilter) ([]uint, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+	if filter.IsEmpty() {
+		return nil, nil
+	}
+
+	courseIDs := []uint{}
+	if err := s.facetQuery(filter, "").Order("r.course_id").Pluck("r.course_id", &courseIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to filter courses by facets: %v", err)
+	}
+	return courseIDs, nil
+}
+
+// CountFacets counts the candidate courses by each facet value. Each facet
+// applies every condition except its own, so a client can tell how many
+// courses picking a different value would leave.
+func (s *CourseFacetService) CountFacets(filter CourseFacetFilter) (*CourseFacetCounts, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	counts := &CourseFacetCounts{Grades: make(map[string]map[string]int)}
+	var err error
+	if counts.Price, err = s.countBy(filter, facetPrice, "r.price"); err != nil {
+		return nil, err
+	}
+	if counts.HandicapDifficulty, err = s.countBy(filter, facetHandicapDifficulty, "r.handicap_difficulty"); err != nil {
+		return nil, err
+	}
+	if counts.HazardDifficulty, err = s.countBy(filter, facetHazardDifficulty, "r.hazard_difficulty"); err != nil {
+		return nil, err
+	}
+	for _, category := range api.GradeCategories {
+		grades, err := s.countBy(filter, facetGradePrefix+category.Slug, "r."+courseGradeColumns[category.Slug])
+		if err != nil {
+			return nil, err
+		}
+		counts.Grades[category.Slug] = grades
+	}
+	if counts.Holes, err = s.countBy(filter, facetHoles, "h.hole_count"); err != nil {
+		return nil, err
+	}
+	if counts.Par, err = s.countBy(filter, facetPar, "h.total_par"); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (s *CourseFacetService) countBy(filter CourseFacetFilter, facet, column string) (map[string]int, error) {
+	var rows []struct {
+		Value string
+		Count int
+	}
+	err := s.facetQuery(filter, facet).
+		Select(column + " AS value, COUNT(*) AS count").
+		Where(column + " IS NOT NULL").
+		Group(column).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count %s facet: %v", facet, err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		if row.Value != "" {
+			counts[row.Value] = row.Count
+		}
+	}
+	return counts, nil
+}
+
+// facetQuery applies the filter's conditions, leaving out those of the skipped facet
+func (s *CourseFacetService) facetQuery(filter CourseFacetFilter, skip string) *gorm.DB {
+	query := s.db.Table(courseFacetSource)
+	if filter.CourseIDs != nil {
+		query = query.Where("r.course_id IN ?", filter.CourseIDs)
+	}
+
+	if skip != facetPrice && (filter.MinPrice != "" || filter.MaxPrice != "") {
+		query = query.Where("r.price IN ?", api.PriceTiersBetween(filter.MinPrice, filter.MaxPrice))
+	}
+	if skip != facetHandicapDifficulty {
+		query = whereBetween(query, "r.handicap_difficulty", filter.MinHandicapDifficulty, filter.MaxHandicapDifficulty)
+	}
+	if skip != facetHazardDifficulty {
+		query = whereBetween(query, "r.hazard_difficulty", filter.MinHazardDifficulty, filter.MaxHazardDifficulty)
+	}
+	for category, grade := range filter.MinGrades {
+		column, ok := courseGradeColumns[category]
+		if !ok || skip == facetGradePrefix+category {
+			continue
+		}
+		query = query.Where("r."+column+" IN ?", api.GradesAtLeast(grade))
+	}
+	if skip != facetHoles && filter.Holes != nil {
+		query = query.Where("h.hole_count = ?", *filter.Holes)
+	}
+	if skip != facetPar {
+		query = whereBetween(query, "h.total_par", filter.MinPar, filter.MaxPar)
+	}
+	return query
+}
+
+func whereBetween(query *gorm.DB, column string, min, max *int) *gorm.DB {
+	if min != nil {
+		query = query.Where(column+" >= ?", *min)
+	}
+	if max != nil {
+		query = query.Where(column+" <= ?", *max)
+	}
+	return query
+}
+
+// SyncCourse replaces a course's rows in course_rankings and course_holes with
+// the rankings and holes from its course data
+func (s *CourseFacetService) SyncCourse(courseID uint, course Course) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	ranking := CourseRanking{
+		CourseID:           courseID,
+		Price:              course.Ranks.Price,
+		HandicapDifficulty: optionalBetween(course.Ranks.HandicapDifficulty, 1, 20),
+		HazardDifficulty:   optionalBetween(course.Ranks.HazardDifficulty, 1, 10),
+		Merch:              optionalGrade(course.Ranks.Merch),
+		Condition:          optionalGrade(course.Ranks.Condition),
+		EnjoymentRating:    optionalGrade(course.Ranks.EnjoymentRating),
+		Vibe:               optionalGrade(course.Ranks.Vibe),
+		RangeRating:        optionalGrade(course.Ranks.Range),
+		Amenities:          optionalGrade(course.Ranks.Amenities),
+		Glizzies:           optionalGrade(course.Ranks.Glizzies),
+		Walkability:        optionalGrade(course.Ranks.Walkability),
+	}
+
+	var holes []CourseHole
+	for _, hole := range course.Holes {
+		if hole.Number < 1 || hole.Number > 18 {
+			continue
+		}
+		holes = append(holes, CourseHole{
+			CourseID:    courseID,
+			HoleNumber:  hole.Number,
+			Par:         optionalBetween(hole.Par, 3, 6),
+			Yardage:     optionalBetween(hole.Yardage, 1, 800),
+			Description: hole.Description,
+		})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := removeCourseFacetRows(tx, courseID); err != nil {
+			return err
+		}
+		if err := tx.Create(&ranking).Error; err != nil {
+			return err
+		}
+		if len(holes) == 0 {
+			return nil
+		}
+		return tx.Create(&holes).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sync course facets: %v", err)
+	}
+	return nil
+}
+
+// RemoveCourse deletes a course's rankings and holes
+func (s *CourseFacetService) RemoveCourse(courseID uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	if err := removeCourseFacetRows(s.db, courseID); err != nil {
+		return fmt.Errorf("failed to remove course facets: %v", err)
+	}
+	return nil
+}
+
+// SyncMissing copies every course that has no course_rankings row yet, such
+// as courses saved before the table existed. It returns how many were
+// copied.
+func (s *CourseFacetService) SyncMissing() (int, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not connected")
+	}
+
+	var courses []CourseDB
+	err := s.db.Select("id", "course_data").
+		Where("id NOT IN (?)", s.db.Model(&CourseRanking{}).Select("course_id")).
+		Find(&courses).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find courses without facets: %v", err)
+	}
+
+	synced := 0
+	for _, courseDB := range courses {
+		var course Course
+		if err := json.Unmarshal([]byte(courseDB.CourseData), &course); err != nil {
+			log.Printf("[FACETS] Skipping course %d with unreadable course data: %v", courseDB.ID, err)
+			continue
+		}
+		if err := s.SyncCourse(courseDB.ID, course); err != nil {
+			return synced, err
+		}
+		synced++
+	}
+	return synced, nil
+}
+
+func removeCourseFacetRows(db *gorm.DB, courseID uint) error {
+	if err := db.Where("course_id = ?", courseID).Delete(&CourseRanking{}).Error; err != nil {
+		return err
+	}
+	return db.Where("course_id = ?", courseID).Delete(&CourseHole{}).Error
+}
+
+// optionalBetween returns nil for values outside the column's check
+func optionalBetween(value, min, max int) *int {
+	if value < min || value > max {
+		return nil
+	}
+	return &value
+}
+
+func optionalGrade(value string) *string {
+	if !api.IsGrade(value) {
+		return nil
+	}
+	return &value
+}
+
+// syncCourseFacets refreshes the course_rankings and course_holes rows after
+// a course's data changed. Failures are logged; course_data stays the source of truth.
+func syncCourseFacets(db *gorm.DB, courseID uint, course Course) {
+	service := &CourseFacetService{db: db}
+	if err := service.SyncCourse(courseID, course); err != nil {
+		log.Printf("[FACETS] Failed to sync course %d: %v", courseID, err)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func facetCourse(name, price string, handicap int, condition, walkability string, pars ...int) Course {
+	course := Course{
+		Name:    name,
+		Address: name + " Rd",
+		Ranks: Ranking{
+			Price:              price,
+			HandicapDifficulty: handicap,
+			Condition:          condition,
+			Walkability:        walkability,
+		},
+	}
+	for i, par := range pars {
+		course.Holes = append(course.Holes, Hole{Number: i + 1, Par: par})
+	}
+	return course
+}
+
+func nineHoles(par int) []int {
+	pars := make([]int, 9)
+	for i := range pars {
+		pars[i] = par
+	}
+	return pars
+}
+
+func seedFacetCourses(t *testing.T, db *gorm.DB) map[string]uint {
+	t.Helper()
+
+	courses := []Course{
+		facetCourse("Budget Nine", "$", 1, "C", "A", nineHoles(4)...),
+		facetCourse("Muni", "$$", 3, "B", "A", append(nineHoles(4), nineHoles(4)...)...),
+		facetCourse("Resort", "$$$$", 5, "S", "C", append(nineHoles(4), nineHoles(4)...)...),
+		facetCourse("Unranked", "", 0, "", ""),
+	}
+
+	service := NewDatabaseService()
+	ids := make(map[string]uint)
+	for _, course := range courses {
+		require.NoError(t, service.SaveCourseToDatabase(course, nil))
+		var courseDB CourseDB
+		require.NoError(t, db.Where("name = ?", course.Name).First(&courseDB).Error)
+		ids[course.Name] = courseDB.ID
+	}
+	return ids
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func TestCourseFacetService_FilterCourseIDs(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedFacetCourses(t, db)
+	service := NewCourseFacetService()
+
+	courseIDs, err := service.FilterCourseIDs(CourseFacetFilter{})
+	require.NoError(t, err)
+	assert.Nil(t, courseIDs, "no facet conditions means no filtering")
+
+	for name, test := range map[string]struct {
+		filter   CourseFacetFilter
+		expected []uint
+	}{
+		"price range":      {CourseFacetFilter{MinPrice: "$$", MaxPrice: "$$$"}, []uint{ids["Muni"]}},
+		"cheap":            {CourseFacetFilter{MaxPrice: "$$"}, []uint{ids["Budget Nine"], ids["Muni"]}},
+		"difficulty":       {CourseFacetFilter{MinHandicapDifficulty: intPtr(2), MaxHandicapDifficulty: intPtr(4)}, []uint{ids["Muni"]}},
+		"condition floor":  {CourseFacetFilter{MinGrades: map[string]string{"condition": "B"}}, []uint{ids["Muni"], ids["Resort"]}},
+		"two grade floors": {CourseFacetFilter{MinGrades: map[string]string{"condition": "B", "walkability": "A"}}, []uint{ids["Muni"]}},
+		"nine holes":       {CourseFacetFilter{Holes: intPtr(9)}, []uint{ids["Budget Nine"]}},
+		"par":              {CourseFacetFilter{MinPar: intPtr(70), MaxPar: intPtr(72)}, []uint{ids["Muni"], ids["Resort"]}},
+		"candidates":       {CourseFacetFilter{CourseIDs: []uint{ids["Resort"]}, MinPar: intPtr(70)}, []uint{ids["Resort"]}},
+		"no match":         {CourseFacetFilter{CourseIDs: []uint{}, MinPar: intPtr(70)}, []uint{}},
+	} {
+		courseIDs, err := service.FilterCourseIDs(test.filter)
+		require.NoError(t, err, name)
+		assert.Equal(t, test.expected, courseIDs, name)
+	}
+}
+
+func TestCourseFacetService_CountFacets(t *testing.T) {
+	db := setupTestDatabase(t)
+	seedFacetCourses(t, db)
+	service := NewCourseFacetService()
+
+	counts, err := service.CountFacets(CourseFacetFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"$": 1, "$$": 1, "$$$$": 1}, counts.Price)
+	assert.Equal(t, map[string]int{"1": 1, "3": 1, "5": 1}, counts.HandicapDifficulty)
+	assert.Equal(t, map[string]int{"S": 1, "B": 1, "C": 1}, counts.Grades["condition"])
+	assert.Equal(t, map[string]int{"9": 1, "18": 2}, counts.Holes)
+	assert.Equal(t, map[string]int{"36": 1, "72": 2}, counts.Par)
+	assert.Empty(t, counts.HazardDifficulty, "unset difficulties aren't counted")
+
+	counts, err = service.CountFacets(CourseFacetFilter{MinPrice: "$$", Holes: intPtr(18)})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"$$": 1, "$$$$": 1}, counts.Price, "price counts ignore the price filter")
+	assert.Equal(t, map[string]int{"18": 2}, counts.Holes, "hole counts ignore the holes filter")
+	assert.Equal(t, map[string]int{"3": 1, "5": 1}, counts.HandicapDifficulty)
+}
+
+func TestCourseFacetService_Sync(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedFacetCourses(t, db)
+	service := NewDatabaseService()
+
+	var courseDB CourseDB
+	require.NoError(t, db.First(&courseDB, ids["Muni"]).Error)
+	updated := facetCourse("Muni", "$$$", 3, "A", "A", nineHoles(3)...)
+	require.NoError(t, service.UpdateCourseWithOwnership(&courseDB, updated, 1))
+
+	var ranking CourseRanking
+	require.NoError(t, db.Where("course_id = ?", ids["Muni"]).First(&ranking).Error)
+	assert.Equal(t, "$$$", ranking.Price)
+	require.NotNil(t, ranking.Condition)
+	assert.Equal(t, "A", *ranking.Condition)
+	assert.Nil(t, ranking.Merch)
+
+	var holes int64
+	require.NoError(t, db.Model(&CourseHole{}).Where("course_id = ?", ids["Muni"]).Count(&holes).Error)
+	assert.Equal(t, int64(9), holes)
+
+	var rankings int64
+	require.NoError(t, service.DeleteCourse(ids["Muni"]))
+	require.NoError(t, db.Model(&CourseRanking{}).Where("course_id = ?", ids["Muni"]).Count(&rankings).Error)
+	assert.Zero(t, rankings)
+
+	t.Run("Values outside the table checks are stored as NULL", func(t *testing.T) {
+		odd := facetCourse("Odd", "$", 21, "x", "B", 4, 2, 4)
+		odd.Holes = append(odd.Holes, Hole{Number: 19, Par: 4})
+		odd.Holes[0].Yardage = 950
+		require.NoError(t, service.SaveCourseToDatabase(odd, nil))
+		var courseDB CourseDB
+		require.NoError(t, db.Where("name = ?", "Odd").First(&courseDB).Error)
+
+		var ranking CourseRanking
+		require.NoError(t, db.Where("course_id = ?", courseDB.ID).First(&ranking).Error)
+		assert.Nil(t, ranking.HandicapDifficulty)
+		assert.Nil(t, ranking.Condition)
+		var holes []CourseHole
+		require.NoError(t, db.Where("course_id = ?", courseDB.ID).Order("hole_number").Find(&holes).Error)
+		require.Len(t, holes, 3, "hole 19 isn't on the card")
+		assert.Nil(t, holes[0].Yardage)
+		assert.Nil(t, holes[1].Par)
+
+		courseIDs, err := NewCourseFacetService().FilterCourseIDs(CourseFacetFilter{CourseIDs: []uint{courseDB.ID}, MaxPar: intPtr(72)})
+		require.NoError(t, err)
+		assert.Empty(t, courseIDs, "a card missing a par has no total")
+	})
+
+	t.Run("Backfills courses saved outside the app", func(t *testing.T) {
+		data, err := json.Marshal(facetCourse("Imported", "$", 2, "D", "B", nineHoles(5)...))
+		require.NoError(t, err)
+		imported := CourseDB{Name: "Imported", Address: "Imported Rd", Hash: "imported", CourseData: string(data)}
+		require.NoError(t, db.Create(&imported).Error)
+		require.NoError(t, db.Create(&CourseDB{Name: "Broken", Address: "Broken Rd", Hash: "broken", CourseData: "{"}).Error)
+
+		synced, err := NewCourseFacetService().SyncMissing()
+		require.NoError(t, err)
+		assert.Equal(t, 1, synced, "unreadable course data is skipped")
+
+		courseIDs, err := NewCourseFacetService().FilterCourseIDs(CourseFacetFilter{Holes: intPtr(9), MinPar: intPtr(45)})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{imported.ID}, courseIDs)
+	})
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"course_management/services"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// halfEarthCircumferenceKm is as far apart as two points on the globe can be
+	halfEarthCircumferenceKm = math.Pi * services.EarthRadiusKm
+	// nearestFirstRadiusKm is where the fallback nearest-course search starts
+	// looking before widening
+	nearestFirstRadiusKm = 50.0
+)
+
+// courseGeographySQL derives the PostGIS point behind course_dbs.geog from the
+// latitude and longitude columns, so the two can't drift apart
+const courseGeographySQL = "CASE WHEN latitude IS NOT NULL AND longitude IS NOT NULL " +
+	"THEN ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography END"
+
+// geoPointSQL is a query point as a PostGIS geography; it takes the longitude then the latitude
+const geoPointSQL = "ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography"
+
+// geogColumnChecks caches, per connection, whether course_dbs has the PostGIS geog column
+var geogColumnChecks sync.Map
+
+// CourseGeoService finds courses by location: within a radius, inside map
+// bounds, or nearest to a point. On Postgres with PostGIS it uses the indexed
+// geog column; elsewhere it narrows by a latitude/longitude box and measures
+// great-circle distances itself.
+type CourseGeoService struct {
+	db *gorm.DB
+}
+
+func NewCourseGeoService() *CourseGeoService {
+	return &CourseGeoService{
+		db: GetDB(),
+	}
+}
+
+// CourseLocation is a located course found by a geo query
+type CourseLocation struct {
+	CourseID  uint
+	Name      string
+	Address   string
+	Latitude  float64
+	Longitude float64
+	CreatedBy *uint
+	Distance  *float64 // Kilometers from the query point; nil for bounds queries
+}
+
+// GeoBounds is a map viewport. West must be less than East.
+type GeoBounds struct {
+	North float64
+	South float64
+	East  float64
+	West  float64
+}
+
+// Nearby returns a page of the courses within radiusKm of a point, nearest
+// first, and how many there are in all. courseIDs limits the candidates; nil
+// means every course.
+func (s *CourseGeoService) Nearby(lat, lng, radiusKm float64, courseIDs []uint, limit, offset int) ([]CourseLocation, int, error) {
+	if s.db == nil {
+		return nil, 0, fmt.Errorf("database not connected")
+	}
+
+	if s.hasGeography() {
+		within := s.candidates(courseIDs).Where("ST_DWithin(geog, "+geoPointSQL+", ?)", lng, lat, radiusKm*1000)
+		var total int64
+		if err := within.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, 0, fmt.Errorf("failed to count nearby courses: %v", err)
+		}
+		var locations []CourseLocation
+		err := within.Select(courseLocationColumns+", ST_Distance(geog, "+geoPointSQL+") / 1000 AS distance", lng, lat).
+			Order("distance, id").Limit(limit).Offset(offset).
+			Scan(&locations).Error
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to find nearby courses: %v", err)
+		}
+		return locations, int(total), nil
+	}
+
+	locations, err := s.withinRadius(lat, lng, radiusKm, courseIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := len(locations)
+	start := min(offset, total)
+	end := min(start+limit, total)
+	return locations[start:end], total, nil
+}
+
+// Nearest returns the k courses closest to a point, nearest first
+func (s *CourseGeoService) Nearest(lat, lng float64, k int, courseIDs []uint) ([]CourseLocation, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	if s.hasGeography() {
+		var locations []CourseLocation
+		err := s.candidates(courseIDs).
+			Select(courseLocationColumns+", ST_Distance(geog, "+geoPointSQL+") / 1000 AS distance", lng, lat).
+			Where("geog IS NOT NULL").
+			Order(gorm.Expr("geog <-> "+geoPointSQL+", id", lng, lat)).
+			Limit(k).
+			Scan(&locations).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to find nearest courses: %v", err)
+		}
+		return locations, nil
+	}
+
+	// Widen the search until it holds k courses or covers the globe
+	for radius := nearestFirstRadiusKm; ; radius *= 4 {
+		locations, err := s.withinRadius(lat, lng, radius, courseIDs)
+		if err != nil {
+			return nil, err
+		}
+		if len(locations) >= k || radius >= halfEarthCircumferenceKm {
+			return locations[:min(k, len(locations))], nil
+		}
+	}
+}
+
+// InBounds returns the courses inside a map viewport, by name. Viewports are
+// latitude/longitude rectangles, so this uses the plain location index on
+// every database.
+func (s *CourseGeoService) InBounds(bounds GeoBounds, courseIDs []uint) ([]CourseLocation, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var locations []CourseLocation
+	err := s.candidates(courseIDs).
+		Select(courseLocationColumns).
+		Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", bounds.South, bounds.North, bounds.West, bounds.East).
+		Order("name, id").
+		Scan(&locations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find courses in bounds: %v", err)
+	}
+	return locations, nil
+}
+
+// Locations returns the located courses with the given IDs
+func (s *CourseGeoService) Locations(courseIDs []uint) ([]CourseLocation, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var locations []CourseLocation
+	if err := s.candidates(courseIDs).Select(courseLocationColumns).Order("id").Scan(&locations).Error; err != nil {
+		return nil, fmt.Errorf("failed to load course locations: %v", err)
+	}
+	return locations, nil
+}
+
+const courseLocationColumns = "id AS course_id, name, address, latitude, longitude, created_by"
+
+func (s *CourseGeoService) candidates(courseIDs []uint) *gorm.DB {
+	query := s.db.Model(&CourseDB{}).Where("latitude IS NOT NULL AND longitude IS NOT NULL")
+	if courseIDs != nil {
+		query = query.Where("id IN ?", courseIDs)
+	}
+	return query
+}
+
+// withinRadius is the fallback radius search: a latitude/longitude box that
+// contains the circle narrows the rows, then the haversine distance decides
+func (s *CourseGeoService) withinRadius(lat, lng, radiusKm float64, courseIDs []uint) ([]CourseLocation, error) {
+	query := s.candidates(courseIDs).Select(courseLocationColumns)
+
+	south, north, west, east, allLongitudes := boundingBox(lat, lng, radiusKm)
+	query = query.Where("latitude BETWEEN ? AND ?", south, north)
+	switch {
+	case allLongitudes:
+	case west < -180:
+		query = query.Where("(longitude >= ? OR longitude <= ?)", west+360, east)
+	case east > 180:
+		query = query.Where("(longitude >= ? OR longitude <= ?)", west, east-360)
+	default:
+		query = query.Where("longitude BETWEEN ? AND ?", west, east)
+	}
+
+	var candidates []CourseLocation
+	if err := query.Scan(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to find nearby courses: %v", err)
+	}
+
+	locations := candidates[:0]
+	for _, location := range candidates {
+		distance := services.HaversineKm(lat, lng, location.Latitude, location.Longitude)
+		if distance <= radiusKm {
+			location.Distance = &distance
+			locations = append(locations, location)
+		}
+	}
+	sort.SliceStable(locations, func(i, j int) bool {
+		if *locations[i].Distance != *locations[j].Distance {
+			return *locations[i].Distance < *locations[j].Distance
+		}
+		return locations[i].CourseID < locations[j].CourseID
+	})
+	return locations, nil
+}
+
+// hasGeography reports whether course_dbs has the PostGIS geog column that
+// CreatePerformanceIndexes adds when PostGIS is installed
+func (s *CourseGeoService) hasGeography() bool {
+	if s.db.Dialector.Name() != "postgres" {
+		return false
+	}
+	if cached, ok := geogColumnChecks.Load(s.db); ok {
+		return cached.(bool)
+	}
+
+	var columns int64
+	err := s.db.Raw("SELECT COUNT(*) FROM information_schema.columns WHERE table_name = 'course_dbs' AND column_name = 'geog'").
+		Scan(&columns).Error
+	available := err == nil && columns > 0
+	if err == nil {
+		geogColumnChecks.Store(s.db, available)
+	}
+	return available
+}
+
+// boundingBox returns a latitude/longitude box around a circle on the globe.
+// West and East may run past ±180 when the circle crosses the antimeridian,
+// and allLongitudes is set when it reaches a pole.
+func boundingBox(lat, lng, radiusKm float64) (south, north, west, east float64, allLongitudes bool) {
+	angular := radiusKm / services.EarthRadiusKm
+	south = lat - angular*180/math.Pi
+	north = lat + angular*180/math.Pi
+	if south <= -90 || north >= 90 {
+		return math.Max(south, -90), math.Min(north, 90), -180, 180, true
+	}
+
+	spread := math.Sin(angular) / math.Cos(lat*math.Pi/180)
+	if angular >= math.Pi/2 || spread >= 1 {
+		return south, north, -180, 180, true
+	}
+	deltaLng := math.Asin(spread) * 180 / math.Pi
+	return south, north, lng - deltaLng, lng + deltaLng, false
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"testing"
+
+	"course_management/api"
+	"course_management/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func seedGeoCourses(t *testing.T, db *gorm.DB) map[string]uint {
+	t.Helper()
+
+	places := []struct {
+		name     string
+		lat, lng float64
+	}{
+		{"Pinehurst No. 2", 35.1907, -79.4704},
+		{"Mid Pines", 35.1618, -79.4379},
+		{"Tobacco Road", 35.3580, -79.1756},
+		{"Pebble Beach", 36.5686, -121.9496},
+		{"Fiji West", -17.75, 179.9},
+		{"Fiji East", -17.75, -179.9},
+	}
+
+	ids := make(map[string]uint)
+	for _, place := range places {
+		lat, lng := place.lat, place.lng
+		course := CourseDB{Name: place.name, Address: place.name, Hash: place.name, CourseData: "{}", Latitude: &lat, Longitude: &lng}
+		require.NoError(t, db.Create(&course).Error)
+		ids[place.name] = course.ID
+	}
+	require.NoError(t, db.Create(&CourseDB{Name: "Unmapped", Address: "Somewhere", Hash: "unmapped", CourseData: "{}"}).Error)
+	return ids
+}
+
+func locationNames(locations []CourseLocation) []string {
+	names := make([]string, 0, len(locations))
+	for _, location := range locations {
+		names = append(names, location.Name)
+	}
+	return names
+}
+
+func TestCourseGeoService_Nearby(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedGeoCourses(t, db)
+	service := NewCourseGeoService()
+
+	locations, total, err := service.Nearby(35.19, -79.47, 50, nil, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []string{"Pinehurst No. 2", "Mid Pines", "Tobacco Road"}, locationNames(locations))
+	require.NotNil(t, locations[1].Distance)
+	assert.InDelta(t, 4.4, *locations[1].Distance, 0.2)
+
+	locations, total, err = service.Nearby(35.19, -79.47, 50, nil, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []string{"Mid Pines"}, locationNames(locations))
+
+	locations, _, err = service.Nearby(35.19, -79.47, 50, []uint{ids["Tobacco Road"]}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Tobacco Road"}, locationNames(locations))
+
+	locations, _, err = service.Nearby(-17.75, 179.95, 20, nil, 10, 0)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Fiji West", "Fiji East"}, locationNames(locations), "searches across the antimeridian")
+}
+
+func TestCourseGeoService_Nearest(t *testing.T) {
+	db := setupTestDatabase(t)
+	seedGeoCourses(t, db)
+	service := NewCourseGeoService()
+
+	locations, err := service.Nearest(36.0, -121.0, 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Pebble Beach", "Pinehurst No. 2"}, locationNames(locations), "widens the search until it finds k courses")
+	require.NotNil(t, locations[1].Distance)
+	assert.Greater(t, *locations[1].Distance, 3500.0)
+
+	locations, err = service.Nearest(0, 0, 50, nil)
+	require.NoError(t, err)
+	assert.Len(t, locations, 6, "every located course when k is more than there are")
+}
+
+func TestCourseGeoService_InBounds(t *testing.T) {
+	db := setupTestDatabase(t)
+	seedGeoCourses(t, db)
+	service := NewCourseGeoService()
+
+	locations, err := service.InBounds(GeoBounds{North: 35.3, South: 35.0, East: -79.0, West: -80.0}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Mid Pines", "Pinehurst No. 2"}, locationNames(locations))
+	assert.Nil(t, locations[0].Distance)
+}
+
+func TestGetCoursesInBounds_RatingFilter(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedGeoCourses(t, db)
+	require.NoError(t, db.Model(&CourseDB{}).Where("id = ?", ids["Pinehurst No. 2"]).
+		Update("course_data", `{"overallRating": "S"}`).Error)
+	require.NoError(t, db.Model(&CourseDB{}).Where("id = ?", ids["Mid Pines"]).
+		Update("course_data", `{"overallRating": "C"}`).Error)
+	lat, lng := 35.2, -79.5
+	require.NoError(t, db.Create(&CourseDB{Name: "Unrated", Address: "Unrated", Hash: "unrated", CourseData: "{}", Latitude: &lat, Longitude: &lng}).Error)
+
+	bounds := &api.BoundsRequest{NorthLat: 35.3, SouthLat: 35.0, EastLng: -79.0, WestLng: -80.0}
+	courses, err := (&APIDBServiceAdapter{}).GetCoursesInBounds(bounds, nil)
+	require.NoError(t, err)
+	require.Len(t, courses, 3)
+	ratings := make(map[string]*float64)
+	for _, course := range courses {
+		ratings[course.Name] = course.OverallRating
+	}
+	assert.Equal(t, 10.0, *ratings["Pinehurst No. 2"])
+	assert.Equal(t, 4.0, *ratings["Mid Pines"])
+	assert.Nil(t, ratings["Unrated"])
+
+	minRating := 5.0
+	bounds.MinRating = &minRating
+	courses, err = (&APIDBServiceAdapter{}).GetCoursesInBounds(bounds, nil)
+	require.NoError(t, err)
+	require.Len(t, courses, 1)
+	assert.Equal(t, "Pinehurst No. 2", courses[0].Name)
+
+	maxRating := 5.0
+	bounds.MinRating, bounds.MaxRating = nil, &maxRating
+	courses, err = (&APIDBServiceAdapter{}).GetCoursesInBounds(bounds, nil)
+	require.NoError(t, err)
+	require.Len(t, courses, 1)
+	assert.Equal(t, "Mid Pines", courses[0].Name)
+}
+
+func TestHaversineAndBoundingBox(t *testing.T) {
+	assert.InDelta(t, 343.5, services.HaversineKm(51.5074, -0.1278, 48.8566, 2.3522), 1, "London to Paris")
+	assert.Zero(t, services.HaversineKm(10, 10, 10, 10))
+
+	south, north, west, east, all := boundingBox(0, 179.9, 100)
+	assert.False(t, all)
+	assert.InDelta(t, -0.9, south, 0.01)
+	assert.InDelta(t, 0.9, north, 0.01)
+	assert.Less(t, west, 179.9)
+	assert.Greater(t, east, 180.0)
+
+	_, _, _, _, all = boundingBox(89.5, 0, 100)
+	assert.True(t, all, "circles reaching a pole cover every longitude")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"course_management/services"
+
+	"gorm.io/gorm"
+)
+
+// courseGeocodeTimeout bounds a background geocode, including any wait under
+// the provider's rate limit
+const courseGeocodeTimeout = 2 * time.Minute
+
+// geocodingService geocodes course addresses and backs the geocoding API. It
+// is nil when no provider is configured.
+var geocodingService services.GeocodingService
+
+// InitGeocodingService sets up geocoding from the environment; see
+// services.LoadGeocodingConfig for the variables it reads
+func InitGeocodingService() {
+	geocodingService = services.NewGeocodingServiceFromConfig(GetDB(), services.LoadGeocodingConfig())
+}
+
+// GetGeocodingService returns the geocoding service, or nil when geocoding is off
+func GetGeocodingService() services.GeocodingService {
+	return geocodingService
+}
+
+// geocodeCourseAsync locates a course from its address in the background.
+// Call it when a course is created or its address changes.
+func geocodeCourseAsync(db *gorm.DB, courseID uint, address string) {
+	if geocodingService == nil || strings.TrimSpace(address) == "" {
+		return
+	}
+	go geocodeCourse(db, geocodingService, courseID, address)
+}
+
+// geocodeCourse stores a course's geocoded location. The course keeps its old
+// coordinates when geocoding fails, and a result for an address the course no
+// longer has is dropped.
+func geocodeCourse(db *gorm.DB, geocoder services.GeocodingService, courseID uint, address string) {
+	ctx, cancel := context.WithTimeout(context.Background(), courseGeocodeTimeout)
+	defer cancel()
+
+	result, err := geocoder.Geocode(ctx, address)
+	if err != nil {
+		log.Printf("[GEOCODE] Failed to geocode course %d: %v", courseID, err)
+		return
+	}
+
+	update := db.Model(&CourseDB{}).
+		Where("id = ? AND address = ?", courseID, address).
+		Updates(map[string]interface{}{"latitude": result.Latitude, "longitude": result.Longitude})
+	if update.Error != nil {
+		log.Printf("[GEOCODE] Failed to save location for course %d: %v", courseID, update.Error)
+		return
+	}
+	if update.RowsAffected == 0 {
+		return
+	}
+
+	invalidateCourseClusters(db)
+	invalidateCourseTiles(db)
+	log.Printf("[GEOCODE] Located course %d at %.6f, %.6f", courseID, result.Latitude, result.Longitude)
+}
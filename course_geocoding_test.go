@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"course_management/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeocodeCourse(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedGeoCourses(t, db)
+	geocoder := services.NewGeocodingService(nil, services.NewFakeGeocoder([]services.GeocodeResult{
+		{Address: "Somewhere", Latitude: 36.57, Longitude: -121.95},
+	}), 0)
+	pebbleBay := GeoBounds{North: 37, South: 36, East: -121, West: -122}
+	clusterService := NewCourseClusterService()
+
+	clusters, err := clusterService.Clusters(pebbleBay, 5, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, clusterCounts(clusters))
+
+	var unmapped CourseDB
+	require.NoError(t, db.Where("name = ?", "Unmapped").First(&unmapped).Error)
+
+	geocodeCourse(db, geocoder, unmapped.ID, "Elsewhere")
+	require.NoError(t, db.First(&unmapped, unmapped.ID).Error)
+	assert.Nil(t, unmapped.Latitude, "addresses the provider can't find leave the course alone")
+
+	geocodeCourse(db, geocoder, ids["Pebble Beach"], "Somewhere")
+	var pebble CourseDB
+	require.NoError(t, db.First(&pebble, ids["Pebble Beach"]).Error)
+	assert.Equal(t, 36.5686, *pebble.Latitude, "results for an address the course no longer has are dropped")
+
+	geocodeCourse(db, geocoder, unmapped.ID, "Somewhere")
+	require.NoError(t, db.First(&unmapped, unmapped.ID).Error)
+	require.NotNil(t, unmapped.Latitude)
+	assert.Equal(t, 36.57, *unmapped.Latitude)
+	assert.Equal(t, -121.95, *unmapped.Longitude)
+
+	clusters, err = clusterService.Clusters(pebbleBay, 5, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, clusterCounts(clusters), "locating a course invalidates the clusters")
+}
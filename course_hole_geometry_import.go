@@ -0,0 +1,619 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"course_management/services"
+
+	"gorm.io/gorm"
+)
+
+// holeMatchMeters is how close a feature without a hole number must be to a
+// golf=hole line to be placed on that hole
+const holeMatchMeters = 100.0
+
+// HoleGeometryImport summarizes an imported hole layout
+type HoleGeometryImport struct {
+	Format   string // "geojson" or "osm"
+	Holes    []int  // Holes whose geometry was replaced, in order
+	Features int    // Golf features placed on a hole
+	Skipped  int    // Golf features that couldn't be placed or read
+}
+
+// golfFeature is a golf feature read from an import, before it is placed on a
+// hole. Kinds follow the OpenStreetMap golf key; pins are read as green
+// centres.
+type golfFeature struct {
+	Kind   string
+	Hole   int // Zero when the source doesn't number it
+	Name   string
+	Points []GeoPoint // One point for a node; a ring without its closing point for an area
+	Area   bool
+}
+
+// golfFeatureKinds are the golf tags an import reads. The front and back of
+// the green aren't OpenStreetMap tags, but the layout's own GeoJSON has them.
+var golfFeatureKinds = map[string]string{
+	"hole":                 "hole",
+	"tee":                  "tee",
+	"green":                "green",
+	"green_center":         "green_center",
+	"green_front":          "green_front",
+	"green_back":           "green_back",
+	"pin":                  "green_center",
+	"fairway":              "fairway",
+	"bunker":               "bunker",
+	"water_hazard":         "water_hazard",
+	"lateral_water_hazard": "lateral_water_hazard",
+}
+
+// Import replaces the geometry of every hole found in a GeoJSON
+// FeatureCollection, an OSM XML extract or an Overpass API JSON response.
+// Features are read by their golf tag, numbered by a hole or ref property, and
+// those without a number are placed on the nearest golf=hole line. A hole
+// mapped only as a line gets its tee and green centre from the line's ends.
+// Holes missing from the import keep their geometry. Nothing is saved unless
+// every hole is valid.
+func (s *CourseHoleGeometryService) Import(courseID uint, data []byte, userID uint) (*HoleGeometryImport, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	course, err := s.course(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	format, features, skipped, err := parseGolfFeatures(data)
+	if err != nil {
+		return nil, err
+	}
+	holes, placed, unplaced := placeGolfFeatures(features)
+	summary := &HoleGeometryImport{Format: format, Features: placed, Skipped: skipped + unplaced}
+	if len(holes) == 0 {
+		return nil, fmt.Errorf("%w: no numbered golf holes were found", ErrInvalidHoleGeometry)
+	}
+
+	for number := range holes {
+		summary.Holes = append(summary.Holes, number)
+	}
+	sort.Ints(summary.Holes)
+	for _, number := range summary.Holes {
+		if err := normalizeHoleGeometry(holes[number], courseLocation(course)); err != nil {
+			return nil, fmt.Errorf("%w: hole %d: %v", ErrInvalidHoleGeometry, number, err)
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, number := range summary.Holes {
+			if err := saveHoleGeometry(tx, courseID, number, *holes[number], format, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// parseGolfFeatures reads golf features from any supported format, returning
+// the format and how many golf features it couldn't read
+func parseGolfFeatures(data []byte) (string, []golfFeature, int, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		features, skipped, err := parseOSMXML(trimmed)
+		return "osm", features, skipped, err
+	}
+
+	var probe struct {
+		Type     string          `json:"type"`
+		Elements json.RawMessage `json:"elements"`
+	}
+	if err := json.Unmarshal(trimmed, &probe); err == nil {
+		switch {
+		case probe.Elements != nil:
+			features, skipped, err := parseOverpassJSON(trimmed)
+			return "osm", features, skipped, err
+		case probe.Type == "FeatureCollection":
+			features, skipped, err := parseGeoJSONGolfFeatures(trimmed)
+			return "geojson", features, skipped, err
+		}
+	}
+	return "", nil, 0, fmt.Errorf("%w: imports must be a GeoJSON FeatureCollection, OSM XML or Overpass JSON", ErrInvalidHoleGeometry)
+}
+
+func parseGeoJSONGolfFeatures(data []byte) ([]golfFeature, int, error) {
+	var collection struct {
+		Features []struct {
+			Geometry *struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, 0, fmt.Errorf("%w: the GeoJSON couldn't be read", ErrInvalidHoleGeometry)
+	}
+
+	var features []golfFeature
+	skipped := 0
+	for _, item := range collection.Features {
+		golf, _ := item.Properties["golf"].(string)
+		kind, ok := golfFeatureKinds[golf]
+		if !ok {
+			continue
+		}
+
+		feature := golfFeature{Kind: kind, Hole: holeNumberProperty(item.Properties["hole"])}
+		if feature.Hole == 0 {
+			feature.Hole = holeNumberProperty(item.Properties["ref"])
+		}
+		feature.Name = golfFeatureName(func(key string) string {
+			value, _ := item.Properties[key].(string)
+			return value
+		})
+
+		if item.Geometry == nil || !readGeoJSONGeometry(item.Geometry.Type, item.Geometry.Coordinates, &feature) {
+			skipped++
+			continue
+		}
+		features = append(features, feature)
+	}
+	return features, skipped, nil
+}
+
+// readGeoJSONGeometry sets a feature's points from a Point, LineString,
+// Polygon or MultiPolygon, using the outer ring of the largest polygon
+func readGeoJSONGeometry(kind string, coordinates json.RawMessage, feature *golfFeature) bool {
+	switch kind {
+	case "Point":
+		var position []float64
+		if json.Unmarshal(coordinates, &position) != nil || len(position) < 2 {
+			return false
+		}
+		feature.Points = []GeoPoint{{Latitude: position[1], Longitude: position[0]}}
+	case "LineString":
+		var positions [][]float64
+		if json.Unmarshal(coordinates, &positions) != nil {
+			return false
+		}
+		feature.Points = geoPointsFromPositions(positions)
+		feature.Area = isClosedWay(feature.Kind, feature.Points)
+	case "Polygon":
+		var rings [][][]float64
+		if json.Unmarshal(coordinates, &rings) != nil || len(rings) == 0 {
+			return false
+		}
+		feature.Points, feature.Area = geoPointsFromPositions(rings[0]), true
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if json.Unmarshal(coordinates, &polygons) != nil {
+			return false
+		}
+		largest := 0.0
+		for _, rings := range polygons {
+			if len(rings) == 0 {
+				continue
+			}
+			ring := openRing(geoPointsFromPositions(rings[0]))
+			if len(ring) >= 3 && polygonArea(ring) > largest {
+				feature.Points, feature.Area, largest = ring, true, polygonArea(ring)
+			}
+		}
+	default:
+		return false
+	}
+	return finishGolfFeature(feature)
+}
+
+func geoPointsFromPositions(positions [][]float64) []GeoPoint {
+	points := make([]GeoPoint, 0, len(positions))
+	for _, position := range positions {
+		if len(position) >= 2 {
+			points = append(points, GeoPoint{Latitude: position[1], Longitude: position[0]})
+		}
+	}
+	return points
+}
+
+// osmElement is a node, way or relation from any OSM format
+type osmElement struct {
+	Type     string
+	ID       int64
+	Point    GeoPoint
+	Refs     []int64
+	Geometry []GeoPoint // Way positions when the source includes them
+	Members  []osmMember
+	Tags     map[string]string
+}
+
+func parseOSMXML(data []byte) ([]golfFeature, int, error) {
+	type osmTag struct {
+		Key   string `xml:"k,attr"`
+		Value string `xml:"v,attr"`
+	}
+	var document struct {
+		Nodes []struct {
+			ID   int64    `xml:"id,attr"`
+			Lat  float64  `xml:"lat,attr"`
+			Lon  float64  `xml:"lon,attr"`
+			Tags []osmTag `xml:"tag"`
+		} `xml:"node"`
+		Ways []struct {
+			ID   int64 `xml:"id,attr"`
+			Refs []struct {
+				Ref int64 `xml:"ref,attr"`
+			} `xml:"nd"`
+			Tags []osmTag `xml:"tag"`
+		} `xml:"way"`
+		Relations []struct {
+			Tags []osmTag `xml:"tag"`
+		} `xml:"relation"`
+	}
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return nil, 0, fmt.Errorf("%w: the OSM XML couldn't be read", ErrInvalidHoleGeometry)
+	}
+
+	tags := func(list []osmTag) map[string]string {
+		values := make(map[string]string, len(list))
+		for _, tag := range list {
+			values[tag.Key] = tag.Value
+		}
+		return values
+	}
+
+	var elements []osmElement
+	for _, node := range document.Nodes {
+		elements = append(elements, osmElement{
+			Type:  "node",
+			ID:    node.ID,
+			Point: GeoPoint{Latitude: node.Lat, Longitude: node.Lon},
+			Tags:  tags(node.Tags),
+		})
+	}
+	for _, way := range document.Ways {
+		element := osmElement{Type: "way", ID: way.ID, Tags: tags(way.Tags)}
+		for _, ref := range way.Refs {
+			element.Refs = append(element.Refs, ref.Ref)
+		}
+		elements = append(elements, element)
+	}
+	for _, relation := range document.Relations {
+		elements = append(elements, osmElement{Type: "relation", Tags: tags(relation.Tags)})
+	}
+	features, skipped := osmGolfFeatures(elements)
+	return features, skipped, nil
+}
+
+func parseOverpassJSON(data []byte) ([]golfFeature, int, error) {
+	var response struct {
+		Elements []struct {
+			Type     string  `json:"type"`
+			ID       int64   `json:"id"`
+			Lat      float64 `json:"lat"`
+			Lon      float64 `json:"lon"`
+			Nodes    []int64 `json:"nodes"`
+			Geometry []struct {
+				Lat float64 `json:"lat"`
+				Lon float64 `json:"lon"`
+			} `json:"geometry"` // Present when the query asks for out geom
+			Tags map[string]string `json:"tags"`
+		} `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, 0, fmt.Errorf("%w: the Overpass JSON couldn't be read", ErrInvalidHoleGeometry)
+	}
+
+	elements := make([]osmElement, 0, len(response.Elements))
+	for _, item := range response.Elements {
+		element := osmElement{
+			Type:  item.Type,
+			ID:    item.ID,
+			Point: GeoPoint{Latitude: item.Lat, Longitude: item.Lon},
+			Refs:  item.Nodes,
+			Tags:  item.Tags,
+		}
+		for _, position := range item.Geometry {
+			element.Geometry = append(element.Geometry, GeoPoint{Latitude: position.Lat, Longitude: position.Lon})
+		}
+		elements = append(elements, element)
+	}
+	features, skipped := osmGolfFeatures(elements)
+	return features, skipped, nil
+}
+
+// osmGolfFeatures turns golf-tagged nodes and ways into features, resolving
+// way nodes by reference. Relations, such as multipolygon fairways, aren't
+// read and are counted as skipped.
+func osmGolfFeatures(elements []osmElement) ([]golfFeature, int) {
+	nodes := make(map[int64]GeoPoint)
+	for _, element := range elements {
+		if element.Type == "node" {
+			nodes[element.ID] = element.Point
+		}
+	}
+
+	var features []golfFeature
+	skipped := 0
+	for _, element := range elements {
+		kind, ok := golfFeatureKinds[element.Tags["golf"]]
+		if !ok {
+			continue
+		}
+
+		feature := golfFeature{
+			Kind: kind,
+			Hole: holeNumberProperty(element.Tags["ref"]),
+			Name: golfFeatureName(func(key string) string { return element.Tags[key] }),
+		}
+		switch element.Type {
+		case "node":
+			feature.Points = []GeoPoint{element.Point}
+		case "way":
+			feature.Points = element.Geometry
+			if len(feature.Points) == 0 {
+				for _, ref := range element.Refs {
+					point, found := nodes[ref]
+					if !found {
+						feature.Points = nil
+						break
+					}
+					feature.Points = append(feature.Points, point)
+				}
+			}
+			feature.Area = isClosedWay(kind, feature.Points)
+		}
+
+		if !finishGolfFeature(&feature) {
+			skipped++
+			continue
+		}
+		features = append(features, feature)
+	}
+	return features, skipped
+}
+
+// finishGolfFeature drops an area's closing point and reports whether the
+// feature has enough points to use
+func finishGolfFeature(feature *golfFeature) bool {
+	if feature.Area {
+		feature.Points = openRing(feature.Points)
+		return len(feature.Points) >= 3
+	}
+	if feature.Kind == "hole" {
+		return len(feature.Points) >= 2
+	}
+	return len(feature.Points) > 0
+}
+
+// isClosedWay reports whether a line ends where it starts, which outlines an
+// area for every golf feature but a hole's line of play
+func isClosedWay(kind string, points []GeoPoint) bool {
+	return kind != "hole" && len(points) >= 4 && points[0] == points[len(points)-1]
+}
+
+func openRing(ring []GeoPoint) []GeoPoint {
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		return ring[:len(ring)-1]
+	}
+	return ring
+}
+
+// holeNumberProperty reads a hole number from a number or a string such as "7"
+func holeNumberProperty(value interface{}) int {
+	switch number := value.(type) {
+	case float64:
+		if number == math.Trunc(number) && number >= 1 && number <= MaxHoleNumber {
+			return int(number)
+		}
+	case string:
+		if parsed, err := strconv.Atoi(strings.TrimSpace(number)); err == nil && parsed >= 1 && parsed <= MaxHoleNumber {
+			return parsed
+		}
+	}
+	return 0
+}
+
+// golfFeatureName is a feature's name tag, falling back to its colour, which
+// is how tees are often told apart
+func golfFeatureName(tag func(key string) string) string {
+	for _, key := range []string{"name", "colour"} {
+		if value := strings.TrimSpace(tag(key)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// placeGolfFeatures sorts features onto holes. It returns the geometry of
+// each hole, how many features were placed, and how many weren't.
+func placeGolfFeatures(features []golfFeature) (map[int]*HoleGeometry, int, int) {
+	lines := make(map[int][]GeoPoint)
+	for _, feature := range features {
+		if feature.Kind == "hole" && feature.Hole > 0 && lines[feature.Hole] == nil {
+			lines[feature.Hole] = feature.Points
+		}
+	}
+
+	holes := make(map[int]*HoleGeometry)
+	hole := func(number int) *HoleGeometry {
+		if holes[number] == nil {
+			holes[number] = &HoleGeometry{}
+		}
+		return holes[number]
+	}
+
+	placed, skipped := 0, 0
+	for _, feature := range features {
+		if feature.Kind == "hole" {
+			if feature.Hole > 0 && !feature.Area {
+				hole(feature.Hole)
+				placed++
+			} else {
+				skipped++
+			}
+			continue
+		}
+
+		number := feature.Hole
+		if number == 0 {
+			number = nearestHoleLine(feature, lines)
+		}
+		if number == 0 || !addGolfFeature(hole(number), feature) {
+			skipped++
+			continue
+		}
+		placed++
+	}
+
+	for number, geometry := range holes {
+		line := lines[number]
+		if geometry.GreenCenter != nil && len(geometry.Green) >= 3 && !polygonContains(geometry.Green, *geometry.GreenCenter) {
+			geometry.GreenCenter = nil
+		}
+		if len(line) >= 2 && len(geometry.Tees) == 0 {
+			geometry.Tees = []HoleTee{{Name: defaultHoleTeeName, Point: line[0]}}
+		}
+		if len(line) >= 2 && geometry.GreenCenter == nil && len(geometry.Green) == 0 {
+			end := line[len(line)-1]
+			geometry.GreenCenter = &end
+		}
+	}
+	return holes, placed, skipped
+}
+
+// addGolfFeature adds a feature to a hole, reporting false when the hole
+// already has one of that kind or the feature's shape doesn't suit it
+func addGolfFeature(geometry *HoleGeometry, feature golfFeature) bool {
+	center := feature.Points[0]
+	if feature.Area {
+		center = polygonCentroid(feature.Points)
+	}
+
+	switch feature.Kind {
+	case "tee":
+		if len(geometry.Tees) >= maxHoleTees {
+			return false
+		}
+		geometry.Tees = append(geometry.Tees, HoleTee{Name: uniqueTeeName(geometry.Tees, feature.Name), Point: center})
+	case "green":
+		if !feature.Area || len(geometry.Green) > 0 {
+			return false
+		}
+		geometry.Green = feature.Points
+	case "green_center":
+		if geometry.GreenCenter != nil {
+			return false
+		}
+		geometry.GreenCenter = &center
+	case "green_front", "green_back":
+		edge := &geometry.GreenFront
+		if feature.Kind == "green_back" {
+			edge = &geometry.GreenBack
+		}
+		if feature.Area || *edge != nil {
+			return false
+		}
+		*edge = &center
+	case "fairway":
+		if !feature.Area {
+			return false
+		}
+		if len(geometry.Fairway) > 0 && polygonArea(geometry.Fairway) >= polygonArea(feature.Points) {
+			return false
+		}
+		geometry.Fairway = feature.Points
+	default:
+		if len(geometry.Hazards)+len(geometry.HazardPoints) >= maxHoleHazards {
+			return false
+		}
+		switch {
+		case feature.Area:
+			geometry.Hazards = append(geometry.Hazards, HoleHazard{Kind: feature.Kind, Polygon: feature.Points})
+		case len(feature.Points) == 1:
+			geometry.HazardPoints = append(geometry.HazardPoints, HoleHazardPoint{Kind: feature.Kind, Point: center})
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueTeeName names a tee, numbering repeats such as a second unnamed tee
+func uniqueTeeName(tees []HoleTee, name string) string {
+	if name == "" {
+		name = defaultHoleTeeName
+	}
+	if len(name) > maxHoleTeeNameLength {
+		name = strings.TrimSpace(name[:maxHoleTeeNameLength-3])
+	}
+
+	taken := func(candidate string) bool {
+		for _, tee := range tees {
+			if strings.EqualFold(tee.Name, candidate) {
+				return true
+			}
+		}
+		return false
+	}
+	candidate := name
+	for n := 2; taken(candidate); n++ {
+		candidate = fmt.Sprintf("%s %d", name, n)
+	}
+	return candidate
+}
+
+// nearestHoleLine finds the hole a feature belongs to by its distance to each
+// golf=hole line: tees from the line's start, greens from its end, and
+// everything else from anywhere along it. It returns zero when no line is
+// close enough.
+func nearestHoleLine(feature golfFeature, lines map[int][]GeoPoint) int {
+	point := feature.Points[0]
+	if feature.Area {
+		point = polygonCentroid(feature.Points)
+	}
+
+	best, bestDistance := 0, holeMatchMeters
+	for number, line := range lines {
+		var distance float64
+		switch feature.Kind {
+		case "tee":
+			distance = services.HaversineKm(point.Latitude, point.Longitude, line[0].Latitude, line[0].Longitude) * 1000
+		case "green", "green_center", "green_front", "green_back":
+			end := line[len(line)-1]
+			distance = services.HaversineKm(point.Latitude, point.Longitude, end.Latitude, end.Longitude) * 1000
+		default:
+			distance = distanceToLineMeters(point, line)
+		}
+		if distance < bestDistance || (distance == bestDistance && number < best) {
+			best, bestDistance = number, distance
+		}
+	}
+	return best
+}
+
+// distanceToLineMeters is the distance from a point to the nearest part of a line
+func distanceToLineMeters(point GeoPoint, line []GeoPoint) float64 {
+	projected := projectPoints(line, point)
+	nearest := math.Inf(1)
+	for i := 0; i+1 < len(projected); i++ {
+		a, b := projected[i], projected[i+1]
+		dx, dy := b.X-a.X, b.Y-a.Y
+		t := 0.0
+		if length := dx*dx + dy*dy; length > 0 {
+			t = math.Max(0, math.Min(1, -(a.X*dx+a.Y*dy)/length))
+		}
+		nearest = math.Min(nearest, math.Hypot(a.X+t*dx, a.Y+t*dy))
+	}
+	return nearest
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// osmSandhills maps two holes north of Pinehurst No. 2. Only the hole lines
+// are numbered, as in most OpenStreetMap golf courses; hole 2 is mapped only
+// as a line, and the clubhouse and an unconnected way aren't hole features.
+const osmSandhills = `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6">
+  <node id="1" lat="35.1900" lon="-79.4720"/>
+  <node id="2" lat="35.1930" lon="-79.4700"/>
+  <node id="3" lat="35.1940" lon="-79.4700"/>
+  <node id="4" lat="35.1960" lon="-79.4650"/>
+  <node id="10" lat="35.1928" lon="-79.4702"/>
+  <node id="11" lat="35.1928" lon="-79.4698"/>
+  <node id="12" lat="35.1932" lon="-79.4698"/>
+  <node id="13" lat="35.1932" lon="-79.4702"/>
+  <node id="20" lat="35.19005" lon="-79.47205"><tag k="golf" v="tee"/><tag k="colour" v="blue"/></node>
+  <node id="21" lat="35.19010" lon="-79.47195"><tag k="golf" v="tee"/></node>
+  <node id="22" lat="35.19301" lon="-79.47001"><tag k="golf" v="pin"/></node>
+  <node id="30" lat="35.1915" lon="-79.4600"><tag k="building" v="clubhouse"/></node>
+  <way id="100"><nd ref="1"/><nd ref="2"/><tag k="golf" v="hole"/><tag k="ref" v="1"/><tag k="par" v="4"/></way>
+  <way id="101"><nd ref="3"/><nd ref="4"/><tag k="golf" v="hole"/><tag k="ref" v="2"/></way>
+  <way id="110"><nd ref="10"/><nd ref="11"/><nd ref="12"/><nd ref="13"/><nd ref="10"/><tag k="golf" v="green"/></way>
+  <way id="111"><nd ref="10"/><nd ref="99"/><nd ref="12"/><nd ref="10"/><tag k="golf" v="bunker"/></way>
+  <relation id="200"><tag k="golf" v="fairway"/><tag k="type" v="multipolygon"/></relation>
+</osm>`
+
+func TestParseGolfFeatures(t *testing.T) {
+	format, features, skipped, err := parseGolfFeatures([]byte(osmSandhills))
+	require.NoError(t, err)
+	assert.Equal(t, "osm", format)
+	assert.Equal(t, 2, skipped, "the bunker with a missing node and the relation")
+
+	kinds := make(map[string]int)
+	for _, feature := range features {
+		kinds[feature.Kind]++
+	}
+	assert.Equal(t, map[string]int{"hole": 2, "tee": 2, "green_center": 1, "green": 1}, kinds)
+
+	for _, feature := range features {
+		if feature.Kind == "green" {
+			assert.True(t, feature.Area)
+			assert.Len(t, feature.Points, 4, "closing node dropped")
+		}
+		if feature.Kind == "hole" {
+			assert.False(t, feature.Area)
+		}
+	}
+
+	overpass := `{"version": 0.6, "elements": [
+		{"type": "way", "id": 1, "tags": {"golf": "green", "ref": "3"},
+		 "geometry": [{"lat": 35.19, "lon": -79.47}, {"lat": 35.19, "lon": -79.4696}, {"lat": 35.1904, "lon": -79.4696}, {"lat": 35.19, "lon": -79.47}]},
+		{"type": "node", "id": 2, "lat": 35.1880, "lon": -79.4710, "tags": {"golf": "tee", "ref": "3", "name": "Championship"}}
+	]}`
+	format, features, _, err = parseGolfFeatures([]byte(overpass))
+	require.NoError(t, err)
+	assert.Equal(t, "osm", format)
+	require.Len(t, features, 2)
+	assert.Equal(t, 3, features[0].Hole)
+	assert.Len(t, features[0].Points, 3)
+	assert.Equal(t, "Championship", features[1].Name)
+
+	_, _, _, err = parseGolfFeatures([]byte(`{"type": "Feature"}`))
+	assert.ErrorIs(t, err, ErrInvalidHoleGeometry)
+	_, _, _, err = parseGolfFeatures([]byte(`not a map`))
+	assert.ErrorIs(t, err, ErrInvalidHoleGeometry)
+}
+
+func TestPlaceGolfFeatures(t *testing.T) {
+	_, features, _, err := parseGolfFeatures([]byte(osmSandhills))
+	require.NoError(t, err)
+
+	holes, placed, skipped := placeGolfFeatures(features)
+	assert.Equal(t, 6, placed)
+	assert.Zero(t, skipped)
+	require.Len(t, holes, 2)
+
+	first := holes[1]
+	require.Len(t, first.Tees, 2)
+	assert.Equal(t, "blue", first.Tees[0].Name)
+	assert.Equal(t, "Tee", first.Tees[1].Name)
+	assert.Len(t, first.Green, 4)
+	assert.Equal(t, GeoPoint{Latitude: 35.19301, Longitude: -79.47001}, *first.GreenCenter, "the pin")
+
+	second := holes[2]
+	assert.Equal(t, []HoleTee{{Name: "Tee", Point: GeoPoint{Latitude: 35.1940, Longitude: -79.4700}}}, second.Tees, "from the start of the line")
+	assert.Equal(t, GeoPoint{Latitude: 35.1960, Longitude: -79.4650}, *second.GreenCenter, "at the end of the line")
+
+	// Features far from every hole line, or without any line to match, aren't placed
+	far := golfFeature{Kind: "bunker", Area: true, Points: square(35.2100, -79.4700, 0.0001)}
+	_, _, skipped = placeGolfFeatures(append(features, far))
+	assert.Equal(t, 1, skipped)
+	holes, _, skipped = placeGolfFeatures([]golfFeature{{Kind: "tee", Points: []GeoPoint{{Latitude: 35.19, Longitude: -79.47}}}})
+	assert.Empty(t, holes)
+	assert.Equal(t, 1, skipped)
+
+	// A pin off the green is dropped for the green's middle
+	pin := golfFeature{Kind: "green_center", Hole: 4, Points: []GeoPoint{{Latitude: 35.1800, Longitude: -79.4700}}}
+	green := golfFeature{Kind: "green", Hole: 4, Area: true, Points: square(35.1850, -79.4700, 0.0002)}
+	bunker := golfFeature{Kind: "bunker", Hole: 4, Points: []GeoPoint{{Latitude: 35.1840, Longitude: -79.4700}}}
+	holes, _, _ = placeGolfFeatures([]golfFeature{pin, green, bunker})
+	assert.Nil(t, holes[4].GreenCenter)
+	assert.Equal(t, []HoleHazardPoint{{Kind: "bunker", Point: bunker.Points[0]}}, holes[4].HazardPoints, "a hazard mapped as a node")
+
+	assert.Equal(t, "Blue 2", uniqueTeeName([]HoleTee{{Name: "blue"}}, "Blue"))
+}
+
+func TestCourseHoleGeometryService_Import(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	ids := seedGeoCourses(t, db)
+	courseID := ids["Pinehurst No. 2"]
+	service := NewCourseHoleGeometryService()
+
+	// A hole mapped by hand that the import doesn't touch
+	kept := HoleGeometry{Tees: []HoleTee{{Name: "Blue", Point: GeoPoint{Latitude: 35.1890, Longitude: -79.4700}}}}
+	_, err := service.SaveHole(courseID, 9, kept, owner.ID)
+	require.NoError(t, err)
+
+	summary, err := service.Import(courseID, []byte(osmSandhills), owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &HoleGeometryImport{Format: "osm", Holes: []int{1, 2}, Features: 6, Skipped: 2}, summary)
+
+	layout, err := service.GetLayout(courseID)
+	require.NoError(t, err)
+	require.Len(t, layout, 3)
+	assert.Equal(t, "osm", layout[0].Source)
+	assert.Equal(t, "manual", layout[2].Source)
+
+	// The layout's own GeoJSON imports back to the same holes
+	exported, err := json.Marshal(holeLayoutCollection(layout))
+	require.NoError(t, err)
+	summary, err = service.Import(ids["Pinehurst No. 2"], exported, golfer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "geojson", summary.Format)
+	assert.Equal(t, []int{1, 2, 9}, summary.Holes)
+	reimported, err := service.GetLayout(courseID)
+	require.NoError(t, err)
+	for i := range layout {
+		assert.Equal(t, layout[i].Geometry, reimported[i].Geometry, "hole %d", layout[i].HoleNumber)
+	}
+
+	// Nothing is saved when any hole is invalid
+	crossed := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"golf": "tee", "hole": 5}, "geometry": {"type": "Point", "coordinates": [-79.47, 35.19]}},
+		{"type": "Feature", "properties": {"golf": "green", "ref": "6"}, "geometry": {"type": "Polygon", "coordinates": [[
+			[-79.4702, 35.1928], [-79.4698, 35.1932], [-79.4698, 35.1928], [-79.4702, 35.1932], [-79.4702, 35.1928]]]}}
+	]}`
+	_, err = service.Import(courseID, []byte(crossed), owner.ID)
+	assert.ErrorIs(t, err, ErrInvalidHoleGeometry)
+	assert.ErrorContains(t, err, "hole 6: the green crosses itself")
+	_, err = service.GetHole(courseID, 5)
+	assert.ErrorIs(t, err, ErrHoleGeometryNotFound)
+
+	_, err = service.Import(courseID, []byte(`{"type": "FeatureCollection", "features": []}`), owner.ID)
+	assert.ErrorIs(t, err, ErrInvalidHoleGeometry)
+	_, err = service.Import(9999, []byte(osmSandhills), owner.ID)
+	assert.ErrorIs(t, err, ErrCourseNotFound)
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"course_management/api"
+	"course_management/services"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	MaxHoleNumber        = 18
+	maxHoleTees          = 8
+	maxHoleTeeNameLength = 30
+	maxHoleHazards       = 40
+	maxHolePolygonPoints = 500
+	// maxHoleFeatureDistanceKm is how far a hole's features may sit from the
+	// course's location, which catches swapped latitudes and longitudes
+	maxHoleFeatureDistanceKm = 5.0
+	// maxGreenEdgeMeters is how far the front or back of a green may be from
+	// its centre
+	maxGreenEdgeMeters = 60.0
+	defaultHoleTeeName = "Tee"
+)
+
+// HoleHazardKinds are the hazards a hole layout can outline, named after
+// their OpenStreetMap golf tags
+var HoleHazardKinds = []string{"bunker", "water_hazard", "lateral_water_hazard"}
+
+var (
+	ErrHoleGeometryNotFound = errors.New("hole geometry not found")
+	ErrInvalidHoleGeometry  = errors.New("invalid hole geometry")
+)
+
+// GeoPoint is a WGS84 position
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// HoleTee is where one set of tees sits on a hole
+type HoleTee struct {
+	Name  string   `json:"name"` // Tee box, such as "Blue" or "Championship"
+	Point GeoPoint `json:"point"`
+}
+
+// HoleHazard is the outline of a bunker or water hazard
+type HoleHazard struct {
+	Kind    string     `json:"kind"` // One of HoleHazardKinds
+	Polygon []GeoPoint `json:"polygon"`
+}
+
+// HoleHazardPoint marks a hazard by a single point, as yardage books do for
+// hazards that aren't outlined
+type HoleHazardPoint struct {
+	Kind  string   `json:"kind"` // One of HoleHazardKinds
+	Point GeoPoint `json:"point"`
+}
+
+// HoleGeometry is the mapped layout of one hole. Polygons are a single outer
+// ring, stored without repeating the first point at the end.
+type HoleGeometry struct {
+	Tees         []HoleTee         `json:"tees"`
+	Green        []GeoPoint        `json:"green,omitempty"`
+	GreenCenter  *GeoPoint         `json:"green_center,omitempty"` // Defaults to the middle of the green
+	GreenFront   *GeoPoint         `json:"green_front,omitempty"`  // For yardages when the green isn't outlined
+	GreenBack    *GeoPoint         `json:"green_back,omitempty"`
+	Fairway      []GeoPoint        `json:"fairway,omitempty"`
+	Hazards      []HoleHazard      `json:"hazards,omitempty"`
+	HazardPoints []HoleHazardPoint `json:"hazard_points,omitempty"`
+}
+
+// HoleGeometryView is a hole's saved geometry with its par and yardage from
+// the course's card, which are zero when the card doesn't list the hole
+type HoleGeometryView struct {
+	CourseHoleGeometry
+	Geometry HoleGeometry
+	Par      int
+	Yardage  int
+}
+
+// CourseHoleGeometryService stores the tees, greens, fairways and hazards of
+// each hole on a course, for hole maps and GPS yardages
+type CourseHoleGeometryService struct {
+	db *gorm.DB
+}
+
+func NewCourseHoleGeometryService() *CourseHoleGeometryService {
+	return &CourseHoleGeometryService{
+		db: GetDB(),
+	}
+}
+
+// GetLayout returns the geometry of every mapped hole on a course, in hole order
+func (s *CourseHoleGeometryService) GetLayout(courseID uint) ([]HoleGeometryView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	if _, err := s.course(courseID); err != nil {
+		return nil, err
+	}
+
+	var rows []CourseHoleGeometry
+	if err := s.db.Where("course_id = ?", courseID).Order("hole_number").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get hole geometry: %v", err)
+	}
+	return s.views(courseID, rows)
+}
+
+// GetHole returns the geometry of one hole
+func (s *CourseHoleGeometryService) GetHole(courseID uint, holeNumber int) (*HoleGeometryView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var row CourseHoleGeometry
+	err := s.db.Where("course_id = ? AND hole_number = ?", courseID, holeNumber).First(&row).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHoleGeometryNotFound
+		}
+		return nil, fmt.Errorf("failed to get hole geometry: %v", err)
+	}
+
+	views, err := s.views(courseID, []CourseHoleGeometry{row})
+	if err != nil {
+		return nil, err
+	}
+	return &views[0], nil
+}
+
+// SaveHole validates a hole's geometry and replaces whatever was mapped before
+func (s *CourseHoleGeometryService) SaveHole(courseID uint, holeNumber int, geometry HoleGeometry, userID uint) (*HoleGeometryView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	course, err := s.course(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateHoleNumber(holeNumber); err != nil {
+		return nil, err
+	}
+	if err := normalizeHoleGeometry(&geometry, courseLocation(course)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHoleGeometry, err)
+	}
+
+	if err := saveHoleGeometry(s.db, courseID, holeNumber, geometry, "manual", userID); err != nil {
+		return nil, err
+	}
+	return s.GetHole(courseID, holeNumber)
+}
+
+// DeleteHole removes a hole's geometry
+func (s *CourseHoleGeometryService) DeleteHole(courseID uint, holeNumber int) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	result := s.db.Where("course_id = ? AND hole_number = ?", courseID, holeNumber).Delete(&CourseHoleGeometry{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete hole geometry: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrHoleGeometryNotFound
+	}
+	return nil
+}
+
+// RemoveCourse deletes the geometry of every hole on a course
+func (s *CourseHoleGeometryService) RemoveCourse(courseID uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	if err := s.db.Where("course_id = ?", courseID).Delete(&CourseHoleGeometry{}).Error; err != nil {
+		return fmt.Errorf("failed to remove hole geometry: %v", err)
+	}
+	return nil
+}
+
+func (s *CourseHoleGeometryService) course(courseID uint) (*CourseDB, error) {
+	var course CourseDB
+	if err := s.db.Select("id", "latitude", "longitude").First(&course, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, fmt.Errorf("failed to get course: %v", err)
+	}
+	return &course, nil
+}
+
+// views decodes saved geometry and adds each hole's par and yardage
+func (s *CourseHoleGeometryService) views(courseID uint, rows []CourseHoleGeometry) ([]HoleGeometryView, error) {
+	var card []CourseHole
+	if err := s.db.Where("course_id = ?", courseID).Find(&card).Error; err != nil {
+		return nil, fmt.Errorf("failed to get course holes: %v", err)
+	}
+	holes := make(map[int]CourseHole, len(card))
+	for _, hole := range card {
+		holes[hole.HoleNumber] = hole
+	}
+
+	views := make([]HoleGeometryView, 0, len(rows))
+	for _, row := range rows {
+		view := HoleGeometryView{CourseHoleGeometry: row}
+		if err := json.Unmarshal([]byte(row.GeometryData), &view.Geometry); err != nil {
+			return nil, fmt.Errorf("failed to read hole %d geometry: %v", row.HoleNumber, err)
+		}
+		view.Par = safeIntValue(holes[row.HoleNumber].Par)
+		view.Yardage = safeIntValue(holes[row.HoleNumber].Yardage)
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// saveHoleGeometry writes normalized geometry over a hole's existing row
+func saveHoleGeometry(db *gorm.DB, courseID uint, holeNumber int, geometry HoleGeometry, source string, userID uint) error {
+	data, err := json.Marshal(geometry)
+	if err != nil {
+		return fmt.Errorf("failed to encode hole geometry: %v", err)
+	}
+
+	row := CourseHoleGeometry{
+		CourseID:     courseID,
+		HoleNumber:   holeNumber,
+		GeometryData: string(data),
+		Source:       source,
+		UpdatedBy:    &userID,
+	}
+	err = db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "course_id"}, {Name: "hole_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"geometry_data", "source", "updated_by", "updated_at"}),
+	}).Create(&row).Error
+	if err != nil {
+		return fmt.Errorf("failed to save hole geometry: %v", err)
+	}
+	return nil
+}
+
+func isHoleHazardKind(kind string) bool {
+	for _, hazard := range HoleHazardKinds {
+		if hazard == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// courseLocation is where a course is mapped, or nil if it isn't
+func courseLocation(course *CourseDB) *GeoPoint {
+	if course.Latitude == nil || course.Longitude == nil {
+		return nil
+	}
+	return &GeoPoint{Latitude: *course.Latitude, Longitude: *course.Longitude}
+}
+
+func validateHoleNumber(holeNumber int) error {
+	if holeNumber < 1 || holeNumber > MaxHoleNumber {
+		return fmt.Errorf("%w: hole numbers run from 1 to %d", ErrInvalidHoleGeometry, MaxHoleNumber)
+	}
+	return nil
+}
+
+// normalizeHoleGeometry tidies a hole's geometry in place and checks it,
+// returning the problem for callers to wrap in ErrInvalidHoleGeometry. Tee
+// names are trimmed, polygons lose a repeated closing point, and a green
+// without a centre gets one. Features must be within a few kilometers of the
+// course when it has a location.
+func normalizeHoleGeometry(geometry *HoleGeometry, course *GeoPoint) error {
+	if len(geometry.Tees) == 0 && len(geometry.Green) == 0 && geometry.GreenCenter == nil &&
+		len(geometry.Fairway) == 0 && len(geometry.Hazards) == 0 && len(geometry.HazardPoints) == 0 {
+		return errors.New("a hole needs at least a tee, green, fairway or hazard")
+	}
+	if len(geometry.Tees) > maxHoleTees {
+		return fmt.Errorf("a hole can have at most %d tees", maxHoleTees)
+	}
+	if len(geometry.Hazards)+len(geometry.HazardPoints) > maxHoleHazards {
+		return fmt.Errorf("a hole can have at most %d hazards", maxHoleHazards)
+	}
+
+	var points []GeoPoint
+	names := make(map[string]bool)
+	for i := range geometry.Tees {
+		tee := &geometry.Tees[i]
+		tee.Name = strings.TrimSpace(tee.Name)
+		if tee.Name == "" || utf8.RuneCountInString(tee.Name) > maxHoleTeeNameLength {
+			return fmt.Errorf("tee names must be between 1 and %d characters", maxHoleTeeNameLength)
+		}
+		if names[strings.ToLower(tee.Name)] {
+			return fmt.Errorf("there is more than one %s tee", tee.Name)
+		}
+		names[strings.ToLower(tee.Name)] = true
+		points = append(points, tee.Point)
+	}
+
+	var err error
+	if geometry.Green, err = normalizeHolePolygon(geometry.Green, "the green"); err != nil {
+		return err
+	}
+	if geometry.Fairway, err = normalizeHolePolygon(geometry.Fairway, "the fairway"); err != nil {
+		return err
+	}
+	points = append(points, geometry.Green...)
+	points = append(points, geometry.Fairway...)
+
+	for i := range geometry.Hazards {
+		hazard := &geometry.Hazards[i]
+		if !isHoleHazardKind(hazard.Kind) {
+			return errors.New("hazards must be a bunker, water_hazard or lateral_water_hazard")
+		}
+		if len(hazard.Polygon) == 0 {
+			return errors.New("every hazard needs an outline")
+		}
+		if hazard.Polygon, err = normalizeHolePolygon(hazard.Polygon, "a "+strings.ReplaceAll(hazard.Kind, "_", " ")); err != nil {
+			return err
+		}
+		points = append(points, hazard.Polygon...)
+	}
+	for _, hazard := range geometry.HazardPoints {
+		if !isHoleHazardKind(hazard.Kind) {
+			return errors.New("hazards must be a bunker, water_hazard or lateral_water_hazard")
+		}
+		points = append(points, hazard.Point)
+	}
+
+	switch {
+	case geometry.GreenCenter != nil && len(geometry.Green) > 0 && !polygonContains(geometry.Green, *geometry.GreenCenter):
+		return errors.New("the green centre must be on the green")
+	case geometry.GreenCenter == nil && len(geometry.Green) > 0:
+		center := polygonCentroid(geometry.Green)
+		geometry.GreenCenter = &center
+	}
+	if geometry.GreenCenter != nil {
+		points = append(points, *geometry.GreenCenter)
+	}
+	for _, edge := range []*GeoPoint{geometry.GreenFront, geometry.GreenBack} {
+		if edge == nil {
+			continue
+		}
+		if geometry.GreenCenter == nil {
+			return errors.New("the front and back of the green need a green or green centre")
+		}
+		if services.HaversineKm(edge.Latitude, edge.Longitude, geometry.GreenCenter.Latitude, geometry.GreenCenter.Longitude)*1000 > maxGreenEdgeMeters {
+			return fmt.Errorf("the front and back of the green must be within %g m of its centre", maxGreenEdgeMeters)
+		}
+		points = append(points, *edge)
+	}
+
+	for _, point := range points {
+		if point.Latitude < -90 || point.Latitude > 90 || point.Longitude < -180 || point.Longitude > 180 {
+			return errors.New("latitudes must be between -90 and 90 and longitudes between -180 and 180")
+		}
+		if course != nil && services.HaversineKm(course.Latitude, course.Longitude, point.Latitude, point.Longitude) > maxHoleFeatureDistanceKm {
+			return fmt.Errorf("every feature must be within %g km of the course", maxHoleFeatureDistanceKm)
+		}
+	}
+	return nil
+}
+
+// normalizeHolePolygon drops a repeated closing point and checks the ring is
+// a simple polygon. An empty ring is left as it is.
+func normalizeHolePolygon(ring []GeoPoint, name string) ([]GeoPoint, error) {
+	if len(ring) == 0 {
+		return nil, nil
+	}
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+
+	switch {
+	case len(ring) < 3:
+		return nil, fmt.Errorf("%s needs at least 3 points", name)
+	case len(ring) > maxHolePolygonPoints:
+		return nil, fmt.Errorf("%s can have at most %d points", name, maxHolePolygonPoints)
+	case polygonSelfIntersects(ring):
+		return nil, fmt.Errorf("%s crosses itself", name)
+	case polygonArea(ring) < 1:
+		return nil, fmt.Errorf("%s has no area", name)
+	}
+	return ring, nil
+}
+
+// holeLayoutCollection draws a course's holes as GeoJSON: a line from the
+// first tee to the green centre, then each hole's tees, green, green centre,
+// front and back, fairway and hazards. Every feature has the hole number and a golf property
+// naming what it is, as OpenStreetMap tags golf features.
+func holeLayoutCollection(holes []HoleGeometryView) api.GeoJSONShapeCollection {
+	collection := api.NewShapeCollection()
+	add := func(feature api.GeoJSONShapeFeature) {
+		collection.Features = append(collection.Features, feature)
+	}
+
+	for _, hole := range holes {
+		geometry := hole.Geometry
+		properties := func(golf string) map[string]interface{} {
+			return map[string]interface{}{"hole": hole.HoleNumber, "golf": golf}
+		}
+
+		if len(geometry.Tees) > 0 && geometry.GreenCenter != nil {
+			line := properties("hole")
+			if hole.Par > 0 {
+				line["par"] = hole.Par
+			}
+			if hole.Yardage > 0 {
+				line["yardage"] = hole.Yardage
+			}
+			add(api.NewLineFeature(geoJSONPositions([]GeoPoint{geometry.Tees[0].Point, *geometry.GreenCenter}), line))
+		}
+		for _, tee := range geometry.Tees {
+			teeProperties := properties("tee")
+			teeProperties["name"] = tee.Name
+			add(api.NewShapePointFeature(tee.Point.Latitude, tee.Point.Longitude, teeProperties))
+		}
+		if len(geometry.Green) > 0 {
+			add(api.NewPolygonFeature(geoJSONPositions(geometry.Green), properties("green")))
+		}
+		if geometry.GreenCenter != nil {
+			add(api.NewShapePointFeature(geometry.GreenCenter.Latitude, geometry.GreenCenter.Longitude, properties("green_center")))
+		}
+		if geometry.GreenFront != nil {
+			add(api.NewShapePointFeature(geometry.GreenFront.Latitude, geometry.GreenFront.Longitude, properties("green_front")))
+		}
+		if geometry.GreenBack != nil {
+			add(api.NewShapePointFeature(geometry.GreenBack.Latitude, geometry.GreenBack.Longitude, properties("green_back")))
+		}
+		if len(geometry.Fairway) > 0 {
+			add(api.NewPolygonFeature(geoJSONPositions(geometry.Fairway), properties("fairway")))
+		}
+		for _, hazard := range geometry.Hazards {
+			add(api.NewPolygonFeature(geoJSONPositions(hazard.Polygon), properties(hazard.Kind)))
+		}
+		for _, hazard := range geometry.HazardPoints {
+			add(api.NewShapePointFeature(hazard.Point.Latitude, hazard.Point.Longitude, properties(hazard.Kind)))
+		}
+	}
+	return collection
+}
+
+// geoJSONPositions converts points to [longitude, latitude] positions
+func geoJSONPositions(points []GeoPoint) [][]float64 {
+	positions := make([][]float64, len(points))
+	for i, point := range points {
+		positions[i] = []float64{point.Longitude, point.Latitude}
+	}
+	return positions
+}
+
+// Hole geometry is measured on a flat projection around the feature, which is
+// accurate to well under a meter across a golf hole
+
+// planarPoint is a position in meters east and north of a reference point
+type planarPoint struct {
+	X, Y float64
+}
+
+func projectPoints(points []GeoPoint, origin GeoPoint) []planarPoint {
+	metersPerDegreeLng := 111320 * math.Cos(origin.Latitude*math.Pi/180)
+	projected := make([]planarPoint, len(points))
+	for i, point := range points {
+		projected[i] = planarPoint{
+			X: (point.Longitude - origin.Longitude) * metersPerDegreeLng,
+			Y: (point.Latitude - origin.Latitude) * 110574,
+		}
+	}
+	return projected
+}
+
+// polygonArea is a ring's area in square meters
+func polygonArea(ring []GeoPoint) float64 {
+	return math.Abs(signedArea(projectPoints(ring, ring[0])))
+}
+
+func signedArea(ring []planarPoint) float64 {
+	var area float64
+	for i := range ring {
+		next := ring[(i+1)%len(ring)]
+		area += ring[i].X*next.Y - next.X*ring[i].Y
+	}
+	return area / 2
+}
+
+// polygonCentroid is the centre of mass of a ring
+func polygonCentroid(ring []GeoPoint) GeoPoint {
+	origin := ring[0]
+	projected := projectPoints(ring, origin)
+	area := signedArea(projected)
+
+	var x, y float64
+	for i := range projected {
+		next := projected[(i+1)%len(projected)]
+		cross := projected[i].X*next.Y - next.X*projected[i].Y
+		x += (projected[i].X + next.X) * cross
+		y += (projected[i].Y + next.Y) * cross
+	}
+	x /= 6 * area
+	y /= 6 * area
+
+	return GeoPoint{
+		Latitude:  origin.Latitude + y/110574,
+		Longitude: origin.Longitude + x/(111320*math.Cos(origin.Latitude*math.Pi/180)),
+	}
+}
+
+// polygonContains reports whether a point is inside a ring
+func polygonContains(ring []GeoPoint, point GeoPoint) bool {
+	projected := projectPoints(append([]GeoPoint{point}, ring...), ring[0])
+	p, polygon := projected[0], projected[1:]
+
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// polygonSelfIntersects reports whether any two edges of a ring that don't
+// share a corner cross
+func polygonSelfIntersects(ring []GeoPoint) bool {
+	projected := projectPoints(ring, ring[0])
+	n := len(projected)
+	for i := 0; i < n; i++ {
+		for j := i + 2; j < n; j++ {
+			if i == 0 && j == n-1 {
+				continue
+			}
+			if segmentsCross(projected[i], projected[i+1], projected[j], projected[(j+1)%n]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func segmentsCross(a, b, c, d planarPoint) bool {
+	orientation := func(p, q, r planarPoint) float64 {
+		return (q.X-p.X)*(r.Y-p.Y) - (q.Y-p.Y)*(r.X-p.X)
+	}
+	d1, d2 := orientation(c, d, a), orientation(c, d, b)
+	d3, d4 := orientation(a, b, c), orientation(a, b, d)
+	return ((d1 > 0) != (d2 > 0)) && ((d3 > 0) != (d4 > 0))
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// square is a ring of four corners around a point, about 2*size*111 km across
+func square(lat, lng, size float64) []GeoPoint {
+	return []GeoPoint{
+		{Latitude: lat - size, Longitude: lng - size},
+		{Latitude: lat - size, Longitude: lng + size},
+		{Latitude: lat + size, Longitude: lng + size},
+		{Latitude: lat + size, Longitude: lng - size},
+	}
+}
+
+// pinehurstFirst is a hole laid out just north of Pinehurst No. 2's location
+func pinehurstFirst() HoleGeometry {
+	return HoleGeometry{
+		Tees: []HoleTee{
+			{Name: " Blue ", Point: GeoPoint{Latitude: 35.1900, Longitude: -79.4720}},
+			{Name: "White", Point: GeoPoint{Latitude: 35.1905, Longitude: -79.4718}},
+		},
+		Green:   append(square(35.1930, -79.4700, 0.0002), GeoPoint{Latitude: 35.1928, Longitude: -79.4702}),
+		Fairway: square(35.1915, -79.4710, 0.0005),
+		Hazards: []HoleHazard{{Kind: "bunker", Polygon: square(35.1926, -79.4695, 0.00005)}},
+	}
+}
+
+func TestNormalizeHoleGeometry(t *testing.T) {
+	course := &GeoPoint{Latitude: 35.1907, Longitude: -79.4704}
+
+	geometry := pinehurstFirst()
+	require.NoError(t, normalizeHoleGeometry(&geometry, course))
+	assert.Equal(t, "Blue", geometry.Tees[0].Name)
+	assert.Len(t, geometry.Green, 4, "drops the closing point")
+	require.NotNil(t, geometry.GreenCenter)
+	assert.InDelta(t, 35.1930, geometry.GreenCenter.Latitude, 1e-7)
+	assert.InDelta(t, -79.4700, geometry.GreenCenter.Longitude, 1e-7)
+
+	center := GeoPoint{Latitude: 35.1931, Longitude: -79.4701}
+	geometry = pinehurstFirst()
+	geometry.GreenCenter = &center
+	require.NoError(t, normalizeHoleGeometry(&geometry, course))
+	assert.Equal(t, center, *geometry.GreenCenter, "keeps a centre on the green")
+
+	geometry = HoleGeometry{GreenCenter: &center}
+	assert.NoError(t, normalizeHoleGeometry(&geometry, course), "a green centre alone is enough for yardages")
+
+	front, back := GeoPoint{Latitude: 35.1929, Longitude: -79.4701}, GeoPoint{Latitude: 35.1933, Longitude: -79.4699}
+	geometry = HoleGeometry{GreenCenter: &center, GreenFront: &front, GreenBack: &back,
+		HazardPoints: []HoleHazardPoint{{Kind: "water_hazard", Point: GeoPoint{Latitude: 35.1925, Longitude: -79.4702}}}}
+	assert.NoError(t, normalizeHoleGeometry(&geometry, course), "a green marked by points, as in a yardage book")
+
+	invalid := map[string]func(*HoleGeometry){
+		"nothing mapped":  func(g *HoleGeometry) { *g = HoleGeometry{} },
+		"repeated tee":    func(g *HoleGeometry) { g.Tees[1].Name = "blue" },
+		"unnamed tee":     func(g *HoleGeometry) { g.Tees[0].Name = " " },
+		"two point green": func(g *HoleGeometry) { g.Green = g.Green[:2] },
+		"crossed green":   func(g *HoleGeometry) { g.Green[1], g.Green[2] = g.Green[2], g.Green[1] },
+		"flat fairway": func(g *HoleGeometry) {
+			g.Fairway = []GeoPoint{g.Tees[0].Point, g.Tees[1].Point, g.Tees[0].Point, g.Tees[1].Point}
+		},
+		"centre off green":   func(g *HoleGeometry) { g.GreenCenter = &g.Tees[0].Point },
+		"unknown hazard":     func(g *HoleGeometry) { g.Hazards[0].Kind = "trees" },
+		"hazard no outline":  func(g *HoleGeometry) { g.Hazards[0].Polygon = nil },
+		"far from course":    func(g *HoleGeometry) { g.Tees[0].Point = GeoPoint{Latitude: -79.4720, Longitude: 35.1900} },
+		"latitude too large": func(g *HoleGeometry) { g.Tees[0].Point.Latitude = 135 },
+		"front off green":    func(g *HoleGeometry) { g.GreenFront = &GeoPoint{Latitude: 35.1920, Longitude: -79.4700} },
+		"back without green": func(g *HoleGeometry) { *g = HoleGeometry{Tees: g.Tees, GreenBack: &g.Tees[0].Point} },
+		"unknown hazard point": func(g *HoleGeometry) {
+			g.HazardPoints = []HoleHazardPoint{{Kind: "trees", Point: g.Tees[0].Point}}
+		},
+	}
+	for name, change := range invalid {
+		geometry := pinehurstFirst()
+		change(&geometry)
+		assert.Error(t, normalizeHoleGeometry(&geometry, course), name)
+	}
+
+	geometry = pinehurstFirst()
+	geometry.Tees[0].Point = GeoPoint{Latitude: -79.4720, Longitude: 35.1900}
+	assert.NoError(t, normalizeHoleGeometry(&geometry, nil), "unmapped courses can't check distance")
+}
+
+func TestPolygonHelpers(t *testing.T) {
+	green := square(35.1930, -79.4700, 0.0002)
+
+	// 0.0004 degrees is about 44.2 m north to south and 36.4 m east to west here
+	assert.InDelta(t, 44.2*36.4, polygonArea(green), 20)
+	assert.True(t, polygonContains(green, GeoPoint{Latitude: 35.1931, Longitude: -79.4699}))
+	assert.False(t, polygonContains(green, GeoPoint{Latitude: 35.1933, Longitude: -79.4700}))
+	assert.False(t, polygonSelfIntersects(green))
+
+	bowtie := []GeoPoint{green[0], green[2], green[1], green[3]}
+	assert.True(t, polygonSelfIntersects(bowtie))
+
+	// An L shape's centroid is pulled toward its long arm
+	l := []GeoPoint{{Latitude: 0, Longitude: 0}, {Latitude: 0, Longitude: 0.003}, {Latitude: 0.001, Longitude: 0.003},
+		{Latitude: 0.001, Longitude: 0.001}, {Latitude: 0.003, Longitude: 0.001}, {Latitude: 0.003, Longitude: 0}}
+	centroid := polygonCentroid(l)
+	assert.InDelta(t, 0.0011, centroid.Latitude, 0.0001)
+	assert.InDelta(t, 0.0011, centroid.Longitude, 0.0001)
+}
+
+func TestCourseHoleGeometryService(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	ids := seedGeoCourses(t, db)
+	courseID := ids["Pinehurst No. 2"]
+	require.NoError(t, db.Create(&CourseHole{CourseID: courseID, HoleNumber: 1, Par: intPtr(4), Yardage: intPtr(401)}).Error)
+	service := NewCourseHoleGeometryService()
+
+	saved, err := service.SaveHole(courseID, 1, pinehurstFirst(), owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, saved.Par)
+	assert.Equal(t, 401, saved.Yardage)
+	assert.Equal(t, "manual", saved.Source)
+	assert.Equal(t, owner.ID, *saved.UpdatedBy)
+	assert.Equal(t, "Blue", saved.Geometry.Tees[0].Name)
+	assert.NotNil(t, saved.Geometry.GreenCenter)
+
+	second := HoleGeometry{Tees: []HoleTee{{Name: "Blue", Point: GeoPoint{Latitude: 35.1940, Longitude: -79.4700}}}}
+	_, err = service.SaveHole(courseID, 2, second, owner.ID)
+	require.NoError(t, err)
+
+	// Saving again replaces the hole rather than adding another
+	second.Tees[0].Name = "Gold"
+	_, err = service.SaveHole(courseID, 2, second, owner.ID)
+	require.NoError(t, err)
+	var rows int64
+	require.NoError(t, db.Model(&CourseHoleGeometry{}).Where("course_id = ?", courseID).Count(&rows).Error)
+	assert.EqualValues(t, 2, rows)
+
+	layout, err := service.GetLayout(courseID)
+	require.NoError(t, err)
+	require.Len(t, layout, 2)
+	assert.Equal(t, 1, layout[0].HoleNumber)
+	assert.Equal(t, "Gold", layout[1].Geometry.Tees[0].Name)
+	assert.Zero(t, layout[1].Par, "not on the card")
+
+	_, err = service.SaveHole(courseID, 19, second, owner.ID)
+	assert.ErrorIs(t, err, ErrInvalidHoleGeometry)
+	_, err = service.SaveHole(courseID, 3, HoleGeometry{}, owner.ID)
+	assert.ErrorIs(t, err, ErrInvalidHoleGeometry)
+	_, err = service.SaveHole(9999, 1, pinehurstFirst(), owner.ID)
+	assert.ErrorIs(t, err, ErrCourseNotFound)
+	_, err = service.SaveHole(ids["Pebble Beach"], 1, pinehurstFirst(), owner.ID)
+	assert.ErrorIs(t, err, ErrInvalidHoleGeometry, "another course's hole")
+	_, err = service.GetLayout(9999)
+	assert.ErrorIs(t, err, ErrCourseNotFound)
+
+	require.NoError(t, service.DeleteHole(courseID, 2))
+	assert.ErrorIs(t, service.DeleteHole(courseID, 2), ErrHoleGeometryNotFound)
+	_, err = service.GetHole(courseID, 2)
+	assert.ErrorIs(t, err, ErrHoleGeometryNotFound)
+
+	// Deleting the course removes its holes
+	require.NoError(t, (&DatabaseService{db: db}).DeleteCourse(courseID))
+	require.NoError(t, db.Model(&CourseHoleGeometry{}).Count(&rows).Error)
+	assert.Zero(t, rows)
+}
+
+func TestHoleLayoutCollection(t *testing.T) {
+	geometry := pinehurstFirst()
+	require.NoError(t, normalizeHoleGeometry(&geometry, nil))
+	collection := holeLayoutCollection([]HoleGeometryView{{
+		CourseHoleGeometry: CourseHoleGeometry{HoleNumber: 1},
+		Geometry:           geometry,
+		Par:                4,
+	}})
+
+	var kinds []string
+	for _, feature := range collection.Features {
+		assert.Equal(t, 1, feature.Properties["hole"])
+		kinds = append(kinds, feature.Properties["golf"].(string)+" "+feature.Geometry.Type)
+	}
+	assert.Equal(t, []string{
+		"hole LineString", "tee Point", "tee Point", "green Polygon", "green_center Point", "fairway Polygon", "bunker Polygon",
+	}, kinds)
+
+	line := collection.Features[0]
+	assert.Equal(t, 4, line.Properties["par"])
+	assert.NotContains(t, line.Properties, "yardage")
+	assert.Equal(t, []float64{-79.4720, 35.1900}, line.Geometry.Coordinates.([][]float64)[0], "from the first tee")
+
+	green := collection.Features[3].Geometry.Coordinates.([][][]float64)[0]
+	assert.Len(t, green, 5, "GeoJSON rings are closed")
+	assert.Equal(t, green[0], green[4])
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"course_management/services"
+)
+
+const (
+	metersPerYard = 0.9144
+	// holeDetectMeters is how far a player may be from a hole's line of play
+	// for the hole to be found from their position
+	holeDetectMeters = 100.0
+	// minLayupMeters is how far ahead of the player a layup point must be
+	minLayupMeters = 20.0
+	// hazardCenterMeters is how close to the line of play a hazard marked by
+	// a point counts as straight ahead
+	hazardCenterMeters = 5.0
+)
+
+// LayupYards are the distances from the green centre that a yardage offers
+// layup points for
+var LayupYards = []int{50, 100, 150, 200}
+
+var ErrHoleNotDetected = errors.New("no mapped hole near this position")
+
+// GreenYardage is the distance in yards to the front, centre and back of the
+// green. Each is nil when the hole doesn't map it.
+type GreenYardage struct {
+	Front  *int `json:"front"`
+	Center *int `json:"center"`
+	Back   *int `json:"back"`
+}
+
+// HazardYardage is how far a player must hit to reach a hazard and to carry
+// it, in yards along the line to the green centre
+type HazardYardage struct {
+	Kind  string `json:"kind"`
+	Reach int    `json:"reach"`
+	Carry int    `json:"carry"`
+	Side  string `json:"side"` // "left", "right" or "center" of the line to the green
+}
+
+// LayupPoint is a spot on the line to the green a set distance short of the
+// green centre
+type LayupPoint struct {
+	ToGreen  int      `json:"to_green"` // Yards left to the green centre from the point
+	Distance int      `json:"distance"` // Yards from the player to the point
+	Point    GeoPoint `json:"point"`
+	InHazard bool     `json:"in_hazard"`
+}
+
+// HoleYardage is what a GPS rangefinder shows for a player's position on a hole
+type HoleYardage struct {
+	HoleNumber int
+	Detected   bool // Whether the hole was found from the player's position
+	Par        int
+	Yardage    int
+	Green      GreenYardage
+	Hazards    []HazardYardage // Hazards ahead of the player, nearest first
+	Layups     []LayupPoint
+}
+
+// Yardage measures a hole from a player's position. A zero hole number finds
+// the hole the player is on.
+func (s *CourseHoleGeometryService) Yardage(courseID uint, holeNumber int, position GeoPoint) (*HoleYardage, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	if holeNumber != 0 {
+		if err := validateHoleNumber(holeNumber); err != nil {
+			return nil, err
+		}
+		hole, err := s.GetHole(courseID, holeNumber)
+		if err != nil {
+			return nil, err
+		}
+		return holeYardage(*hole, position), nil
+	}
+
+	holes, err := s.GetLayout(courseID)
+	if err != nil {
+		return nil, err
+	}
+	index, ok := detectHole(holes, position)
+	if !ok {
+		return nil, ErrHoleNotDetected
+	}
+	yardage := holeYardage(holes[index], position)
+	yardage.Detected = true
+	return yardage, nil
+}
+
+// holeYardage measures a hole from a player's position. The front and back of
+// an outlined green are where the line from the player to the green centre
+// crosses its edge, so they follow the player's angle of approach; otherwise
+// the hole's marked front and back are used.
+func holeYardage(hole HoleGeometryView, position GeoPoint) *HoleYardage {
+	geometry := hole.Geometry
+	yardage := &HoleYardage{
+		HoleNumber: hole.HoleNumber,
+		Par:        hole.Par,
+		Yardage:    hole.Yardage,
+		Hazards:    []HazardYardage{},
+		Layups:     []LayupPoint{},
+	}
+	yards := func(point GeoPoint) *int {
+		distance := metersToYards(geodesicMeters(position, point))
+		return &distance
+	}
+
+	if geometry.GreenFront != nil {
+		yardage.Green.Front = yards(*geometry.GreenFront)
+	}
+	if geometry.GreenBack != nil {
+		yardage.Green.Back = yards(*geometry.GreenBack)
+	}
+	if geometry.GreenCenter == nil {
+		return yardage
+	}
+	yardage.Green.Center = yards(*geometry.GreenCenter)
+
+	line, ok := newLineOfPlay(position, *geometry.GreenCenter)
+	if !ok {
+		return yardage
+	}
+	if len(geometry.Green) > 0 {
+		if crossings := line.crossings(geometry.Green); len(crossings) >= 2 {
+			yardage.Green.Front = yards(line.pointAt(math.Max(crossings[0], 0)))
+			yardage.Green.Back = yards(line.pointAt(crossings[len(crossings)-1]))
+		}
+	}
+
+	for _, hazard := range geometry.Hazards {
+		if measured, ok := line.hazard(hazard); ok {
+			yardage.Hazards = append(yardage.Hazards, measured)
+		}
+	}
+	for _, hazard := range geometry.HazardPoints {
+		along, across := line.measure(hazard.Point)
+		if along <= 0 {
+			continue
+		}
+		distance := *yards(hazard.Point)
+		yardage.Hazards = append(yardage.Hazards, HazardYardage{
+			Kind:  hazard.Kind,
+			Reach: distance,
+			Carry: distance,
+			Side:  hazardSide(across),
+		})
+	}
+	sort.SliceStable(yardage.Hazards, func(i, j int) bool {
+		return yardage.Hazards[i].Reach < yardage.Hazards[j].Reach
+	})
+
+	toCenter := geodesicMeters(position, *geometry.GreenCenter)
+	for _, layup := range LayupYards {
+		short := float64(layup) * metersPerYard
+		if toCenter-short < minLayupMeters {
+			continue
+		}
+		point := interpolateGeoPoint(position, *geometry.GreenCenter, (toCenter-short)/toCenter)
+		inHazard := false
+		for _, hazard := range geometry.Hazards {
+			if polygonContains(hazard.Polygon, point) {
+				inHazard = true
+				break
+			}
+		}
+		yardage.Layups = append(yardage.Layups, LayupPoint{
+			ToGreen:  layup,
+			Distance: *yards(point),
+			Point:    point,
+			InHazard: inHazard,
+		})
+	}
+	return yardage
+}
+
+// detectHole finds the hole a player is on: the one whose green they are
+// standing on, then whose fairway, then whose line from a tee to the green
+// centre passes closest, within holeDetectMeters. It returns the hole's index.
+func detectHole(holes []HoleGeometryView, position GeoPoint) (int, bool) {
+	best, bestTier, bestDistance := -1, 3, holeDetectMeters
+	for i, hole := range holes {
+		geometry := hole.Geometry
+		tier, distance := 2, math.Inf(1)
+		switch {
+		case len(geometry.Green) > 0 && polygonContains(geometry.Green, position):
+			tier, distance = 0, 0
+		case len(geometry.Fairway) > 0 && polygonContains(geometry.Fairway, position):
+			tier, distance = 1, 0
+		default:
+			for _, line := range holeLinesOfPlay(geometry) {
+				if len(line) == 1 {
+					distance = math.Min(distance, services.HaversineKm(position.Latitude, position.Longitude, line[0].Latitude, line[0].Longitude)*1000)
+				} else {
+					distance = math.Min(distance, distanceToLineMeters(position, line))
+				}
+			}
+			if distance > holeDetectMeters {
+				continue
+			}
+		}
+		if tier < bestTier || (tier == bestTier && distance < bestDistance) {
+			best, bestTier, bestDistance = i, tier, distance
+		}
+	}
+	return best, best >= 0
+}
+
+// holeLinesOfPlay are the lines from each tee to the green centre, or the
+// single points a hole has when it's missing either end
+func holeLinesOfPlay(geometry HoleGeometry) [][]GeoPoint {
+	var lines [][]GeoPoint
+	for _, tee := range geometry.Tees {
+		if geometry.GreenCenter != nil {
+			lines = append(lines, []GeoPoint{tee.Point, *geometry.GreenCenter})
+		} else {
+			lines = append(lines, []GeoPoint{tee.Point})
+		}
+	}
+	if len(lines) == 0 && geometry.GreenCenter != nil {
+		lines = append(lines, []GeoPoint{*geometry.GreenCenter})
+	}
+	return lines
+}
+
+// lineOfPlay measures features along the line from a player to the green,
+// in meters on a flat projection around the player
+type lineOfPlay struct {
+	origin GeoPoint
+	dx, dy float64 // Unit vector toward the green, east and north
+}
+
+// newLineOfPlay reports false when the player is standing on the target
+func newLineOfPlay(from, to GeoPoint) (lineOfPlay, bool) {
+	target := projectPoints([]GeoPoint{to}, from)[0]
+	length := math.Hypot(target.X, target.Y)
+	if length < 0.01 {
+		return lineOfPlay{}, false
+	}
+	return lineOfPlay{origin: from, dx: target.X / length, dy: target.Y / length}, true
+}
+
+// measure returns how far ahead of the player a point is along the line, and
+// how far to its left (positive) or right (negative)
+func (l lineOfPlay) measure(point GeoPoint) (float64, float64) {
+	p := projectPoints([]GeoPoint{point}, l.origin)[0]
+	return p.X*l.dx + p.Y*l.dy, l.dx*p.Y - l.dy*p.X
+}
+
+// crossings are the distances along the line, in order, at which it crosses
+// a ring's edges. Points behind the player are negative.
+func (l lineOfPlay) crossings(ring []GeoPoint) []float64 {
+	var crossings []float64
+	for i := range ring {
+		alongA, acrossA := l.measure(ring[i])
+		alongB, acrossB := l.measure(ring[(i+1)%len(ring)])
+		if (acrossA > 0) == (acrossB > 0) {
+			continue
+		}
+		t := acrossA / (acrossA - acrossB)
+		crossings = append(crossings, alongA+t*(alongB-alongA))
+	}
+	sort.Float64s(crossings)
+	return crossings
+}
+
+// hazard measures an outlined hazard. A hazard the line crosses is reached
+// and carried where the line enters and leaves it; one to the side spans its
+// nearest and farthest points along the line. Hazards behind the player are
+// left out.
+func (l lineOfPlay) hazard(hazard HoleHazard) (HazardYardage, bool) {
+	reach, carry, side := math.Inf(1), math.Inf(-1), "center"
+	if crossings := l.crossings(hazard.Polygon); len(crossings) >= 2 {
+		reach, carry = crossings[0], crossings[len(crossings)-1]
+	} else {
+		var across float64
+		for _, point := range hazard.Polygon {
+			along, offset := l.measure(point)
+			reach, carry = math.Min(reach, along), math.Max(carry, along)
+			across += offset
+		}
+		side = hazardSide(across / float64(len(hazard.Polygon)))
+	}
+	if carry <= 0 {
+		return HazardYardage{}, false
+	}
+
+	yards := func(along float64) int {
+		return metersToYards(geodesicMeters(l.origin, l.pointAt(along)))
+	}
+	return HazardYardage{
+		Kind:  hazard.Kind,
+		Reach: yards(math.Max(reach, 0)),
+		Carry: yards(carry),
+		Side:  side,
+	}, true
+}
+
+// pointAt is the position a distance along the line
+func (l lineOfPlay) pointAt(along float64) GeoPoint {
+	return GeoPoint{
+		Latitude:  l.origin.Latitude + along*l.dy/110574,
+		Longitude: l.origin.Longitude + along*l.dx/(111320*math.Cos(l.origin.Latitude*math.Pi/180)),
+	}
+}
+
+func hazardSide(across float64) string {
+	switch {
+	case across > hazardCenterMeters:
+		return "left"
+	case across < -hazardCenterMeters:
+		return "right"
+	default:
+		return "center"
+	}
+}
+
+// interpolateGeoPoint is the point a fraction of the way from one point to
+// another, which is close enough to the geodesic over a golf hole
+func interpolateGeoPoint(from, to GeoPoint, fraction float64) GeoPoint {
+	return GeoPoint{
+		Latitude:  from.Latitude + (to.Latitude-from.Latitude)*fraction,
+		Longitude: from.Longitude + (to.Longitude-from.Longitude)*fraction,
+	}
+}
+
+func metersToYards(meters float64) int {
+	return int(math.Round(meters / metersPerYard))
+}
+
+// geodesicMeters is the distance between two points on the WGS84 ellipsoid by
+// Vincenty's inverse formula, which is accurate to well under a yard. Nearly
+// antipodal points, where the formula doesn't converge, fall back to the
+// great-circle distance.
+func geodesicMeters(from, to GeoPoint) float64 {
+	const (
+		semiMajor  = 6378137.0
+		flattening = 1 / 298.257223563
+		semiMinor  = semiMajor * (1 - flattening)
+	)
+	toRadians := math.Pi / 180
+
+	l := (to.Longitude - from.Longitude) * toRadians
+	sinU1, cosU1 := math.Sincos(math.Atan((1 - flattening) * math.Tan(from.Latitude*toRadians)))
+	sinU2, cosU2 := math.Sincos(math.Atan((1 - flattening) * math.Tan(to.Latitude*toRadians)))
+
+	lambda := l
+	for i := 0; i < 200; i++ {
+		sinLambda, cosLambda := math.Sincos(lambda)
+		sinSigma := math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0
+		}
+		cosSigma := sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma := math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha := 1 - sinAlpha*sinAlpha
+		cos2SigmaM := 0.0
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		c := flattening / 16 * cosSqAlpha * (4 + flattening*(4-3*cosSqAlpha))
+
+		previous := lambda
+		lambda = l + (1-c)*flattening*sinAlpha*(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-previous) > 1e-12 {
+			continue
+		}
+
+		uSq := cosSqAlpha * (semiMajor*semiMajor - semiMinor*semiMinor) / (semiMinor * semiMinor)
+		a := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+		b := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+		deltaSigma := b * sinSigma * (cos2SigmaM + b/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+			b/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+		return semiMinor * a * (sigma - deltaSigma)
+	}
+	return services.HaversineKm(from.Latitude, from.Longitude, to.Latitude, to.Longitude) * 1000
+}
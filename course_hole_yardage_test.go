@@ -0,0 +1,140 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeodesicMeters(t *testing.T) {
+	// Vincenty's own test line, Flinders Peak to Buninyong
+	flinders := GeoPoint{Latitude: -37.95103341666667, Longitude: 144.42486788888888}
+	buninyong := GeoPoint{Latitude: -37.65282113888889, Longitude: 143.92649552777777}
+	assert.InDelta(t, 54972.271, geodesicMeters(flinders, buninyong), 0.01)
+
+	assert.Zero(t, geodesicMeters(flinders, flinders))
+	assert.InDelta(t, 19936289, geodesicMeters(GeoPoint{}, GeoPoint{Latitude: 0.5, Longitude: 179.7}), 100000,
+		"nearly antipodal points still get a distance")
+}
+
+func TestHoleYardage(t *testing.T) {
+	geometry := pinehurstFirst()
+	geometry.HazardPoints = []HoleHazardPoint{
+		{Kind: "water_hazard", Point: GeoPoint{Latitude: 35.1915, Longitude: -79.4725}},
+		{Kind: "bunker", Point: GeoPoint{Latitude: 35.1890, Longitude: -79.4725}},
+	}
+	require.NoError(t, normalizeHoleGeometry(&geometry, nil))
+	hole := HoleGeometryView{CourseHoleGeometry: CourseHoleGeometry{HoleNumber: 1}, Geometry: geometry, Par: 4}
+	tee := geometry.Tees[0].Point
+
+	yardage := holeYardage(hole, tee)
+	assert.Equal(t, 1, yardage.HoleNumber)
+	assert.Equal(t, 4, yardage.Par)
+	require.NotNil(t, yardage.Green.Center)
+	assert.Equal(t, metersToYards(geodesicMeters(tee, *geometry.GreenCenter)), *yardage.Green.Center)
+	require.NotNil(t, yardage.Green.Front)
+	require.NotNil(t, yardage.Green.Back)
+	assert.Less(t, *yardage.Green.Front, *yardage.Green.Center)
+	assert.Greater(t, *yardage.Green.Back, *yardage.Green.Center)
+	assert.InDelta(t, 55, *yardage.Green.Back-*yardage.Green.Front, 5, "diagonally across a green about 44 m by 36 m")
+
+	// The bunker behind the tee is left out
+	require.Len(t, yardage.Hazards, 2)
+	water, bunker := yardage.Hazards[0], yardage.Hazards[1]
+	assert.Equal(t, "water_hazard", water.Kind)
+	assert.Equal(t, "left", water.Side)
+	assert.Equal(t, water.Reach, water.Carry, "a hazard point has one distance")
+	assert.Equal(t, "bunker", bunker.Kind)
+	assert.Equal(t, "right", bunker.Side)
+	assert.Less(t, bunker.Reach, bunker.Carry)
+	assert.Less(t, bunker.Carry, *yardage.Green.Back)
+
+	require.Len(t, yardage.Layups, len(LayupYards))
+	for i, layup := range yardage.Layups {
+		assert.Equal(t, LayupYards[i], layup.ToGreen)
+		assert.InDelta(t, layup.ToGreen, metersToYards(geodesicMeters(layup.Point, *geometry.GreenCenter)), 1)
+		assert.InDelta(t, *yardage.Green.Center-layup.ToGreen, layup.Distance, 1)
+	}
+
+	// Close to the green only the short layups are ahead
+	short := holeYardage(hole, GeoPoint{Latitude: 35.1922, Longitude: -79.4705})
+	require.Len(t, short.Layups, 1)
+	assert.Equal(t, 50, short.Layups[0].ToGreen)
+
+	// Crossing a hazard, it's reached where the line enters and carried where it leaves
+	hole.Geometry.Hazards = []HoleHazard{{Kind: "water_hazard", Polygon: square(35.1915, -79.4710, 0.0003)}}
+	crossing := holeYardage(hole, tee)
+	assert.Equal(t, "center", crossing.Hazards[0].Side)
+	assert.InDelta(t, 75, crossing.Hazards[0].Carry-crossing.Hazards[0].Reach, 10)
+	assert.True(t, crossing.Layups[3].InHazard, "200 yards out is in the water")
+
+	// Without a green outline, the marked front and back are used
+	center := GeoPoint{Latitude: 35.1930, Longitude: -79.4700}
+	front := GeoPoint{Latitude: 35.1928, Longitude: -79.4701}
+	marked := holeYardage(HoleGeometryView{Geometry: HoleGeometry{GreenCenter: &center, GreenFront: &front}}, tee)
+	assert.Equal(t, metersToYards(geodesicMeters(tee, front)), *marked.Green.Front)
+	assert.Nil(t, marked.Green.Back)
+
+	// Standing on the green centre there's no line to measure along
+	onGreen := holeYardage(hole, center)
+	assert.Zero(t, *onGreen.Green.Center)
+	assert.Empty(t, onGreen.Hazards)
+	assert.Empty(t, onGreen.Layups)
+}
+
+func TestDetectHole(t *testing.T) {
+	first := pinehurstFirst()
+	require.NoError(t, normalizeHoleGeometry(&first, nil))
+	second := HoleGeometry{
+		Tees:  []HoleTee{{Name: "Blue", Point: GeoPoint{Latitude: 35.1935, Longitude: -79.4700}}},
+		Green: square(35.1960, -79.4650, 0.0002),
+	}
+	require.NoError(t, normalizeHoleGeometry(&second, nil))
+	holes := []HoleGeometryView{
+		{CourseHoleGeometry: CourseHoleGeometry{HoleNumber: 1}, Geometry: first},
+		{CourseHoleGeometry: CourseHoleGeometry{HoleNumber: 2}, Geometry: second},
+	}
+
+	positions := map[GeoPoint]int{
+		{Latitude: 35.1930, Longitude: -79.4700}: 0, // On the first green, a few meters from the second tee
+		{Latitude: 35.1936, Longitude: -79.4700}: 1, // On the second tee
+		{Latitude: 35.1915, Longitude: -79.4710}: 0, // In the first fairway
+		{Latitude: 35.1950, Longitude: -79.4671}: 1, // Off the second line of play
+	}
+	for position, want := range positions {
+		index, ok := detectHole(holes, position)
+		require.True(t, ok, "%v", position)
+		assert.Equal(t, want, index, "%v", position)
+	}
+
+	_, ok := detectHole(holes, GeoPoint{Latitude: 35.2000, Longitude: -79.4800})
+	assert.False(t, ok)
+}
+
+func TestCourseHoleGeometryService_Yardage(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	ids := seedGeoCourses(t, db)
+	courseID := ids["Pinehurst No. 2"]
+	service := NewCourseHoleGeometryService()
+	_, err := service.SaveHole(courseID, 1, pinehurstFirst(), owner.ID)
+	require.NoError(t, err)
+
+	tee := GeoPoint{Latitude: 35.1901, Longitude: -79.4719}
+	yardage, err := service.Yardage(courseID, 0, tee)
+	require.NoError(t, err)
+	assert.True(t, yardage.Detected)
+	assert.Equal(t, 1, yardage.HoleNumber)
+
+	yardage, err = service.Yardage(courseID, 1, tee)
+	require.NoError(t, err)
+	assert.False(t, yardage.Detected)
+
+	_, err = service.Yardage(courseID, 0, GeoPoint{Latitude: 35.2100, Longitude: -79.4700})
+	assert.ErrorIs(t, err, ErrHoleNotDetected)
+	_, err = service.Yardage(courseID, 2, tee)
+	assert.ErrorIs(t, err, ErrHoleGeometryNotFound)
+	_, err = service.Yardage(9999, 0, tee)
+	assert.ErrorIs(t, err, ErrCourseNotFound)
+}
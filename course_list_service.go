@@ -0,0 +1,509 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"course_management/api"
+
+	"gorm.io/gorm"
+)
+
+// Course list kinds
+const (
+	// CourseListKindWantToPlay is the user's built-in bucket list
+	CourseListKindWantToPlay = "want_to_play"
+	// CourseListKindCustom lists are named and created by the user
+	CourseListKindCustom = "custom"
+)
+
+const (
+	wantToPlayListName      = "Want to play"
+	maxCourseListNameLength = 100
+)
+
+var (
+	ErrCourseListNotFound      = errors.New("course list not found")
+	ErrInvalidCourseListName   = errors.New("list names must be between 1 and 100 characters")
+	ErrCannotDeleteWantToPlay  = errors.New("the want-to-play list can't be deleted")
+	ErrCourseListOrderMismatch = errors.New("the new order must include every course on the list exactly once")
+)
+
+// CourseListService manages users' want-to-play and named course lists
+type CourseListService struct {
+	db *gorm.DB
+}
+
+func NewCourseListService() *CourseListService {
+	return &CourseListService{
+		db: GetDB(),
+	}
+}
+
+// WantToPlayList returns the user's want-to-play list, creating it if needed
+func (s *CourseListService) WantToPlayList(userID uint) (*CourseList, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	list := CourseList{UserID: userID, Kind: CourseListKindWantToPlay}
+	if err := s.db.Where(&list).Attrs(CourseList{Name: wantToPlayListName}).FirstOrCreate(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to get want-to-play list: %v", err)
+	}
+	return &list, nil
+}
+
+// GetLists returns all of the user's lists, want-to-play first and the rest
+// in the order they were created
+func (s *CourseListService) GetLists(userID uint) ([]CourseListView, error) {
+	if _, err := s.WantToPlayList(userID); err != nil {
+		return nil, err
+	}
+
+	var lists []CourseList
+	if err := s.db.Where("user_id = ?", userID).Order("created_at, id").Find(&lists).Error; err != nil {
+		return nil, fmt.Errorf("failed to get course lists: %v", err)
+	}
+	sort.SliceStable(lists, func(i, j int) bool {
+		return lists[i].Kind == CourseListKindWantToPlay && lists[j].Kind != CourseListKindWantToPlay
+	})
+
+	return s.buildViews(lists)
+}
+
+// GetList returns one of the user's lists
+func (s *CourseListService) GetList(userID, listID uint) (*CourseListView, error) {
+	list, err := s.ownedList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildView(*list)
+}
+
+// GetSharedList returns the list shared with token, with its owner's name
+func (s *CourseListService) GetSharedList(token string) (*CourseListView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+	if token == "" {
+		return nil, ErrCourseListNotFound
+	}
+
+	var list CourseList
+	if err := s.db.Where("share_token = ?", token).First(&list).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCourseListNotFound
+		}
+		return nil, fmt.Errorf("failed to get shared list: %v", err)
+	}
+
+	view, err := s.buildView(list)
+	if err != nil {
+		return nil, err
+	}
+	view.OwnerName = (&ReviewCommentService{db: s.db}).AuthorName(list.UserID)
+	return view, nil
+}
+
+// CreateList adds an empty named list for the user
+func (s *CourseListService) CreateList(userID uint, name string) (*CourseListView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	name, err := normalizeCourseListName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	list := CourseList{UserID: userID, Name: name, Kind: CourseListKindCustom}
+	if err := s.db.Create(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to create course list: %v", err)
+	}
+	return &CourseListView{CourseList: list, Courses: []CourseListEntry{}}, nil
+}
+
+// RenameList changes the name of one of the user's lists
+func (s *CourseListService) RenameList(userID, listID uint, name string) (*CourseListView, error) {
+	list, err := s.ownedList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err = normalizeCourseListName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(list).Update("name", name).Error; err != nil {
+		return nil, fmt.Errorf("failed to rename course list: %v", err)
+	}
+	return s.buildView(*list)
+}
+
+// DeleteList removes one of the user's named lists and its courses
+func (s *CourseListService) DeleteList(userID, listID uint) error {
+	list, err := s.ownedList(userID, listID)
+	if err != nil {
+		return err
+	}
+	if list.Kind == CourseListKindWantToPlay {
+		return ErrCannotDeleteWantToPlay
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("list_id = ?", list.ID).Delete(&ListedCourse{}).Error; err != nil {
+			return fmt.Errorf("failed to delete list courses: %v", err)
+		}
+		if err := tx.Delete(list).Error; err != nil {
+			return fmt.Errorf("failed to delete course list: %v", err)
+		}
+		return nil
+	})
+}
+
+// AddCourse puts a course at the end of one of the user's lists. Adding a
+// course that is already on the list leaves it where it is.
+func (s *CourseListService) AddCourse(userID, listID, courseID uint) (*CourseListView, error) {
+	list, err := s.ownedList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.addCourse(list, courseID); err != nil {
+		return nil, err
+	}
+	return s.buildView(*list)
+}
+
+// RemoveCourse takes a course off one of the user's lists
+func (s *CourseListService) RemoveCourse(userID, listID, courseID uint) (*CourseListView, error) {
+	list, err := s.ownedList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.removeCourse(list, courseID); err != nil {
+		return nil, err
+	}
+	return s.buildView(*list)
+}
+
+// ReorderCourses puts a list's courses in the given order. courseIDs must
+// include every course on the list exactly once.
+func (s *CourseListService) ReorderCourses(userID, listID uint, courseIDs []uint) (*CourseListView, error) {
+	list, err := s.ownedList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []ListedCourse
+	if err := s.db.Where("list_id = ?", list.ID).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get list courses: %v", err)
+	}
+
+	if len(courseIDs) != len(items) {
+		return nil, ErrCourseListOrderMismatch
+	}
+	positions := make(map[uint]int, len(courseIDs))
+	for i, courseID := range courseIDs {
+		if _, duplicate := positions[courseID]; duplicate {
+			return nil, ErrCourseListOrderMismatch
+		}
+		positions[courseID] = i + 1
+	}
+	for _, item := range items {
+		if _, ok := positions[item.CourseID]; !ok {
+			return nil, ErrCourseListOrderMismatch
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			if err := tx.Model(&item).Update("position", positions[item.CourseID]).Error; err != nil {
+				return fmt.Errorf("failed to reorder course list: %v", err)
+			}
+		}
+		return touchCourseList(tx, list.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.buildView(*list)
+}
+
+// MoveCourse moves a course up (negative offset) or down a list, stopping at
+// either end
+func (s *CourseListService) MoveCourse(userID, listID, courseID uint, offset int) (*CourseListView, error) {
+	view, err := s.GetList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]uint, 0, len(view.Courses))
+	from := -1
+	for i, entry := range view.Courses {
+		order = append(order, entry.CourseID)
+		if entry.CourseID == courseID {
+			from = i
+		}
+	}
+	if from < 0 {
+		return view, nil
+	}
+
+	to := from + offset
+	if to < 0 {
+		to = 0
+	}
+	if to > len(order)-1 {
+		to = len(order) - 1
+	}
+	if to == from {
+		return view, nil
+	}
+
+	moved := order[from]
+	order = append(order[:from], order[from+1:]...)
+	order = append(order[:to], append([]uint{moved}, order[to:]...)...)
+	return s.ReorderCourses(userID, listID, order)
+}
+
+// SetWantToPlay adds a course to or removes it from the user's want-to-play list
+func (s *CourseListService) SetWantToPlay(userID, courseID uint, want bool) error {
+	list, err := s.WantToPlayList(userID)
+	if err != nil {
+		return err
+	}
+	if want {
+		return s.addCourse(list, courseID)
+	}
+	return s.removeCourse(list, courseID)
+}
+
+// SetSharing turns a list's share link on or off. Turning sharing off and on
+// again gives the list a new link, so old links stop working.
+func (s *CourseListService) SetSharing(userID, listID uint, shared bool) (*CourseListView, error) {
+	list, err := s.ownedList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	if shared && list.ShareToken == nil {
+		token, err := newShareToken()
+		if err != nil {
+			return nil, err
+		}
+		list.ShareToken = &token
+	} else if !shared {
+		list.ShareToken = nil
+	}
+
+	if err := s.db.Model(list).Select("share_token").Updates(list).Error; err != nil {
+		return nil, fmt.Errorf("failed to update list sharing: %v", err)
+	}
+	return s.buildView(*list)
+}
+
+func (s *CourseListService) ownedList(userID, listID uint) (*CourseList, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var list CourseList
+	if err := s.db.Where("id = ? AND user_id = ?", listID, userID).First(&list).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCourseListNotFound
+		}
+		return nil, fmt.Errorf("failed to get course list: %v", err)
+	}
+	return &list, nil
+}
+
+func (s *CourseListService) addCourse(list *CourseList, courseID uint) error {
+	var courses int64
+	if err := s.db.Model(&CourseDB{}).Where("id = ?", courseID).Count(&courses).Error; err != nil {
+		return fmt.Errorf("failed to verify course: %v", err)
+	}
+	if courses == 0 {
+		return ErrCourseNotFound
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&ListedCourse{}).Where("list_id = ? AND course_id = ?", list.ID, courseID).Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to check list courses: %v", err)
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		var last int
+		if err := tx.Model(&ListedCourse{}).Where("list_id = ?", list.ID).Select("COALESCE(MAX(position), 0)").Scan(&last).Error; err != nil {
+			return fmt.Errorf("failed to check list courses: %v", err)
+		}
+		if err := tx.Create(&ListedCourse{ListID: list.ID, CourseID: courseID, Position: last + 1}).Error; err != nil {
+			return fmt.Errorf("failed to add course to list: %v", err)
+		}
+		return touchCourseList(tx, list.ID)
+	})
+}
+
+func (s *CourseListService) removeCourse(list *CourseList, courseID uint) error {
+	result := s.db.Where("list_id = ? AND course_id = ?", list.ID, courseID).Delete(&ListedCourse{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove course from list: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	return touchCourseList(s.db, list.ID)
+}
+
+func (s *CourseListService) buildView(list CourseList) (*CourseListView, error) {
+	views, err := s.buildViews([]CourseList{list})
+	if err != nil {
+		return nil, err
+	}
+	return &views[0], nil
+}
+
+// buildViews loads the courses on each list and marks the ones the list's
+// owner has posted scores at
+func (s *CourseListService) buildViews(lists []CourseList) ([]CourseListView, error) {
+	views := make([]CourseListView, len(lists))
+	if len(lists) == 0 {
+		return views, nil
+	}
+
+	listIDs := make([]uint, 0, len(lists))
+	ownerIDs := make([]uint, 0, len(lists))
+	for i, list := range lists {
+		views[i] = CourseListView{CourseList: list, Courses: []CourseListEntry{}}
+		listIDs = append(listIDs, list.ID)
+		ownerIDs = append(ownerIDs, list.UserID)
+	}
+
+	var items []ListedCourse
+	if err := s.db.Preload("Course", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "name", "address", "latitude", "longitude")
+	}).Where("list_id IN ?", listIDs).Order("list_id, position, id").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get list courses: %v", err)
+	}
+	if len(items) == 0 {
+		return views, nil
+	}
+
+	courseIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		courseIDs = append(courseIDs, item.CourseID)
+	}
+
+	type playedKey struct{ userID, courseID uint }
+	var rows []struct {
+		UserID    uint
+		CourseID  uint
+		Rounds    int
+		BestScore int
+	}
+	if err := s.db.Model(&UserCourseScore{}).
+		Select("user_id, course_id, COUNT(*) AS rounds, MIN(score) AS best_score").
+		Where("user_id IN ? AND course_id IN ?", ownerIDs, courseIDs).
+		Group("user_id, course_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get played courses: %v", err)
+	}
+	played := make(map[playedKey]int, len(rows))
+	for i, row := range rows {
+		played[playedKey{row.UserID, row.CourseID}] = i
+	}
+
+	viewIndex := make(map[uint]int, len(views))
+	for i, view := range views {
+		viewIndex[view.ID] = i
+	}
+	for _, item := range items {
+		if item.Course == nil {
+			continue
+		}
+		view := &views[viewIndex[item.ListID]]
+		entry := CourseListEntry{
+			CourseID:   item.CourseID,
+			CourseName: item.Course.Name,
+			Address:    item.Course.Address,
+			Latitude:   item.Course.Latitude,
+			Longitude:  item.Course.Longitude,
+			Position:   item.Position,
+			AddedAt:    item.CreatedAt,
+		}
+		if i, ok := played[playedKey{view.UserID, item.CourseID}]; ok {
+			best := rows[i].BestScore
+			entry.Played = true
+			entry.Rounds = rows[i].Rounds
+			entry.BestScore = &best
+			view.PlayedCount++
+		}
+		view.Courses = append(view.Courses, entry)
+	}
+	return views, nil
+}
+
+// Has reports whether a course is on the list
+func (v CourseListView) Has(courseID uint) bool {
+	for _, entry := range v.Courses {
+		if entry.CourseID == courseID {
+			return true
+		}
+	}
+	return false
+}
+
+// CourseListsMap returns the courses on the lists with a known location as
+// GeoJSON points, one per list and course so a map can show a single list
+func CourseListsMap(lists []CourseListView) api.GeoJSONFeatureCollection {
+	collection := api.NewFeatureCollection()
+	for _, list := range lists {
+		for _, entry := range list.Courses {
+			if entry.Latitude == nil || entry.Longitude == nil {
+				continue
+			}
+			collection.Features = append(collection.Features, api.NewPointFeature(*entry.Latitude, *entry.Longitude, map[string]interface{}{
+				"list_id":   list.ID,
+				"list_name": list.Name,
+				"course_id": entry.CourseID,
+				"name":      entry.CourseName,
+				"position":  entry.Position,
+				"played":    entry.Played,
+			}))
+		}
+	}
+	return collection
+}
+
+func normalizeCourseListName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxCourseListNameLength {
+		return "", ErrInvalidCourseListName
+	}
+	return name, nil
+}
+
+// newShareToken makes the secret behind a share, invite or calendar link
+func newShareToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to create link token: %v", err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
+func touchCourseList(db *gorm.DB, listID uint) error {
+	if err := db.Model(&CourseList{}).Where("id = ?", listID).Update("updated_at", time.Now().Unix()).Error; err != nil {
+		return fmt.Errorf("failed to update course list: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCourseListService_WantToPlayAndPlayedStatus(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewCourseListService()
+
+	other := CourseDB{Name: "Quarry Hills", Address: "2 Quarry Rd", Hash: "quarry", CourseData: "{}", CreatedBy: &owner.ID}
+	require.NoError(t, db.Create(&other).Error)
+
+	require.NoError(t, service.SetWantToPlay(golfer.ID, course.ID, true))
+	require.NoError(t, service.SetWantToPlay(golfer.ID, other.ID, true))
+	// Adding twice keeps a single entry
+	require.NoError(t, service.SetWantToPlay(golfer.ID, course.ID, true))
+	assert.ErrorIs(t, service.SetWantToPlay(golfer.ID, 999, true), ErrCourseNotFound)
+
+	// Played status comes from the list owner's scores, not anyone else's
+	require.NoError(t, db.Create(&UserCourseScore{CourseID: course.ID, UserID: golfer.ID, Score: 90}).Error)
+	require.NoError(t, db.Create(&UserCourseScore{CourseID: course.ID, UserID: golfer.ID, Score: 85}).Error)
+	require.NoError(t, db.Create(&UserCourseScore{CourseID: other.ID, UserID: reviewer.ID, Score: 72}).Error)
+
+	lists, err := service.GetLists(golfer.ID)
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+	wantToPlay := lists[0]
+	assert.Equal(t, CourseListKindWantToPlay, wantToPlay.Kind)
+	require.Len(t, wantToPlay.Courses, 2)
+	assert.Equal(t, 1, wantToPlay.PlayedCount)
+
+	assert.Equal(t, course.ID, wantToPlay.Courses[0].CourseID)
+	assert.True(t, wantToPlay.Courses[0].Played)
+	assert.Equal(t, 2, wantToPlay.Courses[0].Rounds)
+	require.NotNil(t, wantToPlay.Courses[0].BestScore)
+	assert.Equal(t, 85, *wantToPlay.Courses[0].BestScore)
+	assert.False(t, wantToPlay.Courses[1].Played)
+	assert.True(t, wantToPlay.Has(other.ID))
+
+	require.NoError(t, service.SetWantToPlay(golfer.ID, course.ID, false))
+	view, err := service.GetList(golfer.ID, wantToPlay.ID)
+	require.NoError(t, err)
+	assert.False(t, view.Has(course.ID))
+
+	// The want-to-play list is always there
+	assert.ErrorIs(t, service.DeleteList(golfer.ID, wantToPlay.ID), ErrCannotDeleteWantToPlay)
+}
+
+func TestCourseListService_NamedListsAndOrder(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	pebble := seedCourse(t, db, &owner.ID)
+	service := NewCourseListService()
+
+	var courses []CourseDB
+	for _, name := range []string{"Dunes", "Marsh", "Pines"} {
+		course := CourseDB{Name: name, Address: name + " Rd", Hash: name, CourseData: "{}", CreatedBy: &owner.ID}
+		require.NoError(t, db.Create(&course).Error)
+		courses = append(courses, course)
+	}
+
+	_, err := service.CreateList(golfer.ID, "   ")
+	assert.ErrorIs(t, err, ErrInvalidCourseListName)
+
+	trip, err := service.CreateList(golfer.ID, "  Myrtle Beach trip ")
+	require.NoError(t, err)
+	assert.Equal(t, "Myrtle Beach trip", trip.Name)
+	assert.Equal(t, CourseListKindCustom, trip.Kind)
+
+	for _, course := range courses {
+		_, err := service.AddCourse(golfer.ID, trip.ID, course.ID)
+		require.NoError(t, err)
+	}
+
+	// Other users can't see or change the list
+	_, err = service.GetList(reviewer.ID, trip.ID)
+	assert.ErrorIs(t, err, ErrCourseListNotFound)
+	_, err = service.AddCourse(reviewer.ID, trip.ID, pebble.ID)
+	assert.ErrorIs(t, err, ErrCourseListNotFound)
+
+	_, err = service.ReorderCourses(golfer.ID, trip.ID, []uint{courses[0].ID, courses[0].ID, courses[1].ID})
+	assert.ErrorIs(t, err, ErrCourseListOrderMismatch)
+	_, err = service.ReorderCourses(golfer.ID, trip.ID, []uint{courses[0].ID, courses[1].ID})
+	assert.ErrorIs(t, err, ErrCourseListOrderMismatch)
+
+	view, err := service.ReorderCourses(golfer.ID, trip.ID, []uint{courses[2].ID, courses[0].ID, courses[1].ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Pines", "Dunes", "Marsh"}, courseListNames(view))
+
+	view, err = service.MoveCourse(golfer.ID, trip.ID, courses[1].ID, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Pines", "Marsh", "Dunes"}, courseListNames(view))
+
+	// Moving past either end stops there
+	view, err = service.MoveCourse(golfer.ID, trip.ID, courses[2].ID, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Pines", "Marsh", "Dunes"}, courseListNames(view))
+
+	// New courses go to the end
+	view, err = service.AddCourse(golfer.ID, trip.ID, pebble.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Pines", "Marsh", "Dunes", "Pebble Creek"}, courseListNames(view))
+
+	view, err = service.RenameList(golfer.ID, trip.ID, "Spring trip")
+	require.NoError(t, err)
+	assert.Equal(t, "Spring trip", view.Name)
+
+	lists, err := service.GetLists(golfer.ID)
+	require.NoError(t, err)
+	require.Len(t, lists, 2)
+	assert.Equal(t, CourseListKindWantToPlay, lists[0].Kind)
+
+	require.NoError(t, service.DeleteList(golfer.ID, trip.ID))
+	var remaining int64
+	db.Model(&ListedCourse{}).Where("list_id = ?", trip.ID).Count(&remaining)
+	assert.Equal(t, int64(0), remaining)
+}
+
+func TestCourseListService_Sharing(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	course := seedCourse(t, db, &owner.ID)
+	service := NewCourseListService()
+
+	lat, lng := 33.69, -78.89
+	require.NoError(t, db.Model(&course).Updates(map[string]interface{}{"latitude": lat, "longitude": lng}).Error)
+
+	list, err := service.CreateList(owner.ID, "Bucket list")
+	require.NoError(t, err)
+	_, err = service.AddCourse(owner.ID, list.ID, course.ID)
+	require.NoError(t, err)
+
+	shared, err := service.SetSharing(owner.ID, list.ID, true)
+	require.NoError(t, err)
+	require.NotNil(t, shared.ShareToken)
+	token := *shared.ShareToken
+
+	view, err := service.GetSharedList(token)
+	require.NoError(t, err)
+	assert.Equal(t, "Course Owner", view.OwnerName)
+	assert.Len(t, view.Courses, 1)
+
+	// Sharing an already shared list keeps the link
+	again, err := service.SetSharing(owner.ID, list.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, token, *again.ShareToken)
+
+	// Turning sharing off breaks the old link
+	_, err = service.SetSharing(owner.ID, list.ID, false)
+	require.NoError(t, err)
+	_, err = service.GetSharedList(token)
+	assert.ErrorIs(t, err, ErrCourseListNotFound)
+	_, err = service.GetSharedList("")
+	assert.ErrorIs(t, err, ErrCourseListNotFound)
+
+	lists, err := service.GetLists(owner.ID)
+	require.NoError(t, err)
+	collection := CourseListsMap(lists)
+	require.Len(t, collection.Features, 1)
+	assert.Equal(t, []float64{lng, lat}, collection.Features[0].Geometry.Coordinates)
+	assert.Equal(t, list.ID, collection.Features[0].Properties["list_id"])
+}
+
+func courseListNames(view *CourseListView) []string {
+	names := make([]string, 0, len(view.Courses))
+	for _, entry := range view.Courses {
+		names = append(names, entry.CourseName)
+	}
+	return names
+}
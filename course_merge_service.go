@@ -0,0 +1,416 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCourseMergeSelf = errors.New("a course can't be merged into itself")
+
+	// errCourseMergeDryRun rolls back a merge that was only being previewed
+	errCourseMergeDryRun = errors.New("course merge dry run")
+)
+
+// courseMergeKeptFields are never copied from the duplicate's course_data
+var courseMergeKeptFields = map[string]bool{"name": true, "address": true, "id": true, "ID": true}
+
+// CourseMergeOptions controls a merge
+type CourseMergeOptions struct {
+	// DryRun works out what would move and rolls it back
+	DryRun bool
+	// MergedBy is recorded on the merge and as the surviving course's updater
+	MergedBy *uint
+}
+
+// CourseMergeService merges duplicate courses and follows the redirects
+// merges leave behind
+type CourseMergeService struct {
+	db *gorm.DB
+}
+
+func NewCourseMergeService() *CourseMergeService {
+	return &CourseMergeService{db: GetDB()}
+}
+
+// Merge moves everything attached to the duplicate course to the survivor,
+// deletes the duplicate and leaves a redirect from its ID and hash. A golfer
+// who reviewed both courses keeps their newer review; the comments and
+// helpful votes on the older one move to it. Survivor rows win other
+// clashes, such as a hole layout both courses have.
+func (s *CourseMergeService) Merge(duplicateID, survivorID uint, options CourseMergeOptions) (*CourseMerge, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+	if duplicateID == survivorID {
+		return nil, ErrCourseMergeSelf
+	}
+
+	var merge *CourseMerge
+	var survivor CourseDB
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var duplicate CourseDB
+		for _, course := range []struct {
+			id  uint
+			row *CourseDB
+		}{{duplicateID, &duplicate}, {survivorID, &survivor}} {
+			if err := tx.First(course.row, course.id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("course %d: %w", course.id, ErrCourseNotFound)
+				}
+				return fmt.Errorf("failed to load course %d: %v", course.id, err)
+			}
+		}
+
+		var err error
+		merge, err = mergeCourseRows(tx, &duplicate, &survivor, options.MergedBy)
+		if err != nil {
+			return err
+		}
+		if options.DryRun {
+			return errCourseMergeDryRun
+		}
+		return nil
+	})
+	if options.DryRun && errors.Is(err, errCourseMergeDryRun) {
+		merge.ID = 0
+		return merge, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Everything derived from the moved rows is rebuilt for the survivor
+	var course Course
+	if err := json.Unmarshal([]byte(survivor.CourseData), &course); err == nil {
+		syncCourseFacets(s.db, survivor.ID, course)
+	}
+	if err := (&CourseAttributeService{db: s.db}).RecomputeCourseAttributes(survivor.ID); err != nil {
+		log.Printf("[MERGE] Failed to recompute attributes for course %d: %v", survivor.ID, err)
+	}
+	refreshCourseReviewInsight(s.db, survivor.ID)
+	invalidateCourseTiles(s.db)
+	invalidateCourseClusters(s.db)
+
+	log.Printf("[MERGE] Merged course %d (%s) into course %d (%s): %d reviews, %d scores, %d activities moved",
+		merge.SourceCourseID, merge.SourceName, survivor.ID, survivor.Name, merge.Moved.Reviews, merge.Moved.Scores, merge.Moved.Activities)
+	return merge, nil
+}
+
+// mergeCourseRows does a merge's writes inside its transaction
+func mergeCourseRows(tx *gorm.DB, duplicate, survivor *CourseDB, mergedBy *uint) (*CourseMerge, error) {
+	merge := &CourseMerge{
+		SourceCourseID: duplicate.ID,
+		TargetCourseID: survivor.ID,
+		SourceName:     duplicate.Name,
+		SourceAddress:  duplicate.Address,
+		SourceData:     duplicate.CourseData,
+		Score:          scoreCourseDuplicate(newCourseDuplicateProfile(*duplicate), newCourseDuplicateProfile(*survivor)).Score,
+		MergedBy:       mergedBy,
+	}
+	moved := &merge.Moved
+
+	if err := mergeCourseReviews(tx, duplicate.ID, survivor.ID, moved); err != nil {
+		return nil, err
+	}
+
+	// move repoints a table's rows from the duplicate to the survivor. Rows
+	// matched by clash, which the survivor already has, are deleted instead.
+	move := func(model interface{}, count *int64, clash string) error {
+		if clash != "" {
+			err := tx.Where("course_id = ? AND "+clash, duplicate.ID, survivor.ID).Delete(model).Error
+			if err != nil {
+				return err
+			}
+		}
+		result := tx.Model(model).Where("course_id = ?", duplicate.ID).UpdateColumn("course_id", survivor.ID)
+		if count != nil {
+			*count = result.RowsAffected
+		}
+		return result.Error
+	}
+	moves := []struct {
+		name  string
+		model interface{}
+		count *int64
+		clash string
+	}{
+		{"scores", &UserCourseScore{}, &moved.Scores, ""},
+		{"player holes", &UserCourseHole{}, &moved.PlayerHoles,
+			"EXISTS (SELECT 1 FROM user_course_holes kept WHERE kept.course_id = ? AND kept.user_id = user_course_holes.user_id AND kept.number = user_course_holes.number)"},
+		{"activities", &UserActivity{}, &moved.Activities, ""},
+		{"conditions reports", &ConditionsReport{}, &moved.Conditions, ""},
+		{"notifications", &Notification{}, &moved.Notifications, ""},
+		{"list entries", &ListedCourse{}, &moved.ListEntries,
+			"list_id IN (SELECT list_id FROM listed_courses WHERE course_id = ?)"},
+		{"outings", &Outing{}, &moved.Outings, ""},
+		{"hole layouts", &CourseHoleGeometry{}, &moved.HoleLayouts,
+			"hole_number IN (SELECT hole_number FROM course_hole_geometries WHERE course_id = ?)"},
+		{"shots", &RoundShot{}, &moved.Shots, ""},
+		{"suggested edits", &CourseEditProposal{}, &moved.CourseEdits, ""},
+	}
+	for _, m := range moves {
+		if err := move(m.model, m.count, m.clash); err != nil {
+			return nil, fmt.Errorf("failed to move %s: %v", m.name, err)
+		}
+	}
+
+	itineraries, err := mergeItineraryCourses(tx, duplicate.ID, survivor.ID)
+	if err != nil {
+		return nil, err
+	}
+	moved.Itineraries = itineraries
+
+	// Derived rows are rebuilt for the survivor once the merge commits
+	for _, model := range []interface{}{&CourseRanking{}, &CourseHole{}, &CourseAttribute{}, &CourseReviewInsight{}} {
+		if err := tx.Where("course_id = ?", duplicate.ID).Delete(model).Error; err != nil {
+			return nil, fmt.Errorf("failed to clear course %d: %v", duplicate.ID, err)
+		}
+	}
+
+	data, copied, err := fillCourseData(survivor.CourseData, duplicate.CourseData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge course data: %v", err)
+	}
+	moved.CourseFields = copied
+	survivor.CourseData = data
+	if courseLocation(survivor) == nil && courseLocation(duplicate) != nil {
+		survivor.Latitude, survivor.Longitude = duplicate.Latitude, duplicate.Longitude
+	}
+	if mergedBy != nil {
+		survivor.UpdatedBy = mergedBy
+	}
+	if err := tx.Delete(duplicate).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete course %d: %v", duplicate.ID, err)
+	}
+	if err := tx.Save(survivor).Error; err != nil {
+		return nil, fmt.Errorf("failed to save course %d: %v", survivor.ID, err)
+	}
+
+	result := tx.Model(&CourseRedirect{}).Where("to_course_id = ?", duplicate.ID).UpdateColumn("to_course_id", survivor.ID)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update redirects: %v", result.Error)
+	}
+	moved.Redirects = result.RowsAffected
+	if err := tx.Create(merge).Error; err != nil {
+		return nil, fmt.Errorf("failed to record merge: %v", err)
+	}
+	redirect := CourseRedirect{FromCourseID: duplicate.ID, FromHash: duplicate.Hash, ToCourseID: survivor.ID, MergeID: merge.ID}
+	if err := tx.Create(&redirect).Error; err != nil {
+		return nil, fmt.Errorf("failed to create redirect: %v", err)
+	}
+	return merge, nil
+}
+
+// mergeCourseReviews moves the duplicate's reviews to the survivor, keeping
+// only the newest review of anyone who reviewed both
+func mergeCourseReviews(tx *gorm.DB, duplicateID, survivorID uint, moved *CourseMergeCounts) error {
+	var reviews []CourseReview
+	err := tx.Where("course_id IN ?", []uint{duplicateID, survivorID}).
+		Order("updated_at DESC, id DESC").Find(&reviews).Error
+	if err != nil {
+		return fmt.Errorf("failed to load reviews: %v", err)
+	}
+
+	kept := make(map[uint]uint)
+	for _, review := range reviews {
+		keep, ok := kept[review.UserID]
+		if !ok {
+			kept[review.UserID] = review.ID
+			continue
+		}
+
+		// The older review's discussion carries over to the one that's kept
+		if err := tx.Model(&ReviewComment{}).Where("review_id = ?", review.ID).UpdateColumn("review_id", keep).Error; err != nil {
+			return fmt.Errorf("failed to move comments: %v", err)
+		}
+		err := tx.Where("review_id = ? AND user_id IN (?)", review.ID,
+			tx.Model(&ReviewHelpfulVote{}).Select("user_id").Where("review_id = ?", keep)).
+			Delete(&ReviewHelpfulVote{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to move helpful votes: %v", err)
+		}
+		if err := tx.Model(&ReviewHelpfulVote{}).Where("review_id = ?", review.ID).UpdateColumn("review_id", keep).Error; err != nil {
+			return fmt.Errorf("failed to move helpful votes: %v", err)
+		}
+		if err := tx.Where("review_id = ?", review.ID).Delete(&ReviewAttributeVote{}).Error; err != nil {
+			return fmt.Errorf("failed to delete review attributes: %v", err)
+		}
+		if err := tx.Delete(&CourseReview{}, review.ID).Error; err != nil {
+			return fmt.Errorf("failed to delete review %d: %v", review.ID, err)
+		}
+		moved.ReviewsDropped++
+	}
+
+	result := tx.Model(&CourseReview{}).Where("course_id = ?", duplicateID).UpdateColumn("course_id", survivorID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to move reviews: %v", result.Error)
+	}
+	moved.Reviews = result.RowsAffected
+	if err := tx.Model(&ReviewAttributeVote{}).Where("course_id = ?", duplicateID).UpdateColumn("course_id", survivorID).Error; err != nil {
+		return fmt.Errorf("failed to move review attributes: %v", err)
+	}
+	return nil
+}
+
+// mergeItineraryCourses points the stops of saved itineraries at the
+// survivor. Only the course IDs change; the rest of each plan is kept as it
+// was made.
+func mergeItineraryCourses(tx *gorm.DB, duplicateID, survivorID uint) (int64, error) {
+	var itineraries []Itinerary
+	// Plans are compact JSON, so this finds every plan with the course on it
+	// and few without
+	err := tx.Select("id", "plan_data").
+		Where("plan_data LIKE ?", fmt.Sprintf(`%%"course_id":%d,%%`, duplicateID)).
+		Find(&itineraries).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load itineraries: %v", err)
+	}
+
+	var updated int64
+	for _, itinerary := range itineraries {
+		var plan TripPlan
+		if err := json.Unmarshal([]byte(itinerary.PlanData), &plan); err != nil {
+			return 0, fmt.Errorf("failed to decode itinerary %d: %v", itinerary.ID, err)
+		}
+
+		changed := false
+		repoint := func(stop *TripStop) {
+			if stop.CourseID == duplicateID {
+				stop.CourseID = survivorID
+				changed = true
+			}
+		}
+		for i := range plan.Days {
+			for j := range plan.Days[i].Legs {
+				repoint(&plan.Days[i].Legs[j].From)
+				repoint(&plan.Days[i].Legs[j].To)
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		data, err := json.Marshal(plan)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode itinerary %d: %v", itinerary.ID, err)
+		}
+		if err := tx.Model(&itinerary).UpdateColumn("plan_data", string(data)).Error; err != nil {
+			return 0, fmt.Errorf("failed to update itinerary %d: %v", itinerary.ID, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// fillCourseData copies the top-level fields of the duplicate's course data
+// that the survivor's lacks or leaves empty. It returns the merged data and
+// the fields it copied.
+func fillCourseData(survivor, duplicate string) (string, []string, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal([]byte(survivor), &fields); err != nil {
+		return "", nil, fmt.Errorf("the surviving course's data can't be read: %v", err)
+	}
+	extra := make(map[string]json.RawMessage)
+	if err := json.Unmarshal([]byte(duplicate), &extra); err != nil {
+		// Nothing can be copied from data that can't be read
+		return survivor, nil, nil
+	}
+
+	var copied []string
+	for key, value := range extra {
+		if courseMergeKeptFields[key] || isEmptyJSON(value) || !isEmptyJSON(fields[key]) {
+			continue
+		}
+		fields[key] = value
+		copied = append(copied, key)
+	}
+	if len(copied) == 0 {
+		return survivor, nil, nil
+	}
+	sort.Strings(copied)
+
+	data, err := canonicalCourseData(fields)
+	return data, copied, err
+}
+
+// isEmptyJSON reports whether a JSON value is missing, null or an empty
+// string, array or object
+func isEmptyJSON(value json.RawMessage) bool {
+	switch strings.TrimSpace(string(value)) {
+	case "", "null", `""`, "[]", "{}":
+		return true
+	}
+	return false
+}
+
+// ResolveCourseID returns the course a merged course's ID now points at, or
+// the ID itself when it wasn't merged
+func (s *CourseMergeService) ResolveCourseID(courseID uint) (uint, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not connected")
+	}
+
+	var redirect CourseRedirect
+	err := s.db.Where("from_course_id = ?", courseID).First(&redirect).Error
+	switch {
+	case err == nil:
+		return redirect.ToCourseID, nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return courseID, nil
+	}
+	return 0, fmt.Errorf("failed to look up course redirect: %v", err)
+}
+
+// writeCourseMergeReport prints a merge for the merge-courses command
+func writeCourseMergeReport(w io.Writer, merge *CourseMerge, survivorName string, applied bool) {
+	verb := "Would merge"
+	if applied {
+		verb = "Merged"
+	}
+	fmt.Fprintf(w, "%s course %d %s into course %d %s (duplicate score %.2f)\n",
+		verb, merge.SourceCourseID, merge.SourceName, merge.TargetCourseID, survivorName, merge.Score)
+
+	moved := merge.Moved
+	fmt.Fprintf(w, "  Reviews:            %d", moved.Reviews)
+	if moved.ReviewsDropped > 0 {
+		fmt.Fprintf(w, " (%d older reviews by golfers who reviewed both replaced)", moved.ReviewsDropped)
+	}
+	fmt.Fprintln(w)
+	for _, row := range []struct {
+		label string
+		count int64
+	}{
+		{"Scores", moved.Scores},
+		{"Player hole notes", moved.PlayerHoles},
+		{"Activities", moved.Activities},
+		{"Conditions reports", moved.Conditions},
+		{"Notifications", moved.Notifications},
+		{"List entries", moved.ListEntries},
+		{"Outings", moved.Outings},
+		{"Hole layouts", moved.HoleLayouts},
+		{"Tracked shots", moved.Shots},
+		{"Suggested edits", moved.CourseEdits},
+		{"Itineraries", moved.Itineraries},
+		{"Earlier redirects", moved.Redirects},
+	} {
+		fmt.Fprintf(w, "  %-19s %d\n", row.label+":", row.count)
+	}
+	if len(moved.CourseFields) > 0 {
+		fmt.Fprintf(w, "  Course fields copied: %s\n", strings.Join(moved.CourseFields, ", "))
+	}
+
+	fmt.Fprintln(w)
+	if applied {
+		fmt.Fprintf(w, "Course %d now redirects to course %d.\n", merge.SourceCourseID, merge.TargetCourseID)
+	} else {
+		fmt.Fprintln(w, "Nothing was written. Run again with -apply to merge.")
+	}
+}
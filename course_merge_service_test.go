@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mergeFixtures struct {
+	golfer    User
+	reviewer  User
+	duplicate CourseDB
+	survivor  CourseDB
+	kept      CourseReview // The golfer's newer review, on the survivor
+	older     CourseReview // The golfer's older review, on the duplicate
+}
+
+func seedMergeFixtures(t *testing.T) mergeFixtures {
+	t.Helper()
+	db := GetDB()
+
+	latitude, longitude := 35.4770, -76.8113
+	f := mergeFixtures{
+		golfer:   seedUser(t, db, "golfer", "Golfer"),
+		reviewer: seedUser(t, db, "reviewer", "Reviewer"),
+		duplicate: CourseDB{Name: "Bath CC", Address: "100 Main St Suite 2, Bath, NC", Latitude: &latitude, Longitude: &longitude,
+			CourseData: `{"name": "Bath CC", "description": "Short", "website": "https://bathcc.example", "holes": [{"number": 1, "par": 4, "yardage": 380}]}`},
+		survivor: CourseDB{Name: "Bath Country Club", Address: "100 Main Street, Bath, NC",
+			CourseData: `{"name": "Bath Country Club", "description": "Lakeside parkland course", "holes": []}`},
+	}
+	for _, row := range []interface{}{&f.duplicate, &f.survivor} {
+		require.NoError(t, db.Create(row).Error)
+	}
+
+	f.older = CourseReview{CourseID: f.duplicate.ID, UserID: f.golfer.ID, CreatedAt: 1000, UpdatedAt: 1000}
+	f.kept = CourseReview{CourseID: f.survivor.ID, UserID: f.golfer.ID, CreatedAt: 2000, UpdatedAt: 2000}
+	moved := CourseReview{CourseID: f.duplicate.ID, UserID: f.reviewer.ID, CreatedAt: 1500, UpdatedAt: 1500}
+	for _, review := range []*CourseReview{&f.older, &f.kept, &moved} {
+		require.NoError(t, db.Create(review).Error)
+	}
+
+	golferList := CourseList{UserID: f.golfer.ID, Name: "Want to play", Kind: "want_to_play"}
+	reviewerList := CourseList{UserID: f.reviewer.ID, Name: "Want to play", Kind: "want_to_play"}
+	require.NoError(t, db.Create(&golferList).Error)
+	require.NoError(t, db.Create(&reviewerList).Error)
+
+	rows := []interface{}{
+		&ReviewComment{ReviewID: f.older.ID, UserID: f.reviewer.ID, Body: "Agreed"},
+		&ReviewHelpfulVote{ReviewID: f.older.ID, UserID: f.reviewer.ID},
+		&ReviewHelpfulVote{ReviewID: f.kept.ID, UserID: f.reviewer.ID},
+		&ReviewAttributeVote{ReviewID: f.older.ID, CourseID: f.duplicate.ID, Kind: AttributeKindTag, Slug: "walkable", Value: true},
+		&UserCourseScore{CourseID: f.duplicate.ID, UserID: f.golfer.ID, Score: 84},
+		&UserCourseHole{CourseID: f.duplicate.ID, UserID: f.golfer.ID, Number: 1},
+		&UserCourseHole{CourseID: f.duplicate.ID, UserID: f.golfer.ID, Number: 2},
+		&UserCourseHole{CourseID: f.survivor.ID, UserID: f.golfer.ID, Number: 1},
+		&UserActivity{UserID: f.golfer.ID, ActivityType: "score_posted", CourseID: &f.duplicate.ID, Data: "{}"},
+		&ListedCourse{ListID: golferList.ID, CourseID: f.duplicate.ID, Position: 1},
+		&ListedCourse{ListID: golferList.ID, CourseID: f.survivor.ID, Position: 2},
+		&ListedCourse{ListID: reviewerList.ID, CourseID: f.duplicate.ID, Position: 1},
+		&CourseHoleGeometry{CourseID: f.duplicate.ID, HoleNumber: 1, GeometryData: "{}", Source: "manual"},
+		&CourseEditProposal{CourseID: f.duplicate.ID, UserID: f.reviewer.ID, Status: CourseEditPending,
+			Changes: []CourseFieldChange{{Field: "address", From: "100 Main St Suite 2, Bath, NC", To: "100 Main Street, Bath, NC"}}},
+		// An earlier merge into the duplicate
+		&CourseRedirect{FromCourseID: 9999, FromHash: "bath-old", ToCourseID: f.duplicate.ID, MergeID: 1},
+	}
+	for _, row := range rows {
+		require.NoError(t, db.Create(row).Error)
+	}
+
+	plan, err := json.Marshal(TripPlan{Days: []TripDay{{Day: 1, Legs: []TripLeg{
+		{From: TripStop{Name: "Home"}, To: TripStop{CourseID: f.survivor.ID, Name: f.survivor.Name}},
+		{From: TripStop{CourseID: f.survivor.ID, Name: f.survivor.Name}, To: TripStop{CourseID: f.duplicate.ID, Name: f.duplicate.Name}},
+	}}}})
+	require.NoError(t, err)
+	itinerary := Itinerary{UserID: f.golfer.ID, Name: "Bath weekend", Days: 1, PlanData: string(plan)}
+	require.NoError(t, db.Create(&itinerary).Error)
+	return f
+}
+
+func TestCourseMergeService_Merge(t *testing.T) {
+	db := setupTestDatabase(t)
+	f := seedMergeFixtures(t)
+	service := NewCourseMergeService()
+
+	expected := CourseMergeCounts{
+		Reviews: 1, ReviewsDropped: 1, Scores: 1, PlayerHoles: 1, Activities: 1,
+		ListEntries: 1, HoleLayouts: 1, CourseEdits: 1, Itineraries: 1, Redirects: 1, CourseFields: []string{"holes", "website"},
+	}
+
+	t.Run("a dry run changes nothing", func(t *testing.T) {
+		merge, err := service.Merge(f.duplicate.ID, f.survivor.ID, CourseMergeOptions{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, uint(0), merge.ID)
+		assert.Equal(t, expected, merge.Moved)
+
+		var count int64
+		db.Model(&CourseDB{}).Where("id = ?", f.duplicate.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+		db.Model(&CourseRedirect{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("merging moves everything to the survivor", func(t *testing.T) {
+		merge, err := service.Merge(f.duplicate.ID, f.survivor.ID, CourseMergeOptions{MergedBy: &f.golfer.ID})
+		require.NoError(t, err)
+		assert.NotZero(t, merge.ID)
+		assert.InDelta(t, 1.0, merge.Score, 1e-9)
+
+		var stored CourseMerge
+		require.NoError(t, db.First(&stored, merge.ID).Error)
+		assert.Equal(t, expected, stored.Moved)
+		assert.Equal(t, "Bath CC", stored.SourceName)
+		assert.Contains(t, stored.SourceData, "bathcc.example")
+		assert.Equal(t, f.golfer.ID, *stored.MergedBy)
+
+		err = db.First(&CourseDB{}, f.duplicate.ID).Error
+		assert.Error(t, err, "the duplicate is deleted")
+
+		var reviews []CourseReview
+		require.NoError(t, db.Where("course_id = ?", f.survivor.ID).Order("id").Find(&reviews).Error)
+		require.Len(t, reviews, 2)
+		assert.Equal(t, f.kept.ID, reviews[0].ID, "the golfer's newer review is kept")
+		var comment ReviewComment
+		require.NoError(t, db.First(&comment).Error)
+		assert.Equal(t, f.kept.ID, comment.ReviewID)
+		var votes int64
+		db.Model(&ReviewHelpfulVote{}).Count(&votes)
+		assert.Equal(t, int64(1), votes, "a voter who liked both reviews keeps one vote")
+
+		for _, model := range []interface{}{&UserCourseScore{}, &UserCourseHole{}, &UserActivity{}, &ListedCourse{}, &CourseHoleGeometry{}, &ReviewAttributeVote{}, &CourseEditProposal{}} {
+			var left int64
+			db.Model(model).Where("course_id = ?", f.duplicate.ID).Count(&left)
+			assert.Zero(t, left, "%T", model)
+		}
+		var listed int64
+		db.Model(&ListedCourse{}).Where("course_id = ?", f.survivor.ID).Count(&listed)
+		assert.Equal(t, int64(2), listed)
+
+		edits, err := NewCourseEditService().ListForCourse(f.reviewer.ID, f.survivor.ID)
+		require.NoError(t, err)
+		require.Len(t, edits, 1, "pending suggested edits move to the survivor")
+		assert.Equal(t, CourseEditPending, edits[0].Status)
+		assert.Equal(t, "Bath Country Club", edits[0].CourseName)
+
+		var itinerary Itinerary
+		require.NoError(t, db.First(&itinerary).Error)
+		var plan TripPlan
+		require.NoError(t, json.Unmarshal([]byte(itinerary.PlanData), &plan))
+		assert.Equal(t, f.survivor.ID, plan.Days[0].Legs[1].To.CourseID, "saved itineraries are repointed")
+		assert.Equal(t, "Bath CC", plan.Days[0].Legs[1].To.Name, "the rest of the plan is kept")
+
+		var survivor CourseDB
+		require.NoError(t, db.First(&survivor, f.survivor.ID).Error)
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(survivor.CourseData), &data))
+		assert.Equal(t, "Lakeside parkland course", data["description"], "the survivor's own fields win")
+		assert.Equal(t, "https://bathcc.example", data["website"])
+		require.NotNil(t, survivor.Latitude)
+		assert.Equal(t, 35.4770, *survivor.Latitude)
+		assert.Equal(t, f.golfer.ID, *survivor.UpdatedBy)
+		var holes []CourseHole
+		require.NoError(t, db.Where("course_id = ?", f.survivor.ID).Find(&holes).Error)
+		require.Len(t, holes, 1, "the copied card is synced")
+		assert.Equal(t, intPtr(380), holes[0].Yardage)
+
+		for _, id := range []uint{f.duplicate.ID, 9999, f.survivor.ID} {
+			resolved, err := service.ResolveCourseID(id)
+			require.NoError(t, err)
+			assert.Equal(t, f.survivor.ID, resolved, "course %d", id)
+		}
+	})
+
+	t.Run("merged courses aren't imported again", func(t *testing.T) {
+		record := fmt.Sprintf(`{"name": %q, "address": %q, "description": "Again", "overallRating": "B", "ranks": {"price": "$", "handicapDifficulty": 5, "hazardDifficulty": 2, "condition": "B", "merch": "B", "enjoymentRating": "B", "vibe": "B", "range": "B", "amenities": "B", "glizzies": "B"}}`, f.duplicate.Name, f.duplicate.Address)
+		report, err := NewCourseBulkService().Import(readTestCourseRecords(t, record), CourseImportOptions{DryRun: true})
+		require.NoError(t, err)
+		require.Equal(t, courseImportInvalid, report.Results[0].Action)
+		assert.Equal(t, fmt.Sprintf("this course was merged into course %d", f.survivor.ID), report.Results[0].Errors[0].Message)
+
+		var existing []CourseDB
+		require.NoError(t, db.Find(&existing).Error)
+		var redirects []CourseRedirect
+		require.NoError(t, db.Find(&redirects).Error)
+		index := newOSMCourseIndex(existing)
+		index.addRedirects(redirects)
+		duplicate := index.match(OSMCourse{Name: f.duplicate.Name, Address: f.duplicate.Address, Location: GeoPoint{Latitude: 10, Longitude: 10}})
+		require.NotNil(t, duplicate)
+		assert.Equal(t, f.survivor.ID, duplicate.MatchID)
+		assert.Equal(t, "hash", duplicate.Reason)
+	})
+
+	t.Run("merges need two existing courses", func(t *testing.T) {
+		_, err := service.Merge(f.survivor.ID, f.survivor.ID, CourseMergeOptions{})
+		assert.ErrorIs(t, err, ErrCourseMergeSelf)
+		_, err = service.Merge(f.duplicate.ID, f.survivor.ID, CourseMergeOptions{})
+		assert.ErrorIs(t, err, ErrCourseNotFound)
+	})
+}
+
+func TestRedirectMergedCourses(t *testing.T) {
+	db := setupTestDatabase(t)
+	require.NoError(t, db.Create(&CourseRedirect{FromCourseID: 7, FromHash: "old", ToCourseID: 12, MergeID: 1}).Error)
+
+	e := echo.New()
+	group := e.Group("/api/v1")
+	group.Use(RedirectMergedCourses())
+	ok := func(c echo.Context) error { return c.String(http.StatusOK, c.Param("id")) }
+	group.GET("/courses/:id/conditions", ok)
+	group.GET("/courses/:courseId/reviews", ok)
+	group.GET("/lists/:id/courses/:courseId", ok)
+	group.GET("/map/courses/clusters/:id", ok)
+	group.POST("/lists/:id", ok)
+
+	for path, expected := range map[string]string{
+		"/api/v1/courses/7/conditions?hours=2": "/api/v1/courses/12/conditions?hours=2",
+		"/api/v1/courses/12/conditions":        "",
+		"/api/v1/courses/7/reviews":            "/api/v1/courses/12/reviews",
+		"/api/v1/lists/7/courses/7":            "/api/v1/lists/7/courses/12",
+		"/api/v1/map/courses/clusters/7":       "",
+		"/api/v1/lists/7":                      "",
+	} {
+		method := http.MethodGet
+		if path == "/api/v1/lists/7" {
+			method = http.MethodPost
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		if expected == "" {
+			assert.Equal(t, http.StatusOK, rec.Code, path)
+			continue
+		}
+		assert.Equal(t, http.StatusPermanentRedirect, rec.Code, path)
+		assert.Equal(t, expected, rec.Header().Get("Location"), path)
+	}
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Course file formats coursectl reads and writes
+const (
+	courseFormatJSON   = "json"
+	courseFormatNDJSON = "ndjson"
+	courseFormatCSV    = "csv"
+)
+
+// courseRecord is one course read from a file, with where it was and how it
+// breaks the schema
+type courseRecord struct {
+	Source     string
+	Line       int
+	Value      *jsonValue
+	Violations []courseRecordError
+}
+
+// courseRecordError is a problem at a position in a course file
+type courseRecordError struct {
+	Source  string
+	Line    int
+	Column  int
+	Pointer string
+	Message string
+}
+
+func (e courseRecordError) String() string {
+	location := e.Source
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", e.Source, e.Line, e.Column)
+	}
+	if e.Pointer != "" {
+		return fmt.Sprintf("%s: %s: %s", location, e.Pointer, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", location, e.Message)
+}
+
+// courseFileFormat picks a file's format from the -format flag, else its
+// extension
+func courseFileFormat(path, format string) (string, error) {
+	if format != "" {
+		switch format {
+		case courseFormatJSON, courseFormatNDJSON, courseFormatCSV:
+			return format, nil
+		}
+		return "", fmt.Errorf("unknown format %q, expected json, ndjson or csv", format)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return courseFormatJSON, nil
+	case ".ndjson", ".jsonl":
+		return courseFormatNDJSON, nil
+	case ".csv":
+		return courseFormatCSV, nil
+	}
+	return "", fmt.Errorf("%s: can't tell the format from the extension; use -format", path)
+}
+
+// readCourseRecords reads the courses in a file and validates each against
+// the schema. A file that can't be parsed returns a single error for the
+// file; records that parse but don't validate carry their violations.
+func readCourseRecords(path, format string, schema *jsonSchema) ([]courseRecord, *courseRecordError) {
+	fileError := func(line, column int, message string) *courseRecordError {
+		return &courseRecordError{Source: path, Line: line, Column: column, Message: message}
+	}
+
+	format, err := courseFileFormat(path, format)
+	if err != nil {
+		return nil, fileError(0, 0, err.Error())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fileError(0, 0, err.Error())
+	}
+
+	var records []courseRecord
+	switch format {
+	case courseFormatJSON:
+		document, err := parseJSONDocument(data, 1)
+		if err != nil {
+			return nil, syntaxRecordError(path, err)
+		}
+		switch document.Kind {
+		case jsonObject:
+			records = append(records, courseRecord{Value: document})
+		case jsonArray:
+			for _, item := range document.Items {
+				records = append(records, courseRecord{Value: item})
+			}
+		default:
+			return nil, fileError(document.Line, document.Column, "expected a course object or an array of courses")
+		}
+
+	case courseFormatNDJSON:
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			document, err := parseJSONDocument(scanner.Bytes(), line)
+			if err != nil {
+				return nil, syntaxRecordError(path, err)
+			}
+			records = append(records, courseRecord{Value: document})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fileError(0, 0, err.Error())
+		}
+
+	case courseFormatCSV:
+		var csvErr *courseRecordError
+		records, csvErr = readCSVCourseRecords(path, data, schema)
+		if csvErr != nil {
+			return nil, csvErr
+		}
+	}
+
+	for i := range records {
+		record := &records[i]
+		record.Source, record.Line = path, record.Value.Line
+		if record.Value.Kind != jsonObject {
+			record.Violations = append(record.Violations, courseRecordError{
+				Source: path, Line: record.Value.Line, Column: record.Value.Column, Pointer: "/", Message: "expected a course object",
+			})
+			continue
+		}
+		for _, violation := range schema.validate(record.Value) {
+			pointer := violation.Pointer
+			if pointer == "" {
+				pointer = "/"
+			}
+			record.Violations = append(record.Violations, courseRecordError{
+				Source: path, Line: violation.Line, Column: violation.Column, Pointer: pointer, Message: violation.Message,
+			})
+		}
+	}
+	return records, nil
+}
+
+func syntaxRecordError(path string, err error) *courseRecordError {
+	var syntax *jsonSyntaxError
+	if errors.As(err, &syntax) {
+		return &courseRecordError{Source: path, Line: syntax.Line, Column: syntax.Column, Message: syntax.Message}
+	}
+	return &courseRecordError{Source: path, Message: err.Error()}
+}
+
+// readCSVCourseRecords reads one course per row. Headers are paths into the
+// course, with dots between levels and 1-based array positions, such as
+// "ranks.price" or "holes.3.par". Cells are typed by the schema, and empty
+// cells are left out.
+func readCSVCourseRecords(path string, data []byte, schema *jsonSchema) ([]courseRecord, *courseRecordError) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	fail := func(line, column int, format string, args ...interface{}) *courseRecordError {
+		return &courseRecordError{Source: path, Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+	}
+	csvFail := func(err error) *courseRecordError {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return fail(parseErr.Line, parseErr.Column, "invalid CSV: %v", parseErr.Err)
+		}
+		return fail(0, 0, "invalid CSV: %v", err)
+	}
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, csvFail(err)
+	}
+
+	columns := make([][]string, len(header))
+	seen := make(map[string]int)
+	for i, name := range header {
+		line, column := reader.FieldPos(i)
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		segments := strings.Split(name, ".")
+		for j, segment := range segments {
+			if segment == "" {
+				return nil, fail(line, column, "column %q has an empty path segment", name)
+			}
+			if j > 0 {
+				if index, err := strconv.Atoi(segment); err == nil && index < 1 {
+					return nil, fail(line, column, "column %q: positions start at 1", name)
+				}
+			}
+		}
+		for other := range seen {
+			if other == name || strings.HasPrefix(other, name+".") || strings.HasPrefix(name, other+".") {
+				return nil, fail(line, column, "column %q overlaps column %q", name, other)
+			}
+		}
+		seen[name] = i
+		columns[i] = segments
+	}
+
+	var records []courseRecord
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, csvFail(err)
+		}
+
+		line, column := reader.FieldPos(0)
+		course := &jsonValue{Kind: jsonObject, Line: line, Column: column}
+		for i, cell := range row {
+			if cell == "" {
+				continue
+			}
+			line, column := reader.FieldPos(i)
+			cellValue := csvCellValue(cell, schemaAt(schema, columns[i]))
+			cellValue.Line, cellValue.Column = line, column
+			setJSONPath(course, columns[i], cellValue)
+		}
+		compactJSONArrays(course)
+		records = append(records, courseRecord{Value: course})
+	}
+	return records, nil
+}
+
+// schemaAt follows a CSV column's path through the schema, returning nil
+// where the schema doesn't describe it
+func schemaAt(schema *jsonSchema, path []string) *jsonSchema {
+	for _, segment := range path {
+		if schema == nil {
+			return nil
+		}
+		if _, err := strconv.Atoi(segment); err == nil && schema.items != nil {
+			schema = schema.items
+		} else {
+			schema = schema.property(segment)
+		}
+	}
+	return schema
+}
+
+// csvCellValue types a cell the way its schema expects. Cells that don't
+// parse stay strings, so validation reports them against the cell.
+func csvCellValue(cell string, schema *jsonSchema) *jsonValue {
+	if schema != nil {
+		trimmed := strings.TrimSpace(cell)
+		if schema.allows("integer") || schema.allows("number") {
+			if _, err := strconv.ParseFloat(trimmed, 64); err == nil && json.Valid([]byte(trimmed)) {
+				return &jsonValue{Kind: jsonNumber, Text: trimmed}
+			}
+		}
+		if schema.allows("boolean") {
+			if parsed, err := strconv.ParseBool(trimmed); err == nil {
+				return &jsonValue{Kind: jsonBool, Bool: parsed}
+			}
+		}
+	}
+	return &jsonValue{Kind: jsonString, Text: cell}
+}
+
+// setJSONPath places a value in an object, creating the objects and arrays on
+// its path. Array positions are 1-based; gaps are left as nil until
+// compactJSONArrays closes them.
+func setJSONPath(target *jsonValue, path []string, value *jsonValue) {
+	for i, segment := range path {
+		last := i == len(path)-1
+		child := func() *jsonValue {
+			if last {
+				return value
+			}
+			if _, err := strconv.Atoi(path[i+1]); err == nil {
+				return &jsonValue{Kind: jsonArray, Line: value.Line, Column: value.Column}
+			}
+			return &jsonValue{Kind: jsonObject, Line: value.Line, Column: value.Column}
+		}
+
+		if index, err := strconv.Atoi(segment); err == nil && target.Kind == jsonArray {
+			for len(target.Items) < index {
+				target.Items = append(target.Items, nil)
+			}
+			if target.Items[index-1] == nil {
+				target.Items[index-1] = child()
+			}
+			target = target.Items[index-1]
+			continue
+		}
+
+		next := target.field(segment)
+		if next == nil {
+			next = child()
+			target.Fields = append(target.Fields, jsonField{Key: segment, Value: next})
+		}
+		target = next
+	}
+}
+
+func compactJSONArrays(value *jsonValue) {
+	if value.Kind == jsonArray {
+		items := value.Items[:0]
+		for _, item := range value.Items {
+			if item != nil {
+				items = append(items, item)
+			}
+		}
+		value.Items = items
+	}
+	for _, item := range value.Items {
+		compactJSONArrays(item)
+	}
+	for _, field := range value.Fields {
+		compactJSONArrays(field.Value)
+	}
+}
+
+// writeCourseRecords writes exported courses in a format
+func writeCourseRecords(w io.Writer, format string, courses []*jsonValue) error {
+	switch format {
+	case courseFormatJSON:
+		items := make([]json.RawMessage, len(courses))
+		for i, course := range courses {
+			encoded, err := course.MarshalJSON()
+			if err != nil {
+				return err
+			}
+			items[i] = encoded
+		}
+		encoded, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", encoded)
+		return err
+
+	case courseFormatNDJSON:
+		for _, course := range courses {
+			encoded, err := course.MarshalJSON()
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "%s\n", encoded); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case courseFormatCSV:
+		return writeCSVCourseRecords(w, courses)
+	}
+	return fmt.Errorf("unknown format %q, expected json, ndjson or csv", format)
+}
+
+// csvColumn is a node in the tree of column paths, which keeps properties in
+// the order courses list them and array positions in number order
+type csvColumn struct {
+	key      string
+	children []*csvColumn
+	leaf     bool
+}
+
+func (c *csvColumn) child(key string) *csvColumn {
+	for _, child := range c.children {
+		if child.key == key {
+			return child
+		}
+	}
+	child := &csvColumn{key: key}
+	c.children = append(c.children, child)
+	return child
+}
+
+func (c *csvColumn) paths(prefix string, out []string) []string {
+	if c.leaf {
+		out = append(out, prefix)
+	}
+	sort.SliceStable(c.children, func(i, j int) bool {
+		a, errA := strconv.Atoi(c.children[i].key)
+		b, errB := strconv.Atoi(c.children[j].key)
+		return errA == nil && errB == nil && a < b
+	})
+	for _, child := range c.children {
+		path := child.key
+		if prefix != "" {
+			path = prefix + "." + child.key
+		}
+		out = child.paths(path, out)
+	}
+	return out
+}
+
+// writeCSVCourseRecords flattens courses into the columns readCSVCourseRecords
+// reads
+func writeCSVCourseRecords(w io.Writer, courses []*jsonValue) error {
+	root := &csvColumn{}
+	rows := make([]map[string]string, len(courses))
+	for i, course := range courses {
+		rows[i] = make(map[string]string)
+		flattenCSVCourse(course, root, "", rows[i])
+	}
+	header := root.paths("", nil)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	row := make([]string, len(header))
+	for _, values := range rows {
+		for i, column := range header {
+			row[i] = values[column]
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func flattenCSVCourse(value *jsonValue, column *csvColumn, path string, row map[string]string) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch value.Kind {
+	case jsonObject:
+		for _, field := range value.Fields {
+			flattenCSVCourse(field.Value, column.child(field.Key), join(field.Key), row)
+		}
+	case jsonArray:
+		for i, item := range value.Items {
+			key := strconv.Itoa(i + 1)
+			flattenCSVCourse(item, column.child(key), join(key), row)
+		}
+	case jsonNull:
+		column.leaf = true
+	case jsonBool:
+		column.leaf = true
+		row[path] = strconv.FormatBool(value.Bool)
+	default:
+		column.leaf = true
+		row[path] = value.Text
+	}
+}
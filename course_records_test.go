@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCourseJSON is a course that meets courses/schema.json
+const testCourseJSON = `{"name": "Sandhills Links", "description": "Links in the pines", "address": "100 Links Drive, Pinehurst, NC", "overallRating": "A", "ranks": {"price": "$$", "handicapDifficulty": 12, "hazardDifficulty": 3, "condition": "A", "merch": "B", "enjoymentRating": "A", "vibe": "S", "range": "B", "amenities": "C", "glizzies": "A"}, "holes": [{"number": 1, "par": 4, "yardage": 401}, {"number": 2, "par": 3, "yardage": 165, "description": "Over water"}]}`
+
+func writeCourseFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func violationStrings(records []courseRecord) []string {
+	var problems []string
+	for _, record := range records {
+		for _, violation := range record.Violations {
+			problems = append(problems, violation.String())
+		}
+	}
+	return problems
+}
+
+func TestReadCourseRecords(t *testing.T) {
+	schema, err := loadJSONSchema(defaultCourseSchema)
+	require.NoError(t, err)
+
+	t.Run("NDJSON reports the line each course is on", func(t *testing.T) {
+		invalid := strings.Replace(testCourseJSON, `"par": 3`, `"par": 9`, 1)
+		path := writeCourseFile(t, "courses.ndjson", testCourseJSON+"\n\n"+invalid+"\n")
+
+		records, fileErr := readCourseRecords(path, "", schema)
+		require.Nil(t, fileErr)
+		require.Len(t, records, 2)
+		assert.Equal(t, []int{1, 3}, []int{records[0].Line, records[1].Line})
+		assert.Empty(t, records[0].Violations)
+		assert.Equal(t, []string{path + ":3:398: /holes/1/par: 9 is greater than the maximum of 6"}, violationStrings(records))
+	})
+
+	t.Run("JSON files hold one course or an array", func(t *testing.T) {
+		path := writeCourseFile(t, "courses.json", "[\n"+testCourseJSON+",\n"+testCourseJSON+"\n]")
+		records, fileErr := readCourseRecords(path, "", schema)
+		require.Nil(t, fileErr)
+		assert.Equal(t, []int{2, 3}, []int{records[0].Line, records[1].Line})
+
+		_, fileErr = readCourseRecords(writeCourseFile(t, "courses.json", `"Sandhills"`), "", schema)
+		require.NotNil(t, fileErr)
+		assert.Contains(t, fileErr.String(), ":1:1: expected a course object or an array of courses")
+
+		_, fileErr = readCourseRecords(writeCourseFile(t, "broken.json", "{\n  \"name\": \"Sandhills\"\n  \"address\": \"\"\n}"), "", schema)
+		require.NotNil(t, fileErr)
+		assert.Contains(t, fileErr.String(), ":3:3: invalid JSON")
+
+		_, fileErr = readCourseRecords(writeCourseFile(t, "courses.txt", testCourseJSON), "", schema)
+		require.NotNil(t, fileErr)
+		assert.Contains(t, fileErr.String(), "use -format")
+		records, fileErr = readCourseRecords(writeCourseFile(t, "courses.txt", testCourseJSON), courseFormatJSON, schema)
+		require.Nil(t, fileErr)
+		assert.Len(t, records, 1)
+	})
+
+	t.Run("CSV columns are paths typed by the schema", func(t *testing.T) {
+		path := writeCourseFile(t, "courses.csv", strings.Join([]string{
+			"name,description,address,overallRating,ranks.price,ranks.handicapDifficulty,ranks.hazardDifficulty,ranks.condition,ranks.merch,ranks.enjoymentRating,ranks.vibe,ranks.range,ranks.amenities,ranks.glizzies,holes.1.number,holes.1.par,holes.1.yardage,holes.2.number,holes.2.par,holes.2.yardage",
+			`Sandhills Links,"Links, in the pines",Pinehurst NC,A,$$,12,3,A,B,A,S,B,C,A,,,,2,3,165`,
+			`Mid Pines,Classic,Southern Pines NC,B,$$$,ten,2,A,B,A,S,B,C,A,1,4,390,,,`,
+		}, "\n"))
+
+		records, fileErr := readCourseRecords(path, "", schema)
+		require.Nil(t, fileErr)
+		require.Len(t, records, 2)
+		assert.Equal(t, []int{2, 3}, []int{records[0].Line, records[1].Line})
+
+		encoded, err := records[0].Value.field("holes").MarshalJSON()
+		require.NoError(t, err)
+		assert.Equal(t, `[{"number":2,"par":3,"yardage":165}]`, string(encoded), "empty cells are left out and the gap closed")
+		assert.Equal(t, "Links, in the pines", records[0].Value.field("description").Text)
+		assert.Equal(t, []string{path + ":3:43: /ranks/handicapDifficulty: expected integer, got string"}, violationStrings(records))
+
+		_, fileErr = readCourseRecords(writeCourseFile(t, "courses.csv", "name,holes,holes.1.par\nA,B,4\n"), "", schema)
+		require.NotNil(t, fileErr)
+		assert.Contains(t, fileErr.String(), `:1:12: column "holes.1.par" overlaps column "holes"`)
+		_, fileErr = readCourseRecords(writeCourseFile(t, "courses.csv", "name,address\n\"Sandhills,NC\n"), "", schema)
+		require.NotNil(t, fileErr)
+		assert.Contains(t, fileErr.String(), "invalid CSV")
+	})
+}
+
+func TestWriteCourseRecords(t *testing.T) {
+	schema, err := loadJSONSchema(defaultCourseSchema)
+	require.NoError(t, err)
+	course, err := parseJSONDocument([]byte(testCourseJSON), 1)
+	require.NoError(t, err)
+	short, err := parseJSONDocument([]byte(`{"name": "Par Three", "holes": [{"number": 1, "par": 3, "yardage": 120}], "website": "https://parthree.example"}`), 1)
+	require.NoError(t, err)
+	courses := []*jsonValue{short, course}
+
+	var out bytes.Buffer
+	require.NoError(t, writeCourseRecords(&out, courseFormatCSV, courses))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "name,holes.1.number,holes.1.par,holes.1.yardage,holes.2.number,holes.2.par,holes.2.yardage,holes.2.description,website,description,address,overallRating,ranks.price,ranks.handicapDifficulty,ranks.hazardDifficulty,ranks.condition,ranks.merch,ranks.enjoymentRating,ranks.vibe,ranks.range,ranks.amenities,ranks.glizzies", lines[0])
+	assert.Equal(t, "Par Three,1,3,120,,,,,https://parthree.example,,,,,,,,,,,,,", lines[1])
+
+	// What's written reads back the same
+	for _, format := range []string{courseFormatJSON, courseFormatNDJSON, courseFormatCSV} {
+		out.Reset()
+		require.NoError(t, writeCourseRecords(&out, format, courses))
+		records, fileErr := readCourseRecords(writeCourseFile(t, "export."+format, out.String()), "", schema)
+		require.Nil(t, fileErr, format)
+		require.Len(t, records, 2, format)
+		for i, record := range records {
+			assert.True(t, jsonValuesEqual(courses[i], record.Value), "%s: %s", format, out.String())
+		}
+		assert.Empty(t, records[1].Violations, format)
+	}
+
+	assert.Error(t, writeCourseRecords(&out, "xml", courses))
+}
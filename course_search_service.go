@@ -0,0 +1,647 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxSearchTerms        = 10
+	maxSuggestionLimit    = 10
+	searchSnippetLength   = 160
+	suggestionCandidates  = 50
+	locationCandidates    = 200
+	searchReviewRankScale = 0.4 // Review text counts for less than the course's own fields
+)
+
+// courseSearchVectorSQL is the weighted document a course is searched by on
+// Postgres: name, address (which holds the city), description and the
+// course's own review. CreatePerformanceIndexes builds a GIN index on the
+// same expression, so it must stay in sync with idx_course_dbs_search.
+const courseSearchVectorSQL = "(setweight(to_tsvector('english', coalesce(name, '')), 'A') || " +
+	"setweight(to_tsvector('english', coalesce(address, '')), 'B') || " +
+	"setweight(to_tsvector('english', coalesce(course_data->>'description', '')), 'C') || " +
+	"setweight(to_tsvector('english', coalesce(course_data->>'review', '')), 'D'))"
+
+// reviewSearchVectorSQL is the document a review is searched by, matching
+// idx_course_reviews_search
+const reviewSearchVectorSQL = "to_tsvector('english', coalesce(review_text, ''))"
+
+// trigramChecks caches, per connection, whether Postgres has pg_trgm
+var trigramChecks sync.Map
+
+// searchStopwords are dropped from queries, as Postgres' english configuration does
+var searchStopwords = toWordSet(`a an and at by for in of on or the to with`)
+
+// Field weights for the in-memory ranking, in the spirit of the A-D weights
+// of the Postgres vector
+var searchFieldWeights = map[string]float64{
+	"name":        1.0,
+	"city":        0.7,
+	"address":     0.5,
+	"description": 0.3,
+	"review":      0.2,
+}
+
+// CourseSearchResult is a course matching a search, best first
+type CourseSearchResult struct {
+	CourseID  uint
+	Name      string
+	Address   string
+	City      string
+	Latitude  *float64
+	Longitude *float64
+	Rank      float64
+	// Snippet is HTML: the matching passage, escaped, with the matched
+	// words in <mark> tags. Empty when only the name or address matched.
+	Snippet string
+}
+
+// SearchSuggestions are typeahead completions for a search box
+type SearchSuggestions struct {
+	Courses   []CourseSuggestion
+	Locations []string
+	Users     []UserSuggestion
+}
+
+// CourseSuggestion is a course whose name starts with the typed prefix
+type CourseSuggestion struct {
+	ID   uint
+	Name string
+}
+
+// UserSuggestion is a user whose display name starts with the typed prefix
+type UserSuggestion struct {
+	ID   uint
+	Name string
+}
+
+// CourseSearchService provides full-text course search and typeahead
+// suggestions. On Postgres it uses full-text search with pg_trgm similarity
+// for typos, when the extension is installed; other databases (SQLite in
+// tests) rank in memory.
+type CourseSearchService struct {
+	db *gorm.DB
+}
+
+func NewCourseSearchService() *CourseSearchService {
+	return &CourseSearchService{db: GetDB()}
+}
+
+// searchDocument is the searchable text of one course
+type searchDocument struct {
+	course      CourseDB
+	city        string
+	description string
+	review      string   // The review in the course data
+	reviews     []string // Review texts of non-private reviews
+}
+
+// Search returns a page of the courses matching query, best first, with the
+// total number of matches
+func (s *CourseSearchService) Search(query string, limit, offset int) ([]CourseSearchResult, int, error) {
+	results, err := s.MatchCourses(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(results)
+	if offset >= total {
+		return []CourseSearchResult{}, total, nil
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return results[offset:end], total, nil
+}
+
+// MatchCourses returns every course matching query, best first. Every word
+// of the query has to match, but words may be misspelled slightly.
+func (s *CourseSearchService) MatchCourses(query string) ([]CourseSearchResult, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []CourseSearchResult{}, nil
+	}
+
+	if s.db.Dialector.Name() == "postgres" {
+		return s.matchPostgres(query, terms)
+	}
+	return s.matchInMemory(terms)
+}
+
+// matchPostgres ranks with ts_rank_cd over the weighted course vector, plus
+// the best matching review and trigram similarity of the name. Without
+// pg_trgm, names and addresses containing the query match instead of close
+// misspellings.
+func (s *CourseSearchService) matchPostgres(query string, terms []string) ([]CourseSearchResult, error) {
+	type rankedCourse struct {
+		ID   uint
+		Rank float64
+	}
+
+	phrase := strings.Join(terms, " ")
+	contains := "%" + escapeLike(phrase) + "%"
+	similarity := gorm.Expr("0")
+	fuzzyMatch := gorm.Expr("course_dbs.name ILIKE ? OR course_dbs.address ILIKE ?", contains, contains)
+	if s.hasTrigrams() {
+		similarity = gorm.Expr("similarity(course_dbs.name, ?)", phrase)
+		fuzzyMatch = gorm.Expr("course_dbs.name % ? OR ? <% (course_dbs.name || ' ' || coalesce(course_dbs.address, ''))", phrase, phrase)
+	}
+
+	var ranked []rankedCourse
+	err := s.db.Raw(`
+		WITH search AS (SELECT websearch_to_tsquery('english', ?) AS query)
+		SELECT course_dbs.id,
+			ts_rank_cd(`+courseSearchVectorSQL+`, search.query)
+			+ ? * COALESCE((
+				SELECT MAX(ts_rank_cd(`+reviewSearchVectorSQL+`, search.query))
+				FROM course_reviews
+				WHERE course_reviews.course_id = course_dbs.id AND course_reviews.visibility <> ?
+				AND `+reviewSearchVectorSQL+` @@ search.query
+			), 0)
+			+ ? AS rank
+		FROM course_dbs, search
+		WHERE `+courseSearchVectorSQL+` @@ search.query
+			OR course_dbs.id IN (
+				SELECT course_id FROM course_reviews
+				WHERE visibility <> ? AND `+reviewSearchVectorSQL+` @@ search.query
+			)
+			OR (?)
+		ORDER BY rank DESC, course_dbs.name, course_dbs.id`,
+		query, searchReviewRankScale, ReviewVisibilityPrivate, similarity,
+		ReviewVisibilityPrivate, fuzzyMatch,
+	).Scan(&ranked).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search courses: %v", err)
+	}
+	if len(ranked) == 0 {
+		return []CourseSearchResult{}, nil
+	}
+
+	ids := make([]uint, len(ranked))
+	for i, course := range ranked {
+		ids[i] = course.ID
+	}
+	documents, err := s.loadDocuments(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]CourseSearchResult, 0, len(ranked))
+	for _, course := range ranked {
+		document, ok := documents[course.ID]
+		if !ok {
+			continue // Deleted since it was ranked
+		}
+		results = append(results, newCourseSearchResult(document, course.Rank, terms))
+	}
+	return results, nil
+}
+
+// hasTrigrams reports whether the pg_trgm extension, which
+// CreatePerformanceIndexes tries to create, is installed
+func (s *CourseSearchService) hasTrigrams() bool {
+	if cached, ok := trigramChecks.Load(s.db); ok {
+		return cached.(bool)
+	}
+
+	var extensions int64
+	err := s.db.Raw("SELECT COUNT(*) FROM pg_extension WHERE extname = 'pg_trgm'").Scan(&extensions).Error
+	available := err == nil && extensions > 0
+	if err == nil {
+		trigramChecks.Store(s.db, available)
+		if !available {
+			log.Printf("[SEARCH] pg_trgm is not installed, course search won't match misspellings")
+		}
+	}
+	return available
+}
+
+// matchInMemory scores every course against the query terms. Each term
+// scores its best match across the weighted fields: a whole word counts in
+// full, a word it starts a bit less and a close misspelling of a name or
+// address word half.
+func (s *CourseSearchService) matchInMemory(terms []string) ([]CourseSearchResult, error) {
+	documents, err := s.loadDocuments(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []CourseSearchResult
+	for _, document := range documents {
+		fields := map[string][]string{
+			"name":        searchWords(document.course.Name),
+			"city":        searchWords(document.city),
+			"address":     searchWords(document.course.Address),
+			"description": searchWords(document.description),
+			"review":      searchWords(strings.Join(append([]string{document.review}, document.reviews...), " ")),
+		}
+
+		rank := 0.0
+		for _, term := range terms {
+			best := 0.0
+			for field, words := range fields {
+				fuzzy := field == "name" || field == "city" || field == "address"
+				for _, word := range words {
+					if score := termMatch(term, word, fuzzy) * searchFieldWeights[field]; score > best {
+						best = score
+					}
+				}
+			}
+			if best == 0 {
+				rank = 0
+				break
+			}
+			rank += best
+		}
+		if rank > 0 {
+			results = append(results, newCourseSearchResult(document, rank/float64(len(terms)), terms))
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].CourseID < results[j].CourseID
+	})
+	if results == nil {
+		results = []CourseSearchResult{}
+	}
+	return results, nil
+}
+
+// loadDocuments loads the searchable text of the courses with ids, or of
+// every course when ids is nil
+func (s *CourseSearchService) loadDocuments(ids []uint) (map[uint]*searchDocument, error) {
+	query := s.db.Select("id", "name", "address", "course_data", "latitude", "longitude")
+	if ids != nil {
+		query = query.Where("id IN ?", ids)
+	}
+	var courses []CourseDB
+	if err := query.Find(&courses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get courses: %v", err)
+	}
+
+	documents := make(map[uint]*searchDocument, len(courses))
+	for _, course := range courses {
+		document := &searchDocument{course: course, city: courseCity(course.Address)}
+		var data Course
+		if course.CourseData != "" && json.Unmarshal([]byte(course.CourseData), &data) == nil {
+			document.description = data.Description
+			document.review = data.Review
+		}
+		documents[course.ID] = document
+	}
+
+	var reviews []CourseReview
+	reviewQuery := s.db.Select("course_id", "review_text").
+		Where("visibility <> ? AND review_text IS NOT NULL AND review_text <> ''", ReviewVisibilityPrivate)
+	if ids != nil {
+		reviewQuery = reviewQuery.Where("course_id IN ?", ids)
+	}
+	if err := reviewQuery.Order("created_at DESC, id DESC").Find(&reviews).Error; err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %v", err)
+	}
+	for _, review := range reviews {
+		if document, ok := documents[review.CourseID]; ok {
+			document.reviews = append(document.reviews, *review.ReviewText)
+		}
+	}
+	return documents, nil
+}
+
+// Suggest returns typeahead completions for prefix: courses whose name,
+// cities and users whose display name have a word starting with it. Only
+// display names are searched, never real names or email addresses.
+func (s *CourseSearchService) Suggest(prefix string, limit int) (*SearchSuggestions, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+	if limit <= 0 || limit > maxSuggestionLimit {
+		limit = maxSuggestionLimit
+	}
+
+	suggestions := &SearchSuggestions{
+		Courses:   []CourseSuggestion{},
+		Locations: []string{},
+		Users:     []UserSuggestion{},
+	}
+	prefix = strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+	if prefix == "" {
+		return suggestions, nil
+	}
+	starts := escapeLike(prefix) + "%"
+	wordStarts := "% " + escapeLike(prefix) + "%"
+
+	var courses []CourseDB
+	if err := s.db.Select("id", "name").
+		Where(`LOWER(name) LIKE ? ESCAPE '\' OR LOWER(name) LIKE ? ESCAPE '\'`, starts, wordStarts).
+		Order("name, id").Limit(suggestionCandidates).Find(&courses).Error; err != nil {
+		return nil, fmt.Errorf("failed to suggest courses: %v", err)
+	}
+	sortByPrefix(courses, prefix, func(course CourseDB) string { return course.Name })
+	for _, course := range courses {
+		if len(suggestions.Courses) == limit {
+			break
+		}
+		suggestions.Courses = append(suggestions.Courses, CourseSuggestion{ID: course.ID, Name: course.Name})
+	}
+
+	var addresses []string
+	if err := s.db.Model(&CourseDB{}).
+		Where(`LOWER(address) LIKE ? ESCAPE '\'`, "%"+escapeLike(prefix)+"%").
+		Limit(locationCandidates).Pluck("address", &addresses).Error; err != nil {
+		return nil, fmt.Errorf("failed to suggest locations: %v", err)
+	}
+	seen := make(map[string]bool)
+	var cities []string
+	for _, address := range addresses {
+		city := courseCity(address)
+		key := strings.ToLower(city)
+		if city == "" || seen[key] || !hasWordPrefix(key, prefix) {
+			continue
+		}
+		seen[key] = true
+		cities = append(cities, city)
+	}
+	sort.Strings(cities)
+	sortByPrefix(cities, prefix, func(city string) string { return city })
+	if len(cities) > limit {
+		cities = cities[:limit]
+	}
+	suggestions.Locations = append(suggestions.Locations, cities...)
+
+	var users []User
+	if err := s.db.Select("id", "display_name").
+		Where(`display_name IS NOT NULL AND (LOWER(display_name) LIKE ? ESCAPE '\' OR LOWER(display_name) LIKE ? ESCAPE '\')`, starts, wordStarts).
+		Order("display_name, id").Limit(suggestionCandidates).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to suggest users: %v", err)
+	}
+	sortByPrefix(users, prefix, func(user User) string { return *user.DisplayName })
+	for _, user := range users {
+		if len(suggestions.Users) == limit {
+			break
+		}
+		suggestions.Users = append(suggestions.Users, UserSuggestion{ID: user.ID, Name: *user.DisplayName})
+	}
+
+	return suggestions, nil
+}
+
+func newCourseSearchResult(document *searchDocument, rank float64, terms []string) CourseSearchResult {
+	result := CourseSearchResult{
+		CourseID:  document.course.ID,
+		Name:      document.course.Name,
+		Address:   document.course.Address,
+		City:      document.city,
+		Latitude:  document.course.Latitude,
+		Longitude: document.course.Longitude,
+		Rank:      rank,
+	}
+	for _, text := range append([]string{document.description, document.review}, document.reviews...) {
+		if snippet, ok := highlightSnippet(text, terms, searchSnippetLength); ok {
+			result.Snippet = snippet
+			break
+		}
+	}
+	return result
+}
+
+// searchTerms splits a query into lowercase words, without stopwords or repeats
+func searchTerms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range searchWords(query) {
+		if searchStopwords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// searchWords splits text into lowercase words of letters and digits
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// termMatch scores how well a query term matches a word: 1 for the same
+// word, 0.8 when the word starts with the term (so "bunker" finds
+// "bunkers") and, if fuzzy, 0.5 for a close misspelling
+func termMatch(term, word string, fuzzy bool) float64 {
+	switch {
+	case term == word:
+		return 1
+	case utf8.RuneCountInString(term) >= 3 && strings.HasPrefix(word, term):
+		return 0.8
+	case fuzzy && withinEditDistance(term, word, allowedTypos(term)):
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// allowedTypos is how many edits a term may be from a word and still match
+func allowedTypos(term string) int {
+	switch length := utf8.RuneCountInString(term); {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// withinEditDistance reports whether a and b are at most max insertions,
+// deletions or substitutions apart
+func withinEditDistance(a, b string, max int) bool {
+	if max == 0 {
+		return a == b
+	}
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > max || -diff > max {
+		return false
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		rowMin := current[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(min(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+			rowMin = min(rowMin, current[j])
+		}
+		if rowMin > max {
+			return false
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)] <= max
+}
+
+// highlightSnippet cuts a passage of about length bytes around the first
+// word of text matching a term. The passage is HTML-escaped with matching
+// words wrapped in <mark>. It reports false when no word matches.
+func highlightSnippet(text string, terms []string, length int) (string, bool) {
+	type span struct {
+		start, end int
+		match      bool
+	}
+
+	var words []span
+	first := -1
+	start := -1
+	for i, r := range text + " " {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			word := strings.ToLower(text[start:i])
+			match := false
+			for _, term := range terms {
+				if termMatch(term, word, true) > 0 {
+					match = true
+					break
+				}
+			}
+			if match && first < 0 {
+				first = len(words)
+			}
+			words = append(words, span{start, i, match})
+			start = -1
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+
+	// Open the passage a few words before the first match
+	from := first
+	for from > 0 && words[first].start-words[from-1].start < length/3 {
+		from--
+	}
+	to := first
+	for to+1 < len(words) && words[to+1].end-words[from].start <= length {
+		to++
+	}
+
+	var snippet strings.Builder
+	if from > 0 {
+		snippet.WriteString("...")
+	}
+	position := words[from].start
+	for _, word := range words[from : to+1] {
+		snippet.WriteString(html.EscapeString(text[position:word.start]))
+		if word.match {
+			snippet.WriteString("<mark>" + html.EscapeString(text[word.start:word.end]) + "</mark>")
+		} else {
+			snippet.WriteString(html.EscapeString(text[word.start:word.end]))
+		}
+		position = word.end
+	}
+	if to+1 < len(words) {
+		snippet.WriteString("...")
+	} else {
+		snippet.WriteString(html.EscapeString(text[position:]))
+	}
+	return strings.TrimSpace(snippet.String()), true
+}
+
+// courseCity reads the city from an address like "1 Fairway Dr, Pinehurst,
+// NC 28374, USA": the part before the state, or the last part when there
+// is no state
+func courseCity(address string) string {
+	var parts []string
+	for _, part := range strings.Split(address, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) > 0 {
+		switch strings.ToLower(parts[len(parts)-1]) {
+		case "usa", "us", "united states", "united states of america":
+			parts = parts[:len(parts)-1]
+		}
+	}
+	if len(parts) < 2 {
+		return ""
+	}
+	if last := parts[len(parts)-1]; isStateAndZip(last) {
+		if len(parts) < 3 {
+			return ""
+		}
+		return parts[len(parts)-2]
+	}
+	return parts[len(parts)-1]
+}
+
+// isStateAndZip reports whether part looks like "NC", "NC 28374" or "28374"
+func isStateAndZip(part string) bool {
+	fields := strings.Fields(part)
+	if len(fields) == 0 || len(fields) > 2 {
+		return false
+	}
+	for _, field := range fields {
+		isState := len(field) == 2 && strings.ToUpper(field) == field && unicode.IsLetter(rune(field[0])) && unicode.IsLetter(rune(field[1]))
+		isZip := len(field) >= 5 && strings.IndexFunc(field, func(r rune) bool { return !unicode.IsDigit(r) && r != '-' }) < 0
+		if !isState && !isZip {
+			return false
+		}
+	}
+	return true
+}
+
+// hasWordPrefix reports whether a word of text starts with prefix
+func hasWordPrefix(text, prefix string) bool {
+	return strings.HasPrefix(text, prefix) || strings.Contains(text, " "+prefix)
+}
+
+// sortByPrefix moves items whose text starts with prefix ahead of those
+// where only a later word does, keeping the order otherwise
+func sortByPrefix[T any](items []T, prefix string, text func(T) string) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return strings.HasPrefix(strings.ToLower(text(items[i])), prefix) &&
+			!strings.HasPrefix(strings.ToLower(text(items[j])), prefix)
+	})
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
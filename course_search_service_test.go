@@ -0,0 +1,147 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func seedSearchCourses(t *testing.T, db *gorm.DB, golfer, owner User) (dunes, ridge CourseDB) {
+	t.Helper()
+
+	dunes = CourseDB{
+		Name:       "Sandy Dunes Links",
+		Address:    "40 Ocean Blvd, Myrtle Beach, SC 29577, USA",
+		Hash:       "dunes",
+		CourseData: `{"description": "Windswept links with deep pot bunkers & <firm> fairways", "review": "Bring extra balls."}`,
+	}
+	ridge = CourseDB{
+		Name:       "Pine Ridge",
+		Address:    "9 Ridge Rd, Pinehurst, NC 28374",
+		Hash:       "ridge",
+		CourseData: `{"description": "Tree-lined parkland course", "review": ""}`,
+	}
+	require.NoError(t, db.Create(&dunes).Error)
+	require.NoError(t, db.Create(&ridge).Error)
+
+	rating := "B"
+	public := "The bunkers on the back nine are brutal"
+	private := "Secret shortcut over the lighthouse"
+	require.NoError(t, db.Create(&CourseReview{CourseID: ridge.ID, UserID: golfer.ID, OverallRating: &rating, ReviewText: &public}).Error)
+	require.NoError(t, db.Create(&CourseReview{CourseID: ridge.ID, UserID: owner.ID, OverallRating: &rating, ReviewText: &private, Visibility: ReviewVisibilityPrivate}).Error)
+	return dunes, ridge
+}
+
+func searchResultNames(results []CourseSearchResult) []string {
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.Name)
+	}
+	return names
+}
+
+func TestCourseSearchService_Search(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	dunes, _ := seedSearchCourses(t, db, golfer, owner)
+	service := NewCourseSearchService()
+
+	t.Run("Ranks name matches above description and review matches", func(t *testing.T) {
+		results, err := service.MatchCourses("bunkers")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Sandy Dunes Links", "Pine Ridge"}, searchResultNames(results))
+		assert.Greater(t, results[0].Rank, results[1].Rank)
+
+		results, err = service.MatchCourses("links")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Sandy Dunes Links"}, searchResultNames(results))
+	})
+
+	t.Run("Highlights the matching passage", func(t *testing.T) {
+		results, err := service.MatchCourses("pot bunker")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, dunes.ID, results[0].CourseID)
+		assert.Equal(t, "Windswept links with deep <mark>pot</mark> <mark>bunkers</mark> &amp; &lt;firm&gt; fairways", results[0].Snippet)
+
+		results, err = service.MatchCourses("brutal")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Contains(t, results[0].Snippet, "<mark>brutal</mark>", "non-private reviews are searched")
+	})
+
+	t.Run("Tolerates typos and searches the city", func(t *testing.T) {
+		results, err := service.MatchCourses("Sandi Dunez")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Sandy Dunes Links"}, searchResultNames(results))
+
+		results, err = service.MatchCourses("pinehurst")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "Pinehurst", results[0].City)
+		assert.Empty(t, results[0].Snippet, "address matches have no passage to show")
+	})
+
+	t.Run("Requires every word and skips private reviews", func(t *testing.T) {
+		for _, query := range []string{"lighthouse", "dunes pinehurst", "   ", "the"} {
+			results, err := service.MatchCourses(query)
+			require.NoError(t, err)
+			assert.Empty(t, results, query)
+		}
+	})
+
+	t.Run("Pages results", func(t *testing.T) {
+		results, total, err := service.Search("bunkers", 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, []string{"Pine Ridge"}, searchResultNames(results))
+	})
+}
+
+func TestCourseSearchService_Suggest(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	seedSearchCourses(t, db, golfer, owner)
+	service := NewCourseSearchService()
+
+	suggestions, err := service.Suggest("pi", 5)
+	require.NoError(t, err)
+	require.Len(t, suggestions.Courses, 1)
+	assert.Equal(t, "Pine Ridge", suggestions.Courses[0].Name)
+	assert.Equal(t, []string{"Pinehurst"}, suggestions.Locations)
+	assert.Empty(t, suggestions.Users)
+
+	suggestions, err = service.Suggest("BEACH", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Myrtle Beach"}, suggestions.Locations, "later words of a city match too")
+
+	suggestions, err = service.Suggest("own", 5)
+	require.NoError(t, err)
+	require.Len(t, suggestions.Users, 1)
+	assert.Equal(t, "Course Owner", suggestions.Users[0].Name)
+	assert.Equal(t, owner.ID, suggestions.Users[0].ID)
+
+	for _, prefix := range []string{"golfer", "owner@", "%", "_"} {
+		suggestions, err = service.Suggest(prefix, 5)
+		require.NoError(t, err)
+		assert.Empty(t, suggestions.Users, "only display names are suggested: %q", prefix)
+		assert.Empty(t, suggestions.Courses, prefix)
+	}
+}
+
+func TestCourseCity(t *testing.T) {
+	for address, city := range map[string]string{
+		"40 Ocean Blvd, Myrtle Beach, SC 29577, USA": "Myrtle Beach",
+		"9 Ridge Rd, Pinehurst, NC 28374":            "Pinehurst",
+		"9 Ridge Rd, Pinehurst, NC":                  "Pinehurst",
+		"1 Links Rd, St Andrews":                     "St Andrews",
+		"1 Fairway Dr":                               "",
+		"1 Fairway Dr, NC 28374":                     "",
+	} {
+		assert.Equal(t, city, courseCity(address), address)
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxTileZoom is the deepest zoom level course tiles are served for
+	MaxTileZoom = 22
+	// courseTileLayer is the layer map styles read courses from, the same one
+	// the tippecanoe pipeline in scripts/generate_vector_tiles.go wrote
+	courseTileLayer = "golf"
+	// courseTileExtent is a tile's width in tile coordinates
+	courseTileExtent = 4096
+	// courseTileBuffer keeps courses just past a tile's edge in it, so
+	// markers straddling two tiles are drawn whole in both
+	courseTileBuffer = 64
+	// courseTileRecheckInterval is how often cached tiles are compared with
+	// the database, which catches courses changed by other processes
+	courseTileRecheckInterval = time.Minute
+	// maxCachedCourseTiles bounds the tile cache, which starts over when full
+	maxCachedCourseTiles = 10000
+	// webMercatorHalfWidth is half the width of the world in EPSG:3857 meters
+	webMercatorHalfWidth = 20037508.342789244
+)
+
+var ErrCourseTileNotFound = errors.New("course tile not found")
+
+// courseTilePostGISSQL encodes a tile with ST_AsMVT. It takes the tile's z,
+// x and y, then the buffer in meters for the index lookup. The id is selected
+// twice because ST_AsMVT moves the feature ID column out of the properties.
+const courseTilePostGISSQL = `WITH bounds AS (SELECT ST_TileEnvelope(?, ?, ?) AS tile)
+SELECT ST_AsMVT(features, '` + courseTileLayer + `', 4096, 'geom', 'feature_id') FROM (
+	SELECT c.id AS feature_id, c.id, c.name, c.address,
+		COALESCE(NULLIF(c.course_data->>'overallRating', ''), '-') AS rating,
+		ST_AsMVTGeom(ST_Transform(c.geog::geometry, 3857), bounds.tile, 4096, 64, true) AS geom
+	FROM course_dbs c, bounds
+	WHERE c.geog && ST_Transform(ST_Expand(bounds.tile, ?), 4326)::geography
+	ORDER BY c.id
+) AS features WHERE geom IS NOT NULL`
+
+// courseTileCaches holds, per connection, the encoded tiles
+var courseTileCaches sync.Map
+
+// CourseTileService encodes located courses as Mapbox Vector Tiles, one
+// point per course with its ID, name, address and overall rating. On Postgres
+// with PostGIS the database encodes tiles with ST_AsMVT; elsewhere, or when
+// that fails, they are encoded in Go. Encoded tiles are cached until a course
+// changes.
+type CourseTileService struct {
+	db *gorm.DB
+}
+
+func NewCourseTileService() *CourseTileService {
+	return &CourseTileService{
+		db: GetDB(),
+	}
+}
+
+// CourseTile is an encoded tile. ETag changes only when the tile's contents do.
+type CourseTile struct {
+	Data []byte
+	ETag string
+}
+
+type courseTileKey struct {
+	z, x, y int
+}
+
+type courseTileCache struct {
+	mu        sync.Mutex
+	tiles     map[courseTileKey]*CourseTile
+	signature courseLocationSignature
+	checkedAt time.Time
+	// generation counts resets, so a tile encoded before one isn't cached after it
+	generation uint64
+	// postgisFailed turns the ST_AsMVT path off after it errors once
+	postgisFailed atomic.Bool
+}
+
+// courseTileRow is a course as the Go encoder reads it
+type courseTileRow struct {
+	ID         uint
+	Name       string
+	Address    string
+	CourseData string
+	Latitude   float64
+	Longitude  float64
+}
+
+// Tile returns the tile at z/x/y, encoding it when it isn't cached
+func (s *CourseTileService) Tile(z, x, y int) (*CourseTile, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+	if z < 0 || z > MaxTileZoom || x < 0 || y < 0 || x >= 1<<z || y >= 1<<z {
+		return nil, ErrCourseTileNotFound
+	}
+
+	value, _ := courseTileCaches.LoadOrStore(s.db, &courseTileCache{})
+	cache := value.(*courseTileCache)
+	key := courseTileKey{z: z, x: x, y: y}
+
+	generation, tile, err := cache.lookup(s.db, key)
+	if err != nil || tile != nil {
+		return tile, err
+	}
+
+	data, err := s.encode(cache, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	tile = &CourseTile{Data: data, ETag: `"` + hex.EncodeToString(sum[:8]) + `"`}
+	cache.store(generation, key, tile)
+	return tile, nil
+}
+
+func (s *CourseTileService) encode(cache *courseTileCache, z, x, y int) ([]byte, error) {
+	geo := &CourseGeoService{db: s.db}
+	if !cache.postgisFailed.Load() && geo.hasGeography() {
+		data, err := s.encodePostGIS(z, x, y)
+		if err == nil {
+			return data, nil
+		}
+		cache.postgisFailed.Store(true)
+		log.Printf("[TILES] ST_AsMVT failed, encoding tiles in Go instead: %v", err)
+	}
+	return s.encodeGo(z, x, y)
+}
+
+func (s *CourseTileService) encodePostGIS(z, x, y int) ([]byte, error) {
+	bufferMeters := 2 * webMercatorHalfWidth / float64(int(1)<<z) * courseTileBuffer / courseTileExtent
+
+	var data []byte
+	if err := s.db.Raw(courseTilePostGISSQL, z, x, y, bufferMeters).Row().Scan(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *CourseTileService) encodeGo(z, x, y int) ([]byte, error) {
+	scale := float64(int(1) << z)
+	margin := float64(courseTileBuffer) / courseTileExtent
+	north := tileLatitude((float64(y) - margin) / scale)
+	south := tileLatitude((float64(y+1) + margin) / scale)
+	west := (float64(x)-margin)/scale*360 - 180
+	east := (float64(x+1)+margin)/scale*360 - 180
+
+	var rows []courseTileRow
+	err := s.db.Model(&CourseDB{}).
+		Select("id, name, address, course_data, latitude, longitude").
+		Where("latitude IS NOT NULL AND longitude IS NOT NULL").
+		Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", south, north, west, east).
+		Order("id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load courses for tile: %v", err)
+	}
+
+	layer := newVectorTileLayer(courseTileLayer, courseTileExtent)
+	for _, row := range rows {
+		mx, my := mercator(row.Latitude, row.Longitude)
+		px := int(math.Round((mx*scale - float64(x)) * courseTileExtent))
+		py := int(math.Round((my*scale - float64(y)) * courseTileExtent))
+		if px < -courseTileBuffer || px > courseTileExtent+courseTileBuffer ||
+			py < -courseTileBuffer || py > courseTileExtent+courseTileBuffer {
+			continue
+		}
+		layer.addPoint(uint64(row.ID), px, py, []vectorTileProperty{
+			{Key: "id", Value: row.ID},
+			{Key: "name", Value: row.Name},
+			{Key: "address", Value: row.Address},
+			{Key: "rating", Value: courseTileRating(row.CourseData)},
+		})
+	}
+	return encodeVectorTile(layer), nil
+}
+
+// courseTileRating is a course's overall rating, or "-" when it has none
+func courseTileRating(courseData string) string {
+	var course struct {
+		OverallRating string `json:"overallRating"`
+	}
+	if err := json.Unmarshal([]byte(courseData), &course); err != nil || course.OverallRating == "" {
+		return "-"
+	}
+	return course.OverallRating
+}
+
+// tileLatitude is the latitude at a Web Mercator y, 0 at the top of the map
+// and 1 at the bottom
+func tileLatitude(y float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y))) * 180 / math.Pi
+}
+
+// lookup returns a cached tile, or nil with the generation to store it under.
+// The cache starts over when the located courses have changed.
+func (c *courseTileCache) lookup(db *gorm.DB, key courseTileKey) (uint64, *CourseTile, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tiles == nil || time.Since(c.checkedAt) >= courseTileRecheckInterval {
+		signature, err := loadCourseLocationSignature(db)
+		if err != nil {
+			return 0, nil, err
+		}
+		if c.tiles == nil || signature != c.signature {
+			c.tiles = make(map[courseTileKey]*CourseTile)
+			c.signature = signature
+			c.generation++
+		}
+		c.checkedAt = time.Now()
+	}
+	return c.generation, c.tiles[key], nil
+}
+
+func (c *courseTileCache) store(generation uint64, key courseTileKey, tile *CourseTile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation || c.tiles == nil {
+		return
+	}
+	if len(c.tiles) >= maxCachedCourseTiles {
+		c.tiles = make(map[courseTileKey]*CourseTile)
+	}
+	c.tiles[key] = tile
+}
+
+// invalidateCourseTiles drops the cached tiles so the next requests encode
+// them again. Call it when a course is added, changed, geocoded or removed.
+func invalidateCourseTiles(db *gorm.DB) {
+	if value, ok := courseTileCaches.Load(db); ok {
+		cache := value.(*courseTileCache)
+		cache.mu.Lock()
+		cache.tiles = nil
+		cache.generation++
+		cache.mu.Unlock()
+	}
+}
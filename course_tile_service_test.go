@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodedTileFeature is a point feature read back from an encoded tile
+type decodedTileFeature struct {
+	ID         uint64
+	X, Y       int
+	Properties map[string]interface{}
+}
+
+// decodeTestTile reads the point features of a layer. It understands just
+// enough protobuf to check what encodeVectorTile writes.
+func decodeTestTile(t *testing.T, data []byte, layerName string) (features []decodedTileFeature, extent uint64) {
+	t.Helper()
+
+	for _, layerField := range decodeTestProto(t, data) {
+		require.Equal(t, 3, layerField.number, "tiles hold only layers")
+
+		var name string
+		var keys []string
+		var values []interface{}
+		var rawFeatures [][]byte
+		for _, field := range decodeTestProto(t, layerField.bytes) {
+			switch field.number {
+			case 15:
+				assert.Equal(t, uint64(2), field.varint, "version 2 of the spec")
+			case 1:
+				name = string(field.bytes)
+			case 2:
+				rawFeatures = append(rawFeatures, field.bytes)
+			case 3:
+				keys = append(keys, string(field.bytes))
+			case 4:
+				value := decodeTestProto(t, field.bytes)[0]
+				switch value.number {
+				case 1:
+					values = append(values, string(value.bytes))
+				case 3:
+					values = append(values, math.Float64frombits(value.varint))
+				case 5:
+					values = append(values, value.varint)
+				case 6:
+					values = append(values, int64(value.varint>>1)^-int64(value.varint&1))
+				case 7:
+					values = append(values, value.varint == 1)
+				}
+			case 5:
+				extent = field.varint
+			}
+		}
+		if name != layerName {
+			continue
+		}
+
+		for _, raw := range rawFeatures {
+			feature := decodedTileFeature{Properties: make(map[string]interface{})}
+			for _, field := range decodeTestProto(t, raw) {
+				switch field.number {
+				case 1:
+					feature.ID = field.varint
+				case 2:
+					tags := decodeTestPacked(t, field.bytes)
+					for i := 0; i+1 < len(tags); i += 2 {
+						feature.Properties[keys[tags[i]]] = values[tags[i+1]]
+					}
+				case 3:
+					assert.Equal(t, uint64(1), field.varint, "a point")
+				case 4:
+					geometry := decodeTestPacked(t, field.bytes)
+					require.Len(t, geometry, 3)
+					assert.Equal(t, uint64(9), geometry[0], "a single MoveTo")
+					feature.X = int(int64(geometry[1]>>1) ^ -int64(geometry[1]&1))
+					feature.Y = int(int64(geometry[2]>>1) ^ -int64(geometry[2]&1))
+				}
+			}
+			features = append(features, feature)
+		}
+	}
+	return features, extent
+}
+
+type testProtoField struct {
+	number int
+	varint uint64
+	bytes  []byte
+}
+
+func decodeTestProto(t *testing.T, data []byte) []testProtoField {
+	t.Helper()
+
+	var fields []testProtoField
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		require.Greater(t, n, 0)
+		data = data[n:]
+		field := testProtoField{number: int(tag >> 3)}
+		switch tag & 7 {
+		case protoVarint:
+			field.varint, n = binary.Uvarint(data)
+			require.Greater(t, n, 0)
+			data = data[n:]
+		case protoFixed64:
+			field.varint = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case protoLengthDelimited:
+			length, n := binary.Uvarint(data)
+			require.Greater(t, n, 0)
+			field.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func decodeTestPacked(t *testing.T, data []byte) []uint64 {
+	t.Helper()
+
+	var values []uint64
+	for len(data) > 0 {
+		value, n := binary.Uvarint(data)
+		require.Greater(t, n, 0)
+		values = append(values, value)
+		data = data[n:]
+	}
+	return values
+}
+
+func TestEncodeVectorTile(t *testing.T) {
+	layer := newVectorTileLayer("golf", 4096)
+	layer.addPoint(7, 10, -3, []vectorTileProperty{
+		{Key: "name", Value: "Pinehurst No. 2"},
+		{Key: "rating", Value: "A"},
+		{Key: "holes", Value: 18},
+		{Key: "par", Value: uint(72)},
+		{Key: "slope", Value: 135.5},
+		{Key: "public", Value: true},
+	})
+	layer.addPoint(8, 4000, 4100, []vectorTileProperty{{Key: "rating", Value: "A"}})
+
+	features, extent := decodeTestTile(t, encodeVectorTile(layer), "golf")
+	assert.Equal(t, uint64(4096), extent)
+	require.Len(t, features, 2)
+	assert.Equal(t, decodedTileFeature{ID: 7, X: 10, Y: -3, Properties: map[string]interface{}{
+		"name": "Pinehurst No. 2", "rating": "A", "holes": int64(18), "par": uint64(72), "slope": 135.5, "public": true,
+	}}, features[0])
+	assert.Equal(t, decodedTileFeature{ID: 8, X: 4000, Y: 4100, Properties: map[string]interface{}{"rating": "A"}}, features[1])
+	assert.Len(t, layer.values, 6, "shared values are stored once")
+
+	assert.Empty(t, encodeVectorTile(newVectorTileLayer("golf", 4096)), "empty layers are left out")
+}
+
+func TestCourseTileService_Tile(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedGeoCourses(t, db)
+	require.NoError(t, db.Model(&CourseDB{}).Where("id = ?", ids["Pinehurst No. 2"]).
+		Update("course_data", `{"overallRating":"S"}`).Error)
+	service := NewCourseTileService()
+
+	// Zoom 5 tile 8/12 covers the Sandhills
+	tile, err := service.Tile(5, 8, 12)
+	require.NoError(t, err)
+	features, _ := decodeTestTile(t, tile.Data, courseTileLayer)
+	require.Len(t, features, 3)
+	assert.Equal(t, uint64(ids["Pinehurst No. 2"]), features[0].ID)
+	assert.Equal(t, map[string]interface{}{
+		"id": uint64(ids["Pinehurst No. 2"]), "name": "Pinehurst No. 2", "address": "Pinehurst No. 2", "rating": "S",
+	}, features[0].Properties)
+	assert.Equal(t, "-", features[1].Properties["rating"], "courses without a rating")
+
+	x, y := mercator(35.1907, -79.4704)
+	assert.Equal(t, int(math.Round((x*32-8)*courseTileExtent)), features[0].X)
+	assert.Equal(t, int(math.Round((y*32-12)*courseTileExtent)), features[0].Y)
+
+	world, err := service.Tile(0, 0, 0)
+	require.NoError(t, err)
+	features, _ = decodeTestTile(t, world.Data, courseTileLayer)
+	assert.Len(t, features, 6, "every located course")
+
+	empty, err := service.Tile(5, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, empty.Data)
+	assert.NotEmpty(t, empty.ETag)
+
+	for _, coordinates := range [][3]int{{-1, 0, 0}, {MaxTileZoom + 1, 0, 0}, {2, 4, 0}, {2, 0, -1}} {
+		_, err := service.Tile(coordinates[0], coordinates[1], coordinates[2])
+		assert.ErrorIs(t, err, ErrCourseTileNotFound, coordinates)
+	}
+}
+
+func TestCourseTileService_Invalidation(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedGeoCourses(t, db)
+	service := NewCourseTileService()
+
+	before, err := service.Tile(5, 8, 12)
+	require.NoError(t, err)
+	again, err := service.Tile(5, 8, 12)
+	require.NoError(t, err)
+	assert.Same(t, before, again, "served from the cache")
+
+	pebbleBefore, err := service.Tile(5, 5, 12)
+	require.NoError(t, err)
+
+	require.NoError(t, NewDatabaseService().DeleteCourse(ids["Tobacco Road"]))
+	after, err := service.Tile(5, 8, 12)
+	require.NoError(t, err)
+	assert.NotEqual(t, before.ETag, after.ETag, "deleting a located course changes its tile")
+	features, _ := decodeTestTile(t, after.Data, courseTileLayer)
+	assert.Len(t, features, 2)
+
+	pebbleAfter, err := service.Tile(5, 5, 12)
+	require.NoError(t, err)
+	assert.NotSame(t, pebbleBefore, pebbleAfter, "every tile is encoded again")
+	assert.Equal(t, pebbleBefore.ETag, pebbleAfter.ETag, "but tiles that didn't change keep their ETag")
+}
+
+func TestCourseTileHandler(t *testing.T) {
+	db := setupTestDatabase(t)
+	seedGeoCourses(t, db)
+
+	e := echo.New()
+	e.GET("/tiles/:z/:x/:file", NewHandlers().CourseTile)
+	serve := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/tiles/5/8/12.mvt", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, courseTileContentType, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, courseTileCacheControl, rec.Header().Get(echo.HeaderCacheControl))
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	features, _ := decodeTestTile(t, rec.Body.Bytes(), courseTileLayer)
+	assert.Len(t, features, 3)
+
+	rec = serve("/tiles/5/8/12.mvt", `"stale", `+etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.Bytes())
+	assert.Equal(t, etag, rec.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusNotFound, serve("/tiles/5/8/12.png", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("/tiles/5/32/12.mvt", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("/tiles/five/8/12.mvt", "").Code)
+}
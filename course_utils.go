@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
@@ -66,6 +67,12 @@ func CreateCourseWithHash(name, address, courseData string, createdBy *uint) (*C
 	}
 
 	log.Printf("✅ Created course: %s (hash: %s)", name, course.Hash)
+
+	var parsed Course
+	if err := json.Unmarshal([]byte(courseData), &parsed); err == nil {
+		syncCourseFacets(DB, course.ID, parsed)
+	}
+	geocodeCourseAsync(DB, course.ID, course.Address)
 	return &course, nil
 }
 
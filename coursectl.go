@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const defaultCourseSchema = "courses/schema.json"
+
+// runCoursectl validates, imports and exports course files in the
+// courses/schema.json format
+func runCoursectl(args []string, stdout io.Writer) error {
+	usage := "usage: coursectl validate|import|export [flags]"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "validate":
+		return runCoursectlValidate(args[1:], stdout)
+	case "import":
+		return runCoursectlImport(args[1:], stdout)
+	case "export":
+		return runCoursectlExport(args[1:], stdout)
+	}
+	return fmt.Errorf("unknown coursectl command %q; %s", args[0], usage)
+}
+
+// courseFileFlags are the flags the commands that read files share
+type courseFileFlags struct {
+	schema string
+	format string
+}
+
+func (f *courseFileFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.schema, "schema", defaultCourseSchema, "JSON Schema every course is validated against")
+	flags.StringVar(&f.format, "format", "", "json, ndjson or csv; by default taken from each file's extension")
+}
+
+// read loads the schema and every file's records, writing parse errors and
+// schema violations to w. It fails only when the schema can't be used.
+func (f *courseFileFlags) read(files []string, w io.Writer) ([]courseRecord, int, error) {
+	schema, err := loadJSONSchema(f.schema)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var records []courseRecord
+	unreadable := 0
+	for _, file := range files {
+		fileRecords, fileErr := readCourseRecords(file, f.format, schema)
+		if fileErr != nil {
+			fmt.Fprintln(w, fileErr.String())
+			unreadable++
+			continue
+		}
+		for _, record := range fileRecords {
+			for _, violation := range record.Violations {
+				fmt.Fprintln(w, violation.String())
+			}
+		}
+		records = append(records, fileRecords...)
+	}
+	return records, unreadable, nil
+}
+
+func runCoursectlValidate(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("coursectl validate", flag.ContinueOnError)
+	var files courseFileFlags
+	files.register(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: coursectl validate [-schema path] [-format json|ndjson|csv] <file>...\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("expected at least one file")
+	}
+
+	records, unreadable, err := files.read(flags.Args(), stdout)
+	if err != nil {
+		return err
+	}
+	invalid := 0
+	for _, record := range records {
+		if len(record.Violations) > 0 {
+			invalid++
+		}
+	}
+
+	fmt.Fprintf(stdout, "%d records in %d files: %d valid, %d invalid", len(records), flags.NArg()-unreadable, len(records)-invalid, invalid)
+	if unreadable > 0 {
+		fmt.Fprintf(stdout, ", %d files unreadable", unreadable)
+	}
+	fmt.Fprintln(stdout)
+	if invalid > 0 || unreadable > 0 {
+		return ErrInvalidCourseRecords
+	}
+	return nil
+}
+
+func runCoursectlImport(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("coursectl import", flag.ContinueOnError)
+	var files courseFileFlags
+	files.register(flags)
+	dryRun := flags.Bool("dry-run", false, "report what would change without writing")
+	transaction := flags.Bool("transaction", false, "write every course or none; any invalid record stops the import")
+	createdBy := flags.Uint("user", 0, "user ID to record as the creator of new courses and the updater of others")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: coursectl import [-dry-run] [-transaction] [-user ID] [-schema path] [-format json|ndjson|csv] <file>...\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("expected at least one file")
+	}
+
+	records, unreadable, err := files.read(flags.Args(), stdout)
+	if err != nil {
+		return err
+	}
+	if unreadable > 0 && *transaction {
+		return fmt.Errorf("%d files couldn't be read; nothing was written", unreadable)
+	}
+
+	if err := InitDatabase(); err != nil {
+		return err
+	}
+	options := CourseImportOptions{DryRun: *dryRun, Transaction: *transaction}
+	if *createdBy != 0 {
+		id := *createdBy
+		if err := GetDB().First(&User{}, id).Error; err != nil {
+			return fmt.Errorf("user %d not found", id)
+		}
+		options.CreatedBy = &id
+	}
+
+	report, err := NewCourseBulkService().Import(records, options)
+	if report != nil {
+		writeCourseImportReport(stdout, report)
+	}
+	switch {
+	case errors.Is(err, ErrInvalidCourseRecords):
+		return fmt.Errorf("%d records are invalid; nothing was written", report.Invalid)
+	case err != nil && report != nil && options.Transaction:
+		return fmt.Errorf("%v; nothing was written", err)
+	case err != nil:
+		return err
+	case report.Invalid > 0 || report.Failed > 0 || unreadable > 0:
+		return ErrInvalidCourseRecords
+	}
+	return nil
+}
+
+// writeCourseImportReport prints the records an import creates, updates or
+// couldn't write, then the totals
+func writeCourseImportReport(w io.Writer, report *CourseImportReport) {
+	for _, result := range report.Results {
+		switch result.Action {
+		case courseImportCreate, courseImportUpdate:
+			fmt.Fprintf(w, "%-9s %s:%d %s", result.Action, result.Source, result.Line, result.Name)
+			if result.CourseID != 0 {
+				fmt.Fprintf(w, " (course %d)", result.CourseID)
+			}
+			fmt.Fprintln(w)
+		case courseImportInvalid, courseImportFailed:
+			// Schema violations were printed as the files were read
+			for _, problem := range result.Errors {
+				if problem.Pointer == "" {
+					fmt.Fprintln(w, problem.String())
+				}
+			}
+		}
+	}
+
+	mode := ""
+	if report.DryRun {
+		mode = " (dry run, nothing was written)"
+	}
+	fmt.Fprintf(w, "\n%d created, %d updated, %d unchanged, %d invalid, %d failed%s\n",
+		report.Created, report.Updated, report.Unchanged, report.Invalid, report.Failed, mode)
+	if report.Unlocated > 0 {
+		fmt.Fprintf(w, "%d new courses have no latitude and longitude; run scripts/geocode_courses.sh to map them\n", report.Unlocated)
+	}
+}
+
+func runCoursectlExport(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("coursectl export", flag.ContinueOnError)
+	format := flags.String("format", courseFormatJSON, "json, ndjson or csv")
+	output := flags.String("o", "", "file to write; standard output by default")
+	ids := flags.String("ids", "", "comma-separated course IDs to export; all courses by default")
+	schemaPath := flags.String("schema", defaultCourseSchema, "JSON Schema exported courses are checked against")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: coursectl export [-format json|ndjson|csv] [-o file] [-ids 1,2,3] [-schema path]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if _, err := courseFileFormat("", *format); err != nil {
+		return err
+	}
+	courseIDs, err := parseCourseIDs(*ids)
+	if err != nil {
+		return err
+	}
+	schema, err := loadJSONSchema(*schemaPath)
+	if err != nil {
+		return err
+	}
+
+	if err := InitDatabase(); err != nil {
+		return err
+	}
+	courses, err := NewCourseBulkService().Export(courseIDs)
+	if err != nil {
+		return err
+	}
+
+	// Courses saved before the schema was enforced may not meet it; they're
+	// exported as they are so nothing is lost, with a warning to fix them
+	for _, course := range courses {
+		for _, violation := range schema.validate(course) {
+			fmt.Fprintf(os.Stderr, "warning: course %s: %s: %s\n", course.field("id").Text, violation.Pointer, violation.Message)
+		}
+	}
+
+	w := stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %v", *output, err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := writeCourseRecords(w, *format, courses); err != nil {
+		return fmt.Errorf("failed to write courses: %v", err)
+	}
+	if *output != "" {
+		fmt.Fprintf(stdout, "Exported %d courses to %s\n", len(courses), *output)
+	}
+	return nil
+}
+
+// parseCourseIDs reads a comma-separated list of course IDs
+func parseCourseIDs(list string) ([]uint, error) {
+	var ids []uint
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("invalid course ID %q", part)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
@@ -11,6 +11,8 @@ import (
 	"strings"
 	"time"
 
+	"course_management/services"
+
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -27,6 +29,7 @@ type User struct {
 	DisplayName *string  `json:"display_name"` // Custom display name
 	Picture     string   `json:"picture"`
 	Handicap    *float64 `json:"handicap,omitempty"`
+	IsAdmin     bool     `gorm:"not null;default:false" json:"is_admin"` // Granted with `./main admins grant`
 	CreatedAt   int64    `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   int64    `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -174,6 +177,30 @@ func AutoMigrate() error {
 		&UserCourseScore{},
 		&UserCourseHole{},
 		&UserActivity{},
+		&UserFollow{},
+		&ReviewComment{},
+		&ReviewAttributeVote{},
+		&CourseAttribute{},
+		&ConditionsReport{},
+		&CourseReviewInsight{},
+		&ReviewHelpfulVote{},
+		&Notification{},
+		&NotificationPreference{},
+		&ProfilePrivacy{},
+		&CourseList{},
+		&ListedCourse{},
+		&Outing{},
+		&OutingInvite{},
+		&OutingCalendarFeed{},
+		&CourseRanking{},
+		&CourseHole{},
+		&CourseHoleGeometry{},
+		&RoundShot{},
+		&Itinerary{},
+		&CourseMerge{},
+		&CourseRedirect{},
+		&CourseEditProposal{},
+		&services.GeocodeCacheDB{},
 	)
 
 	if err != nil {
@@ -35,6 +35,24 @@ func CreatePerformanceIndexes() error {
 		"CREATE INDEX IF NOT EXISTS idx_user_activities_user_id ON user_activities(user_id)",
 		"CREATE INDEX IF NOT EXISTS idx_user_activities_user_created ON user_activities(user_id, created_at DESC)",
 		"CREATE INDEX IF NOT EXISTS idx_user_activities_type ON user_activities(activity_type)",
+		"CREATE INDEX IF NOT EXISTS idx_user_activities_feed ON user_activities(user_id, created_at DESC, id DESC)", // Feed pages are keyed on (created_at, id)
+
+		// Review comment indexes
+		"CREATE INDEX IF NOT EXISTS idx_review_comments_review_parent ON review_comments(review_id, parent_id, created_at)",
+		"CREATE INDEX IF NOT EXISTS idx_review_comments_user_id ON review_comments(user_id)",
+
+		// Review tag and amenity indexes
+		"CREATE INDEX IF NOT EXISTS idx_review_attribute_votes_course ON review_attribute_votes(course_id, kind, slug)",
+		"CREATE INDEX IF NOT EXISTS idx_course_attributes_slug ON course_attributes(kind, slug, course_id)",
+
+		// Conditions report indexes
+		"CREATE INDEX IF NOT EXISTS idx_conditions_reports_course_active ON conditions_reports(course_id, expires_at, created_at DESC)",
+
+		// Full-text and typo-tolerant course search (see CourseSearchService)
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_course_dbs_search ON course_dbs USING GIN(" + courseSearchVectorSQL + ")",
+		"CREATE INDEX IF NOT EXISTS idx_course_dbs_name_trgm ON course_dbs USING GIN(name gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_course_reviews_search ON course_reviews USING GIN(" + reviewSearchVectorSQL + ")",
 
 		// Composite indexes for common queries
 		"CREATE INDEX IF NOT EXISTS idx_course_ownership ON course_dbs(created_by, created_at DESC)",
@@ -43,6 +61,12 @@ func CreatePerformanceIndexes() error {
 		// Geospatial indexes for location-based queries
 		"CREATE INDEX IF NOT EXISTS idx_course_dbs_location ON course_dbs(latitude, longitude)",
 
+		// PostGIS radius and nearest-course search (see CourseGeoService). These fail
+		// where PostGIS isn't installed, and the service falls back to plain maths.
+		"CREATE EXTENSION IF NOT EXISTS postgis",
+		"ALTER TABLE course_dbs ADD COLUMN IF NOT EXISTS geog geography(Point, 4326) GENERATED ALWAYS AS (" + courseGeographySQL + ") STORED",
+		"CREATE INDEX IF NOT EXISTS idx_course_dbs_geog ON course_dbs USING GIST(geog)",
+
 		// JSONB indexes for course data queries (if needed)
 		"CREATE INDEX IF NOT EXISTS idx_course_data_gin ON course_dbs USING GIN(course_data)",
 	}
@@ -175,6 +175,20 @@ func (ds *DatabaseService) SaveCourseToDatabase(course Course, createdBy *uint)
 	}
 
 	log.Printf("✅ Course '%s' saved to database with ID: %d", course.Name, courseDB.ID)
+	syncCourseFacets(ds.db, courseDB.ID, course)
+	geocodeCourseAsync(ds.db, courseDB.ID, courseDB.Address)
+
+	if createdBy != nil {
+		recordActivity(ds.db, &UserActivity{
+			UserID:       *createdBy,
+			ActivityType: "course_added",
+			CourseID:     &courseDB.ID,
+		}, nil)
+	}
+
+	if course.Review != "" {
+		refreshCourseReviewInsight(ds.db, courseDB.ID)
+	}
 	return nil
 }
 
@@ -247,6 +261,7 @@ func (ds *DatabaseService) UpdateCourseInDatabase(course Course) error {
 		return fmt.Errorf("failed to marshal course data: %v", err)
 	}
 
+	moved := courseDB.Address != course.Address
 	courseDB.CourseData = string(courseDataJSON)
 	courseDB.Address = course.Address
 
@@ -256,6 +271,15 @@ func (ds *DatabaseService) UpdateCourseInDatabase(course Course) error {
 	}
 
 	log.Printf("✅ Course '%s' updated in database", course.Name)
+
+	syncCourseFacets(ds.db, courseDB.ID, course)
+	if courseDB.Latitude != nil && courseDB.Longitude != nil {
+		invalidateCourseTiles(ds.db)
+	}
+	if moved {
+		geocodeCourseAsync(ds.db, courseDB.ID, courseDB.Address)
+	}
+	refreshCourseReviewInsight(ds.db, courseDB.ID)
 	return nil
 }
 
@@ -491,6 +515,7 @@ func (ds *DatabaseService) UpdateCourseWithOwnership(courseDB *CourseDB, updated
 	}
 
 	// Update the course data and set updatedBy
+	moved := courseDB.Address != updatedCourse.Address
 	courseDB.CourseData = string(courseDataJSON)
 	courseDB.Address = updatedCourse.Address
 	courseDB.Name = updatedCourse.Name
@@ -502,9 +527,25 @@ func (ds *DatabaseService) UpdateCourseWithOwnership(courseDB *CourseDB, updated
 	}
 
 	log.Printf("✅ Course '%s' updated in database by user ID %d", updatedCourse.Name, updatedBy)
+
+	courseSaved(ds.db, courseDB, updatedCourse, moved, updatedBy)
 	return nil
 }
 
+// courseSaved brings everything derived from a course's details up to date
+// after an edit is saved, and tells everyone watching the course
+func courseSaved(db *gorm.DB, courseDB *CourseDB, course Course, moved bool, updatedBy uint) {
+	syncCourseFacets(db, courseDB.ID, course)
+	if courseDB.Latitude != nil && courseDB.Longitude != nil {
+		invalidateCourseTiles(db)
+	}
+	if moved {
+		geocodeCourseAsync(db, courseDB.ID, courseDB.Address)
+	}
+	refreshCourseReviewInsight(db, courseDB.ID)
+	publishLiveEvent(db, LiveEvent{Type: "course_updated", UserID: updatedBy, CourseID: &courseDB.ID})
+}
+
 // User course listing and ownership methods
 func (ds *DatabaseService) GetCoursesByUser(userID uint) ([]CourseDB, error) {
 	if ds.db == nil {
@@ -668,5 +709,16 @@ func (ds *DatabaseService) DeleteCourse(courseID uint) error {
 	}
 
 	log.Printf("✅ Course '%s' (ID: %d) deleted from database", courseDB.Name, courseID)
+
+	if err := (&CourseFacetService{db: ds.db}).RemoveCourse(courseID); err != nil {
+		log.Printf("[FACETS] %v", err)
+	}
+	if err := (&CourseHoleGeometryService{db: ds.db}).RemoveCourse(courseID); err != nil {
+		log.Printf("[HOLES] %v", err)
+	}
+	if courseDB.Latitude != nil && courseDB.Longitude != nil {
+		invalidateCourseClusters(ds.db)
+		invalidateCourseTiles(ds.db)
+	}
 	return nil
 }
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// eventBusRedisChannel is the pub/sub channel instances share events on
+	eventBusRedisChannel = "course_management:events"
+	// eventSubscriberBuffer is how many events a slow client may fall behind
+	// before new events are dropped for it
+	eventSubscriberBuffer = 32
+)
+
+// LiveEvent is something that just happened, pushed to connected clients.
+// Events recorded as a UserActivity carry its ID; others, like course_updated,
+// only exist on the bus.
+type LiveEvent struct {
+	Type         string `json:"type"`
+	ActivityID   uint   `json:"activity_id,omitempty"`
+	UserID       uint   `json:"user_id"`
+	CourseID     *uint  `json:"course_id,omitempty"`
+	TargetUserID *uint  `json:"target_user_id,omitempty"`
+	Summary      string `json:"summary,omitempty"`
+	Private      bool   `json:"private,omitempty"` // Only delivered to UserID
+	CreatedAt    int64  `json:"created_at"`
+}
+
+// EventBus fans live events out to subscribers in this process and, when
+// Redis is configured, to the subscribers of every other instance. Listeners
+// let services in this process react to the events it publishes.
+type EventBus struct {
+	mu           sync.RWMutex
+	subscribers  map[*EventSubscription]struct{}
+	listeners    map[int]func(LiveEvent)
+	nextListener int
+	redis        *redis.Client
+	origin       string // Identifies this instance's messages on the Redis channel
+}
+
+// EventSubscription receives the events one connected user is allowed to
+// see: their own, those of the users they follow, and follows of them
+type EventSubscription struct {
+	UserID uint
+	Events <-chan LiveEvent // Closed by Close
+
+	events    chan LiveEvent
+	bus       *EventBus
+	mu        sync.Mutex
+	following map[uint]bool
+}
+
+// eventEnvelope is an event as sent over Redis
+type eventEnvelope struct {
+	Origin string    `json:"origin"`
+	Event  LiveEvent `json:"event"`
+}
+
+var eventBus = NewEventBus()
+
+func NewEventBus() *EventBus {
+	origin := make([]byte, 8)
+	if _, err := rand.Read(origin); err != nil {
+		log.Printf("⚠️ Failed to generate event bus ID: %v", err)
+	}
+	return &EventBus{
+		subscribers: make(map[*EventSubscription]struct{}),
+		listeners:   make(map[int]func(LiveEvent)),
+		origin:      hex.EncodeToString(origin),
+	}
+}
+
+// GetEventBus returns the process-wide event bus
+func GetEventBus() *EventBus {
+	return eventBus
+}
+
+// UseRedis shares events with other instances through Redis pub/sub until
+// ctx is cancelled. It returns once the subscription is confirmed.
+func (b *EventBus) UseRedis(ctx context.Context, client *redis.Client) error {
+	pubsub := client.Subscribe(ctx, eventBusRedisChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to event channel: %v", err)
+	}
+
+	b.mu.Lock()
+	b.redis = client
+	b.mu.Unlock()
+
+	go func() {
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				b.receive(message.Payload)
+			}
+		}
+	}()
+
+	log.Printf("✅ Event bus sharing events over Redis")
+	return nil
+}
+
+// Subscribe registers a listener for userID, who follows the given users
+func (b *EventBus) Subscribe(userID uint, following []uint) *EventSubscription {
+	events := make(chan LiveEvent, eventSubscriberBuffer)
+	sub := &EventSubscription{
+		UserID:    userID,
+		Events:    events,
+		events:    events,
+		bus:       b,
+		following: make(map[uint]bool, len(following)),
+	}
+	for _, id := range following {
+		sub.following[id] = true
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Close stops the subscription and closes its Events channel
+func (s *EventSubscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subscribers[s]; ok {
+		delete(s.bus.subscribers, s)
+		close(s.events)
+	}
+}
+
+// Listen calls handle with every event this instance publishes, until the
+// returned stop function is called. Events arriving from other instances are
+// not passed on, so each event is handled once however many instances run.
+func (b *EventBus) Listen(handle func(LiveEvent)) (stop func()) {
+	b.mu.Lock()
+	id := b.nextListener
+	b.nextListener++
+	b.listeners[id] = handle
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		delete(b.listeners, id)
+		b.mu.Unlock()
+	}
+}
+
+// Publish delivers an event to local subscribers and listeners, and to other instances
+func (b *EventBus) Publish(event LiveEvent) {
+	b.deliver(event)
+
+	b.mu.RLock()
+	client := b.redis
+	listeners := make([]func(LiveEvent), 0, len(b.listeners))
+	for _, handle := range b.listeners {
+		listeners = append(listeners, handle)
+	}
+	b.mu.RUnlock()
+
+	// Called without the lock held, since listeners may publish events themselves
+	for _, handle := range listeners {
+		handle(event)
+	}
+
+	if client == nil {
+		return
+	}
+
+	payload, err := json.Marshal(eventEnvelope{Origin: b.origin, Event: event})
+	if err != nil {
+		log.Printf("⚠️ Failed to encode live event: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Publish(ctx, eventBusRedisChannel, payload).Err(); err != nil {
+		log.Printf("⚠️ Failed to publish live event to Redis: %v", err)
+	}
+}
+
+// Active reports whether anyone could receive a published event, so callers
+// can skip building events nobody will see
+func (b *EventBus) Active() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.redis != nil || len(b.subscribers) > 0 || len(b.listeners) > 0
+}
+
+// receive delivers an event published by another instance
+func (b *EventBus) receive(payload string) {
+	var envelope eventEnvelope
+	if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+		log.Printf("⚠️ Ignoring malformed live event: %v", err)
+		return
+	}
+	if envelope.Origin == b.origin {
+		return // Already delivered locally by Publish
+	}
+	b.deliver(envelope.Event)
+}
+
+func (b *EventBus) deliver(event LiveEvent) {
+	// Holding the read lock keeps Close from closing a channel mid-send
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers {
+		sub.offer(event)
+	}
+}
+
+// offer queues the event if the subscriber may see it, without ever blocking
+// the publisher on a slow client
+func (s *EventSubscription) offer(event LiveEvent) {
+	s.mu.Lock()
+	if event.UserID == s.UserID && event.TargetUserID != nil {
+		// Keep the follow list current as the user follows and unfollows
+		switch event.Type {
+		case "follow":
+			s.following[*event.TargetUserID] = true
+		case "unfollow":
+			delete(s.following, *event.TargetUserID)
+		}
+	}
+	visible := event.UserID == s.UserID ||
+		(!event.Private && (s.following[event.UserID] || (event.TargetUserID != nil && *event.TargetUserID == s.UserID)))
+	s.mu.Unlock()
+
+	if !visible {
+		return
+	}
+	select {
+	case s.events <- event:
+	default:
+		log.Printf("⚠️ Dropping live event for user %d: client is too slow", s.UserID)
+	}
+}
+
+// publishActivity announces a freshly recorded activity on the event bus
+func publishActivity(db *gorm.DB, activity *UserActivity) {
+	event := LiveEvent{
+		Type:         activity.ActivityType,
+		ActivityID:   activity.ID,
+		UserID:       activity.UserID,
+		CourseID:     activity.CourseID,
+		TargetUserID: activity.TargetUserID,
+		CreatedAt:    activity.CreatedAt,
+	}
+	publishLiveEvent(db, event)
+}
+
+// publishLiveEvent fills in the event's summary, unless it has one, and its
+// visibility and publishes it.
+// Nothing is looked up when no one is listening.
+func publishLiveEvent(db *gorm.DB, event LiveEvent) {
+	bus := GetEventBus()
+	if !bus.Active() {
+		return
+	}
+
+	if event.CreatedAt == 0 {
+		event.CreatedAt = time.Now().Unix()
+	}
+
+	// Same rule as visibleActivities: only the reviewer hears about their
+	// anonymous or private reviews
+	if event.Type == "course_review" && event.CourseID != nil {
+		var hidden int64
+		if err := db.Model(&CourseReview{}).
+			Where("user_id = ? AND course_id = ? AND visibility <> ?", event.UserID, *event.CourseID, ReviewVisibilityPublic).
+			Count(&hidden).Error; err != nil {
+			log.Printf("⚠️ Failed to check review visibility for live event: %v", err)
+			hidden = 1
+		}
+		event.Private = event.Private || hidden > 0
+	}
+
+	// Subscribers other than the user hear about them as followers, so
+	// leave out what the user's privacy settings hide from followers
+	if !event.Private {
+		privacy, err := (&ProfileService{db: db}).GetPrivacy(event.UserID)
+		if err != nil {
+			log.Printf("⚠️ Failed to check profile privacy for live event: %v", err)
+			event.Private = true
+		} else {
+			event.Private = privacy.HiddenFromFollowers(event.Type)
+		}
+	}
+
+	if event.Summary == "" {
+		items, err := (&FollowService{db: db}).describeActivities([]UserActivity{{
+			UserID:       event.UserID,
+			ActivityType: event.Type,
+			CourseID:     event.CourseID,
+			TargetUserID: event.TargetUserID,
+		}})
+		if err != nil {
+			log.Printf("⚠️ Failed to describe live event: %v", err)
+		} else if len(items) == 1 {
+			event.Summary = items[0].Summary()
+		}
+	}
+
+	bus.Publish(event)
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"course_management/api"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// drainEvents returns the events already queued for a subscription
+func drainEvents(sub *EventSubscription) []LiveEvent {
+	var events []LiveEvent
+	for {
+		select {
+		case event := <-sub.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventBus_DeliversOnlyVisibleEvents(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(1, []uint{2})
+	defer sub.Close()
+
+	me, stranger := uint(1), uint(3)
+	bus.Publish(LiveEvent{Type: "score_posted", ActivityID: 10, UserID: 1})
+	bus.Publish(LiveEvent{Type: "course_review", ActivityID: 11, UserID: 1, Private: true})
+	bus.Publish(LiveEvent{Type: "course_review", ActivityID: 12, UserID: 2})
+	bus.Publish(LiveEvent{Type: "course_review", ActivityID: 13, UserID: 2, Private: true})
+	bus.Publish(LiveEvent{Type: "follow", ActivityID: 14, UserID: 4, TargetUserID: &me})
+	bus.Publish(LiveEvent{Type: "follow", ActivityID: 15, UserID: 4, TargetUserID: &stranger})
+	bus.Publish(LiveEvent{Type: "score_posted", ActivityID: 16, UserID: 4})
+
+	var ids []uint
+	for _, event := range drainEvents(sub) {
+		ids = append(ids, event.ActivityID)
+	}
+	assert.Equal(t, []uint{10, 11, 12, 14}, ids)
+}
+
+func TestEventBus_TracksFollowsOfSubscriber(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(1, nil)
+	defer sub.Close()
+
+	other := uint(5)
+	bus.Publish(LiveEvent{Type: "score_posted", ActivityID: 1, UserID: 5})
+	bus.Publish(LiveEvent{Type: "follow", ActivityID: 2, UserID: 1, TargetUserID: &other})
+	bus.Publish(LiveEvent{Type: "score_posted", ActivityID: 3, UserID: 5})
+	bus.Publish(LiveEvent{Type: "unfollow", UserID: 1, TargetUserID: &other, Private: true})
+	bus.Publish(LiveEvent{Type: "score_posted", ActivityID: 4, UserID: 5})
+
+	var types []string
+	var ids []uint
+	for _, event := range drainEvents(sub) {
+		types = append(types, event.Type)
+		ids = append(ids, event.ActivityID)
+	}
+	assert.Equal(t, []string{"follow", "score_posted", "unfollow"}, types)
+	assert.Equal(t, []uint{2, 3, 0}, ids)
+}
+
+func TestEventBus_SlowSubscriberDoesNotBlock(t *testing.T) {
+	bus := NewEventBus()
+	slow := bus.Subscribe(1, nil)
+	defer slow.Close()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < eventSubscriberBuffer+10; i++ {
+			bus.Publish(LiveEvent{Type: "score_posted", UserID: 1})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("publishing blocked on a slow subscriber")
+	}
+	assert.Len(t, drainEvents(slow), eventSubscriberBuffer)
+}
+
+func TestEventBus_ReceiveFromOtherInstances(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(1, nil)
+	defer sub.Close()
+
+	encode := func(origin string, activityID uint) string {
+		payload, err := json.Marshal(eventEnvelope{Origin: origin, Event: LiveEvent{Type: "score_posted", ActivityID: activityID, UserID: 1}})
+		require.NoError(t, err)
+		return string(payload)
+	}
+
+	bus.receive(encode(bus.origin, 1))
+	bus.receive(encode("another-instance", 2))
+	bus.receive("not json")
+
+	events := drainEvents(sub)
+	require.Len(t, events, 1, "our own messages were already delivered locally")
+	assert.Equal(t, uint(2), events[0].ActivityID)
+}
+
+func TestEventBus_Close(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(1, nil)
+	assert.True(t, bus.Active())
+
+	sub.Close()
+	sub.Close()
+	assert.False(t, bus.Active())
+
+	bus.Publish(LiveEvent{Type: "score_posted", UserID: 1})
+	_, open := <-sub.Events
+	assert.False(t, open)
+}
+
+func TestPublishActivity_RespectsReviewVisibility(t *testing.T) {
+	db := setupTestDatabase(t)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+	reviewService := NewReviewService()
+
+	sub := GetEventBus().Subscribe(reviewer.ID, []uint{golfer.ID})
+	defer sub.Close()
+	own := GetEventBus().Subscribe(golfer.ID, nil)
+	defer own.Close()
+
+	second := CourseDB{Name: "Dunes", Address: "2 Shore Rd", Hash: "dunes", CourseData: "{}"}
+	require.NoError(t, db.Create(&second).Error)
+
+	_, err := reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{OverallRating: "A"})
+	require.NoError(t, err)
+	_, err = reviewService.CreateOrUpdateReview(golfer.ID, second.ID, ReviewFormData{OverallRating: "C", Visibility: "anonymous"})
+	require.NoError(t, err)
+
+	events := drainEvents(sub)
+	require.Len(t, events, 1, "followers don't hear about anonymous reviews")
+	assert.Equal(t, "course_review", events[0].Type)
+	assert.NotZero(t, events[0].ActivityID)
+	assert.Equal(t, "Golfer reviewed Pebble Creek", events[0].Summary)
+
+	assert.Len(t, drainEvents(own), 2, "reviewers see all of their own reviews")
+}
+
+func TestPublishActivity_RespectsProfilePrivacy(t *testing.T) {
+	db := setupTestDatabase(t)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+
+	_, err := NewProfileService().UpdatePrivacy(golfer.ID, ProfilePrivacy{Activity: ProfileAudiencePrivate})
+	require.NoError(t, err)
+
+	sub := GetEventBus().Subscribe(reviewer.ID, []uint{golfer.ID})
+	defer sub.Close()
+	own := GetEventBus().Subscribe(golfer.ID, nil)
+	defer own.Close()
+
+	recordActivity(db, &UserActivity{UserID: golfer.ID, ActivityType: "score_posted", CourseID: &course.ID}, nil)
+
+	assert.Empty(t, drainEvents(sub), "followers don't hear about private activity")
+	events := drainEvents(own)
+	require.Len(t, events, 1)
+	assert.True(t, events[0].Private)
+}
+
+// newEventServer serves the event endpoints as the JWT-authenticated user
+func newEventServer(t *testing.T, userID uint) *httptest.Server {
+	t.Helper()
+
+	e := echo.New()
+	authenticate := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_claims", &api.JWTClaims{UserID: userID})
+			return next(c)
+		}
+	}
+	handlers := NewHandlers()
+	e.GET("/events", handlers.EventStream, authenticate)
+	e.GET("/events/ws", handlers.EventSocket, authenticate)
+
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEventStream_ServerSentEvents(t *testing.T) {
+	db := setupTestDatabase(t)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	server := newEventServer(t, golfer.ID)
+
+	resp, err := http.Get(server.URL + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readLine := func() string {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		return strings.TrimSuffix(line, "\n")
+	}
+	assert.Equal(t, fmt.Sprintf("retry: %d", eventStreamRetryMillis), readLine())
+	assert.Equal(t, "", readLine())
+
+	// The first line has been flushed, so the subscription is in place
+	GetEventBus().Publish(LiveEvent{Type: "score_posted", ActivityID: 7, UserID: golfer.ID, Summary: "Golfer posted a score at Pebble Creek"})
+
+	assert.Equal(t, "id: 7", readLine())
+	data, found := strings.CutPrefix(readLine(), "data: ")
+	require.True(t, found)
+	var event LiveEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &event))
+	assert.Equal(t, "Golfer posted a score at Pebble Creek", event.Summary)
+}
+
+func TestEventSocket_SendsEvents(t *testing.T) {
+	db := setupTestDatabase(t)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	server := newEventServer(t, golfer.ID)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/events/ws"
+	ws, err := websocket.Dial(url, "", "http://mobile.example")
+	require.NoError(t, err)
+	defer ws.Close()
+
+	// The subscription exists before the handshake completes
+	GetEventBus().Publish(LiveEvent{Type: "score_posted", ActivityID: 8, UserID: golfer.ID})
+
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var event LiveEvent
+	require.NoError(t, websocket.JSON.Receive(ws, &event))
+	assert.Equal(t, uint(8), event.ActivityID)
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"course_management/api"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultFeedPageSize = 20
+	maxFeedPageSize     = 50
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrCannotFollowSelf = errors.New("you can't follow yourself")
+)
+
+// feedActions describes each activity and live event type, followed by the course or user it was about
+var feedActions = map[string]string{
+	"course_review":     "reviewed",
+	"score_posted":      "posted a score at",
+	"conditions_report": "reported conditions at",
+	"review_comment":    "commented on a review of",
+	"follow":            "started following",
+	"course_added":      "added",
+	"course_updated":    "updated",
+	"unfollow":          "stopped following",
+	"review_helpful":    "upvoted a review by",
+}
+
+// feedFilterLabels are the names of the activity types in the feed filter
+var feedFilterLabels = map[string]string{
+	"course_review":     "Reviews",
+	"score_posted":      "Scores",
+	"conditions_report": "Conditions",
+	"review_comment":    "Comments",
+	"follow":            "Follows",
+	"course_added":      "New courses",
+}
+
+// FollowService manages who follows whom and builds the activity feed of the
+// users someone follows
+type FollowService struct {
+	db *gorm.DB
+}
+
+// FeedPage is one page of a user's activity feed
+type FeedPage struct {
+	Items []FeedItem
+	Next  *api.FeedCursor // Nil on the last page
+}
+
+// FeedFilterOption is an activity type choice in the feed filter
+type FeedFilterOption struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+func NewFollowService() *FollowService {
+	return &FollowService{
+		db: GetDB(),
+	}
+}
+
+// Follow makes followerID follow followeeID. Following someone twice is a no-op.
+func (s *FollowService) Follow(followerID, followeeID uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	if followerID == followeeID {
+		return ErrCannotFollowSelf
+	}
+
+	var count int64
+	if err := s.db.Model(&User{}).Where("id = ?", followeeID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to find user: %v", err)
+	}
+	if count == 0 {
+		return ErrUserNotFound
+	}
+
+	follow := UserFollow{FollowerID: followerID, FolloweeID: followeeID}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow)
+	if result.Error != nil {
+		return fmt.Errorf("failed to follow user: %v", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		recordActivity(s.db, &UserActivity{
+			UserID:       followerID,
+			ActivityType: "follow",
+			TargetUserID: &followeeID,
+		}, nil)
+	}
+	return nil
+}
+
+// Unfollow stops followerID following followeeID, along with the activity
+// that announced the follow
+func (s *FollowService) Unfollow(followerID, followeeID uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	removed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).Delete(&UserFollow{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to unfollow user: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		removed = true
+
+		if err := tx.Where("user_id = ? AND activity_type = ? AND target_user_id = ?", followerID, "follow", followeeID).
+			Delete(&UserActivity{}).Error; err != nil {
+			return fmt.Errorf("failed to remove follow activity: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if removed {
+		// Lets the follower's open event streams stop passing on the user's events
+		publishLiveEvent(s.db, LiveEvent{Type: "unfollow", UserID: followerID, TargetUserID: &followeeID, Private: true})
+	}
+	return nil
+}
+
+// FollowingSet reports which of userIDs followerID follows
+func (s *FollowService) FollowingSet(followerID uint, userIDs []uint) (map[uint]bool, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	following := make(map[uint]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return following, nil
+	}
+
+	var followeeIDs []uint
+	if err := s.db.Model(&UserFollow{}).
+		Where("follower_id = ? AND followee_id IN ?", followerID, userIDs).
+		Pluck("followee_id", &followeeIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get follows: %v", err)
+	}
+	for _, id := range followeeIDs {
+		following[id] = true
+	}
+	return following, nil
+}
+
+// FollowedUserIDs returns the IDs of every user followerID follows
+func (s *FollowService) FollowedUserIDs(followerID uint) ([]uint, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var followeeIDs []uint
+	if err := s.db.Model(&UserFollow{}).Where("follower_id = ?", followerID).Pluck("followee_id", &followeeIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get follows: %v", err)
+	}
+	return followeeIDs, nil
+}
+
+// CountFollowers returns how many users follow userID
+func (s *FollowService) CountFollowers(userID uint) (int64, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not connected")
+	}
+
+	var count int64
+	if err := s.db.Model(&UserFollow{}).Where("followee_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count followers: %v", err)
+	}
+	return count, nil
+}
+
+// CountFollowing returns how many users userID follows
+func (s *FollowService) CountFollowing(userID uint) (int64, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not connected")
+	}
+
+	var count int64
+	if err := s.db.Model(&UserFollow{}).Where("follower_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count follows: %v", err)
+	}
+	return count, nil
+}
+
+// GetFeed returns a page of activity from the users viewerID follows, newest
+// first. Pages are keyed on (created_at, id) rather than offsets, so each page
+// is an index range scan that costs the same however deep the client pages.
+func (s *FollowService) GetFeed(viewerID uint, query api.FeedQuery) (*FeedPage, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	limit := query.Limit
+	if limit < 1 || limit > maxFeedPageSize {
+		limit = defaultFeedPageSize
+	}
+	types := query.Types
+	if len(types) == 0 {
+		types = api.FeedActivityTypes
+	}
+
+	feedFilter := func(db *gorm.DB) *gorm.DB {
+		db = db.Scopes(visibleActivities(&viewerID), activitiesVisibleToFollowers).
+			Where("user_activities.activity_type IN ?", types)
+		if query.Cursor != nil {
+			db = db.Where("(user_activities.created_at < ? OR (user_activities.created_at = ? AND user_activities.id < ?))",
+				query.Cursor.CreatedAt, query.Cursor.CreatedAt, query.Cursor.ID)
+		}
+		return db
+	}
+
+	// Fetch one extra row to learn whether there is a next page
+	var activities []UserActivity
+	var err error
+	if s.db.Dialector.Name() == "postgres" {
+		// Read at most one page from each followed user's (user_id, created_at, id)
+		// index and merge those, instead of sorting everything they ever did
+		perUser := s.db.Session(&gorm.Session{NewDB: true}).
+			Model(&UserActivity{}).
+			Scopes(feedFilter).
+			Where("user_activities.user_id = user_follows.followee_id").
+			Order("user_activities.created_at DESC, user_activities.id DESC").
+			Limit(limit + 1)
+		err = s.db.Table("user_follows").
+			Select("user_activities.*").
+			Joins("CROSS JOIN LATERAL (?) AS user_activities", perUser).
+			Where("user_follows.follower_id = ?", viewerID).
+			Order("user_activities.created_at DESC, user_activities.id DESC").
+			Limit(limit + 1).
+			Find(&activities).Error
+	} else {
+		followees := s.db.Session(&gorm.Session{NewDB: true}).
+			Model(&UserFollow{}).
+			Select("followee_id").
+			Where("follower_id = ?", viewerID)
+		err = s.db.Scopes(feedFilter).
+			Where("user_activities.user_id IN (?)", followees).
+			Order("user_activities.created_at DESC, user_activities.id DESC").
+			Limit(limit + 1).
+			Find(&activities).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity feed: %v", err)
+	}
+
+	page := &FeedPage{}
+	if len(activities) > limit {
+		activities = activities[:limit]
+		last := activities[limit-1]
+		page.Next = &api.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	page.Items, err = s.describeActivities(activities)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// describeActivities resolves the user and course names a feed page mentions
+// with one query each
+func (s *FollowService) describeActivities(activities []UserActivity) ([]FeedItem, error) {
+	userIDs := make([]uint, 0, len(activities))
+	var courseIDs []uint
+	for _, activity := range activities {
+		userIDs = append(userIDs, activity.UserID)
+		if activity.TargetUserID != nil {
+			userIDs = append(userIDs, *activity.TargetUserID)
+		}
+		if activity.CourseID != nil {
+			courseIDs = append(courseIDs, *activity.CourseID)
+		}
+	}
+
+	names, err := (&ReviewCommentService{db: s.db}).authorNames(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	courseNames := make(map[uint]string, len(courseIDs))
+	if len(courseIDs) > 0 {
+		var courses []CourseDB
+		if err := s.db.Select("id", "name").Where("id IN ?", courseIDs).Find(&courses).Error; err != nil {
+			return nil, fmt.Errorf("failed to get feed courses: %v", err)
+		}
+		for _, course := range courses {
+			courseNames[course.ID] = course.Name
+		}
+	}
+
+	now := time.Now()
+	items := make([]FeedItem, 0, len(activities))
+	for _, activity := range activities {
+		item := FeedItem{
+			UserActivity: activity,
+			ActorName:    names[activity.UserID],
+			Action:       feedActions[activity.ActivityType],
+			PostedOn:     formatTimeAgo(now, activity.CreatedAt),
+		}
+		switch {
+		case activity.TargetUserID != nil:
+			item.ObjectName = names[*activity.TargetUserID]
+		case activity.CourseID != nil:
+			item.ObjectName = courseNames[*activity.CourseID]
+			if item.ObjectName == "" {
+				item.ObjectName = "a removed course"
+			}
+		}
+		if item.Action == "" {
+			log.Printf("Warning: no feed description for activity type %q", activity.ActivityType)
+			item.Action = "was active"
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// BuildFeedFilterOptions lists the feed's activity type filters with the current one selected
+func BuildFeedFilterOptions(current string) []FeedFilterOption {
+	options := make([]FeedFilterOption, 0, len(api.FeedActivityTypes))
+	for _, activityType := range api.FeedActivityTypes {
+		options = append(options, FeedFilterOption{
+			Value:    activityType,
+			Label:    feedFilterLabels[activityType],
+			Selected: activityType == current,
+		})
+	}
+	return options
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"course_management/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowService_FollowAndUnfollow(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	service := NewFollowService()
+
+	assert.ErrorIs(t, service.Follow(golfer.ID, golfer.ID), ErrCannotFollowSelf)
+	assert.ErrorIs(t, service.Follow(golfer.ID, 999), ErrUserNotFound)
+
+	// Following twice keeps a single follow and a single announcement
+	require.NoError(t, service.Follow(golfer.ID, reviewer.ID))
+	require.NoError(t, service.Follow(golfer.ID, reviewer.ID))
+
+	followers, err := service.CountFollowers(reviewer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), followers)
+
+	var announcements int64
+	db.Model(&UserActivity{}).Where("activity_type = ? AND target_user_id = ?", "follow", reviewer.ID).Count(&announcements)
+	assert.Equal(t, int64(1), announcements)
+
+	following, err := service.FollowingSet(golfer.ID, []uint{reviewer.ID, owner.ID})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]bool{reviewer.ID: true}, following)
+
+	// Unfollowing takes the announcement with it and is safe to repeat
+	require.NoError(t, service.Unfollow(golfer.ID, reviewer.ID))
+	require.NoError(t, service.Unfollow(golfer.ID, reviewer.ID))
+
+	followers, err = service.CountFollowers(reviewer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), followers)
+	db.Model(&UserActivity{}).Where("activity_type = ? AND target_user_id = ?", "follow", reviewer.ID).Count(&announcements)
+	assert.Equal(t, int64(0), announcements)
+}
+
+func TestFollowService_GetFeed(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewFollowService()
+
+	require.NoError(t, service.Follow(golfer.ID, reviewer.ID))
+	require.NoError(t, db.Where("activity_type = ?", "follow").Delete(&UserActivity{}).Error)
+
+	// Five activities share a timestamp so pages have to break ties on ID
+	for i := 0; i < 5; i++ {
+		require.NoError(t, db.Create(&UserActivity{UserID: reviewer.ID, ActivityType: "score_posted", CourseID: &course.ID, Data: "{}", CreatedAt: 1000}).Error)
+	}
+	require.NoError(t, db.Create(&UserActivity{UserID: reviewer.ID, ActivityType: "conditions_report", CourseID: &course.ID, Data: "{}", CreatedAt: 2000}).Error)
+	require.NoError(t, db.Create(&UserActivity{UserID: owner.ID, ActivityType: "score_posted", CourseID: &course.ID, Data: "{}", CreatedAt: 3000}).Error)
+
+	// Walking the pages returns every followed activity exactly once, newest first
+	var seen []uint
+	var cursor *api.FeedCursor
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10, "pagination should terminate")
+		page, err := service.GetFeed(golfer.ID, api.FeedQuery{Cursor: cursor, Limit: 2})
+		require.NoError(t, err)
+		for _, item := range page.Items {
+			assert.Equal(t, reviewer.ID, item.UserID, "only followed users appear")
+			seen = append(seen, item.ID)
+		}
+		if page.Next == nil {
+			break
+		}
+		cursor = page.Next
+	}
+	require.Len(t, seen, 6)
+	for i := 2; i < len(seen); i++ {
+		assert.Greater(t, seen[i-1], seen[i], "ties are ordered by ID")
+	}
+
+	// New activity doesn't shift the page after a cursor
+	page, err := service.GetFeed(golfer.ID, api.FeedQuery{Limit: 2})
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&UserActivity{UserID: reviewer.ID, ActivityType: "score_posted", CourseID: &course.ID, Data: "{}", CreatedAt: 4000}).Error)
+	next, err := service.GetFeed(golfer.ID, api.FeedQuery{Cursor: page.Next, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, seen[2], next.Items[0].ID)
+
+	// Filtering by type
+	page, err = service.GetFeed(golfer.ID, api.FeedQuery{Types: []string{"conditions_report"}})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Nil(t, page.Next)
+	assert.Equal(t, "Golfer reported conditions at Pebble Creek", page.Items[0].Summary())
+}
+
+func TestFollowService_GetFeed_RespectsReviewVisibility(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewFollowService()
+	reviewService := NewReviewService()
+
+	require.NoError(t, service.Follow(reviewer.ID, golfer.ID))
+	require.NoError(t, service.Follow(golfer.ID, owner.ID))
+
+	second := CourseDB{Name: "Dunes", Address: "2 Shore Rd", Hash: "dunes", CourseData: "{}"}
+	require.NoError(t, db.Create(&second).Error)
+
+	_, err := reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{OverallRating: "A"})
+	require.NoError(t, err)
+	_, err = reviewService.CreateOrUpdateReview(golfer.ID, second.ID, ReviewFormData{OverallRating: "C", Visibility: "anonymous"})
+	require.NoError(t, err)
+
+	page, err := service.GetFeed(reviewer.ID, api.FeedQuery{})
+	require.NoError(t, err)
+
+	var summaries []string
+	for _, item := range page.Items {
+		summaries = append(summaries, item.Summary())
+	}
+	assert.Contains(t, summaries, "Golfer reviewed Pebble Creek")
+	assert.Contains(t, summaries, "Golfer started following Course Owner")
+	assert.NotContains(t, summaries, "Golfer reviewed Dunes", "anonymous reviews stay unattributed")
+}
+
+func TestFollowService_GetFeed_RespectsProfilePrivacy(t *testing.T) {
+	db := setupTestDatabase(t)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+	service := NewFollowService()
+	profiles := NewProfileService()
+
+	require.NoError(t, service.Follow(reviewer.ID, golfer.ID))
+	recordActivity(db, &UserActivity{UserID: golfer.ID, ActivityType: "score_posted", CourseID: &course.ID}, nil)
+	recordActivity(db, &UserActivity{UserID: golfer.ID, ActivityType: "conditions_report", CourseID: &course.ID}, nil)
+
+	summaries := func() []string {
+		page, err := service.GetFeed(reviewer.ID, api.FeedQuery{})
+		require.NoError(t, err)
+		var summaries []string
+		for _, item := range page.Items {
+			summaries = append(summaries, item.Summary())
+		}
+		return summaries
+	}
+
+	_, err := profiles.UpdatePrivacy(golfer.ID, ProfilePrivacy{Activity: ProfileAudienceFollowers})
+	require.NoError(t, err)
+	assert.Len(t, summaries(), 2, "followers see activity shared with followers")
+
+	_, err = profiles.UpdatePrivacy(golfer.ID, ProfilePrivacy{PlayedCourses: ProfileAudiencePrivate})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Golfer reported conditions at Pebble Creek"}, summaries(), "scores would give away private played courses")
+
+	_, err = profiles.UpdatePrivacy(golfer.ID, ProfilePrivacy{Activity: ProfileAudiencePrivate})
+	require.NoError(t, err)
+	assert.Empty(t, summaries(), "following doesn't reveal private activity")
+}
+
+func TestActivityFeedTemplates(t *testing.T) {
+	templates := NewTemplates("views")
+	var out bytes.Buffer
+	err := templates.templates.ExecuteTemplate(&out, "activity-feed", ActivityFeedData{
+		Items: []FeedItem{{
+			UserActivity: UserActivity{ActivityType: "course_review"},
+			ActorName:    "Sam",
+			Action:       "reviewed",
+			ObjectName:   "Pebble Creek",
+			PostedOn:     "yesterday",
+		}},
+		Filters:    BuildFeedFilterOptions("course_review"),
+		Type:       "course_review",
+		NextCursor: "abc",
+		Following:  1,
+	})
+	require.NoError(t, err)
+
+	html := out.String()
+	assert.Contains(t, html, "<strong>Sam</strong> reviewed <strong>Pebble Creek</strong>")
+	assert.Contains(t, html, `<option value="course_review" selected>Reviews</option>`)
+	assert.Contains(t, html, `hx-get="/feed?type=course_review&cursor=abc"`)
+
+	out.Reset()
+	require.NoError(t, templates.templates.ExecuteTemplate(&out, "activity-feed", ActivityFeedData{Filters: BuildFeedFilterOptions("")}))
+	assert.Contains(t, out.String(), "You're not following anyone yet")
+
+	out.Reset()
+	require.NoError(t, templates.templates.ExecuteTemplate(&out, "course-discussion", CourseDiscussionData{
+		Reviews: []ReviewWithComments{
+			{CourseReview: CourseReview{ID: 1, UserID: 7}, AuthorName: "Sam"},
+			{CourseReview: CourseReview{ID: 2}, AuthorName: AnonymousReviewerName},
+		},
+		FollowButtons: map[uint]*FollowButton{7: {UserID: 7, Following: true}},
+		IsLoggedIn:    true,
+	}))
+	assert.Contains(t, out.String(), `hx-delete="/users/7/follow"`)
+	assert.Equal(t, 1, bytes.Count(out.Bytes(), []byte("follow-btn")))
+}
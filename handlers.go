@@ -7,9 +7,12 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
+	"course_management/api"
+
 	"github.com/labstack/echo/v4"
 )
 
@@ -398,6 +401,7 @@ func (h *Handlers) Profile(c echo.Context) error {
 		Handicap        *float64
 		DisplayName     *string
 		EditPermissions map[int]bool
+		ProfileUserID   uint // Database ID for the public profile link, 0 when unknown
 	}{
 		GoogleUser:      user,
 		Courses:         userCourses,
@@ -405,6 +409,9 @@ func (h *Handlers) Profile(c echo.Context) error {
 		DisplayName:     displayName,
 		EditPermissions: editPermissions,
 	}
+	if dbUser != nil {
+		data.ProfileUserID = dbUser.ID
+	}
 
 	if handicap != nil {
 		log.Printf("📊 Rendering profile with handicap: %.1f", *handicap)
@@ -558,17 +565,35 @@ func (h *Handlers) GetCourse(c echo.Context) error {
 		}
 	}
 
+	// Reviewer consensus on tags and amenities, and what reviewers mention in their write-ups
+	var attributes *CourseAttributeSummary
+	var insight *CourseReviewInsight
+	if dbCourse, err := dbService.GetCourseByNameAndAddress(baseCourse.Name, baseCourse.Address); err == nil && dbCourse != nil {
+		attributes, err = NewCourseAttributeService().GetCourseAttributes(dbCourse.ID)
+		if err != nil {
+			log.Printf("Warning: failed to get course attributes: %v", err)
+		}
+		insight, err = NewReviewInsightService().GetCourseInsight(dbCourse.ID)
+		if err != nil {
+			log.Printf("Warning: failed to get review insight: %v", err)
+		}
+	}
+
 	// Add context to course data
 	courseData := struct {
 		Course
 		CanEdit       bool
 		HasUserReview bool
 		IsLoggedIn    bool
+		Attributes    *CourseAttributeSummary
+		Insight       *CourseReviewInsight
 	}{
 		Course:        courseToDisplay,
 		CanEdit:       canEdit,
 		HasUserReview: hasUserReview,
 		IsLoggedIn:    userID != nil,
+		Attributes:    attributes,
+		Insight:       insight,
 	}
 
 	return c.Render(http.StatusOK, "course", courseData)
@@ -1119,6 +1144,8 @@ func (h *Handlers) Map(c echo.Context) error {
 		AllCoursesEditPermissions map[int]bool
 		AllCoursesReviewStatus    map[int]bool
 		DefaultFilter             string
+		CourseLists               []CourseListView             // The signed-in user's lists for the list layer
+		CourseListsMap            api.GeoJSONFeatureCollection // One point per list and course
 	}{
 		Courses:                   coursesToShow,
 		AllCourses:                allCourses, // Use courses with coordinates
@@ -1172,6 +1199,18 @@ func (h *Handlers) Map(c echo.Context) error {
 		}
 	}
 
+	// Courses on the user's lists, shown as a layer they can pick a list for
+	data.CourseListsMap = api.NewFeatureCollection()
+	if userID != nil {
+		lists, err := NewCourseListService().GetLists(*userID)
+		if err != nil {
+			log.Printf("Warning: failed to get course lists: %v", err)
+		} else {
+			data.CourseLists = lists
+			data.CourseListsMap = CourseListsMap(lists)
+		}
+	}
+
 	return c.Render(http.StatusOK, "map", data)
 }
 
@@ -1519,16 +1558,40 @@ func (h *Handlers) ReviewSpecificCourseForm(c echo.Context) error {
 		}
 	}
 
+	// Pre-fill the reviewer's tag and amenity answers
+	var reviewTags []string
+	var reviewAmenities map[string]bool
+	if userReview != nil {
+		attributeService := NewCourseAttributeService()
+		reviewTags, reviewAmenities, err = attributeService.GetReviewAttributes(userReview.ID)
+		if err != nil {
+			log.Printf("Warning: failed to get review attributes: %v", err)
+		}
+	}
+	tagFields, amenityFields := BuildReviewAttributeFields(reviewTags, reviewAmenities)
+
+	// Pre-select the review's current visibility
+	visibility := ReviewVisibilityPublic
+	if userReview != nil && userReview.Visibility != "" {
+		visibility = userReview.Visibility
+	}
+
 	data := struct {
-		Course     *CourseDB
-		UserReview *TemplateReview
-		UserScores []UserCourseScore
-		UserHoles  []UserCourseHole
+		Course            *CourseDB
+		UserReview        *TemplateReview
+		UserScores        []UserCourseScore
+		UserHoles         []UserCourseHole
+		TagFields         []ReviewAttributeField
+		AmenityFields     []ReviewAttributeField
+		VisibilityOptions []ReviewVisibilityOption
 	}{
-		Course:     &courseDB,
-		UserReview: templateReview,
-		UserScores: userScores,
-		UserHoles:  userHoles,
+		Course:            &courseDB,
+		UserReview:        templateReview,
+		UserScores:        userScores,
+		UserHoles:         userHoles,
+		TagFields:         tagFields,
+		AmenityFields:     amenityFields,
+		VisibilityOptions: BuildReviewVisibilityOptions(visibility),
 	}
 
 	return c.Render(http.StatusOK, "review-course", data)
@@ -1562,7 +1625,11 @@ func (h *Handlers) GetAllCoursesAPI(c echo.Context) error {
 		filter = f
 	}
 	
-	log.Printf("🚀 GetAllCoursesAPI: page=%d, limit=%d, search='%s', filter='%s'", page, limit, search, filter)
+	// Tag and amenity facets (?tags=links-style&amenities=range,carts)
+	tags := api.NormalizeAttributeFilter(c.QueryParams()["tags"])
+	amenities := api.NormalizeAttributeFilter(c.QueryParams()["amenities"])
+	
+	log.Printf("🚀 GetAllCoursesAPI: page=%d, limit=%d, search='%s', filter='%s', tags=%v, amenities=%v", page, limit, search, filter, tags, amenities)
 	
 	// Get ownership context that was added by middleware
 	editPermissions := c.Get("EditPermissions").([]bool)
@@ -1577,11 +1644,36 @@ func (h *Handlers) GetAllCoursesAPI(c echo.Context) error {
 		})
 	}
 
+	// Resolve attribute facets to the matching courses up front
+	var attributeMatches map[string]bool
+	if len(tags) > 0 || len(amenities) > 0 {
+		attributeMatches, err = NewCourseAttributeService().FindCourseKeysWithAttributes(tags, amenities)
+		if err != nil {
+			log.Printf("Warning: failed to filter courses by attributes: %v", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to filter courses",
+			})
+		}
+	}
+
+	// Rank search matches with full-text search, best first
+	var searchPositions map[string]int
+	if search != "" {
+		matches, err := NewCourseSearchService().MatchCourses(search)
+		if err != nil {
+			log.Printf("Warning: failed to search courses: %v", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to search courses",
+			})
+		}
+		searchPositions = make(map[string]int, len(matches))
+		for position, match := range matches {
+			searchPositions[courseAttributeKey(match.Name, match.Address)] = position
+		}
+	}
+
 	// Filter courses based on search and filter criteria
-	var filteredCourses []Course
-	var filteredEditPermissions []bool
-	var filteredReviewStatus []bool
-	
+	var matchingIndexes []int
 	for i, course := range allCourses {
 		// Apply filter criteria
 		matchesFilter := true
@@ -1591,25 +1683,42 @@ func (h *Handlers) GetAllCoursesAPI(c echo.Context) error {
 		
 		// Apply search criteria
 		matchesSearch := true
-		if search != "" {
-			matchesSearch = false
-			if course.Name != "" && strings.Contains(strings.ToLower(course.Name), strings.ToLower(search)) {
-				matchesSearch = true
-			}
+		if searchPositions != nil {
+			_, matchesSearch = searchPositions[courseAttributeKey(course.Name, course.Address)]
+		}
+		
+		// Apply tag and amenity facets
+		if attributeMatches != nil && !attributeMatches[courseAttributeKey(course.Name, course.Address)] {
+			matchesFilter = false
 		}
 		
 		if matchesFilter && matchesSearch {
-			filteredCourses = append(filteredCourses, course)
-			if i < len(editPermissions) {
-				filteredEditPermissions = append(filteredEditPermissions, editPermissions[i])
-			} else {
-				filteredEditPermissions = append(filteredEditPermissions, false)
-			}
-			if i < len(reviewStatus) {
-				filteredReviewStatus = append(filteredReviewStatus, reviewStatus[i])
-			} else {
-				filteredReviewStatus = append(filteredReviewStatus, false)
-			}
+			matchingIndexes = append(matchingIndexes, i)
+		}
+	}
+	if searchPositions != nil {
+		sort.SliceStable(matchingIndexes, func(a, b int) bool {
+			courseA, courseB := allCourses[matchingIndexes[a]], allCourses[matchingIndexes[b]]
+			return searchPositions[courseAttributeKey(courseA.Name, courseA.Address)] < searchPositions[courseAttributeKey(courseB.Name, courseB.Address)]
+		})
+	}
+
+	var filteredCourses []Course
+	var filteredEditPermissions []bool
+	var filteredReviewStatus []bool
+	
+	for _, i := range matchingIndexes {
+		course := allCourses[i]
+		filteredCourses = append(filteredCourses, course)
+		if i < len(editPermissions) {
+			filteredEditPermissions = append(filteredEditPermissions, editPermissions[i])
+		} else {
+			filteredEditPermissions = append(filteredEditPermissions, false)
+		}
+		if i < len(reviewStatus) {
+			filteredReviewStatus = append(filteredReviewStatus, reviewStatus[i])
+		} else {
+			filteredReviewStatus = append(filteredReviewStatus, false)
 		}
 	}
 	
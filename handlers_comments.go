@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CourseDiscussionData is the view model for the "course-discussion" template
+type CourseDiscussionData struct {
+	CourseIndex    int
+	Reviews        []ReviewWithComments
+	FollowButtons  map[uint]*FollowButton  // Keyed by reviewer, for reviewers the viewer can follow
+	HelpfulButtons map[uint]*HelpfulButton // Keyed by review
+	IsLoggedIn     bool
+	CanModerate    bool
+	Error          string
+}
+
+// CourseDiscussion renders the review comments section of a course page
+func (h *Handlers) CourseDiscussion(c echo.Context) error {
+	return h.renderCourseDiscussion(c, "")
+}
+
+// PostReviewComment adds a comment or reply to a review from the course page
+func (h *Handlers) PostReviewComment(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to comment")
+	}
+
+	reviewID, err := strconv.ParseUint(c.Param("reviewId"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid review ID")
+	}
+
+	var parentID *uint
+	if parentParam := c.FormValue("parent_id"); parentParam != "" {
+		parsed, err := strconv.ParseUint(parentParam, 10, 32)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid parent comment ID")
+		}
+		parent := uint(parsed)
+		parentID = &parent
+	}
+
+	commentService := NewReviewCommentService()
+	_, err = commentService.CreateComment(*userID, uint(reviewID), parentID, c.FormValue("body"))
+	if err != nil {
+		log.Printf("[REVIEW_COMMENT] Failed to create comment on review %d: %v", reviewID, err)
+		return h.renderCourseDiscussion(c, commentErrorMessage(err))
+	}
+
+	return h.renderCourseDiscussion(c, "")
+}
+
+// DeleteReviewComment deletes a comment from the course page
+func (h *Handlers) DeleteReviewComment(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to delete a comment")
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid comment ID")
+	}
+
+	commentService := NewReviewCommentService()
+	if err := commentService.DeleteComment(*userID, uint(commentID)); err != nil {
+		log.Printf("[REVIEW_COMMENT] Failed to delete comment %d: %v", commentID, err)
+		return h.renderCourseDiscussion(c, commentErrorMessage(err))
+	}
+
+	return h.renderCourseDiscussion(c, "")
+}
+
+// ModerateReviewComment lets the course creator approve or hide a comment
+func (h *Handlers) ModerateReviewComment(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to moderate comments")
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid comment ID")
+	}
+
+	commentService := NewReviewCommentService()
+	if _, err := commentService.SetCommentStatus(*userID, uint(commentID), c.FormValue("status")); err != nil {
+		log.Printf("[REVIEW_COMMENT] Failed to moderate comment %d: %v", commentID, err)
+		return h.renderCourseDiscussion(c, commentErrorMessage(err))
+	}
+
+	return h.renderCourseDiscussion(c, "")
+}
+
+func (h *Handlers) renderCourseDiscussion(c echo.Context, errorMessage string) error {
+	dbCourse, courseIndex, err := h.courseFromIndexParam(c)
+	if err != nil || dbCourse == nil {
+		return err
+	}
+
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+
+	commentService := NewReviewCommentService()
+	reviews, err := commentService.GetCourseDiscussion(dbCourse.ID, userID)
+	if err != nil {
+		log.Printf("[REVIEW_COMMENT] Failed to load discussion for course %d: %v", dbCourse.ID, err)
+		return c.String(http.StatusInternalServerError, "Failed to load comments")
+	}
+
+	for i := range reviews {
+		for j := range reviews[i].Comments {
+			reviews[i].Comments[j].CourseIndex = courseIndex
+			for k := range reviews[i].Comments[j].Replies {
+				reviews[i].Comments[j].Replies[k].CourseIndex = courseIndex
+			}
+		}
+	}
+
+	data := CourseDiscussionData{
+		CourseIndex: courseIndex,
+		Reviews:     reviews,
+		IsLoggedIn:  userID != nil,
+		CanModerate: userID != nil && dbCourse.CreatedBy != nil && *dbCourse.CreatedBy == *userID,
+		Error:       errorMessage,
+	}
+
+	if userID != nil {
+		data.FollowButtons = reviewerFollowButtons(reviews, *userID)
+	}
+	data.HelpfulButtons = reviewHelpfulButtons(reviews, courseIndex, userID)
+
+	return c.Render(http.StatusOK, "course-discussion", data)
+}
+
+// reviewHelpfulButtons builds the helpful vote button or count for each review
+// in a discussion. Viewers can vote on any review but their own.
+func reviewHelpfulButtons(reviews []ReviewWithComments, courseIndex int, viewerID *uint) map[uint]*HelpfulButton {
+	reviewIDs := make([]uint, 0, len(reviews))
+	for _, review := range reviews {
+		reviewIDs = append(reviewIDs, review.ID)
+	}
+
+	counts, voted, err := NewReviewService().GetHelpfulVotes(reviewIDs, viewerID)
+	if err != nil {
+		log.Printf("[REVIEW_HELPFUL] Failed to load helpful votes: %v", err)
+		return nil
+	}
+
+	buttons := make(map[uint]*HelpfulButton, len(reviews))
+	for _, review := range reviews {
+		buttons[review.ID] = &HelpfulButton{
+			CourseIndex: courseIndex,
+			ReviewID:    review.ID,
+			Count:       counts[review.ID],
+			Marked:      voted[review.ID],
+			CanVote:     viewerID != nil && review.UserID != *viewerID,
+		}
+	}
+	return buttons
+}
+
+// reviewerFollowButtons builds follow buttons for the named reviewers in a
+// discussion. Anonymous reviews have no reviewer ID, so they get none.
+func reviewerFollowButtons(reviews []ReviewWithComments, viewerID uint) map[uint]*FollowButton {
+	buttons := make(map[uint]*FollowButton)
+	var reviewerIDs []uint
+	for _, review := range reviews {
+		if review.UserID == 0 || review.UserID == viewerID {
+			continue
+		}
+		if _, seen := buttons[review.UserID]; !seen {
+			buttons[review.UserID] = &FollowButton{UserID: review.UserID}
+			reviewerIDs = append(reviewerIDs, review.UserID)
+		}
+	}
+
+	following, err := NewFollowService().FollowingSet(viewerID, reviewerIDs)
+	if err != nil {
+		log.Printf("[FOLLOW] Failed to load follows for user %d: %v", viewerID, err)
+		return nil
+	}
+	for id, button := range buttons {
+		button.Following = following[id]
+	}
+	return buttons
+}
+
+// courseFromIndexParam resolves the :id array index used by course page routes to
+// its database record. On failure it writes the error response and returns a nil course.
+func (h *Handlers) courseFromIndexParam(c echo.Context) (*CourseDB, int, error) {
+	courseIndex, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, 0, c.String(http.StatusBadRequest, "Invalid course ID")
+	}
+
+	dbService := NewDatabaseService()
+	allCourses, err := dbService.GetAllCoursesFromDatabase()
+	if err != nil {
+		return nil, 0, c.String(http.StatusInternalServerError, "Failed to load courses from database")
+	}
+	if courseIndex < 0 || courseIndex >= len(allCourses) {
+		return nil, 0, c.String(http.StatusNotFound, "Course not found")
+	}
+
+	course := allCourses[courseIndex]
+	dbCourse, err := dbService.GetCourseByNameAndAddress(course.Name, course.Address)
+	if err != nil || dbCourse == nil {
+		return nil, 0, c.String(http.StatusNotFound, "Course not found")
+	}
+
+	return dbCourse, courseIndex, nil
+}
+
+// commentErrorMessage converts comment service errors into user-facing messages
+func commentErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidCommentBody),
+		errors.Is(err, ErrCommentThreadDepth),
+		errors.Is(err, ErrCommentForbidden),
+		errors.Is(err, ErrInvalidCommentState):
+		return err.Error()
+	case errors.Is(err, ErrCommentNotFound):
+		return "That comment no longer exists"
+	case errors.Is(err, ErrReviewNotFound):
+		return "That review no longer exists"
+	default:
+		return "Something went wrong, please try again"
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CourseConditionsData is the view model for the "course-conditions" template
+type CourseConditionsData struct {
+	CourseIndex int
+	Conditions  *CurrentConditions
+	IsLoggedIn  bool
+	Error       string
+}
+
+// CourseConditions renders the current conditions section of a course page
+func (h *Handlers) CourseConditions(c echo.Context) error {
+	return h.renderCourseConditions(c, "")
+}
+
+// PostConditionsReport adds a conditions report from the course page
+func (h *Handlers) PostConditionsReport(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to report conditions")
+	}
+
+	dbCourse, _, err := h.courseFromIndexParam(c)
+	if err != nil || dbCourse == nil {
+		return err
+	}
+
+	formData := ParseConditionsReportFormData(func(key string) string {
+		return c.FormValue(key)
+	})
+
+	conditionsService := NewConditionsReportService()
+	if _, err := conditionsService.CreateReport(*userID, dbCourse.ID, formData); err != nil {
+		log.Printf("[CONDITIONS] Failed to create report for course %d: %v", dbCourse.ID, err)
+		return h.renderCourseConditions(c, conditionsErrorMessage(err))
+	}
+
+	return h.renderCourseConditions(c, "")
+}
+
+// DeleteConditionsReport removes one of the user's conditions reports
+func (h *Handlers) DeleteConditionsReport(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to remove a conditions report")
+	}
+
+	reportID, err := strconv.ParseUint(c.Param("reportId"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid report ID")
+	}
+
+	conditionsService := NewConditionsReportService()
+	if err := conditionsService.DeleteReport(*userID, uint(reportID)); err != nil {
+		log.Printf("[CONDITIONS] Failed to delete report %d: %v", reportID, err)
+		return h.renderCourseConditions(c, conditionsErrorMessage(err))
+	}
+
+	return h.renderCourseConditions(c, "")
+}
+
+func (h *Handlers) renderCourseConditions(c echo.Context, errorMessage string) error {
+	dbCourse, courseIndex, err := h.courseFromIndexParam(c)
+	if err != nil || dbCourse == nil {
+		return err
+	}
+
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+
+	conditionsService := NewConditionsReportService()
+	conditions, err := conditionsService.GetCurrentConditions(dbCourse.ID, userID)
+	if err != nil {
+		log.Printf("[CONDITIONS] Failed to load conditions for course %d: %v", dbCourse.ID, err)
+		return c.String(http.StatusInternalServerError, "Failed to load course conditions")
+	}
+
+	data := CourseConditionsData{
+		CourseIndex: courseIndex,
+		Conditions:  conditions,
+		IsLoggedIn:  userID != nil,
+		Error:       errorMessage,
+	}
+
+	return c.Render(http.StatusOK, "course-conditions", data)
+}
+
+// conditionsErrorMessage converts conditions service errors into user-facing messages
+func conditionsErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidConditionsReport),
+		errors.Is(err, ErrConditionsReportForbidden):
+		return err.Error()
+	case errors.Is(err, ErrConditionsReportNotFound):
+		return "That report no longer exists"
+	case errors.Is(err, ErrCourseNotFound):
+		return "That course no longer exists"
+	default:
+		return "Something went wrong, please try again"
+	}
+}
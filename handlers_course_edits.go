@@ -0,0 +1,277 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CourseEditsData is the view model for the "course-edits" template
+type CourseEditsData struct {
+	CourseIndex int
+	Course      Course        // Current details, to fill in the suggestion form
+	Holes       []HoleEditRow // Rows of the suggestion form's hole card
+	Proposals   []CourseEditProposalView
+	IsLoggedIn  bool
+	IsCreator   bool // The viewer created the course, so edits it directly
+	Error       string
+	Message     string
+}
+
+// HoleEditRow is one hole of the suggestion form's hole card
+type HoleEditRow struct {
+	Number  int
+	Par     string
+	Yardage string
+	Notes   string
+}
+
+// CourseEditQueueData is the view model for the "course-edit-queue" template
+type CourseEditQueueData struct {
+	Queue []CourseEditProposalView
+	Mine  []CourseEditProposalView
+	Error string
+}
+
+// CourseEdits renders the suggested edits section of a course page
+func (h *Handlers) CourseEdits(c echo.Context) error {
+	return h.renderCourseEdits(c, "", "")
+}
+
+// SuggestCourseEdit suggests the changes in the course page's form
+func (h *Handlers) SuggestCourseEdit(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to suggest an edit")
+	}
+
+	dbCourse, _, err := h.courseFromIndexParam(c)
+	if err != nil || dbCourse == nil {
+		return err
+	}
+
+	current := courseEditDetails(dbCourse)
+	changes := ParseCourseEditFormData(current, func(key string) string {
+		return c.FormValue(key)
+	})
+	if len(changes) == 0 {
+		return h.renderCourseEdits(c, "Change at least one detail to suggest an edit", "")
+	}
+
+	service := NewCourseEditService()
+	if _, err := service.Propose(*userID, dbCourse.ID, changes, c.FormValue("note")); err != nil {
+		log.Printf("[COURSE_EDITS] Failed to suggest edit to course %d: %v", dbCourse.ID, err)
+		return h.renderCourseEdits(c, courseEditErrorMessage(err), "")
+	}
+	return h.renderCourseEdits(c, "", "Thanks! Your suggestion has been sent for review.")
+}
+
+// AcceptCourseEditOnCourse accepts a suggestion from the course page
+func (h *Handlers) AcceptCourseEditOnCourse(c echo.Context) error {
+	errorMessage := h.answerCourseEdit(c, "editId", func(service *CourseEditService, userID, proposalID uint) error {
+		_, err := service.Accept(userID, proposalID, c.FormValue("note"))
+		return err
+	})
+	return h.renderCourseEdits(c, errorMessage, "")
+}
+
+// RejectCourseEditOnCourse rejects a suggestion from the course page
+func (h *Handlers) RejectCourseEditOnCourse(c echo.Context) error {
+	errorMessage := h.answerCourseEdit(c, "editId", func(service *CourseEditService, userID, proposalID uint) error {
+		_, err := service.Reject(userID, proposalID, c.FormValue("note"))
+		return err
+	})
+	return h.renderCourseEdits(c, errorMessage, "")
+}
+
+// WithdrawCourseEditOnCourse withdraws the user's own suggestion from the
+// course page
+func (h *Handlers) WithdrawCourseEditOnCourse(c echo.Context) error {
+	errorMessage := h.answerCourseEdit(c, "editId", func(service *CourseEditService, userID, proposalID uint) error {
+		return service.Withdraw(userID, proposalID)
+	})
+	return h.renderCourseEdits(c, errorMessage, "")
+}
+
+// CourseEditQueue renders the suggestions the user can answer and the ones
+// they made
+func (h *Handlers) CourseEditQueue(c echo.Context) error {
+	return h.renderCourseEditQueue(c, "")
+}
+
+// AcceptCourseEdit accepts a suggestion from the review queue
+func (h *Handlers) AcceptCourseEdit(c echo.Context) error {
+	errorMessage := h.answerCourseEdit(c, "id", func(service *CourseEditService, userID, proposalID uint) error {
+		_, err := service.Accept(userID, proposalID, c.FormValue("note"))
+		return err
+	})
+	return h.renderCourseEditQueue(c, errorMessage)
+}
+
+// RejectCourseEdit rejects a suggestion from the review queue
+func (h *Handlers) RejectCourseEdit(c echo.Context) error {
+	errorMessage := h.answerCourseEdit(c, "id", func(service *CourseEditService, userID, proposalID uint) error {
+		_, err := service.Reject(userID, proposalID, c.FormValue("note"))
+		return err
+	})
+	return h.renderCourseEditQueue(c, errorMessage)
+}
+
+// WithdrawCourseEdit withdraws one of the user's suggestions from the review
+// queue page
+func (h *Handlers) WithdrawCourseEdit(c echo.Context) error {
+	errorMessage := h.answerCourseEdit(c, "id", func(service *CourseEditService, userID, proposalID uint) error {
+		return service.Withdraw(userID, proposalID)
+	})
+	return h.renderCourseEditQueue(c, errorMessage)
+}
+
+// answerCourseEdit runs an action on the suggestion named by the param and
+// returns the message to show when it fails
+func (h *Handlers) answerCourseEdit(c echo.Context, param string, action func(service *CourseEditService, userID, proposalID uint) error) string {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return "You must be logged in to answer a suggested edit"
+	}
+
+	proposalID, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		return "Invalid suggested edit"
+	}
+	if err := action(NewCourseEditService(), *userID, uint(proposalID)); err != nil {
+		log.Printf("[COURSE_EDITS] Failed to update suggested edit %d: %v", proposalID, err)
+		return courseEditErrorMessage(err)
+	}
+	return ""
+}
+
+func (h *Handlers) renderCourseEdits(c echo.Context, errorMessage, message string) error {
+	dbCourse, courseIndex, err := h.courseFromIndexParam(c)
+	if err != nil || dbCourse == nil {
+		return err
+	}
+
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+
+	current := courseEditDetails(dbCourse)
+	data := CourseEditsData{
+		CourseIndex: courseIndex,
+		Course:      current,
+		Holes:       courseEditHoleRows(current),
+		IsLoggedIn:  userID != nil,
+		Error:       errorMessage,
+		Message:     message,
+	}
+	if userID != nil {
+		data.IsCreator = dbCourse.CreatedBy != nil && *dbCourse.CreatedBy == *userID
+		proposals, err := NewCourseEditService().ListForCourse(*userID, dbCourse.ID)
+		if err != nil {
+			log.Printf("[COURSE_EDITS] Failed to load suggested edits for course %d: %v", dbCourse.ID, err)
+			return c.String(http.StatusInternalServerError, "Failed to load suggested edits")
+		}
+		data.Proposals = proposals
+	}
+
+	return c.Render(http.StatusOK, "course-edits", data)
+}
+
+func (h *Handlers) renderCourseEditQueue(c echo.Context, errorMessage string) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to see suggested edits")
+	}
+
+	service := NewCourseEditService()
+	queue, err := service.Queue(*userID)
+	if err != nil {
+		log.Printf("[COURSE_EDITS] Failed to load review queue for user %d: %v", *userID, err)
+		return c.String(http.StatusInternalServerError, "Failed to load suggested edits")
+	}
+	mine, err := service.ListByUser(*userID)
+	if err != nil {
+		log.Printf("[COURSE_EDITS] Failed to load suggested edits by user %d: %v", *userID, err)
+		return c.String(http.StatusInternalServerError, "Failed to load suggested edits")
+	}
+
+	return c.Render(http.StatusOK, "course-edit-queue", CourseEditQueueData{
+		Queue: queue,
+		Mine:  mine,
+		Error: errorMessage,
+	})
+}
+
+// courseEditHoleRows lists the holes of a course's card for the suggestion
+// form, or 18 empty holes when the course has no card
+func courseEditHoleRows(course Course) []HoleEditRow {
+	if len(course.Holes) == 0 {
+		rows := make([]HoleEditRow, 18)
+		for i := range rows {
+			rows[i].Number = i + 1
+		}
+		return rows
+	}
+
+	rows := make([]HoleEditRow, 0, len(course.Holes))
+	for _, hole := range course.Holes {
+		rows = append(rows, HoleEditRow{
+			Number:  hole.Number,
+			Par:     courseFieldValue(course, hole.Number, "par"),
+			Yardage: courseFieldValue(course, hole.Number, "yardage"),
+			Notes:   hole.Description,
+		})
+	}
+	return rows
+}
+
+// ParseCourseEditFormData reads the suggestion form into changes, keeping
+// only the fields that differ from the course's current details. Hole fields
+// are named hole-<number>-par, hole-<number>-yardage and hole-<number>-notes.
+func ParseCourseEditFormData(current Course, getValue func(string) string) []CourseFieldChange {
+	var changes []CourseFieldChange
+	add := func(key, field string, hole int, name string) {
+		value := strings.TrimSpace(getValue(key))
+		if value != courseFieldValue(current, hole, name) {
+			changes = append(changes, CourseFieldChange{Field: field, To: value})
+		}
+	}
+
+	for _, name := range []string{"name", "address", "description"} {
+		add(name, name, 0, name)
+	}
+	for _, row := range courseEditHoleRows(current) {
+		prefix := fmt.Sprintf("hole-%d-", row.Number)
+		field := fmt.Sprintf("holes.%d.", row.Number)
+		add(prefix+"par", field+"par", row.Number, "par")
+		add(prefix+"yardage", field+"yardage", row.Number, "yardage")
+		add(prefix+"notes", field+"description", row.Number, "description")
+	}
+	return changes
+}
+
+// courseEditErrorMessage converts course edit service errors into
+// user-facing messages
+func courseEditErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidCourseEdit),
+		errors.Is(err, ErrCourseEditConflict),
+		errors.Is(err, ErrCourseEditForbidden),
+		errors.Is(err, ErrCourseEditAnswered),
+		errors.Is(err, ErrCourseEditOwnCourse):
+		return err.Error()
+	case errors.Is(err, ErrCourseEditNotFound):
+		return "That suggested edit no longer exists"
+	case errors.Is(err, ErrCourseNotFound):
+		return "That course no longer exists"
+	default:
+		return "Something went wrong, please try again"
+	}
+}
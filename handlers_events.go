@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"course_management/api"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// eventStreamHeartbeat keeps idle connections from being closed by proxies
+	eventStreamHeartbeat = 25 * time.Second
+	// eventStreamRetryMillis is how long EventSource clients wait before reconnecting
+	eventStreamRetryMillis = 5000
+	// eventSocketWriteTimeout drops WebSocket clients that stop reading
+	eventSocketWriteTimeout = 10 * time.Second
+)
+
+// EventStream streams live events to the user as Server-Sent Events. Mobile
+// clients authenticate with a JWT, browsers with their session.
+func (h *Handlers) EventStream(c echo.Context) error {
+	userID, _ := eventStreamUser(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to receive live updates")
+	}
+
+	sub, err := subscribeToEvents(*userID)
+	if err != nil {
+		log.Printf("[EVENTS] Failed to subscribe user %d: %v", *userID, err)
+		return c.String(http.StatusInternalServerError, "Failed to open event stream")
+	}
+	defer sub.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set("X-Accel-Buffering", "no")
+
+	// The stream is meant to outlive the server's write timeout
+	if err := http.NewResponseController(res).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("[EVENTS] Failed to clear write deadline: %v", err)
+	}
+
+	res.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(res, "retry: %d\n\n", eventStreamRetryMillis); err != nil {
+		return nil
+	}
+	res.Flush()
+
+	streamEvents(c.Request().Context().Done(), sub, func(event *LiveEvent) error {
+		if event == nil {
+			_, err := fmt.Fprint(res, ": ping\n\n")
+			res.Flush()
+			return err
+		}
+
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if event.ActivityID != 0 {
+			fmt.Fprintf(res, "id: %d\n", event.ActivityID)
+		}
+		_, err = fmt.Fprintf(res, "data: %s\n\n", payload)
+		res.Flush()
+		return err
+	})
+	return nil
+}
+
+// EventSocket streams the same live events as EventStream over a WebSocket,
+// one JSON message per event
+func (h *Handlers) EventSocket(c echo.Context) error {
+	userID, viaSession := eventStreamUser(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to receive live updates")
+	}
+
+	sub, err := subscribeToEvents(*userID)
+	if err != nil {
+		log.Printf("[EVENTS] Failed to subscribe user %d: %v", *userID, err)
+		return c.String(http.StatusInternalServerError, "Failed to open event stream")
+	}
+	defer sub.Close()
+
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			origin, err := websocket.Origin(config, req)
+			if err != nil {
+				return err
+			}
+			// Browsers send the session cookie with cross-site WebSocket
+			// handshakes, so a session only works from this site's own pages
+			if viaSession && (origin == nil || origin.Host != req.Host) {
+				return fmt.Errorf("event socket origin %v not allowed", origin)
+			}
+			config.Origin = origin
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			// The hijacked connection keeps the server's deadlines until cleared
+			ws.SetDeadline(time.Time{})
+
+			// Clients never send anything, so reading only notices them leaving
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var message []byte
+				for {
+					if err := websocket.Message.Receive(ws, &message); err != nil {
+						return
+					}
+				}
+			}()
+
+			streamEvents(closed, sub, func(event *LiveEvent) error {
+				ws.SetWriteDeadline(time.Now().Add(eventSocketWriteTimeout))
+				if event == nil {
+					return websocket.JSON.Send(ws, map[string]string{"type": "ping"})
+				}
+				return websocket.JSON.Send(ws, event)
+			})
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// eventStreamUser identifies the listener by the API's JWT claims when present,
+// falling back to the browser session
+func eventStreamUser(c echo.Context) (userID *uint, viaSession bool) {
+	if id, err := api.GetUserID(c); err == nil {
+		return &id, false
+	}
+	return NewSessionService().GetDatabaseUserID(c), true
+}
+
+// subscribeToEvents subscribes userID to the event bus along with the users they follow
+func subscribeToEvents(userID uint) (*EventSubscription, error) {
+	following, err := NewFollowService().FollowedUserIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	return GetEventBus().Subscribe(userID, following), nil
+}
+
+// streamEvents passes each event to send until done is closed, the
+// subscription ends or send fails. A nil event asks send for a heartbeat.
+func streamEvents(done <-chan struct{}, sub *EventSubscription, send func(event *LiveEvent) error) {
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case <-heartbeat.C:
+			err = send(nil)
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			err = send(&event)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"course_management/api"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ActivityFeedData is the view model for the "activity-feed" and "activity-feed-items" templates
+type ActivityFeedData struct {
+	Items      []FeedItem
+	Filters    []FeedFilterOption
+	Type       string // Selected activity type, empty for all
+	NextCursor string
+	Following  int64
+	Error      string
+}
+
+// FollowButton is the view model for the "follow-button" template
+type FollowButton struct {
+	UserID    uint
+	Following bool
+}
+
+// ActivityFeed renders the home page feed of activity from followed users.
+// Requests with a cursor only render the next page of items.
+func (h *Handlers) ActivityFeed(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to see your feed")
+	}
+
+	data := ActivityFeedData{Type: c.QueryParam("type")}
+	data.Filters = BuildFeedFilterOptions(data.Type)
+
+	query := api.FeedQuery{Limit: defaultFeedPageSize}
+	if data.Type != "" {
+		if !api.IsFeedActivityType(data.Type) {
+			return c.String(http.StatusBadRequest, "Unknown activity type")
+		}
+		query.Types = []string{data.Type}
+	}
+	if raw := c.QueryParam("cursor"); raw != "" {
+		cursor, err := api.DecodeFeedCursor(raw)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid cursor")
+		}
+		query.Cursor = cursor
+	}
+
+	followService := NewFollowService()
+	page, err := followService.GetFeed(*userID, query)
+	if err != nil {
+		log.Printf("[FEED] Failed to load feed for user %d: %v", *userID, err)
+		data.Error = "Failed to load your feed"
+	} else {
+		data.Items = page.Items
+		if page.Next != nil {
+			data.NextCursor = page.Next.Encode()
+		}
+	}
+
+	if query.Cursor != nil {
+		return c.Render(http.StatusOK, "activity-feed-items", data)
+	}
+
+	if data.Following, err = followService.CountFollowing(*userID); err != nil {
+		log.Printf("[FEED] Failed to count follows for user %d: %v", *userID, err)
+	}
+	return c.Render(http.StatusOK, "activity-feed", data)
+}
+
+// FollowUser follows another user and renders the updated follow button
+func (h *Handlers) FollowUser(c echo.Context) error {
+	return h.setFollowing(c, true)
+}
+
+// UnfollowUser unfollows another user and renders the updated follow button
+func (h *Handlers) UnfollowUser(c echo.Context) error {
+	return h.setFollowing(c, false)
+}
+
+func (h *Handlers) setFollowing(c echo.Context, follow bool) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to follow golfers")
+	}
+
+	parsed, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid user ID")
+	}
+	followeeID := uint(parsed)
+
+	followService := NewFollowService()
+	if follow {
+		err = followService.Follow(*userID, followeeID)
+	} else {
+		err = followService.Unfollow(*userID, followeeID)
+	}
+	if err != nil {
+		log.Printf("[FOLLOW] Failed to update follow of user %d by user %d: %v", followeeID, *userID, err)
+		switch {
+		case errors.Is(err, ErrCannotFollowSelf):
+			return c.String(http.StatusBadRequest, err.Error())
+		case errors.Is(err, ErrUserNotFound):
+			return c.String(http.StatusNotFound, "Golfer not found")
+		default:
+			return c.String(http.StatusInternalServerError, "Failed to update follow")
+		}
+	}
+
+	return c.Render(http.StatusOK, "follow-button", FollowButton{UserID: followeeID, Following: follow})
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CourseListsData is the view model for the "course-lists" template
+type CourseListsData struct {
+	Lists []CourseListView
+	Error string
+}
+
+// SharedCourseListData is the view model for the "shared-course-list" template
+type SharedCourseListData struct {
+	List  *CourseListView
+	IsOwn bool
+}
+
+// CourseListButtonsData is the view model for the "course-list-buttons" template
+type CourseListButtonsData struct {
+	CourseIndex int
+	IsLoggedIn  bool
+	WantToPlay  bool
+	Lists       []CourseListOption // Named lists only; want-to-play has its own button
+	Error       string
+}
+
+// CourseListOption is a named list in the course page's add-to-list menu
+type CourseListOption struct {
+	ID   uint
+	Name string
+	Has  bool
+}
+
+// CourseLists renders the signed-in user's lists
+func (h *Handlers) CourseLists(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to see your lists")
+	}
+	return h.renderCourseLists(c, *userID, "")
+}
+
+// CreateCourseList adds a named list from the lists page
+func (h *Handlers) CreateCourseList(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to create a list")
+	}
+
+	if _, err := NewCourseListService().CreateList(*userID, c.FormValue("name")); err != nil {
+		log.Printf("[LISTS] Failed to create list for user %d: %v", *userID, err)
+		return h.renderCourseLists(c, *userID, courseListErrorMessage(err))
+	}
+	return h.renderCourseLists(c, *userID, "")
+}
+
+// DeleteCourseList removes one of the user's named lists
+func (h *Handlers) DeleteCourseList(c echo.Context) error {
+	return h.updateCourseList(c, func(service *CourseListService, userID, listID uint) error {
+		return service.DeleteList(userID, listID)
+	})
+}
+
+// ShareCourseList turns on a list's share link
+func (h *Handlers) ShareCourseList(c echo.Context) error {
+	return h.updateCourseList(c, func(service *CourseListService, userID, listID uint) error {
+		_, err := service.SetSharing(userID, listID, true)
+		return err
+	})
+}
+
+// UnshareCourseList turns off a list's share link
+func (h *Handlers) UnshareCourseList(c echo.Context) error {
+	return h.updateCourseList(c, func(service *CourseListService, userID, listID uint) error {
+		_, err := service.SetSharing(userID, listID, false)
+		return err
+	})
+}
+
+// MoveCourseListCourse moves a course one place up or down a list
+func (h *Handlers) MoveCourseListCourse(c echo.Context) error {
+	courseID, err := strconv.ParseUint(c.Param("courseId"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid course ID")
+	}
+	offset := 1
+	if c.FormValue("direction") == "up" {
+		offset = -1
+	}
+
+	return h.updateCourseList(c, func(service *CourseListService, userID, listID uint) error {
+		_, err := service.MoveCourse(userID, listID, uint(courseID), offset)
+		return err
+	})
+}
+
+// RemoveCourseListCourse takes a course off a list
+func (h *Handlers) RemoveCourseListCourse(c echo.Context) error {
+	courseID, err := strconv.ParseUint(c.Param("courseId"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid course ID")
+	}
+
+	return h.updateCourseList(c, func(service *CourseListService, userID, listID uint) error {
+		_, err := service.RemoveCourse(userID, listID, uint(courseID))
+		return err
+	})
+}
+
+// SharedCourseList renders a list shared by link. Anyone with the link can see it.
+func (h *Handlers) SharedCourseList(c echo.Context) error {
+	list, err := NewCourseListService().GetSharedList(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, ErrCourseListNotFound) {
+			return c.String(http.StatusNotFound, "This list isn't shared anymore")
+		}
+		log.Printf("[LISTS] Failed to load shared list: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load list")
+	}
+
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	data := SharedCourseListData{
+		List:  list,
+		IsOwn: userID != nil && *userID == list.UserID,
+	}
+
+	// Share links are usually opened straight from a message rather than
+	// from inside the app, so those get a page of their own
+	if c.Request().Header.Get("HX-Request") == "" {
+		return c.Render(http.StatusOK, "shared-course-list-page", data)
+	}
+	return c.Render(http.StatusOK, "shared-course-list", data)
+}
+
+// CourseListButtons renders the want-to-play and add-to-list controls of a course page
+func (h *Handlers) CourseListButtons(c echo.Context) error {
+	return h.renderCourseListButtons(c, "")
+}
+
+// AddCourseToWantToPlay puts the course on the user's want-to-play list
+func (h *Handlers) AddCourseToWantToPlay(c echo.Context) error {
+	return h.setWantToPlay(c, true)
+}
+
+// RemoveCourseFromWantToPlay takes the course off the user's want-to-play list
+func (h *Handlers) RemoveCourseFromWantToPlay(c echo.Context) error {
+	return h.setWantToPlay(c, false)
+}
+
+// AddCourseToList puts the course on one of the user's named lists from the course page
+func (h *Handlers) AddCourseToList(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to add courses to a list")
+	}
+
+	dbCourse, _, err := h.courseFromIndexParam(c)
+	if err != nil || dbCourse == nil {
+		return err
+	}
+
+	listID, err := strconv.ParseUint(c.FormValue("list_id"), 10, 32)
+	if err != nil {
+		return h.renderCourseListButtons(c, "Choose a list")
+	}
+
+	if _, err := NewCourseListService().AddCourse(*userID, uint(listID), dbCourse.ID); err != nil {
+		log.Printf("[LISTS] Failed to add course %d to list %d: %v", dbCourse.ID, listID, err)
+		return h.renderCourseListButtons(c, courseListErrorMessage(err))
+	}
+	return h.renderCourseListButtons(c, "")
+}
+
+func (h *Handlers) setWantToPlay(c echo.Context, want bool) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to keep a want-to-play list")
+	}
+
+	dbCourse, _, err := h.courseFromIndexParam(c)
+	if err != nil || dbCourse == nil {
+		return err
+	}
+
+	if err := NewCourseListService().SetWantToPlay(*userID, dbCourse.ID, want); err != nil {
+		log.Printf("[LISTS] Failed to update want-to-play for course %d: %v", dbCourse.ID, err)
+		return h.renderCourseListButtons(c, courseListErrorMessage(err))
+	}
+	return h.renderCourseListButtons(c, "")
+}
+
+// updateCourseList runs a change to the list in the :id parameter and
+// renders the lists page again
+func (h *Handlers) updateCourseList(c echo.Context, update func(service *CourseListService, userID, listID uint) error) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to change your lists")
+	}
+
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid list ID")
+	}
+
+	if err := update(NewCourseListService(), *userID, uint(listID)); err != nil {
+		log.Printf("[LISTS] Failed to update list %d: %v", listID, err)
+		return h.renderCourseLists(c, *userID, courseListErrorMessage(err))
+	}
+	return h.renderCourseLists(c, *userID, "")
+}
+
+func (h *Handlers) renderCourseLists(c echo.Context, userID uint, errorMessage string) error {
+	lists, err := NewCourseListService().GetLists(userID)
+	if err != nil {
+		log.Printf("[LISTS] Failed to load lists for user %d: %v", userID, err)
+		return c.String(http.StatusInternalServerError, "Failed to load lists")
+	}
+
+	return c.Render(http.StatusOK, "course-lists", CourseListsData{Lists: lists, Error: errorMessage})
+}
+
+func (h *Handlers) renderCourseListButtons(c echo.Context, errorMessage string) error {
+	dbCourse, courseIndex, err := h.courseFromIndexParam(c)
+	if err != nil || dbCourse == nil {
+		return err
+	}
+
+	data := CourseListButtonsData{
+		CourseIndex: courseIndex,
+		Error:       errorMessage,
+	}
+
+	sessionService := NewSessionService()
+	if userID := sessionService.GetDatabaseUserID(c); userID != nil {
+		data.IsLoggedIn = true
+		lists, err := NewCourseListService().GetLists(*userID)
+		if err != nil {
+			log.Printf("[LISTS] Failed to load lists for user %d: %v", *userID, err)
+			return c.String(http.StatusInternalServerError, "Failed to load lists")
+		}
+		for _, list := range lists {
+			if list.Kind == CourseListKindWantToPlay {
+				data.WantToPlay = list.Has(dbCourse.ID)
+				continue
+			}
+			data.Lists = append(data.Lists, CourseListOption{ID: list.ID, Name: list.Name, Has: list.Has(dbCourse.ID)})
+		}
+	}
+
+	return c.Render(http.StatusOK, "course-list-buttons", data)
+}
+
+// courseListErrorMessage converts list service errors into user-facing messages
+func courseListErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidCourseListName),
+		errors.Is(err, ErrCannotDeleteWantToPlay):
+		return err.Error()
+	case errors.Is(err, ErrCourseListNotFound):
+		return "That list no longer exists"
+	case errors.Is(err, ErrCourseNotFound):
+		return "That course no longer exists"
+	default:
+		return "Something went wrong, please try again"
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const notificationsPanelSize = 20
+
+// NotificationsData is the view model for the "notifications" template
+type NotificationsData struct {
+	Items    []NotificationItem
+	Unread   int64
+	Settings []NotificationSetting
+	Saved    bool
+	Error    string
+}
+
+// NotificationItem is a notification as shown in the inbox panel
+type NotificationItem struct {
+	Notification
+	PostedOn string
+}
+
+// HelpfulButton is the view model for the "helpful-button" template
+type HelpfulButton struct {
+	CourseIndex int
+	ReviewID    uint
+	Count       int
+	Marked      bool
+	CanVote     bool
+}
+
+// Notifications renders the home page notification inbox and preferences
+func (h *Handlers) Notifications(c echo.Context) error {
+	return h.renderNotifications(c, "", false)
+}
+
+// MarkNotificationRead marks one notification as read and renders the inbox
+func (h *Handlers) MarkNotificationRead(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to see notifications")
+	}
+
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid notification ID")
+	}
+
+	if err := NewNotificationService().MarkRead(*userID, uint(notificationID)); err != nil {
+		if errors.Is(err, ErrNotificationNotFound) {
+			return c.String(http.StatusNotFound, "Notification not found")
+		}
+		log.Printf("[NOTIFICATIONS] Failed to mark notification %d read: %v", notificationID, err)
+		return h.renderNotifications(c, "Failed to update notification", false)
+	}
+	return h.renderNotifications(c, "", false)
+}
+
+// MarkAllNotificationsRead marks the whole inbox as read and renders it
+func (h *Handlers) MarkAllNotificationsRead(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to see notifications")
+	}
+
+	if err := NewNotificationService().MarkAllRead(*userID); err != nil {
+		log.Printf("[NOTIFICATIONS] Failed to mark notifications read for user %d: %v", *userID, err)
+		return h.renderNotifications(c, "Failed to update notifications", false)
+	}
+	return h.renderNotifications(c, "", false)
+}
+
+// UpdateNotificationPreferences saves the preferences form. Unchecked boxes
+// aren't submitted, so every type is saved with whatever the form sent.
+func (h *Handlers) UpdateNotificationPreferences(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to change notification settings")
+	}
+
+	notificationService := NewNotificationService()
+	settings, err := notificationService.GetSettings(*userID)
+	if err != nil {
+		log.Printf("[NOTIFICATIONS] Failed to load settings for user %d: %v", *userID, err)
+		return h.renderNotifications(c, "Failed to save notification settings", false)
+	}
+	for i := range settings {
+		settings[i].InApp = c.FormValue("in_app_"+settings[i].Type) != ""
+		settings[i].Email = c.FormValue("email_"+settings[i].Type) != ""
+	}
+
+	if err := notificationService.UpdateSettings(*userID, settings); err != nil {
+		log.Printf("[NOTIFICATIONS] Failed to save settings for user %d: %v", *userID, err)
+		return h.renderNotifications(c, "Failed to save notification settings", false)
+	}
+	return h.renderNotifications(c, "", true)
+}
+
+func (h *Handlers) renderNotifications(c echo.Context, errorMessage string, saved bool) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to see notifications")
+	}
+
+	data := NotificationsData{Saved: saved, Error: errorMessage}
+	notificationService := NewNotificationService()
+
+	notifications, _, err := notificationService.GetNotifications(*userID, false, 1, notificationsPanelSize)
+	if err != nil {
+		log.Printf("[NOTIFICATIONS] Failed to load notifications for user %d: %v", *userID, err)
+		data.Error = "Failed to load your notifications"
+	}
+	now := time.Now()
+	for _, notification := range notifications {
+		data.Items = append(data.Items, NotificationItem{
+			Notification: notification,
+			PostedOn:     formatTimeAgo(now, notification.CreatedAt),
+		})
+	}
+
+	if data.Unread, err = notificationService.CountUnread(*userID); err != nil {
+		log.Printf("[NOTIFICATIONS] Failed to count notifications for user %d: %v", *userID, err)
+	}
+	if data.Settings, err = notificationService.GetSettings(*userID); err != nil {
+		log.Printf("[NOTIFICATIONS] Failed to load settings for user %d: %v", *userID, err)
+	}
+
+	return c.Render(http.StatusOK, "notifications", data)
+}
+
+// MarkReviewHelpful votes a review helpful and renders the updated button
+func (h *Handlers) MarkReviewHelpful(c echo.Context) error {
+	return h.setReviewHelpful(c, true)
+}
+
+// UnmarkReviewHelpful withdraws a helpful vote and renders the updated button
+func (h *Handlers) UnmarkReviewHelpful(c echo.Context) error {
+	return h.setReviewHelpful(c, false)
+}
+
+func (h *Handlers) setReviewHelpful(c echo.Context, helpful bool) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to vote on reviews")
+	}
+
+	courseIndex, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid course ID")
+	}
+	parsed, err := strconv.ParseUint(c.Param("reviewId"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid review ID")
+	}
+	reviewID := uint(parsed)
+
+	reviewService := NewReviewService()
+	if err := reviewService.SetReviewHelpful(*userID, reviewID, helpful); err != nil {
+		log.Printf("[REVIEW_HELPFUL] Failed to update vote on review %d by user %d: %v", reviewID, *userID, err)
+		switch {
+		case errors.Is(err, ErrReviewNotFound):
+			return c.String(http.StatusNotFound, "Review not found")
+		case errors.Is(err, ErrOwnReviewHelpful):
+			return c.String(http.StatusBadRequest, err.Error())
+		default:
+			return c.String(http.StatusInternalServerError, "Failed to update vote")
+		}
+	}
+
+	counts, _, err := reviewService.GetHelpfulVotes([]uint{reviewID}, nil)
+	if err != nil {
+		log.Printf("[REVIEW_HELPFUL] Failed to count votes on review %d: %v", reviewID, err)
+	}
+
+	return c.Render(http.StatusOK, "helpful-button", HelpfulButton{
+		CourseIndex: courseIndex,
+		ReviewID:    reviewID,
+		Count:       counts[reviewID],
+		Marked:      helpful,
+		CanVote:     true,
+	})
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"course_management/api"
+
+	"github.com/labstack/echo/v4"
+)
+
+// outingTimeLayout is the format of datetime-local form inputs
+const outingTimeLayout = "2006-01-02T15:04"
+
+// OutingsData is the view model for the "outings" template
+type OutingsData struct {
+	Upcoming []OutingView
+	Past     []OutingView
+	Courses  []CourseDB // Choices for the new outing form
+	FeedURL  string
+	Error    string
+}
+
+// OutingDetailData is the view model for the "outing-detail" template
+type OutingDetailData struct {
+	Outing        *OutingView
+	Results       []OutingResult
+	Scores        []UserCourseScore // The viewer's scores at the course, for linking
+	Started       bool
+	StartsAtInput string
+	Error         string
+}
+
+// GuestOutingData is the view model for the "guest-outing" template
+type GuestOutingData struct {
+	Outing *OutingView
+	Token  string
+	Error  string
+}
+
+// Outings renders the signed-in user's outings and the new outing form
+func (h *Handlers) Outings(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to see your outings")
+	}
+	return h.renderOutings(c, *userID, "")
+}
+
+// CreateOuting schedules an outing from the outings page and invites the
+// email addresses in the form
+func (h *Handlers) CreateOuting(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to plan an outing")
+	}
+
+	courseID, err := strconv.ParseUint(c.FormValue("course_id"), 10, 32)
+	if err != nil {
+		return h.renderOutings(c, *userID, "Choose a course")
+	}
+	input, errorMessage := outingFormInput(c)
+	if errorMessage != "" {
+		return h.renderOutings(c, *userID, errorMessage)
+	}
+	input.CourseID = uint(courseID)
+
+	service := NewOutingService()
+	outing, err := service.CreateOuting(*userID, input)
+	if err != nil {
+		log.Printf("[OUTINGS] Failed to create outing for user %d: %v", *userID, err)
+		return h.renderOutings(c, *userID, outingErrorMessage(err))
+	}
+
+	if emails := splitOutingEmails(c.FormValue("emails")); len(emails) > 0 {
+		if _, err := service.Invite(*userID, outing.ID, nil, emails); err != nil {
+			log.Printf("[OUTINGS] Failed to invite players to outing %d: %v", outing.ID, err)
+			return h.renderOutingDetail(c, *userID, outing.ID, outingErrorMessage(err))
+		}
+	}
+	return h.renderOutingDetail(c, *userID, outing.ID, "")
+}
+
+// OutingDetail renders one outing with its invite list and results
+func (h *Handlers) OutingDetail(c echo.Context) error {
+	return h.updateOuting(c, nil)
+}
+
+// UpdateOuting changes an outing's time, capacity or notes
+func (h *Handlers) UpdateOuting(c echo.Context) error {
+	return h.updateOuting(c, func(service *OutingService, userID, outingID uint) error {
+		input, errorMessage := outingFormInput(c)
+		if errorMessage != "" {
+			return outingFormError(errorMessage)
+		}
+		_, err := service.UpdateOuting(userID, outingID, input)
+		return err
+	})
+}
+
+// CancelOuting calls off an outing
+func (h *Handlers) CancelOuting(c echo.Context) error {
+	return h.updateOuting(c, func(service *OutingService, userID, outingID uint) error {
+		return service.CancelOuting(userID, outingID)
+	})
+}
+
+// InviteToOuting invites the email addresses in the form. Addresses of
+// registered users invite those users.
+func (h *Handlers) InviteToOuting(c echo.Context) error {
+	return h.updateOuting(c, func(service *OutingService, userID, outingID uint) error {
+		emails := splitOutingEmails(c.FormValue("emails"))
+		if len(emails) == 0 {
+			return outingFormError("Enter at least one email address")
+		}
+		_, err := service.Invite(userID, outingID, nil, emails)
+		return err
+	})
+}
+
+// RSVPOuting records the signed-in user's answer to an invitation
+func (h *Handlers) RSVPOuting(c echo.Context) error {
+	return h.updateOuting(c, func(service *OutingService, userID, outingID uint) error {
+		_, err := service.RSVP(userID, outingID, c.FormValue("rsvp"))
+		return err
+	})
+}
+
+// LinkOutingScore marks one of the signed-in user's scores as their round at
+// the outing
+func (h *Handlers) LinkOutingScore(c echo.Context) error {
+	return h.updateOuting(c, func(service *OutingService, userID, outingID uint) error {
+		scoreID, err := strconv.ParseUint(c.FormValue("score_id"), 10, 32)
+		if err != nil {
+			return outingFormError("Choose a score")
+		}
+		return service.LinkScore(userID, outingID, uint(scoreID))
+	})
+}
+
+// OutingCalendar downloads an outing as an iCalendar file
+func (h *Handlers) OutingCalendar(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to download outings")
+	}
+
+	outingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid outing ID")
+	}
+
+	outing, err := NewOutingService().GetOuting(*userID, uint(outingID))
+	if err != nil {
+		if errors.Is(err, ErrOutingNotFound) {
+			return c.String(http.StatusNotFound, "Outing not found")
+		}
+		log.Printf("[OUTINGS] Failed to load outing %d: %v", outingID, err)
+		return c.String(http.StatusInternalServerError, "Failed to load outing")
+	}
+	return h.renderOutingCalendar(c, fmt.Sprintf("outing-%d.ics", outing.ID), outing.Title, []OutingView{*outing})
+}
+
+// OutingCalendarFeed serves a user's outing calendar feed to calendar apps.
+// The token in the address is the only credential.
+func (h *Handlers) OutingCalendarFeed(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("file"), ".ics")
+	outings, err := NewOutingService().GetCalendarFeed(token)
+	if err != nil {
+		if errors.Is(err, ErrOutingNotFound) {
+			return c.String(http.StatusNotFound, "Calendar not found")
+		}
+		log.Printf("[OUTINGS] Failed to load calendar feed: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load calendar")
+	}
+	return h.renderOutingCalendar(c, "", "Golf outings", outings)
+}
+
+// GuestOuting renders the RSVP page an email invitee reaches from their invitation
+func (h *Handlers) GuestOuting(c echo.Context) error {
+	outing, _, err := NewOutingService().GetOutingByToken(c.Param("token"))
+	if err != nil {
+		return guestOutingError(c, err)
+	}
+	return h.renderGuestOuting(c, outing, "")
+}
+
+// GuestRSVP records an email invitee's answer
+func (h *Handlers) GuestRSVP(c echo.Context) error {
+	service := NewOutingService()
+	outing, _, err := service.RSVPByToken(c.Param("token"), c.FormValue("rsvp"))
+	if err != nil {
+		if errors.Is(err, ErrOutingNotFound) {
+			return guestOutingError(c, err)
+		}
+		log.Printf("[OUTINGS] Failed to save guest RSVP: %v", err)
+		outing, _, loadErr := service.GetOutingByToken(c.Param("token"))
+		if loadErr != nil {
+			return guestOutingError(c, loadErr)
+		}
+		return h.renderGuestOuting(c, outing, outingErrorMessage(err))
+	}
+	return h.renderGuestOuting(c, outing, "")
+}
+
+// GuestOutingCalendar downloads the outing an email invitee was invited to
+func (h *Handlers) GuestOutingCalendar(c echo.Context) error {
+	outing, _, err := NewOutingService().GetOutingByToken(c.Param("token"))
+	if err != nil {
+		return guestOutingError(c, err)
+	}
+	return h.renderOutingCalendar(c, "outing.ics", outing.Title, []OutingView{*outing})
+}
+
+// updateOuting runs a change to the outing in the :id parameter, if any, and
+// renders the outing again
+func (h *Handlers) updateOuting(c echo.Context, update func(service *OutingService, userID, outingID uint) error) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to see outings")
+	}
+
+	outingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid outing ID")
+	}
+
+	if update != nil {
+		if err := update(NewOutingService(), *userID, uint(outingID)); err != nil {
+			log.Printf("[OUTINGS] Failed to update outing %d: %v", outingID, err)
+			return h.renderOutingDetail(c, *userID, uint(outingID), outingErrorMessage(err))
+		}
+	}
+	return h.renderOutingDetail(c, *userID, uint(outingID), "")
+}
+
+func (h *Handlers) renderOutings(c echo.Context, userID uint, errorMessage string) error {
+	service := NewOutingService()
+	outings, err := service.GetOutings(userID)
+	if err != nil {
+		log.Printf("[OUTINGS] Failed to load outings for user %d: %v", userID, err)
+		return c.String(http.StatusInternalServerError, "Failed to load outings")
+	}
+	courses, err := service.CourseChoices()
+	if err != nil {
+		log.Printf("[OUTINGS] Failed to load courses: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load outings")
+	}
+	token, err := service.CalendarFeedToken(userID)
+	if err != nil {
+		log.Printf("[OUTINGS] Failed to load calendar feed for user %d: %v", userID, err)
+		return c.String(http.StatusInternalServerError, "Failed to load outings")
+	}
+
+	data := OutingsData{
+		Courses: courses,
+		FeedURL: api.RequestBaseURL(c) + "/outings/calendar/" + token + ".ics",
+		Error:   errorMessage,
+	}
+	now := time.Now().Unix()
+	for _, outing := range outings {
+		if outing.StartsAt >= now {
+			data.Upcoming = append(data.Upcoming, outing)
+		} else {
+			data.Past = append([]OutingView{outing}, data.Past...) // Most recent first
+		}
+	}
+	return c.Render(http.StatusOK, "outings", data)
+}
+
+func (h *Handlers) renderOutingDetail(c echo.Context, userID, outingID uint, errorMessage string) error {
+	service := NewOutingService()
+	outing, results, err := service.GetResults(userID, outingID)
+	if err != nil {
+		if errors.Is(err, ErrOutingNotFound) {
+			return c.String(http.StatusNotFound, "Outing not found")
+		}
+		log.Printf("[OUTINGS] Failed to load outing %d: %v", outingID, err)
+		return c.String(http.StatusInternalServerError, "Failed to load outing")
+	}
+
+	data := OutingDetailData{
+		Outing:        outing,
+		Results:       results,
+		Started:       time.Now().Unix() >= outing.StartsAt,
+		StartsAtInput: time.Unix(outing.StartsAt, 0).Format(outingTimeLayout),
+		Error:         errorMessage,
+	}
+	if data.Started && outing.MyInvite != nil && outing.MyInvite.RSVP == OutingRSVPGoing {
+		if data.Scores, err = service.ScoresAtCourse(userID, outing.CourseID); err != nil {
+			log.Printf("[OUTINGS] Failed to load scores for user %d: %v", userID, err)
+			return c.String(http.StatusInternalServerError, "Failed to load outing")
+		}
+	}
+
+	// Outing links in calendars open straight from the calendar app rather
+	// than from inside the app, so those get a page of their own
+	if c.Request().Header.Get("HX-Request") == "" {
+		return c.Render(http.StatusOK, "outing-page", data)
+	}
+	return c.Render(http.StatusOK, "outing-detail", data)
+}
+
+func (h *Handlers) renderGuestOuting(c echo.Context, outing *OutingView, errorMessage string) error {
+	data := GuestOutingData{Outing: outing, Token: c.Param("token"), Error: errorMessage}
+	if c.Request().Header.Get("HX-Request") == "" {
+		return c.Render(http.StatusOK, "guest-outing-page", data)
+	}
+	return c.Render(http.StatusOK, "guest-outing", data)
+}
+
+// renderOutingCalendar writes outings as an iCalendar document. A filename
+// makes browsers download it; feeds leave it out.
+func (h *Handlers) renderOutingCalendar(c echo.Context, filename, name string, outings []OutingView) error {
+	if filename != "" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	}
+	calendar := api.NewCalendar(name, OutingCalendarEvents(outings, api.RequestBaseURL(c)))
+	return c.Blob(http.StatusOK, api.CalendarContentType, calendar)
+}
+
+func guestOutingError(c echo.Context, err error) error {
+	if errors.Is(err, ErrOutingNotFound) {
+		return c.String(http.StatusNotFound, "This invitation link isn't valid anymore")
+	}
+	log.Printf("[OUTINGS] Failed to load guest outing: %v", err)
+	return c.String(http.StatusInternalServerError, "Failed to load outing")
+}
+
+// outingFormError is a form problem found before reaching the outing service
+type outingFormError string
+
+func (e outingFormError) Error() string {
+	return string(e)
+}
+
+// outingFormInput reads the outing fields of a form
+func outingFormInput(c echo.Context) (OutingInput, string) {
+	startsAt, err := time.ParseInLocation(outingTimeLayout, c.FormValue("starts_at"), time.Local)
+	if err != nil {
+		return OutingInput{}, "Choose a date and tee time"
+	}
+
+	capacity := 0
+	if value := strings.TrimSpace(c.FormValue("capacity")); value != "" {
+		if capacity, err = strconv.Atoi(value); err != nil {
+			return OutingInput{}, ErrInvalidOutingCapacity.Error()
+		}
+	}
+
+	return OutingInput{
+		Title:    c.FormValue("title"),
+		StartsAt: startsAt,
+		Capacity: capacity,
+		Notes:    c.FormValue("notes"),
+	}, ""
+}
+
+// splitOutingEmails splits a list of email addresses separated by commas,
+// semicolons or line breaks
+func splitOutingEmails(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n' || r == '\r'
+	})
+	emails := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			emails = append(emails, field)
+		}
+	}
+	return emails
+}
+
+// outingErrorMessage converts outing service errors into user-facing messages
+func outingErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidOutingTitle),
+		errors.Is(err, ErrInvalidOutingCapacity),
+		errors.Is(err, ErrOutingNotesTooLong),
+		errors.Is(err, ErrOutingInPast),
+		errors.Is(err, ErrInvalidOutingInvitee),
+		errors.Is(err, ErrInvalidRSVP),
+		errors.Is(err, ErrOutingNotStarted),
+		errors.Is(err, ErrOutingScoreMismatch),
+		errors.Is(err, ErrNotOutingParticipant):
+		return err.Error()
+	case errors.Is(err, ErrOutingCancelled):
+		return "This outing has been cancelled"
+	case errors.Is(err, ErrOutingNotFound):
+		return "That outing no longer exists, or only its organizer can change it"
+	case errors.Is(err, ErrCourseNotFound):
+		return "That course no longer exists"
+	case errors.Is(err, ErrUserNotFound):
+		return "One of those players no longer has an account"
+	default:
+		var formError outingFormError
+		if errors.As(err, &formError) {
+			return string(formError)
+		}
+		return "Something went wrong, please try again"
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PublicProfileData is the view model for the "public-profile" template
+type PublicProfileData struct {
+	*PublicProfile
+	FollowButton  *FollowButton         // Nil on your own profile and for signed-out visitors
+	PrivacyFields []ProfilePrivacyField // Only on your own profile
+	PrivacySaved  bool
+	MapboxToken   string
+	Error         string
+}
+
+// PublicProfile renders a user's profile with the sections they share with the viewer
+func (h *Handlers) PublicProfile(c echo.Context) error {
+	parsed, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	sessionService := NewSessionService()
+	return h.renderPublicProfile(c, uint(parsed), sessionService.GetDatabaseUserID(c), "", false)
+}
+
+// UpdateProfilePrivacy saves who can see each section of the signed-in
+// user's profile and renders the profile again
+func (h *Handlers) UpdateProfilePrivacy(c echo.Context) error {
+	sessionService := NewSessionService()
+	userID := sessionService.GetDatabaseUserID(c)
+	if userID == nil {
+		return c.String(http.StatusUnauthorized, "You must be logged in to change privacy settings")
+	}
+
+	_, err := NewProfileService().UpdatePrivacy(*userID, ProfilePrivacy{
+		Handicap:      c.FormValue("handicap"),
+		Reviews:       c.FormValue("reviews"),
+		PlayedCourses: c.FormValue("played_courses"),
+		Activity:      c.FormValue("activity"),
+	})
+	if err != nil {
+		log.Printf("[PROFILE] Failed to save privacy for user %d: %v", *userID, err)
+		message := "Failed to save privacy settings"
+		if errors.Is(err, ErrInvalidProfileAudience) {
+			message = "Choose who can see each section"
+		}
+		return h.renderPublicProfile(c, *userID, userID, message, false)
+	}
+
+	return h.renderPublicProfile(c, *userID, userID, "", true)
+}
+
+func (h *Handlers) renderPublicProfile(c echo.Context, profileID uint, viewerID *uint, errorMessage string, saved bool) error {
+	profile, err := NewProfileService().GetProfile(profileID, viewerID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return c.String(http.StatusNotFound, "Golfer not found")
+		}
+		log.Printf("[PROFILE] Failed to load profile of user %d: %v", profileID, err)
+		return c.String(http.StatusInternalServerError, "Failed to load profile")
+	}
+
+	data := PublicProfileData{
+		PublicProfile: profile,
+		PrivacySaved:  saved,
+		MapboxToken:   os.Getenv("MAPBOX_ACCESS_TOKEN"),
+		Error:         errorMessage,
+	}
+	if profile.IsOwn {
+		data.PrivacyFields = BuildProfilePrivacyFields(profile.Privacy)
+	} else if viewerID != nil {
+		data.FollowButton = &FollowButton{UserID: profile.UserID, Following: profile.IsFollowing}
+	}
+
+	return c.Render(http.StatusOK, "public-profile", data)
+}
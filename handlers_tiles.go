@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// courseTileContentType is the registered media type for Mapbox Vector Tiles
+	courseTileContentType = "application/vnd.mapbox-vector-tile"
+	// courseTileCacheControl lets browsers reuse a tile for a minute, then
+	// revalidate it with its ETag
+	courseTileCacheControl = "public, max-age=60"
+)
+
+// CourseTile serves located courses as a Mapbox Vector Tile at
+// /tiles/{z}/{x}/{y}.mvt, in a "golf" layer of points with id, name, address
+// and rating properties
+func (h *Handlers) CourseTile(c echo.Context) error {
+	name, isTile := strings.CutSuffix(c.Param("file"), ".mvt")
+	if !isTile {
+		return c.String(http.StatusNotFound, "Tile not found")
+	}
+	z, errZ := strconv.Atoi(c.Param("z"))
+	x, errX := strconv.Atoi(c.Param("x"))
+	y, errY := strconv.Atoi(name)
+	if errZ != nil || errX != nil || errY != nil {
+		return c.String(http.StatusBadRequest, "Invalid tile coordinates")
+	}
+
+	tile, err := NewCourseTileService().Tile(z, x, y)
+	if err != nil {
+		if errors.Is(err, ErrCourseTileNotFound) {
+			return c.String(http.StatusNotFound, "Tile not found")
+		}
+		log.Printf("[TILES] Failed to encode tile %d/%d/%d: %v", z, x, y, err)
+		return c.String(http.StatusInternalServerError, "Failed to load tile")
+	}
+
+	header := c.Response().Header()
+	header.Set("ETag", tile.ETag)
+	header.Set(echo.HeaderCacheControl, courseTileCacheControl)
+	if etagMatches(c.Request().Header.Get("If-None-Match"), tile.ETag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.Blob(http.StatusOK, courseTileContentType, tile.Data)
+}
+
+// etagMatches reports whether an If-None-Match header names the ETag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
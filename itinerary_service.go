@@ -0,0 +1,561 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"course_management/services"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxItineraryCourses is the most courses one trip can visit
+	MaxItineraryCourses = 25
+	// MaxItineraryDays is the longest trip that can be planned
+	MaxItineraryDays       = 14
+	maxItineraryNameLength = 100
+	// exactItineraryCourses is the most courses ordered by checking every
+	// order; longer trips start from the nearest course each time and are
+	// then improved with 2-opt
+	exactItineraryCourses   = 10
+	itineraryRoutingTimeout = 20 * time.Second
+	defaultTripStartName    = "Start"
+)
+
+var (
+	ErrItineraryNotFound         = errors.New("itinerary not found")
+	ErrInvalidItineraryName      = errors.New("itinerary names must be between 1 and 100 characters")
+	ErrInvalidItinerary          = errors.New("itineraries visit 1 to 25 courses over 1 to 14 days")
+	ErrItineraryCourseNotLocated = errors.New("every course on an itinerary must exist and have a location")
+)
+
+// routingProvider estimates driving between courses. It is nil until
+// InitRoutingProvider runs, and GetRoutingProvider falls back to straight-line
+// estimates until then.
+var routingProvider services.RoutingProvider
+
+// InitRoutingProvider sets up routing from the environment; see
+// services.LoadRoutingConfig for the variables it reads
+func InitRoutingProvider() {
+	routingProvider = services.NewRoutingProviderFromConfig(services.LoadRoutingConfig())
+}
+
+// GetRoutingProvider returns the routing provider
+func GetRoutingProvider() services.RoutingProvider {
+	if routingProvider == nil {
+		return services.NewStraightLineRouter()
+	}
+	return routingProvider
+}
+
+// TripStop is a place on a trip: where it starts, or a course
+type TripStop struct {
+	CourseID  uint    `json:"course_id,omitempty"` // Zero for the start
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// TripLeg is one drive on a trip
+type TripLeg struct {
+	From            TripStop `json:"from"`
+	To              TripStop `json:"to"`
+	DistanceKm      float64  `json:"distance_km"`
+	DurationSeconds int      `json:"duration_seconds"`
+}
+
+// TripDay is a day of driving and golf. Its first leg leaves from wherever
+// the day before ended.
+type TripDay struct {
+	Day             int       `json:"day"`
+	Legs            []TripLeg `json:"legs"`
+	DistanceKm      float64   `json:"distance_km"`
+	DurationSeconds int       `json:"duration_seconds"`
+}
+
+// TripPlan is a trip's courses in visiting order, split into days
+type TripPlan struct {
+	Start           TripStop  `json:"start"`
+	ReturnToStart   bool      `json:"return_to_start"`
+	Provider        string    `json:"provider"` // The routing provider that timed the drives
+	DistanceKm      float64   `json:"distance_km"`
+	DurationSeconds int       `json:"duration_seconds"`
+	Days            []TripDay `json:"days"`
+}
+
+// ItineraryInput is a trip to plan
+type ItineraryInput struct {
+	Name          string // Only needed to save the itinerary
+	Start         TripStop
+	CourseIDs     []uint
+	Days          int
+	ReturnToStart bool
+}
+
+// ItineraryService plans golf trips across several courses and keeps the
+// ones users save
+type ItineraryService struct {
+	db     *gorm.DB
+	router services.RoutingProvider
+}
+
+func NewItineraryService() *ItineraryService {
+	return &ItineraryService{
+		db:     GetDB(),
+		router: GetRoutingProvider(),
+	}
+}
+
+// Plan orders the courses to keep the trip's driving distance low and splits
+// them into days so the longest day's driving is as short as it can be
+func (s *ItineraryService) Plan(input ItineraryInput) (*TripPlan, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	courseIDs := uniqueCourseIDs(input.CourseIDs)
+	if len(courseIDs) == 0 || len(courseIDs) > MaxItineraryCourses || input.Days < 1 || input.Days > MaxItineraryDays {
+		return nil, ErrInvalidItinerary
+	}
+
+	var courses []CourseDB
+	if err := s.db.Select("id", "name", "latitude", "longitude").
+		Where("id IN ? AND latitude IS NOT NULL AND longitude IS NOT NULL", courseIDs).
+		Find(&courses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get itinerary courses: %v", err)
+	}
+	if len(courses) != len(courseIDs) {
+		return nil, ErrItineraryCourseNotLocated
+	}
+	located := make(map[uint]CourseDB, len(courses))
+	for _, course := range courses {
+		located[course.ID] = course
+	}
+
+	start := input.Start
+	start.CourseID = 0
+	if start.Name = strings.TrimSpace(start.Name); start.Name == "" {
+		start.Name = defaultTripStartName
+	}
+	stops := []TripStop{start}
+	points := []services.RoutePoint{{Latitude: start.Latitude, Longitude: start.Longitude}}
+	for _, courseID := range courseIDs {
+		course := located[courseID]
+		stops = append(stops, TripStop{CourseID: course.ID, Name: course.Name, Latitude: *course.Latitude, Longitude: *course.Longitude})
+		points = append(points, services.RoutePoint{Latitude: *course.Latitude, Longitude: *course.Longitude})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), itineraryRoutingTimeout)
+	defer cancel()
+	matrix, err := s.router.Matrix(ctx, points)
+	if err != nil {
+		return nil, fmt.Errorf("failed to time itinerary drives: %v", err)
+	}
+
+	return planTrip(stops, matrix, input.Days, input.ReturnToStart), nil
+}
+
+// CreateItinerary plans a trip and saves it for the user
+func (s *ItineraryService) CreateItinerary(userID uint, input ItineraryInput) (*ItineraryView, error) {
+	name, err := normalizeItineraryName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.Plan(input)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode itinerary: %v", err)
+	}
+
+	itinerary := Itinerary{
+		UserID:        userID,
+		Name:          name,
+		Days:          len(plan.Days),
+		ReturnToStart: plan.ReturnToStart,
+		PlanData:      string(data),
+	}
+	if err := s.db.Create(&itinerary).Error; err != nil {
+		return nil, fmt.Errorf("failed to save itinerary: %v", err)
+	}
+	return &ItineraryView{Itinerary: itinerary, Plan: *plan}, nil
+}
+
+// GetItineraries returns the user's saved itineraries, newest first
+func (s *ItineraryService) GetItineraries(userID uint) ([]ItineraryView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var itineraries []Itinerary
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&itineraries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get itineraries: %v", err)
+	}
+
+	views := make([]ItineraryView, 0, len(itineraries))
+	for _, itinerary := range itineraries {
+		view, err := buildItineraryView(itinerary)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *view)
+	}
+	return views, nil
+}
+
+// GetItinerary returns one of the user's itineraries
+func (s *ItineraryService) GetItinerary(userID, itineraryID uint) (*ItineraryView, error) {
+	itinerary, err := s.ownedItinerary(userID, itineraryID)
+	if err != nil {
+		return nil, err
+	}
+	return buildItineraryView(*itinerary)
+}
+
+// GetSharedItinerary returns the itinerary shared with token, with its
+// owner's name
+func (s *ItineraryService) GetSharedItinerary(token string) (*ItineraryView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+	if token == "" {
+		return nil, ErrItineraryNotFound
+	}
+
+	var itinerary Itinerary
+	if err := s.db.Where("share_token = ?", token).First(&itinerary).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrItineraryNotFound
+		}
+		return nil, fmt.Errorf("failed to get shared itinerary: %v", err)
+	}
+
+	view, err := buildItineraryView(itinerary)
+	if err != nil {
+		return nil, err
+	}
+	view.OwnerName = (&ReviewCommentService{db: s.db}).AuthorName(itinerary.UserID)
+	return view, nil
+}
+
+// DeleteItinerary removes one of the user's itineraries
+func (s *ItineraryService) DeleteItinerary(userID, itineraryID uint) error {
+	itinerary, err := s.ownedItinerary(userID, itineraryID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(itinerary).Error; err != nil {
+		return fmt.Errorf("failed to delete itinerary: %v", err)
+	}
+	return nil
+}
+
+// SetSharing turns an itinerary's share link on or off. Turning sharing off
+// and on again gives the itinerary a new link, so old links stop working.
+func (s *ItineraryService) SetSharing(userID, itineraryID uint, shared bool) (*ItineraryView, error) {
+	itinerary, err := s.ownedItinerary(userID, itineraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if shared && itinerary.ShareToken == nil {
+		token, err := newShareToken()
+		if err != nil {
+			return nil, err
+		}
+		itinerary.ShareToken = &token
+	} else if !shared {
+		itinerary.ShareToken = nil
+	}
+
+	if err := s.db.Model(itinerary).Select("share_token").Updates(itinerary).Error; err != nil {
+		return nil, fmt.Errorf("failed to update itinerary sharing: %v", err)
+	}
+	return buildItineraryView(*itinerary)
+}
+
+func (s *ItineraryService) ownedItinerary(userID, itineraryID uint) (*Itinerary, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var itinerary Itinerary
+	if err := s.db.Where("id = ? AND user_id = ?", itineraryID, userID).First(&itinerary).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrItineraryNotFound
+		}
+		return nil, fmt.Errorf("failed to get itinerary: %v", err)
+	}
+	return &itinerary, nil
+}
+
+func buildItineraryView(itinerary Itinerary) (*ItineraryView, error) {
+	view := &ItineraryView{Itinerary: itinerary}
+	if err := json.Unmarshal([]byte(itinerary.PlanData), &view.Plan); err != nil {
+		return nil, fmt.Errorf("failed to read itinerary %d: %v", itinerary.ID, err)
+	}
+	return view, nil
+}
+
+// planTrip orders stops[1:] for a trip leaving from stops[0] and splits the
+// drives into at most days days, one course or more a day
+func planTrip(stops []TripStop, matrix *services.TravelMatrix, days int, returnToStart bool) *TripPlan {
+	order := orderTripStops(matrix.DistancesKm, returnToStart)
+
+	legs := make([]TripLeg, 0, len(order)+1)
+	from := 0
+	for _, to := range order {
+		legs = append(legs, tripLeg(stops, matrix, from, to))
+		from = to
+	}
+	if returnToStart {
+		legs = append(legs, tripLeg(stops, matrix, from, 0))
+	}
+
+	plan := &TripPlan{
+		Start:         stops[0],
+		ReturnToStart: returnToStart,
+		Provider:      matrix.Provider,
+		Days:          make([]TripDay, 0, days),
+	}
+	for i, dayLegs := range splitTripDays(legs, len(order), days) {
+		day := TripDay{Day: i + 1, Legs: dayLegs}
+		for _, leg := range dayLegs {
+			day.DistanceKm += leg.DistanceKm
+			day.DurationSeconds += leg.DurationSeconds
+		}
+		plan.DistanceKm += day.DistanceKm
+		plan.DurationSeconds += day.DurationSeconds
+		plan.Days = append(plan.Days, day)
+	}
+	return plan
+}
+
+func tripLeg(stops []TripStop, matrix *services.TravelMatrix, from, to int) TripLeg {
+	return TripLeg{
+		From:            stops[from],
+		To:              stops[to],
+		DistanceKm:      matrix.DistancesKm[from][to],
+		DurationSeconds: matrix.Durations[from][to],
+	}
+}
+
+// splitTripDays splits the legs to courses into at most days runs, each
+// visiting at least one course, so the longest day's driving is as short as
+// it can be. The drive back to the start, when there is one, is the last
+// day's.
+func splitTripDays(legs []TripLeg, courses, days int) [][]TripLeg {
+	if days > courses {
+		days = courses
+	}
+
+	// elapsed[i] is the driving time of the first i legs
+	elapsed := make([]int, courses+1)
+	for i := 0; i < courses; i++ {
+		elapsed[i+1] = elapsed[i] + legs[i].DurationSeconds
+	}
+	homeward := 0
+	if len(legs) > courses {
+		homeward = legs[courses].DurationSeconds
+	}
+
+	// longest[d][i] is the shortest longest day covering the first i courses
+	// in d days; split[d][i] is where the last of those days starts
+	longest := make([][]int, days+1)
+	split := make([][]int, days+1)
+	for d := range longest {
+		longest[d] = make([]int, courses+1)
+		split[d] = make([]int, courses+1)
+		for i := range longest[d] {
+			longest[d][i] = math.MaxInt
+		}
+	}
+	longest[0][0] = 0
+	for d := 1; d <= days; d++ {
+		for i := d; i <= courses; i++ {
+			for j := d - 1; j < i; j++ {
+				if longest[d-1][j] == math.MaxInt {
+					continue
+				}
+				day := elapsed[i] - elapsed[j]
+				if i == courses {
+					day += homeward
+				}
+				if worst := max(longest[d-1][j], day); worst < longest[d][i] {
+					longest[d][i], split[d][i] = worst, j
+				}
+			}
+		}
+	}
+
+	result := make([][]TripLeg, days)
+	end := courses
+	for d := days; d >= 1; d-- {
+		start := split[d][end]
+		dayLegs := append([]TripLeg{}, legs[start:end]...)
+		if end == courses {
+			dayLegs = append(dayLegs, legs[courses:]...)
+		}
+		result[d-1] = dayLegs
+		end = start
+	}
+	return result
+}
+
+// orderTripStops returns the order to visit stops 1 to n from stop 0 with the
+// least total distance, counting the drive back to stop 0 when returnToStart
+// is set. Orders of up to exactItineraryCourses stops are exact; longer ones
+// are close.
+func orderTripStops(distances [][]float64, returnToStart bool) []int {
+	n := len(distances) - 1
+	if n <= exactItineraryCourses {
+		return exactTripOrder(distances, returnToStart)
+	}
+	return improveTripOrder(distances, nearestTripOrder(distances), returnToStart)
+}
+
+// exactTripOrder finds the shortest order with the Held-Karp algorithm
+func exactTripOrder(distances [][]float64, returnToStart bool) []int {
+	n := len(distances) - 1
+	if n == 0 {
+		return []int{}
+	}
+
+	// best[visited][last] is the shortest drive from stop 0 through the stops
+	// in visited (bit k-1 for stop k), ending at stop last+1
+	full := 1<<n - 1
+	best := make([][]float64, full+1)
+	previous := make([][]int, full+1)
+	for visited := range best {
+		best[visited] = make([]float64, n)
+		previous[visited] = make([]int, n)
+		for last := range best[visited] {
+			best[visited][last] = math.Inf(1)
+		}
+	}
+	for last := 0; last < n; last++ {
+		best[1<<last][last] = distances[0][last+1]
+		previous[1<<last][last] = -1
+	}
+	for visited := 1; visited <= full; visited++ {
+		for last := 0; last < n; last++ {
+			if visited&(1<<last) == 0 || math.IsInf(best[visited][last], 1) {
+				continue
+			}
+			for next := 0; next < n; next++ {
+				if visited&(1<<next) != 0 {
+					continue
+				}
+				extended := visited | 1<<next
+				if distance := best[visited][last] + distances[last+1][next+1]; distance < best[extended][next] {
+					best[extended][next], previous[extended][next] = distance, last
+				}
+			}
+		}
+	}
+
+	last, shortest := 0, math.Inf(1)
+	for end := 0; end < n; end++ {
+		distance := best[full][end]
+		if returnToStart {
+			distance += distances[end+1][0]
+		}
+		if distance < shortest {
+			last, shortest = end, distance
+		}
+	}
+
+	order := make([]int, n)
+	for visited, i := full, n-1; i >= 0; i-- {
+		order[i] = last + 1
+		last, visited = previous[visited][last], visited&^(1<<last)
+	}
+	return order
+}
+
+// nearestTripOrder drives to the nearest stop not yet visited each time
+func nearestTripOrder(distances [][]float64) []int {
+	n := len(distances) - 1
+	visited := make([]bool, n+1)
+	order := make([]int, 0, n)
+	for current := 0; len(order) < n; {
+		next := -1
+		for stop := 1; stop <= n; stop++ {
+			if !visited[stop] && (next < 0 || distances[current][stop] < distances[current][next]) {
+				next = stop
+			}
+		}
+		visited[next] = true
+		order = append(order, next)
+		current = next
+	}
+	return order
+}
+
+// improveTripOrder reverses runs of stops while that shortens the trip (2-opt)
+func improveTripOrder(distances [][]float64, order []int, returnToStart bool) []int {
+	shortest := tripDistance(distances, order, returnToStart)
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < len(order)-1; i++ {
+			for j := i + 1; j < len(order); j++ {
+				reverseStops(order[i : j+1])
+				if distance := tripDistance(distances, order, returnToStart); distance < shortest-1e-9 {
+					shortest, improved = distance, true
+				} else {
+					reverseStops(order[i : j+1])
+				}
+			}
+		}
+	}
+	return order
+}
+
+func tripDistance(distances [][]float64, order []int, returnToStart bool) float64 {
+	total, from := 0.0, 0
+	for _, to := range order {
+		total += distances[from][to]
+		from = to
+	}
+	if returnToStart {
+		total += distances[from][0]
+	}
+	return total
+}
+
+func reverseStops(stops []int) {
+	for i, j := 0, len(stops)-1; i < j; i, j = i+1, j-1 {
+		stops[i], stops[j] = stops[j], stops[i]
+	}
+}
+
+// uniqueCourseIDs drops repeated course IDs, keeping the first of each
+func uniqueCourseIDs(courseIDs []uint) []uint {
+	seen := make(map[uint]bool, len(courseIDs))
+	unique := make([]uint, 0, len(courseIDs))
+	for _, courseID := range courseIDs {
+		if !seen[courseID] {
+			seen[courseID] = true
+			unique = append(unique, courseID)
+		}
+	}
+	return unique
+}
+
+func normalizeItineraryName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxItineraryNameLength {
+		return "", ErrInvalidItineraryName
+	}
+	return name, nil
+}
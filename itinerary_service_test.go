@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	"course_management/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// southernPines is a start a few kilometers from Mid Pines and Pinehurst
+var southernPines = TripStop{Name: "Southern Pines", Latitude: 35.174, Longitude: -79.392}
+
+// bruteForceTripDistance is the shortest trip found by trying every order
+func bruteForceTripDistance(distances [][]float64, returnToStart bool) float64 {
+	order := make([]int, len(distances)-1)
+	for i := range order {
+		order[i] = i + 1
+	}
+
+	shortest := math.Inf(1)
+	var permute func(k int)
+	permute = func(k int) {
+		if k == len(order) {
+			shortest = math.Min(shortest, tripDistance(distances, order, returnToStart))
+			return
+		}
+		for i := k; i < len(order); i++ {
+			order[k], order[i] = order[i], order[k]
+			permute(k + 1)
+			order[k], order[i] = order[i], order[k]
+		}
+	}
+	permute(0)
+	return shortest
+}
+
+func randomDistances(random *rand.Rand, stops int) [][]float64 {
+	distances := make([][]float64, stops)
+	for i := range distances {
+		distances[i] = make([]float64, stops)
+		for j := range distances[i] {
+			if i != j {
+				distances[i][j] = 1 + random.Float64()*100
+			}
+		}
+	}
+	return distances
+}
+
+func TestOrderTripStops(t *testing.T) {
+	random := rand.New(rand.NewSource(42))
+	for trial := 0; trial < 20; trial++ {
+		distances := randomDistances(random, 2+random.Intn(7))
+		for _, returnToStart := range []bool{false, true} {
+			order := orderTripStops(distances, returnToStart)
+			assert.Len(t, order, len(distances)-1)
+			assert.InDelta(t, bruteForceTripDistance(distances, returnToStart), tripDistance(distances, order, returnToStart), 1e-9,
+				"trial %d, return %v", trial, returnToStart)
+		}
+	}
+
+	// Stops along a road in shuffled order, too many to check every order
+	positions := []float64{0, 9, 3, 14, 1, 7, 12, 5, 2, 11, 6, 13, 4, 10, 8}
+	distances := make([][]float64, len(positions))
+	for i := range positions {
+		distances[i] = make([]float64, len(positions))
+		for j := range positions {
+			distances[i][j] = math.Abs(positions[i] - positions[j])
+		}
+	}
+	order := orderTripStops(distances, false)
+	visited := make([]float64, 0, len(order))
+	for _, stop := range order {
+		visited = append(visited, positions[stop])
+	}
+	assert.Equal(t, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, visited, "straight down the road")
+
+	assert.Empty(t, orderTripStops([][]float64{{0}}, true))
+}
+
+func TestSplitTripDays(t *testing.T) {
+	legs := func(durations ...int) []TripLeg {
+		legs := make([]TripLeg, len(durations))
+		for i, duration := range durations {
+			legs[i] = TripLeg{To: TripStop{CourseID: uint(i + 1)}, DurationSeconds: duration}
+		}
+		return legs
+	}
+	days := func(split [][]TripLeg) [][]int {
+		var durations [][]int
+		for _, day := range split {
+			var drives []int
+			for _, leg := range day {
+				drives = append(drives, leg.DurationSeconds)
+			}
+			durations = append(durations, drives)
+		}
+		return durations
+	}
+
+	assert.Equal(t, [][]int{{100, 50}, {50, 100}}, days(splitTripDays(legs(100, 50, 50, 100), 4, 2)))
+	assert.Equal(t, [][]int{{100}, {50, 50}, {100}}, days(splitTripDays(legs(100, 50, 50, 100), 4, 3)))
+	assert.Equal(t, [][]int{{10}, {20}}, days(splitTripDays(legs(10, 20), 2, 5)), "at most a day per course")
+
+	// The drive home belongs to the last day, so more courses go earlier
+	assert.Equal(t, [][]int{{60, 60}, {60, 120}}, days(splitTripDays(legs(60, 60, 60, 120), 3, 2)))
+}
+
+func TestItineraryService_Plan(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedGeoCourses(t, db)
+	service := NewItineraryService()
+
+	plan, err := service.Plan(ItineraryInput{
+		Start:     southernPines,
+		CourseIDs: []uint{ids["Tobacco Road"], ids["Pinehurst No. 2"], ids["Mid Pines"], ids["Tobacco Road"]},
+		Days:      2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "straight_line", plan.Provider)
+	assert.Equal(t, southernPines, plan.Start)
+	require.Len(t, plan.Days, 2)
+
+	require.Len(t, plan.Days[0].Legs, 2)
+	assert.Equal(t, "Southern Pines", plan.Days[0].Legs[0].From.Name)
+	assert.Equal(t, "Mid Pines", plan.Days[0].Legs[0].To.Name)
+	assert.Equal(t, ids["Mid Pines"], plan.Days[0].Legs[0].To.CourseID)
+	assert.Equal(t, "Pinehurst No. 2", plan.Days[0].Legs[1].To.Name)
+	require.Len(t, plan.Days[1].Legs, 1)
+	assert.Equal(t, "Pinehurst No. 2", plan.Days[1].Legs[0].From.Name, "day two leaves from where day one ended")
+	assert.Equal(t, "Tobacco Road", plan.Days[1].Legs[0].To.Name)
+
+	assert.InDelta(t, plan.Days[0].DistanceKm+plan.Days[1].DistanceKm, plan.DistanceKm, 1e-9)
+	assert.Equal(t, plan.Days[0].DurationSeconds+plan.Days[1].DurationSeconds, plan.DurationSeconds)
+	assert.Greater(t, plan.Days[1].DurationSeconds, plan.Days[0].DurationSeconds)
+
+	roundTrip, err := service.Plan(ItineraryInput{
+		Start:         TripStop{Latitude: 35.174, Longitude: -79.392},
+		CourseIDs:     []uint{ids["Mid Pines"], ids["Pinehurst No. 2"]},
+		Days:          1,
+		ReturnToStart: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, roundTrip.Days, 1)
+	legs := roundTrip.Days[0].Legs
+	require.Len(t, legs, 3)
+	assert.Equal(t, "Start", legs[0].From.Name)
+	assert.Equal(t, roundTrip.Start, legs[2].To, "drives home")
+
+	_, err = service.Plan(ItineraryInput{Start: southernPines, CourseIDs: []uint{ids["Mid Pines"], 9999}, Days: 1})
+	assert.ErrorIs(t, err, ErrItineraryCourseNotLocated)
+	var unmapped CourseDB
+	require.NoError(t, db.Where("name = ?", "Unmapped").First(&unmapped).Error)
+	_, err = service.Plan(ItineraryInput{Start: southernPines, CourseIDs: []uint{unmapped.ID}, Days: 1})
+	assert.ErrorIs(t, err, ErrItineraryCourseNotLocated, "courses without a location")
+	_, err = service.Plan(ItineraryInput{Start: southernPines, Days: 1})
+	assert.ErrorIs(t, err, ErrInvalidItinerary)
+	_, err = service.Plan(ItineraryInput{Start: southernPines, CourseIDs: []uint{ids["Mid Pines"]}, Days: MaxItineraryDays + 1})
+	assert.ErrorIs(t, err, ErrInvalidItinerary)
+}
+
+func TestItineraryService_UsesRoutingProvider(t *testing.T) {
+	db := setupTestDatabase(t)
+	ids := seedGeoCourses(t, db)
+
+	// A road network where Pinehurst is a long way round from the start
+	service := &ItineraryService{db: db, router: roadRouter{
+		{0, 50, 5},
+		{50, 0, 5},
+		{5, 5, 0},
+	}}
+	plan, err := service.Plan(ItineraryInput{Start: southernPines, CourseIDs: []uint{ids["Pinehurst No. 2"], ids["Mid Pines"]}, Days: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "road", plan.Provider)
+	assert.Equal(t, "Mid Pines", plan.Days[0].Legs[0].To.Name)
+	assert.InDelta(t, 10, plan.DistanceKm, 1e-9)
+	assert.Equal(t, 600, plan.DurationSeconds)
+}
+
+// roadRouter answers with fixed distances, and a minute a kilometer
+type roadRouter [][]float64
+
+func (r roadRouter) Name() string { return "road" }
+
+func (r roadRouter) Matrix(ctx context.Context, points []services.RoutePoint) (*services.TravelMatrix, error) {
+	matrix := &services.TravelMatrix{Provider: "road", DistancesKm: r}
+	for _, row := range r {
+		durations := make([]int, len(row))
+		for j, km := range row {
+			durations[j] = int(km * 60)
+		}
+		matrix.Durations = append(matrix.Durations, durations)
+	}
+	return matrix, nil
+}
+
+func TestItineraryService_SaveAndShare(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	ids := seedGeoCourses(t, db)
+	service := NewItineraryService()
+	input := ItineraryInput{Name: "  Sandhills weekend ", Start: southernPines, CourseIDs: []uint{ids["Mid Pines"], ids["Pinehurst No. 2"]}, Days: 3}
+
+	_, err := service.CreateItinerary(owner.ID, ItineraryInput{Start: southernPines, CourseIDs: input.CourseIDs, Days: 1})
+	assert.ErrorIs(t, err, ErrInvalidItineraryName)
+
+	created, err := service.CreateItinerary(owner.ID, input)
+	require.NoError(t, err)
+	assert.Equal(t, "Sandhills weekend", created.Name)
+	assert.Equal(t, 2, created.Days, "no more days than courses")
+	assert.Nil(t, created.ShareToken)
+
+	saved, err := service.GetItinerary(owner.ID, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.Plan, saved.Plan)
+	_, err = service.GetItinerary(golfer.ID, created.ID)
+	assert.ErrorIs(t, err, ErrItineraryNotFound, "other users can't see it")
+
+	// Saved plans don't change when the course moves
+	require.NoError(t, db.Model(&CourseDB{}).Where("id = ?", ids["Mid Pines"]).Update("latitude", 10).Error)
+	saved, err = service.GetItinerary(owner.ID, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.Plan, saved.Plan)
+
+	itineraries, err := service.GetItineraries(owner.ID)
+	require.NoError(t, err)
+	assert.Len(t, itineraries, 1)
+
+	shared, err := service.SetSharing(owner.ID, created.ID, true)
+	require.NoError(t, err)
+	require.NotNil(t, shared.ShareToken)
+	token := *shared.ShareToken
+
+	view, err := service.GetSharedItinerary(token)
+	require.NoError(t, err)
+	assert.Equal(t, "Course Owner", view.OwnerName)
+	assert.Equal(t, created.Plan, view.Plan)
+
+	_, err = service.SetSharing(owner.ID, created.ID, false)
+	require.NoError(t, err)
+	_, err = service.GetSharedItinerary(token)
+	assert.ErrorIs(t, err, ErrItineraryNotFound, "old links stop working")
+
+	assert.ErrorIs(t, service.DeleteItinerary(golfer.ID, created.ID), ErrItineraryNotFound)
+	require.NoError(t, service.DeleteItinerary(owner.ID, created.ID))
+	itineraries, err = service.GetItineraries(owner.ID)
+	require.NoError(t, err)
+	assert.Empty(t, itineraries)
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// jsonKind is the type of a parsed JSON value
+type jsonKind int
+
+const (
+	jsonNull jsonKind = iota
+	jsonBool
+	jsonNumber
+	jsonString
+	jsonArray
+	jsonObject
+)
+
+var jsonKindNames = map[jsonKind]string{
+	jsonNull:   "null",
+	jsonBool:   "boolean",
+	jsonNumber: "number",
+	jsonString: "string",
+	jsonArray:  "array",
+	jsonObject: "object",
+}
+
+// jsonValue is a parsed JSON value that remembers where it was in its file,
+// so validation errors can point at the line to fix. Object fields keep
+// their order.
+type jsonValue struct {
+	Kind   jsonKind
+	Line   int
+	Column int
+	Fields []jsonField  // Objects
+	Items  []*jsonValue // Arrays
+	Text   string       // Strings, and numbers as written
+	Bool   bool
+}
+
+type jsonField struct {
+	Key   string
+	Value *jsonValue
+}
+
+// field returns an object's value for key, or nil
+func (v *jsonValue) field(key string) *jsonValue {
+	for _, field := range v.Fields {
+		if field.Key == key {
+			return field.Value
+		}
+	}
+	return nil
+}
+
+// MarshalJSON writes the value back out with its fields in order
+func (v *jsonValue) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := v.write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (v *jsonValue) write(buf *bytes.Buffer) error {
+	switch v.Kind {
+	case jsonNull:
+		buf.WriteString("null")
+	case jsonBool:
+		buf.WriteString(strconv.FormatBool(v.Bool))
+	case jsonNumber:
+		buf.WriteString(v.Text)
+	case jsonString:
+		encoded, err := json.Marshal(v.Text)
+		if err != nil {
+			return err
+		}
+		buf.Write(encoded)
+	case jsonArray:
+		buf.WriteByte('[')
+		for i, item := range v.Items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := item.write(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case jsonObject:
+		buf.WriteByte('{')
+		for i, field := range v.Fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(field.Key)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := field.Value.write(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	return nil
+}
+
+// jsonSyntaxError is a parse failure at a position in the file
+type jsonSyntaxError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *jsonSyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// parseJSONDocument parses one JSON value. firstLine is the line data starts
+// on in its file, for NDJSON where each line is a document.
+func parseJSONDocument(data []byte, firstLine int) (*jsonValue, error) {
+	parser := &jsonParser{data: data, firstLine: firstLine, decoder: json.NewDecoder(bytes.NewReader(data))}
+	parser.decoder.UseNumber()
+	for i, b := range data {
+		if b == '\n' {
+			parser.lineStarts = append(parser.lineStarts, i+1)
+		}
+	}
+
+	value, err := parser.value()
+	if err != nil {
+		return nil, err
+	}
+	if _, offset, err := parser.next(); !errors.Is(err, io.EOF) {
+		return nil, parser.errorAt(offset, "unexpected data after the document")
+	}
+	return value, nil
+}
+
+type jsonParser struct {
+	data       []byte
+	decoder    *json.Decoder
+	firstLine  int
+	lineStarts []int // Offsets just after each newline
+}
+
+// position turns a byte offset into a line and a character column
+func (p *jsonParser) position(offset int) (int, int) {
+	if offset > len(p.data) {
+		offset = len(p.data)
+	}
+	line := sort.SearchInts(p.lineStarts, offset+1)
+	start := 0
+	if line > 0 {
+		start = p.lineStarts[line-1]
+	}
+	return p.firstLine + line, utf8.RuneCount(p.data[start:offset]) + 1
+}
+
+func (p *jsonParser) errorAt(offset int, message string) error {
+	line, column := p.position(offset)
+	return &jsonSyntaxError{Line: line, Column: column, Message: message}
+}
+
+// next reads a token and the offset it starts at. The decoder consumes the
+// commas and colons between tokens, so they're skipped too.
+func (p *jsonParser) next() (json.Token, int, error) {
+	offset := int(p.decoder.InputOffset())
+	for offset < len(p.data) && strings.IndexByte(" \t\r\n,:", p.data[offset]) >= 0 {
+		offset++
+	}
+	token, err := p.decoder.Token()
+	if err != nil {
+		var syntax *json.SyntaxError
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil, offset, err
+		case errors.As(err, &syntax):
+			// Offset counts the bytes read, including the one that failed
+			at := int(syntax.Offset) - 1
+			if int(syntax.Offset) >= len(p.data) {
+				at = len(p.data)
+			}
+			return nil, offset, p.errorAt(at, "invalid JSON: "+syntax.Error())
+		}
+		return nil, offset, p.errorAt(offset, "invalid JSON: "+err.Error())
+	}
+	return token, offset, nil
+}
+
+func (p *jsonParser) value() (*jsonValue, error) {
+	token, offset, err := p.next()
+	if errors.Is(err, io.EOF) {
+		return nil, p.errorAt(offset, "unexpected end of JSON")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	value := &jsonValue{}
+	value.Line, value.Column = p.position(offset)
+	switch token := token.(type) {
+	case nil:
+		value.Kind = jsonNull
+	case bool:
+		value.Kind, value.Bool = jsonBool, token
+	case json.Number:
+		value.Kind, value.Text = jsonNumber, token.String()
+	case string:
+		value.Kind, value.Text = jsonString, token
+	case json.Delim:
+		switch token {
+		case '[':
+			value.Kind, value.Items = jsonArray, []*jsonValue{}
+			for p.decoder.More() {
+				item, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				value.Items = append(value.Items, item)
+			}
+		case '{':
+			value.Kind = jsonObject
+			for p.decoder.More() {
+				key, _, err := p.next()
+				if err != nil {
+					return nil, err
+				}
+				field, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				value.Fields = append(value.Fields, jsonField{Key: key.(string), Value: field})
+			}
+		}
+		if _, _, err := p.next(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, p.errorAt(len(p.data), "unexpected end of JSON")
+			}
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// jsonSchema is a compiled JSON Schema. The draft-07 keywords a data file
+// schema uses are supported; a schema using any other validation keyword is
+// refused rather than half applied.
+type jsonSchema struct {
+	types                []string
+	required             []string
+	properties           []jsonSchemaProperty
+	additionalProperties *jsonSchema
+	noAdditional         bool
+	items                *jsonSchema
+	enum                 []*jsonValue
+	pattern              *regexp.Regexp
+	minimum              *float64
+	maximum              *float64
+	exclusiveMinimum     *float64
+	exclusiveMaximum     *float64
+	minLength            *int
+	maxLength            *int
+	minItems             *int
+	maxItems             *int
+}
+
+type jsonSchemaProperty struct {
+	name   string
+	schema *jsonSchema
+}
+
+// jsonSchemaAnnotations are keywords that describe rather than validate
+var jsonSchemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true,
+	"description": true, "default": true, "examples": true,
+}
+
+// loadJSONSchema reads and compiles a schema file
+func loadJSONSchema(path string) (*jsonSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %v", err)
+	}
+	document, err := parseJSONDocument(data, 1)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", path, err)
+	}
+	schema, err := compileJSONSchema(document)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", path, err)
+	}
+	return schema, nil
+}
+
+func compileJSONSchema(document *jsonValue) (*jsonSchema, error) {
+	fail := func(value *jsonValue, format string, args ...interface{}) error {
+		return &jsonSyntaxError{Line: value.Line, Column: value.Column, Message: fmt.Sprintf(format, args...)}
+	}
+	if document.Kind != jsonObject {
+		return nil, fail(document, "a schema must be an object")
+	}
+
+	number := func(value *jsonValue) (*float64, error) {
+		if value.Kind != jsonNumber {
+			return nil, fail(value, "expected a number")
+		}
+		parsed, err := strconv.ParseFloat(value.Text, 64)
+		return &parsed, err
+	}
+	count := func(value *jsonValue) (*int, error) {
+		parsed, err := strconv.Atoi(value.Text)
+		if value.Kind != jsonNumber || err != nil || parsed < 0 {
+			return nil, fail(value, "expected a non-negative integer")
+		}
+		return &parsed, nil
+	}
+
+	schema := &jsonSchema{}
+	for _, field := range document.Fields {
+		value := field.Value
+		var err error
+		switch field.Key {
+		case "type":
+			switch value.Kind {
+			case jsonString:
+				schema.types = []string{value.Text}
+			case jsonArray:
+				for _, item := range value.Items {
+					schema.types = append(schema.types, item.Text)
+				}
+			default:
+				return nil, fail(value, "type must be a string or an array")
+			}
+			for _, name := range schema.types {
+				if name != "integer" && !jsonTypeKnown(name) {
+					return nil, fail(value, "unknown type %q", name)
+				}
+			}
+		case "required":
+			for _, item := range value.Items {
+				schema.required = append(schema.required, item.Text)
+			}
+		case "properties":
+			if value.Kind != jsonObject {
+				return nil, fail(value, "properties must be an object")
+			}
+			for _, property := range value.Fields {
+				compiled, err := compileJSONSchema(property.Value)
+				if err != nil {
+					return nil, err
+				}
+				schema.properties = append(schema.properties, jsonSchemaProperty{name: property.Key, schema: compiled})
+			}
+		case "additionalProperties":
+			if value.Kind == jsonBool {
+				schema.noAdditional = !value.Bool
+			} else {
+				schema.additionalProperties, err = compileJSONSchema(value)
+			}
+		case "items":
+			schema.items, err = compileJSONSchema(value)
+		case "enum":
+			if value.Kind != jsonArray || len(value.Items) == 0 {
+				return nil, fail(value, "enum must be a non-empty array")
+			}
+			schema.enum = value.Items
+		case "pattern":
+			schema.pattern, err = regexp.Compile(value.Text)
+			if err != nil {
+				return nil, fail(value, "invalid pattern: %v", err)
+			}
+		case "minimum":
+			schema.minimum, err = number(value)
+		case "maximum":
+			schema.maximum, err = number(value)
+		case "exclusiveMinimum":
+			schema.exclusiveMinimum, err = number(value)
+		case "exclusiveMaximum":
+			schema.exclusiveMaximum, err = number(value)
+		case "minLength":
+			schema.minLength, err = count(value)
+		case "maxLength":
+			schema.maxLength, err = count(value)
+		case "minItems":
+			schema.minItems, err = count(value)
+		case "maxItems":
+			schema.maxItems, err = count(value)
+		default:
+			if !jsonSchemaAnnotations[field.Key] {
+				return nil, fail(value, "unsupported schema keyword %q", field.Key)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return schema, nil
+}
+
+func jsonTypeKnown(name string) bool {
+	for _, known := range jsonKindNames {
+		if known == name {
+			return true
+		}
+	}
+	return false
+}
+
+// property returns the schema for one of an object's properties, or nil when
+// the schema doesn't describe it
+func (s *jsonSchema) property(name string) *jsonSchema {
+	for _, property := range s.properties {
+		if property.name == name {
+			return property.schema
+		}
+	}
+	return s.additionalProperties
+}
+
+// allows reports whether the schema accepts a JSON type
+func (s *jsonSchema) allows(kind string) bool {
+	for _, name := range s.types {
+		if name == kind || (name == "number" && kind == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaViolation is one way a value breaks its schema
+type schemaViolation struct {
+	Pointer string // JSON Pointer to the value, such as "/ranks/price"
+	Line    int
+	Column  int
+	Message string
+}
+
+// validate returns every violation in a value, in document order
+func (s *jsonSchema) validate(value *jsonValue) []schemaViolation {
+	var violations []schemaViolation
+	s.check(value, "", &violations)
+	return violations
+}
+
+func (s *jsonSchema) check(value *jsonValue, pointer string, violations *[]schemaViolation) {
+	report := func(at *jsonValue, pointer, format string, args ...interface{}) {
+		*violations = append(*violations, schemaViolation{Pointer: pointer, Line: at.Line, Column: at.Column, Message: fmt.Sprintf(format, args...)})
+	}
+
+	kind := jsonKindNames[value.Kind]
+	var number float64
+	if value.Kind == jsonNumber {
+		number, _ = strconv.ParseFloat(value.Text, 64)
+		if number == math.Trunc(number) {
+			kind = "integer"
+		}
+	}
+	if len(s.types) > 0 && !s.allows(kind) {
+		report(value, pointer, "expected %s, got %s", strings.Join(s.types, " or "), jsonKindNames[value.Kind])
+		return
+	}
+
+	if len(s.enum) > 0 {
+		found := false
+		var options []string
+		for _, option := range s.enum {
+			if jsonValuesEqual(option, value) {
+				found = true
+				break
+			}
+			encoded, _ := option.MarshalJSON()
+			options = append(options, string(encoded))
+		}
+		if !found {
+			encoded, _ := value.MarshalJSON()
+			report(value, pointer, "%s is not one of %s", encoded, strings.Join(options, ", "))
+		}
+	}
+
+	switch value.Kind {
+	case jsonString:
+		length := utf8.RuneCountInString(value.Text)
+		if s.pattern != nil && !s.pattern.MatchString(value.Text) {
+			report(value, pointer, "%q does not match %s", value.Text, s.pattern)
+		}
+		if s.minLength != nil && length < *s.minLength {
+			report(value, pointer, "is %d characters, shorter than the minimum of %d", length, *s.minLength)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			report(value, pointer, "is %d characters, longer than the maximum of %d", length, *s.maxLength)
+		}
+	case jsonNumber:
+		if s.minimum != nil && number < *s.minimum {
+			report(value, pointer, "%s is less than the minimum of %g", value.Text, *s.minimum)
+		}
+		if s.maximum != nil && number > *s.maximum {
+			report(value, pointer, "%s is greater than the maximum of %g", value.Text, *s.maximum)
+		}
+		if s.exclusiveMinimum != nil && number <= *s.exclusiveMinimum {
+			report(value, pointer, "%s must be greater than %g", value.Text, *s.exclusiveMinimum)
+		}
+		if s.exclusiveMaximum != nil && number >= *s.exclusiveMaximum {
+			report(value, pointer, "%s must be less than %g", value.Text, *s.exclusiveMaximum)
+		}
+	case jsonArray:
+		if s.minItems != nil && len(value.Items) < *s.minItems {
+			report(value, pointer, "has %d items, fewer than the minimum of %d", len(value.Items), *s.minItems)
+		}
+		if s.maxItems != nil && len(value.Items) > *s.maxItems {
+			report(value, pointer, "has %d items, more than the maximum of %d", len(value.Items), *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range value.Items {
+				s.items.check(item, pointer+"/"+strconv.Itoa(i), violations)
+			}
+		}
+	case jsonObject:
+		for _, name := range s.required {
+			if value.field(name) == nil {
+				report(value, pointer, "missing required property %q", name)
+			}
+		}
+		for _, field := range value.Fields {
+			child := pointer + "/" + jsonPointerEscape(field.Key)
+			if schema := s.property(field.Key); schema != nil {
+				schema.check(field.Value, child, violations)
+			} else if s.noAdditional {
+				report(field.Value, child, "property %q is not allowed", field.Key)
+			}
+		}
+	}
+}
+
+func jsonPointerEscape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// jsonValuesEqual compares values the way JSON Schema does, with numbers
+// equal by value
+func jsonValuesEqual(a, b *jsonValue) bool {
+	if a.Kind != b.Kind {
+		return false
+	}
+	switch a.Kind {
+	case jsonNumber:
+		x, _ := strconv.ParseFloat(a.Text, 64)
+		y, _ := strconv.ParseFloat(b.Text, 64)
+		return x == y
+	case jsonString:
+		return a.Text == b.Text
+	case jsonBool:
+		return a.Bool == b.Bool
+	case jsonArray:
+		if len(a.Items) != len(b.Items) {
+			return false
+		}
+		for i := range a.Items {
+			if !jsonValuesEqual(a.Items[i], b.Items[i]) {
+				return false
+			}
+		}
+	case jsonObject:
+		if len(a.Fields) != len(b.Fields) {
+			return false
+		}
+		for _, field := range a.Fields {
+			other := b.field(field.Key)
+			if other == nil || !jsonValuesEqual(field.Value, other) {
+				return false
+			}
+		}
+	}
+	return true
+}
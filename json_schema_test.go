@@ -0,0 +1,124 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSONDocument(t *testing.T) {
+	document, err := parseJSONDocument([]byte("{\n  \"name\": \"Pinehurst\",\n  \"holes\": [\n    {\"par\": 4}, {\"par\": 3.0}\n  ],\n  \"ok\": true, \"é\": null\n}"), 1)
+	require.NoError(t, err)
+	assert.Equal(t, jsonObject, document.Kind)
+	assert.Equal(t, []int{1, 1}, []int{document.Line, document.Column})
+
+	name := document.field("name")
+	assert.Equal(t, "Pinehurst", name.Text)
+	assert.Equal(t, []int{2, 11}, []int{name.Line, name.Column})
+
+	holes := document.field("holes")
+	require.Len(t, holes.Items, 2)
+	assert.Equal(t, []int{4, 5}, []int{holes.Items[0].Line, holes.Items[0].Column})
+	assert.Equal(t, []int{4, 25}, []int{holes.Items[1].field("par").Line, holes.Items[1].field("par").Column})
+	assert.Equal(t, "3.0", holes.Items[1].field("par").Text, "numbers are kept as written")
+	assert.Equal(t, 20, document.field("é").Column, "columns count characters")
+
+	encoded, err := document.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"Pinehurst","holes":[{"par":4},{"par":3.0}],"ok":true,"é":null}`, string(encoded), "fields keep their order")
+
+	// NDJSON lines are parsed on their own but report their line in the file
+	line, err := parseJSONDocument([]byte(`{"name": "Mid Pines"}`), 7)
+	require.NoError(t, err)
+	assert.Equal(t, 7, line.field("name").Line)
+
+	for input, position := range map[string][]int{
+		"{\n  \"name\": \"Pinehurst\",\n  \"par\": 4,\n}": {3, 11}, // The trailing comma
+		"{\n  \"name\": \"Pinehurst\"\n  \"par\": 4\n}":   {3, 3},
+		"{\"name\": \"Pinehurst\"":                        {1, 21},
+		"{} {}":                                           {1, 4},
+	} {
+		_, err := parseJSONDocument([]byte(input), 1)
+		var syntax *jsonSyntaxError
+		require.ErrorAs(t, err, &syntax, input)
+		assert.Equal(t, position, []int{syntax.Line, syntax.Column}, input)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	compile := func(source string) *jsonSchema {
+		document, err := parseJSONDocument([]byte(source), 1)
+		require.NoError(t, err)
+		schema, err := compileJSONSchema(document)
+		require.NoError(t, err)
+		return schema
+	}
+	violations := func(schema *jsonSchema, source string) []string {
+		document, err := parseJSONDocument([]byte(source), 1)
+		require.NoError(t, err)
+		var messages []string
+		for _, violation := range schema.validate(document) {
+			messages = append(messages, violation.Pointer+": "+violation.Message)
+		}
+		return messages
+	}
+
+	schema := compile(`{
+		"type": "object",
+		"required": ["name", "par"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 2, "maxLength": 5, "pattern": "^[A-Z]"},
+			"par": {"type": "integer", "minimum": 3, "maximum": 6},
+			"rating": {"type": ["number", "null"], "exclusiveMinimum": 0},
+			"grade": {"enum": ["A", "B", 1]},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+		}
+	}`)
+
+	assert.Empty(t, violations(schema, `{"name": "Links", "par": 4.0, "rating": null, "grade": 1.0, "tags": ["a"]}`))
+	assert.Equal(t, []string{
+		`: missing required property "par"`,
+		`/name: "linksss" does not match ^[A-Z]`,
+		`/name: is 7 characters, longer than the maximum of 5`,
+		`/rating: 0 must be greater than 0`,
+		`/grade: "C" is not one of "A", "B", 1`,
+		`/tags: has 3 items, more than the maximum of 2`,
+		`/tags/1: expected string, got number`,
+		`/extra: property "extra" is not allowed`,
+	}, violations(schema, `{"name": "linksss", "rating": 0, "grade": "C", "tags": ["a", 2, "c"], "extra": 1}`))
+	assert.Equal(t, []string{
+		`/par: expected integer, got number`,
+		`/name: expected string, got null`,
+	}, violations(schema, `{"par": 4.5, "name": null}`), "a value of the wrong type isn't checked further")
+	assert.Equal(t, []string{`: expected object, got array`}, violations(schema, `[]`))
+
+	document, err := parseJSONDocument([]byte(`{"type": "object", "oneOf": []}`), 1)
+	require.NoError(t, err)
+	_, err = compileJSONSchema(document)
+	assert.ErrorContains(t, err, `unsupported schema keyword "oneOf"`)
+}
+
+func TestLoadJSONSchema_Courses(t *testing.T) {
+	schema, err := loadJSONSchema(defaultCourseSchema)
+	require.NoError(t, err)
+
+	records, fileErr := readCourseRecords("courses/test_bath_no_show.json", "", schema)
+	require.Nil(t, fileErr)
+	require.Len(t, records, 1)
+
+	var problems []string
+	for _, violation := range records[0].Violations {
+		problems = append(problems, violation.String())
+	}
+	assert.Equal(t, []string{
+		`courses/test_bath_no_show.json:6:14: /ranks/price: "F" does not match ^\${1,4}$`,
+		`courses/test_bath_no_show.json:8:25: /ranks/hazardDifficulty: 20 is greater than the maximum of 5`,
+		`courses/test_bath_no_show.json:23:19: /scores/0/handicap: 99 is greater than the maximum of 54`,
+	}, problems)
+
+	records, fileErr = readCourseRecords("courses/stono_ferry_golf_course.json", "", schema)
+	require.Nil(t, fileErr)
+	assert.Empty(t, records[0].Violations)
+}
@@ -10,6 +10,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -41,6 +42,10 @@ func NewTemplates(viewsDir string) *Templates {
 		filepath.Join(viewsDir, "authentication.html"),
 		filepath.Join(viewsDir, "sidebar.html"),
 		filepath.Join(viewsDir, "review-course.html"),
+		filepath.Join(viewsDir, "profile.html"),
+		filepath.Join(viewsDir, "lists.html"),
+		filepath.Join(viewsDir, "outings.html"),
+		filepath.Join(viewsDir, "course-edits.html"),
 	}
 	
 	return &Templates{
@@ -180,6 +185,48 @@ func AddOwnershipContext(sessionService *SessionService) echo.MiddlewareFunc {
 	}
 }
 
+// RedirectMergedCourses sends API requests for a course that was merged into
+// another to the same path on the course that stayed. A course ID is any route
+// parameter right after a "courses" segment, whatever it is named, such as
+// :id in /courses/:id or :courseId in /lists/:id/courses/:courseId.
+func RedirectMergedCourses() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route := strings.Split(c.Path(), "/")
+			url := *c.Request().URL
+			segments := strings.Split(url.Path, "/")
+			if len(segments) != len(route) {
+				return next(c)
+			}
+
+			redirect := false
+			for _, name := range c.ParamNames() {
+				for i := 1; i < len(route); i++ {
+					if route[i] != ":"+name || route[i-1] != "courses" {
+						continue
+					}
+					courseID, err := strconv.ParseUint(c.Param(name), 10, 32)
+					if err != nil {
+						continue
+					}
+					target, err := NewCourseMergeService().ResolveCourseID(uint(courseID))
+					if err != nil || target == uint(courseID) {
+						continue
+					}
+					segments[i] = strconv.FormatUint(uint64(target), 10)
+					redirect = true
+				}
+			}
+			if !redirect {
+				return next(c)
+			}
+			url.Path = strings.Join(segments, "/")
+			url.RawPath = ""
+			return c.Redirect(http.StatusPermanentRedirect, url.RequestURI())
+		}
+	}
+}
+
 func main() {
 	// Determine environment
 	environment := os.Getenv("ENV")
@@ -193,6 +240,11 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Maintenance commands such as import-osm run instead of the server
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	// Setup logging based on configuration
 	setupLogging(cfg)
 
@@ -221,6 +273,28 @@ func main() {
 		if err := CreatePerformanceIndexes(); err != nil {
 			log.Printf("⚠️ Failed to create performance indexes: %v", err)
 		}
+
+		// Geocode new and moved courses in the background
+		InitGeocodingService()
+
+		// Time itinerary drives with the configured router
+		InitRoutingProvider()
+
+		// Copy rankings of courses that have no course_rankings row yet
+		go func() {
+			if synced, err := NewCourseFacetService().SyncMissing(); err != nil {
+				log.Printf("[FACETS] Backfill failed: %v", err)
+			} else if synced > 0 {
+				log.Printf("[FACETS] Backfilled rankings for %d courses", synced)
+			}
+		}()
+
+		// Catch up on review text analysis for anything imported or edited outside the app
+		go func() {
+			if _, err := NewReviewInsightService().RefreshAll(); err != nil {
+				log.Printf("⚠️ Review insight job failed: %v", err)
+			}
+		}()
 	}
 
 	// Initialize cache service
@@ -240,6 +314,44 @@ func main() {
 		log.Printf("⚠️ Cache health check failed: %v", err)
 	}
 
+	// Share live events with other instances when Redis is available
+	if cacheService.redis != nil && !cacheService.fallback {
+		if err := GetEventBus().UseRedis(context.Background(), cacheService.redis); err != nil {
+			log.Printf("⚠️ Live events will only reach clients of this instance: %v", err)
+		}
+	}
+
+	// Notify course creators and reviewers about what happens to their content
+	GetEventBus().Listen(NewNotificationService().HandleEvent)
+
+	// Email pending notifications as periodic digests, and outing invitations
+	// to people without an account
+	if sender, err := NewEmailSender(cfg.Email); err != nil {
+		log.Printf("⚠️ Notification emails disabled: %v", err)
+	} else if sender != nil {
+		if cfg.Email.DigestInterval > 0 {
+			digestService, err := NewDigestService(sender, filepath.Join(cfg.Paths.ViewsDir, "email"), cfg.Email.BaseURL)
+			if err != nil {
+				log.Printf("⚠️ Notification emails disabled: %v", err)
+			} else {
+				go digestService.Run(context.Background(), cfg.Email.DigestInterval)
+				log.Printf("✅ Sending notification digests every %s via %s", cfg.Email.DigestInterval, cfg.Email.Sender)
+			}
+		}
+
+		if outingMailer, err := NewOutingMailer(sender, filepath.Join(cfg.Paths.ViewsDir, "email"), cfg.Email.BaseURL); err != nil {
+			log.Printf("⚠️ Outing invitation emails disabled: %v", err)
+		} else {
+			SetOutingMailer(outingMailer)
+		}
+	}
+
+	// Send suggested course edits nobody has answered to the admins
+	if cfg.CourseEdits.EscalateAfter > 0 {
+		go NewCourseEditService().RunEscalations(context.Background(), cfg.CourseEdits.EscalateAfter)
+		log.Printf("[COURSE_EDITS] Escalating suggested edits unanswered after %s", cfg.CourseEdits.EscalateAfter)
+	}
+
 	sessionService := NewSessionService()
 	handlers := NewHandlers()
 
@@ -311,8 +423,65 @@ func main() {
 	
 	// Create API group and register auth routes
 	apiGroup := e.Group("/api/v1")
+	apiGroup.Use(RedirectMergedCourses())
 	authHandler.RegisterRoutes(apiGroup, jwtService)
 
+	// Review comment routes
+	commentHandler := api.NewCommentHandler(apiDBService)
+	commentHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Course conditions routes
+	conditionsHandler := api.NewConditionsHandler(apiDBService)
+	conditionsHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Follow and activity feed routes
+	followHandler := api.NewFollowHandler(apiDBService)
+	followHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Notification inbox and preference routes
+	notificationHandler := api.NewNotificationHandler(apiDBService)
+	notificationHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Public profile and profile privacy routes
+	profileHandler := api.NewProfileHandler(apiDBService)
+	profileHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Want-to-play and named course list routes
+	listHandler := api.NewListHandler(apiDBService)
+	listHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Outing, RSVP and outing calendar routes
+	outingHandler := api.NewOutingHandler(apiDBService)
+	outingHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Search typeahead routes
+	searchHandler := api.NewSearchHandler(apiDBService)
+	searchHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Nearby, nearest and map bounds course queries
+	geoHandler := api.NewGeoHandler(apiDBService)
+	geoHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Road-trip itinerary planning, saving and sharing routes
+	itineraryHandler := api.NewItineraryHandler(apiDBService)
+	itineraryHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Hole layouts: tees, greens, fairways and hazards
+	holeGeometryHandler := api.NewHoleGeometryHandler(apiDBService)
+	holeGeometryHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// GPS yardages and shot tracking from the hole layouts
+	yardageHandler := api.NewYardageHandler(apiDBService)
+	yardageHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Suggested course edits and the review queue
+	courseEditHandler := api.NewCourseEditHandler(apiDBService)
+	courseEditHandler.RegisterRoutes(apiGroup, jwtService)
+
+	// Live event streams
+	apiGroup.GET("/events", handlers.EventStream, api.JWTMiddleware(jwtService))
+	apiGroup.GET("/events/ws", handlers.EventSocket, api.JWTMiddleware(jwtService))
+
 	// Auth handlers
 	authHandlers := NewAuthHandlers()
 
@@ -339,11 +508,13 @@ func main() {
 	e.POST("/profile/handicap", handlers.UpdateHandicap, RequireAuth(sessionService))
 	e.POST("/profile/display-name", handlers.UpdateDisplayName, RequireAuth(sessionService))
 	e.POST("/profile/add-score", handlers.AddScore, RequireAuth(sessionService))
+	e.POST("/profile/privacy", handlers.UpdateProfilePrivacy, RequireAuth(sessionService))
 	e.GET("/course/:id", handlers.GetCourse, AddOwnershipContext(sessionService))
 	e.GET("/review-landing", handlers.CreateCourseForm, RequireAuth(sessionService))
 	e.GET("/review-course/:id", handlers.ReviewSpecificCourseForm, RequireAuth(sessionService))
 	e.POST("/create-course", handlers.CreateCourse, RequireAuth(sessionService))
 	e.GET("/map", handlers.Map, AddOwnershipContext(sessionService))
+	e.GET("/tiles/:z/:x/:file", handlers.CourseTile)
 
 	// Protected edit routes with ownership verification
 	e.GET("/edit-course/:id", handlers.EditCourseForm, RequireOwnership(sessionService))
@@ -353,6 +524,76 @@ func main() {
 	// Review management routes
 	e.DELETE("/delete-review/:id", handlers.DeleteReview, RequireAuth(sessionService))
 
+	// Review discussion routes
+	e.GET("/course/:id/discussion", handlers.CourseDiscussion, AddOwnershipContext(sessionService))
+	e.POST("/course/:id/reviews/:reviewId/comments", handlers.PostReviewComment, RequireAuth(sessionService))
+	e.DELETE("/course/:id/comments/:commentId", handlers.DeleteReviewComment, RequireAuth(sessionService))
+	e.POST("/course/:id/comments/:commentId/status", handlers.ModerateReviewComment, RequireAuth(sessionService))
+	e.POST("/course/:id/reviews/:reviewId/helpful", handlers.MarkReviewHelpful, RequireAuth(sessionService))
+	e.DELETE("/course/:id/reviews/:reviewId/helpful", handlers.UnmarkReviewHelpful, RequireAuth(sessionService))
+
+	// Conditions report routes
+	e.GET("/course/:id/conditions", handlers.CourseConditions, AddOwnershipContext(sessionService))
+	e.POST("/course/:id/conditions", handlers.PostConditionsReport, RequireAuth(sessionService))
+	e.DELETE("/course/:id/conditions/:reportId", handlers.DeleteConditionsReport, RequireAuth(sessionService))
+
+	// Suggested course edit routes
+	e.GET("/course/:id/edits", handlers.CourseEdits, AddOwnershipContext(sessionService))
+	e.POST("/course/:id/edits", handlers.SuggestCourseEdit, RequireAuth(sessionService))
+	e.POST("/course/:id/edits/:editId/accept", handlers.AcceptCourseEditOnCourse, RequireAuth(sessionService))
+	e.POST("/course/:id/edits/:editId/reject", handlers.RejectCourseEditOnCourse, RequireAuth(sessionService))
+	e.DELETE("/course/:id/edits/:editId", handlers.WithdrawCourseEditOnCourse, RequireAuth(sessionService))
+	e.GET("/course-edits", handlers.CourseEditQueue, RequireAuth(sessionService))
+	e.POST("/course-edits/:id/accept", handlers.AcceptCourseEdit, RequireAuth(sessionService))
+	e.POST("/course-edits/:id/reject", handlers.RejectCourseEdit, RequireAuth(sessionService))
+	e.DELETE("/course-edits/:id", handlers.WithdrawCourseEdit, RequireAuth(sessionService))
+
+	// Course list routes
+	e.GET("/course/:id/lists", handlers.CourseListButtons, AddOwnershipContext(sessionService))
+	e.POST("/course/:id/lists", handlers.AddCourseToList, RequireAuth(sessionService))
+	e.POST("/course/:id/want-to-play", handlers.AddCourseToWantToPlay, RequireAuth(sessionService))
+	e.DELETE("/course/:id/want-to-play", handlers.RemoveCourseFromWantToPlay, RequireAuth(sessionService))
+	e.GET("/lists", handlers.CourseLists, RequireAuth(sessionService))
+	e.POST("/lists", handlers.CreateCourseList, RequireAuth(sessionService))
+	e.GET("/lists/shared/:token", handlers.SharedCourseList, AddOwnershipContext(sessionService))
+	e.DELETE("/lists/:id", handlers.DeleteCourseList, RequireAuth(sessionService))
+	e.POST("/lists/:id/share", handlers.ShareCourseList, RequireAuth(sessionService))
+	e.DELETE("/lists/:id/share", handlers.UnshareCourseList, RequireAuth(sessionService))
+	e.POST("/lists/:id/courses/:courseId/move", handlers.MoveCourseListCourse, RequireAuth(sessionService))
+	e.DELETE("/lists/:id/courses/:courseId", handlers.RemoveCourseListCourse, RequireAuth(sessionService))
+
+	// Outing routes. RSVP links and calendar feeds carry their own tokens, so
+	// they work without signing in.
+	e.GET("/outings", handlers.Outings, RequireAuth(sessionService))
+	e.POST("/outings", handlers.CreateOuting, RequireAuth(sessionService))
+	e.GET("/outings/calendar/:file", handlers.OutingCalendarFeed)
+	e.GET("/outings/rsvp/:token", handlers.GuestOuting)
+	e.POST("/outings/rsvp/:token", handlers.GuestRSVP)
+	e.GET("/outings/rsvp/:token/calendar.ics", handlers.GuestOutingCalendar)
+	e.GET("/outings/:id", handlers.OutingDetail, RequireAuth(sessionService))
+	e.DELETE("/outings/:id", handlers.CancelOuting, RequireAuth(sessionService))
+	e.POST("/outings/:id/edit", handlers.UpdateOuting, RequireAuth(sessionService))
+	e.POST("/outings/:id/invites", handlers.InviteToOuting, RequireAuth(sessionService))
+	e.POST("/outings/:id/rsvp", handlers.RSVPOuting, RequireAuth(sessionService))
+	e.POST("/outings/:id/score", handlers.LinkOutingScore, RequireAuth(sessionService))
+	e.GET("/outings/:id/calendar.ics", handlers.OutingCalendar, RequireAuth(sessionService))
+
+	// Follow and activity feed routes
+	e.GET("/feed", handlers.ActivityFeed, RequireAuth(sessionService))
+	e.GET("/users/:id", handlers.PublicProfile, AddOwnershipContext(sessionService))
+	e.POST("/users/:id/follow", handlers.FollowUser, RequireAuth(sessionService))
+	e.DELETE("/users/:id/follow", handlers.UnfollowUser, RequireAuth(sessionService))
+
+	// Notification routes
+	e.GET("/notifications", handlers.Notifications, RequireAuth(sessionService))
+	e.POST("/notifications/read-all", handlers.MarkAllNotificationsRead, RequireAuth(sessionService))
+	e.POST("/notifications/preferences", handlers.UpdateNotificationPreferences, RequireAuth(sessionService))
+	e.POST("/notifications/:id/read", handlers.MarkNotificationRead, RequireAuth(sessionService))
+
+	// Live event streams
+	e.GET("/events", handlers.EventStream, RequireAuth(sessionService))
+	e.GET("/events/ws", handlers.EventSocket, RequireAuth(sessionService))
+
 	// API routes
 	e.GET("/api/status/database", handlers.DatabaseStatus)
 	e.POST("/api/migrate/courses", handlers.MigrateCourses)
@@ -0,0 +1,15 @@
+-- Migration: Widen the handicap difficulty check on course rankings
+-- Date: 2026-10-18
+-- Description: Course data rates handicap difficulty from 1 to 20 (see courses/schema.json),
+-- but course_rankings only accepted 1 to 10, so harder courses couldn't be copied in
+
+ALTER TABLE course_rankings DROP CONSTRAINT IF EXISTS course_rankings_handicap_difficulty_check;
+ALTER TABLE course_rankings ADD CONSTRAINT course_rankings_handicap_difficulty_check CHECK (handicap_difficulty BETWEEN 1 AND 20);
+
+-- ===================================================================
+-- ROLLBACK INSTRUCTIONS
+-- ===================================================================
+
+-- To rollback this migration (fails while any course is rated above 10):
+-- ALTER TABLE course_rankings DROP CONSTRAINT course_rankings_handicap_difficulty_check;
+-- ALTER TABLE course_rankings ADD CONSTRAINT course_rankings_handicap_difficulty_check CHECK (handicap_difficulty BETWEEN 1 AND 10);
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"course_management/config"
+
+	"gorm.io/gorm"
+)
+
+// EmailMessage is a single email with plain text and HTML bodies
+type EmailMessage struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []EmailAttachment
+}
+
+// EmailAttachment is a file sent along with an email
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// EmailSender delivers emails
+type EmailSender interface {
+	Send(message EmailMessage) error
+}
+
+// SMTPEmailSender delivers emails through an SMTP server, upgrading to TLS
+// when the server supports it
+type SMTPEmailSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// WriterEmailSender writes emails to a file or stdout instead of sending
+// them, for local development
+type WriterEmailSender struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+// NewEmailSender builds the sender the configuration asks for. It returns nil
+// when email is turned off.
+func NewEmailSender(cfg config.EmailConfig) (EmailSender, error) {
+	switch cfg.Sender {
+	case "smtp":
+		return NewSMTPEmailSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "file":
+		return NewFileEmailSender(cfg.OutboxPath, cfg.From)
+	case "stdout":
+		return NewWriterEmailSender(os.Stdout, cfg.From), nil
+	case "", "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown email sender %q", cfg.Sender)
+	}
+}
+
+func NewSMTPEmailSender(host string, port int, username, password, from string) *SMTPEmailSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPEmailSender{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+		auth: auth,
+	}
+}
+
+func (s *SMTPEmailSender) Send(message EmailMessage) error {
+	from, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %v", err)
+	}
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %v", err)
+	}
+
+	data, err := message.encode(s.from)
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(s.addr, s.auth, from.Address, []string{to.Address}, data); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+	return nil
+}
+
+func NewWriterEmailSender(w io.Writer, from string) *WriterEmailSender {
+	if from == "" {
+		from = "Course Management <noreply@localhost>"
+	}
+	return &WriterEmailSender{w: w, from: from}
+}
+
+// NewFileEmailSender appends emails to the file at path, creating it if needed
+func NewFileEmailSender(path, from string) (*WriterEmailSender, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox: %v", err)
+	}
+	return NewWriterEmailSender(file, from), nil
+}
+
+func (s *WriterEmailSender) Send(message EmailMessage) error {
+	data, err := message.encode(s.from)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := fmt.Fprintf(s.w, "%s\r\n\r\n", data); err != nil {
+		return fmt.Errorf("failed to write email: %v", err)
+	}
+	return nil
+}
+
+// encode renders the message as a multipart/alternative MIME email, wrapped
+// in multipart/mixed when it has attachments
+func (m EmailMessage) encode(from string) ([]byte, error) {
+	for _, header := range []string{from, m.To, m.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, errors.New("email headers can't contain line breaks")
+		}
+	}
+
+	body, contentType, err := m.encodeBodies()
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Attachments) > 0 {
+		body, contentType, err = m.encodeAttachments(body, contentType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", m.To)
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: %s\r\n\r\n", contentType)
+	message.Write(body)
+	return message.Bytes(), nil
+}
+
+// encodeBodies renders the text and HTML bodies as a multipart/alternative
+// part and returns it with its content type
+func (m EmailMessage) encodeBodies() ([]byte, string, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to build email: %v", err)
+		}
+		encoder := quotedprintable.NewWriter(w)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, "", fmt.Errorf("failed to build email: %v", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, "", fmt.Errorf("failed to build email: %v", err)
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to build email: %v", err)
+	}
+	return body.Bytes(), "multipart/alternative; boundary=" + parts.Boundary(), nil
+}
+
+// encodeAttachments wraps the bodies and the message's attachments in a
+// multipart/mixed part and returns it with its content type
+func (m EmailMessage) encodeAttachments(bodies []byte, bodiesType string) ([]byte, string, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	w, err := parts.CreatePart(textproto.MIMEHeader{"Content-Type": {bodiesType}})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build email: %v", err)
+	}
+	if _, err := w.Write(bodies); err != nil {
+		return nil, "", fmt.Errorf("failed to build email: %v", err)
+	}
+
+	for _, attachment := range m.Attachments {
+		if strings.ContainsAny(attachment.Filename+attachment.ContentType, "\r\n\"") {
+			return nil, "", errors.New("attachment names and types can't contain line breaks or quotes")
+		}
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Disposition":       {fmt.Sprintf(`attachment; filename="%s"`, attachment.Filename)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to build email: %v", err)
+		}
+		if err := writeBase64Lines(w, attachment.Content); err != nil {
+			return nil, "", fmt.Errorf("failed to build email: %v", err)
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to build email: %v", err)
+	}
+	return body.Bytes(), "multipart/mixed; boundary=" + parts.Boundary(), nil
+}
+
+// writeBase64Lines base64-encodes content in lines of 76 characters, the
+// longest MIME allows
+func writeBase64Lines(w io.Writer, content []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
+
+// DigestService batches each user's pending email notifications into a
+// single digest email
+type DigestService struct {
+	db      *gorm.DB
+	sender  EmailSender
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+	baseURL string
+}
+
+// DigestEmailData is the view model for the digest email templates
+type DigestEmailData struct {
+	Name          string
+	Notifications []DigestEmailItem
+	InboxURL      string
+}
+
+// DigestEmailItem is one notification in a digest
+type DigestEmailItem struct {
+	Message  string
+	PostedOn string
+}
+
+// NewDigestService loads the digest.html and digest.txt templates from
+// templatesDir. Links in the emails point at baseURL.
+func NewDigestService(sender EmailSender, templatesDir, baseURL string) (*DigestService, error) {
+	html, err := htmltemplate.ParseFiles(filepath.Join(templatesDir, "digest.html"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load digest template: %v", err)
+	}
+	text, err := texttemplate.ParseFiles(filepath.Join(templatesDir, "digest.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load digest template: %v", err)
+	}
+
+	return &DigestService{
+		db:      GetDB(),
+		sender:  sender,
+		html:    html,
+		text:    text,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+// Run sends digests every interval until ctx is cancelled. Only one instance
+// should run digests, or users get one email per instance.
+func (d *DigestService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := d.SendDigests()
+			if err != nil {
+				log.Printf("⚠️ Some notification digests failed: %v", err)
+			}
+			if sent > 0 {
+				log.Printf("✅ Sent %d notification digests", sent)
+			}
+		}
+	}
+}
+
+// SendDigests emails every user with pending notifications one digest of
+// them and returns how many digests were sent. A failure for one user doesn't
+// stop the others; their notifications stay pending for the next run.
+func (d *DigestService) SendDigests() (int, error) {
+	if d.db == nil {
+		return 0, fmt.Errorf("database not connected")
+	}
+
+	var userIDs []uint
+	if err := d.db.Model(&Notification{}).Where("email_pending = ?", true).Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+		return 0, fmt.Errorf("failed to find pending notifications: %v", err)
+	}
+
+	sent := 0
+	var errs []error
+	for _, userID := range userIDs {
+		ok, err := d.sendDigest(userID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %d: %w", userID, err))
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+func (d *DigestService) sendDigest(userID uint) (bool, error) {
+	var user User
+	if err := d.db.Select("id", "email", "name", "display_name").First(&user, userID).Error; err != nil {
+		return false, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	var notifications []Notification
+	if err := d.db.Where("user_id = ? AND email_pending = ?", userID, true).
+		Order("created_at ASC, id ASC").
+		Find(&notifications).Error; err != nil {
+		return false, fmt.Errorf("failed to get notifications: %v", err)
+	}
+	if len(notifications) == 0 {
+		return false, nil
+	}
+
+	ids := make([]uint, 0, len(notifications))
+	for _, notification := range notifications {
+		ids = append(ids, notification.ID)
+	}
+
+	if user.Email != "" {
+		message, err := d.render(user, notifications)
+		if err != nil {
+			return false, err
+		}
+		if err := d.sender.Send(message); err != nil {
+			return false, err
+		}
+	}
+
+	// Users without an address have nothing to wait for either
+	if err := d.db.Model(&Notification{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"email_pending": false, "emailed_at": time.Now().Unix()}).Error; err != nil {
+		return false, fmt.Errorf("failed to mark notifications emailed: %v", err)
+	}
+	return user.Email != "", nil
+}
+
+func (d *DigestService) render(user User, notifications []Notification) (EmailMessage, error) {
+	data := DigestEmailData{
+		Name:     user.Name,
+		InboxURL: d.baseURL + "/#notifications",
+	}
+	if user.DisplayName != nil && *user.DisplayName != "" {
+		data.Name = *user.DisplayName
+	}
+	now := time.Now()
+	for _, notification := range notifications {
+		data.Notifications = append(data.Notifications, DigestEmailItem{
+			Message:  notification.Message,
+			PostedOn: formatTimeAgo(now, notification.CreatedAt),
+		})
+	}
+
+	var html, text bytes.Buffer
+	if err := d.html.Execute(&html, data); err != nil {
+		return EmailMessage{}, fmt.Errorf("failed to render digest: %v", err)
+	}
+	if err := d.text.Execute(&text, data); err != nil {
+		return EmailMessage{}, fmt.Errorf("failed to render digest: %v", err)
+	}
+
+	subject := "You have 1 new notification"
+	if len(notifications) > 1 {
+		subject = fmt.Sprintf("You have %d new notifications", len(notifications))
+	}
+	return EmailMessage{
+		To:      user.Email,
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
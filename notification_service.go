@@ -0,0 +1,325 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"course_management/api"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// notificationTypeLabels describe each notification type in the preferences form
+var notificationTypeLabels = map[string]string{
+	"course_reviewed":       "Someone reviews a course you added",
+	"course_edited":         "Someone edits a course you added",
+	"course_edit_suggested": "Someone suggests an edit for you to review",
+	"course_edit_answered":  "Your suggested course edit is accepted or rejected",
+	"review_helpful":        "Someone finds your review helpful",
+	"outing_invite":         "Someone invites you to an outing",
+	"outing_update":         "An outing you're invited to changes or you get a spot",
+}
+
+// NotificationService turns events on the event bus into notifications for
+// the users they concern, and manages each user's inbox and preferences
+type NotificationService struct {
+	db *gorm.DB
+}
+
+// NotificationSetting is a user's delivery choice for one notification type
+type NotificationSetting struct {
+	Type  string
+	Label string
+	InApp bool
+	Email bool
+}
+
+func NewNotificationService() *NotificationService {
+	return &NotificationService{
+		db: GetDB(),
+	}
+}
+
+// HandleEvent creates the notifications an event calls for. It is meant to be
+// registered with EventBus.Listen; failures are logged, never returned to the
+// action that published the event.
+func (s *NotificationService) HandleEvent(event LiveEvent) {
+	if s.db == nil {
+		return
+	}
+
+	var err error
+	switch event.Type {
+	case "course_review":
+		err = s.notifyCourseReviewed(event)
+	case "course_updated":
+		err = s.notifyCourseEdited(event)
+	case "review_helpful":
+		err = s.notifyReviewHelpful(event)
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to notify about %s event: %v", event.Type, err)
+	}
+}
+
+func (s *NotificationService) notifyCourseReviewed(event LiveEvent) error {
+	course, err := s.courseForEvent(event)
+	if course == nil || err != nil {
+		return err
+	}
+	if course.CreatedBy == nil || *course.CreatedBy == event.UserID {
+		return nil
+	}
+
+	var review CourseReview
+	if err := s.db.Select("visibility").Where("user_id = ? AND course_id = ?", event.UserID, course.ID).First(&review).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get review: %v", err)
+	}
+
+	actorID := &event.UserID
+	actorName := s.actorName(event.UserID)
+	switch review.Visibility {
+	case ReviewVisibilityPrivate:
+		return nil // Notes for the reviewer alone
+	case ReviewVisibilityAnonymous:
+		actorID, actorName = nil, "Someone"
+	}
+
+	return s.notify(*course.CreatedBy, "course_reviewed", actorID, &course.ID,
+		fmt.Sprintf("%s reviewed %s", actorName, course.Name))
+}
+
+func (s *NotificationService) notifyCourseEdited(event LiveEvent) error {
+	course, err := s.courseForEvent(event)
+	if course == nil || err != nil {
+		return err
+	}
+	if course.CreatedBy == nil || *course.CreatedBy == event.UserID {
+		return nil
+	}
+
+	return s.notify(*course.CreatedBy, "course_edited", &event.UserID, &course.ID,
+		fmt.Sprintf("%s edited %s", s.actorName(event.UserID), course.Name))
+}
+
+func (s *NotificationService) notifyReviewHelpful(event LiveEvent) error {
+	if event.TargetUserID == nil || *event.TargetUserID == event.UserID {
+		return nil
+	}
+	course, err := s.courseForEvent(event)
+	if course == nil || err != nil {
+		return err
+	}
+
+	return s.notify(*event.TargetUserID, "review_helpful", &event.UserID, &course.ID,
+		fmt.Sprintf("%s found your review of %s helpful", s.actorName(event.UserID), course.Name))
+}
+
+// notify delivers a notification the way the recipient asked for that type.
+// Inbox notifications are also pushed to the recipient's open event streams.
+func (s *NotificationService) notify(recipientID uint, notificationType string, actorID, courseID *uint, message string) error {
+	settings, err := s.GetSettings(recipientID)
+	if err != nil {
+		return err
+	}
+
+	var setting NotificationSetting
+	for _, candidate := range settings {
+		if candidate.Type == notificationType {
+			setting = candidate
+		}
+	}
+	if !setting.InApp && !setting.Email {
+		return nil
+	}
+
+	notification := Notification{
+		UserID:       recipientID,
+		Type:         notificationType,
+		ActorID:      actorID,
+		CourseID:     courseID,
+		Message:      message,
+		InApp:        setting.InApp,
+		EmailPending: setting.Email,
+	}
+	if err := s.db.Create(&notification).Error; err != nil {
+		return fmt.Errorf("failed to create notification: %v", err)
+	}
+
+	if notification.InApp {
+		publishLiveEvent(s.db, LiveEvent{Type: "notification", UserID: recipientID, CourseID: courseID, Summary: message, Private: true})
+	}
+	return nil
+}
+
+// courseForEvent loads the course an event is about, or nil if it has none or
+// the course is gone
+func (s *NotificationService) courseForEvent(event LiveEvent) (*CourseDB, error) {
+	if event.CourseID == nil {
+		return nil, nil
+	}
+
+	var course CourseDB
+	if err := s.db.Select("id", "name", "created_by").First(&course, *event.CourseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get course: %v", err)
+	}
+	return &course, nil
+}
+
+func (s *NotificationService) actorName(userID uint) string {
+	return (&ReviewCommentService{db: s.db}).AuthorName(userID)
+}
+
+// GetNotifications returns a page of the user's inbox, newest first
+func (s *NotificationService) GetNotifications(userID uint, unreadOnly bool, page, perPage int) ([]Notification, int64, error) {
+	if s.db == nil {
+		return nil, 0, fmt.Errorf("database not connected")
+	}
+
+	query := s.inbox(userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %v", err)
+	}
+
+	var notifications []Notification
+	if err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get notifications: %v", err)
+	}
+	return notifications, total, nil
+}
+
+// CountUnread returns how many notifications in the user's inbox are unread
+func (s *NotificationService) CountUnread(userID uint) (int64, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not connected")
+	}
+
+	var count int64
+	if err := s.inbox(userID).Where("read_at IS NULL").Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %v", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks one of the user's notifications as read. Reading it also
+// keeps it out of the next email digest.
+func (s *NotificationService) MarkRead(userID, notificationID uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	var count int64
+	if err := s.inbox(userID).Where("id = ?", notificationID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to find notification: %v", err)
+	}
+	if count == 0 {
+		return ErrNotificationNotFound
+	}
+
+	if err := s.inbox(userID).Where("id = ? AND read_at IS NULL", notificationID).
+		Updates(map[string]interface{}{"read_at": time.Now().Unix(), "email_pending": false}).Error; err != nil {
+		return fmt.Errorf("failed to mark notification read: %v", err)
+	}
+	return nil
+}
+
+// MarkAllRead marks every notification in the user's inbox as read
+func (s *NotificationService) MarkAllRead(userID uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	if err := s.inbox(userID).Where("read_at IS NULL").
+		Updates(map[string]interface{}{"read_at": time.Now().Unix(), "email_pending": false}).Error; err != nil {
+		return fmt.Errorf("failed to mark notifications read: %v", err)
+	}
+	return nil
+}
+
+// GetSettings returns the user's delivery choice for every notification type.
+// Types the user never changed are delivered both ways.
+func (s *NotificationService) GetSettings(userID uint) ([]NotificationSetting, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var preferences []NotificationPreference
+	if err := s.db.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %v", err)
+	}
+	saved := make(map[string]NotificationPreference, len(preferences))
+	for _, preference := range preferences {
+		saved[preference.Type] = preference
+	}
+
+	settings := make([]NotificationSetting, 0, len(api.NotificationTypes))
+	for _, notificationType := range api.NotificationTypes {
+		setting := NotificationSetting{
+			Type:  notificationType,
+			Label: notificationTypeLabels[notificationType],
+			InApp: true,
+			Email: true,
+		}
+		if preference, ok := saved[notificationType]; ok {
+			setting.InApp = preference.InApp
+			setting.Email = preference.Email
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// UpdateSettings saves the user's delivery choices for the given types
+func (s *NotificationService) UpdateSettings(userID uint, settings []NotificationSetting) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	preferences := make([]NotificationPreference, 0, len(settings))
+	for _, setting := range settings {
+		if _, known := notificationTypeLabels[setting.Type]; !known {
+			return fmt.Errorf("unknown notification type %q", setting.Type)
+		}
+		preferences = append(preferences, NotificationPreference{
+			UserID: userID,
+			Type:   setting.Type,
+			InApp:  setting.InApp,
+			Email:  setting.Email,
+		})
+	}
+	if len(preferences) == 0 {
+		return nil
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "updated_at"}),
+	}).Create(&preferences).Error; err != nil {
+		return fmt.Errorf("failed to save notification preferences: %v", err)
+	}
+	return nil
+}
+
+// inbox scopes a query to the notifications shown in the user's inbox
+func (s *NotificationService) inbox(userID uint) *gorm.DB {
+	return s.db.Model(&Notification{}).Where("user_id = ? AND in_app = ?", userID, true)
+}
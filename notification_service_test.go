@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// listenForNotifications routes the event bus into a notification service for
+// the rest of the test, the way main wires it up
+func listenForNotifications(t *testing.T) {
+	t.Helper()
+	stop := GetEventBus().Listen(NewNotificationService().HandleEvent)
+	t.Cleanup(stop)
+}
+
+func notificationsFor(t *testing.T, db *gorm.DB, userID uint) []Notification {
+	t.Helper()
+	var notifications []Notification
+	require.NoError(t, db.Where("user_id = ?", userID).Order("id").Find(&notifications).Error)
+	return notifications
+}
+
+func TestNotificationService_CourseReviewed(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	listenForNotifications(t)
+	reviewService := NewReviewService()
+
+	_, err := reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{OverallRating: "B", ReviewText: "Nice layout"})
+	require.NoError(t, err)
+
+	notifications := notificationsFor(t, db, owner.ID)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "course_reviewed", notifications[0].Type)
+	assert.Equal(t, "Golfer reviewed Pebble Creek", notifications[0].Message)
+	require.NotNil(t, notifications[0].ActorID)
+	assert.Equal(t, golfer.ID, *notifications[0].ActorID)
+	assert.True(t, notifications[0].InApp)
+	assert.True(t, notifications[0].EmailPending)
+
+	t.Run("Anonymous reviews hide the reviewer", func(t *testing.T) {
+		stranger := seedUser(t, db, "stranger", "Stranger")
+
+		_, err := reviewService.CreateOrUpdateReview(stranger.ID, course.ID, ReviewFormData{OverallRating: "A", Visibility: ReviewVisibilityAnonymous})
+		require.NoError(t, err)
+
+		notifications := notificationsFor(t, db, owner.ID)
+		require.Len(t, notifications, 2)
+		assert.Equal(t, "Someone reviewed Pebble Creek", notifications[1].Message)
+		assert.Nil(t, notifications[1].ActorID)
+	})
+
+	t.Run("Private notes, edits and the owner's own review are not announced", func(t *testing.T) {
+		notes := seedUser(t, db, "notes", "Notes")
+
+		_, err := reviewService.CreateOrUpdateReview(notes.ID, course.ID, ReviewFormData{OverallRating: "A", Visibility: ReviewVisibilityPrivate})
+		require.NoError(t, err)
+		_, err = reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{OverallRating: "A", ReviewText: "Even better the second time"})
+		require.NoError(t, err)
+		_, err = reviewService.CreateOrUpdateReview(owner.ID, course.ID, ReviewFormData{OverallRating: "A"})
+		require.NoError(t, err)
+
+		assert.Len(t, notificationsFor(t, db, owner.ID), 2)
+	})
+}
+
+func TestNotificationService_CourseEdited(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewNotificationService()
+
+	service.HandleEvent(LiveEvent{Type: "course_updated", UserID: golfer.ID, CourseID: &course.ID})
+	service.HandleEvent(LiveEvent{Type: "course_updated", UserID: owner.ID, CourseID: &course.ID})
+
+	notifications := notificationsFor(t, db, owner.ID)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "course_edited", notifications[0].Type)
+	assert.Equal(t, "Golfer edited Pebble Creek", notifications[0].Message)
+}
+
+func TestNotificationService_ReviewHelpful(t *testing.T) {
+	db := setupTestDatabase(t)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+	review := seedReview(t, db, course.ID, reviewer.ID)
+	listenForNotifications(t)
+	reviewService := NewReviewService()
+
+	require.NoError(t, reviewService.SetReviewHelpful(golfer.ID, review.ID, true))
+	// Voting again changes nothing and doesn't notify twice
+	require.NoError(t, reviewService.SetReviewHelpful(golfer.ID, review.ID, true))
+	assert.ErrorIs(t, reviewService.SetReviewHelpful(reviewer.ID, review.ID, true), ErrOwnReviewHelpful)
+	assert.ErrorIs(t, reviewService.SetReviewHelpful(golfer.ID, 9999, true), ErrReviewNotFound)
+
+	notifications := notificationsFor(t, db, reviewer.ID)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "Golfer found your review of Pebble Creek helpful", notifications[0].Message)
+
+	counts, voted, err := reviewService.GetHelpfulVotes([]uint{review.ID}, &golfer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, counts[review.ID])
+	assert.True(t, voted[review.ID])
+
+	require.NoError(t, reviewService.SetReviewHelpful(golfer.ID, review.ID, false))
+	counts, voted, err = reviewService.GetHelpfulVotes([]uint{review.ID}, &golfer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, counts[review.ID])
+	assert.False(t, voted[review.ID])
+}
+
+func TestNotificationService_Preferences(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewNotificationService()
+
+	settings, err := service.GetSettings(owner.ID)
+	require.NoError(t, err)
+	require.Len(t, settings, 7)
+	for _, setting := range settings {
+		assert.True(t, setting.InApp, setting.Type)
+		assert.True(t, setting.Email, setting.Type)
+	}
+
+	require.NoError(t, service.UpdateSettings(owner.ID, []NotificationSetting{
+		{Type: "course_edited", InApp: false, Email: false},
+		{Type: "course_reviewed", InApp: false, Email: true},
+	}))
+	// Saving again updates rather than duplicates
+	require.NoError(t, service.UpdateSettings(owner.ID, []NotificationSetting{{Type: "course_reviewed", InApp: false, Email: true}}))
+	assert.Error(t, service.UpdateSettings(owner.ID, []NotificationSetting{{Type: "birthday", InApp: true}}))
+
+	service.HandleEvent(LiveEvent{Type: "course_updated", UserID: golfer.ID, CourseID: &course.ID})
+	assert.Empty(t, notificationsFor(t, db, owner.ID), "turned off types are dropped")
+
+	_, err = NewReviewService().CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{OverallRating: "B"})
+	require.NoError(t, err)
+	service.HandleEvent(LiveEvent{Type: "course_review", UserID: golfer.ID, CourseID: &course.ID})
+
+	notifications := notificationsFor(t, db, owner.ID)
+	require.Len(t, notifications, 1)
+	assert.False(t, notifications[0].InApp, "email-only notifications stay out of the inbox")
+	assert.True(t, notifications[0].EmailPending)
+
+	count, err := service.CountUnread(owner.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestNotificationService_Inbox(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewNotificationService()
+
+	for i := 0; i < 3; i++ {
+		service.HandleEvent(LiveEvent{Type: "course_updated", UserID: golfer.ID, CourseID: &course.ID})
+	}
+	notifications := notificationsFor(t, db, owner.ID)
+	require.Len(t, notifications, 3)
+
+	page, total, err := service.GetNotifications(owner.ID, false, 1, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	require.Len(t, page, 2)
+	assert.Equal(t, notifications[2].ID, page[0].ID, "newest first")
+
+	require.NoError(t, service.MarkRead(owner.ID, notifications[0].ID))
+	assert.ErrorIs(t, service.MarkRead(golfer.ID, notifications[1].ID), ErrNotificationNotFound)
+
+	unread, total, err := service.GetNotifications(owner.ID, true, 1, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.Len(t, unread, 2)
+
+	var read Notification
+	require.NoError(t, db.First(&read, notifications[0].ID).Error)
+	assert.NotNil(t, read.ReadAt)
+	assert.False(t, read.EmailPending, "read notifications are left out of the digest")
+
+	require.NoError(t, service.MarkAllRead(owner.ID))
+	count, err := service.CountUnread(owner.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestDigestService_SendDigests(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewNotificationService()
+
+	service.HandleEvent(LiveEvent{Type: "course_updated", UserID: golfer.ID, CourseID: &course.ID})
+	service.HandleEvent(LiveEvent{Type: "review_helpful", UserID: golfer.ID, CourseID: &course.ID, TargetUserID: &owner.ID})
+	service.HandleEvent(LiveEvent{Type: "review_helpful", UserID: golfer.ID, CourseID: &course.ID, TargetUserID: &reviewer.ID})
+	require.NoError(t, service.MarkAllRead(reviewer.ID))
+
+	var outbox bytes.Buffer
+	digest, err := NewDigestService(NewWriterEmailSender(&outbox, "Course Management <noreply@example.com>"), "views/email", "https://golf.example.com/")
+	require.NoError(t, err)
+
+	sent, err := digest.SendDigests()
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "the reviewer already read theirs in the app")
+
+	email := outbox.String()
+	assert.Contains(t, email, "To: owner@example.com")
+	assert.Contains(t, email, "Subject: You have 2 new notifications")
+	assert.Contains(t, email, "Golfer edited Pebble Creek")
+	assert.Contains(t, email, "https://golf.example.com/#notifications")
+	assert.NotContains(t, email, "reviewer@example.com")
+
+	var pending int64
+	require.NoError(t, db.Model(&Notification{}).Where("email_pending = ?", true).Count(&pending).Error)
+	assert.Zero(t, pending)
+
+	sent, err = digest.SendDigests()
+	require.NoError(t, err)
+	assert.Zero(t, sent, "each notification is emailed once")
+}
+
+func TestEmailMessage_RejectsHeaderInjection(t *testing.T) {
+	_, err := EmailMessage{To: "owner@example.com\r\nBcc: everyone@example.com", Subject: "Hi"}.encode("noreply@example.com")
+	assert.Error(t, err)
+}
+
+func TestEmailMessage_Attachments(t *testing.T) {
+	data, err := EmailMessage{
+		To:      "owner@example.com",
+		Subject: "Outing",
+		Text:    "See you there",
+		HTML:    "<p>See you there</p>",
+		Attachments: []EmailAttachment{
+			{Filename: "outing.ics", ContentType: "text/calendar; charset=utf-8; method=PUBLISH", Content: []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")},
+		},
+	}.encode("noreply@example.com")
+	require.NoError(t, err)
+
+	email := string(data)
+	assert.Contains(t, email, "Content-Type: multipart/mixed; boundary=")
+	assert.Contains(t, email, "Content-Type: multipart/alternative; boundary=")
+	assert.Contains(t, email, `Content-Disposition: attachment; filename="outing.ics"`)
+	assert.Contains(t, email, "QkVHSU46VkNBTEVOREFSDQpFTkQ6VkNBTEVOREFSDQo=")
+	assert.Contains(t, email, "See you there")
+
+	_, err = EmailMessage{To: "owner@example.com", Attachments: []EmailAttachment{{Filename: "a\r\n.ics"}}}.encode("noreply@example.com")
+	assert.Error(t, err)
+}
@@ -0,0 +1,587 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"course_management/services"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Any existing course this close to an OSM course is taken to be the same
+	// one, whatever either is called
+	osmDuplicateMeters = 300.0
+	// A course with the same name this close is the same one mapped with a
+	// different address or centre
+	osmSameNameKm = 5.0
+	// osmDedupeBand is the latitude band, in degrees, the dedupe index buckets
+	// courses by; it's wider than osmSameNameKm
+	osmDedupeBand = 0.05
+
+	osmCourseSource = "openstreetmap"
+)
+
+// errOSMPassDone stops a read of the extract once the elements a pass needs
+// are behind it
+var errOSMPassDone = errors.New("osm pass done")
+
+// OSMCourse is a golf course found in an OSM extract
+type OSMCourse struct {
+	OSMID    string // The element it came from, such as "way/123"
+	Name     string
+	Address  string
+	Website  string
+	Phone    string
+	Location GeoPoint
+}
+
+// OSMCourseDuplicate is an OSM course that matches one already known
+type OSMCourseDuplicate struct {
+	Course OSMCourse
+	// MatchID is the existing course, or zero when the match is an earlier
+	// course in the same extract
+	MatchID   uint
+	MatchName string
+	// Reason is "hash" for the same name and address, "name" for the same
+	// name nearby and "nearby" for any course within osmDuplicateMeters
+	Reason         string
+	DistanceMeters float64
+}
+
+// OSMCourseSkip is a golf course feature that can't be imported
+type OSMCourseSkip struct {
+	OSMID  string
+	Reason string
+}
+
+// OSMImportReport is what importing an extract would do, or did once applied
+type OSMImportReport struct {
+	Extract    string
+	Found      int
+	New        []OSMCourse
+	Duplicates []OSMCourseDuplicate
+	Skipped    []OSMCourseSkip
+	Applied    bool
+	Created    int
+}
+
+// OSMCourseImporter creates courses from the golf courses in OSM extracts
+type OSMCourseImporter struct {
+	db *gorm.DB
+}
+
+func NewOSMCourseImporter() *OSMCourseImporter {
+	return &OSMCourseImporter{db: GetDB()}
+}
+
+// Plan reads an extract and sorts its golf courses into new ones, duplicates
+// of existing courses and features that can't be imported. Nothing is written.
+func (s *OSMCourseImporter) Plan(path string) (*OSMImportReport, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	courses, skipped, err := readOSMGolfCourses(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []CourseDB
+	if err := s.db.Select("id", "name", "address", "hash", "latitude", "longitude").Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to load courses: %v", err)
+	}
+	index := newOSMCourseIndex(existing)
+	var redirects []CourseRedirect
+	if err := s.db.Find(&redirects).Error; err != nil {
+		return nil, fmt.Errorf("failed to load course redirects: %v", err)
+	}
+	index.addRedirects(redirects)
+
+	report := &OSMImportReport{Extract: path, Found: len(courses) + len(skipped), Skipped: skipped}
+	for _, course := range courses {
+		if duplicate := index.match(course); duplicate != nil {
+			report.Duplicates = append(report.Duplicates, *duplicate)
+			continue
+		}
+		report.New = append(report.New, course)
+		index.add(osmIndexedCourse{name: course.Name, hash: GenerateCourseHash(course.Name, course.Address), location: &course.Location, osmID: course.OSMID})
+	}
+	return report, nil
+}
+
+// Apply creates the report's new courses in one transaction, attributed to
+// createdBy when it's set
+func (s *OSMCourseImporter) Apply(report *OSMImportReport, createdBy *uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	created := make([]CourseDB, 0, len(report.New))
+	parsed := make([]Course, 0, len(report.New))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, course := range report.New {
+			latitude, longitude := course.Location.Latitude, course.Location.Longitude
+			data, err := json.Marshal(map[string]interface{}{
+				"name":      course.Name,
+				"address":   course.Address,
+				"latitude":  latitude,
+				"longitude": longitude,
+				"website":   course.Website,
+				"phone":     course.Phone,
+				"source":    osmCourseSource,
+				"osm_id":    course.OSMID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to encode %s: %v", course.OSMID, err)
+			}
+
+			row := CourseDB{
+				Name:       course.Name,
+				Address:    course.Address,
+				CourseData: string(data),
+				CreatedBy:  createdBy,
+				UpdatedBy:  createdBy,
+				Latitude:   &latitude,
+				Longitude:  &longitude,
+			}
+			if err := tx.Create(&row).Error; err != nil {
+				return fmt.Errorf("failed to create %s (%s): %v", course.Name, course.OSMID, err)
+			}
+			created = append(created, row)
+			parsed = append(parsed, Course{Name: course.Name, Address: course.Address, Latitude: &latitude, Longitude: &longitude})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, row := range created {
+		syncCourseFacets(s.db, row.ID, parsed[i])
+	}
+	invalidateCourseTiles(s.db)
+	invalidateCourseClusters(s.db)
+
+	report.Applied = true
+	report.Created = len(created)
+	log.Printf("[OSM] Created %d courses from %s", len(created), report.Extract)
+	return nil
+}
+
+// osmCourseCandidate is a golf course element waiting for its geometry
+type osmCourseCandidate struct {
+	element osmElement
+	ways    []int64 // Outer member ways of a relation
+}
+
+// readOSMGolfCourses finds the leisure=golf_course features in an extract.
+// Extracts are too large to hold, so it reads the file up to three times:
+// for the courses, for the member ways of course relations, then for the
+// nodes the course outlines need.
+func readOSMGolfCourses(path string) ([]OSMCourse, []OSMCourseSkip, error) {
+	var candidates []osmCourseCandidate
+	memberWays := make(map[int64][]int64)
+	err := readOSMExtract(path, func(element osmElement) error {
+		if element.Tags["leisure"] != "golf_course" {
+			return nil
+		}
+		candidate := osmCourseCandidate{element: element}
+		if element.Type == "relation" {
+			for _, member := range element.Members {
+				if member.Type == "way" && (member.Role == "outer" || member.Role == "") {
+					candidate.ways = append(candidate.ways, member.Ref)
+					memberWays[member.Ref] = nil
+				}
+			}
+		}
+		candidates = append(candidates, candidate)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(memberWays) > 0 {
+		err := readOSMExtract(path, func(element osmElement) error {
+			switch element.Type {
+			case "way":
+				if _, ok := memberWays[element.ID]; ok {
+					memberWays[element.ID] = element.Refs
+				}
+			case "relation":
+				return errOSMPassDone
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errOSMPassDone) {
+			return nil, nil, err
+		}
+	}
+
+	nodes := make(map[int64]*GeoPoint)
+	for _, candidate := range candidates {
+		for _, ref := range candidate.element.Refs {
+			nodes[ref] = nil
+		}
+		for _, way := range candidate.ways {
+			for _, ref := range memberWays[way] {
+				nodes[ref] = nil
+			}
+		}
+	}
+	if len(nodes) > 0 {
+		err := readOSMExtract(path, func(element osmElement) error {
+			if element.Type != "node" {
+				return errOSMPassDone
+			}
+			if _, ok := nodes[element.ID]; ok {
+				point := element.Point
+				nodes[element.ID] = &point
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errOSMPassDone) {
+			return nil, nil, err
+		}
+	}
+
+	// Outlined courses come first so that where a course is mapped twice the
+	// outline, with its better centre, is the one kept
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].element.Type != "node" && candidates[j].element.Type == "node"
+	})
+
+	var courses []OSMCourse
+	var skipped []OSMCourseSkip
+	for _, candidate := range candidates {
+		element := candidate.element
+		osmID := element.Type + "/" + strconv.FormatInt(element.ID, 10)
+		name := strings.TrimSpace(element.Tags["name"])
+		if name == "" {
+			skipped = append(skipped, OSMCourseSkip{OSMID: osmID, Reason: "no name"})
+			continue
+		}
+
+		var location *GeoPoint
+		switch element.Type {
+		case "node":
+			location = &element.Point
+		case "way":
+			if points, ok := osmWayPoints(element.Refs, nodes); ok {
+				location = osmCentroid([][]GeoPoint{points})
+			}
+		case "relation":
+			var ways [][]GeoPoint
+			for _, way := range candidate.ways {
+				if points, ok := osmWayPoints(memberWays[way], nodes); ok {
+					ways = append(ways, points)
+				}
+			}
+			location = osmCentroid(ways)
+		}
+		if location == nil {
+			skipped = append(skipped, OSMCourseSkip{OSMID: osmID, Reason: "geometry not in the extract"})
+			continue
+		}
+
+		courses = append(courses, OSMCourse{
+			OSMID:    osmID,
+			Name:     name,
+			Address:  osmAddress(element.Tags),
+			Website:  osmFirstTag(element.Tags, "website", "contact:website", "url"),
+			Phone:    osmFirstTag(element.Tags, "phone", "contact:phone"),
+			Location: *location,
+		})
+	}
+	return courses, skipped, nil
+}
+
+// osmWayPoints resolves a way's nodes, failing when the extract was clipped
+// through it
+func osmWayPoints(refs []int64, nodes map[int64]*GeoPoint) ([]GeoPoint, bool) {
+	if len(refs) == 0 {
+		return nil, false
+	}
+	points := make([]GeoPoint, len(refs))
+	for i, ref := range refs {
+		if nodes[ref] == nil {
+			return nil, false
+		}
+		points[i] = *nodes[ref]
+	}
+	return points, true
+}
+
+// osmCentroid is the area-weighted centre of the rings the ways join into.
+// Ways that don't close fall back to the average of their points.
+func osmCentroid(ways [][]GeoPoint) *GeoPoint {
+	var latitude, longitude, total float64
+	for _, ring := range assembleOSMRings(ways) {
+		area := polygonArea(openRing(ring))
+		if area <= 0 {
+			continue
+		}
+		centre := polygonCentroid(openRing(ring))
+		latitude += centre.Latitude * area
+		longitude += centre.Longitude * area
+		total += area
+	}
+	if total > 0 {
+		return &GeoPoint{Latitude: latitude / total, Longitude: longitude / total}
+	}
+
+	var count float64
+	for _, way := range ways {
+		for _, point := range way {
+			latitude += point.Latitude
+			longitude += point.Longitude
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	return &GeoPoint{Latitude: latitude / count, Longitude: longitude / count}
+}
+
+// assembleOSMRings joins ways end to end into closed rings, the way
+// multipolygon outlines are split across several ways
+func assembleOSMRings(ways [][]GeoPoint) [][]GeoPoint {
+	pending := make([][]GeoPoint, 0, len(ways))
+	for _, way := range ways {
+		if len(way) >= 2 {
+			pending = append(pending, way)
+		}
+	}
+
+	var rings [][]GeoPoint
+	for len(pending) > 0 {
+		ring := append([]GeoPoint(nil), pending[0]...)
+		pending = pending[1:]
+		for ring[0] != ring[len(ring)-1] {
+			end := ring[len(ring)-1]
+			joined := -1
+			for i, way := range pending {
+				if way[0] == end {
+					ring = append(ring, way[1:]...)
+				} else if way[len(way)-1] == end {
+					for j := len(way) - 2; j >= 0; j-- {
+						ring = append(ring, way[j])
+					}
+				} else {
+					continue
+				}
+				joined = i
+				break
+			}
+			if joined < 0 {
+				break
+			}
+			pending = append(pending[:joined], pending[joined+1:]...)
+		}
+		if len(ring) >= 4 && ring[0] == ring[len(ring)-1] {
+			rings = append(rings, ring)
+		}
+	}
+	return rings
+}
+
+// osmAddress builds a one-line address from the addr:* tags
+func osmAddress(tags map[string]string) string {
+	if full := strings.TrimSpace(tags["addr:full"]); full != "" {
+		return full
+	}
+
+	join := func(separator string, values ...string) string {
+		var parts []string
+		for _, value := range values {
+			if value = strings.TrimSpace(value); value != "" {
+				parts = append(parts, value)
+			}
+		}
+		return strings.Join(parts, separator)
+	}
+	return join(", ",
+		join(" ", tags["addr:housenumber"], tags["addr:street"]),
+		osmFirstTag(tags, "addr:city", "addr:town", "addr:village"),
+		join(" ", osmFirstTag(tags, "addr:state", "addr:province"), tags["addr:postcode"]),
+	)
+}
+
+func osmFirstTag(tags map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(tags[key]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// osmIndexedCourse is a course OSM courses are checked against
+type osmIndexedCourse struct {
+	id       uint
+	name     string
+	hash     string
+	location *GeoPoint
+	osmID    string // Set for courses from the extract being imported
+}
+
+// osmCourseIndex finds the courses an OSM course may duplicate, by hash and
+// by latitude band for the distance checks
+type osmCourseIndex struct {
+	courses []osmIndexedCourse
+	hashes  map[string]int
+	bands   map[int][]int
+}
+
+func newOSMCourseIndex(existing []CourseDB) *osmCourseIndex {
+	index := &osmCourseIndex{hashes: make(map[string]int), bands: make(map[int][]int)}
+	for _, course := range existing {
+		index.add(osmIndexedCourse{id: course.ID, name: course.Name, hash: course.Hash, location: courseLocation(&course)})
+	}
+	return index
+}
+
+func (x *osmCourseIndex) add(course osmIndexedCourse) {
+	position := len(x.courses)
+	x.courses = append(x.courses, course)
+	if _, ok := x.hashes[course.hash]; !ok {
+		x.hashes[course.hash] = position
+	}
+	if course.location != nil {
+		band := osmLatitudeBand(course.location.Latitude)
+		x.bands[band] = append(x.bands[band], position)
+	}
+}
+
+// addRedirects matches the hash of each merged course to the course it was
+// merged into, so a merged duplicate isn't imported again
+func (x *osmCourseIndex) addRedirects(redirects []CourseRedirect) {
+	positions := make(map[uint]int, len(x.courses))
+	for position, course := range x.courses {
+		positions[course.id] = position
+	}
+	for _, redirect := range redirects {
+		position, ok := positions[redirect.ToCourseID]
+		if _, taken := x.hashes[redirect.FromHash]; ok && !taken {
+			x.hashes[redirect.FromHash] = position
+		}
+	}
+}
+
+// match returns what an OSM course duplicates: the course with the same hash,
+// else the closest with the same name within osmSameNameKm, else the closest
+// of any name within osmDuplicateMeters
+func (x *osmCourseIndex) match(course OSMCourse) *OSMCourseDuplicate {
+	duplicate := func(indexed osmIndexedCourse, reason string, meters float64) *OSMCourseDuplicate {
+		name := indexed.name
+		if indexed.osmID != "" {
+			name += " (" + indexed.osmID + ")"
+		}
+		return &OSMCourseDuplicate{Course: course, MatchID: indexed.id, MatchName: name, Reason: reason, DistanceMeters: meters}
+	}
+
+	if position, ok := x.hashes[GenerateCourseHash(course.Name, course.Address)]; ok {
+		indexed := x.courses[position]
+		meters := -1.0
+		if indexed.location != nil {
+			meters = services.HaversineKm(course.Location.Latitude, course.Location.Longitude, indexed.location.Latitude, indexed.location.Longitude) * 1000
+		}
+		return duplicate(indexed, "hash", meters)
+	}
+
+	name := normalizeString(course.Name)
+	sameName, nearby := -1, -1
+	sameNameMeters, nearbyMeters := math.Inf(1), math.Inf(1)
+	band := osmLatitudeBand(course.Location.Latitude)
+	for _, neighbour := range []int{band - 1, band, band + 1} {
+		for _, position := range x.bands[neighbour] {
+			indexed := x.courses[position]
+			meters := services.HaversineKm(course.Location.Latitude, course.Location.Longitude, indexed.location.Latitude, indexed.location.Longitude) * 1000
+			if meters <= osmSameNameKm*1000 && meters < sameNameMeters && normalizeString(indexed.name) == name {
+				sameName, sameNameMeters = position, meters
+			}
+			if meters <= osmDuplicateMeters && meters < nearbyMeters {
+				nearby, nearbyMeters = position, meters
+			}
+		}
+	}
+	switch {
+	case sameName >= 0:
+		return duplicate(x.courses[sameName], "name", sameNameMeters)
+	case nearby >= 0:
+		return duplicate(x.courses[nearby], "nearby", nearbyMeters)
+	}
+	return nil
+}
+
+func osmLatitudeBand(latitude float64) int {
+	return int(math.Floor(latitude / osmDedupeBand))
+}
+
+// writeOSMImportReport prints a report for the import-osm command
+func writeOSMImportReport(w io.Writer, report *OSMImportReport) {
+	mode := "dry run"
+	if report.Applied {
+		mode = "applied"
+	}
+	fmt.Fprintf(w, "OSM course import of %s (%s)\n", report.Extract, mode)
+	fmt.Fprintf(w, "  Golf courses found: %d\n", report.Found)
+	fmt.Fprintf(w, "  New courses:        %d\n", len(report.New))
+	fmt.Fprintf(w, "  Duplicates:         %d\n", len(report.Duplicates))
+	fmt.Fprintf(w, "  Skipped:            %d\n", len(report.Skipped))
+
+	if len(report.New) > 0 {
+		fmt.Fprintf(w, "\nNew courses:\n")
+		for _, course := range report.New {
+			fmt.Fprintf(w, "  %-16s %s (%.5f, %.5f)", course.OSMID, course.Name, course.Location.Latitude, course.Location.Longitude)
+			if course.Address != "" {
+				fmt.Fprintf(w, " %s", course.Address)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	if len(report.Duplicates) > 0 {
+		reasons := map[string]string{"hash": "same name and address", "name": "same name", "nearby": "nearby"}
+		fmt.Fprintf(w, "\nDuplicates:\n")
+		for _, duplicate := range report.Duplicates {
+			match := "extract course " + duplicate.MatchName
+			if duplicate.MatchID != 0 {
+				match = fmt.Sprintf("course %d %s", duplicate.MatchID, duplicate.MatchName)
+			}
+			fmt.Fprintf(w, "  %-16s %s matches %s (%s", duplicate.Course.OSMID, duplicate.Course.Name, match, reasons[duplicate.Reason])
+			if duplicate.DistanceMeters >= 0 {
+				fmt.Fprintf(w, ", %.0f m", duplicate.DistanceMeters)
+			}
+			fmt.Fprintln(w, ")")
+		}
+	}
+
+	if len(report.Skipped) > 0 {
+		sort.SliceStable(report.Skipped, func(i, j int) bool { return report.Skipped[i].Reason < report.Skipped[j].Reason })
+		fmt.Fprintf(w, "\nSkipped:\n")
+		for _, skip := range report.Skipped {
+			fmt.Fprintf(w, "  %-16s %s\n", skip.OSMID, skip.Reason)
+		}
+	}
+
+	fmt.Fprintln(w)
+	switch {
+	case report.Applied:
+		fmt.Fprintf(w, "Created %d courses.\n", report.Created)
+	case len(report.New) > 0:
+		fmt.Fprintf(w, "Nothing was written. Run again with -apply to create the %d new courses.\n", len(report.New))
+	default:
+		fmt.Fprintf(w, "Nothing to import.\n")
+	}
+}
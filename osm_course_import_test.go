@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCourseExtract has new courses mapped as a way, a relation split across
+// two ways and a node, alongside duplicates and courses that can't be imported
+const testCourseExtract = `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6">
+  <node id="1" lat="35.2000" lon="-79.4000"/>
+  <node id="2" lat="35.2000" lon="-79.3900"/>
+  <node id="3" lat="35.2100" lon="-79.3900"/>
+  <node id="4" lat="35.2100" lon="-79.4000"/>
+  <node id="5" lat="35.3000" lon="-79.3000"/>
+  <node id="6" lat="35.3000" lon="-79.2900"/>
+  <node id="7" lat="35.3100" lon="-79.2900"/>
+  <node id="8" lat="35.3100" lon="-79.3000"/>
+  <node id="20" lat="35.1950" lon="-79.4650">
+    <tag k="leisure" v="golf_course"/>
+    <tag k="name" v="Pinehurst no 2"/>
+  </node>
+  <node id="21" lat="35.1625" lon="-79.4390">
+    <tag k="leisure" v="golf_course"/>
+    <tag k="name" v="Southern Pines Elks Club"/>
+  </node>
+  <node id="22" lat="35.2051" lon="-79.3951">
+    <tag k="leisure" v="golf_course"/>
+    <tag k="name" v="Foxfire"/>
+  </node>
+  <node id="23" lat="34.0000" lon="-78.0000">
+    <tag k="leisure" v="golf_course"/>
+  </node>
+  <node id="24" lat="36.0000" lon="-80.0000">
+    <tag k="leisure" v="golf_course"/>
+    <tag k="name" v="Hidden Valley"/>
+    <tag k="contact:phone" v="+1 336 555 0100"/>
+    <tag k="addr:full" v="1 Valley Rd, Winston-Salem, NC"/>
+  </node>
+  <node id="25" lat="37.0000" lon="-81.0000">
+    <tag k="leisure" v="golf_course"/>
+    <tag k="name" v="Old Course"/>
+    <tag k="addr:city" v="Somewhere"/>
+  </node>
+  <node id="26" lat="35.5000" lon="-79.5000">
+    <tag k="leisure" v="park"/>
+    <tag k="name" v="Not A Course"/>
+  </node>
+  <way id="10">
+    <nd ref="1"/><nd ref="2"/><nd ref="3"/><nd ref="4"/><nd ref="1"/>
+    <tag k="leisure" v="golf_course"/>
+    <tag k="name" v="Sandhills Links"/>
+    <tag k="website" v="https://sandhills.example"/>
+    <tag k="phone" v="+1 910 555 0199"/>
+    <tag k="addr:housenumber" v="100"/>
+    <tag k="addr:street" v="Links Drive"/>
+    <tag k="addr:city" v="Pinehurst"/>
+    <tag k="addr:state" v="NC"/>
+    <tag k="addr:postcode" v="28374"/>
+  </way>
+  <way id="11">
+    <nd ref="5"/><nd ref="6"/><nd ref="7"/>
+  </way>
+  <way id="12">
+    <nd ref="5"/><nd ref="8"/><nd ref="7"/>
+  </way>
+  <way id="13">
+    <nd ref="1"/><nd ref="99"/><nd ref="2"/><nd ref="1"/>
+    <tag k="leisure" v="golf_course"/>
+    <tag k="name" v="Clipped Course"/>
+  </way>
+  <relation id="30">
+    <member type="way" ref="11" role="outer"/>
+    <member type="way" ref="12" role="outer"/>
+    <tag k="type" v="multipolygon"/>
+    <tag k="leisure" v="golf_course"/>
+    <tag k="name" v="Longleaf Ridge"/>
+  </relation>
+</osm>`
+
+func TestOSMCourseImporter(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	ids := seedGeoCourses(t, db)
+	require.NoError(t, db.Create(&CourseDB{Name: "Old Course", Address: "Somewhere", CourseData: "{}"}).Error)
+
+	path := filepath.Join(t.TempDir(), "sandhills.osm")
+	require.NoError(t, os.WriteFile(path, []byte(testCourseExtract), 0o644))
+	importer := NewOSMCourseImporter()
+
+	report, err := importer.Plan(path)
+	require.NoError(t, err)
+	assert.Equal(t, 9, report.Found)
+
+	require.Len(t, report.New, 3)
+	links, ridge, valley := report.New[0], report.New[1], report.New[2]
+	assert.Equal(t, OSMCourse{
+		OSMID:    "way/10",
+		Name:     "Sandhills Links",
+		Address:  "100 Links Drive, Pinehurst, NC 28374",
+		Website:  "https://sandhills.example",
+		Phone:    "+1 910 555 0199",
+		Location: links.Location,
+	}, links)
+	assert.InDelta(t, 35.205, links.Location.Latitude, 1e-4)
+	assert.InDelta(t, -79.395, links.Location.Longitude, 1e-4)
+
+	assert.Equal(t, "relation/30", ridge.OSMID)
+	assert.InDelta(t, 35.305, ridge.Location.Latitude, 1e-4, "the outline is joined from two ways")
+	assert.InDelta(t, -79.295, ridge.Location.Longitude, 1e-4)
+
+	assert.Equal(t, "node/24", valley.OSMID)
+	assert.Equal(t, "1 Valley Rd, Winston-Salem, NC", valley.Address)
+	assert.Equal(t, "+1 336 555 0100", valley.Phone)
+
+	duplicates := make(map[string]OSMCourseDuplicate)
+	for _, duplicate := range report.Duplicates {
+		duplicates[duplicate.Course.OSMID] = duplicate
+	}
+	require.Len(t, duplicates, 4)
+	assert.Equal(t, "name", duplicates["node/20"].Reason, "the same name, spelled differently, under a kilometre away")
+	assert.Equal(t, ids["Pinehurst No. 2"], duplicates["node/20"].MatchID)
+	assert.InDelta(t, 690, duplicates["node/20"].DistanceMeters, 50)
+	assert.Equal(t, "nearby", duplicates["node/21"].Reason)
+	assert.Equal(t, ids["Mid Pines"], duplicates["node/21"].MatchID)
+	assert.Equal(t, "nearby", duplicates["node/22"].Reason, "another course in the same extract")
+	assert.Zero(t, duplicates["node/22"].MatchID)
+	assert.Equal(t, "Sandhills Links (way/10)", duplicates["node/22"].MatchName)
+	assert.Equal(t, "hash", duplicates["node/25"].Reason)
+	assert.Equal(t, -1.0, duplicates["node/25"].DistanceMeters, "the existing course isn't mapped")
+
+	assert.ElementsMatch(t, []OSMCourseSkip{
+		{OSMID: "node/23", Reason: "no name"},
+		{OSMID: "way/13", Reason: "geometry not in the extract"},
+	}, report.Skipped)
+
+	var out bytes.Buffer
+	writeOSMImportReport(&out, report)
+	assert.Contains(t, out.String(), "(dry run)")
+	assert.Contains(t, out.String(), "Run again with -apply to create the 3 new courses.")
+	var before int64
+	require.NoError(t, db.Model(&CourseDB{}).Count(&before).Error)
+
+	// Applying creates the new courses where they're mapped
+	require.NoError(t, importer.Apply(report, &owner.ID))
+	assert.Equal(t, 3, report.Created)
+	var after int64
+	require.NoError(t, db.Model(&CourseDB{}).Count(&after).Error)
+	assert.Equal(t, before+3, after)
+
+	var created CourseDB
+	require.NoError(t, db.Where("name = ?", "Sandhills Links").First(&created).Error)
+	assert.Equal(t, GenerateCourseHash(links.Name, links.Address), created.Hash)
+	assert.Equal(t, &owner.ID, created.CreatedBy)
+	require.NotNil(t, created.Latitude)
+	assert.InDelta(t, links.Location.Latitude, *created.Latitude, 1e-9)
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(created.CourseData), &data))
+	assert.Equal(t, "openstreetmap", data["source"])
+	assert.Equal(t, "way/10", data["osm_id"])
+	assert.Equal(t, "https://sandhills.example", data["website"])
+
+	out.Reset()
+	writeOSMImportReport(&out, report)
+	assert.Contains(t, out.String(), "Created 3 courses.")
+
+	// A second run finds nothing new
+	report, err = importer.Plan(path)
+	require.NoError(t, err)
+	assert.Empty(t, report.New)
+	assert.Len(t, report.Duplicates, 7)
+}
+
+func TestAssembleOSMRings(t *testing.T) {
+	a := GeoPoint{Latitude: 0, Longitude: 0}
+	b := GeoPoint{Latitude: 0, Longitude: 1}
+	c := GeoPoint{Latitude: 1, Longitude: 1}
+	d := GeoPoint{Latitude: 1, Longitude: 0}
+
+	// Segments in any order and direction close into one ring
+	rings := assembleOSMRings([][]GeoPoint{{c, d}, {a, b}, {a, d}, {b, c}})
+	require.Len(t, rings, 1)
+	assert.Equal(t, []GeoPoint{c, d, a, b, c}, rings[0])
+
+	assert.Empty(t, assembleOSMRings([][]GeoPoint{{a, b, c}}), "an open way isn't a ring")
+	assert.Nil(t, osmCentroid(nil))
+	assert.Equal(t, &GeoPoint{Latitude: 0, Longitude: 0.5}, osmCentroid([][]GeoPoint{{a, b}}), "open ways fall back to their average")
+}
@@ -0,0 +1,685 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PBF files are split into blobs of at most 32 MiB, each with a header of
+	// at most 64 KiB, per the format's specification
+	maxPBFHeaderSize = 64 << 10
+	maxPBFBlobSize   = 32 << 20
+)
+
+var ErrUnsupportedOSMExtract = errors.New("unsupported OSM extract")
+
+// osmMember is one member of a relation
+type osmMember struct {
+	Type string // "node", "way" or "relation"
+	Ref  int64
+	Role string
+}
+
+// readOSMExtract streams the elements of an OSM XML (optionally bzip2
+// compressed) or PBF file to visit, in file order. Extracts list nodes, then
+// ways, then relations, so callers that need way or member geometry read the
+// file more than once rather than holding a whole country in memory.
+func readOSMExtract(path string, visit func(osmElement) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open extract: %v", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = bufio.NewReaderSize(file, 1<<20)
+	if strings.HasSuffix(strings.ToLower(path), ".bz2") {
+		reader = bufio.NewReader(bzip2.NewReader(reader))
+	}
+	buffered := reader.(*bufio.Reader)
+
+	start, err := buffered.Peek(64)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read extract: %v", err)
+	}
+	if trimmed := bytes.TrimLeft(bytes.TrimPrefix(start, []byte("\xef\xbb\xbf")), " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '<' {
+		return readOSMXMLExtract(buffered, visit)
+	}
+	return readOSMPBF(buffered, visit)
+}
+
+// readOSMXMLExtract streams the nodes, ways and relations of an OSM XML file
+func readOSMXMLExtract(reader io.Reader, visit func(osmElement) error) error {
+	decoder := xml.NewDecoder(reader)
+	var current *osmElement
+	attribute := func(element xml.StartElement, name string) string {
+		for _, attr := range element.Attr {
+			if attr.Name.Local == name {
+				return attr.Value
+			}
+		}
+		return ""
+	}
+	number := func(element xml.StartElement, name string) float64 {
+		value, _ := strconv.ParseFloat(attribute(element, name), 64)
+		return value
+	}
+	id := func(element xml.StartElement, name string) int64 {
+		value, _ := strconv.ParseInt(attribute(element, name), 10, 64)
+		return value
+	}
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: the OSM XML couldn't be read: %v", ErrUnsupportedOSMExtract, err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "node", "way", "relation":
+				current = &osmElement{Type: token.Name.Local, ID: id(token, "id")}
+				if current.Type == "node" {
+					current.Point = GeoPoint{Latitude: number(token, "lat"), Longitude: number(token, "lon")}
+				}
+			case "nd":
+				if current != nil {
+					current.Refs = append(current.Refs, id(token, "ref"))
+				}
+			case "member":
+				if current != nil {
+					current.Members = append(current.Members, osmMember{
+						Type: attribute(token, "type"),
+						Ref:  id(token, "ref"),
+						Role: attribute(token, "role"),
+					})
+				}
+			case "tag":
+				if current != nil {
+					if current.Tags == nil {
+						current.Tags = make(map[string]string)
+					}
+					current.Tags[attribute(token, "k")] = attribute(token, "v")
+				}
+			}
+		case xml.EndElement:
+			if current != nil && token.Name.Local == current.Type {
+				if err := visit(*current); err != nil {
+					return err
+				}
+				current = nil
+			}
+		}
+	}
+}
+
+// readOSMPBF streams the elements of an OSM PBF file. Raw and zlib blobs are
+// read, which is what planet files and regional extracts use.
+func readOSMPBF(reader io.Reader, visit func(osmElement) error) error {
+	length := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(reader, length); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("%w: truncated PBF blob header", ErrUnsupportedOSMExtract)
+		}
+		size := binary.BigEndian.Uint32(length)
+		if size > maxPBFHeaderSize {
+			return fmt.Errorf("%w: not an OSM XML or PBF file", ErrUnsupportedOSMExtract)
+		}
+		header := make([]byte, size)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return fmt.Errorf("%w: truncated PBF blob header", ErrUnsupportedOSMExtract)
+		}
+		blobType, blobSize, err := parsePBFBlobHeader(header)
+		if err != nil {
+			return err
+		}
+		if blobSize > maxPBFBlobSize {
+			return fmt.Errorf("%w: PBF blob of %d bytes is too large", ErrUnsupportedOSMExtract, blobSize)
+		}
+		blob := make([]byte, blobSize)
+		if _, err := io.ReadFull(reader, blob); err != nil {
+			return fmt.Errorf("%w: truncated PBF blob", ErrUnsupportedOSMExtract)
+		}
+
+		switch blobType {
+		case "OSMHeader":
+			data, err := decodePBFBlob(blob)
+			if err != nil {
+				return err
+			}
+			if err := checkPBFHeader(data); err != nil {
+				return err
+			}
+		case "OSMData":
+			data, err := decodePBFBlob(blob)
+			if err != nil {
+				return err
+			}
+			if err := decodePBFPrimitiveBlock(data, visit); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func parsePBFBlobHeader(data []byte) (string, int, error) {
+	var blobType string
+	var blobSize int
+	message := protoMessage{data: data}
+	for message.more() {
+		field, wireType, err := message.key()
+		if err != nil {
+			return "", 0, err
+		}
+		switch {
+		case field == 1 && wireType == protoLengthDelimited:
+			value, err := message.bytes()
+			if err != nil {
+				return "", 0, err
+			}
+			blobType = string(value)
+		case field == 3 && wireType == protoVarint:
+			value, err := message.varint()
+			if err != nil {
+				return "", 0, err
+			}
+			blobSize = int(value)
+		default:
+			if err := message.skip(wireType); err != nil {
+				return "", 0, err
+			}
+		}
+	}
+	return blobType, blobSize, nil
+}
+
+// decodePBFBlob returns a blob's uncompressed contents
+func decodePBFBlob(data []byte) ([]byte, error) {
+	message := protoMessage{data: data}
+	for message.more() {
+		field, wireType, err := message.key()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == 1 && wireType == protoLengthDelimited:
+			return message.bytes()
+		case field == 3 && wireType == protoLengthDelimited:
+			compressed, err := message.bytes()
+			if err != nil {
+				return nil, err
+			}
+			inflater, err := zlib.NewReader(bytes.NewReader(compressed))
+			if err != nil {
+				return nil, fmt.Errorf("%w: corrupt PBF blob: %v", ErrUnsupportedOSMExtract, err)
+			}
+			defer inflater.Close()
+			raw, err := io.ReadAll(io.LimitReader(inflater, maxPBFBlobSize+1))
+			if err != nil || len(raw) > maxPBFBlobSize {
+				return nil, fmt.Errorf("%w: corrupt PBF blob", ErrUnsupportedOSMExtract)
+			}
+			return raw, nil
+		case field >= 4 && wireType == protoLengthDelimited:
+			return nil, fmt.Errorf("%w: only raw and zlib PBF blobs are supported", ErrUnsupportedOSMExtract)
+		default:
+			if err := message.skip(wireType); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: empty PBF blob", ErrUnsupportedOSMExtract)
+}
+
+// checkPBFHeader refuses files that need features this reader doesn't have,
+// such as history extracts
+func checkPBFHeader(data []byte) error {
+	message := protoMessage{data: data}
+	for message.more() {
+		field, wireType, err := message.key()
+		if err != nil {
+			return err
+		}
+		if field != 4 || wireType != protoLengthDelimited {
+			if err := message.skip(wireType); err != nil {
+				return err
+			}
+			continue
+		}
+		feature, err := message.bytes()
+		if err != nil {
+			return err
+		}
+		if name := string(feature); name != "OsmSchema-V0.6" && name != "DenseNodes" {
+			return fmt.Errorf("%w: the PBF needs %s", ErrUnsupportedOSMExtract, name)
+		}
+	}
+	return nil
+}
+
+// pbfBlock holds what decoding a primitive block's groups needs
+type pbfBlock struct {
+	strings     []string
+	granularity int64
+	latOffset   int64
+	lonOffset   int64
+}
+
+func (b *pbfBlock) point(lat, lon int64) GeoPoint {
+	return GeoPoint{
+		Latitude:  float64(b.latOffset+b.granularity*lat) * 1e-9,
+		Longitude: float64(b.lonOffset+b.granularity*lon) * 1e-9,
+	}
+}
+
+func (b *pbfBlock) tags(keys, values []uint64) map[string]string {
+	if len(keys) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(keys))
+	for i := range keys {
+		if i < len(values) && int(keys[i]) < len(b.strings) && int(values[i]) < len(b.strings) {
+			tags[b.strings[keys[i]]] = b.strings[values[i]]
+		}
+	}
+	return tags
+}
+
+func decodePBFPrimitiveBlock(data []byte, visit func(osmElement) error) error {
+	block := &pbfBlock{granularity: 100}
+	var groups [][]byte
+
+	message := protoMessage{data: data}
+	for message.more() {
+		field, wireType, err := message.key()
+		if err != nil {
+			return err
+		}
+		switch {
+		case field == 1 && wireType == protoLengthDelimited:
+			table, err := message.bytes()
+			if err != nil {
+				return err
+			}
+			entries := protoMessage{data: table}
+			for entries.more() {
+				field, wireType, err := entries.key()
+				if err != nil {
+					return err
+				}
+				if field != 1 || wireType != protoLengthDelimited {
+					if err := entries.skip(wireType); err != nil {
+						return err
+					}
+					continue
+				}
+				value, err := entries.bytes()
+				if err != nil {
+					return err
+				}
+				block.strings = append(block.strings, string(value))
+			}
+		case field == 2 && wireType == protoLengthDelimited:
+			group, err := message.bytes()
+			if err != nil {
+				return err
+			}
+			groups = append(groups, group)
+		case (field == 17 || field == 19 || field == 20) && wireType == protoVarint:
+			value, err := message.varint()
+			if err != nil {
+				return err
+			}
+			switch field {
+			case 17:
+				block.granularity = int64(value)
+			case 19:
+				block.latOffset = int64(value)
+			case 20:
+				block.lonOffset = int64(value)
+			}
+		default:
+			if err := message.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, group := range groups {
+		if err := block.decodeGroup(group, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *pbfBlock) decodeGroup(data []byte, visit func(osmElement) error) error {
+	message := protoMessage{data: data}
+	for message.more() {
+		field, wireType, err := message.key()
+		if err != nil {
+			return err
+		}
+		if wireType != protoLengthDelimited || field < 1 || field > 4 {
+			if err := message.skip(wireType); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := message.bytes()
+		if err != nil {
+			return err
+		}
+
+		var elements []osmElement
+		switch field {
+		case 1:
+			elements, err = b.decodeNode(value)
+		case 2:
+			elements, err = b.decodeDenseNodes(value)
+		case 3:
+			elements, err = b.decodeWay(value)
+		case 4:
+			elements, err = b.decodeRelation(value)
+		}
+		if err != nil {
+			return err
+		}
+		for _, element := range elements {
+			if err := visit(element); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *pbfBlock) decodeNode(data []byte) ([]osmElement, error) {
+	var id, lat, lon int64
+	var keys, values []uint64
+	message := protoMessage{data: data}
+	for message.more() {
+		field, wireType, err := message.key()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1, 8, 9:
+			value, err := message.varint()
+			if err != nil {
+				return nil, err
+			}
+			switch field {
+			case 1:
+				id = unzigzag(value)
+			case 8:
+				lat = unzigzag(value)
+			case 9:
+				lon = unzigzag(value)
+			}
+		case 2:
+			keys, err = message.repeatedVarints(wireType, keys)
+		case 3:
+			values, err = message.repeatedVarints(wireType, values)
+		default:
+			err = message.skip(wireType)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []osmElement{{Type: "node", ID: id, Point: b.point(lat, lon), Tags: b.tags(keys, values)}}, nil
+}
+
+func (b *pbfBlock) decodeDenseNodes(data []byte) ([]osmElement, error) {
+	var ids, lats, lons, keyValues []uint64
+	message := protoMessage{data: data}
+	for message.more() {
+		field, wireType, err := message.key()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1:
+			ids, err = message.repeatedVarints(wireType, ids)
+		case 8:
+			lats, err = message.repeatedVarints(wireType, lats)
+		case 9:
+			lons, err = message.repeatedVarints(wireType, lons)
+		case 10:
+			keyValues, err = message.repeatedVarints(wireType, keyValues)
+		default:
+			err = message.skip(wireType)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(lats) != len(ids) || len(lons) != len(ids) {
+		return nil, fmt.Errorf("%w: corrupt PBF dense nodes", ErrUnsupportedOSMExtract)
+	}
+
+	nodes := make([]osmElement, len(ids))
+	var id, lat, lon int64
+	next := 0
+	for i := range ids {
+		id += unzigzag(ids[i])
+		lat += unzigzag(lats[i])
+		lon += unzigzag(lons[i])
+		nodes[i] = osmElement{Type: "node", ID: id, Point: b.point(lat, lon)}
+
+		// Tags are key and value string indexes, with a zero after each node's
+		var keys, values []uint64
+		for next < len(keyValues) && keyValues[next] != 0 {
+			if next+1 < len(keyValues) {
+				keys = append(keys, keyValues[next])
+				values = append(values, keyValues[next+1])
+			}
+			next += 2
+		}
+		next++
+		nodes[i].Tags = b.tags(keys, values)
+	}
+	return nodes, nil
+}
+
+func (b *pbfBlock) decodeWay(data []byte) ([]osmElement, error) {
+	var id int64
+	var keys, values, refs []uint64
+	message := protoMessage{data: data}
+	for message.more() {
+		field, wireType, err := message.key()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1:
+			var value uint64
+			value, err = message.varint()
+			id = int64(value)
+		case 2:
+			keys, err = message.repeatedVarints(wireType, keys)
+		case 3:
+			values, err = message.repeatedVarints(wireType, values)
+		case 8:
+			refs, err = message.repeatedVarints(wireType, refs)
+		default:
+			err = message.skip(wireType)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	way := osmElement{Type: "way", ID: id, Tags: b.tags(keys, values), Refs: make([]int64, len(refs))}
+	var ref int64
+	for i := range refs {
+		ref += unzigzag(refs[i])
+		way.Refs[i] = ref
+	}
+	return []osmElement{way}, nil
+}
+
+func (b *pbfBlock) decodeRelation(data []byte) ([]osmElement, error) {
+	var id int64
+	var keys, values, roles, members, types []uint64
+	message := protoMessage{data: data}
+	for message.more() {
+		field, wireType, err := message.key()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1:
+			var value uint64
+			value, err = message.varint()
+			id = int64(value)
+		case 2:
+			keys, err = message.repeatedVarints(wireType, keys)
+		case 3:
+			values, err = message.repeatedVarints(wireType, values)
+		case 8:
+			roles, err = message.repeatedVarints(wireType, roles)
+		case 9:
+			members, err = message.repeatedVarints(wireType, members)
+		case 10:
+			types, err = message.repeatedVarints(wireType, types)
+		default:
+			err = message.skip(wireType)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(roles) != len(members) || len(types) != len(members) {
+		return nil, fmt.Errorf("%w: corrupt PBF relation", ErrUnsupportedOSMExtract)
+	}
+
+	relation := osmElement{Type: "relation", ID: id, Tags: b.tags(keys, values), Members: make([]osmMember, len(members))}
+	memberTypes := []string{"node", "way", "relation"}
+	var ref int64
+	for i := range members {
+		ref += unzigzag(members[i])
+		member := osmMember{Ref: ref}
+		if types[i] < uint64(len(memberTypes)) {
+			member.Type = memberTypes[types[i]]
+		}
+		if roles[i] < uint64(len(b.strings)) {
+			member.Role = b.strings[roles[i]]
+		}
+		relation.Members[i] = member
+	}
+	return []osmElement{relation}, nil
+}
+
+// protoFixed32 is the last wire type the PBF format can use, alongside those
+// the vector tile encoder writes
+const protoFixed32 = 5
+
+// protoMessage reads the fields of an encoded protocol buffer message
+type protoMessage struct {
+	data []byte
+	pos  int
+}
+
+func (m *protoMessage) more() bool {
+	return m.pos < len(m.data)
+}
+
+func (m *protoMessage) key() (int, int, error) {
+	key, err := m.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(key >> 3), int(key & 7), nil
+}
+
+func (m *protoMessage) varint() (uint64, error) {
+	value, n := binary.Uvarint(m.data[m.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("%w: corrupt PBF varint", ErrUnsupportedOSMExtract)
+	}
+	m.pos += n
+	return value, nil
+}
+
+func (m *protoMessage) bytes() ([]byte, error) {
+	length, err := m.varint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(m.data)-m.pos) {
+		return nil, fmt.Errorf("%w: corrupt PBF field length", ErrUnsupportedOSMExtract)
+	}
+	value := m.data[m.pos : m.pos+int(length)]
+	m.pos += int(length)
+	return value, nil
+}
+
+// repeatedVarints reads a repeated integer field, which writers may pack
+func (m *protoMessage) repeatedVarints(wireType int, values []uint64) ([]uint64, error) {
+	if wireType == protoVarint {
+		value, err := m.varint()
+		return append(values, value), err
+	}
+	if wireType != protoLengthDelimited {
+		return values, m.skip(wireType)
+	}
+
+	packed, err := m.bytes()
+	if err != nil {
+		return nil, err
+	}
+	inner := protoMessage{data: packed}
+	for inner.more() {
+		value, err := inner.varint()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (m *protoMessage) skip(wireType int) error {
+	switch wireType {
+	case protoVarint:
+		_, err := m.varint()
+		return err
+	case protoLengthDelimited:
+		_, err := m.bytes()
+		return err
+	case protoFixed64, protoFixed32:
+		size := 8
+		if wireType == protoFixed32 {
+			size = 4
+		}
+		if m.pos+size > len(m.data) {
+			return fmt.Errorf("%w: corrupt PBF field", ErrUnsupportedOSMExtract)
+		}
+		m.pos += size
+		return nil
+	}
+	return fmt.Errorf("%w: unknown PBF wire type %d", ErrUnsupportedOSMExtract, wireType)
+}
+
+// unzigzag reverses zigzag64
+func unzigzag(value uint64) int64 {
+	return int64(value>>1) ^ -int64(value&1)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testExtractXML is a small extract with a tagged node, a way and a relation
+const testExtractXML = `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6">
+  <node id="1" lat="35.1900000" lon="-79.4710000"/>
+  <node id="2" lat="35.1910000" lon="-79.4710000"/>
+  <node id="3" lat="35.1910000" lon="-79.4690000">
+    <tag k="natural" v="tree"/>
+  </node>
+  <way id="10">
+    <nd ref="1"/><nd ref="2"/><nd ref="3"/><nd ref="1"/>
+    <tag k="leisure" v="golf_course"/>
+    <tag k="name" v="Test Links"/>
+  </way>
+  <relation id="20">
+    <member type="way" ref="10" role="outer"/>
+    <member type="node" ref="3" role=""/>
+    <tag k="type" v="multipolygon"/>
+  </relation>
+</osm>`
+
+// encodeTestPBF writes the same elements as testExtractXML as an OSM PBF,
+// with an uncompressed header blob and a zlib data blob
+func encodeTestPBF(t *testing.T, requiredFeatures ...string) []byte {
+	t.Helper()
+
+	blob := func(blobType string, data []byte, compress bool) []byte {
+		var body []byte
+		if compress {
+			var compressed bytes.Buffer
+			writer := zlib.NewWriter(&compressed)
+			_, err := writer.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+			body = appendProtoVarint(nil, 2, uint64(len(data)))
+			body = appendProtoBytes(body, 3, compressed.Bytes())
+		} else {
+			body = appendProtoBytes(nil, 1, data)
+		}
+		header := appendProtoBytes(nil, 1, []byte(blobType))
+		header = appendProtoVarint(header, 3, uint64(len(body)))
+
+		out := binary.BigEndian.AppendUint32(nil, uint32(len(header)))
+		return append(append(out, header...), body...)
+	}
+
+	var header []byte
+	for _, feature := range append([]string{"OsmSchema-V0.6", "DenseNodes"}, requiredFeatures...) {
+		header = appendProtoBytes(header, 4, []byte(feature))
+	}
+
+	var table []byte
+	for _, value := range []string{"", "natural", "tree", "leisure", "golf_course", "name", "Test Links", "outer", "type", "multipolygon"} {
+		table = appendProtoBytes(table, 1, []byte(value))
+	}
+
+	// Coordinates are in units of the default granularity, 100 nanodegrees
+	dense := appendProtoPacked(nil, 1, []uint64{zigzag64(1), zigzag64(1), zigzag64(1)})
+	dense = appendProtoPacked(dense, 8, []uint64{zigzag64(351900000), zigzag64(10000), 0})
+	dense = appendProtoPacked(dense, 9, []uint64{zigzag64(-794710000), 0, zigzag64(20000)})
+	dense = appendProtoPacked(dense, 10, []uint64{0, 0, 1, 2, 0})
+	nodes := appendProtoBytes(nil, 2, dense)
+
+	way := appendProtoVarint(nil, 1, 10)
+	way = appendProtoPacked(way, 2, []uint64{3, 5})
+	way = appendProtoPacked(way, 3, []uint64{4, 6})
+	way = appendProtoPacked(way, 8, []uint64{zigzag64(1), zigzag64(1), zigzag64(1), zigzag64(-2)})
+	ways := appendProtoBytes(nil, 3, way)
+
+	relation := appendProtoVarint(nil, 1, 20)
+	relation = appendProtoPacked(relation, 2, []uint64{8})
+	relation = appendProtoPacked(relation, 3, []uint64{9})
+	relation = appendProtoPacked(relation, 8, []uint64{7, 0})
+	relation = appendProtoPacked(relation, 9, []uint64{zigzag64(10), zigzag64(-7)})
+	relation = appendProtoPacked(relation, 10, []uint64{1, 0})
+	relations := appendProtoBytes(nil, 4, relation)
+
+	block := appendProtoBytes(nil, 1, table)
+	block = appendProtoBytes(block, 2, nodes)
+	block = appendProtoBytes(block, 2, ways)
+	block = appendProtoBytes(block, 2, relations)
+
+	return append(blob("OSMHeader", header, false), blob("OSMData", block, true)...)
+}
+
+func readTestExtract(t *testing.T, name string, data []byte) ([]osmElement, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	var elements []osmElement
+	err := readOSMExtract(path, func(element osmElement) error {
+		elements = append(elements, element)
+		return nil
+	})
+	return elements, err
+}
+
+func TestReadOSMExtract(t *testing.T) {
+	fromXML, err := readTestExtract(t, "test.osm", []byte(testExtractXML))
+	require.NoError(t, err)
+	fromPBF, err := readTestExtract(t, "test.osm.pbf", encodeTestPBF(t))
+	require.NoError(t, err)
+
+	for name, elements := range map[string][]osmElement{"xml": fromXML, "pbf": fromPBF} {
+		require.Len(t, elements, 5, name)
+
+		assert.Equal(t, "node", elements[0].Type, name)
+		assert.Equal(t, int64(1), elements[0].ID, name)
+		assert.InDelta(t, 35.19, elements[0].Point.Latitude, 1e-9, name)
+		assert.InDelta(t, -79.471, elements[0].Point.Longitude, 1e-9, name)
+		assert.Empty(t, elements[0].Tags, name)
+		assert.InDelta(t, -79.469, elements[2].Point.Longitude, 1e-9, name)
+		assert.Equal(t, map[string]string{"natural": "tree"}, elements[2].Tags, name)
+
+		way := elements[3]
+		assert.Equal(t, "way", way.Type, name)
+		assert.Equal(t, []int64{1, 2, 3, 1}, way.Refs, name)
+		assert.Equal(t, map[string]string{"leisure": "golf_course", "name": "Test Links"}, way.Tags, name)
+
+		relation := elements[4]
+		assert.Equal(t, "relation", relation.Type, name)
+		assert.Equal(t, int64(20), relation.ID, name)
+		assert.Equal(t, []osmMember{{Type: "way", Ref: 10, Role: "outer"}, {Type: "node", Ref: 3}}, relation.Members, name)
+		assert.Equal(t, "multipolygon", relation.Tags["type"], name)
+	}
+
+	_, err = readTestExtract(t, "history.osm.pbf", encodeTestPBF(t, "HistoricalInformation"))
+	assert.ErrorIs(t, err, ErrUnsupportedOSMExtract)
+	_, err = readTestExtract(t, "notes.txt", []byte("golf courses near me\n"))
+	assert.ErrorIs(t, err, ErrUnsupportedOSMExtract)
+	_, err = readTestExtract(t, "truncated.osm.pbf", encodeTestPBF(t)[:40])
+	assert.ErrorIs(t, err, ErrUnsupportedOSMExtract)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"course_management/api"
+
+	"gorm.io/gorm"
+)
+
+var (
+	outingMailer   *OutingMailer
+	outingMailerMu sync.RWMutex
+)
+
+// OutingMailer emails outing invitations, with the outing attached as a
+// calendar file, to invitees who aren't registered users
+type OutingMailer struct {
+	sender  EmailSender
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+	baseURL string
+}
+
+// OutingInviteEmailData is the view model for the outing invitation email templates
+type OutingInviteEmailData struct {
+	OrganizerName string
+	Title         string
+	CourseName    string
+	CourseAddress string
+	When          string
+	Notes         string
+	RSVPURL       string
+}
+
+// NewOutingMailer loads the outing_invite.html and outing_invite.txt
+// templates from templatesDir. Links in the emails point at baseURL.
+func NewOutingMailer(sender EmailSender, templatesDir, baseURL string) (*OutingMailer, error) {
+	html, err := htmltemplate.ParseFiles(filepath.Join(templatesDir, "outing_invite.html"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load outing invite template: %v", err)
+	}
+	text, err := texttemplate.ParseFiles(filepath.Join(templatesDir, "outing_invite.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load outing invite template: %v", err)
+	}
+
+	return &OutingMailer{
+		sender:  sender,
+		html:    html,
+		text:    text,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+// SetOutingMailer sets the mailer outing services send invitations with.
+// Without one, email invitees only get the RSVP link the organizer shares.
+func SetOutingMailer(mailer *OutingMailer) {
+	outingMailerMu.Lock()
+	defer outingMailerMu.Unlock()
+	outingMailer = mailer
+}
+
+// GetOutingMailer returns the mailer set with SetOutingMailer, or nil
+func GetOutingMailer() *OutingMailer {
+	outingMailerMu.RLock()
+	defer outingMailerMu.RUnlock()
+	return outingMailer
+}
+
+// SendInvite emails an invitation for outing to an email invitee
+func (m *OutingMailer) SendInvite(db *gorm.DB, outing Outing, invite OutingInvite, organizerName string) error {
+	if invite.Email == nil {
+		return fmt.Errorf("invite %d has no email address", invite.ID)
+	}
+
+	var course CourseDB
+	if err := db.Select("id", "name", "address", "latitude", "longitude").First(&course, outing.CourseID).Error; err != nil {
+		return fmt.Errorf("failed to get course: %v", err)
+	}
+
+	data := OutingInviteEmailData{
+		OrganizerName: organizerName,
+		Title:         outing.Title,
+		CourseName:    course.Name,
+		CourseAddress: course.Address,
+		When:          time.Unix(outing.StartsAt, 0).Format("Monday, January 2, 2006 at 3:04 PM MST"),
+		RSVPURL:       m.baseURL + "/outings/rsvp/" + invite.Token,
+	}
+	if outing.Notes != nil {
+		data.Notes = *outing.Notes
+	}
+
+	var html, text bytes.Buffer
+	if err := m.html.Execute(&html, data); err != nil {
+		return fmt.Errorf("failed to render outing invite: %v", err)
+	}
+	if err := m.text.Execute(&text, data); err != nil {
+		return fmt.Errorf("failed to render outing invite: %v", err)
+	}
+
+	outing.Course = &course
+	view := OutingView{Outing: outing, CourseName: course.Name, CourseAddress: course.Address, OrganizerName: organizerName}
+	calendar := api.NewCalendar(outing.Title, OutingCalendarEvents([]OutingView{view}, m.baseURL))
+
+	return m.sender.Send(EmailMessage{
+		To:      *invite.Email,
+		Subject: fmt.Sprintf("%s invited you to %s", organizerName, outing.Title),
+		Text:    text.String(),
+		HTML:    html.String(),
+		Attachments: []EmailAttachment{
+			{Filename: "outing.ics", ContentType: api.CalendarContentType + "; method=PUBLISH", Content: calendar},
+		},
+	})
+}
@@ -0,0 +1,938 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"course_management/api"
+
+	"gorm.io/gorm"
+)
+
+// Outing RSVP states
+const (
+	OutingRSVPInvited    = "invited"
+	OutingRSVPGoing      = "going"
+	OutingRSVPMaybe      = "maybe"
+	OutingRSVPDeclined   = "declined"
+	OutingRSVPWaitlisted = "waitlisted" // Said going once the outing was full
+)
+
+const (
+	maxOutingTitleLength = 100
+	maxOutingNotesLength = 2000
+	maxOutingCapacity    = 200
+	maxOutingInvites     = 100
+
+	// outingLength is how long an outing blocks out in calendars
+	outingLength = 5 * time.Hour
+	// outingScoreWindow is how long after the start a score without a date
+	// played is taken to be from the outing
+	outingScoreWindow = 36 * time.Hour
+)
+
+var (
+	ErrOutingNotFound        = errors.New("outing not found")
+	ErrInvalidOutingTitle    = errors.New("outing titles must be between 1 and 100 characters")
+	ErrInvalidOutingCapacity = errors.New("capacity must be between 0 (no limit) and 200 players")
+	ErrOutingNotesTooLong    = errors.New("notes must be at most 2000 characters")
+	ErrOutingInPast          = errors.New("outings must start in the future")
+	ErrInvalidOutingInvitee  = errors.New("invite registered users or valid email addresses, up to 100 people")
+	ErrInvalidRSVP           = errors.New("RSVP must be going, maybe or declined")
+	ErrOutingCancelled       = errors.New("this outing has been cancelled")
+	ErrOutingNotStarted      = errors.New("scores can be linked once the outing has started")
+	ErrOutingScoreMismatch   = errors.New("only your own scores from the outing's course can be linked")
+	ErrNotOutingParticipant  = errors.New("only players who went can link scores")
+)
+
+// OutingInput is what an organizer sets when creating or changing an outing.
+// The course can't be changed once the outing exists.
+type OutingInput struct {
+	CourseID uint
+	Title    string
+	StartsAt time.Time
+	Capacity int
+	Notes    string
+}
+
+// OutingService manages outings, their invite lists and RSVPs, calendar
+// files and results
+type OutingService struct {
+	db     *gorm.DB
+	mailer *OutingMailer
+}
+
+func NewOutingService() *OutingService {
+	return &OutingService{
+		db:     GetDB(),
+		mailer: GetOutingMailer(),
+	}
+}
+
+// CreateOuting schedules an outing with the organizer as its first player
+func (s *OutingService) CreateOuting(organizerID uint, input OutingInput) (*OutingView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	outing := Outing{CourseID: input.CourseID, OrganizerID: organizerID}
+	if err := applyOutingInput(&outing, input); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&CourseDB{}).Where("id = ?", input.CourseID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to get course: %v", err)
+	}
+	if count == 0 {
+		return nil, ErrCourseNotFound
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&outing).Error; err != nil {
+			return fmt.Errorf("failed to create outing: %v", err)
+		}
+
+		token, err := newShareToken()
+		if err != nil {
+			return err
+		}
+		now := time.Now().Unix()
+		organizer := OutingInvite{OutingID: outing.ID, UserID: &organizerID, RSVP: OutingRSVPGoing, Token: token, RespondedAt: &now}
+		if err := tx.Create(&organizer).Error; err != nil {
+			return fmt.Errorf("failed to add organizer to outing: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetOuting(organizerID, outing.ID)
+}
+
+// UpdateOuting changes an outing's details. Invitees hear about a new time.
+// Lowering the capacity doesn't bump anyone who is already going.
+func (s *OutingService) UpdateOuting(organizerID, outingID uint, input OutingInput) (*OutingView, error) {
+	outing, err := s.organizedOuting(organizerID, outingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if outing.CancelledAt != nil {
+		return nil, ErrOutingCancelled
+	}
+
+	previousStart := outing.StartsAt
+	input.CourseID = outing.CourseID
+	if err := applyOutingInput(outing, input); err != nil {
+		return nil, err
+	}
+	outing.Sequence++
+
+	var promoted []OutingInvite
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(outing).Error; err != nil {
+			return fmt.Errorf("failed to update outing: %v", err)
+		}
+		var err error
+		promoted, err = fillOpenSpots(tx, *outing)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifyPromoted(*outing, promoted)
+
+	if outing.StartsAt != previousStart {
+		s.notifyInvitees(outing, fmt.Sprintf("%s moved %s to %s", s.userName(organizerID), outing.Title, formatOutingTime(outing.StartsAt)))
+	}
+	return s.GetOuting(organizerID, outing.ID)
+}
+
+// CancelOuting calls an outing off. It stays visible, marked cancelled, so
+// calendars that subscribed to it remove it.
+func (s *OutingService) CancelOuting(organizerID, outingID uint) error {
+	outing, err := s.organizedOuting(organizerID, outingID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	outing.CancelledAt = &now
+	outing.Sequence++
+	if err := s.db.Save(outing).Error; err != nil {
+		return fmt.Errorf("failed to cancel outing: %v", err)
+	}
+
+	s.notifyInvitees(outing, fmt.Sprintf("%s cancelled %s", s.userName(organizerID), outing.Title))
+	return nil
+}
+
+// Invite adds registered users and email addresses to an outing. Email
+// addresses of registered users invite those users. People already invited
+// are skipped. Users get an "outing_invite" notification; email invitees get
+// an email with a calendar file when email is set up.
+func (s *OutingService) Invite(organizerID, outingID uint, userIDs []uint, emails []string) (*OutingView, error) {
+	outing, err := s.organizedOuting(organizerID, outingID)
+	if err != nil {
+		return nil, err
+	}
+	if outing.CancelledAt != nil {
+		return nil, ErrOutingCancelled
+	}
+	if len(userIDs)+len(emails) > maxOutingInvites {
+		return nil, ErrInvalidOutingInvitee
+	}
+
+	invitees, err := s.resolveInvitees(userIDs, emails)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []OutingInvite
+	if err := s.db.Where("outing_id = ?", outing.ID).Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to get invites: %v", err)
+	}
+	invited := make(map[string]bool, len(existing))
+	for _, invite := range existing {
+		invited[outingInviteKey(invite)] = true
+	}
+
+	var created []OutingInvite
+	for _, invite := range invitees {
+		key := outingInviteKey(invite)
+		if invited[key] {
+			continue
+		}
+		invited[key] = true
+
+		invite.OutingID = outing.ID
+		invite.RSVP = OutingRSVPInvited
+		if invite.Token, err = newShareToken(); err != nil {
+			return nil, err
+		}
+		created = append(created, invite)
+	}
+	if len(created) > 0 {
+		if len(existing)+len(created) > maxOutingInvites+1 {
+			return nil, ErrInvalidOutingInvitee
+		}
+		if err := s.db.Create(&created).Error; err != nil {
+			return nil, fmt.Errorf("failed to invite players: %v", err)
+		}
+	}
+
+	organizerName := s.userName(organizerID)
+	notifications := &NotificationService{db: s.db}
+	for _, invite := range created {
+		if invite.UserID != nil {
+			message := fmt.Sprintf("%s invited you to %s on %s", organizerName, outing.Title, formatOutingTime(outing.StartsAt))
+			if err := notifications.notify(*invite.UserID, "outing_invite", &organizerID, &outing.CourseID, message); err != nil {
+				log.Printf("[OUTINGS] Failed to notify user %d of outing %d: %v", *invite.UserID, outing.ID, err)
+			}
+			continue
+		}
+		if s.mailer != nil {
+			if err := s.mailer.SendInvite(s.db, *outing, invite, organizerName); err != nil {
+				log.Printf("[OUTINGS] Failed to email invite %d for outing %d: %v", invite.ID, outing.ID, err)
+			}
+		}
+	}
+
+	return s.GetOuting(organizerID, outing.ID)
+}
+
+// RSVP records a registered invitee's answer. Saying going to a full outing
+// puts the invitee on the waitlist; when someone going drops out, the first
+// player on the waitlist takes their spot.
+func (s *OutingService) RSVP(userID, outingID uint, rsvp string) (*OutingView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var invite OutingInvite
+	if err := s.db.Where("outing_id = ? AND user_id = ?", outingID, userID).First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOutingNotFound
+		}
+		return nil, fmt.Errorf("failed to get invite: %v", err)
+	}
+
+	if err := s.respond(&invite, rsvp); err != nil {
+		return nil, err
+	}
+	return s.GetOuting(userID, outingID)
+}
+
+// RSVPByToken records the answer of whoever holds an invite's token, the way
+// email invitees respond
+func (s *OutingService) RSVPByToken(token, rsvp string) (*OutingView, *OutingInvite, error) {
+	_, invite, err := s.GetOutingByToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.respond(invite, rsvp); err != nil {
+		return nil, nil, err
+	}
+	return s.GetOutingByToken(token)
+}
+
+// GetOuting returns an outing for its organizer or one of its registered
+// invitees
+func (s *OutingService) GetOuting(viewerID, outingID uint) (*OutingView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var outing Outing
+	err := s.db.Where("id = ?", outingID).
+		Where("(organizer_id = ? OR id IN (?))", viewerID, s.db.Model(&OutingInvite{}).Select("outing_id").Where("user_id = ?", viewerID)).
+		First(&outing).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOutingNotFound
+		}
+		return nil, fmt.Errorf("failed to get outing: %v", err)
+	}
+
+	views, err := s.buildViews([]Outing{outing}, func(invite OutingInvite) bool {
+		return invite.UserID != nil && *invite.UserID == viewerID
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &views[0], nil
+}
+
+// GetOutingByToken returns the outing an invite token belongs to, with that
+// invite as the viewer's
+func (s *OutingService) GetOutingByToken(token string) (*OutingView, *OutingInvite, error) {
+	if s.db == nil {
+		return nil, nil, fmt.Errorf("database not connected")
+	}
+	if token == "" {
+		return nil, nil, ErrOutingNotFound
+	}
+
+	var invite OutingInvite
+	if err := s.db.Where("token = ?", token).First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrOutingNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get invite: %v", err)
+	}
+
+	var outing Outing
+	if err := s.db.First(&outing, invite.OutingID).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get outing: %v", err)
+	}
+
+	views, err := s.buildViews([]Outing{outing}, func(candidate OutingInvite) bool {
+		return candidate.ID == invite.ID
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &views[0], views[0].MyInvite, nil
+}
+
+// GetOutings returns the outings the user organizes or is invited to,
+// soonest first
+func (s *OutingService) GetOutings(userID uint) ([]OutingView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var outings []Outing
+	err := s.db.Where("organizer_id = ? OR id IN (?)", userID, s.db.Model(&OutingInvite{}).Select("outing_id").Where("user_id = ?", userID)).
+		Order("starts_at, id").
+		Find(&outings).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outings: %v", err)
+	}
+
+	return s.buildViews(outings, func(invite OutingInvite) bool {
+		return invite.UserID != nil && *invite.UserID == userID
+	})
+}
+
+// LinkScore marks one of the player's scores as their round at the outing,
+// replacing a score linked before
+func (s *OutingService) LinkScore(userID, outingID, scoreID uint) error {
+	view, err := s.GetOuting(userID, outingID)
+	if err != nil {
+		return err
+	}
+	if view.MyInvite == nil || view.MyInvite.RSVP != OutingRSVPGoing {
+		return ErrNotOutingParticipant
+	}
+	if time.Now().Unix() < view.StartsAt {
+		return ErrOutingNotStarted
+	}
+
+	var count int64
+	if err := s.db.Model(&UserCourseScore{}).Where("id = ? AND user_id = ? AND course_id = ?", scoreID, userID, view.CourseID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to get score: %v", err)
+	}
+	if count == 0 {
+		return ErrOutingScoreMismatch
+	}
+
+	if err := s.db.Model(&OutingInvite{}).Where("id = ?", view.MyInvite.ID).Update("score_id", scoreID).Error; err != nil {
+		return fmt.Errorf("failed to link score: %v", err)
+	}
+	return nil
+}
+
+// GetResults returns the scores of the players who went, best first. Scores
+// posted at the course on the day of the outing are linked automatically;
+// players can link a different score with LinkScore.
+func (s *OutingService) GetResults(viewerID, outingID uint) (*OutingView, []OutingResult, error) {
+	view, err := s.GetOuting(viewerID, outingID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if view.CancelledAt != nil || time.Now().Unix() < view.StartsAt {
+		return view, []OutingResult{}, nil
+	}
+
+	var invites []OutingInvite
+	if err := s.db.Where("outing_id = ? AND rsvp = ? AND user_id IS NOT NULL", outingID, OutingRSVPGoing).Find(&invites).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get players: %v", err)
+	}
+	if err := s.linkPostedScores(view.Outing, invites); err != nil {
+		return nil, nil, err
+	}
+
+	results := []OutingResult{}
+	for _, invite := range invites {
+		if invite.ScoreID == nil {
+			continue
+		}
+		var score UserCourseScore
+		if err := s.db.First(&score, *invite.ScoreID).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to get score: %v", err)
+		}
+
+		result := OutingResult{
+			UserID:   *invite.UserID,
+			Name:     s.userName(*invite.UserID),
+			ScoreID:  score.ID,
+			Score:    score.Score,
+			Handicap: score.Handicap,
+		}
+		if score.Handicap != nil {
+			net := math.Round((float64(score.Score)-*score.Handicap)*100) / 100
+			result.Net = &net
+		}
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score < results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+	for i := range results {
+		results[i].Rank = i + 1
+		if i > 0 && results[i].Score == results[i-1].Score {
+			results[i].Rank = results[i-1].Rank
+		}
+	}
+	return view, results, nil
+}
+
+// ScoresAtCourse returns the player's scores at a course, newest first, for
+// choosing which one to link to an outing
+func (s *OutingService) ScoresAtCourse(userID, courseID uint) ([]UserCourseScore, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var scores []UserCourseScore
+	if err := s.db.Where("user_id = ? AND course_id = ?", userID, courseID).Order("created_at DESC, id DESC").Find(&scores).Error; err != nil {
+		return nil, fmt.Errorf("failed to get scores: %v", err)
+	}
+	return scores, nil
+}
+
+// CourseChoices returns the courses an outing can be planned at, by name
+func (s *OutingService) CourseChoices() ([]CourseDB, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var courses []CourseDB
+	if err := s.db.Select("id", "name", "address").Order("name, id").Find(&courses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get courses: %v", err)
+	}
+	return courses, nil
+}
+
+// CalendarFeedToken returns the token of the user's outing calendar feed,
+// creating it if needed
+func (s *OutingService) CalendarFeedToken(userID uint) (string, error) {
+	if s.db == nil {
+		return "", fmt.Errorf("database not connected")
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return "", err
+	}
+	feed := OutingCalendarFeed{UserID: userID}
+	if err := s.db.Where(&feed).Attrs(OutingCalendarFeed{Token: token}).FirstOrCreate(&feed).Error; err != nil {
+		return "", fmt.Errorf("failed to get calendar feed: %v", err)
+	}
+	return feed.Token, nil
+}
+
+// GetCalendarFeed returns the outings in the calendar feed with token: those
+// its owner organizes or hasn't declined
+func (s *OutingService) GetCalendarFeed(token string) ([]OutingView, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+	if token == "" {
+		return nil, ErrOutingNotFound
+	}
+
+	var feed OutingCalendarFeed
+	if err := s.db.Where("token = ?", token).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOutingNotFound
+		}
+		return nil, fmt.Errorf("failed to get calendar feed: %v", err)
+	}
+
+	outings, err := s.GetOutings(feed.UserID)
+	if err != nil {
+		return nil, err
+	}
+	feedOutings := make([]OutingView, 0, len(outings))
+	for _, outing := range outings {
+		if outing.IsOrganizer || (outing.MyInvite != nil && outing.MyInvite.RSVP != OutingRSVPDeclined) {
+			feedOutings = append(feedOutings, outing)
+		}
+	}
+	return feedOutings, nil
+}
+
+// OutingCalendarEvents converts outings to calendar events. baseURL is the
+// site address the events link back to.
+func OutingCalendarEvents(outings []OutingView, baseURL string) []api.CalendarEvent {
+	baseURL = strings.TrimRight(baseURL, "/")
+	events := make([]api.CalendarEvent, 0, len(outings))
+	for _, outing := range outings {
+		start := time.Unix(outing.StartsAt, 0)
+		description := "Organized by " + outing.OrganizerName
+		if outing.Notes != nil && *outing.Notes != "" {
+			description += "\n\n" + *outing.Notes
+		}
+		location := outing.CourseName
+		if outing.CourseAddress != "" {
+			location += ", " + outing.CourseAddress
+		}
+
+		event := api.CalendarEvent{
+			UID:         fmt.Sprintf("outing-%d@course-management", outing.ID),
+			Sequence:    outing.Sequence,
+			Start:       start,
+			End:         start.Add(outingLength),
+			Summary:     outing.Title,
+			Location:    location,
+			Description: description,
+			Cancelled:   outing.CancelledAt != nil,
+		}
+		if baseURL != "" {
+			event.URL = fmt.Sprintf("%s/outings/%d", baseURL, outing.ID)
+		}
+		if outing.Course != nil {
+			event.Latitude = outing.Course.Latitude
+			event.Longitude = outing.Course.Longitude
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// respond saves an RSVP and fills or frees spots as needed
+func (s *OutingService) respond(invite *OutingInvite, rsvp string) error {
+	if rsvp != OutingRSVPGoing && rsvp != OutingRSVPMaybe && rsvp != OutingRSVPDeclined {
+		return ErrInvalidRSVP
+	}
+
+	var promoted []OutingInvite
+	var outing Outing
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&outing, invite.OutingID).Error; err != nil {
+			return fmt.Errorf("failed to get outing: %v", err)
+		}
+		if outing.CancelledAt != nil {
+			return ErrOutingCancelled
+		}
+
+		wasGoing := invite.RSVP == OutingRSVPGoing
+		if rsvp == OutingRSVPGoing && !wasGoing {
+			full, err := outingFull(tx, outing)
+			if err != nil {
+				return err
+			}
+			if full {
+				rsvp = OutingRSVPWaitlisted
+			}
+		}
+		if rsvp == invite.RSVP {
+			return nil
+		}
+
+		now := time.Now().Unix()
+		invite.RSVP = rsvp
+		invite.RespondedAt = &now
+		if err := tx.Model(invite).Updates(map[string]interface{}{"rsvp": rsvp, "responded_at": now}).Error; err != nil {
+			return fmt.Errorf("failed to save RSVP: %v", err)
+		}
+
+		if wasGoing {
+			var err error
+			promoted, err = fillOpenSpots(tx, outing)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyPromoted(outing, promoted)
+	return nil
+}
+
+// notifyPromoted tells registered players they moved off the waitlist
+func (s *OutingService) notifyPromoted(outing Outing, promoted []OutingInvite) {
+	message := fmt.Sprintf("A spot opened up: you're going to %s on %s", outing.Title, formatOutingTime(outing.StartsAt))
+	notifications := &NotificationService{db: s.db}
+	for _, invite := range promoted {
+		if invite.UserID == nil {
+			continue
+		}
+		if err := notifications.notify(*invite.UserID, "outing_update", nil, &outing.CourseID, message); err != nil {
+			log.Printf("[OUTINGS] Failed to notify user %d of waitlist spot: %v", *invite.UserID, err)
+		}
+	}
+}
+
+// resolveInvitees turns user IDs and email addresses into unsaved invites
+func (s *OutingService) resolveInvitees(userIDs []uint, emails []string) ([]OutingInvite, error) {
+	invites := make([]OutingInvite, 0, len(userIDs)+len(emails))
+
+	if len(userIDs) > 0 {
+		var count int64
+		if err := s.db.Model(&User{}).Where("id IN ?", userIDs).Distinct("id").Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to get users: %v", err)
+		}
+		unique := make(map[uint]bool, len(userIDs))
+		for _, id := range userIDs {
+			unique[id] = true
+		}
+		if int(count) != len(unique) {
+			return nil, ErrUserNotFound
+		}
+		for _, id := range userIDs {
+			id := id
+			invites = append(invites, OutingInvite{UserID: &id})
+		}
+	}
+
+	for _, email := range emails {
+		address, err := mail.ParseAddress(strings.TrimSpace(email))
+		if err != nil {
+			return nil, ErrInvalidOutingInvitee
+		}
+		normalized := strings.ToLower(address.Address)
+
+		var user User
+		err = s.db.Select("id").Where("LOWER(email) = ?", normalized).First(&user).Error
+		switch {
+		case err == nil:
+			invites = append(invites, OutingInvite{UserID: &user.ID})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			invites = append(invites, OutingInvite{Email: &normalized})
+		default:
+			return nil, fmt.Errorf("failed to get users: %v", err)
+		}
+	}
+	return invites, nil
+}
+
+// linkPostedScores links a score to each player who went and hasn't linked
+// one, or whose linked score was deleted. A score counts when it was played at
+// the course on the day of the outing, or has no date and was posted soon
+// after the start.
+func (s *OutingService) linkPostedScores(outing Outing, invites []OutingInvite) error {
+	start := time.Unix(outing.StartsAt, 0)
+	day := start.Format("2006-01-02")
+
+	for i := range invites {
+		invite := &invites[i]
+		linked := invite.ScoreID
+		if invite.ScoreID != nil {
+			var count int64
+			if err := s.db.Model(&UserCourseScore{}).Where("id = ?", *invite.ScoreID).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to get score: %v", err)
+			}
+			if count > 0 {
+				continue
+			}
+			invite.ScoreID = nil
+		}
+
+		var score UserCourseScore
+		err := s.db.Where("user_id = ? AND course_id = ?", *invite.UserID, outing.CourseID).
+			Where("date_played = ? OR (date_played IS NULL AND created_at BETWEEN ? AND ?)", day, outing.StartsAt, start.Add(outingScoreWindow).Unix()).
+			Order("id").
+			First(&score).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to find posted score: %v", err)
+		}
+		if err == nil {
+			invite.ScoreID = &score.ID
+		}
+		if invite.ScoreID == nil && linked == nil {
+			continue
+		}
+
+		if err := s.db.Model(invite).Update("score_id", invite.ScoreID).Error; err != nil {
+			return fmt.Errorf("failed to link score: %v", err)
+		}
+	}
+	return nil
+}
+
+// organizedOuting loads an outing the user organizes
+func (s *OutingService) organizedOuting(organizerID, outingID uint) (*Outing, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var outing Outing
+	if err := s.db.Where("id = ? AND organizer_id = ?", outingID, organizerID).First(&outing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOutingNotFound
+		}
+		return nil, fmt.Errorf("failed to get outing: %v", err)
+	}
+	return &outing, nil
+}
+
+// notifyInvitees sends an "outing_update" notification to every registered
+// invitee other than the organizer who hasn't declined
+func (s *OutingService) notifyInvitees(outing *Outing, message string) {
+	var userIDs []uint
+	if err := s.db.Model(&OutingInvite{}).
+		Where("outing_id = ? AND user_id IS NOT NULL AND user_id <> ? AND rsvp <> ?", outing.ID, outing.OrganizerID, OutingRSVPDeclined).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		log.Printf("[OUTINGS] Failed to get invitees of outing %d: %v", outing.ID, err)
+		return
+	}
+
+	notifications := &NotificationService{db: s.db}
+	for _, userID := range userIDs {
+		if err := notifications.notify(userID, "outing_update", &outing.OrganizerID, &outing.CourseID, message); err != nil {
+			log.Printf("[OUTINGS] Failed to notify user %d of outing %d: %v", userID, outing.ID, err)
+		}
+	}
+}
+
+// buildViews loads the courses and invite lists of outings. isViewer picks
+// out the viewer's own invite. Only organizers see email invitees' addresses;
+// everyone else sees them as guests.
+func (s *OutingService) buildViews(outings []Outing, isViewer func(OutingInvite) bool) ([]OutingView, error) {
+	views := make([]OutingView, 0, len(outings))
+	if len(outings) == 0 {
+		return views, nil
+	}
+
+	ids := make([]uint, 0, len(outings))
+	courseIDs := make([]uint, 0, len(outings))
+	for _, outing := range outings {
+		ids = append(ids, outing.ID)
+		courseIDs = append(courseIDs, outing.CourseID)
+	}
+
+	var courses []CourseDB
+	if err := s.db.Select("id", "name", "address", "latitude", "longitude").Where("id IN ?", courseIDs).Find(&courses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get courses: %v", err)
+	}
+	coursesByID := make(map[uint]*CourseDB, len(courses))
+	for i := range courses {
+		coursesByID[courses[i].ID] = &courses[i]
+	}
+
+	var invites []OutingInvite
+	if err := s.db.Where("outing_id IN ?", ids).Order("created_at, id").Find(&invites).Error; err != nil {
+		return nil, fmt.Errorf("failed to get invites: %v", err)
+	}
+	invitesByOuting := make(map[uint][]OutingInvite, len(outings))
+	for _, invite := range invites {
+		invitesByOuting[invite.OutingID] = append(invitesByOuting[invite.OutingID], invite)
+	}
+
+	names := make(map[uint]string)
+	nameOf := func(userID uint) string {
+		if name, ok := names[userID]; ok {
+			return name
+		}
+		names[userID] = s.userName(userID)
+		return names[userID]
+	}
+
+	for _, outing := range outings {
+		view := OutingView{
+			Outing:        outing,
+			OrganizerName: nameOf(outing.OrganizerID),
+			Invitees:      []OutingInvitee{},
+			SpotsLeft:     -1,
+		}
+		if course, ok := coursesByID[outing.CourseID]; ok {
+			view.Outing.Course = course
+			view.CourseName = course.Name
+			view.CourseAddress = course.Address
+		}
+
+		for _, invite := range invitesByOuting[outing.ID] {
+			if isViewer(invite) {
+				invite := invite
+				view.MyInvite = &invite
+				view.IsOrganizer = invite.UserID != nil && *invite.UserID == outing.OrganizerID
+			}
+		}
+		for _, invite := range invitesByOuting[outing.ID] {
+			invitee := OutingInvitee{InviteID: invite.ID, UserID: invite.UserID, RSVP: invite.RSVP, RespondedAt: invite.RespondedAt}
+			switch {
+			case invite.UserID != nil:
+				invitee.Name = nameOf(*invite.UserID)
+			case view.IsOrganizer && invite.Email != nil:
+				invitee.Name = *invite.Email
+				invitee.Email = invite.Email
+			default:
+				invitee.Name = "Guest"
+			}
+			view.Invitees = append(view.Invitees, invitee)
+
+			if invite.RSVP == OutingRSVPGoing {
+				view.GoingCount++
+			}
+		}
+		if outing.Capacity > 0 {
+			view.SpotsLeft = outing.Capacity - view.GoingCount
+			if view.SpotsLeft < 0 {
+				view.SpotsLeft = 0
+			}
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (s *OutingService) userName(userID uint) string {
+	return (&ReviewCommentService{db: s.db}).AuthorName(userID)
+}
+
+// fillOpenSpots moves players off the waitlist, longest waiting first, until
+// the outing is full, and returns the players it moved
+func fillOpenSpots(db *gorm.DB, outing Outing) ([]OutingInvite, error) {
+	var waitlist []OutingInvite
+	if err := db.Where("outing_id = ? AND rsvp = ?", outing.ID, OutingRSVPWaitlisted).
+		Order("responded_at, id").
+		Find(&waitlist).Error; err != nil {
+		return nil, fmt.Errorf("failed to get waitlist: %v", err)
+	}
+
+	var promoted []OutingInvite
+	for _, invite := range waitlist {
+		full, err := outingFull(db, outing)
+		if err != nil {
+			return nil, err
+		}
+		if full {
+			break
+		}
+		if err := db.Model(&invite).Update("rsvp", OutingRSVPGoing).Error; err != nil {
+			return nil, fmt.Errorf("failed to move player off waitlist: %v", err)
+		}
+		promoted = append(promoted, invite)
+	}
+	return promoted, nil
+}
+
+// outingFull reports whether every spot of an outing is taken
+func outingFull(db *gorm.DB, outing Outing) (bool, error) {
+	if outing.Capacity <= 0 {
+		return false, nil
+	}
+	var going int64
+	if err := db.Model(&OutingInvite{}).Where("outing_id = ? AND rsvp = ?", outing.ID, OutingRSVPGoing).Count(&going).Error; err != nil {
+		return false, fmt.Errorf("failed to count players: %v", err)
+	}
+	return int(going) >= outing.Capacity, nil
+}
+
+// applyOutingInput validates input and copies it onto the outing
+func applyOutingInput(outing *Outing, input OutingInput) error {
+	title := strings.TrimSpace(input.Title)
+	if title == "" || utf8.RuneCountInString(title) > maxOutingTitleLength {
+		return ErrInvalidOutingTitle
+	}
+	if input.Capacity < 0 || input.Capacity > maxOutingCapacity {
+		return ErrInvalidOutingCapacity
+	}
+	if !input.StartsAt.After(time.Now()) {
+		return ErrOutingInPast
+	}
+
+	notes := strings.TrimSpace(input.Notes)
+	if utf8.RuneCountInString(notes) > maxOutingNotesLength {
+		return ErrOutingNotesTooLong
+	}
+
+	outing.Title = title
+	outing.StartsAt = input.StartsAt.Unix()
+	outing.Capacity = input.Capacity
+	outing.Notes = nil
+	if notes != "" {
+		outing.Notes = &notes
+	}
+	return nil
+}
+
+// outingInviteKey identifies the person an invite is for
+func outingInviteKey(invite OutingInvite) string {
+	if invite.UserID != nil {
+		return fmt.Sprintf("user:%d", *invite.UserID)
+	}
+	if invite.Email != nil {
+		return "email:" + strings.ToLower(*invite.Email)
+	}
+	return ""
+}
+
+// When formats the outing's start for pages
+func (v OutingView) When() string {
+	return formatOutingTime(v.StartsAt)
+}
+
+// formatOutingTime formats an outing's start for messages, e.g.
+// "Sat, May 2 at 2:30 PM"
+func formatOutingTime(startsAt int64) string {
+	return time.Unix(startsAt, 0).Format("Mon, Jan 2 at 3:04 PM")
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestOuting(t *testing.T, service *OutingService, organizerID, courseID uint, capacity int) *OutingView {
+	t.Helper()
+	outing, err := service.CreateOuting(organizerID, OutingInput{
+		CourseID: courseID,
+		Title:    "  Saturday skins  ",
+		StartsAt: time.Now().Add(48 * time.Hour),
+		Capacity: capacity,
+		Notes:    "Carts are booked",
+	})
+	require.NoError(t, err)
+	return outing
+}
+
+// inviteeRSVPs maps "user:<id>" for users and the address for email invitees
+// to their RSVP
+func inviteeRSVPs(outing *OutingView) map[string]string {
+	rsvps := make(map[string]string, len(outing.Invitees))
+	for _, invitee := range outing.Invitees {
+		key := invitee.Name
+		if invitee.UserID != nil {
+			key = fmt.Sprintf("user:%d", *invitee.UserID)
+		}
+		rsvps[key] = invitee.RSVP
+	}
+	return rsvps
+}
+
+func userKey(user User) string {
+	return fmt.Sprintf("user:%d", user.ID)
+}
+
+func TestOutingService_InvitesAndWaitlist(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewOutingService()
+
+	outing := createTestOuting(t, service, owner.ID, course.ID, 2)
+	assert.Equal(t, "Saturday skins", outing.Title)
+	assert.Equal(t, "Pebble Creek", outing.CourseName)
+	assert.True(t, outing.IsOrganizer)
+	assert.Equal(t, 1, outing.GoingCount, "the organizer is going")
+	assert.Equal(t, 1, outing.SpotsLeft)
+
+	_, err := service.GetOuting(golfer.ID, outing.ID)
+	assert.ErrorIs(t, err, ErrOutingNotFound, "only invitees can see an outing")
+
+	outing, err = service.Invite(owner.ID, outing.ID, []uint{golfer.ID, golfer.ID}, []string{"REVIEWER@example.com", "Friend <friend@example.com>", "owner@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		userKey(owner):       OutingRSVPGoing,
+		userKey(golfer):      OutingRSVPInvited,
+		userKey(reviewer):    OutingRSVPInvited,
+		"friend@example.com": OutingRSVPInvited,
+	}, inviteeRSVPs(outing), "registered emails invite the user and repeats are skipped")
+
+	invites := notificationsFor(t, db, golfer.ID)
+	require.Len(t, invites, 1)
+	assert.Equal(t, "outing_invite", invites[0].Type)
+	assert.Contains(t, invites[0].Message, "Course Owner invited you to Saturday skins")
+
+	_, err = service.Invite(golfer.ID, outing.ID, []uint{reviewer.ID}, nil)
+	assert.ErrorIs(t, err, ErrOutingNotFound, "only the organizer invites")
+	_, err = service.Invite(owner.ID, outing.ID, []uint{9999}, nil)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	_, err = service.Invite(owner.ID, outing.ID, nil, []string{"not an email"})
+	assert.ErrorIs(t, err, ErrInvalidOutingInvitee)
+
+	outing, err = service.RSVP(golfer.ID, outing.ID, OutingRSVPGoing)
+	require.NoError(t, err)
+	assert.Equal(t, 0, outing.SpotsLeft)
+
+	outing, err = service.RSVP(reviewer.ID, outing.ID, OutingRSVPGoing)
+	require.NoError(t, err)
+	assert.Equal(t, OutingRSVPWaitlisted, outing.MyInvite.RSVP, "a full outing waitlists new players")
+
+	_, err = service.RSVP(reviewer.ID, outing.ID, OutingRSVPWaitlisted)
+	assert.ErrorIs(t, err, ErrInvalidRSVP)
+
+	outing, err = service.RSVP(golfer.ID, outing.ID, OutingRSVPDeclined)
+	require.NoError(t, err)
+	assert.Equal(t, OutingRSVPGoing, inviteeRSVPs(outing)[userKey(reviewer)], "the waitlist fills the open spot")
+
+	updates := notificationsFor(t, db, reviewer.ID)
+	require.Len(t, updates, 2)
+	assert.Equal(t, "outing_update", updates[1].Type)
+	assert.Contains(t, updates[1].Message, "A spot opened up")
+
+	require.NoError(t, service.CancelOuting(owner.ID, outing.ID))
+	_, err = service.RSVP(golfer.ID, outing.ID, OutingRSVPGoing)
+	assert.ErrorIs(t, err, ErrOutingCancelled)
+	assert.Len(t, notificationsFor(t, db, golfer.ID), 1, "players who declined don't hear about the cancellation")
+	assert.Len(t, notificationsFor(t, db, reviewer.ID), 3)
+}
+
+func TestOutingService_Validation(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewOutingService()
+	tomorrow := time.Now().Add(24 * time.Hour)
+
+	for name, tc := range map[string]struct {
+		input OutingInput
+		err   error
+	}{
+		"missing title":  {OutingInput{CourseID: course.ID, Title: " ", StartsAt: tomorrow}, ErrInvalidOutingTitle},
+		"long title":     {OutingInput{CourseID: course.ID, Title: strings.Repeat("a", 101), StartsAt: tomorrow}, ErrInvalidOutingTitle},
+		"in the past":    {OutingInput{CourseID: course.ID, Title: "Skins", StartsAt: time.Now().Add(-time.Hour)}, ErrOutingInPast},
+		"capacity":       {OutingInput{CourseID: course.ID, Title: "Skins", StartsAt: tomorrow, Capacity: -1}, ErrInvalidOutingCapacity},
+		"unknown course": {OutingInput{CourseID: 9999, Title: "Skins", StartsAt: tomorrow}, ErrCourseNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.CreateOuting(owner.ID, tc.input)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	t.Run("raising the capacity fills it from the waitlist", func(t *testing.T) {
+		outing := createTestOuting(t, service, owner.ID, course.ID, 1)
+		_, err := service.Invite(owner.ID, outing.ID, []uint{golfer.ID}, nil)
+		require.NoError(t, err)
+		_, err = service.RSVP(golfer.ID, outing.ID, OutingRSVPGoing)
+		require.NoError(t, err)
+
+		outing, err = service.UpdateOuting(owner.ID, outing.ID, OutingInput{Title: "Skins", StartsAt: time.Unix(outing.StartsAt, 0), Capacity: 4})
+		require.NoError(t, err)
+		assert.Equal(t, OutingRSVPGoing, inviteeRSVPs(outing)[userKey(golfer)])
+		assert.Equal(t, 2, outing.SpotsLeft)
+		assert.Equal(t, 1, outing.Sequence)
+		assert.Nil(t, outing.Notes)
+	})
+}
+
+func TestOutingService_EmailInvites(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	course := seedCourse(t, db, &owner.ID)
+
+	var outbox bytes.Buffer
+	mailer, err := NewOutingMailer(NewWriterEmailSender(&outbox, "Course Management <noreply@example.com>"), "views/email", "https://golf.example.com/")
+	require.NoError(t, err)
+	service := &OutingService{db: db, mailer: mailer}
+
+	outing := createTestOuting(t, service, owner.ID, course.ID, 0)
+	_, err = service.Invite(owner.ID, outing.ID, nil, []string{"friend@example.com"})
+	require.NoError(t, err)
+
+	var invite OutingInvite
+	require.NoError(t, db.Where("email = ?", "friend@example.com").First(&invite).Error)
+
+	decoded, err := io.ReadAll(quotedprintable.NewReader(&outbox))
+	require.NoError(t, err)
+	email := string(decoded)
+	assert.Contains(t, email, "To: friend@example.com")
+	assert.Contains(t, email, "Course Owner invited you to Saturday skins")
+	assert.Contains(t, email, `filename="outing.ics"`)
+	assert.Contains(t, email, "https://golf.example.com/outings/rsvp/"+invite.Token)
+	assert.NotContains(t, email, "golfer@example.com")
+
+	view, mine, err := service.RSVPByToken(invite.Token, OutingRSVPMaybe)
+	require.NoError(t, err)
+	assert.Equal(t, OutingRSVPMaybe, mine.RSVP)
+	organizerView, err := service.GetOuting(owner.ID, outing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, OutingRSVPMaybe, inviteeRSVPs(organizerView)["friend@example.com"])
+	assert.Equal(t, -1, view.SpotsLeft, "no capacity means no limit")
+	assert.Equal(t, OutingRSVPMaybe, inviteeRSVPs(view)["Guest"], "guests don't see each other's addresses")
+
+	_, _, err = service.RSVPByToken("nope", OutingRSVPGoing)
+	assert.ErrorIs(t, err, ErrOutingNotFound)
+}
+
+func TestOutingService_Results(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewOutingService()
+
+	outing := createTestOuting(t, service, owner.ID, course.ID, 0)
+	_, err := service.Invite(owner.ID, outing.ID, []uint{golfer.ID, reviewer.ID}, nil)
+	require.NoError(t, err)
+	for _, userID := range []uint{golfer.ID, reviewer.ID} {
+		_, err = service.RSVP(userID, outing.ID, OutingRSVPGoing)
+		require.NoError(t, err)
+	}
+
+	assert.ErrorIs(t, service.LinkScore(golfer.ID, outing.ID, 1), ErrOutingNotStarted)
+
+	// Play the round
+	start := time.Now().Add(-3 * time.Hour)
+	require.NoError(t, db.Model(&Outing{}).Where("id = ?", outing.ID).Update("starts_at", start.Unix()).Error)
+
+	day := start.Format("2006-01-02")
+	otherDay := start.AddDate(0, 0, -10).Format("2006-01-02")
+	handicap := 8.5
+	scores := []UserCourseScore{
+		{UserID: golfer.ID, CourseID: course.ID, Score: 84, DatePlayed: &day, Handicap: &handicap},
+		{UserID: owner.ID, CourseID: course.ID, Score: 79},
+		{UserID: reviewer.ID, CourseID: course.ID, Score: 90, DatePlayed: &otherDay},
+		{UserID: reviewer.ID, CourseID: course.ID, Score: 79, DatePlayed: &otherDay},
+	}
+	require.NoError(t, db.Create(&scores).Error)
+
+	_, results, err := service.GetResults(golfer.ID, outing.ID)
+	require.NoError(t, err)
+	require.Len(t, results, 2, "the reviewer's scores are from another day")
+	assert.Equal(t, "Course Owner", results[0].Name)
+	assert.Equal(t, 79, results[0].Score)
+	assert.Equal(t, 2, results[1].Rank)
+	require.NotNil(t, results[1].Net)
+	assert.InDelta(t, 75.5, *results[1].Net, 0.001)
+
+	assert.ErrorIs(t, service.LinkScore(reviewer.ID, outing.ID, scores[0].ID), ErrOutingScoreMismatch)
+	require.NoError(t, service.LinkScore(reviewer.ID, outing.ID, scores[3].ID))
+
+	_, results, err = service.GetResults(owner.ID, outing.ID)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, []int{1, 1, 3}, []int{results[0].Rank, results[1].Rank, results[2].Rank}, "ties share a rank")
+
+	// Re-saving scores replaces them; the link follows the new score
+	require.NoError(t, db.Delete(&scores[0]).Error)
+	replacement := UserCourseScore{UserID: golfer.ID, CourseID: course.ID, Score: 82, DatePlayed: &day}
+	require.NoError(t, db.Create(&replacement).Error)
+	_, results, err = service.GetResults(golfer.ID, outing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 82, results[2].Score)
+}
+
+func TestOutingService_CalendarFeed(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewOutingService()
+
+	first := createTestOuting(t, service, owner.ID, course.ID, 0)
+	second := createTestOuting(t, service, owner.ID, course.ID, 0)
+	for _, outing := range []*OutingView{first, second} {
+		_, err := service.Invite(owner.ID, outing.ID, []uint{golfer.ID}, nil)
+		require.NoError(t, err)
+	}
+	_, err := service.RSVP(golfer.ID, second.ID, OutingRSVPDeclined)
+	require.NoError(t, err)
+	require.NoError(t, service.CancelOuting(owner.ID, first.ID))
+
+	token, err := service.CalendarFeedToken(golfer.ID)
+	require.NoError(t, err)
+	again, err := service.CalendarFeedToken(golfer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, token, again)
+
+	outings, err := service.GetCalendarFeed(token)
+	require.NoError(t, err)
+	require.Len(t, outings, 1, "declined outings are left out")
+
+	events := OutingCalendarEvents(outings, "https://golf.example.com/")
+	require.Len(t, events, 1)
+	assert.True(t, events[0].Cancelled, "cancelled outings stay so calendars remove them")
+	assert.Equal(t, 1, events[0].Sequence)
+	assert.Equal(t, "Pebble Creek, 1 Fairway Dr", events[0].Location)
+	assert.Equal(t, fmt.Sprintf("https://golf.example.com/outings/%d", first.ID), events[0].URL)
+	assert.Equal(t, 5*time.Hour, events[0].End.Sub(events[0].Start))
+
+	_, err = service.GetCalendarFeed("unknown")
+	assert.ErrorIs(t, err, ErrOutingNotFound)
+
+	var feeds int64
+	require.NoError(t, db.Model(&OutingCalendarFeed{}).Count(&feeds).Error)
+	assert.Equal(t, int64(1), feeds)
+}
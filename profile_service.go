@@ -0,0 +1,424 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"course_management/api"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Profile section audiences
+const (
+	// ProfileAudiencePublic sections are shown to everyone, signed in or not
+	ProfileAudiencePublic = "public"
+	// ProfileAudienceFollowers sections are shown to the user's followers
+	ProfileAudienceFollowers = "followers"
+	// ProfileAudiencePrivate sections are only shown to the user
+	ProfileAudiencePrivate = "private"
+)
+
+const (
+	profileReviewLimit   = 20
+	profileActivityLimit = 10
+)
+
+var ErrInvalidProfileAudience = errors.New("audience must be public, followers or private")
+
+// DefaultProfilePrivacy applies to users who never changed their settings.
+// Handicaps are only shown once the user chooses to share theirs.
+var DefaultProfilePrivacy = ProfilePrivacy{
+	Handicap:      ProfileAudiencePrivate,
+	Reviews:       ProfileAudiencePublic,
+	PlayedCourses: ProfileAudiencePublic,
+	Activity:      ProfileAudiencePublic,
+}
+
+// ProfileService builds public profiles and manages who can see each section
+type ProfileService struct {
+	db *gorm.DB
+}
+
+// ProfilePrivacyField is one section's audience choice on the privacy form
+type ProfilePrivacyField struct {
+	Name    string
+	Label   string
+	Options []ProfileAudienceOption
+}
+
+// ProfileAudienceOption is an audience choice on the privacy form
+type ProfileAudienceOption struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+func NewProfileService() *ProfileService {
+	return &ProfileService{
+		db: GetDB(),
+	}
+}
+
+// GetPrivacy returns who can see each section of the user's profile
+func (s *ProfileService) GetPrivacy(userID uint) (ProfilePrivacy, error) {
+	if s.db == nil {
+		return ProfilePrivacy{}, fmt.Errorf("database not connected")
+	}
+
+	var privacy ProfilePrivacy
+	if err := s.db.Where("user_id = ?", userID).First(&privacy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			privacy = DefaultProfilePrivacy
+			privacy.UserID = userID
+			return privacy, nil
+		}
+		return ProfilePrivacy{}, fmt.Errorf("failed to get profile privacy: %v", err)
+	}
+	return privacy, nil
+}
+
+// UpdatePrivacy changes who can see the user's profile sections. Sections
+// left blank in update keep their current audience.
+func (s *ProfileService) UpdatePrivacy(userID uint, update ProfilePrivacy) (ProfilePrivacy, error) {
+	privacy, err := s.GetPrivacy(userID)
+	if err != nil {
+		return ProfilePrivacy{}, err
+	}
+
+	for _, field := range []struct {
+		current *string
+		value   string
+	}{
+		{&privacy.Handicap, update.Handicap},
+		{&privacy.Reviews, update.Reviews},
+		{&privacy.PlayedCourses, update.PlayedCourses},
+		{&privacy.Activity, update.Activity},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if !isProfileAudience(field.value) {
+			return ProfilePrivacy{}, ErrInvalidProfileAudience
+		}
+		*field.current = field.value
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"handicap", "reviews", "played_courses", "activity", "updated_at"}),
+	}).Create(&privacy).Error; err != nil {
+		return ProfilePrivacy{}, fmt.Errorf("failed to save profile privacy: %v", err)
+	}
+	return s.GetPrivacy(userID)
+}
+
+// GetProfile builds userID's profile as viewerID may see it. viewerID is nil
+// for signed-out visitors.
+func (s *ProfileService) GetProfile(userID uint, viewerID *uint) (*PublicProfile, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var user User
+	if err := s.db.Select("id", "display_name", "handicap", "created_at").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	profile := &PublicProfile{
+		UserID:      user.ID,
+		Name:        (&ReviewCommentService{db: s.db}).AuthorName(user.ID),
+		MemberSince: user.CreatedAt,
+		IsOwn:       viewerID != nil && *viewerID == user.ID,
+	}
+
+	followService := &FollowService{db: s.db}
+	var err error
+	if viewerID != nil && !profile.IsOwn {
+		following, err := followService.FollowingSet(*viewerID, []uint{user.ID})
+		if err != nil {
+			return nil, err
+		}
+		profile.IsFollowing = following[user.ID]
+	}
+	if profile.Followers, err = followService.CountFollowers(user.ID); err != nil {
+		return nil, err
+	}
+	if profile.Following, err = followService.CountFollowing(user.ID); err != nil {
+		return nil, err
+	}
+
+	if profile.Privacy, err = s.GetPrivacy(user.ID); err != nil {
+		return nil, err
+	}
+	canSee := func(audience string) bool {
+		return profileAudienceAllows(audience, profile.IsOwn, profile.IsFollowing)
+	}
+
+	if profile.ShowHandicap = canSee(profile.Privacy.Handicap); profile.ShowHandicap {
+		profile.Handicap = user.Handicap
+	}
+	if profile.ShowReviews = canSee(profile.Privacy.Reviews); profile.ShowReviews {
+		if err := s.loadReviews(profile); err != nil {
+			return nil, err
+		}
+	}
+	if profile.ShowPlayed = canSee(profile.Privacy.PlayedCourses); profile.ShowPlayed {
+		if err := s.loadPlayedCourses(profile); err != nil {
+			return nil, err
+		}
+	}
+	if profile.ShowActivity = canSee(profile.Privacy.Activity); profile.ShowActivity {
+		if err := s.loadActivity(profile, viewerID); err != nil {
+			return nil, err
+		}
+	}
+	return profile, nil
+}
+
+// loadReviews lists the user's most recent reviews. Other viewers only see
+// public reviews, so anonymous ones can't be traced back to the user.
+func (s *ProfileService) loadReviews(profile *PublicProfile) error {
+	query := s.db.Model(&CourseReview{}).Where("user_id = ?", profile.UserID)
+	if !profile.IsOwn {
+		query = query.Where("visibility = ?", ReviewVisibilityPublic)
+	}
+
+	if err := query.Count(&profile.ReviewCount).Error; err != nil {
+		return fmt.Errorf("failed to count reviews: %v", err)
+	}
+
+	var reviews []CourseReview
+	if err := query.Preload("Course", func(db *gorm.DB) *gorm.DB { return db.Select("id", "name") }).
+		Order("created_at DESC, id DESC").
+		Limit(profileReviewLimit).
+		Find(&reviews).Error; err != nil {
+		return fmt.Errorf("failed to get reviews: %v", err)
+	}
+
+	for _, review := range reviews {
+		item := ProfileReview{
+			CourseReview: review,
+			CourseName:   "a removed course",
+			PostedOn:     formatCommentDate(review.CreatedAt),
+		}
+		if review.Course != nil {
+			item.CourseName = review.Course.Name
+		}
+		profile.Reviews = append(profile.Reviews, item)
+	}
+	return nil
+}
+
+// loadPlayedCourses summarizes the user's score history by course, most
+// recently played first
+func (s *ProfileService) loadPlayedCourses(profile *PublicProfile) error {
+	var rows []struct {
+		CourseID   uint
+		Rounds     int
+		BestScore  int
+		LastPlayed int64
+	}
+	if err := s.db.Model(&UserCourseScore{}).
+		Select("course_id, COUNT(*) AS rounds, MIN(score) AS best_score, MAX(created_at) AS last_played").
+		Where("user_id = ?", profile.UserID).
+		Group("course_id").
+		Order("last_played DESC, course_id").
+		Scan(&rows).Error; err != nil {
+		return fmt.Errorf("failed to get played courses: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	courseIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		courseIDs = append(courseIDs, row.CourseID)
+	}
+	var courses []CourseDB
+	if err := s.db.Select("id", "name", "latitude", "longitude").Where("id IN ?", courseIDs).Find(&courses).Error; err != nil {
+		return fmt.Errorf("failed to get played courses: %v", err)
+	}
+	coursesByID := make(map[uint]CourseDB, len(courses))
+	for _, course := range courses {
+		coursesByID[course.ID] = course
+	}
+
+	for _, row := range rows {
+		course, ok := coursesByID[row.CourseID]
+		if !ok {
+			continue
+		}
+		profile.Played = append(profile.Played, PlayedCourse{
+			CourseID:   course.ID,
+			CourseName: course.Name,
+			Latitude:   course.Latitude,
+			Longitude:  course.Longitude,
+			Rounds:     row.Rounds,
+			BestScore:  row.BestScore,
+			LastPlayed: row.LastPlayed,
+		})
+		profile.RoundsPlayed += row.Rounds
+		if profile.BestScore == nil || row.BestScore < *profile.BestScore {
+			best := row.BestScore
+			profile.BestScore = &best
+		}
+	}
+	return nil
+}
+
+// loadActivity lists the user's recent activity. Activity that would give
+// away a hidden section, like the scores behind hidden played courses, is
+// left out.
+func (s *ProfileService) loadActivity(profile *PublicProfile, viewerID *uint) error {
+	types := make([]string, 0, len(api.FeedActivityTypes))
+	for _, activityType := range api.FeedActivityTypes {
+		if (activityType == "course_review" && !profile.ShowReviews) ||
+			(activityType == "score_posted" && !profile.ShowPlayed) {
+			continue
+		}
+		types = append(types, activityType)
+	}
+
+	var activities []UserActivity
+	if err := s.db.Scopes(visibleActivities(viewerID)).
+		Where("user_activities.user_id = ? AND user_activities.activity_type IN ?", profile.UserID, types).
+		Order("user_activities.created_at DESC, user_activities.id DESC").
+		Limit(profileActivityLimit).
+		Find(&activities).Error; err != nil {
+		return fmt.Errorf("failed to get activity: %v", err)
+	}
+
+	items, err := (&FollowService{db: s.db}).describeActivities(activities)
+	if err != nil {
+		return err
+	}
+	profile.Activity = items
+	return nil
+}
+
+// CoursesPlayed returns how many different courses the user has posted scores at
+func (p *PublicProfile) CoursesPlayed() int {
+	return len(p.Played)
+}
+
+// PlayedMap returns the played courses with a known location as GeoJSON points
+func (p *PublicProfile) PlayedMap() api.GeoJSONFeatureCollection {
+	collection := api.NewFeatureCollection()
+	for _, played := range p.Played {
+		if played.Latitude == nil || played.Longitude == nil {
+			continue
+		}
+		collection.Features = append(collection.Features, api.NewPointFeature(*played.Latitude, *played.Longitude, map[string]interface{}{
+			"course_id":  played.CourseID,
+			"name":       played.CourseName,
+			"rounds":     played.Rounds,
+			"best_score": played.BestScore,
+		}))
+	}
+	return collection
+}
+
+// BuildProfilePrivacyFields lists the privacy form's sections with their current audience selected
+func BuildProfilePrivacyFields(privacy ProfilePrivacy) []ProfilePrivacyField {
+	fields := []ProfilePrivacyField{
+		{Name: "handicap", Label: "Handicap"},
+		{Name: "reviews", Label: "Courses reviewed"},
+		{Name: "played_courses", Label: "Courses played and map"},
+		{Name: "activity", Label: "Recent activity"},
+	}
+	current := map[string]string{
+		"handicap":       privacy.Handicap,
+		"reviews":        privacy.Reviews,
+		"played_courses": privacy.PlayedCourses,
+		"activity":       privacy.Activity,
+	}
+	labels := map[string]string{
+		ProfileAudiencePublic:    "Everyone",
+		ProfileAudienceFollowers: "Followers",
+		ProfileAudiencePrivate:   "Only me",
+	}
+
+	for i := range fields {
+		for _, audience := range api.ProfileAudiences {
+			fields[i].Options = append(fields[i].Options, ProfileAudienceOption{
+				Value:    audience,
+				Label:    labels[audience],
+				Selected: audience == current[fields[i].Name],
+			})
+		}
+	}
+	return fields
+}
+
+func isProfileAudience(audience string) bool {
+	for _, candidate := range api.ProfileAudiences {
+		if candidate == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// profileAudienceAllows reports whether a viewer may see a section shared
+// with the audience
+func profileAudienceAllows(audience string, isOwn, isFollowing bool) bool {
+	switch audience {
+	case ProfileAudiencePublic:
+		return true
+	case ProfileAudienceFollowers:
+		return isOwn || isFollowing
+	default:
+		return isOwn
+	}
+}
+
+// activityAudiences returns the audiences that must all allow a viewer to see
+// an activity: the user's activity setting and, for activity that gives away
+// another section, that section's
+func (p ProfilePrivacy) activityAudiences(activityType string) []string {
+	switch activityType {
+	case "course_review":
+		return []string{p.Activity, p.Reviews}
+	case "score_posted":
+		return []string{p.Activity, p.PlayedCourses}
+	}
+	return []string{p.Activity}
+}
+
+// HiddenFromFollowers reports whether the user's privacy settings keep an
+// activity from their followers
+func (p ProfilePrivacy) HiddenFromFollowers(activityType string) bool {
+	for _, audience := range p.activityAudiences(activityType) {
+		if !profileAudienceAllows(audience, false, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// activitiesVisibleToFollowers leaves out activity that its user's privacy
+// settings hide from their followers, by the same rules as HiddenFromFollowers.
+// Users without settings have DefaultProfilePrivacy, which shares activity.
+func activitiesVisibleToFollowers(db *gorm.DB) *gorm.DB {
+	var hidden []string
+	for _, audience := range api.ProfileAudiences {
+		if !profileAudienceAllows(audience, false, true) {
+			hidden = append(hidden, audience)
+		}
+	}
+
+	hiding := db.Session(&gorm.Session{NewDB: true}).
+		Model(&ProfilePrivacy{}).
+		Select("1").
+		Where("profile_privacies.user_id = user_activities.user_id").
+		Where(db.Session(&gorm.Session{NewDB: true}).
+			Where("profile_privacies.activity IN ?", hidden).
+			Or("user_activities.activity_type = ? AND profile_privacies.reviews IN ?", "course_review", hidden).
+			Or("user_activities.activity_type = ? AND profile_privacies.played_courses IN ?", "score_posted", hidden))
+	return db.Where("NOT EXISTS (?)", hiding)
+}
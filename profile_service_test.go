@@ -0,0 +1,156 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileService_SectionsFollowPrivacy(t *testing.T) {
+	db := setupTestDatabase(t)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+	seedReview(t, db, course.ID, reviewer.ID)
+	service := NewProfileService()
+
+	handicap := 12.4
+	require.NoError(t, db.Model(&reviewer).Update("handicap", handicap).Error)
+	require.NoError(t, db.Create(&UserActivity{UserID: reviewer.ID, ActivityType: "course_review", CourseID: &course.ID, Data: "{}", CreatedAt: 1000}).Error)
+	require.NoError(t, db.Create(&UserActivity{UserID: reviewer.ID, ActivityType: "score_posted", CourseID: &course.ID, Data: "{}", CreatedAt: 2000}).Error)
+	require.NoError(t, db.Create(&UserCourseScore{CourseID: course.ID, UserID: reviewer.ID, Score: 84}).Error)
+
+	// Defaults share everything but the handicap
+	profile, err := service.GetProfile(reviewer.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Golfer", profile.Name)
+	assert.False(t, profile.ShowHandicap)
+	assert.Nil(t, profile.Handicap)
+	assert.True(t, profile.ShowReviews)
+	assert.Equal(t, int64(1), profile.ReviewCount)
+	assert.Equal(t, "Pebble Creek", profile.Reviews[0].CourseName)
+	assert.Len(t, profile.Activity, 2)
+
+	own, err := service.GetProfile(reviewer.ID, &reviewer.ID)
+	require.NoError(t, err)
+	assert.True(t, own.IsOwn)
+	require.NotNil(t, own.Handicap)
+	assert.Equal(t, handicap, *own.Handicap)
+
+	// Followers-only sections open up once the viewer follows the user
+	_, err = service.UpdatePrivacy(reviewer.ID, ProfilePrivacy{Handicap: ProfileAudienceFollowers, PlayedCourses: ProfileAudiencePrivate})
+	require.NoError(t, err)
+
+	profile, err = service.GetProfile(reviewer.ID, &golfer.ID)
+	require.NoError(t, err)
+	assert.False(t, profile.ShowHandicap)
+	assert.False(t, profile.ShowPlayed)
+	assert.Empty(t, profile.Played)
+	// The score behind the hidden played courses isn't given away by the activity
+	require.Len(t, profile.Activity, 1)
+	assert.Equal(t, "course_review", profile.Activity[0].ActivityType)
+
+	require.NoError(t, NewFollowService().Follow(golfer.ID, reviewer.ID))
+	profile, err = service.GetProfile(reviewer.ID, &golfer.ID)
+	require.NoError(t, err)
+	assert.True(t, profile.IsFollowing)
+	assert.Equal(t, int64(1), profile.Followers)
+	assert.True(t, profile.ShowHandicap)
+	assert.False(t, profile.ShowPlayed)
+
+	_, err = service.GetProfile(999, nil)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestProfileService_HidesNonPublicReviews(t *testing.T) {
+	db := setupTestDatabase(t)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	seedReview(t, db, seedCourse(t, db, nil).ID, reviewer.ID)
+	service := NewProfileService()
+
+	other := CourseDB{Name: "Quarry Hills", Address: "2 Quarry Rd", Hash: "quarry", CourseData: "{}"}
+	require.NoError(t, db.Create(&other).Error)
+	require.NoError(t, db.Create(&CourseReview{CourseID: other.ID, UserID: reviewer.ID, Visibility: ReviewVisibilityAnonymous}).Error)
+
+	profile, err := service.GetProfile(reviewer.ID, &golfer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), profile.ReviewCount)
+	require.Len(t, profile.Reviews, 1)
+	assert.Equal(t, "Pebble Creek", profile.Reviews[0].CourseName)
+
+	own, err := service.GetProfile(reviewer.ID, &reviewer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), own.ReviewCount)
+}
+
+func TestProfileService_PlayedCourses(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	service := NewProfileService()
+
+	lat, lng := 36.57, -121.95
+	require.NoError(t, db.Model(&course).Updates(map[string]interface{}{"latitude": lat, "longitude": lng}).Error)
+	unmapped := CourseDB{Name: "Quarry Hills", Address: "2 Quarry Rd", Hash: "quarry", CourseData: "{}", CreatedBy: &owner.ID}
+	require.NoError(t, db.Create(&unmapped).Error)
+
+	require.NoError(t, db.Create(&UserCourseScore{CourseID: course.ID, UserID: golfer.ID, Score: 88, CreatedAt: 1000}).Error)
+	require.NoError(t, db.Create(&UserCourseScore{CourseID: course.ID, UserID: golfer.ID, Score: 81, CreatedAt: 2000}).Error)
+	require.NoError(t, db.Create(&UserCourseScore{CourseID: unmapped.ID, UserID: golfer.ID, Score: 79, CreatedAt: 3000}).Error)
+	require.NoError(t, db.Create(&UserCourseScore{CourseID: unmapped.ID, UserID: reviewer.ID, Score: 70, CreatedAt: 4000}).Error)
+
+	profile, err := service.GetProfile(golfer.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, profile.CoursesPlayed())
+	assert.Equal(t, 3, profile.RoundsPlayed)
+	require.NotNil(t, profile.BestScore)
+	assert.Equal(t, 79, *profile.BestScore)
+
+	// Most recently played first
+	require.Len(t, profile.Played, 2)
+	assert.Equal(t, unmapped.ID, profile.Played[0].CourseID)
+	assert.Equal(t, 2, profile.Played[1].Rounds)
+	assert.Equal(t, 81, profile.Played[1].BestScore)
+
+	// Only courses with a location make it onto the map
+	played := profile.PlayedMap()
+	assert.Equal(t, "FeatureCollection", played.Type)
+	require.Len(t, played.Features, 1)
+	assert.Equal(t, []float64{lng, lat}, played.Features[0].Geometry.Coordinates)
+	assert.Equal(t, "Pebble Creek", played.Features[0].Properties["name"])
+}
+
+func TestProfileService_UpdatePrivacy(t *testing.T) {
+	db := setupTestDatabase(t)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	service := NewProfileService()
+
+	privacy, err := service.GetPrivacy(golfer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ProfileAudiencePrivate, privacy.Handicap)
+
+	_, err = service.UpdatePrivacy(golfer.ID, ProfilePrivacy{Reviews: "friends"})
+	assert.ErrorIs(t, err, ErrInvalidProfileAudience)
+
+	privacy, err = service.UpdatePrivacy(golfer.ID, ProfilePrivacy{Activity: ProfileAudienceFollowers})
+	require.NoError(t, err)
+	assert.Equal(t, ProfileAudienceFollowers, privacy.Activity)
+	assert.Equal(t, ProfileAudiencePublic, privacy.Reviews)
+
+	// Saving again updates the same row
+	privacy, err = service.UpdatePrivacy(golfer.ID, ProfilePrivacy{Activity: ProfileAudiencePrivate})
+	require.NoError(t, err)
+	assert.Equal(t, ProfileAudiencePrivate, privacy.Activity)
+	var rows int64
+	db.Model(&ProfilePrivacy{}).Where("user_id = ?", golfer.ID).Count(&rows)
+	assert.Equal(t, int64(1), rows)
+
+	fields := BuildProfilePrivacyFields(privacy)
+	require.Len(t, fields, 4)
+	assert.Equal(t, "activity", fields[3].Name)
+	assert.True(t, fields[3].Options[2].Selected)
+}
@@ -0,0 +1,638 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment moderation statuses
+const (
+	CommentStatusVisible = "visible"
+	CommentStatusPending = "pending"
+	CommentStatusHidden  = "hidden"
+)
+
+const (
+	maxCommentLength       = 2000
+	defaultCommentsPerPage = 20
+	maxCommentsPerPage     = 100
+)
+
+var (
+	ErrCommentNotFound     = errors.New("comment not found")
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrCommentThreadDepth  = errors.New("replies can only be added to top-level comments")
+	ErrCommentForbidden    = errors.New("you don't have permission to change this comment")
+	ErrInvalidCommentBody  = fmt.Errorf("comment must be between 1 and %d characters", maxCommentLength)
+	ErrInvalidCommentState = errors.New("invalid comment status")
+)
+
+// ModerationDecision is the outcome of running a comment through a moderator
+type ModerationDecision struct {
+	Status string // One of the CommentStatus* constants
+	Note   string // Optional reason shown to the author and course owner
+}
+
+// CommentModerator is a hook that inspects a comment before it is saved.
+// Moderators run in registration order; the first one that does not return
+// CommentStatusVisible decides the comment's status.
+type CommentModerator interface {
+	Moderate(comment *ReviewComment) ModerationDecision
+}
+
+// CommentModeratorFunc adapts a plain function to the CommentModerator interface
+type CommentModeratorFunc func(comment *ReviewComment) ModerationDecision
+
+func (f CommentModeratorFunc) Moderate(comment *ReviewComment) ModerationDecision {
+	return f(comment)
+}
+
+var (
+	commentModeratorsMu sync.RWMutex
+	commentModerators   = []CommentModerator{LinkSpamModerator{MaxLinks: 2}}
+)
+
+// RegisterCommentModerator adds a moderation hook that runs on every new or edited comment
+func RegisterCommentModerator(moderator CommentModerator) {
+	commentModeratorsMu.Lock()
+	defer commentModeratorsMu.Unlock()
+	commentModerators = append(commentModerators, moderator)
+}
+
+var commentLinkPattern = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// LinkSpamModerator holds comments with too many links for course owner review
+type LinkSpamModerator struct {
+	MaxLinks int
+}
+
+func (m LinkSpamModerator) Moderate(comment *ReviewComment) ModerationDecision {
+	if len(commentLinkPattern.FindAllStringIndex(comment.Body, -1)) > m.MaxLinks {
+		return ModerationDecision{Status: CommentStatusPending, Note: "Held for review: too many links"}
+	}
+	return ModerationDecision{Status: CommentStatusVisible}
+}
+
+type ReviewCommentService struct {
+	db         *gorm.DB
+	moderators []CommentModerator
+}
+
+func NewReviewCommentService() *ReviewCommentService {
+	commentModeratorsMu.RLock()
+	moderators := append([]CommentModerator(nil), commentModerators...)
+	commentModeratorsMu.RUnlock()
+
+	return &ReviewCommentService{
+		db:         GetDB(),
+		moderators: moderators,
+	}
+}
+
+// CreateComment adds a comment to a review, or a reply when parentID is set.
+// Comments posted by the course creator are flagged as owner responses.
+func (cs *ReviewCommentService) CreateComment(userID, reviewID uint, parentID *uint, body string) (*ReviewComment, error) {
+	if cs.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	body, err := normalizeCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := cs.getReview(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if !canReadReview(review, &userID) {
+		return nil, ErrReviewNotFound
+	}
+
+	if parentID != nil {
+		parent, err := cs.GetComment(*parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.ReviewID != reviewID {
+			return nil, ErrCommentNotFound
+		}
+		if parent.ParentID != nil {
+			return nil, ErrCommentThreadDepth
+		}
+	}
+
+	isOwner, err := cs.isCourseCreator(userID, review.CourseID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &ReviewComment{
+		ReviewID:        reviewID,
+		UserID:          userID,
+		ParentID:        parentID,
+		Body:            body,
+		IsOwnerResponse: isOwner,
+	}
+	cs.applyModeration(comment)
+
+	if err := cs.db.Create(comment).Error; err != nil {
+		return nil, fmt.Errorf("failed to save comment: %v", err)
+	}
+
+	log.Printf("✅ User %d commented on review %d (status: %s)", userID, reviewID, comment.Status)
+
+	reviewService := &ReviewService{db: cs.db}
+	reviewService.createActivity(userID, "review_comment", &review.CourseID, map[string]any{
+		"review_id":  reviewID,
+		"comment_id": comment.ID,
+	})
+
+	return comment, nil
+}
+
+// UpdateComment edits the body of a comment. Only the author may edit.
+func (cs *ReviewCommentService) UpdateComment(userID, commentID uint, body string) (*ReviewComment, error) {
+	if cs.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	body, err := normalizeCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := cs.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != userID {
+		log.Printf("🚨 [SECURITY] User %d attempted to edit comment %d owned by user %d", userID, commentID, comment.UserID)
+		return nil, ErrCommentForbidden
+	}
+
+	// A comment hidden by the course owner stays hidden after an edit
+	wasHidden := comment.Status == CommentStatusHidden
+
+	editedAt := time.Now().Unix()
+	comment.Body = body
+	comment.EditedAt = &editedAt
+	cs.applyModeration(comment)
+	if wasHidden {
+		comment.Status = CommentStatusHidden
+	}
+
+	result := cs.db.Model(comment).Updates(map[string]any{
+		"body":            comment.Body,
+		"edited_at":       comment.EditedAt,
+		"status":          comment.Status,
+		"moderation_note": comment.ModerationNote,
+	})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update comment: %v", result.Error)
+	}
+
+	return comment, nil
+}
+
+// DeleteComment removes a comment and its replies. The author or the course
+// creator (as moderator) may delete.
+func (cs *ReviewCommentService) DeleteComment(userID, commentID uint) error {
+	if cs.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	comment, err := cs.GetComment(commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		canModerate, err := cs.CanModerate(userID, comment.ReviewID)
+		if err != nil {
+			return err
+		}
+		if !canModerate {
+			log.Printf("🚨 [SECURITY] User %d attempted to delete comment %d owned by user %d", userID, commentID, comment.UserID)
+			return ErrCommentForbidden
+		}
+	}
+
+	return cs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("parent_id = ?", commentID).Delete(&ReviewComment{}).Error; err != nil {
+			return fmt.Errorf("failed to delete replies: %v", err)
+		}
+		if err := tx.Delete(&ReviewComment{}, commentID).Error; err != nil {
+			return fmt.Errorf("failed to delete comment: %v", err)
+		}
+		return nil
+	})
+}
+
+// SetCommentStatus lets the course creator approve or hide a comment
+func (cs *ReviewCommentService) SetCommentStatus(userID, commentID uint, status string) (*ReviewComment, error) {
+	if cs.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	if status != CommentStatusVisible && status != CommentStatusHidden && status != CommentStatusPending {
+		return nil, ErrInvalidCommentState
+	}
+
+	comment, err := cs.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	canModerate, err := cs.CanModerate(userID, comment.ReviewID)
+	if err != nil {
+		return nil, err
+	}
+	if !canModerate {
+		return nil, ErrCommentForbidden
+	}
+
+	comment.Status = status
+	if err := cs.db.Model(comment).Update("status", status).Error; err != nil {
+		return nil, fmt.Errorf("failed to update comment status: %v", err)
+	}
+
+	log.Printf("✅ User %d set comment %d status to %s", userID, commentID, status)
+	return comment, nil
+}
+
+// GetComment gets a single comment by ID
+func (cs *ReviewCommentService) GetComment(commentID uint) (*ReviewComment, error) {
+	if cs.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var comment ReviewComment
+	if err := cs.db.First(&comment, commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to get comment: %v", err)
+	}
+	return &comment, nil
+}
+
+// GetReviewComments returns a page of top-level comments for a review, each
+// with all of its replies. Pending and hidden comments are only shown to their
+// author and the course creator.
+func (cs *ReviewCommentService) GetReviewComments(reviewID uint, viewerID *uint, page, perPage int) ([]ReviewCommentThread, int64, error) {
+	if cs.db == nil {
+		return nil, 0, fmt.Errorf("database not connected")
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = defaultCommentsPerPage
+	}
+	if perPage > maxCommentsPerPage {
+		perPage = maxCommentsPerPage
+	}
+
+	review, err := cs.getReview(reviewID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !canReadReview(review, viewerID) {
+		return nil, 0, ErrReviewNotFound
+	}
+
+	canModerate := false
+	if viewerID != nil {
+		if canModerate, err = cs.isCourseCreator(*viewerID, review.CourseID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := cs.visibleCommentsQuery(viewerID, canModerate).
+		Where("review_id = ? AND parent_id IS NULL", reviewID).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count comments: %v", err)
+	}
+
+	var topLevel []ReviewComment
+	result := query.Order("created_at ASC, id ASC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&topLevel)
+	if result.Error != nil {
+		return nil, 0, fmt.Errorf("failed to get comments: %v", result.Error)
+	}
+
+	threads, err := cs.buildThreads(topLevel, map[uint]*CourseReview{review.ID: review}, viewerID, canModerate)
+	if err != nil {
+		return nil, 0, err
+	}
+	return threads, total, nil
+}
+
+// GetCourseDiscussion returns every review for a course with its comment threads
+func (cs *ReviewCommentService) GetCourseDiscussion(courseID uint, viewerID *uint) ([]ReviewWithComments, error) {
+	if cs.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var reviews []CourseReview
+	if err := cs.db.Scopes(readableReviews(viewerID)).Where("course_id = ?", courseID).Order("created_at DESC").Find(&reviews).Error; err != nil {
+		return nil, fmt.Errorf("failed to get course reviews: %v", err)
+	}
+
+	canModerate := false
+	if viewerID != nil {
+		var err error
+		if canModerate, err = cs.isCourseCreator(*viewerID, courseID); err != nil {
+			return nil, err
+		}
+	}
+
+	reviewIDs := make([]uint, 0, len(reviews))
+	userIDs := make([]uint, 0, len(reviews))
+	reviewsByID := make(map[uint]*CourseReview, len(reviews))
+	for i, review := range reviews {
+		reviewIDs = append(reviewIDs, review.ID)
+		userIDs = append(userIDs, review.UserID)
+		reviewsByID[review.ID] = &reviews[i]
+	}
+	names, err := cs.authorNames(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []ReviewComment
+	if len(reviewIDs) > 0 {
+		result := cs.visibleCommentsQuery(viewerID, canModerate).
+			Where("review_id IN ? AND parent_id IS NULL", reviewIDs).
+			Order("created_at ASC, id ASC").
+			Find(&comments)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to get comments: %v", result.Error)
+		}
+	}
+
+	// Each review shows its first page of comments
+	perReview := make(map[uint]int, len(reviews))
+	topLevel := make([]ReviewComment, 0, len(comments))
+	for _, comment := range comments {
+		if perReview[comment.ReviewID] < maxCommentsPerPage {
+			perReview[comment.ReviewID]++
+			topLevel = append(topLevel, comment)
+		}
+	}
+
+	allThreads, err := cs.buildThreads(topLevel, reviewsByID, viewerID, canModerate)
+	if err != nil {
+		return nil, err
+	}
+	threadsByReview := make(map[uint][]ReviewCommentThread, len(reviews))
+	for _, thread := range allThreads {
+		threadsByReview[thread.ReviewID] = append(threadsByReview[thread.ReviewID], thread)
+	}
+
+	discussion := make([]ReviewWithComments, 0, len(reviews))
+	for _, review := range reviews {
+		threads := threadsByReview[review.ID]
+		if threads == nil {
+			threads = []ReviewCommentThread{}
+		}
+
+		total := len(threads)
+		for _, thread := range threads {
+			total += len(thread.Replies)
+		}
+
+		authorName := names[review.UserID]
+		if hidesReviewer(&review, viewerID) {
+			authorName = AnonymousReviewerName
+			redactReviewer(&review, viewerID)
+		}
+
+		discussion = append(discussion, ReviewWithComments{
+			CourseReview:  review,
+			AuthorName:    authorName,
+			PostedOn:      formatCommentDate(review.CreatedAt),
+			Comments:      threads,
+			TotalComments: total,
+		})
+	}
+
+	return discussion, nil
+}
+
+// CanModerate reports whether a user created the course that a review belongs to
+func (cs *ReviewCommentService) CanModerate(userID, reviewID uint) (bool, error) {
+	review, err := cs.getReview(reviewID)
+	if err != nil {
+		return false, err
+	}
+	return cs.isCourseCreator(userID, review.CourseID)
+}
+
+// CanViewReview reports whether a review exists and viewerID may read it.
+// Private reviews only exist as far as their author is concerned.
+func (cs *ReviewCommentService) CanViewReview(reviewID uint, viewerID *uint) (bool, error) {
+	if cs.db == nil {
+		return false, fmt.Errorf("database not connected")
+	}
+
+	var count int64
+	if err := cs.db.Model(&CourseReview{}).Scopes(readableReviews(viewerID)).Where("id = ?", reviewID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check review: %v", err)
+	}
+	return count > 0, nil
+}
+
+// CommentThread wraps a single comment as viewerID sees it, with its author
+// masked the same way as in a review's threads
+func (cs *ReviewCommentService) CommentThread(comment *ReviewComment, viewerID *uint, canModerate bool) (ReviewCommentThread, error) {
+	review, err := cs.getReview(comment.ReviewID)
+	if err != nil {
+		return ReviewCommentThread{}, err
+	}
+	names, err := cs.authorNames([]uint{comment.UserID})
+	if err != nil {
+		return ReviewCommentThread{}, err
+	}
+
+	thread := ReviewCommentThread{
+		ReviewComment: *comment,
+		AuthorName:    names[comment.UserID],
+		PostedOn:      formatCommentDate(comment.CreatedAt),
+		CanEdit:       viewerID != nil && *viewerID == comment.UserID,
+		CanModerate:   canModerate,
+	}
+	redactCommentAuthor(&thread, review, viewerID)
+	return thread, nil
+}
+
+// AuthorName returns the public name shown next to a user's comments
+func (cs *ReviewCommentService) AuthorName(userID uint) string {
+	names, err := cs.authorNames([]uint{userID})
+	if err != nil {
+		return "Golfer"
+	}
+	return names[userID]
+}
+
+func (cs *ReviewCommentService) visibleCommentsQuery(viewerID *uint, canModerate bool) *gorm.DB {
+	query := cs.db.Model(&ReviewComment{})
+	if canModerate {
+		return query
+	}
+	if viewerID != nil {
+		return query.Where("status = ? OR user_id = ?", CommentStatusVisible, *viewerID)
+	}
+	return query.Where("status = ?", CommentStatusVisible)
+}
+
+// buildThreads groups replies under their top-level comments. reviews holds
+// the comments' reviews, so an anonymous reviewer's own comments can be masked.
+func (cs *ReviewCommentService) buildThreads(topLevel []ReviewComment, reviews map[uint]*CourseReview, viewerID *uint, canModerate bool) ([]ReviewCommentThread, error) {
+	if len(topLevel) == 0 {
+		return []ReviewCommentThread{}, nil
+	}
+
+	parentIDs := make([]uint, 0, len(topLevel))
+	for _, comment := range topLevel {
+		parentIDs = append(parentIDs, comment.ID)
+	}
+
+	var replies []ReviewComment
+	result := cs.visibleCommentsQuery(viewerID, canModerate).
+		Where("parent_id IN ?", parentIDs).
+		Order("created_at ASC, id ASC").
+		Find(&replies)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get replies: %v", result.Error)
+	}
+
+	userIDs := make([]uint, 0, len(topLevel)+len(replies))
+	for _, comment := range topLevel {
+		userIDs = append(userIDs, comment.UserID)
+	}
+	for _, reply := range replies {
+		userIDs = append(userIDs, reply.UserID)
+	}
+	names, err := cs.authorNames(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	toThread := func(comment ReviewComment) ReviewCommentThread {
+		thread := ReviewCommentThread{
+			ReviewComment: comment,
+			AuthorName:    names[comment.UserID],
+			PostedOn:      formatCommentDate(comment.CreatedAt),
+			CanEdit:       viewerID != nil && *viewerID == comment.UserID,
+			CanModerate:   canModerate,
+		}
+		redactCommentAuthor(&thread, reviews[comment.ReviewID], viewerID)
+		return thread
+	}
+
+	repliesByParent := make(map[uint][]ReviewCommentThread)
+	for _, reply := range replies {
+		repliesByParent[*reply.ParentID] = append(repliesByParent[*reply.ParentID], toThread(reply))
+	}
+
+	threads := make([]ReviewCommentThread, 0, len(topLevel))
+	for _, comment := range topLevel {
+		thread := toThread(comment)
+		thread.Replies = repliesByParent[comment.ID]
+		threads = append(threads, thread)
+	}
+	return threads, nil
+}
+
+// authorNames maps user IDs to display names without exposing emails or Google names
+func (cs *ReviewCommentService) authorNames(userIDs []uint) (map[uint]string, error) {
+	names := make(map[uint]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return names, nil
+	}
+
+	var users []User
+	if err := cs.db.Select("id, display_name").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get comment authors: %v", err)
+	}
+
+	for _, id := range userIDs {
+		names[id] = "Golfer"
+	}
+	for _, user := range users {
+		if user.DisplayName != nil && *user.DisplayName != "" {
+			names[user.ID] = *user.DisplayName
+		}
+	}
+	return names, nil
+}
+
+func (cs *ReviewCommentService) getReview(reviewID uint) (*CourseReview, error) {
+	if cs.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var review CourseReview
+	if err := cs.db.First(&review, reviewID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, fmt.Errorf("failed to get review: %v", err)
+	}
+	return &review, nil
+}
+
+func (cs *ReviewCommentService) isCourseCreator(userID, courseID uint) (bool, error) {
+	var course CourseDB
+	if err := cs.db.Select("id, created_by").First(&course, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check course owner: %v", err)
+	}
+	return course.CreatedBy != nil && *course.CreatedBy == userID, nil
+}
+
+func (cs *ReviewCommentService) applyModeration(comment *ReviewComment) {
+	comment.Status = CommentStatusVisible
+	comment.ModerationNote = nil
+
+	for _, moderator := range cs.moderators {
+		decision := moderator.Moderate(comment)
+		if decision.Status != "" && decision.Status != CommentStatusVisible {
+			comment.Status = decision.Status
+			if decision.Note != "" {
+				note := decision.Note
+				comment.ModerationNote = &note
+			}
+			return
+		}
+	}
+}
+
+func normalizeCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || len(body) > maxCommentLength {
+		return "", ErrInvalidCommentBody
+	}
+	return body, nil
+}
+
+func formatCommentDate(unix int64) string {
+	return time.Unix(unix, 0).Format("Jan 2, 2006")
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type commentFixtures struct {
+	owner    User
+	reviewer User
+	golfer   User
+	course   CourseDB
+	review   CourseReview
+}
+
+// seedCommentFixtures saves a course with an owner, a review of it, and a
+// golfer to comment on the review
+func seedCommentFixtures(t *testing.T, db *gorm.DB) commentFixtures {
+	t.Helper()
+
+	f := commentFixtures{
+		owner:    seedCourseOwner(t, db),
+		reviewer: seedUser(t, db, "reviewer", "Reviewer"),
+		golfer:   seedUser(t, db, "golfer", "Golfer"),
+	}
+	f.course = seedCourse(t, db, &f.owner.ID)
+	f.review = seedReview(t, db, f.course.ID, f.reviewer.ID)
+	return f
+}
+
+func TestReviewCommentService_ThreadingAndOwnerResponse(t *testing.T) {
+	db := setupTestDatabase(t)
+	f := seedCommentFixtures(t, db)
+	service := NewReviewCommentService()
+
+	top, err := service.CreateComment(f.golfer.ID, f.review.ID, nil, "  Agreed on the greens  ")
+	require.NoError(t, err)
+	assert.Equal(t, "Agreed on the greens", top.Body)
+	assert.False(t, top.IsOwnerResponse)
+	assert.Equal(t, CommentStatusVisible, top.Status)
+
+	reply, err := service.CreateComment(f.owner.ID, f.review.ID, &top.ID, "Thanks, we just aerated them")
+	require.NoError(t, err)
+	assert.True(t, reply.IsOwnerResponse)
+
+	_, err = service.CreateComment(f.golfer.ID, f.review.ID, &reply.ID, "Replying to a reply")
+	assert.ErrorIs(t, err, ErrCommentThreadDepth)
+
+	_, err = service.CreateComment(f.golfer.ID, f.review.ID, nil, "   ")
+	assert.ErrorIs(t, err, ErrInvalidCommentBody)
+
+	_, err = service.CreateComment(f.golfer.ID, 9999, nil, "Missing review")
+	assert.ErrorIs(t, err, ErrReviewNotFound)
+
+	threads, total, err := service.GetReviewComments(f.review.ID, nil, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, threads, 1)
+	require.Len(t, threads[0].Replies, 1)
+	assert.Equal(t, "Course Owner", threads[0].Replies[0].AuthorName)
+	assert.Equal(t, "Golfer", threads[0].AuthorName, "Google names must not be exposed")
+
+	var activities int64
+	db.Model(&UserActivity{}).Where("activity_type = ?", "review_comment").Count(&activities)
+	assert.Equal(t, int64(2), activities)
+}
+
+func TestReviewCommentService_AnonymousReviewerStaysMasked(t *testing.T) {
+	db := setupTestDatabase(t)
+	f := seedCommentFixtures(t, db)
+	require.NoError(t, db.Model(&f.review).Update("visibility", ReviewVisibilityAnonymous).Error)
+	service := NewReviewCommentService()
+
+	top, err := service.CreateComment(f.golfer.ID, f.review.ID, nil, "Who wrote this?")
+	require.NoError(t, err)
+	reply, err := service.CreateComment(f.reviewer.ID, f.review.ID, &top.ID, "I did, and I stand by it")
+	require.NoError(t, err)
+
+	for name, viewerID := range map[string]*uint{"guest": nil, "golfer": &f.golfer.ID, "course owner": &f.owner.ID} {
+		threads, _, err := service.GetReviewComments(f.review.ID, viewerID, 1, 10)
+		require.NoError(t, err, name)
+		require.Len(t, threads[0].Replies, 1, name)
+		assert.Equal(t, AnonymousReviewerName, threads[0].Replies[0].AuthorName, name)
+		assert.Zero(t, threads[0].Replies[0].UserID, name)
+		assert.Equal(t, f.golfer.ID, threads[0].UserID, "other commenters keep their names")
+
+		discussion, err := service.GetCourseDiscussion(f.course.ID, viewerID)
+		require.NoError(t, err, name)
+		assert.Equal(t, AnonymousReviewerName, discussion[0].Comments[0].Replies[0].AuthorName, name)
+	}
+
+	moderated, err := service.CommentThread(reply, &f.owner.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, AnonymousReviewerName, moderated.AuthorName)
+	assert.Zero(t, moderated.UserID)
+
+	own, err := service.CommentThread(reply, &f.reviewer.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, f.reviewer.ID, own.UserID, "the reviewer sees their own reply")
+	assert.True(t, own.CanEdit)
+}
+
+func TestReviewCommentService_Pagination(t *testing.T) {
+	db := setupTestDatabase(t)
+	f := seedCommentFixtures(t, db)
+	service := NewReviewCommentService()
+
+	for i := 0; i < 5; i++ {
+		_, err := service.CreateComment(f.golfer.ID, f.review.ID, nil, "Comment")
+		require.NoError(t, err)
+	}
+
+	page, total, err := service.GetReviewComments(f.review.ID, nil, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Len(t, page, 2)
+
+	last, _, err := service.GetReviewComments(f.review.ID, nil, 3, 2)
+	require.NoError(t, err)
+	assert.Len(t, last, 1)
+}
+
+func TestReviewCommentService_EditAndDelete(t *testing.T) {
+	db := setupTestDatabase(t)
+	f := seedCommentFixtures(t, db)
+	service := NewReviewCommentService()
+
+	top, err := service.CreateComment(f.golfer.ID, f.review.ID, nil, "Original")
+	require.NoError(t, err)
+	_, err = service.CreateComment(f.reviewer.ID, f.review.ID, &top.ID, "Reply")
+	require.NoError(t, err)
+
+	_, err = service.UpdateComment(f.reviewer.ID, top.ID, "Not mine")
+	assert.ErrorIs(t, err, ErrCommentForbidden)
+
+	edited, err := service.UpdateComment(f.golfer.ID, top.ID, "Edited")
+	require.NoError(t, err)
+	assert.Equal(t, "Edited", edited.Body)
+	assert.NotNil(t, edited.EditedAt)
+
+	// The reviewer neither wrote the comment nor owns the course
+	assert.ErrorIs(t, service.DeleteComment(f.reviewer.ID, top.ID), ErrCommentForbidden)
+
+	// Deleting a top-level comment removes its replies
+	require.NoError(t, service.DeleteComment(f.golfer.ID, top.ID))
+	var remaining int64
+	db.Model(&ReviewComment{}).Count(&remaining)
+	assert.Equal(t, int64(0), remaining)
+}
+
+func TestReviewCommentService_Moderation(t *testing.T) {
+	db := setupTestDatabase(t)
+	f := seedCommentFixtures(t, db)
+	service := NewReviewCommentService()
+
+	spam, err := service.CreateComment(f.golfer.ID, f.review.ID, nil, "http://a.example http://b.example http://c.example")
+	require.NoError(t, err)
+	assert.Equal(t, CommentStatusPending, spam.Status)
+	require.NotNil(t, spam.ModerationNote)
+
+	// Pending comments are visible to their author and the course owner only
+	public, _, err := service.GetReviewComments(f.review.ID, nil, 1, 10)
+	require.NoError(t, err)
+	assert.Empty(t, public)
+
+	forAuthor, _, err := service.GetReviewComments(f.review.ID, &f.golfer.ID, 1, 10)
+	require.NoError(t, err)
+	assert.Len(t, forAuthor, 1)
+
+	forOwner, _, err := service.GetReviewComments(f.review.ID, &f.owner.ID, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, forOwner, 1)
+	assert.True(t, forOwner[0].CanModerate)
+
+	_, err = service.SetCommentStatus(f.golfer.ID, spam.ID, CommentStatusVisible)
+	assert.ErrorIs(t, err, ErrCommentForbidden)
+
+	_, err = service.SetCommentStatus(f.owner.ID, spam.ID, "deleted")
+	assert.ErrorIs(t, err, ErrInvalidCommentState)
+
+	approved, err := service.SetCommentStatus(f.owner.ID, spam.ID, CommentStatusVisible)
+	require.NoError(t, err)
+	assert.Equal(t, CommentStatusVisible, approved.Status)
+
+	// Custom hooks run after the built-in ones
+	service.moderators = append(service.moderators, CommentModeratorFunc(func(comment *ReviewComment) ModerationDecision {
+		if strings.Contains(strings.ToLower(comment.Body), "refund") {
+			return ModerationDecision{Status: CommentStatusHidden, Note: "Billing disputes belong with the pro shop"}
+		}
+		return ModerationDecision{Status: CommentStatusVisible}
+	}))
+	hidden, err := service.CreateComment(f.golfer.ID, f.review.ID, nil, "I want a refund")
+	require.NoError(t, err)
+	assert.Equal(t, CommentStatusHidden, hidden.Status)
+}
+
+func TestReviewService_DeleteUserReviewRemovesComments(t *testing.T) {
+	db := setupTestDatabase(t)
+	f := seedCommentFixtures(t, db)
+
+	_, err := NewReviewCommentService().CreateComment(f.golfer.ID, f.review.ID, nil, "Nice review")
+	require.NoError(t, err)
+
+	require.NoError(t, NewReviewService().DeleteUserReview(f.reviewer.ID, f.course.ID))
+
+	var remaining int64
+	db.Model(&ReviewComment{}).Count(&remaining)
+	assert.Equal(t, int64(0), remaining)
+}
+
+func TestCourseDiscussionTemplate(t *testing.T) {
+	db := setupTestDatabase(t)
+	f := seedCommentFixtures(t, db)
+	service := NewReviewCommentService()
+
+	top, err := service.CreateComment(f.golfer.ID, f.review.ID, nil, "Agreed")
+	require.NoError(t, err)
+	_, err = service.CreateComment(f.owner.ID, f.review.ID, &top.ID, "Thanks for the kind words")
+	require.NoError(t, err)
+
+	reviews, err := service.GetCourseDiscussion(f.course.ID, &f.owner.ID)
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	assert.Equal(t, 2, reviews[0].TotalComments)
+
+	templates := NewTemplates("views")
+	var out bytes.Buffer
+	err = templates.templates.ExecuteTemplate(&out, "course-discussion", CourseDiscussionData{
+		CourseIndex: 3,
+		Reviews:     reviews,
+		IsLoggedIn:  true,
+		CanModerate: true,
+	})
+	require.NoError(t, err)
+
+	html := out.String()
+	assert.Contains(t, html, "Thanks for the kind words")
+	assert.Contains(t, html, "owner-response")
+	assert.Contains(t, html, "/course/3/reviews/")
+}
+
+func TestGetCourseDiscussion_GroupsCommentsByReview(t *testing.T) {
+	db := setupTestDatabase(t)
+	f := seedCommentFixtures(t, db)
+	service := NewReviewCommentService()
+
+	second := CourseReview{CourseID: f.course.ID, UserID: f.golfer.ID, CreatedAt: f.review.CreatedAt + 1}
+	require.NoError(t, db.Create(&second).Error)
+
+	first, err := service.CreateComment(f.golfer.ID, f.review.ID, nil, "On the first review")
+	require.NoError(t, err)
+	_, err = service.CreateComment(f.reviewer.ID, f.review.ID, &first.ID, "A reply")
+	require.NoError(t, err)
+	_, err = service.CreateComment(f.reviewer.ID, second.ID, nil, "On the second review")
+	require.NoError(t, err)
+
+	reviews, err := service.GetCourseDiscussion(f.course.ID, nil)
+	require.NoError(t, err)
+	require.Len(t, reviews, 2)
+	bodies := make(map[uint][]string)
+	for _, review := range reviews {
+		for _, thread := range review.Comments {
+			bodies[review.ID] = append(bodies[review.ID], thread.Body)
+		}
+	}
+	assert.Equal(t, []string{"On the first review"}, bodies[f.review.ID])
+	assert.Equal(t, []string{"On the second review"}, bodies[second.ID])
+	for _, review := range reviews {
+		if review.ID == f.review.ID {
+			assert.Equal(t, 2, review.TotalComments, "replies are counted")
+		}
+	}
+}
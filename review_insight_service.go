@@ -0,0 +1,237 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReviewInsightService runs the review text analysis and stores one
+// CourseReviewInsight per course. It needs nothing beyond the database, so it
+// can run at startup, after a review is saved, or against an offline copy.
+//
+// Each course's texts are fingerprinted; a course is only re-analyzed when its
+// review texts or the analyzer version changed since the stored result.
+type ReviewInsightService struct {
+	db *gorm.DB
+}
+
+// ReviewInsightRun summarizes one pass of the insight job
+type ReviewInsightRun struct {
+	Courses  int
+	Analyzed int
+	Removed  int
+	Skipped  int
+	Duration time.Duration
+}
+
+func NewReviewInsightService() *ReviewInsightService {
+	return &ReviewInsightService{
+		db: GetDB(),
+	}
+}
+
+// GetCourseInsight returns the stored analysis for a course, or nil if its
+// reviews have no text yet
+func (s *ReviewInsightService) GetCourseInsight(courseID uint) (*CourseReviewInsight, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	var insight CourseReviewInsight
+	if err := s.db.Where("course_id = ?", courseID).First(&insight).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get review insight: %v", err)
+	}
+	return &insight, nil
+}
+
+// RefreshCourse re-analyzes one course if its review texts changed and reports
+// whether the stored insight was rewritten
+func (s *ReviewInsightService) RefreshCourse(courseID uint) (bool, error) {
+	if s.db == nil {
+		return false, fmt.Errorf("database not connected")
+	}
+
+	var course CourseDB
+	if err := s.db.Select("id", "course_data").First(&course, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrCourseNotFound
+		}
+		return false, fmt.Errorf("failed to find course: %v", err)
+	}
+
+	var reviews []CourseReview
+	if err := s.db.Select("id", "course_id", "review_text").
+		Scopes(aggregatedReviews).
+		Where("course_id = ? AND review_text IS NOT NULL", courseID).
+		Order("id").
+		Find(&reviews).Error; err != nil {
+		return false, fmt.Errorf("failed to get review texts: %v", err)
+	}
+
+	existing, err := s.GetCourseInsight(courseID)
+	if err != nil {
+		return false, err
+	}
+
+	changed, _, err := s.refresh(courseID, courseReviewTexts(course, reviews), existing)
+	return changed, err
+}
+
+// RefreshAll brings every course's insight up to date. Texts are loaded in
+// bulk and unchanged courses are skipped, so re-running it is cheap.
+func (s *ReviewInsightService) RefreshAll() (*ReviewInsightRun, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	start := time.Now()
+
+	var courses []CourseDB
+	if err := s.db.Select("id", "course_data").Order("id").Find(&courses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get courses: %v", err)
+	}
+
+	var reviews []CourseReview
+	if err := s.db.Select("id", "course_id", "review_text").
+		Scopes(aggregatedReviews).
+		Where("review_text IS NOT NULL").
+		Order("id").
+		Find(&reviews).Error; err != nil {
+		return nil, fmt.Errorf("failed to get review texts: %v", err)
+	}
+	reviewsByCourse := make(map[uint][]CourseReview)
+	for _, review := range reviews {
+		reviewsByCourse[review.CourseID] = append(reviewsByCourse[review.CourseID], review)
+	}
+
+	var insights []CourseReviewInsight
+	if err := s.db.Select("id", "course_id", "source_hash", "analyzer_version").Find(&insights).Error; err != nil {
+		return nil, fmt.Errorf("failed to get review insights: %v", err)
+	}
+	existingByCourse := make(map[uint]*CourseReviewInsight)
+	for i := range insights {
+		existingByCourse[insights[i].CourseID] = &insights[i]
+	}
+
+	run := &ReviewInsightRun{Courses: len(courses)}
+	for _, course := range courses {
+		changed, removed, err := s.refresh(course.ID, courseReviewTexts(course, reviewsByCourse[course.ID]), existingByCourse[course.ID])
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case removed:
+			run.Removed++
+		case changed:
+			run.Analyzed++
+		default:
+			run.Skipped++
+		}
+	}
+	run.Duration = time.Since(start)
+
+	log.Printf("✅ Review insights: %d courses, %d analyzed, %d removed, %d unchanged (%v)",
+		run.Courses, run.Analyzed, run.Removed, run.Skipped, run.Duration)
+	return run, nil
+}
+
+// refresh stores a fresh analysis of texts unless existing already covers them
+func (s *ReviewInsightService) refresh(courseID uint, texts []string, existing *CourseReviewInsight) (changed, removed bool, err error) {
+	if len(texts) == 0 {
+		if existing == nil {
+			return false, false, nil
+		}
+		if err := s.db.Where("course_id = ?", courseID).Delete(&CourseReviewInsight{}).Error; err != nil {
+			return false, false, fmt.Errorf("failed to remove review insight: %v", err)
+		}
+		return true, true, nil
+	}
+
+	hash := reviewTextsHash(texts)
+	if existing != nil && existing.SourceHash == hash && existing.AnalyzerVersion == reviewAnalyzerVersion {
+		return false, false, nil
+	}
+
+	analysis := AnalyzeReviewTexts(texts)
+	insight := CourseReviewInsight{
+		CourseID:        courseID,
+		ReviewCount:     analysis.ReviewCount,
+		Sentiment:       analysis.Sentiment,
+		SentimentLabel:  analysis.SentimentLabel,
+		Positive:        analysis.Positive,
+		Neutral:         analysis.Neutral,
+		Negative:        analysis.Negative,
+		Mentions:        analysis.Mentions,
+		SourceHash:      hash,
+		AnalyzerVersion: reviewAnalyzerVersion,
+	}
+	if insight.Mentions == nil {
+		insight.Mentions = []ReviewMention{}
+	}
+	if existing != nil {
+		insight.ID = existing.ID
+	}
+
+	if err := s.db.Save(&insight).Error; err != nil {
+		return false, false, fmt.Errorf("failed to save review insight: %v", err)
+	}
+	return true, false, nil
+}
+
+// courseReviewTexts collects the free text written about a course: the review
+// stored with the course itself followed by each user review, oldest first.
+// Callers leave private reviews out of reviews.
+func courseReviewTexts(course CourseDB, reviews []CourseReview) []string {
+	var texts []string
+
+	var courseData struct {
+		Review string `json:"review"`
+	}
+	if course.CourseData != "" {
+		if err := json.Unmarshal([]byte(course.CourseData), &courseData); err != nil {
+			log.Printf("Warning: failed to parse course data for course %d: %v", course.ID, err)
+		}
+	}
+	if text := strings.TrimSpace(courseData.Review); text != "" {
+		texts = append(texts, text)
+	}
+
+	for _, review := range reviews {
+		if review.ReviewText == nil {
+			continue
+		}
+		if text := strings.TrimSpace(*review.ReviewText); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+func reviewTextsHash(texts []string) string {
+	hash := sha256.New()
+	for _, text := range texts {
+		hash.Write([]byte(text))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// refreshCourseReviewInsight re-analyzes a course after one of its texts changed.
+// Failures are logged rather than returned so they never block saving a review.
+func refreshCourseReviewInsight(db *gorm.DB, courseID uint) {
+	service := &ReviewInsightService{db: db}
+	if _, err := service.RefreshCourse(courseID); err != nil {
+		log.Printf("Warning: failed to refresh review insight for course %d: %v", courseID, err)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreReviewSentiment(t *testing.T) {
+	score := func(text string) float64 {
+		return scoreReviewSentiment(tokenizeReviewSentences(text))
+	}
+
+	assert.Greater(t, score("Beautiful layout and friendly staff!"), 0.5)
+	assert.Less(t, score("Greens were bumpy and the pace was slow."), -0.5)
+	assert.Equal(t, 0.0, score("We teed off at nine."))
+
+	// Negation flips polarity and intensifiers strengthen it
+	assert.Less(t, score("The greens were not good"), 0.0)
+	assert.Greater(t, score("really good"), score("good"))
+
+	// Negation doesn't leak into the next sentence
+	assert.Greater(t, score("Not cheap. Great layout though."), 0.0)
+
+	assert.Equal(t, SentimentNeutral, sentimentLabel(0.01))
+}
+
+func TestAnalyzeReviewTexts(t *testing.T) {
+	analysis := AnalyzeReviewTexts([]string{
+		"Fast greens and a great clubhouse. Pace was slow on the back nine.",
+		"Loved the fast greens! Slow pace though.",
+		"Fast greens, friendly staff, but the pace dragged.",
+		"   ",
+		"Parking lot was full.",
+	})
+
+	assert.Equal(t, 4, analysis.ReviewCount, "blank texts are skipped")
+	assert.Equal(t, SentimentPositive, analysis.SentimentLabel)
+	assert.Equal(t, 3, analysis.Positive)
+
+	require.NotEmpty(t, analysis.Mentions)
+	assert.Equal(t, ReviewMention{Term: "fast greens", Reviews: 3, Sentiment: analysis.Mentions[0].Sentiment}, analysis.Mentions[0])
+
+	terms := make(map[string]int)
+	for _, mention := range analysis.Mentions {
+		terms[mention.Term] = mention.Reviews
+	}
+	assert.Equal(t, 3, terms["pace"])
+	assert.Equal(t, 2, terms["slow"])
+	assert.NotContains(t, terms, "greens", "greens only comes up as part of fast greens")
+	assert.NotContains(t, terms, "parking", "single mentions are noise once there are three reviews")
+	assert.NotContains(t, terms, "the")
+
+	empty := AnalyzeReviewTexts(nil)
+	assert.Equal(t, 0, empty.ReviewCount)
+	assert.Equal(t, SentimentNeutral, empty.SentimentLabel)
+}
+
+func TestReviewInsightService_IncrementalRefresh(t *testing.T) {
+	db := setupTestDatabase(t)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+	service := NewReviewInsightService()
+
+	// Saving reviews keeps the course insight current
+	reviewService := NewReviewService()
+	_, err := reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{ReviewText: "Fast greens but a slow pace."})
+	require.NoError(t, err)
+
+	insight, err := service.GetCourseInsight(course.ID)
+	require.NoError(t, err)
+	require.NotNil(t, insight)
+	reviewsWithText := insight.ReviewCount
+	assert.GreaterOrEqual(t, reviewsWithText, 1)
+	assert.Contains(t, mentionTerms(insight.Mentions), "fast greens")
+	firstHash := insight.SourceHash
+
+	// Nothing changed, so a full run skips the course
+	run, err := service.RefreshAll()
+	require.NoError(t, err)
+	assert.Equal(t, 0, run.Analyzed)
+	assert.Equal(t, run.Courses, run.Skipped)
+
+	// Editing the text re-analyzes the course
+	_, err = reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{ReviewText: "Rude staff and muddy fairways."})
+	require.NoError(t, err)
+	insight, err = service.GetCourseInsight(course.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, firstHash, insight.SourceHash)
+	assert.Contains(t, mentionTerms(insight.Mentions), "muddy fairways")
+
+	// Texts changed outside the app are picked up by the next run
+	require.NoError(t, db.Model(&CourseReview{}).Where("user_id = ?", golfer.ID).Update("review_text", "Pristine greens.").Error)
+	run, err = service.RefreshAll()
+	require.NoError(t, err)
+	assert.Equal(t, 1, run.Analyzed)
+
+	// An analyzer upgrade re-analyzes everything
+	require.NoError(t, db.Model(&CourseReviewInsight{}).Where("course_id = ?", course.ID).Update("analyzer_version", reviewAnalyzerVersion-1).Error)
+	changed, err := service.RefreshCourse(course.ID)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// Deleting the only text leaves the course without an insight
+	require.NoError(t, db.Model(&CourseReview{}).Where("course_id = ?", course.ID).Update("review_text", nil).Error)
+	require.NoError(t, db.Model(&CourseDB{}).Where("id = ?", course.ID).Update("course_data", "{}").Error)
+	run, err = service.RefreshAll()
+	require.NoError(t, err)
+	assert.Equal(t, 1, run.Removed)
+	insight, err = service.GetCourseInsight(course.ID)
+	require.NoError(t, err)
+	assert.Nil(t, insight)
+}
+
+func TestCourseMentionsTemplate(t *testing.T) {
+	templates := NewTemplates("views")
+	var out bytes.Buffer
+	err := templates.templates.ExecuteTemplate(&out, "course-mentions", &CourseReviewInsight{
+		Mentions: []ReviewMention{
+			{Term: "fast greens", Reviews: 3, Sentiment: 0.6},
+			{Term: "pace", Reviews: 1, Sentiment: -0.4},
+		},
+	})
+	require.NoError(t, err)
+
+	html := out.String()
+	assert.Contains(t, html, "What people mention")
+	assert.Contains(t, html, `<span class="attribute-chip mention-positive" title="Mentioned in 3 reviews">fast greens</span>`)
+	assert.Contains(t, html, `<span class="attribute-chip mention-negative" title="Mentioned in 1 review">pace</span>`)
+
+	out.Reset()
+	require.NoError(t, templates.templates.ExecuteTemplate(&out, "course-mentions", (*CourseReviewInsight)(nil)))
+	assert.NotContains(t, out.String(), "What people mention")
+}
+
+func mentionTerms(mentions []ReviewMention) []string {
+	var terms []string
+	for _, mention := range mentions {
+		terms = append(terms, mention.Term)
+	}
+	return terms
+}
@@ -25,6 +25,9 @@ type CourseReview struct {
 	// Review text
 	ReviewText *string `gorm:"type:text" json:"review_text"`
 
+	// Who can read the review: 'public', 'anonymous' (shown without the reviewer's name) or 'private' (notes for the reviewer only)
+	Visibility string `gorm:"type:varchar(10);not null;default:'public'" json:"visibility"`
+
 	// Timestamps
 	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt int64 `gorm:"autoUpdateTime" json:"updated_at"`
@@ -83,7 +86,7 @@ type UserCourseHole struct {
 type UserActivity struct {
 	ID           uint   `gorm:"primaryKey" json:"id"`
 	UserID       uint   `gorm:"not null" json:"user_id"`
-	ActivityType string `gorm:"type:varchar(50);not null" json:"activity_type"` // 'course_review', 'score_posted', 'course_added'
+	ActivityType string `gorm:"type:varchar(50);not null" json:"activity_type"` // 'course_review', 'score_posted', 'conditions_report', 'review_comment', 'follow', 'course_added'
 	CourseID     *uint  `json:"course_id"`
 	TargetUserID *uint  `json:"target_user_id"`         // For following/friend activities
 	Data         string `gorm:"type:jsonb" json:"data"` // Additional activity-specific data
@@ -97,6 +100,37 @@ type UserActivity struct {
 	TargetUser *User     `gorm:"foreignKey:TargetUserID" json:"target_user,omitempty"`
 }
 
+// UserFollow records that one user follows another
+type UserFollow struct {
+	ID         uint `gorm:"primaryKey" json:"id"`
+	FollowerID uint `gorm:"not null;uniqueIndex:idx_user_follows_pair" json:"follower_id"`
+	FolloweeID uint `gorm:"not null;uniqueIndex:idx_user_follows_pair;index" json:"followee_id"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Follower *User `gorm:"foreignKey:FollowerID" json:"follower,omitempty"`
+	Followee *User `gorm:"foreignKey:FolloweeID" json:"followee,omitempty"`
+}
+
+// FeedItem is a followed user's activity prepared for display
+type FeedItem struct {
+	UserActivity
+	ActorName  string `json:"actor_name"`
+	Action     string `json:"action"`      // e.g. "reviewed", "started following"
+	ObjectName string `json:"object_name"` // Course or user the action was about
+	PostedOn   string `json:"posted_on"`
+}
+
+// Summary describes the activity in one sentence, e.g. "Sam reviewed Pebble Creek"
+func (f FeedItem) Summary() string {
+	if f.ObjectName == "" {
+		return f.ActorName + " " + f.Action
+	}
+	return f.ActorName + " " + f.Action + " " + f.ObjectName
+}
+
 // CourseReviewSummary represents aggregated review data for a course
 type CourseReviewSummary struct {
 	CourseID      uint           `json:"course_id"`
@@ -135,6 +169,11 @@ type ReviewFormData struct {
 	Glizzies           string `json:"glizzies"`
 	Walkability        string `json:"walkability"`
 	ReviewText         string `json:"review_text"`
+	Visibility         string `json:"visibility"` // Empty keeps the review's visibility, or public for a new review
+
+	// Curated tags picked and amenity yes/no answers, see api.ReviewTagOptions and api.AmenityOptions
+	Tags         []string        `json:"tags"`
+	AmenityVotes map[string]bool `json:"amenity_votes"`
 }
 
 // ScoreFormData represents the form data for adding scores
@@ -155,3 +194,632 @@ type HoleFormData struct {
 	Yardage     int    `json:"yardage"`
 	Description string `json:"description"`
 }
+
+// ReviewComment represents a comment on a course review. Comments support one
+// level of threading: a reply points at a top-level comment via ParentID.
+type ReviewComment struct {
+	ID       uint  `gorm:"primaryKey" json:"id"`
+	ReviewID uint  `gorm:"not null;index" json:"review_id"`
+	UserID   uint  `gorm:"not null" json:"user_id"`
+	ParentID *uint `gorm:"index" json:"parent_id"` // nil for top-level comments
+
+	Body            string  `gorm:"type:text;not null" json:"body"`
+	IsOwnerResponse bool    `gorm:"default:false" json:"is_owner_response"`                    // Posted by the course creator
+	Status          string  `gorm:"type:varchar(20);not null;default:'visible'" json:"status"` // 'visible', 'pending', 'hidden'
+	ModerationNote  *string `gorm:"type:text" json:"moderation_note,omitempty"`
+
+	// Timestamps
+	EditedAt  *int64 `json:"edited_at"`
+	CreatedAt int64  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt int64  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Review *CourseReview `gorm:"foreignKey:ReviewID" json:"review,omitempty"`
+	User   *User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// ReviewCommentThread represents a top-level comment with its replies for display
+type ReviewCommentThread struct {
+	ReviewComment
+	AuthorName  string                `json:"author_name"`
+	PostedOn    string                `json:"posted_on"`
+	CanEdit     bool                  `json:"can_edit"`
+	CanModerate bool                  `json:"can_moderate"`
+	CourseIndex int                   `json:"-"` // Set by the web handlers for building course URLs
+	Replies     []ReviewCommentThread `json:"replies,omitempty"`
+}
+
+// ReviewWithComments represents a course review together with its discussion
+type ReviewWithComments struct {
+	CourseReview
+	AuthorName    string                `json:"author_name"`
+	PostedOn      string                `json:"posted_on"`
+	Comments      []ReviewCommentThread `json:"comments"`
+	TotalComments int                   `json:"total_comments"`
+}
+
+// ReviewAttributeVote records a reviewer's answer for one curated tag or amenity.
+// Tags are only stored when picked; amenities store both yes and no answers.
+type ReviewAttributeVote struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	ReviewID uint   `gorm:"not null;index" json:"review_id"`
+	CourseID uint   `gorm:"not null;index" json:"course_id"`
+	Kind     string `gorm:"type:varchar(10);not null" json:"kind"` // 'tag', 'amenity'
+	Slug     string `gorm:"type:varchar(50);not null" json:"slug"`
+	Value    bool   `gorm:"not null" json:"value"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Review *CourseReview `gorm:"foreignKey:ReviewID" json:"review,omitempty"`
+}
+
+// CourseAttribute is a tag or amenity the majority of a course's reviewers agree on.
+// Rows are rebuilt from ReviewAttributeVote whenever a review for the course changes.
+type CourseAttribute struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	CourseID uint   `gorm:"not null;uniqueIndex:idx_course_attribute" json:"course_id"`
+	Kind     string `gorm:"type:varchar(10);not null;uniqueIndex:idx_course_attribute" json:"kind"`
+	Slug     string `gorm:"type:varchar(50);not null;uniqueIndex:idx_course_attribute" json:"slug"`
+	Votes    int    `gorm:"not null" json:"votes"`  // Reviewers in favour
+	Voters   int    `gorm:"not null" json:"voters"` // Reviewers who answered
+
+	// Timestamps
+	UpdatedAt int64 `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// CourseAttributeSummary is the course-level consensus shown on course pages
+type CourseAttributeSummary struct {
+	CourseID  uint                   `json:"course_id"`
+	Tags      []CourseAttributeLabel `json:"tags"`
+	Amenities []CourseAttributeLabel `json:"amenities"`
+}
+
+// CourseAttributeLabel is a display-ready course attribute
+type CourseAttributeLabel struct {
+	Slug   string `json:"slug"`
+	Label  string `json:"label"`
+	Votes  int    `json:"votes"`
+	Voters int    `json:"voters"`
+}
+
+// ConditionsReport is a short-lived update on how a course is playing right now
+type ConditionsReport struct {
+	ID       uint `gorm:"primaryKey" json:"id"`
+	CourseID uint `gorm:"not null;index" json:"course_id"`
+	UserID   uint `gorm:"not null;index" json:"user_id"`
+
+	// Surface conditions: 'excellent', 'good', 'fair', 'poor'
+	Greens   *string `gorm:"type:varchar(10)" json:"greens"`
+	Fairways *string `gorm:"type:varchar(10)" json:"fairways"`
+	Bunkers  *string `gorm:"type:varchar(10)" json:"bunkers"`
+
+	// Pace of play: 'fast', 'average', 'slow'
+	PaceOfPlay *string `gorm:"type:varchar(10)" json:"pace_of_play"`
+
+	// Temporary course status
+	CartPathOnly  bool `gorm:"default:false" json:"cart_path_only"`
+	GreensAerated bool `gorm:"default:false" json:"greens_aerated"`
+	FrostDelay    bool `gorm:"default:false" json:"frost_delay"`
+
+	Note *string `gorm:"type:text" json:"note"`
+
+	// Timestamps
+	ExpiresAt int64 `gorm:"not null;index" json:"expires_at"`
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Course *CourseDB `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	User   *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// ConditionsReportView is a conditions report prepared for display
+type ConditionsReportView struct {
+	ConditionsReport
+	AuthorName string `json:"author_name"`
+	PostedAgo  string `json:"posted_ago"`
+	CanDelete  bool   `json:"can_delete"`
+}
+
+// CurrentConditions is the recency-weighted consensus of a course's active conditions reports
+type CurrentConditions struct {
+	CourseID      uint   `json:"course_id"`
+	ReportCount   int    `json:"report_count"`
+	LatestReport  *int64 `json:"latest_report"`
+	Greens        string `json:"greens"`   // Empty when no active report rated it
+	Fairways      string `json:"fairways"` // Empty when no active report rated it
+	Bunkers       string `json:"bunkers"`  // Empty when no active report rated it
+	PaceOfPlay    string `json:"pace_of_play"`
+	CartPathOnly  bool   `json:"cart_path_only"`
+	GreensAerated bool   `json:"greens_aerated"`
+	FrostDelay    bool   `json:"frost_delay"`
+
+	Reports []ConditionsReportView `json:"reports"` // Active reports, newest first
+}
+
+// ConditionsReportFormData represents the form data for posting a conditions report
+type ConditionsReportFormData struct {
+	Greens        string `json:"greens"`
+	Fairways      string `json:"fairways"`
+	Bunkers       string `json:"bunkers"`
+	PaceOfPlay    string `json:"pace_of_play"`
+	CartPathOnly  bool   `json:"cart_path_only"`
+	GreensAerated bool   `json:"greens_aerated"`
+	FrostDelay    bool   `json:"frost_delay"`
+	Note          string `json:"note"`
+	ValidHours    int    `json:"valid_hours"` // 0 uses the default lifetime
+}
+
+// CourseReviewInsight is the stored text analysis of a course's reviews: overall
+// sentiment and the keywords and phrases reviewers mention most. Rows are rebuilt
+// when the review texts change; SourceHash records which texts were analyzed.
+type CourseReviewInsight struct {
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	CourseID       uint    `gorm:"not null;uniqueIndex" json:"course_id"`
+	ReviewCount    int     `gorm:"not null" json:"review_count"` // Reviews with text
+	Sentiment      float64 `gorm:"not null" json:"sentiment"`    // Mean review sentiment, -1 to 1
+	SentimentLabel string  `gorm:"type:varchar(10);not null" json:"sentiment_label"`
+	Positive       int     `gorm:"not null" json:"positive"`
+	Neutral        int     `gorm:"not null" json:"neutral"`
+	Negative       int     `gorm:"not null" json:"negative"`
+
+	Mentions []ReviewMention `gorm:"type:text;serializer:json" json:"mentions"`
+
+	// Incremental re-analysis bookkeeping
+	SourceHash      string `gorm:"type:varchar(64);not null" json:"-"`
+	AnalyzerVersion int    `gorm:"not null" json:"-"`
+
+	// Timestamps
+	AnalyzedAt int64 `gorm:"autoUpdateTime" json:"analyzed_at"`
+}
+
+// ReviewHelpfulVote records that a user found another user's review helpful
+type ReviewHelpfulVote struct {
+	ID       uint `gorm:"primaryKey" json:"id"`
+	ReviewID uint `gorm:"not null;uniqueIndex:idx_review_helpful_votes_pair" json:"review_id"`
+	UserID   uint `gorm:"not null;uniqueIndex:idx_review_helpful_votes_pair" json:"user_id"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Review *CourseReview `gorm:"foreignKey:ReviewID" json:"review,omitempty"`
+	User   *User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// Notification tells a user that something happened to a course they added or
+// a review they wrote. It shows in their inbox, waits for their next email
+// digest, or both, depending on their preferences when it was created.
+type Notification struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	UserID   uint   `gorm:"not null;index:idx_notifications_inbox,priority:1" json:"user_id"` // Recipient
+	Type     string `gorm:"type:varchar(30);not null" json:"type"`                            // 'course_reviewed', 'course_edited', 'course_edit_suggested', 'course_edit_answered', 'review_helpful', 'outing_invite', 'outing_update'
+	ActorID  *uint  `json:"actor_id"`                                                         // Nil when the actor is anonymous
+	CourseID *uint  `json:"course_id"`
+	Message  string `gorm:"type:text;not null" json:"message"`
+
+	// Delivery
+	InApp        bool   `gorm:"not null" json:"in_app"`
+	EmailPending bool   `gorm:"not null;index" json:"email_pending"` // Cleared when a digest sends it or it is read
+	ReadAt       *int64 `json:"read_at"`
+	EmailedAt    *int64 `json:"emailed_at"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime;index:idx_notifications_inbox,priority:2" json:"created_at"`
+
+	// Relationships
+	User   *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Course *CourseDB `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+}
+
+// NotificationPreference is how a user wants to hear about one notification
+// type. Types without a row are delivered both in the app and by email.
+type NotificationPreference struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	UserID uint   `gorm:"not null;uniqueIndex:idx_notification_preferences_user_type" json:"user_id"`
+	Type   string `gorm:"type:varchar(30);not null;uniqueIndex:idx_notification_preferences_user_type" json:"type"`
+	InApp  bool   `gorm:"not null" json:"in_app"`
+	Email  bool   `gorm:"not null" json:"email"`
+
+	// Timestamps
+	UpdatedAt int64 `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ProfilePrivacy sets who can see each section of a user's public profile.
+// Users without a row get DefaultProfilePrivacy.
+type ProfilePrivacy struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	UserID        uint   `gorm:"not null;uniqueIndex" json:"user_id"`
+	Handicap      string `gorm:"type:varchar(20);not null" json:"handicap"`
+	Reviews       string `gorm:"type:varchar(20);not null" json:"reviews"`
+	PlayedCourses string `gorm:"type:varchar(20);not null" json:"played_courses"`
+	Activity      string `gorm:"type:varchar(20);not null" json:"activity"`
+
+	// Timestamps
+	UpdatedAt int64 `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PublicProfile is a user's profile prepared for one viewer. Sections the
+// user hasn't shared with the viewer are left empty and marked hidden.
+type PublicProfile struct {
+	UserID      uint
+	Name        string
+	MemberSince int64
+	IsOwn       bool // The viewer is looking at their own profile
+	IsFollowing bool // The viewer follows this user
+	Followers   int64
+	Following   int64
+	Privacy     ProfilePrivacy
+
+	ShowHandicap bool
+	Handicap     *float64
+
+	ShowReviews bool
+	ReviewCount int64
+	Reviews     []ProfileReview
+
+	ShowPlayed   bool
+	RoundsPlayed int
+	BestScore    *int
+	Played       []PlayedCourse
+
+	ShowActivity bool
+	Activity     []FeedItem
+}
+
+// ProfileReview is a review listed on a profile
+type ProfileReview struct {
+	CourseReview
+	CourseName string
+	PostedOn   string
+}
+
+// PlayedCourse summarizes a user's scores at one course
+type PlayedCourse struct {
+	CourseID   uint
+	CourseName string
+	Latitude   *float64
+	Longitude  *float64
+	Rounds     int
+	BestScore  int
+	LastPlayed int64
+}
+
+// CourseList is a user's named, ordered list of courses, like a bucket list
+// or a trip. Every user has one want-to-play list, created on first use.
+type CourseList struct {
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	UserID     uint    `gorm:"not null;index" json:"user_id"`
+	Name       string  `gorm:"type:varchar(100);not null" json:"name"`
+	Kind       string  `gorm:"type:varchar(20);not null" json:"kind"` // 'want_to_play' or 'custom'
+	ShareToken *string `gorm:"type:varchar(32);uniqueIndex" json:"-"` // Nil while the list isn't shared
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt int64 `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	User  *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Items []ListedCourse `gorm:"foreignKey:ListID" json:"items,omitempty"`
+}
+
+// ListedCourse is a course on a list. Courses are shown in Position order.
+type ListedCourse struct {
+	ID       uint `gorm:"primaryKey" json:"id"`
+	ListID   uint `gorm:"not null;uniqueIndex:idx_listed_courses_course" json:"list_id"`
+	CourseID uint `gorm:"not null;uniqueIndex:idx_listed_courses_course" json:"course_id"`
+	Position int  `gorm:"not null" json:"position"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Course *CourseDB `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+}
+
+// CourseListView is a list with its courses and the owner's played status
+// for each of them
+type CourseListView struct {
+	CourseList
+	OwnerName   string
+	Courses     []CourseListEntry
+	PlayedCount int
+}
+
+// CourseListEntry is a course on a list, marked played when the list's owner
+// has posted a score there
+type CourseListEntry struct {
+	CourseID   uint
+	CourseName string
+	Address    string
+	Latitude   *float64
+	Longitude  *float64
+	Position   int
+	AddedAt    int64
+	Played     bool
+	Rounds     int
+	BestScore  *int
+}
+
+// Outing is a round a user organizes at a course for a group of invitees.
+// Capacity caps how many can be going; zero means no limit.
+type Outing struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	CourseID    uint    `gorm:"not null;index" json:"course_id"`
+	OrganizerID uint    `gorm:"not null;index" json:"organizer_id"`
+	Title       string  `gorm:"type:varchar(100);not null" json:"title"`
+	StartsAt    int64   `gorm:"not null;index" json:"starts_at"` // First tee time, Unix seconds
+	Capacity    int     `gorm:"not null" json:"capacity"`
+	Notes       *string `gorm:"type:text" json:"notes"`
+	CancelledAt *int64  `json:"cancelled_at"`
+	Sequence    int     `gorm:"not null" json:"-"` // iCalendar revision, bumped on every change
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt int64 `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Course    *CourseDB      `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	Organizer *User          `gorm:"foreignKey:OrganizerID" json:"organizer,omitempty"`
+	Invites   []OutingInvite `gorm:"foreignKey:OutingID" json:"invites,omitempty"`
+}
+
+// OutingInvite is one person asked to an outing, either a registered user or
+// an email address, with their RSVP. Email invitees answer through Token.
+type OutingInvite struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	OutingID    uint    `gorm:"not null;index" json:"outing_id"`
+	UserID      *uint   `gorm:"index" json:"user_id"`
+	Email       *string `gorm:"type:varchar(255)" json:"email"`
+	RSVP        string  `gorm:"column:rsvp;type:varchar(20);not null" json:"rsvp"` // 'invited', 'going', 'maybe', 'declined' or 'waitlisted'
+	Token       string  `gorm:"type:varchar(32);uniqueIndex;not null" json:"-"`
+	RespondedAt *int64  `json:"responded_at"`
+	ScoreID     *uint   `json:"score_id"` // The participant's score for the round, once linked
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	User  *User            `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Score *UserCourseScore `gorm:"foreignKey:ScoreID" json:"score,omitempty"`
+}
+
+// OutingCalendarFeed is the secret token behind a user's outing calendar
+// subscription URL
+type OutingCalendarFeed struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	UserID uint   `gorm:"not null;uniqueIndex" json:"user_id"`
+	Token  string `gorm:"type:varchar(32);not null;uniqueIndex" json:"-"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// OutingView is an outing prepared for one viewer, with everyone's RSVPs
+type OutingView struct {
+	Outing
+	CourseName    string
+	CourseAddress string
+	OrganizerName string
+	IsOrganizer   bool
+	MyInvite      *OutingInvite // Nil when the viewer wasn't invited
+	Invitees      []OutingInvitee
+	GoingCount    int
+	SpotsLeft     int // -1 when the outing has no capacity limit
+}
+
+// OutingInvitee is a person on an outing's invite list
+type OutingInvitee struct {
+	InviteID    uint
+	UserID      *uint
+	Email       *string // Only shown to the organizer
+	Name        string  // Display name, the email address for the organizer, or "Guest"
+	RSVP        string
+	RespondedAt *int64
+}
+
+// OutingResult is a participant's score in an outing's results
+type OutingResult struct {
+	Rank     int
+	UserID   uint
+	Name     string
+	ScoreID  uint
+	Score    int
+	Handicap *float64
+	Net      *float64 // Score less handicap, when the score has one
+}
+
+// CourseRanking is a course's row in the relational course_rankings table
+// (migrations/001_relational_schema.sql), kept in step with course_data so
+// facet filters can be answered with SQL. Unset grades and difficulties are
+// stored as NULL.
+type CourseRanking struct {
+	ID                 uint    `gorm:"primaryKey" json:"id"`
+	CourseID           uint    `gorm:"not null;uniqueIndex" json:"course_id"`
+	Price              string  `gorm:"size:10" json:"price"` // "$" to "$$$$", or empty
+	HandicapDifficulty *int    `gorm:"check:handicap_difficulty BETWEEN 1 AND 20" json:"handicap_difficulty"`
+	HazardDifficulty   *int    `gorm:"check:hazard_difficulty BETWEEN 1 AND 10" json:"hazard_difficulty"`
+	Merch              *string `gorm:"size:1" json:"merch"`
+	Condition          *string `gorm:"size:1" json:"condition"`
+	EnjoymentRating    *string `gorm:"size:1" json:"enjoyment_rating"`
+	Vibe               *string `gorm:"size:1" json:"vibe"`
+	RangeRating        *string `gorm:"size:1" json:"range_rating"`
+	Amenities          *string `gorm:"size:1" json:"amenities"`
+	Glizzies           *string `gorm:"size:1" json:"glizzies"`
+	Walkability        *string `gorm:"size:1" json:"walkability"` // Added by migrations/003_add_walkability_column.sql
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt int64 `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// CourseHole is one hole of a course's card in the relational course_holes
+// table. Par and yardage are NULL when the card's value is missing or outside
+// the table's checks.
+type CourseHole struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	CourseID    uint   `gorm:"not null;index;uniqueIndex:idx_course_holes_course_number" json:"course_id"`
+	HoleNumber  int    `gorm:"not null;uniqueIndex:idx_course_holes_course_number;check:hole_number BETWEEN 1 AND 18" json:"hole_number"`
+	Par         *int   `gorm:"check:par BETWEEN 3 AND 6" json:"par"`
+	Yardage     *int   `gorm:"check:yardage BETWEEN 0 AND 800" json:"yardage"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// CourseHoleGeometry is the mapped layout of one hole on a course. It is keyed
+// by hole number rather than by course_holes row, because those rows are
+// rewritten whenever the course's card is saved.
+type CourseHoleGeometry struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	CourseID     uint   `gorm:"not null;uniqueIndex:idx_course_hole_geometry" json:"course_id"`
+	HoleNumber   int    `gorm:"not null;uniqueIndex:idx_course_hole_geometry" json:"hole_number"`
+	GeometryData string `gorm:"type:text;not null" json:"-"`             // HoleGeometry JSON
+	Source       string `gorm:"type:varchar(10);not null" json:"source"` // "manual", "geojson" or "osm"
+	UpdatedBy    *uint  `json:"updated_by"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt int64 `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// RoundShot is one shot a player tracked by GPS during a round. Shots belong
+// to the player's round at a course on a date, the way scores are dated,
+// rather than to a score row, because scores are rewritten whenever the
+// player's review is saved.
+type RoundShot struct {
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	UserID     uint    `gorm:"not null;index:idx_round_shots_round" json:"user_id"`
+	CourseID   uint    `gorm:"not null;index:idx_round_shots_round" json:"course_id"`
+	DatePlayed string  `gorm:"type:varchar(10);not null;index:idx_round_shots_round" json:"date_played"` // YYYY-MM-DD
+	HoleNumber int     `gorm:"not null" json:"hole_number"`
+	ShotNumber int     `gorm:"not null" json:"shot_number"` // 1 is the tee shot
+	Latitude   float64 `gorm:"not null" json:"latitude"`
+	Longitude  float64 `gorm:"not null" json:"longitude"`
+	Club       *string `gorm:"type:varchar(20)" json:"club"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Itinerary is a saved golf trip: courses in a driving order, split into
+// days. The plan is stored as it was made, so later course edits don't
+// reshuffle a trip someone has booked around.
+type Itinerary struct {
+	ID            uint    `gorm:"primaryKey" json:"id"`
+	UserID        uint    `gorm:"not null;index" json:"user_id"`
+	Name          string  `gorm:"type:varchar(100);not null" json:"name"`
+	Days          int     `gorm:"not null" json:"days"`
+	ReturnToStart bool    `gorm:"not null" json:"return_to_start"`
+	PlanData      string  `gorm:"type:text;not null" json:"-"`           // TripPlan JSON
+	ShareToken    *string `gorm:"type:varchar(32);uniqueIndex" json:"-"` // Nil while the itinerary isn't shared
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt int64 `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// ItineraryView is a saved itinerary with its plan
+type ItineraryView struct {
+	Itinerary
+	OwnerName string // Only on shared itineraries
+	Plan      TripPlan
+}
+
+// CourseMerge records a duplicate course merged into another. The duplicate's
+// row is deleted by the merge, so its name, address and data are kept here.
+type CourseMerge struct {
+	ID             uint              `gorm:"primaryKey" json:"id"`
+	SourceCourseID uint              `gorm:"not null;index" json:"source_course_id"` // The duplicate
+	TargetCourseID uint              `gorm:"not null;index" json:"target_course_id"` // The surviving course
+	SourceName     string            `gorm:"not null" json:"source_name"`
+	SourceAddress  string            `json:"source_address"`
+	SourceData     string            `gorm:"type:text" json:"-"`    // The duplicate's course_data
+	Score          float64           `gorm:"not null" json:"score"` // The pair's duplicate score when merged
+	Moved          CourseMergeCounts `gorm:"type:text;serializer:json" json:"moved"`
+	MergedBy       *uint             `json:"merged_by"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// CourseMergeCounts is what a merge moved to the surviving course
+type CourseMergeCounts struct {
+	Reviews        int64    `json:"reviews"`
+	ReviewsDropped int64    `json:"reviews_dropped"` // Older reviews by golfers who reviewed both courses
+	Scores         int64    `json:"scores"`
+	PlayerHoles    int64    `json:"player_holes"` // Golfers' hole-by-hole notes
+	Activities     int64    `json:"activities"`
+	Conditions     int64    `json:"conditions"`
+	Notifications  int64    `json:"notifications"`
+	ListEntries    int64    `json:"list_entries"`
+	Outings        int64    `json:"outings"`
+	HoleLayouts    int64    `json:"hole_layouts"`
+	Shots          int64    `json:"shots"`
+	CourseEdits    int64    `json:"course_edits"`  // Suggested edits, answered or not
+	Itineraries    int64    `json:"itineraries"`   // Saved trips with the course on them
+	Redirects      int64    `json:"redirects"`     // Earlier merges repointed at the surviving course
+	CourseFields   []string `json:"course_fields"` // course_data fields copied because the surviving course had none
+}
+
+// CourseRedirect points the ID and hash of a merged course at the course it
+// was merged into. Chains are collapsed when the surviving course is merged
+// in turn, so ToCourseID is always a course that exists.
+type CourseRedirect struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	FromCourseID uint   `gorm:"not null;uniqueIndex" json:"from_course_id"`
+	FromHash     string `gorm:"type:varchar(64);not null;index" json:"-"`
+	ToCourseID   uint   `gorm:"not null;index" json:"to_course_id"`
+	MergeID      uint   `gorm:"not null" json:"merge_id"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// CourseEditProposal is a change to a course's details suggested by someone
+// who can't edit the course. The course's creator or an admin accepts or
+// rejects it; suggestions left unanswered are escalated to the admins.
+type CourseEditProposal struct {
+	ID          uint                `gorm:"primaryKey" json:"id"`
+	CourseID    uint                `gorm:"not null;index" json:"course_id"`
+	UserID      uint                `gorm:"not null;index" json:"user_id"` // Who suggested it
+	Changes     []CourseFieldChange `gorm:"type:text;serializer:json" json:"changes"`
+	Note        *string             `gorm:"type:text" json:"note,omitempty"`                                 // Why, e.g. where the proposer saw it
+	Status      string              `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // 'pending', 'accepted', 'rejected', 'withdrawn'
+	EscalatedAt *int64              `gorm:"index" json:"escalated_at,omitempty"`                             // When it was passed to the admins
+	ReviewedBy  *uint               `json:"reviewed_by,omitempty"`                                           // Who accepted or rejected it
+	ReviewNote  *string             `gorm:"type:text" json:"review_note,omitempty"`                          // Shown to the proposer
+	ReviewedAt  *int64              `json:"reviewed_at,omitempty"`
+
+	// Timestamps
+	CreatedAt int64 `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt int64 `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Course *CourseDB `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	User   *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// CourseFieldChange is one field of a suggested course edit. Fields are
+// "name", "address" and "description", or "holes.N.par", "holes.N.yardage"
+// and "holes.N.description" for hole N.
+type CourseFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"` // The value when the edit was suggested; empty for a hole the card doesn't have
+	To    string `json:"to"`
+}
+
+// CourseEditProposalView is a suggested edit prepared for one viewer
+type CourseEditProposalView struct {
+	CourseEditProposal
+	CourseName   string
+	ProposerName string
+	ReviewerName string
+	CanReview    bool // The viewer created the course or is an admin
+	CanWithdraw  bool // The viewer suggested it and it's still pending
+}
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 
+	"course_management/api"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrOwnReviewHelpful = errors.New("you can't mark your own review as helpful")
+
 type ReviewService struct {
 	db *gorm.DB
 }
@@ -28,9 +35,20 @@ func (rs *ReviewService) CreateOrUpdateReview(userID uint, courseID uint, formDa
 	var existingReview CourseReview
 	result := rs.db.Where("user_id = ? AND course_id = ?", userID, courseID).First(&existingReview)
 
+	// New reviews are public unless the form says otherwise
+	current := ReviewVisibilityPublic
+	if result.Error == nil {
+		current = existingReview.Visibility
+	}
+	visibility, err := normalizeReviewVisibility(formData.Visibility, current)
+	if err != nil {
+		return nil, err
+	}
+
 	review := &CourseReview{
-		UserID:   userID,
-		CourseID: courseID,
+		UserID:     userID,
+		CourseID:   courseID,
+		Visibility: visibility,
 	}
 
 	// Convert form data to review fields
@@ -92,6 +110,15 @@ func (rs *ReviewService) CreateOrUpdateReview(userID uint, courseID uint, formDa
 		return nil, fmt.Errorf("failed to save review: %v", result.Error)
 	}
 
+	// Save structured tags and amenities, which also refreshes the course consensus
+	attributeService := &CourseAttributeService{db: rs.db}
+	if err := attributeService.SaveReviewAttributes(review.ID, courseID, formData.Tags, formData.AmenityVotes); err != nil {
+		log.Printf("Warning: failed to save review attributes: %v", err)
+	}
+
+	// Re-run the text analysis for the course
+	refreshCourseReviewInsight(rs.db, courseID)
+
 	return review, nil
 }
 
@@ -147,6 +174,21 @@ func (rs *ReviewService) DeleteUserReview(userID uint, courseID uint) error {
 		return fmt.Errorf("failed to delete review: %v", result.Error)
 	}
 
+	// Delete the discussion attached to the review
+	result = rs.db.Where("review_id = ?", review.ID).Delete(&ReviewComment{})
+	if result.Error != nil {
+		log.Printf("Warning: failed to delete review comments: %v", result.Error)
+	}
+
+	// Drop the review's tag and amenity votes from the course consensus
+	attributeService := &CourseAttributeService{db: rs.db}
+	if err := attributeService.DeleteReviewAttributes(review.ID, courseID); err != nil {
+		log.Printf("Warning: failed to delete review attributes: %v", err)
+	}
+
+	// Drop the review's text from the course's text analysis
+	refreshCourseReviewInsight(rs.db, courseID)
+
 	// Also delete associated scores and holes for this user/course
 	// Delete scores
 	result = rs.db.Where("user_id = ? AND course_id = ?", userID, courseID).Delete(&UserCourseScore{})
@@ -160,6 +202,12 @@ func (rs *ReviewService) DeleteUserReview(userID uint, courseID uint) error {
 		log.Printf("Warning: failed to delete user holes: %v", result.Error)
 	}
 
+	// Delete shots tracked by GPS
+	result = rs.db.Where("user_id = ? AND course_id = ?", userID, courseID).Delete(&RoundShot{})
+	if result.Error != nil {
+		log.Printf("Warning: failed to delete tracked shots: %v", result.Error)
+	}
+
 	log.Printf("✅ Deleted review and associated data for user %d, course %d", userID, courseID)
 	return nil
 }
@@ -220,25 +268,32 @@ func (rs *ReviewService) GetUserReviewsWithAuth(requestingUserID uint, targetUse
 	return reviews, nil
 }
 
-// GetCourseReviews gets all reviews for a specific course
-// SECURITY WARNING: This function returns ALL reviews for a course including user information
-// Consider using GetCourseReviewSummary for public data or implement proper authorization
-func (rs *ReviewService) GetCourseReviews(courseID uint) ([]CourseReview, error) {
+// GetCourseReviews gets the reviews for a specific course that viewerID may read
+// SECURITY: Other users' private reviews are left out and anonymous reviews have
+// their user ID cleared unless viewerID wrote them. viewerID is nil for guests.
+func (rs *ReviewService) GetCourseReviews(courseID uint, viewerID *uint) ([]CourseReview, error) {
 	if rs.db == nil {
 		return nil, fmt.Errorf("database not connected")
 	}
 
-	log.Printf("🚨 [SECURITY] GetCourseReviews called for course_id=%d - returns all user reviews", courseID)
+	log.Printf("🔒 [SECURITY] GetCourseReviews called for course_id=%d", courseID)
 
 	var reviews []CourseReview
 	// SECURITY: Removed Preload("User") to avoid exposing user personal information
-	result := rs.db.Where("course_id = ?", courseID).Order("created_at DESC").Find(&reviews)
+	result := rs.db.Scopes(readableReviews(viewerID)).
+		Where("course_id = ?", courseID).
+		Order("created_at DESC").
+		Find(&reviews)
 
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get course reviews: %v", result.Error)
 	}
 
-	log.Printf("🚨 [SECURITY] Returning %d reviews for course_id=%d", len(reviews), courseID)
+	for i := range reviews {
+		redactReviewer(&reviews[i], viewerID)
+	}
+
+	log.Printf("🔒 [SECURITY] Returning %d reviews for course_id=%d", len(reviews), courseID)
 	return reviews, nil
 }
 
@@ -248,8 +303,9 @@ func (rs *ReviewService) GetCourseReviewSummary(courseID uint) (*CourseReviewSum
 		return nil, fmt.Errorf("database not connected")
 	}
 
+	// Private notes don't count; anonymous reviews do
 	var totalReviews int64
-	result := rs.db.Model(&CourseReview{}).Where("course_id = ?", courseID).Count(&totalReviews)
+	result := rs.db.Model(&CourseReview{}).Scopes(aggregatedReviews).Where("course_id = ?", courseID).Count(&totalReviews)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to count reviews: %v", result.Error)
 	}
@@ -261,6 +317,7 @@ func (rs *ReviewService) GetCourseReviewSummary(courseID uint) (*CourseReviewSum
 	}
 
 	result = rs.db.Model(&CourseReview{}).
+		Scopes(aggregatedReviews).
 		Select("overall_rating, COUNT(*) as count").
 		Where("course_id = ? AND overall_rating IS NOT NULL", courseID).
 		Group("overall_rating").
@@ -295,6 +352,84 @@ func (rs *ReviewService) GetCourseReviewSummary(courseID uint) (*CourseReviewSum
 	return summary, nil
 }
 
+// SetReviewHelpful adds or withdraws userID's helpful vote on a review. A new
+// vote lets the reviewer know through a "review_helpful" event.
+func (rs *ReviewService) SetReviewHelpful(userID, reviewID uint, helpful bool) error {
+	if rs.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	var review CourseReview
+	if err := rs.db.Scopes(readableReviews(&userID)).First(&review, reviewID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrReviewNotFound
+		}
+		return fmt.Errorf("failed to get review: %v", err)
+	}
+	if review.UserID == userID {
+		return ErrOwnReviewHelpful
+	}
+
+	if !helpful {
+		if err := rs.db.Where("review_id = ? AND user_id = ?", reviewID, userID).Delete(&ReviewHelpfulVote{}).Error; err != nil {
+			return fmt.Errorf("failed to remove helpful vote: %v", err)
+		}
+		return nil
+	}
+
+	result := rs.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&ReviewHelpfulVote{ReviewID: reviewID, UserID: userID})
+	if result.Error != nil {
+		return fmt.Errorf("failed to add helpful vote: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		// Private, since it's news to the reviewer rather than the voter's followers
+		publishLiveEvent(rs.db, LiveEvent{Type: "review_helpful", UserID: userID, CourseID: &review.CourseID, TargetUserID: &review.UserID, Private: true})
+	}
+	return nil
+}
+
+// GetHelpfulVotes counts the helpful votes on each review and reports which
+// of them viewerID voted for
+func (rs *ReviewService) GetHelpfulVotes(reviewIDs []uint, viewerID *uint) (map[uint]int, map[uint]bool, error) {
+	if rs.db == nil {
+		return nil, nil, fmt.Errorf("database not connected")
+	}
+
+	counts := make(map[uint]int, len(reviewIDs))
+	voted := make(map[uint]bool)
+	if len(reviewIDs) == 0 {
+		return counts, voted, nil
+	}
+
+	var rows []struct {
+		ReviewID uint
+		Count    int
+	}
+	if err := rs.db.Model(&ReviewHelpfulVote{}).
+		Select("review_id, COUNT(*) AS count").
+		Where("review_id IN ?", reviewIDs).
+		Group("review_id").
+		Scan(&rows).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to count helpful votes: %v", err)
+	}
+	for _, row := range rows {
+		counts[row.ReviewID] = row.Count
+	}
+
+	if viewerID != nil {
+		var votedIDs []uint
+		if err := rs.db.Model(&ReviewHelpfulVote{}).
+			Where("user_id = ? AND review_id IN ?", *viewerID, reviewIDs).
+			Pluck("review_id", &votedIDs).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to get helpful votes: %v", err)
+		}
+		for _, id := range votedIDs {
+			voted[id] = true
+		}
+	}
+	return counts, voted, nil
+}
+
 // AddScore adds a score for a user and course
 func (rs *ReviewService) AddScore(userID uint, courseID uint, formData ScoreFormData) (*UserCourseScore, error) {
 	if rs.db == nil {
@@ -532,22 +667,33 @@ func (rs *ReviewService) createActivity(userID uint, activityType string, course
 		return
 	}
 
-	activity := &UserActivity{
+	recordActivity(rs.db, &UserActivity{
 		UserID:       userID,
 		ActivityType: activityType,
 		CourseID:     courseID,
-	}
+	}, data)
+}
 
-	// Convert data to JSON string if provided
+// recordActivity saves an activity record with its data encoded as JSON, since
+// the data column is jsonb, and announces it on the event bus. Failures are
+// logged so they never block the action itself.
+func recordActivity(db *gorm.DB, activity *UserActivity, data any) {
+	activity.Data = "{}"
 	if data != nil {
-		// For simplicity, we'll store as string - you might want to use proper JSON marshaling
-		activity.Data = fmt.Sprintf("%v", data)
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			log.Printf("Warning: failed to encode activity data: %v", err)
+		} else {
+			activity.Data = string(encoded)
+		}
 	}
 
-	result := rs.db.Create(activity)
+	result := db.Create(activity)
 	if result.Error != nil {
 		log.Printf("Warning: failed to create activity record: %v", result.Error)
+		return
 	}
+	publishActivity(db, activity)
 }
 
 // ParseReviewFormData parses form data from HTTP request into ReviewFormData
@@ -564,6 +710,23 @@ func ParseReviewFormData(getFormValue func(string) string) ReviewFormData {
 		return val
 	}
 
+	// Tags are checkboxes named tag-<slug>; amenities are yes/no selects named amenity-<slug>
+	var tags []string
+	for _, option := range api.ReviewTagOptions {
+		if getFormValue("tag-"+option.Slug) != "" {
+			tags = append(tags, option.Slug)
+		}
+	}
+	amenityVotes := make(map[string]bool)
+	for _, option := range api.AmenityOptions {
+		switch getFormValue("amenity-" + option.Slug) {
+		case "yes":
+			amenityVotes[option.Slug] = true
+		case "no":
+			amenityVotes[option.Slug] = false
+		}
+	}
+
 	return ReviewFormData{
 		OverallRating:      getFormValue("overall-rating"),
 		Price:              getFormValue("price"),
@@ -578,6 +741,9 @@ func ParseReviewFormData(getFormValue func(string) string) ReviewFormData {
 		Glizzies:           getFormValue("glizzies"),
 		Walkability:        getFormValue("walkability"),
 		ReviewText:         getFormValue("course-review"),
+		Visibility:         getFormValue("visibility"),
+		Tags:               tags,
+		AmenityVotes:       amenityVotes,
 	}
 }
 
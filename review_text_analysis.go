@@ -0,0 +1,347 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// reviewAnalyzerVersion is stored with each course insight. Bump it when the
+// lexicon, stopwords or scoring change so the next job run re-analyzes every course.
+const reviewAnalyzerVersion = 1
+
+const (
+	// Sentiment scores are squashed into [-1, 1] with score/sqrt(score² + sentimentNormalization)
+	sentimentNormalization = 15.0
+
+	// Scores within this distance of zero are labelled neutral
+	sentimentNeutralBand = 0.05
+
+	// How many tokens after "not", "never", ... have their polarity flipped
+	negationScope = 3
+
+	maxReviewMentions = 8
+)
+
+// Sentiment labels
+const (
+	SentimentPositive = "positive"
+	SentimentNeutral  = "neutral"
+	SentimentNegative = "negative"
+)
+
+// reviewSentimentLexicon scores words from -4 (very negative) to +4 (very positive).
+// It is a small general-purpose list plus the vocabulary golfers actually use in reviews.
+var reviewSentimentLexicon = map[string]float64{
+	// General
+	"amazing": 4, "awesome": 3, "beautiful": 3, "best": 3, "brilliant": 3, "enjoyable": 2,
+	"enjoyed": 2, "excellent": 3, "fantastic": 4, "favorite": 2, "favourite": 2, "fun": 2,
+	"gem": 3, "gorgeous": 3, "great": 3, "good": 2, "incredible": 4, "love": 3, "loved": 3,
+	"lovely": 3, "nice": 2, "outstanding": 4, "perfect": 3, "pleasant": 2, "recommend": 2,
+	"scenic": 2, "solid": 1, "stunning": 3, "superb": 3, "worth": 2, "wonderful": 3,
+	"awful": -3, "bad": -2, "boring": -2, "disappointed": -2, "disappointing": -2,
+	"horrible": -3, "mediocre": -1, "meh": -1, "poor": -2, "terrible": -3, "ugly": -2,
+	"underwhelming": -2, "unpleasant": -2, "worst": -3, "avoid": -2, "waste": -3,
+
+	// Staff and service
+	"friendly": 2, "helpful": 2, "welcoming": 2, "courteous": 2, "rude": -3, "unfriendly": -2,
+	"unhelpful": -2, "disorganized": -2,
+
+	// Conditions
+	"immaculate": 3, "lush": 2, "manicured": 2, "pristine": 3, "pure": 2, "true": 1,
+	"smooth": 2, "fast": 1, "firm": 1, "receptive": 1, "maintained": 1, "healthy": 1,
+	"bumpy": -2, "burnt": -2, "bare": -2, "brown": -1, "dead": -2, "muddy": -2, "patchy": -2,
+	"shaggy": -2, "soggy": -2, "spotty": -1, "wet": -1, "sandy": -1, "neglected": -3,
+	"unkempt": -2, "overgrown": -2, "dry": -1,
+
+	// Pace and value
+	"quick": 1, "value": 2, "affordable": 2, "cheap": 1, "bargain": 2, "reasonable": 1,
+	"slow": -2, "backed": -1, "crowded": -2, "overpriced": -3, "pricey": -1, "expensive": -1,
+
+	// Design
+	"challenging": 1, "fair": 1, "interesting": 2, "memorable": 2, "strategic": 2, "unique": 2,
+	"variety": 1, "walkable": 1, "tricked": -1, "unfair": -2, "repetitive": -1,
+	"bland": -2, "dangerous": -2, "blind": -1, "cramped": -2, "tight": -1,
+}
+
+var sentimentNegators = map[string]bool{
+	"not": true, "no": true, "never": true, "hardly": true, "barely": true, "without": true,
+	"dont": true, "didnt": true, "doesnt": true, "isnt": true, "wasnt": true, "werent": true,
+	"arent": true, "wont": true, "wouldnt": true, "cant": true, "couldnt": true, "aint": true,
+}
+
+var sentimentIntensifiers = map[string]float64{
+	"very": 1.5, "really": 1.5, "super": 1.5, "extremely": 1.8, "incredibly": 1.8,
+	"so": 1.3, "too": 1.3, "pretty": 1.2, "quite": 1.2, "somewhat": 0.7, "slightly": 0.6,
+	"bit": 0.7, "little": 0.7,
+}
+
+// reviewStopwords are left out of keyword extraction. Besides the usual English
+// function words it drops words every golf review contains.
+var reviewStopwords = toWordSet(`
+a about above after again against all almost also am an and any are as at be
+because been before being below between both but by can could did do does doing
+down during each even ever every few for from further get got had has have having
+he her here hers him his how i if in into is it its itself just me more most my
+no nor not now of off on once only or other our ours out over own really same she
+should so some such than that the their them then there these they this those
+through to too under until up us very was way we were what when where which while
+who whom why will with would you your yours much many well still yet back though
+one two three went go going come came play played playing make made take took
+course courses golf golfer golfers hole holes round rounds time day today
+dont didnt doesnt isnt wasnt werent arent wont wouldnt cant couldnt aint im ive
+lot lots thing things bit little pretty quite
+`)
+
+func toWordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// ReviewMention is a keyword or two-word phrase that comes up across a course's reviews
+type ReviewMention struct {
+	Term      string  `json:"term"`
+	Reviews   int     `json:"reviews"`   // Number of reviews that mention it
+	Sentiment float64 `json:"sentiment"` // Mean sentiment of those reviews
+}
+
+// SentimentLabel labels the mean sentiment of the reviews that mention the term
+func (m ReviewMention) SentimentLabel() string {
+	return sentimentLabel(m.Sentiment)
+}
+
+// ReviewTextAnalysis is the aggregate analysis of one course's review texts
+type ReviewTextAnalysis struct {
+	ReviewCount    int
+	Sentiment      float64
+	SentimentLabel string
+	Positive       int
+	Neutral        int
+	Negative       int
+	Mentions       []ReviewMention
+}
+
+// tokenizeReviewSentences lowercases text and splits it into sentences of word
+// tokens. Apostrophes are dropped so "don't" becomes "dont"; digits are kept
+// so "18th" and "par 3" survive.
+func tokenizeReviewSentences(text string) [][]string {
+	var sentences [][]string
+	var sentence []string
+	var word strings.Builder
+
+	flushWord := func() {
+		if word.Len() > 0 {
+			sentence = append(sentence, word.String())
+			word.Reset()
+		}
+	}
+	flushSentence := func() {
+		flushWord()
+		if len(sentence) > 0 {
+			sentences = append(sentences, sentence)
+			sentence = nil
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		case r == '\'' || r == '’':
+			// Contractions stay one token
+		case r == '.' || r == '!' || r == '?' || r == ';' || r == '\n':
+			flushSentence()
+		default:
+			flushWord()
+		}
+	}
+	flushSentence()
+
+	return sentences
+}
+
+// scoreReviewSentiment scores a review in [-1, 1] from the embedded lexicon.
+// Negators flip the polarity of the next few words and intensifiers scale the
+// next scored word.
+func scoreReviewSentiment(sentences [][]string) float64 {
+	var score float64
+	for _, tokens := range sentences {
+		negateFor := 0
+		boost := 1.0
+		for _, token := range tokens {
+			if sentimentNegators[token] {
+				negateFor = negationScope
+				continue
+			}
+			if factor, ok := sentimentIntensifiers[token]; ok {
+				boost *= factor
+				continue
+			}
+
+			if value, ok := reviewSentimentLexicon[token]; ok {
+				value *= boost
+				if negateFor > 0 {
+					// "not great" is mildly negative rather than as bad as "terrible"
+					value *= -0.75
+				}
+				score += value
+				boost = 1.0
+			}
+			if negateFor > 0 {
+				negateFor--
+			}
+		}
+	}
+
+	if score == 0 {
+		return 0
+	}
+	return score / math.Sqrt(score*score+sentimentNormalization)
+}
+
+func sentimentLabel(score float64) string {
+	switch {
+	case score >= sentimentNeutralBand:
+		return SentimentPositive
+	case score <= -sentimentNeutralBand:
+		return SentimentNegative
+	default:
+		return SentimentNeutral
+	}
+}
+
+func isReviewKeyword(token string) bool {
+	if len([]rune(token)) < 3 || reviewStopwords[token] {
+		return false
+	}
+	for _, r := range token {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false // Pure numbers say nothing on their own
+}
+
+// reviewTerms returns the distinct keywords and bigrams in a review. Bigrams
+// are pairs of adjacent keywords within a sentence, so "greens were fast"
+// yields no bigram but "fast greens" does.
+func reviewTerms(sentences [][]string) (keywords, bigrams map[string]bool) {
+	keywords = make(map[string]bool)
+	bigrams = make(map[string]bool)
+	for _, tokens := range sentences {
+		for i, token := range tokens {
+			if !isReviewKeyword(token) {
+				continue
+			}
+			keywords[token] = true
+			if i > 0 && isReviewKeyword(tokens[i-1]) {
+				bigrams[tokens[i-1]+" "+token] = true
+			}
+		}
+	}
+	return keywords, bigrams
+}
+
+// AnalyzeReviewTexts scores the sentiment of each review and picks the
+// keywords and phrases mentioned by the most reviews. Blank texts are ignored.
+func AnalyzeReviewTexts(texts []string) ReviewTextAnalysis {
+	type termStats struct {
+		reviews   int
+		sentiment float64
+	}
+
+	var analysis ReviewTextAnalysis
+	keywordStats := make(map[string]*termStats)
+	bigramStats := make(map[string]*termStats)
+	var total float64
+
+	count := func(stats map[string]*termStats, terms map[string]bool, score float64) {
+		for term := range terms {
+			if stats[term] == nil {
+				stats[term] = &termStats{}
+			}
+			stats[term].reviews++
+			stats[term].sentiment += score
+		}
+	}
+
+	for _, text := range texts {
+		sentences := tokenizeReviewSentences(text)
+		if len(sentences) == 0 {
+			continue
+		}
+
+		score := scoreReviewSentiment(sentences)
+		analysis.ReviewCount++
+		total += score
+		switch sentimentLabel(score) {
+		case SentimentPositive:
+			analysis.Positive++
+		case SentimentNegative:
+			analysis.Negative++
+		default:
+			analysis.Neutral++
+		}
+
+		keywords, bigrams := reviewTerms(sentences)
+		count(keywordStats, keywords, score)
+		count(bigramStats, bigrams, score)
+	}
+
+	if analysis.ReviewCount == 0 {
+		analysis.SentimentLabel = SentimentNeutral
+		return analysis
+	}
+	analysis.Sentiment = total / float64(analysis.ReviewCount)
+	analysis.SentimentLabel = sentimentLabel(analysis.Sentiment)
+
+	// With a handful of reviews, anything mentioned once is noise
+	minReviews := 1
+	if analysis.ReviewCount >= 3 {
+		minReviews = 2
+	}
+
+	var candidates []ReviewMention
+	for term, stats := range bigramStats {
+		if stats.reviews >= minReviews {
+			candidates = append(candidates, ReviewMention{Term: term, Reviews: stats.reviews, Sentiment: stats.sentiment / float64(stats.reviews)})
+		}
+	}
+
+	// A keyword is only worth its own chip if it comes up outside the phrases above
+	covered := make(map[string]int)
+	for _, mention := range candidates {
+		for _, word := range strings.Fields(mention.Term) {
+			if mention.Reviews > covered[word] {
+				covered[word] = mention.Reviews
+			}
+		}
+	}
+	for term, stats := range keywordStats {
+		if stats.reviews >= minReviews && stats.reviews > covered[term] {
+			candidates = append(candidates, ReviewMention{Term: term, Reviews: stats.reviews, Sentiment: stats.sentiment / float64(stats.reviews)})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Reviews != candidates[j].Reviews {
+			return candidates[i].Reviews > candidates[j].Reviews
+		}
+		// Phrases are more telling than single words at the same count
+		iWords, jWords := strings.Count(candidates[i].Term, " "), strings.Count(candidates[j].Term, " ")
+		if iWords != jWords {
+			return iWords > jWords
+		}
+		return candidates[i].Term < candidates[j].Term
+	})
+	if len(candidates) > maxReviewMentions {
+		candidates = candidates[:maxReviewMentions]
+	}
+	analysis.Mentions = candidates
+
+	return analysis
+}
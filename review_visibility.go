@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Review visibility levels
+const (
+	// ReviewVisibilityPublic reviews are shown with the reviewer's name
+	ReviewVisibilityPublic = "public"
+	// ReviewVisibilityAnonymous reviews are shown and count towards course
+	// aggregates, but the reviewer's name is hidden from everyone else
+	ReviewVisibilityAnonymous = "anonymous"
+	// ReviewVisibilityPrivate reviews are notes only the reviewer can read.
+	// They are left out of every aggregate.
+	ReviewVisibilityPrivate = "private"
+)
+
+// AnonymousReviewerName is shown in place of the name on anonymous reviews
+const AnonymousReviewerName = "Anonymous golfer"
+
+var ErrInvalidReviewVisibility = errors.New("visibility must be public, anonymous or private")
+
+// ReviewVisibilityOption is a visibility choice on the review form
+type ReviewVisibilityOption struct {
+	Value       string
+	Label       string
+	Description string
+	Selected    bool
+}
+
+// normalizeReviewVisibility lowercases a visibility value. An empty value
+// keeps current, so editing a review without choosing leaves it as it was.
+func normalizeReviewVisibility(visibility, current string) (string, error) {
+	visibility = strings.ToLower(strings.TrimSpace(visibility))
+	switch visibility {
+	case "":
+		return current, nil
+	case ReviewVisibilityPublic, ReviewVisibilityAnonymous, ReviewVisibilityPrivate:
+		return visibility, nil
+	default:
+		return "", ErrInvalidReviewVisibility
+	}
+}
+
+// BuildReviewVisibilityOptions lists the visibility choices with the current one selected
+func BuildReviewVisibilityOptions(current string) []ReviewVisibilityOption {
+	if current == "" {
+		current = ReviewVisibilityPublic
+	}
+	options := []ReviewVisibilityOption{
+		{Value: ReviewVisibilityPublic, Label: "Public", Description: "Anyone can read it with your name"},
+		{Value: ReviewVisibilityAnonymous, Label: "Anonymous", Description: "Anyone can read it, but your name is hidden"},
+		{Value: ReviewVisibilityPrivate, Label: "Private notes", Description: "Only you can read it and it doesn't count towards course ratings"},
+	}
+	for i := range options {
+		options[i].Selected = options[i].Value == current
+	}
+	return options
+}
+
+// readableReviews limits a course_reviews query to the reviews viewerID may
+// read: everything except other people's private notes
+func readableReviews(viewerID *uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if viewerID != nil {
+			return db.Where("course_reviews.visibility <> ? OR course_reviews.user_id = ?", ReviewVisibilityPrivate, *viewerID)
+		}
+		return db.Where("course_reviews.visibility <> ?", ReviewVisibilityPrivate)
+	}
+}
+
+// aggregatedReviews limits a course_reviews query to the reviews that count
+// towards course ratings, tags and text insights
+func aggregatedReviews(db *gorm.DB) *gorm.DB {
+	return db.Where("course_reviews.visibility <> ?", ReviewVisibilityPrivate)
+}
+
+// canReadReview reports whether viewerID may read the review
+func canReadReview(review *CourseReview, viewerID *uint) bool {
+	return review.Visibility != ReviewVisibilityPrivate || (viewerID != nil && *viewerID == review.UserID)
+}
+
+// hidesReviewer reports whether the reviewer's identity must be hidden from viewerID
+func hidesReviewer(review *CourseReview, viewerID *uint) bool {
+	return review.Visibility == ReviewVisibilityAnonymous && (viewerID == nil || *viewerID != review.UserID)
+}
+
+// redactReviewer strips the reviewer's identity from an anonymous review
+// unless viewerID wrote it
+func redactReviewer(review *CourseReview, viewerID *uint) {
+	if hidesReviewer(review, viewerID) {
+		review.UserID = 0
+		review.User = nil
+	}
+}
+
+// redactCommentAuthor masks a comment written by the author of an anonymous
+// review in that review's thread, so replying doesn't give the reviewer away
+func redactCommentAuthor(thread *ReviewCommentThread, review *CourseReview, viewerID *uint) {
+	if review != nil && thread.UserID == review.UserID && hidesReviewer(review, viewerID) {
+		thread.UserID = 0
+		thread.AuthorName = AnonymousReviewerName
+	}
+}
+
+// visibleActivities limits a user_activities query to the rows viewerID may
+// see. A "course_review" activity reveals who reviewed which course, so it is
+// hidden from everyone but the reviewer while that review is anonymous or private.
+func visibleActivities(viewerID *uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		hidden := db.Session(&gorm.Session{NewDB: true}).
+			Model(&CourseReview{}).
+			Select("1").
+			Where("course_reviews.user_id = user_activities.user_id AND course_reviews.course_id = user_activities.course_id").
+			Where("course_reviews.visibility <> ?", ReviewVisibilityPublic)
+
+		condition := db.Session(&gorm.Session{NewDB: true}).
+			Where("user_activities.activity_type <> ?", "course_review").
+			Or("NOT EXISTS (?)", hidden)
+		if viewerID != nil {
+			condition = condition.Or("user_activities.user_id = ?", *viewerID)
+		}
+		return db.Where(condition)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewVisibility_CourseReadPaths(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, &owner.ID)
+	seedReview(t, db, course.ID, reviewer.ID)
+	reviewService := NewReviewService()
+
+	_, err := reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{OverallRating: "F", ReviewText: "Rude staff", Visibility: "anonymous"})
+	require.NoError(t, err)
+	_, err = reviewService.CreateOrUpdateReview(owner.ID, course.ID, ReviewFormData{OverallRating: "S", ReviewText: "Note to self: bring a rain jacket", Visibility: "private"})
+	require.NoError(t, err)
+
+	_, err = reviewService.CreateOrUpdateReview(owner.ID, course.ID, ReviewFormData{Visibility: "friends"})
+	assert.ErrorIs(t, err, ErrInvalidReviewVisibility)
+
+	// Guests see the public and anonymous reviews, without the anonymous reviewer's ID
+	reviews, err := reviewService.GetCourseReviews(course.ID, nil)
+	require.NoError(t, err)
+	require.Len(t, reviews, 2)
+	byText := make(map[string]CourseReview)
+	for _, review := range reviews {
+		byText[*review.ReviewText] = review
+	}
+	assert.Equal(t, uint(0), byText["Rude staff"].UserID)
+	assert.Equal(t, reviewer.ID, byText["Fast greens and a great back nine"].UserID)
+
+	// Authors see their own private and anonymous reviews in full
+	reviews, err = reviewService.GetCourseReviews(course.ID, &owner.ID)
+	require.NoError(t, err)
+	assert.Len(t, reviews, 3)
+	reviews, err = reviewService.GetCourseReviews(course.ID, &golfer.ID)
+	require.NoError(t, err)
+	for _, review := range reviews {
+		if review.Visibility == ReviewVisibilityAnonymous {
+			assert.Equal(t, golfer.ID, review.UserID)
+		}
+	}
+
+	// Anonymous reviews count towards the summary, private notes don't
+	summary, err := reviewService.GetCourseReviewSummary(course.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.TotalReviews)
+	assert.Equal(t, map[string]int{"A": 1, "F": 1}, summary.RatingCounts)
+
+	// The course discussion follows the same rules
+	discussion, err := NewReviewCommentService().GetCourseDiscussion(course.ID, nil)
+	require.NoError(t, err)
+	require.Len(t, discussion, 2)
+	for _, review := range discussion {
+		if review.Visibility == ReviewVisibilityAnonymous {
+			assert.Equal(t, AnonymousReviewerName, review.AuthorName)
+			assert.Equal(t, uint(0), review.UserID)
+		}
+	}
+}
+
+func TestReviewVisibility_PrivateReviewsStayPrivate(t *testing.T) {
+	db := setupTestDatabase(t)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+	seedReview(t, db, course.ID, reviewer.ID)
+	reviewService := NewReviewService()
+	commentService := NewReviewCommentService()
+
+	private, err := reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{
+		ReviewText:   "Links style, great range",
+		Tags:         []string{"links-style"},
+		AmenityVotes: map[string]bool{"range": true},
+		Visibility:   "private",
+	})
+	require.NoError(t, err)
+
+	// Nobody else can read or comment on it
+	visible, err := commentService.CanViewReview(private.ID, &reviewer.ID)
+	require.NoError(t, err)
+	assert.False(t, visible)
+	visible, err = commentService.CanViewReview(private.ID, &golfer.ID)
+	require.NoError(t, err)
+	assert.True(t, visible)
+
+	_, err = commentService.CreateComment(reviewer.ID, private.ID, nil, "Can I see this?")
+	assert.ErrorIs(t, err, ErrReviewNotFound)
+	_, _, err = commentService.GetReviewComments(private.ID, nil, 1, 20)
+	assert.ErrorIs(t, err, ErrReviewNotFound)
+
+	// Its tags and text stay out of the course aggregates
+	attributes, err := NewCourseAttributeService().GetCourseAttributes(course.ID)
+	require.NoError(t, err)
+	assert.Empty(t, attributes.Tags)
+	assert.Empty(t, attributes.Amenities)
+
+	insight, err := NewReviewInsightService().GetCourseInsight(course.ID)
+	require.NoError(t, err)
+	require.NotNil(t, insight)
+	assert.Equal(t, 1, insight.ReviewCount, "only the public review is analyzed")
+
+	// Going public brings it back into the aggregates
+	_, err = reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{
+		ReviewText:   "Links style, great range",
+		Tags:         []string{"links-style"},
+		AmenityVotes: map[string]bool{"range": true},
+		Visibility:   "public",
+	})
+	require.NoError(t, err)
+	attributes, err = NewCourseAttributeService().GetCourseAttributes(course.ID)
+	require.NoError(t, err)
+	assert.Len(t, attributes.Amenities, 1)
+}
+
+func TestReviewVisibility_EditingKeepsVisibility(t *testing.T) {
+	db := setupTestDatabase(t)
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+	reviewService := NewReviewService()
+
+	_, err := reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{OverallRating: "B", ReviewText: "Note to self", Visibility: "private"})
+	require.NoError(t, err)
+
+	// An edit that doesn't choose a visibility mustn't publish the review
+	review, err := reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{OverallRating: "A", ReviewText: "Note to self, updated"})
+	require.NoError(t, err)
+	assert.Equal(t, ReviewVisibilityPrivate, review.Visibility)
+
+	var stored CourseReview
+	require.NoError(t, db.First(&stored, review.ID).Error)
+	assert.Equal(t, ReviewVisibilityPrivate, stored.Visibility)
+	assert.Equal(t, "Note to self, updated", *stored.ReviewText)
+
+	// New reviews still default to public
+	second := CourseDB{Name: "Dunes", Address: "2 Shore Rd", Hash: "dunes", CourseData: "{}"}
+	require.NoError(t, db.Create(&second).Error)
+	review, err = reviewService.CreateOrUpdateReview(golfer.ID, second.ID, ReviewFormData{OverallRating: "C"})
+	require.NoError(t, err)
+	assert.Equal(t, ReviewVisibilityPublic, review.Visibility)
+}
+
+func TestReviewVisibility_UserReviewsAndActivity(t *testing.T) {
+	db := setupTestDatabase(t)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	course := seedCourse(t, db, nil)
+	reviewService := NewReviewService()
+
+	second := CourseDB{Name: "Dunes", Address: "2 Shore Rd", Hash: "dunes", CourseData: "{}"}
+	third := CourseDB{Name: "Ridge", Address: "3 Hill Ln", Hash: "ridge", CourseData: "{}"}
+	require.NoError(t, db.Create(&second).Error)
+	require.NoError(t, db.Create(&third).Error)
+
+	_, err := reviewService.CreateOrUpdateReview(golfer.ID, course.ID, ReviewFormData{OverallRating: "A"})
+	require.NoError(t, err)
+	_, err = reviewService.CreateOrUpdateReview(golfer.ID, second.ID, ReviewFormData{OverallRating: "C", Visibility: "anonymous"})
+	require.NoError(t, err)
+	_, err = reviewService.CreateOrUpdateReview(golfer.ID, third.ID, ReviewFormData{OverallRating: "B", Visibility: "private"})
+	require.NoError(t, err)
+
+	own, err := reviewService.GetUserReviewsWithAuth(golfer.ID, golfer.ID)
+	require.NoError(t, err)
+	assert.Len(t, own, 3)
+
+	_, err = reviewService.GetUserReviewsWithAuth(reviewer.ID, golfer.ID)
+	assert.Error(t, err, "other users' review lists stay private")
+
+	// Listing someone's anonymous reviews would unmask them, so their profile
+	// only shows others their public ones
+	profile := &PublicProfile{UserID: golfer.ID}
+	require.NoError(t, (&ProfileService{db: db}).loadReviews(profile))
+	require.Len(t, profile.Reviews, 1)
+	assert.Equal(t, "Pebble Creek", profile.Reviews[0].CourseName)
+
+	var activities []UserActivity
+	require.NoError(t, db.Scopes(visibleActivities(&golfer.ID)).
+		Where("user_activities.user_id = ?", golfer.ID).Find(&activities).Error)
+	assert.Len(t, activities, 3)
+
+	activities = nil
+	require.NoError(t, db.Scopes(visibleActivities(nil)).
+		Where("user_activities.user_id = ?", golfer.ID).Find(&activities).Error)
+	require.Len(t, activities, 1)
+	assert.Equal(t, course.ID, *activities[0].CourseID)
+}
+
+func TestReviewCourseTemplate_VisibilityOptions(t *testing.T) {
+	templates := NewTemplates("views")
+	var out bytes.Buffer
+	err := templates.templates.ExecuteTemplate(&out, "review-course", map[string]interface{}{
+		"Course":            &CourseDB{ID: 1, Name: "Dunes"},
+		"VisibilityOptions": BuildReviewVisibilityOptions(ReviewVisibilityAnonymous),
+	})
+	require.NoError(t, err)
+
+	html := out.String()
+	assert.Contains(t, html, `name="visibility" value="anonymous" checked`)
+	assert.Contains(t, html, `name="visibility" value="private" >`)
+
+	data := ParseReviewFormData(func(key string) string {
+		return map[string]string{"visibility": "private"}[key]
+	})
+	assert.Equal(t, "private", data.Visibility)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxShotsPerHole      = 20
+	maxShotClubLength    = 20
+	shotDateLayout       = "2006-01-02"
+	maxShotDaysInAdvance = 1 // Time zones can put a player's today a day ahead of the server's
+)
+
+var (
+	ErrShotNotFound     = errors.New("shot not found")
+	ErrInvalidShot      = errors.New("invalid shot")
+	ErrShotLimitReached = errors.New("hole has reached the shot limit")
+)
+
+// ShotInput is a GPS position a player records as their next shot
+type ShotInput struct {
+	DatePlayed string // YYYY-MM-DD, defaulting to today
+	HoleNumber int    // Zero to find the hole from the position
+	Position   GeoPoint
+	Club       string
+}
+
+// TrackedShot is a recorded shot with how far it went
+type TrackedShot struct {
+	RoundShot
+	Distance *int // Yards to the next shot on the hole; nil for the last one
+}
+
+// ShotHole is a hole on a player's GPS scorecard
+type ShotHole struct {
+	HoleNumber int
+	Par        int // Zero when the course's card doesn't list the hole
+	Strokes    int
+	Remaining  *int // Yards from the last shot to the green centre, when it's mapped
+	Shots      []TrackedShot
+}
+
+// ShotScorecard is a player's tracked shots for a round, hole by hole, with
+// the score they posted for that day if there is one
+type ShotScorecard struct {
+	CourseID   uint
+	DatePlayed string
+	ScoreID    *uint
+	Strokes    int
+	Holes      []ShotHole
+}
+
+// ShotTrackingService records the shots a player marks by GPS during a round
+type ShotTrackingService struct {
+	db *gorm.DB
+}
+
+func NewShotTrackingService() *ShotTrackingService {
+	return &ShotTrackingService{
+		db: GetDB(),
+	}
+}
+
+// RecordShot adds a shot at the player's position as the next stroke on the
+// hole, finding the hole from the course's hole geometry when it isn't given
+func (s *ShotTrackingService) RecordShot(userID, courseID uint, input ShotInput) (*RoundShot, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	geometry := &CourseHoleGeometryService{db: s.db}
+	if _, err := geometry.course(courseID); err != nil {
+		return nil, err
+	}
+
+	date, err := normalizeShotDate(input.DatePlayed)
+	if err != nil {
+		return nil, err
+	}
+	position := input.Position
+	if position.Latitude < -90 || position.Latitude > 90 || position.Longitude < -180 || position.Longitude > 180 {
+		return nil, fmt.Errorf("%w: latitude must be between -90 and 90 and longitude between -180 and 180", ErrInvalidShot)
+	}
+	club := strings.TrimSpace(input.Club)
+	if utf8.RuneCountInString(club) > maxShotClubLength {
+		return nil, fmt.Errorf("%w: clubs can be at most %d characters", ErrInvalidShot, maxShotClubLength)
+	}
+
+	holeNumber := input.HoleNumber
+	if holeNumber == 0 {
+		holes, err := geometry.GetLayout(courseID)
+		if err != nil {
+			return nil, err
+		}
+		index, ok := detectHole(holes, position)
+		if !ok {
+			return nil, ErrHoleNotDetected
+		}
+		holeNumber = holes[index].HoleNumber
+	}
+	if holeNumber < 1 || holeNumber > MaxHoleNumber {
+		return nil, fmt.Errorf("%w: hole numbers run from 1 to %d", ErrInvalidShot, MaxHoleNumber)
+	}
+
+	shot := RoundShot{
+		UserID:     userID,
+		CourseID:   courseID,
+		DatePlayed: date,
+		HoleNumber: holeNumber,
+		Latitude:   position.Latitude,
+		Longitude:  position.Longitude,
+	}
+	if club != "" {
+		shot.Club = &club
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var last int
+		if err := tx.Model(&RoundShot{}).
+			Where("user_id = ? AND course_id = ? AND date_played = ? AND hole_number = ?", userID, courseID, date, holeNumber).
+			Select("COALESCE(MAX(shot_number), 0)").Scan(&last).Error; err != nil {
+			return fmt.Errorf("failed to count shots: %v", err)
+		}
+		if last >= maxShotsPerHole {
+			return fmt.Errorf("%w: a hole can have at most %d shots", ErrShotLimitReached, maxShotsPerHole)
+		}
+
+		shot.ShotNumber = last + 1
+		if err := tx.Create(&shot).Error; err != nil {
+			return fmt.Errorf("failed to save shot: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &shot, nil
+}
+
+// GetScorecard returns a player's tracked shots for their round on a date
+func (s *ShotTrackingService) GetScorecard(userID, courseID uint, datePlayed string) (*ShotScorecard, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	date, err := normalizeShotDate(datePlayed)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := (&CourseHoleGeometryService{db: s.db}).GetLayout(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var shots []RoundShot
+	if err := s.db.Where("user_id = ? AND course_id = ? AND date_played = ?", userID, courseID, date).
+		Order("hole_number, shot_number").Find(&shots).Error; err != nil {
+		return nil, fmt.Errorf("failed to get shots: %v", err)
+	}
+
+	var card []CourseHole
+	if err := s.db.Where("course_id = ?", courseID).Find(&card).Error; err != nil {
+		return nil, fmt.Errorf("failed to get course holes: %v", err)
+	}
+	pars := make(map[int]int, len(card))
+	for _, hole := range card {
+		pars[hole.HoleNumber] = safeIntValue(hole.Par)
+	}
+	greens := make(map[int]GeoPoint, len(layout))
+	for _, hole := range layout {
+		if hole.Geometry.GreenCenter != nil {
+			greens[hole.HoleNumber] = *hole.Geometry.GreenCenter
+		}
+	}
+
+	scorecard := &ShotScorecard{CourseID: courseID, DatePlayed: date, Holes: []ShotHole{}}
+	for i, shot := range shots {
+		if len(scorecard.Holes) == 0 || scorecard.Holes[len(scorecard.Holes)-1].HoleNumber != shot.HoleNumber {
+			scorecard.Holes = append(scorecard.Holes, ShotHole{HoleNumber: shot.HoleNumber, Par: pars[shot.HoleNumber]})
+		}
+		hole := &scorecard.Holes[len(scorecard.Holes)-1]
+		position := GeoPoint{Latitude: shot.Latitude, Longitude: shot.Longitude}
+
+		tracked := TrackedShot{RoundShot: shot}
+		if i+1 < len(shots) && shots[i+1].HoleNumber == shot.HoleNumber {
+			distance := metersToYards(geodesicMeters(position, GeoPoint{Latitude: shots[i+1].Latitude, Longitude: shots[i+1].Longitude}))
+			tracked.Distance = &distance
+		} else if green, ok := greens[shot.HoleNumber]; ok {
+			remaining := metersToYards(geodesicMeters(position, green))
+			hole.Remaining = &remaining
+		}
+		hole.Shots = append(hole.Shots, tracked)
+		hole.Strokes++
+		scorecard.Strokes++
+	}
+
+	var score UserCourseScore
+	err = s.db.Where("user_id = ? AND course_id = ? AND date_played = ?", userID, courseID, date).
+		Order("created_at DESC").First(&score).Error
+	switch {
+	case err == nil:
+		scorecard.ScoreID = &score.ID
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("failed to get score: %v", err)
+	}
+	return scorecard, nil
+}
+
+// DeleteShot removes one of a player's shots and renumbers the shots after it
+// on the same hole
+func (s *ShotTrackingService) DeleteShot(userID, courseID, shotID uint) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var shot RoundShot
+		if err := tx.Where("id = ? AND user_id = ? AND course_id = ?", shotID, userID, courseID).First(&shot).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShotNotFound
+			}
+			return fmt.Errorf("failed to get shot: %v", err)
+		}
+
+		if err := tx.Delete(&shot).Error; err != nil {
+			return fmt.Errorf("failed to delete shot: %v", err)
+		}
+		if err := tx.Model(&RoundShot{}).
+			Where("user_id = ? AND course_id = ? AND date_played = ? AND hole_number = ? AND shot_number > ?",
+				userID, courseID, shot.DatePlayed, shot.HoleNumber, shot.ShotNumber).
+			Update("shot_number", gorm.Expr("shot_number - 1")).Error; err != nil {
+			return fmt.Errorf("failed to renumber shots: %v", err)
+		}
+		return nil
+	})
+}
+
+// normalizeShotDate checks a round's date, defaulting to today
+func normalizeShotDate(date string) (string, error) {
+	date = strings.TrimSpace(date)
+	if date == "" {
+		return time.Now().Format(shotDateLayout), nil
+	}
+
+	played, err := time.Parse(shotDateLayout, date)
+	if err != nil {
+		return "", fmt.Errorf("%w: dates must be YYYY-MM-DD", ErrInvalidShot)
+	}
+	if played.After(time.Now().AddDate(0, 0, maxShotDaysInAdvance)) {
+		return "", fmt.Errorf("%w: rounds can't be in the future", ErrInvalidShot)
+	}
+	return date, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShotTrackingService(t *testing.T) {
+	db := setupTestDatabase(t)
+	owner := seedCourseOwner(t, db)
+	reviewer := seedUser(t, db, "reviewer", "Reviewer")
+	golfer := seedUser(t, db, "golfer", "Golfer")
+	ids := seedGeoCourses(t, db)
+	courseID := ids["Pinehurst No. 2"]
+	require.NoError(t, db.Create(&CourseHole{CourseID: courseID, HoleNumber: 1, Par: intPtr(4), Yardage: intPtr(401)}).Error)
+	_, err := NewCourseHoleGeometryService().SaveHole(courseID, 1, pinehurstFirst(), owner.ID)
+	require.NoError(t, err)
+	service := NewShotTrackingService()
+	date := "2026-04-10"
+
+	// The hole is found from the first two positions; the third names it
+	positions := []GeoPoint{
+		{Latitude: 35.1900, Longitude: -79.4720},
+		{Latitude: 35.1918, Longitude: -79.4708},
+		{Latitude: 35.1929, Longitude: -79.4701},
+	}
+	for i, position := range positions {
+		input := ShotInput{DatePlayed: date, Position: position, Club: " Driver "}
+		if i == 2 {
+			input.HoleNumber, input.Club = 1, ""
+		}
+		shot, err := service.RecordShot(golfer.ID, courseID, input)
+		require.NoError(t, err)
+		assert.Equal(t, 1, shot.HoleNumber)
+		assert.Equal(t, i+1, shot.ShotNumber)
+	}
+	_, err = service.RecordShot(golfer.ID, courseID, ShotInput{DatePlayed: date, HoleNumber: 2, Position: positions[0]})
+	require.NoError(t, err, "holes don't have to be mapped when they're named")
+
+	score := UserCourseScore{CourseID: courseID, UserID: golfer.ID, Score: 88, DatePlayed: &date}
+	require.NoError(t, db.Create(&score).Error)
+
+	scorecard, err := service.GetScorecard(golfer.ID, courseID, date)
+	require.NoError(t, err)
+	assert.Equal(t, &score.ID, scorecard.ScoreID)
+	assert.Equal(t, 4, scorecard.Strokes)
+	require.Len(t, scorecard.Holes, 2)
+
+	first := scorecard.Holes[0]
+	assert.Equal(t, 4, first.Par)
+	assert.Equal(t, 3, first.Strokes)
+	assert.Equal(t, "Driver", *first.Shots[0].Club)
+	require.NotNil(t, first.Shots[0].Distance)
+	assert.Equal(t, metersToYards(geodesicMeters(positions[0], positions[1])), *first.Shots[0].Distance)
+	assert.Nil(t, first.Shots[2].Distance)
+	require.NotNil(t, first.Remaining)
+	assert.InDelta(t, 15, *first.Remaining, 5, "from the last shot to the green centre")
+	assert.Nil(t, scorecard.Holes[1].Remaining, "hole 2 isn't mapped")
+
+	// Other players and days are separate rounds
+	other, err := service.GetScorecard(reviewer.ID, courseID, date)
+	require.NoError(t, err)
+	assert.Empty(t, other.Holes)
+	assert.Nil(t, other.ScoreID)
+
+	// Deleting a shot renumbers the rest of the hole
+	require.NoError(t, service.DeleteShot(golfer.ID, courseID, first.Shots[0].ID))
+	scorecard, err = service.GetScorecard(golfer.ID, courseID, date)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, []int{scorecard.Holes[0].Shots[0].ShotNumber, scorecard.Holes[0].Shots[1].ShotNumber})
+	assert.ErrorIs(t, service.DeleteShot(golfer.ID, courseID, first.Shots[0].ID), ErrShotNotFound)
+	assert.ErrorIs(t, service.DeleteShot(reviewer.ID, courseID, first.Shots[1].ID), ErrShotNotFound, "another player's shot")
+
+	// Today is the default round
+	shot, err := service.RecordShot(golfer.ID, courseID, ShotInput{Position: positions[0]})
+	require.NoError(t, err)
+	assert.Equal(t, time.Now().Format("2006-01-02"), shot.DatePlayed)
+
+	_, err = service.RecordShot(golfer.ID, courseID, ShotInput{Position: GeoPoint{Latitude: 35.2100, Longitude: -79.4700}})
+	assert.ErrorIs(t, err, ErrHoleNotDetected)
+	_, err = service.RecordShot(golfer.ID, courseID, ShotInput{DatePlayed: "10/04/2026", Position: positions[0]})
+	assert.ErrorIs(t, err, ErrInvalidShot)
+	_, err = service.RecordShot(golfer.ID, courseID, ShotInput{DatePlayed: time.Now().AddDate(0, 0, 3).Format("2006-01-02"), Position: positions[0]})
+	assert.ErrorIs(t, err, ErrInvalidShot)
+	_, err = service.RecordShot(golfer.ID, courseID, ShotInput{HoleNumber: 19, Position: positions[0]})
+	assert.ErrorIs(t, err, ErrInvalidShot)
+	_, err = service.RecordShot(golfer.ID, 9999, ShotInput{Position: positions[0]})
+	assert.ErrorIs(t, err, ErrCourseNotFound)
+
+	for i := 0; i < maxShotsPerHole; i++ {
+		_, err = service.RecordShot(reviewer.ID, courseID, ShotInput{DatePlayed: date, HoleNumber: 3, Position: positions[0]})
+		require.NoError(t, err)
+	}
+	_, err = service.RecordShot(reviewer.ID, courseID, ShotInput{DatePlayed: date, HoleNumber: 3, Position: positions[0]})
+	assert.ErrorIs(t, err, ErrShotLimitReached)
+}
@@ -23,6 +23,7 @@ type serviceContainer struct {
 	authService   AuthService
 	sessionService SessionService
 	reviewService  ReviewService
+	geocodingService GeocodingService
 	
 	// Mutex for thread-safe initialization
 	mu sync.RWMutex
@@ -30,8 +31,9 @@ type serviceContainer struct {
 
 func NewServiceContainer(db *gorm.DB, config ServiceConfig) ServiceContainer {
 	return &serviceContainer{
-		db:     db,
-		config: config,
+		db:               db,
+		config:           config,
+		geocodingService: NewGeocodingServiceFromConfig(db, config.Geocoding),
 	}
 }
 
@@ -51,11 +53,16 @@ func (c *serviceContainer) CourseService() CourseService {
 		return c.courseService
 	}
 
-	c.courseService = NewCourseService(c.CourseRepository(), c.UserRepository())
+	c.courseService = NewCourseServiceWithGeocoding(c.CourseRepository(), c.UserRepository(), c.geocodingService)
 	log.Printf("✅ CourseService initialized")
 	return c.courseService
 }
 
+// GeocodingService is built with the container, since course services need it
+func (c *serviceContainer) GeocodingService() GeocodingService {
+	return c.geocodingService
+}
+
 func (c *serviceContainer) AuthService() AuthService {
 	c.mu.RLock()
 	if c.authService != nil {
@@ -235,6 +242,7 @@ func CreateServiceConfig() ServiceConfig {
 		},
 		DatabaseURL: getEnv("DATABASE_URL", ""),
 		RedisURL:    getEnv("REDIS_URL", ""),
+		Geocoding:   LoadGeocodingConfig(),
 	}
 }
 
@@ -3,20 +3,35 @@ package services
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// courseGeocodeTimeout bounds a background geocode, including any wait
+// under the provider's rate limit
+const courseGeocodeTimeout = 2 * time.Minute
+
 type courseService struct {
 	courseRepo CourseRepository
 	userRepo   UserRepository
+	geocoding  GeocodingService
 }
 
 func NewCourseService(courseRepo CourseRepository, userRepo UserRepository) CourseService {
+	return NewCourseServiceWithGeocoding(courseRepo, userRepo, nil)
+}
+
+// NewCourseServiceWithGeocoding creates a course service that geocodes
+// courses in the background when they're created or their address changes.
+// A nil geocoding service turns that off.
+func NewCourseServiceWithGeocoding(courseRepo CourseRepository, userRepo UserRepository, geocoding GeocodingService) CourseService {
 	return &courseService{
 		courseRepo: courseRepo,
 		userRepo:   userRepo,
+		geocoding:  geocoding,
 	}
 }
 
@@ -40,6 +55,15 @@ func (s *courseService) CreateCourse(ctx context.Context, course Course, created
 		return fmt.Errorf("failed to create course: %w", err)
 	}
 
+	if s.geocoding != nil && course.Latitude == nil && course.Longitude == nil {
+		created, err := s.courseRepo.GetByNameAndAddress(ctx, course.Name, course.Address)
+		if err != nil {
+			log.Printf("[GEOCODE] Failed to find new course %q to geocode: %v", course.Name, err)
+		} else {
+			go s.geocodeCourse(created.ID, course.Address)
+		}
+	}
+
 	return nil
 }
 
@@ -87,14 +111,62 @@ func (s *courseService) UpdateCourse(ctx context.Context, course Course, updated
 		}
 	}
 
+	// Look up the current address to tell whether the update moves the course
+	var previous *Course
+	if s.geocoding != nil {
+		existing, err := s.courseRepo.GetByID(ctx, course.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get course: %w", err)
+		}
+		previous = existing
+	}
+
 	// Update the course
 	if err := s.courseRepo.Update(ctx, course, updatedBy); err != nil {
 		return fmt.Errorf("failed to update course: %w", err)
 	}
 
+	if previous != nil && normalizeAddress(previous.Address) != normalizeAddress(course.Address) &&
+		sameCoordinates(previous.Latitude, course.Latitude) && sameCoordinates(previous.Longitude, course.Longitude) {
+		go s.geocodeCourse(course.ID, course.Address)
+	}
+
 	return nil
 }
 
+// geocodeCourse locates a course from its address. It runs in the
+// background, so failures are logged rather than returned; the course keeps
+// its old coordinates until a geocode succeeds.
+func (s *courseService) geocodeCourse(courseID uint, address string) {
+	if strings.TrimSpace(address) == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), courseGeocodeTimeout)
+	defer cancel()
+
+	result, err := s.geocoding.Geocode(ctx, address)
+	if err != nil {
+		log.Printf("[GEOCODE] Failed to geocode course %d: %v", courseID, err)
+		return
+	}
+	if err := s.courseRepo.UpdateLocation(ctx, courseID, address, *result); err != nil {
+		log.Printf("[GEOCODE] Failed to save location for course %d: %v", courseID, err)
+		return
+	}
+	log.Printf("[GEOCODE] Located course %d at %.6f, %.6f", courseID, result.Latitude, result.Longitude)
+}
+
+// sameCoordinates reports whether an update left a coordinate alone, either
+// by omitting it or by passing the stored value back. A caller that sets new
+// coordinates along with the address has placed the course itself.
+func sameCoordinates(previous, updated *float64) bool {
+	if updated == nil {
+		return true
+	}
+	return previous != nil && *previous == *updated
+}
+
 func (s *courseService) DeleteCourse(ctx context.Context, id uint, userID uint) error {
 	// Check if user can edit this course
 	canEdit, err := s.courseRepo.CanEdit(ctx, id, userID)
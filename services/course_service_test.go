@@ -125,6 +125,11 @@ func (m *MockCourseRepository) GetAvailableForReview(ctx context.Context, userID
 	return args.Get(0).([]Course), args.Error(1)
 }
 
+func (m *MockCourseRepository) UpdateLocation(ctx context.Context, id uint, address string, result GeocodeResult) error {
+	args := m.Called(ctx, id, address, result)
+	return args.Error(0)
+}
+
 // CourseServiceTestSuite provides a test suite for course service tests
 type CourseServiceTestSuite struct {
 	suite.Suite
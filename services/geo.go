@@ -0,0 +1,16 @@
+package services
+
+import "math"
+
+// EarthRadiusKm is the mean radius of the Earth
+const EarthRadiusKm = 6371.0088
+
+// HaversineKm is the great-circle distance between two points
+func HaversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := math.Pi / 180
+	dLat := (lat2 - lat1) * toRadians
+	dLng := (lng2 - lng1) * toRadians
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRadians)*math.Cos(lat2*toRadians)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrGeocodeNotFound is returned when a geocoder has no match
+var ErrGeocodeNotFound = errors.New("no geocoding result found")
+
+// geocodeMissTTL is how long a lookup with no match is remembered before the
+// provider is asked again; matches are kept until the cache is cleared
+const geocodeMissTTL = 7 * 24 * time.Hour
+
+// GeocodeResult is a geocoded address
+type GeocodeResult struct {
+	Address          string            `json:"address"`
+	FormattedAddress string            `json:"formatted_address"`
+	Latitude         float64           `json:"latitude"`
+	Longitude        float64           `json:"longitude"`
+	Confidence       float64           `json:"confidence"` // 0.0 to 1.0
+	Components       AddressComponents `json:"components"`
+}
+
+// AddressComponents are the parts of an address. State is a two-letter code
+// for US addresses.
+type AddressComponents struct {
+	StreetNumber string `json:"street_number,omitempty"`
+	Route        string `json:"route,omitempty"`
+	City         string `json:"city,omitempty"`
+	State        string `json:"state,omitempty"`
+	Country      string `json:"country,omitempty"`
+	PostalCode   string `json:"postal_code,omitempty"`
+}
+
+// GeocodingConfig selects and configures a geocoding provider
+type GeocodingConfig struct {
+	Provider          string // mapbox, nominatim or fake; empty picks Mapbox when a token is set
+	MapboxToken       string
+	NominatimURL      string
+	UserAgent         string  // Nominatim requires one that identifies the app
+	FixturesPath      string  // JSON results for the fake provider
+	RequestsPerSecond float64 // 0 uses the provider's default
+}
+
+// LoadGeocodingConfig reads the geocoding configuration from the environment
+func LoadGeocodingConfig() GeocodingConfig {
+	config := GeocodingConfig{
+		Provider:     strings.ToLower(os.Getenv("GEOCODING_PROVIDER")),
+		MapboxToken:  os.Getenv("MAPBOX_ACCESS_TOKEN"),
+		NominatimURL: os.Getenv("NOMINATIM_URL"),
+		UserAgent:    os.Getenv("GEOCODING_USER_AGENT"),
+		FixturesPath: os.Getenv("GEOCODING_FIXTURES"),
+	}
+	if value := os.Getenv("GEOCODING_REQUESTS_PER_SECOND"); value != "" {
+		if rps, err := strconv.ParseFloat(value, 64); err == nil && rps > 0 {
+			config.RequestsPerSecond = rps
+		}
+	}
+	return config
+}
+
+// NewGeocodingProvider builds the configured provider. It returns nil, nil
+// when geocoding isn't configured.
+func NewGeocodingProvider(config GeocodingConfig) (GeocodingProvider, error) {
+	provider := config.Provider
+	if provider == "" && config.MapboxToken != "" {
+		provider = "mapbox"
+	}
+
+	switch provider {
+	case "":
+		return nil, nil
+	case "mapbox":
+		if config.MapboxToken == "" {
+			return nil, fmt.Errorf("the mapbox geocoder needs MAPBOX_ACCESS_TOKEN")
+		}
+		return NewMapboxGeocoder(config.MapboxToken, ""), nil
+	case "nominatim":
+		return NewNominatimGeocoder(config.NominatimURL, config.UserAgent), nil
+	case "fake":
+		fixtures, err := LoadGeocodingFixtures(config.FixturesPath)
+		if err != nil {
+			return nil, err
+		}
+		return NewFakeGeocoder(fixtures), nil
+	default:
+		return nil, fmt.Errorf("unknown geocoding provider %q", config.Provider)
+	}
+}
+
+// NewGeocodingServiceFromConfig builds the configured geocoding service, or
+// returns nil when geocoding isn't configured or the configuration is invalid
+func NewGeocodingServiceFromConfig(db *gorm.DB, config GeocodingConfig) GeocodingService {
+	provider, err := NewGeocodingProvider(config)
+	if err != nil {
+		log.Printf("[GEOCODE] Geocoding disabled: %v", err)
+		return nil
+	}
+	if provider == nil {
+		log.Printf("[GEOCODE] Geocoding disabled; set MAPBOX_ACCESS_TOKEN or GEOCODING_PROVIDER to enable it")
+		return nil
+	}
+	log.Printf("[GEOCODE] Geocoding with %s", provider.Name())
+	return NewGeocodingService(db, provider, config.RequestsPerSecond)
+}
+
+// GeocodeCacheDB is a remembered geocoding lookup
+type GeocodeCacheDB struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Query     string `gorm:"size:300;not null;uniqueIndex" json:"query"`
+	Provider  string `gorm:"size:20;not null" json:"provider"`
+	Found     bool   `gorm:"not null" json:"found"`
+	Result    string `gorm:"type:text" json:"result"` // GeocodeResult JSON when found
+	ExpiresAt *int64 `json:"expires_at"`              // nil for matches, which don't expire
+	CreatedAt int64  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (GeocodeCacheDB) TableName() string {
+	return "geocode_cache"
+}
+
+type geocodingService struct {
+	db       *gorm.DB
+	provider GeocodingProvider
+	limiter  *rate.Limiter
+}
+
+// NewGeocodingService wraps a provider with the geocode_cache table and a rate
+// limit. requestsPerSecond of 0 uses the provider's default.
+func NewGeocodingService(db *gorm.DB, provider GeocodingProvider, requestsPerSecond float64) GeocodingService {
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = defaultRequestsPerSecond(provider)
+	}
+	return &geocodingService{
+		db:       db,
+		provider: provider,
+		limiter:  rate.NewLimiter(rate.Limit(requestsPerSecond), 1),
+	}
+}
+
+func defaultRequestsPerSecond(provider GeocodingProvider) float64 {
+	switch provider.Name() {
+	case "nominatim":
+		return 1 // The public Nominatim usage policy allows one request a second
+	case "mapbox":
+		return 10
+	default:
+		return 100
+	}
+}
+
+func (s *geocodingService) Geocode(ctx context.Context, address string) (*GeocodeResult, error) {
+	query := normalizeAddress(address)
+	if query == "" {
+		return nil, ErrGeocodeNotFound
+	}
+	return s.lookup(ctx, "forward:"+query, func() (*GeocodeResult, error) {
+		return s.provider.Geocode(ctx, address)
+	})
+}
+
+func (s *geocodingService) ReverseGeocode(ctx context.Context, lat, lng float64) (*GeocodeResult, error) {
+	// Five decimal places is about a meter, closer than any address
+	key := fmt.Sprintf("reverse:%.5f,%.5f", lat, lng)
+	return s.lookup(ctx, key, func() (*GeocodeResult, error) {
+		return s.provider.ReverseGeocode(ctx, lat, lng)
+	})
+}
+
+// lookup answers from the cache when it can, and otherwise waits its turn
+// under the rate limit to ask the provider
+func (s *geocodingService) lookup(ctx context.Context, key string, fetch func() (*GeocodeResult, error)) (*GeocodeResult, error) {
+	if result, found, ok := s.cached(key); ok {
+		if !found {
+			return nil, ErrGeocodeNotFound
+		}
+		return result, nil
+	}
+
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("geocoding rate limit wait failed: %w", err)
+	}
+
+	result, err := fetch()
+	if errors.Is(err, ErrGeocodeNotFound) {
+		s.remember(key, nil)
+		return nil, ErrGeocodeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s geocoding failed: %w", s.provider.Name(), err)
+	}
+	s.remember(key, result)
+	return result, nil
+}
+
+func (s *geocodingService) cached(key string) (*GeocodeResult, bool, bool) {
+	if s.db == nil {
+		return nil, false, false
+	}
+
+	var entry GeocodeCacheDB
+	err := s.db.Where("query = ?", key).Limit(1).Find(&entry).Error
+	if err != nil || entry.ID == 0 {
+		return nil, false, false
+	}
+	if entry.ExpiresAt != nil && *entry.ExpiresAt < time.Now().Unix() {
+		return nil, false, false
+	}
+	if !entry.Found {
+		return nil, false, true
+	}
+
+	var result GeocodeResult
+	if err := json.Unmarshal([]byte(entry.Result), &result); err != nil {
+		return nil, false, false
+	}
+	return &result, true, true
+}
+
+// remember caches a lookup; a nil result records that nothing matched
+func (s *geocodingService) remember(key string, result *GeocodeResult) {
+	if s.db == nil {
+		return
+	}
+
+	entry := GeocodeCacheDB{Query: key, Provider: s.provider.Name(), Found: result != nil}
+	if result != nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return
+		}
+		entry.Result = string(data)
+	} else {
+		expires := time.Now().Add(geocodeMissTTL).Unix()
+		entry.ExpiresAt = &expires
+	}
+
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "query"}},
+		DoUpdates: clause.AssignmentColumns([]string{"provider", "found", "result", "expires_at", "created_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		log.Printf("[GEOCODE] Failed to cache %s: %v", key, err)
+	}
+}
+
+// normalizeAddress lowercases an address and collapses its spacing so the
+// same address typed differently shares a cache entry
+func normalizeAddress(address string) string {
+	return strings.ToLower(strings.Join(strings.Fields(address), " "))
+}
+
+var (
+	stateZipPattern = regexp.MustCompile(`^(.*?)\s*(\d{5}(?:-\d{4})?)?$`)
+	streetPattern   = regexp.MustCompile(`^(\d+[A-Za-z]?)\s+(.+)$`)
+)
+
+// ParseAddress splits a US-style address such as
+// "1 Carolina Vista Dr, Pinehurst, NC 28374, USA" into its parts. Parts it
+// can't find are left empty.
+func ParseAddress(address string) AddressComponents {
+	var parts []string
+	for _, part := range strings.Split(address, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	var components AddressComponents
+	if len(parts) > 0 && isUnitedStates(parts[len(parts)-1]) {
+		components.Country = "US"
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) == 0 {
+		return components
+	}
+
+	// The last part holds the state and ZIP code
+	if match := stateZipPattern.FindStringSubmatch(parts[len(parts)-1]); match != nil {
+		if code, ok := stateCode(match[1]); ok {
+			components.State = code
+			components.PostalCode = match[2]
+			components.Country = "US"
+			parts = parts[:len(parts)-1]
+		} else if match[1] == "" && match[2] != "" {
+			components.PostalCode = match[2]
+			parts = parts[:len(parts)-1]
+		}
+	}
+	if components.State == "" && components.PostalCode == "" {
+		return components
+	}
+
+	if len(parts) > 0 {
+		components.City = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) > 0 {
+		street := parts[0]
+		if match := streetPattern.FindStringSubmatch(street); match != nil {
+			components.StreetNumber = match[1]
+			components.Route = match[2]
+		} else {
+			components.Route = street
+		}
+	}
+	return components
+}
+
+func isUnitedStates(part string) bool {
+	switch strings.ToLower(strings.ReplaceAll(part, ".", "")) {
+	case "us", "usa", "united states", "united states of america":
+		return true
+	}
+	return false
+}
+
+// stateCode returns the two-letter code for a US state or territory, given
+// either its code or its name
+func stateCode(state string) (string, bool) {
+	state = strings.TrimSpace(state)
+	if len(state) == 2 {
+		code := strings.ToUpper(state)
+		for _, known := range usStates {
+			if known == code {
+				return code, true
+			}
+		}
+		return "", false
+	}
+	code, ok := usStates[strings.ToLower(state)]
+	return code, ok
+}
+
+var usStates = map[string]string{
+	"alabama": "AL", "alaska": "AK", "arizona": "AZ", "arkansas": "AR", "california": "CA",
+	"colorado": "CO", "connecticut": "CT", "delaware": "DE", "district of columbia": "DC",
+	"florida": "FL", "georgia": "GA", "hawaii": "HI", "idaho": "ID", "illinois": "IL",
+	"indiana": "IN", "iowa": "IA", "kansas": "KS", "kentucky": "KY", "louisiana": "LA",
+	"maine": "ME", "maryland": "MD", "massachusetts": "MA", "michigan": "MI", "minnesota": "MN",
+	"mississippi": "MS", "missouri": "MO", "montana": "MT", "nebraska": "NE", "nevada": "NV",
+	"new hampshire": "NH", "new jersey": "NJ", "new mexico": "NM", "new york": "NY",
+	"north carolina": "NC", "north dakota": "ND", "ohio": "OH", "oklahoma": "OK", "oregon": "OR",
+	"pennsylvania": "PA", "rhode island": "RI", "south carolina": "SC", "south dakota": "SD",
+	"tennessee": "TN", "texas": "TX", "utah": "UT", "vermont": "VT", "virginia": "VA",
+	"washington": "WA", "west virginia": "WV", "wisconsin": "WI", "wyoming": "WY",
+	"puerto rico": "PR", "guam": "GU", "us virgin islands": "VI",
+}
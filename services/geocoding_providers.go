@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMapboxURL    = "https://api.mapbox.com"
+	defaultNominatimURL = "https://nominatim.openstreetmap.org"
+	defaultUserAgent    = "course_management geocoder"
+	geocoderTimeout     = 10 * time.Second
+	// fakeReverseRadiusKm is how close a point must be to a fixture for the
+	// fake geocoder to reverse geocode it
+	fakeReverseRadiusKm = 1.0
+)
+
+// mapboxGeocoder uses the Mapbox Geocoding API
+type mapboxGeocoder struct {
+	token   string
+	baseURL string
+	client  *http.Client
+}
+
+// NewMapboxGeocoder creates a Mapbox provider. baseURL is only set in tests.
+func NewMapboxGeocoder(token, baseURL string) GeocodingProvider {
+	if baseURL == "" {
+		baseURL = defaultMapboxURL
+	}
+	return &mapboxGeocoder{token: token, baseURL: strings.TrimSuffix(baseURL, "/"), client: &http.Client{Timeout: geocoderTimeout}}
+}
+
+type mapboxResponse struct {
+	Features []mapboxFeature `json:"features"`
+}
+
+type mapboxFeature struct {
+	ID        string          `json:"id"`
+	Text      string          `json:"text"`
+	Address   string          `json:"address"`
+	PlaceName string          `json:"place_name"`
+	Relevance float64         `json:"relevance"`
+	Center    []float64       `json:"center"` // [longitude, latitude]
+	ShortCode string          `json:"short_code"`
+	Context   []mapboxFeature `json:"context"`
+}
+
+func (g *mapboxGeocoder) Name() string {
+	return "mapbox"
+}
+
+func (g *mapboxGeocoder) Geocode(ctx context.Context, address string) (*GeocodeResult, error) {
+	result, err := g.search(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	result.Address = address
+	return result, nil
+}
+
+func (g *mapboxGeocoder) ReverseGeocode(ctx context.Context, lat, lng float64) (*GeocodeResult, error) {
+	result, err := g.search(ctx, fmt.Sprintf("%f,%f", lng, lat))
+	if err != nil {
+		return nil, err
+	}
+	result.Address = result.FormattedAddress
+	return result, nil
+}
+
+func (g *mapboxGeocoder) search(ctx context.Context, query string) (*GeocodeResult, error) {
+	endpoint := fmt.Sprintf("%s/geocoding/v5/mapbox.places/%s.json?limit=1&access_token=%s",
+		g.baseURL, url.PathEscape(query), url.QueryEscape(g.token))
+
+	var response mapboxResponse
+	if err := getJSON(ctx, g.client, endpoint, "", &response); err != nil {
+		return nil, err
+	}
+	if len(response.Features) == 0 || len(response.Features[0].Center) < 2 {
+		return nil, ErrGeocodeNotFound
+	}
+
+	feature := response.Features[0]
+	result := &GeocodeResult{
+		FormattedAddress: feature.PlaceName,
+		Longitude:        feature.Center[0],
+		Latitude:         feature.Center[1],
+		Confidence:       feature.Relevance,
+	}
+
+	// The feature and its context each name one part of the address
+	for _, part := range append([]mapboxFeature{feature}, feature.Context...) {
+		switch strings.SplitN(part.ID, ".", 2)[0] {
+		case "address":
+			result.Components.StreetNumber = part.Address
+			result.Components.Route = part.Text
+		case "postcode":
+			result.Components.PostalCode = part.Text
+		case "place":
+			result.Components.City = part.Text
+		case "region":
+			result.Components.State = part.Text
+			if code, ok := strings.CutPrefix(part.ShortCode, "US-"); ok {
+				result.Components.State = code
+			}
+		case "country":
+			result.Components.Country = strings.ToUpper(part.ShortCode)
+		}
+	}
+	return result, nil
+}
+
+// nominatimGeocoder uses OpenStreetMap's Nominatim API
+type nominatimGeocoder struct {
+	baseURL   string
+	userAgent string
+	client    *http.Client
+}
+
+// NewNominatimGeocoder creates a Nominatim provider. An empty baseURL uses the
+// public server, whose usage policy needs a descriptive user agent.
+func NewNominatimGeocoder(baseURL, userAgent string) GeocodingProvider {
+	if baseURL == "" {
+		baseURL = defaultNominatimURL
+	}
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	return &nominatimGeocoder{baseURL: strings.TrimSuffix(baseURL, "/"), userAgent: userAgent, client: &http.Client{Timeout: geocoderTimeout}}
+}
+
+type nominatimPlace struct {
+	Lat         string            `json:"lat"`
+	Lon         string            `json:"lon"`
+	DisplayName string            `json:"display_name"`
+	Importance  float64           `json:"importance"`
+	Address     map[string]string `json:"address"`
+	Error       string            `json:"error"`
+}
+
+func (g *nominatimGeocoder) Name() string {
+	return "nominatim"
+}
+
+func (g *nominatimGeocoder) Geocode(ctx context.Context, address string) (*GeocodeResult, error) {
+	endpoint := fmt.Sprintf("%s/search?format=jsonv2&addressdetails=1&limit=1&q=%s", g.baseURL, url.QueryEscape(address))
+
+	var places []nominatimPlace
+	if err := getJSON(ctx, g.client, endpoint, g.userAgent, &places); err != nil {
+		return nil, err
+	}
+	if len(places) == 0 {
+		return nil, ErrGeocodeNotFound
+	}
+
+	result, err := places[0].toResult()
+	if err != nil {
+		return nil, err
+	}
+	result.Address = address
+	return result, nil
+}
+
+func (g *nominatimGeocoder) ReverseGeocode(ctx context.Context, lat, lng float64) (*GeocodeResult, error) {
+	endpoint := fmt.Sprintf("%s/reverse?format=jsonv2&addressdetails=1&lat=%f&lon=%f", g.baseURL, lat, lng)
+
+	var place nominatimPlace
+	if err := getJSON(ctx, g.client, endpoint, g.userAgent, &place); err != nil {
+		return nil, err
+	}
+	if place.Error != "" {
+		return nil, ErrGeocodeNotFound
+	}
+
+	result, err := place.toResult()
+	if err != nil {
+		return nil, err
+	}
+	result.Address = result.FormattedAddress
+	return result, nil
+}
+
+func (p nominatimPlace) toResult() (*GeocodeResult, error) {
+	lat, err := strconv.ParseFloat(p.Lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude %q", p.Lat)
+	}
+	lng, err := strconv.ParseFloat(p.Lon, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude %q", p.Lon)
+	}
+
+	result := &GeocodeResult{
+		FormattedAddress: p.DisplayName,
+		Latitude:         lat,
+		Longitude:        lng,
+		Confidence:       math.Min(1, p.Importance),
+		Components: AddressComponents{
+			StreetNumber: p.Address["house_number"],
+			Route:        p.Address["road"],
+			State:        p.Address["state"],
+			Country:      strings.ToUpper(p.Address["country_code"]),
+			PostalCode:   p.Address["postcode"],
+		},
+	}
+	for _, key := range []string{"city", "town", "village", "hamlet"} {
+		if city := p.Address[key]; city != "" {
+			result.Components.City = city
+			break
+		}
+	}
+	if code, ok := strings.CutPrefix(p.Address["ISO3166-2-lvl4"], "US-"); ok {
+		result.Components.State = code
+	}
+	return result, nil
+}
+
+// fakeGeocoder answers from fixtures, for tests and offline development
+type fakeGeocoder struct {
+	fixtures []GeocodeResult
+}
+
+// NewFakeGeocoder creates a provider that geocodes only the fixtures'
+// addresses and reverse geocodes points within a kilometer of them
+func NewFakeGeocoder(fixtures []GeocodeResult) GeocodingProvider {
+	return &fakeGeocoder{fixtures: fixtures}
+}
+
+// LoadGeocodingFixtures reads fake geocoder results from a JSON array
+func LoadGeocodingFixtures(path string) ([]GeocodeResult, error) {
+	if path == "" {
+		return nil, fmt.Errorf("the fake geocoder needs GEOCODING_FIXTURES")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read geocoding fixtures: %w", err)
+	}
+	var fixtures []GeocodeResult
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse geocoding fixtures: %w", err)
+	}
+	return fixtures, nil
+}
+
+func (g *fakeGeocoder) Name() string {
+	return "fake"
+}
+
+func (g *fakeGeocoder) Geocode(ctx context.Context, address string) (*GeocodeResult, error) {
+	query := normalizeAddress(address)
+	for _, fixture := range g.fixtures {
+		if normalizeAddress(fixture.Address) == query {
+			result := fixture
+			return &result, nil
+		}
+	}
+	return nil, ErrGeocodeNotFound
+}
+
+func (g *fakeGeocoder) ReverseGeocode(ctx context.Context, lat, lng float64) (*GeocodeResult, error) {
+	var nearest *GeocodeResult
+	nearestKm := fakeReverseRadiusKm
+	for i, fixture := range g.fixtures {
+		// An equirectangular distance is plenty within a kilometer
+		dLat := (fixture.Latitude - lat) * 111.32
+		dLng := (fixture.Longitude - lng) * 111.32 * math.Cos(lat*math.Pi/180)
+		if distance := math.Hypot(dLat, dLng); distance <= nearestKm {
+			nearest, nearestKm = &g.fixtures[i], distance
+		}
+	}
+	if nearest == nil {
+		return nil, ErrGeocodeNotFound
+	}
+	result := *nearest
+	return &result, nil
+}
+
+// getJSON fetches a URL and decodes its JSON body
+func getJSON(ctx context.Context, client *http.Client, endpoint, userAgent string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Drop the URL, which can carry an access token, from the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
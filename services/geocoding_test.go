@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	testingPkg "course_management/testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var pinehurst = GeocodeResult{
+	Address:          "1 Carolina Vista Dr, Pinehurst, NC 28374",
+	FormattedAddress: "1 Carolina Vista Drive, Pinehurst, North Carolina 28374, United States",
+	Latitude:         35.1907,
+	Longitude:        -79.4704,
+	Confidence:       1,
+	Components:       AddressComponents{StreetNumber: "1", Route: "Carolina Vista Drive", City: "Pinehurst", State: "NC", Country: "US", PostalCode: "28374"},
+}
+
+// countingGeocoder records how often a provider is asked
+type countingGeocoder struct {
+	GeocodingProvider
+	calls int
+}
+
+func (g *countingGeocoder) Geocode(ctx context.Context, address string) (*GeocodeResult, error) {
+	g.calls++
+	return g.GeocodingProvider.Geocode(ctx, address)
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected AddressComponents
+	}{
+		{"1 Carolina Vista Dr, Pinehurst, NC 28374", AddressComponents{StreetNumber: "1", Route: "Carolina Vista Dr", City: "Pinehurst", State: "NC", Country: "US", PostalCode: "28374"}},
+		{"1700 17 Mile Dr, Pebble Beach, California 93953-2536, USA", AddressComponents{StreetNumber: "1700", Route: "17 Mile Dr", City: "Pebble Beach", State: "CA", Country: "US", PostalCode: "93953-2536"}},
+		{"Bandon, or", AddressComponents{City: "Bandon", State: "OR", Country: "US"}},
+		{"Old Course, St Andrews KY16 9SF, Scotland", AddressComponents{}},
+		{"", AddressComponents{}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, ParseAddress(test.address), test.address)
+	}
+}
+
+func TestFakeGeocoder(t *testing.T) {
+	geocoder := NewFakeGeocoder([]GeocodeResult{pinehurst})
+	ctx := context.Background()
+
+	result, err := geocoder.Geocode(ctx, "  1 carolina vista dr,  Pinehurst, NC 28374")
+	require.NoError(t, err)
+	assert.Equal(t, 35.1907, result.Latitude)
+
+	_, err = geocoder.Geocode(ctx, "Somewhere else")
+	assert.ErrorIs(t, err, ErrGeocodeNotFound)
+
+	result, err = geocoder.ReverseGeocode(ctx, 35.19, -79.47)
+	require.NoError(t, err)
+	assert.Equal(t, "Pinehurst", result.Components.City)
+
+	_, err = geocoder.ReverseGeocode(ctx, 35.3, -79.47)
+	assert.ErrorIs(t, err, ErrGeocodeNotFound, "more than a kilometer from every fixture")
+}
+
+func TestMapboxGeocoder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.URL.Query().Get("access_token"))
+		if r.URL.Path == "/geocoding/v5/mapbox.places/nowhere.json" {
+			w.Write([]byte(`{"features":[]}`))
+			return
+		}
+		assert.Equal(t, "/geocoding/v5/mapbox.places/1 Carolina Vista Dr, Pinehurst, NC.json", r.URL.Path)
+		w.Write([]byte(`{"features":[{"id":"address.1","text":"Carolina Vista Drive","address":"1",
+			"place_name":"1 Carolina Vista Drive, Pinehurst, North Carolina 28374, United States","relevance":0.96,
+			"center":[-79.4704,35.1907],"context":[{"id":"postcode.2","text":"28374"},{"id":"place.3","text":"Pinehurst"},
+			{"id":"region.4","text":"North Carolina","short_code":"US-NC"},{"id":"country.5","text":"United States","short_code":"us"}]}]}`))
+	}))
+	defer server.Close()
+
+	geocoder := NewMapboxGeocoder("secret", server.URL)
+	result, err := geocoder.Geocode(context.Background(), "1 Carolina Vista Dr, Pinehurst, NC")
+	require.NoError(t, err)
+	assert.Equal(t, 35.1907, result.Latitude)
+	assert.Equal(t, -79.4704, result.Longitude)
+	assert.Equal(t, 0.96, result.Confidence)
+	assert.Equal(t, AddressComponents{StreetNumber: "1", Route: "Carolina Vista Drive", City: "Pinehurst", State: "NC", Country: "US", PostalCode: "28374"}, result.Components)
+
+	_, err = geocoder.Geocode(context.Background(), "nowhere")
+	assert.ErrorIs(t, err, ErrGeocodeNotFound)
+
+	server.Close()
+	_, err = geocoder.Geocode(context.Background(), "nowhere")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret", "errors never carry the access token")
+}
+
+func TestNominatimGeocoder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "course-tests", r.Header.Get("User-Agent"))
+		switch r.URL.Path {
+		case "/search":
+			w.Write([]byte(`[{"lat":"36.5686","lon":"-121.9496","display_name":"Pebble Beach Golf Links","importance":0.71,
+				"address":{"road":"17 Mile Drive","village":"Pebble Beach","state":"California","ISO3166-2-lvl4":"US-CA","postcode":"93953","country_code":"us"}}]`))
+		case "/reverse":
+			w.Write([]byte(`{"error":"Unable to geocode"}`))
+		}
+	}))
+	defer server.Close()
+
+	geocoder := NewNominatimGeocoder(server.URL, "course-tests")
+	result, err := geocoder.Geocode(context.Background(), "Pebble Beach Golf Links")
+	require.NoError(t, err)
+	assert.Equal(t, 36.5686, result.Latitude)
+	assert.Equal(t, AddressComponents{Route: "17 Mile Drive", City: "Pebble Beach", State: "CA", Country: "US", PostalCode: "93953"}, result.Components)
+
+	_, err = geocoder.ReverseGeocode(context.Background(), 0, 0)
+	assert.ErrorIs(t, err, ErrGeocodeNotFound)
+}
+
+func TestGeocodingService_CachesResults(t *testing.T) {
+	testDB := testingPkg.NewTestDB(t)
+	defer testDB.Close()
+	require.NoError(t, testDB.DB.AutoMigrate(&GeocodeCacheDB{}))
+
+	provider := &countingGeocoder{GeocodingProvider: NewFakeGeocoder([]GeocodeResult{pinehurst})}
+	service := NewGeocodingService(testDB.DB, provider, 0)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, err := service.Geocode(ctx, "1 Carolina Vista Dr,  PINEHURST, NC 28374")
+		require.NoError(t, err)
+		assert.Equal(t, "Pinehurst", result.Components.City)
+	}
+	assert.Equal(t, 1, provider.calls, "the second lookup is served from the cache")
+
+	for i := 0; i < 2; i++ {
+		_, err := service.Geocode(ctx, "Nowhere in particular")
+		assert.ErrorIs(t, err, ErrGeocodeNotFound)
+	}
+	assert.Equal(t, 2, provider.calls, "misses are cached too")
+
+	var entries int64
+	require.NoError(t, testDB.DB.Model(&GeocodeCacheDB{}).Count(&entries).Error)
+	assert.Equal(t, int64(2), entries)
+
+	expired := time.Now().Add(-time.Minute).Unix()
+	require.NoError(t, testDB.DB.Model(&GeocodeCacheDB{}).Where("found = ?", false).Update("expires_at", expired).Error)
+	_, err := service.Geocode(ctx, "Nowhere in particular")
+	assert.ErrorIs(t, err, ErrGeocodeNotFound)
+	assert.Equal(t, 3, provider.calls, "expired misses are looked up again")
+}
+
+func TestGeocodingService_RateLimits(t *testing.T) {
+	provider := &countingGeocoder{GeocodingProvider: NewFakeGeocoder([]GeocodeResult{pinehurst})}
+	service := NewGeocodingService(nil, provider, 1)
+
+	_, err := service.Geocode(context.Background(), pinehurst.Address)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = service.Geocode(ctx, pinehurst.Address)
+	assert.Error(t, err, "a second request within the second has to wait longer than the deadline")
+	assert.Equal(t, 1, provider.calls)
+}
+
+func TestNewGeocodingProvider(t *testing.T) {
+	provider, err := NewGeocodingProvider(GeocodingConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, provider, "off without configuration")
+
+	provider, err = NewGeocodingProvider(GeocodingConfig{MapboxToken: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, "mapbox", provider.Name())
+
+	provider, err = NewGeocodingProvider(GeocodingConfig{Provider: "nominatim"})
+	require.NoError(t, err)
+	assert.Equal(t, "nominatim", provider.Name())
+
+	_, err = NewGeocodingProvider(GeocodingConfig{Provider: "mapbox"})
+	assert.Error(t, err)
+	_, err = NewGeocodingProvider(GeocodingConfig{Provider: "carrier-pigeon"})
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"address":"1 Carolina Vista Dr, Pinehurst, NC 28374","latitude":35.1907,"longitude":-79.4704}]`), 0o600))
+	provider, err = NewGeocodingProvider(GeocodingConfig{Provider: "fake", FixturesPath: path})
+	require.NoError(t, err)
+	result, err := provider.Geocode(context.Background(), pinehurst.Address)
+	require.NoError(t, err)
+	assert.Equal(t, 35.1907, result.Latitude)
+}
+
+func TestCourseService_GeocodesInBackground(t *testing.T) {
+	ctx := context.Background()
+	geocoding := NewGeocodingService(nil, NewFakeGeocoder([]GeocodeResult{pinehurst}), 0)
+
+	t.Run("New courses", func(t *testing.T) {
+		repo := new(MockCourseRepository)
+		service := NewCourseServiceWithGeocoding(repo, new(MockUserRepository), geocoding)
+		course := Course{Name: "Pinehurst No. 2", Address: pinehurst.Address}
+
+		located := make(chan struct{})
+		repo.On("Exists", ctx, course.Name, course.Address).Return(false, nil)
+		repo.On("Create", ctx, course, (*uint)(nil)).Return(nil)
+		repo.On("GetByNameAndAddress", ctx, course.Name, course.Address).Return(&Course{ID: 7, Name: course.Name, Address: course.Address}, nil)
+		repo.On("UpdateLocation", mock.Anything, uint(7), course.Address, pinehurst).Return(nil).Run(func(mock.Arguments) { close(located) })
+
+		require.NoError(t, service.CreateCourse(ctx, course, nil))
+		select {
+		case <-located:
+		case <-time.After(5 * time.Second):
+			t.Fatal("course was never located")
+		}
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Address changes", func(t *testing.T) {
+		repo := new(MockCourseRepository)
+		service := NewCourseServiceWithGeocoding(repo, new(MockUserRepository), geocoding)
+		lat, lng := 35.0, -79.0
+		course := Course{ID: 7, Name: "Pinehurst No. 2", Address: pinehurst.Address, Latitude: &lat, Longitude: &lng}
+
+		located := make(chan struct{})
+		repo.On("GetByID", ctx, uint(7)).Return(&Course{ID: 7, Name: course.Name, Address: "Pinehurst, NC 28374", Latitude: &lat, Longitude: &lng}, nil)
+		repo.On("Update", ctx, course, (*uint)(nil)).Return(nil)
+		repo.On("UpdateLocation", mock.Anything, uint(7), course.Address, pinehurst).Return(nil).Run(func(mock.Arguments) { close(located) })
+
+		require.NoError(t, service.UpdateCourse(ctx, course, nil))
+		select {
+		case <-located:
+		case <-time.After(5 * time.Second):
+			t.Fatal("course was never located")
+		}
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Same address or new coordinates", func(t *testing.T) {
+		repo := new(MockCourseRepository)
+		service := NewCourseServiceWithGeocoding(repo, new(MockUserRepository), geocoding)
+		lat, lng, movedLat := 35.0, -79.0, 35.5
+		sameAddress := Course{ID: 7, Name: "Pinehurst No. 2", Address: pinehurst.Address}
+		placed := Course{ID: 8, Name: "Mid Pines", Address: pinehurst.Address, Latitude: &movedLat, Longitude: &lng}
+
+		repo.On("GetByID", ctx, uint(7)).Return(&Course{ID: 7, Address: " 1 carolina vista dr, Pinehurst, NC 28374"}, nil)
+		repo.On("GetByID", ctx, uint(8)).Return(&Course{ID: 8, Address: "Pinehurst, NC", Latitude: &lat, Longitude: &lng}, nil)
+		repo.On("Update", ctx, mock.Anything, (*uint)(nil)).Return(nil)
+
+		require.NoError(t, service.UpdateCourse(ctx, sameAddress, nil))
+		require.NoError(t, service.UpdateCourse(ctx, placed, nil))
+		repo.AssertNotCalled(t, "UpdateLocation", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
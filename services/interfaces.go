@@ -81,6 +81,10 @@ type CourseRepository interface {
 	// Utility methods
 	Exists(ctx context.Context, name, address string) (bool, error)
 	GetAvailableForReview(ctx context.Context, userID uint) ([]Course, error)
+
+	// UpdateLocation stores a geocoding result, but only while the course
+	// still has the address that was geocoded
+	UpdateLocation(ctx context.Context, id uint, address string, result GeocodeResult) error
 }
 
 type UserRepository interface {
@@ -242,6 +246,30 @@ type ServiceConfig struct {
 	DatabaseURL string
 	RedisURL    string
 	AuthConfig  AuthConfig
+	Geocoding   GeocodingConfig
+}
+
+// GeocodingProvider is an external geocoder such as Mapbox or Nominatim.
+// Both lookups return ErrGeocodeNotFound when nothing matches.
+type GeocodingProvider interface {
+	Name() string
+	Geocode(ctx context.Context, address string) (*GeocodeResult, error)
+	ReverseGeocode(ctx context.Context, lat, lng float64) (*GeocodeResult, error)
+}
+
+// GeocodingService geocodes through a provider, caching results and keeping
+// requests under the provider's rate limit
+type GeocodingService interface {
+	Geocode(ctx context.Context, address string) (*GeocodeResult, error)
+	ReverseGeocode(ctx context.Context, lat, lng float64) (*GeocodeResult, error)
+}
+
+// RoutingProvider estimates driving between points, such as OSRM or a
+// straight-line estimate. Matrix returns ErrNoRoute when some pair of points
+// isn't connected by road.
+type RoutingProvider interface {
+	Name() string
+	Matrix(ctx context.Context, points []RoutePoint) (*TravelMatrix, error)
 }
 
 // Service container interface
@@ -250,6 +278,7 @@ type ServiceContainer interface {
 	AuthService() AuthService
 	SessionService() SessionService
 	ReviewService() ReviewService
+	GeocodingService() GeocodingService // nil when no provider is configured
 	
 	// Repository access (for advanced use cases)
 	CourseRepository() CourseRepository
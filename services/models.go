@@ -46,7 +46,7 @@ type CourseRankingNewDB struct {
 	ID                 uint   `gorm:"primaryKey;autoIncrement" json:"id"`
 	CourseID           uint   `gorm:"not null;uniqueIndex;constraint:OnDelete:CASCADE" json:"course_id"`
 	Price              string `gorm:"size:10" json:"price"`
-	HandicapDifficulty int    `gorm:"check:handicap_difficulty BETWEEN 1 AND 10" json:"handicap_difficulty"`
+	HandicapDifficulty int    `gorm:"check:handicap_difficulty BETWEEN 1 AND 20" json:"handicap_difficulty"`
 	HazardDifficulty   int    `gorm:"check:hazard_difficulty BETWEEN 1 AND 10" json:"hazard_difficulty"`
 	Merch              string `gorm:"size:1;check:merch IN ('','S','A','B','C','D','F')" json:"merch"`
 	Condition          string `gorm:"size:1;check:condition IN ('','S','A','B','C','D','F')" json:"condition"`
@@ -140,6 +140,20 @@ func (cdb *CourseNewDB) ToCourse() Course {
 	return course
 }
 
+// SetAddressComponents fills City, State and ZipCode from parsed or geocoded
+// address parts, skipping parts that don't fit their columns
+func (cdb *CourseNewDB) SetAddressComponents(components AddressComponents) {
+	if len(components.City) <= 50 {
+		cdb.City = components.City
+	}
+	if len(components.State) == 2 {
+		cdb.State = components.State
+	}
+	if len(components.PostalCode) <= 10 {
+		cdb.ZipCode = components.PostalCode
+	}
+}
+
 // FromCourse converts Course model to CourseNewDB for database storage
 func (cdb *CourseNewDB) FromCourse(course Course) {
 	cdb.ID = course.ID
@@ -150,6 +164,7 @@ func (cdb *CourseNewDB) FromCourse(course Course) {
 	cdb.Review = course.Review
 	cdb.Latitude = course.Latitude
 	cdb.Longitude = course.Longitude
+	cdb.SetAddressComponents(ParseAddress(course.Address))
 
 	// Generate hash for uniqueness
 	cdb.Hash = course.Name + "|" + course.Address
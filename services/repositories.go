@@ -185,6 +185,7 @@ func (r *courseRepository) Update(ctx context.Context, course Course, updatedBy
 
 	updates := map[string]interface{}{
 		"course_data": string(courseData),
+		"address":     course.Address,
 		"updated_by":  updatedBy,
 		"latitude":    course.Latitude,
 		"longitude":   course.Longitude,
@@ -201,6 +202,15 @@ func (r *courseRepository) Update(ctx context.Context, course Course, updatedBy
 	return nil
 }
 
+func (r *courseRepository) UpdateLocation(ctx context.Context, id uint, address string, result GeocodeResult) error {
+	return r.db.WithContext(ctx).Model(&CourseDB{}).
+		Where("id = ? AND address = ?", id, address).
+		Updates(map[string]interface{}{
+			"latitude":  result.Latitude,
+			"longitude": result.Longitude,
+		}).Error
+}
+
 func (r *courseRepository) Delete(ctx context.Context, id uint) error {
 	result := r.db.WithContext(ctx).Delete(&CourseDB{}, id)
 	if result.Error != nil {
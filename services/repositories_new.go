@@ -135,10 +135,16 @@ func (r *courseRepositoryNew) GetAll(ctx context.Context) ([]Course, error) {
 
 func (r *courseRepositoryNew) Update(ctx context.Context, course Course, updatedBy *uint) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var parsed CourseNewDB
+		parsed.SetAddressComponents(ParseAddress(course.Address))
+
 		// Update basic course information
 		updates := map[string]interface{}{
 			"name":           course.Name,
 			"address":        course.Address,
+			"city":           parsed.City,
+			"state":          parsed.State,
+			"zip_code":       parsed.ZipCode,
 			"description":    course.Description,
 			"overall_rating": course.OverallRating,
 			"review":         course.Review,
@@ -381,6 +387,30 @@ func (r *courseRepositoryNew) Search(ctx context.Context, name, address string)
 	return courses, nil
 }
 
+func (r *courseRepositoryNew) UpdateLocation(ctx context.Context, id uint, address string, result GeocodeResult) error {
+	var located CourseNewDB
+	located.SetAddressComponents(result.Components)
+
+	updates := map[string]interface{}{
+		"latitude":  result.Latitude,
+		"longitude": result.Longitude,
+	}
+	// Geocoded parts are better than parsed ones, but only when present
+	if located.City != "" {
+		updates["city"] = located.City
+	}
+	if located.State != "" {
+		updates["state"] = located.State
+	}
+	if located.ZipCode != "" {
+		updates["zip_code"] = located.ZipCode
+	}
+
+	return r.db.WithContext(ctx).Model(&CourseNewDB{}).
+		Where("id = ? AND address = ?", id, address).
+		Updates(updates).Error
+}
+
 // Helper method to migrate existing table
 func (r *courseRepositoryNew) MigrateSchema(ctx context.Context) error {
 	log.Println("🔄 Running database schema migration...")
@@ -391,6 +421,7 @@ func (r *courseRepositoryNew) MigrateSchema(ctx context.Context) error {
 		&CourseHoleNewDB{},
 		&CourseRankingNewDB{},
 		&UserCourseScoreNewDB{},
+		&GeocodeCacheDB{},
 	); err != nil {
 		return fmt.Errorf("failed to migrate schema: %w", err)
 	}
@@ -90,6 +90,29 @@ func TestNewRepositoryBasic(t *testing.T) {
 		require.NoError(t, err, "GetAll should succeed")
 		assert.GreaterOrEqual(t, len(courses), 1, "Should have at least 1 course")
 	})
+
+	t.Run("AddressComponentsAndLocation", func(t *testing.T) {
+		course := Course{Name: "Geocoded Course", Address: "1 Carolina Vista Dr, Pinehurst, NC 28374"}
+		require.NoError(t, repo.Create(ctx, course, nil))
+
+		var stored CourseNewDB
+		require.NoError(t, testDB.DB.Where("name = ?", course.Name).First(&stored).Error)
+		assert.Equal(t, "Pinehurst", stored.City, "City should be parsed from the address")
+		assert.Equal(t, "NC", stored.State, "State should be parsed from the address")
+		assert.Equal(t, "28374", stored.ZipCode, "ZIP code should be parsed from the address")
+
+		result := GeocodeResult{Latitude: 35.1907, Longitude: -79.4704, Components: AddressComponents{City: "Village of Pinehurst"}}
+		require.NoError(t, repo.UpdateLocation(ctx, stored.ID, "An address it no longer has", result))
+		require.NoError(t, testDB.DB.First(&stored, stored.ID).Error)
+		assert.Nil(t, stored.Latitude, "Stale results should be dropped")
+
+		require.NoError(t, repo.UpdateLocation(ctx, stored.ID, course.Address, result))
+		require.NoError(t, testDB.DB.First(&stored, stored.ID).Error)
+		require.NotNil(t, stored.Latitude)
+		assert.InDelta(t, 35.1907, *stored.Latitude, 1e-6, "Latitude should be stored")
+		assert.Equal(t, "Village of Pinehurst", stored.City, "Geocoded city should replace the parsed one")
+		assert.Equal(t, "NC", stored.State, "Missing components should keep the parsed ones")
+	})
 }
 
 func TestNewRepositoryPerformance(t *testing.T) {
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoRoute is returned when a router can't connect every pair of points
+var ErrNoRoute = errors.New("no route between the points")
+
+const (
+	defaultOSRMURL = "https://router.project-osrm.org"
+	routerTimeout  = 15 * time.Second
+	// Straight-line estimates stretch the distance by straightLineRoadFactor,
+	// since roads are rarely straight, and drive it at straightLineSpeedKmh
+	straightLineRoadFactor = 1.3
+	straightLineSpeedKmh   = 80.0
+)
+
+// RoutePoint is a place to drive from or to
+type RoutePoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// TravelMatrix holds the driving distance and time from every point to every
+// other, indexed [from][to]
+type TravelMatrix struct {
+	Provider    string      // Name of the provider that answered
+	DistancesKm [][]float64 // Kilometers
+	Durations   [][]int     // Seconds
+}
+
+// RoutingConfig selects and configures a routing provider
+type RoutingConfig struct {
+	Provider string // osrm or straight_line; empty uses straight-line estimates
+	OSRMURL  string
+}
+
+// LoadRoutingConfig reads the routing configuration from the environment
+func LoadRoutingConfig() RoutingConfig {
+	return RoutingConfig{
+		Provider: strings.ToLower(os.Getenv("ROUTING_PROVIDER")),
+		OSRMURL:  os.Getenv("OSRM_URL"),
+	}
+}
+
+// NewRoutingProvider builds the configured provider
+func NewRoutingProvider(config RoutingConfig) (RoutingProvider, error) {
+	switch config.Provider {
+	case "", "straight_line":
+		return NewStraightLineRouter(), nil
+	case "osrm":
+		return NewOSRMRouter(config.OSRMURL), nil
+	default:
+		return nil, fmt.Errorf("unknown routing provider %q", config.Provider)
+	}
+}
+
+// NewRoutingProviderFromConfig builds the configured provider. Road routers
+// fall back to straight-line estimates when they fail, and an invalid
+// configuration uses straight-line estimates only, so it never returns nil.
+func NewRoutingProviderFromConfig(config RoutingConfig) RoutingProvider {
+	provider, err := NewRoutingProvider(config)
+	if err != nil {
+		log.Printf("[ROUTING] Using straight-line estimates: %v", err)
+		return NewStraightLineRouter()
+	}
+	if provider.Name() == "straight_line" {
+		log.Printf("[ROUTING] Using straight-line estimates; set ROUTING_PROVIDER=osrm for road distances")
+		return provider
+	}
+	log.Printf("[ROUTING] Routing with %s", provider.Name())
+	return NewFallbackRouter(provider, NewStraightLineRouter())
+}
+
+// fallbackRouter asks a second provider when the first fails
+type fallbackRouter struct {
+	primary  RoutingProvider
+	fallback RoutingProvider
+}
+
+// NewFallbackRouter creates a provider that uses fallback whenever primary
+// returns an error
+func NewFallbackRouter(primary, fallback RoutingProvider) RoutingProvider {
+	return &fallbackRouter{primary: primary, fallback: fallback}
+}
+
+func (r *fallbackRouter) Name() string {
+	return r.primary.Name()
+}
+
+func (r *fallbackRouter) Matrix(ctx context.Context, points []RoutePoint) (*TravelMatrix, error) {
+	matrix, err := r.primary.Matrix(ctx, points)
+	if err == nil {
+		return matrix, nil
+	}
+	log.Printf("[ROUTING] %s failed, falling back to %s: %v", r.primary.Name(), r.fallback.Name(), err)
+	return r.fallback.Matrix(ctx, points)
+}
+
+// straightLineRouter estimates driving from great-circle distances. It needs
+// no network and always answers.
+type straightLineRouter struct{}
+
+// NewStraightLineRouter creates a provider that estimates driving from
+// straight-line distances
+func NewStraightLineRouter() RoutingProvider {
+	return straightLineRouter{}
+}
+
+func (straightLineRouter) Name() string {
+	return "straight_line"
+}
+
+func (straightLineRouter) Matrix(ctx context.Context, points []RoutePoint) (*TravelMatrix, error) {
+	matrix := newTravelMatrix("straight_line", len(points))
+	for i, from := range points {
+		for j, to := range points {
+			if i == j {
+				continue
+			}
+			km := HaversineKm(from.Latitude, from.Longitude, to.Latitude, to.Longitude) * straightLineRoadFactor
+			matrix.DistancesKm[i][j] = km
+			matrix.Durations[i][j] = int(math.Round(km / straightLineSpeedKmh * 3600))
+		}
+	}
+	return matrix, nil
+}
+
+// osrmRouter uses the table service of an OSRM server
+type osrmRouter struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewOSRMRouter creates an OSRM provider. An empty baseURL uses the public
+// demo server, which is fine for trying things out but not for production.
+func NewOSRMRouter(baseURL string) RoutingProvider {
+	if baseURL == "" {
+		baseURL = defaultOSRMURL
+	}
+	return &osrmRouter{baseURL: strings.TrimSuffix(baseURL, "/"), client: &http.Client{Timeout: routerTimeout}}
+}
+
+type osrmTableResponse struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Distances [][]*float64 `json:"distances"` // Meters; null when unreachable
+	Durations [][]*float64 `json:"durations"` // Seconds; null when unreachable
+}
+
+func (r *osrmRouter) Name() string {
+	return "osrm"
+}
+
+func (r *osrmRouter) Matrix(ctx context.Context, points []RoutePoint) (*TravelMatrix, error) {
+	coordinates := make([]string, len(points))
+	for i, point := range points {
+		coordinates[i] = strconv.FormatFloat(point.Longitude, 'f', 6, 64) + "," + strconv.FormatFloat(point.Latitude, 'f', 6, 64)
+	}
+	endpoint := fmt.Sprintf("%s/table/v1/driving/%s?annotations=distance,duration", r.baseURL, strings.Join(coordinates, ";"))
+
+	var response osrmTableResponse
+	if err := getJSON(ctx, r.client, endpoint, "", &response); err != nil {
+		return nil, err
+	}
+	if response.Code != "Ok" {
+		return nil, fmt.Errorf("osrm returned %s: %s", response.Code, response.Message)
+	}
+	if len(response.Distances) != len(points) || len(response.Durations) != len(points) {
+		return nil, fmt.Errorf("osrm returned a %d-point table for %d points", len(response.Distances), len(points))
+	}
+
+	matrix := newTravelMatrix("osrm", len(points))
+	for i := range points {
+		if len(response.Distances[i]) != len(points) || len(response.Durations[i]) != len(points) {
+			return nil, fmt.Errorf("osrm returned a short table row")
+		}
+		for j := range points {
+			distance, duration := response.Distances[i][j], response.Durations[i][j]
+			if distance == nil || duration == nil {
+				return nil, ErrNoRoute
+			}
+			matrix.DistancesKm[i][j] = *distance / 1000
+			matrix.Durations[i][j] = int(math.Round(*duration))
+		}
+	}
+	return matrix, nil
+}
+
+func newTravelMatrix(provider string, size int) *TravelMatrix {
+	matrix := &TravelMatrix{
+		Provider:    provider,
+		DistancesKm: make([][]float64, size),
+		Durations:   make([][]int, size),
+	}
+	for i := 0; i < size; i++ {
+		matrix.DistancesKm[i] = make([]float64, size)
+		matrix.Durations[i] = make([]int, size)
+	}
+	return matrix
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sandhills = []RoutePoint{
+	{Latitude: 35.1907, Longitude: -79.4704}, // Pinehurst
+	{Latitude: 35.1618, Longitude: -79.4379}, // Mid Pines
+	{Latitude: 35.3580, Longitude: -79.1756}, // Tobacco Road
+}
+
+// failingRouter always fails
+type failingRouter struct{}
+
+func (failingRouter) Name() string { return "failing" }
+
+func (failingRouter) Matrix(ctx context.Context, points []RoutePoint) (*TravelMatrix, error) {
+	return nil, errors.New("router down")
+}
+
+func TestStraightLineRouter(t *testing.T) {
+	matrix, err := NewStraightLineRouter().Matrix(context.Background(), sandhills)
+	require.NoError(t, err)
+
+	assert.Equal(t, "straight_line", matrix.Provider)
+	require.Len(t, matrix.DistancesKm, 3)
+	assert.Zero(t, matrix.DistancesKm[1][1])
+	assert.Equal(t, matrix.DistancesKm[0][2], matrix.DistancesKm[2][0], "symmetric")
+
+	// Pinehurst to Tobacco Road is about 33 km as the crow flies
+	assert.InDelta(t, 33*straightLineRoadFactor, matrix.DistancesKm[0][2], 1)
+	assert.InDelta(t, matrix.DistancesKm[0][2]/straightLineSpeedKmh*3600, float64(matrix.Durations[0][2]), 1)
+}
+
+func TestOSRMRouter(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		assert.Equal(t, "distance,duration", r.URL.Query().Get("annotations"))
+		w.Write([]byte(`{"code":"Ok","distances":[[0,5230.5],[5410,0]],"durations":[[0,480.4],[502,0]]}`))
+	}))
+	defer server.Close()
+
+	matrix, err := NewOSRMRouter(server.URL+"/").Matrix(context.Background(), sandhills[:2])
+	require.NoError(t, err)
+	assert.Equal(t, "/table/v1/driving/-79.470400,35.190700;-79.437900,35.161800", path, "longitude first")
+	assert.Equal(t, "osrm", matrix.Provider)
+	assert.Equal(t, [][]float64{{0, 5.2305}, {5.41, 0}}, matrix.DistancesKm)
+	assert.Equal(t, [][]int{{0, 480}, {502, 0}}, matrix.Durations)
+
+	unreachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":"Ok","distances":[[0,null],[null,0]],"durations":[[0,null],[null,0]]}`))
+	}))
+	defer unreachable.Close()
+	_, err = NewOSRMRouter(unreachable.URL).Matrix(context.Background(), sandhills[:2])
+	assert.ErrorIs(t, err, ErrNoRoute)
+
+	refused := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":"InvalidQuery","message":"Query string malformed"}`))
+	}))
+	defer refused.Close()
+	_, err = NewOSRMRouter(refused.URL).Matrix(context.Background(), sandhills[:2])
+	assert.ErrorContains(t, err, "InvalidQuery")
+}
+
+func TestFallbackRouter(t *testing.T) {
+	router := NewFallbackRouter(failingRouter{}, NewStraightLineRouter())
+	assert.Equal(t, "failing", router.Name())
+
+	matrix, err := router.Matrix(context.Background(), sandhills)
+	require.NoError(t, err)
+	assert.Equal(t, "straight_line", matrix.Provider, "answers with the fallback")
+}
+
+func TestNewRoutingProvider(t *testing.T) {
+	provider, err := NewRoutingProvider(RoutingConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "straight_line", provider.Name())
+
+	provider, err = NewRoutingProvider(RoutingConfig{Provider: "osrm", OSRMURL: "http://osrm.internal:5000"})
+	require.NoError(t, err)
+	assert.Equal(t, "osrm", provider.Name())
+
+	_, err = NewRoutingProvider(RoutingConfig{Provider: "valhalla"})
+	assert.Error(t, err)
+
+	assert.Equal(t, "straight_line", NewRoutingProviderFromConfig(RoutingConfig{Provider: "valhalla"}).Name(), "bad configuration falls back")
+	_, wrapped := NewRoutingProviderFromConfig(RoutingConfig{Provider: "osrm"}).(*fallbackRouter)
+	assert.True(t, wrapped, "road routers fall back to straight lines")
+}
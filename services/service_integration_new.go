@@ -25,6 +25,7 @@ type relationalServiceContainer struct {
 	authService   AuthService
 	sessionService SessionService
 	reviewService  ReviewService
+	geocodingService GeocodingService
 	
 	// Mutex for thread-safe initialization
 	mu sync.RWMutex
@@ -43,8 +44,9 @@ func NewServiceContainerWithRelationalDB(db *gorm.DB, config ServiceConfig) Serv
 
 	// Create a custom service container that uses the new relational repository
 	return &relationalServiceContainer{
-		db:     db,
-		config: config,
+		db:               db,
+		config:           config,
+		geocodingService: NewGeocodingServiceFromConfig(db, config.Geocoding),
 	}
 }
 
@@ -66,11 +68,16 @@ func (c *relationalServiceContainer) CourseService() CourseService {
 		return c.courseService
 	}
 
-	c.courseService = NewCourseService(c.CourseRepository(), c.UserRepository())
+	c.courseService = NewCourseServiceWithGeocoding(c.CourseRepository(), c.UserRepository(), c.geocodingService)
 	log.Printf("✅ CourseService initialized with relational schema")
 	return c.courseService
 }
 
+// GeocodingService is built with the container, since course services need it
+func (c *relationalServiceContainer) GeocodingService() GeocodingService {
+	return c.geocodingService
+}
+
 func (c *relationalServiceContainer) AuthService() AuthService {
 	c.mu.RLock()
 	if c.authService != nil {
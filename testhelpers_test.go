@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupTestDatabase points the global DB at a fresh in-memory SQLite database
+// with the application schema, restoring the previous connection afterwards.
+func setupTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	previous := DB
+	DB = db
+	t.Cleanup(func() { DB = previous })
+
+	require.NoError(t, AutoMigrate())
+	return db
+}
+
+// seedUser saves a user named name whose Google ID is handle and whose email
+// is handle@example.com
+func seedUser(t *testing.T, db *gorm.DB, handle, name string) User {
+	t.Helper()
+
+	user := User{GoogleID: handle, Email: handle + "@example.com", Name: name}
+	require.NoError(t, db.Create(&user).Error)
+	return user
+}
+
+// seedCourseOwner saves a user whose display name, "Course Owner", differs
+// from their Google name, so tests can tell which one is shown
+func seedCourseOwner(t *testing.T, db *gorm.DB) User {
+	t.Helper()
+
+	displayName := "Course Owner"
+	owner := User{GoogleID: "owner", Email: "owner@example.com", Name: "Owner Real Name", DisplayName: &displayName}
+	require.NoError(t, db.Create(&owner).Error)
+	return owner
+}
+
+// seedCourse saves Pebble Creek, created by createdBy when it isn't nil
+func seedCourse(t *testing.T, db *gorm.DB, createdBy *uint) CourseDB {
+	t.Helper()
+
+	course := CourseDB{Name: "Pebble Creek", Address: "1 Fairway Dr", Hash: "pebble", CourseData: "{}", CreatedBy: createdBy}
+	require.NoError(t, db.Create(&course).Error)
+	return course
+}
+
+// seedReview saves a public, A-rated review of the course by the user
+func seedReview(t *testing.T, db *gorm.DB, courseID, userID uint) CourseReview {
+	t.Helper()
+
+	rating := "A"
+	text := "Fast greens and a great back nine"
+	review := CourseReview{CourseID: courseID, UserID: userID, OverallRating: &rating, ReviewText: &text}
+	require.NoError(t, db.Create(&review).Error)
+	return review
+}
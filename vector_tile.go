@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Mapbox Vector Tile encoding, following version 2.1 of the specification:
+// https://github.com/mapbox/vector-tile-spec/tree/master/2.1. Only point
+// features are written, which is all the course map needs.
+
+const (
+	protoVarint          = 0
+	protoFixed64         = 1
+	protoLengthDelimited = 2
+
+	vectorTileVersion = 2
+	// vectorTilePoint is the POINT geometry type
+	vectorTilePoint = 1
+	// vectorTileMoveTo is the MoveTo geometry command
+	vectorTileMoveTo = 1
+)
+
+// vectorTileProperty is one feature attribute. Values are strings, bools,
+// float64s, int64s or uint64s.
+type vectorTileProperty struct {
+	Key   string
+	Value interface{}
+}
+
+// vectorTileLayer collects the features of one layer. Keys and values are
+// shared by every feature in the layer, so each is stored once.
+type vectorTileLayer struct {
+	name       string
+	extent     int
+	keys       []string
+	keyIndex   map[string]uint64
+	values     []interface{}
+	valueIndex map[interface{}]uint64
+	features   [][]byte
+}
+
+func newVectorTileLayer(name string, extent int) *vectorTileLayer {
+	return &vectorTileLayer{
+		name:       name,
+		extent:     extent,
+		keyIndex:   make(map[string]uint64),
+		valueIndex: make(map[interface{}]uint64),
+	}
+}
+
+// addPoint adds a point feature at tile coordinates x and y, measured from
+// the tile's top left corner. An id of zero leaves the feature without one.
+func (l *vectorTileLayer) addPoint(id uint64, x, y int, properties []vectorTileProperty) {
+	tags := make([]uint64, 0, 2*len(properties))
+	for _, property := range properties {
+		tags = append(tags, l.key(property.Key), l.value(property.Value))
+	}
+
+	var feature []byte
+	if id != 0 {
+		feature = appendProtoVarint(feature, 1, id)
+	}
+	feature = appendProtoPacked(feature, 2, tags)
+	feature = appendProtoVarint(feature, 3, vectorTilePoint)
+	feature = appendProtoPacked(feature, 4, []uint64{vectorTileMoveTo | 1<<3, zigzag(x), zigzag(y)})
+	l.features = append(l.features, feature)
+}
+
+func (l *vectorTileLayer) key(key string) uint64 {
+	index, ok := l.keyIndex[key]
+	if !ok {
+		index = uint64(len(l.keys))
+		l.keyIndex[key] = index
+		l.keys = append(l.keys, key)
+	}
+	return index
+}
+
+func (l *vectorTileLayer) value(value interface{}) uint64 {
+	switch v := value.(type) {
+	case int:
+		value = int64(v)
+	case uint:
+		value = uint64(v)
+	case float32:
+		value = float64(v)
+	case string, bool, float64, int64, uint64:
+	default:
+		value = fmt.Sprint(v)
+	}
+
+	index, ok := l.valueIndex[value]
+	if !ok {
+		index = uint64(len(l.values))
+		l.valueIndex[value] = index
+		l.values = append(l.values, value)
+	}
+	return index
+}
+
+func (l *vectorTileLayer) encode() []byte {
+	var layer []byte
+	layer = appendProtoVarint(layer, 15, vectorTileVersion)
+	layer = appendProtoBytes(layer, 1, []byte(l.name))
+	for _, feature := range l.features {
+		layer = appendProtoBytes(layer, 2, feature)
+	}
+	for _, key := range l.keys {
+		layer = appendProtoBytes(layer, 3, []byte(key))
+	}
+	for _, value := range l.values {
+		layer = appendProtoBytes(layer, 4, encodeVectorTileValue(value))
+	}
+	return appendProtoVarint(layer, 5, uint64(l.extent))
+}
+
+func encodeVectorTileValue(value interface{}) []byte {
+	switch v := value.(type) {
+	case string:
+		return appendProtoBytes(nil, 1, []byte(v))
+	case float64:
+		return appendProtoFixed64(nil, 3, math.Float64bits(v))
+	case int64:
+		return appendProtoVarint(nil, 6, zigzag64(v))
+	case uint64:
+		return appendProtoVarint(nil, 5, v)
+	case bool:
+		var b uint64
+		if v {
+			b = 1
+		}
+		return appendProtoVarint(nil, 7, b)
+	}
+	return nil
+}
+
+// encodeVectorTile writes a tile holding the layers that have features. A
+// tile with no features encodes to nothing, which is a valid empty tile.
+func encodeVectorTile(layers ...*vectorTileLayer) []byte {
+	var tile []byte
+	for _, layer := range layers {
+		if len(layer.features) > 0 {
+			tile = appendProtoBytes(tile, 3, layer.encode())
+		}
+	}
+	return tile
+}
+
+func appendProtoTag(buf []byte, field int, wireType int) []byte {
+	return appendVarint(buf, uint64(field)<<3|uint64(wireType))
+}
+
+func appendProtoVarint(buf []byte, field int, value uint64) []byte {
+	return appendVarint(appendProtoTag(buf, field, protoVarint), value)
+}
+
+func appendProtoFixed64(buf []byte, field int, value uint64) []byte {
+	buf = appendProtoTag(buf, field, protoFixed64)
+	for i := 0; i < 8; i++ {
+		buf = append(buf, byte(value>>(8*i)))
+	}
+	return buf
+}
+
+func appendProtoBytes(buf []byte, field int, value []byte) []byte {
+	buf = appendVarint(appendProtoTag(buf, field, protoLengthDelimited), uint64(len(value)))
+	return append(buf, value...)
+}
+
+func appendProtoPacked(buf []byte, field int, values []uint64) []byte {
+	var packed []byte
+	for _, value := range values {
+		packed = appendVarint(packed, value)
+	}
+	return appendProtoBytes(buf, field, packed)
+}
+
+func appendVarint(buf []byte, value uint64) []byte {
+	for value >= 0x80 {
+		buf = append(buf, byte(value)|0x80)
+		value >>= 7
+	}
+	return append(buf, byte(value))
+}
+
+// zigzag maps signed geometry coordinates onto unsigned ones, keeping small
+// negatives small
+func zigzag(n int) uint64 {
+	return zigzag64(int64(n))
+}
+
+func zigzag64(n int64) uint64 {
+	return uint64((n << 1) ^ (n >> 63))
+}